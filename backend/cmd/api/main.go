@@ -1,14 +1,18 @@
 package main
 
 import (
+	"flag"
 	stdlog "log"
+	"net/http"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/config"
 	"github.com/cleberrangel/clickup-excel-api/internal/database"
+	"github.com/cleberrangel/clickup-excel-api/internal/filestore"
 	"github.com/cleberrangel/clickup-excel-api/internal/handler"
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
 	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
@@ -20,9 +24,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const Version = "1.4.3"
+// Informações de build; GitCommit e BuildTime são injetados no build com
+// -ldflags "-X main.GitCommit=... -X main.BuildTime=..."
+var (
+	Version   = "1.4.3"
+	GitCommit = ""
+	BuildTime = ""
+)
 
 func main() {
+	migrateStatus := flag.Bool("migrate-status", false, "mostra o estado das migrações e sai, sem executá-las")
+	flag.Parse()
+
 	// Carrega configurações
 	cfg, err := config.Load()
 	if err != nil {
@@ -31,6 +44,7 @@ func main() {
 
 	// Inicializa logger estruturado
 	logger.Init(cfg.LogLevel, cfg.LogJSON)
+	logger.SetSampleEvery(cfg.LogSampleEvery)
 	log := logger.Global()
 	
 	// Inicializa métricas
@@ -38,6 +52,7 @@ func main() {
 	
 	log.Info().
 		Str("version", Version).
+		Str("git_commit", GitCommit).
 		Str("port", cfg.Port).
 		Str("log_level", cfg.LogLevel).
 		Bool("log_json", cfg.LogJSON).
@@ -64,8 +79,23 @@ func main() {
 	}
 	defer database.Close(db)
 
-	// Executa migrações
 	migrator := migration.NewMigrator(db)
+	if *migrateStatus {
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Erro ao obter estado das migrações")
+		}
+		if err := migration.WriteStatus(os.Stdout, statuses); err != nil {
+			log.Fatal().Err(err).Msg("Erro ao exibir estado das migrações")
+		}
+		return
+	}
+
+	// Executa migrações (registrando antes as pendentes)
+	if _, err := migrator.Plan(); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao verificar migrações pendentes")
+		os.Exit(1)
+	}
 	if err := migrator.Run(); err != nil {
 		log.Fatal().Err(err).Msg("Erro ao executar migrações")
 		os.Exit(1)
@@ -74,49 +104,173 @@ func main() {
 	// Inicializa repositórios
 	metadataRepo := repository.NewMetadataRepository(db)
 	queueRepo := repository.NewQueueRepository(db)
+	queueRepo.SetPriorityAging(time.Duration(cfg.JobPriorityAgingMinutes) * time.Minute)
 	configRepo := repository.NewConfigRepository(db)
 	userRepo := repository.NewUserRepository(db)
 
 	// Inicializa WebSocket hub
 	wsHub := websocket.NewHub()
+	wsHub.SetProgressInterval(time.Duration(cfg.WSProgressIntervalMs) * time.Millisecond)
 	go wsHub.Run() // Start hub in background
 
 	// Inicializa dependências
-	clickupClient := client.NewClient(cfg.TokenClickUp)
+	clientOptions := client.ClientOptions{
+		Timeout:               time.Duration(cfg.ClickUpTimeoutSeconds) * time.Second,
+		MaxIdleConns:          cfg.ClickUpMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.ClickUpMaxIdleConnsPerHost,
+		MaxConcurrentRequests: cfg.ClickUpMaxConcurrentRequests,
+		Retry: client.RetryPolicy{
+			MaxAttempts:    cfg.ClickUpRetryMaxAttempts,
+			InitialBackoff: time.Duration(cfg.ClickUpRetryInitialBackoffMs) * time.Millisecond,
+			Multiplier:     cfg.ClickUpRetryMultiplier,
+			MaxBackoff:     time.Duration(cfg.ClickUpRetryMaxBackoffMs) * time.Millisecond,
+			Jitter:         float64(cfg.ClickUpRetryJitterPercent) / 100,
+		},
+		Debug: cfg.ClickUpDebug,
+	}
+	clickupClient := client.NewClientWithOptions(cfg.TokenClickUp, clientOptions)
 	reportService := service.NewReportService(clickupClient)
 	webhookService := service.NewWebhookService()
+	webhookService.SetAllowPrivateNetworks(cfg.WebhookAllowPrivateNetworks)
 	authService := service.NewAuthService(userRepo)
-	uploadService := service.NewUploadService("")
+	csrfStrategy, ok := middleware.ParseCSRFStrategy(cfg.CSRFStrategy)
+	if !ok {
+		log.Fatal().Str("csrf_strategy", cfg.CSRFStrategy).Msg("CSRF_STRATEGY inválido (use header ou double_submit)")
+	}
+	authService.SetCSRFConfig(csrfStrategy, time.Duration(cfg.CSRFTokenTTLMinutes)*time.Minute)
+	sameSite, ok := middleware.ParseSameSite(cfg.SessionCookieSameSite)
+	if !ok {
+		log.Fatal().Str("same_site", cfg.SessionCookieSameSite).Msg("SESSION_COOKIE_SAMESITE inválido (use lax, strict ou none)")
+	}
+	if sameSite == http.SameSiteNoneMode && !cfg.SessionCookieSecure {
+		log.Fatal().Msg("SESSION_COOKIE_SAMESITE=none exige SESSION_COOKIE_SECURE=true")
+	}
+	authService.SetCookieAttributes(middleware.CookieAttributes{
+		Domain:   cfg.SessionCookieDomain,
+		Path:     cfg.SessionCookiePath,
+		Secure:   cfg.SessionCookieSecure,
+		SameSite: sameSite,
+	})
+	uploadService := service.NewUploadService("", int64(cfg.MaxUploadSizeMB)<<20)
+	uploadService.SetMaxRows(cfg.MaxUploadRows)
+	uploadService.SetTempFileTTL(time.Duration(cfg.TempFileTTLMinutes) * time.Minute)
+	uploadService.SetInUseCheck(queueRepo.IsFileInUse)
+	if cfg.EncryptUploads {
+		uploadService.SetEncryptionKey(cfg.EncryptionKey)
+	}
+	switch cfg.StorageBackend {
+	case "local":
+	case "s3":
+		s3Store, err := filestore.NewS3(filestore.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			Prefix:          cfg.S3Prefix,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Configuração do S3 inválida")
+		}
+		uploadService.SetFileStore(s3Store)
+	default:
+		log.Fatal().Str("storage_backend", cfg.StorageBackend).Msg("STORAGE_BACKEND inválido (use local ou s3)")
+	}
 	mappingService := service.NewMappingService(metadataRepo)
+	mappingService.SetSuggestMinSimilarity(cfg.MappingSuggestMinSimilarity)
+	mappingService.SetDefaultLocale(cfg.DefaultLocale)
+	mappingService.SetMappingStore(repository.NewMappingRepository(db)) // mapeamentos salvos sobrevivem a reinícios
 	
 	// Inicializa QueueService
 	queueService := service.NewQueueService(queueRepo, wsHub)
+	wsHub.SetJobSnapshots(queueService) // reconexões recebem o progresso salvo dos jobs
+	// Modo manutenção: bloqueia escritas e pausa a fila (alternado por admins em /api/web/admin/maintenance)
+	// O estado fica no banco, compartilhado pelas instâncias
+	maintenance := middleware.NewMaintenance(cfg.MaintenanceMode, cfg.MaintenanceMessage)
+	if err := maintenance.SetStore(repository.NewMaintenanceRepository(db)); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao ativar modo manutenção")
+	}
+	queueService.SetPauseCheck(maintenance.Enabled)
+	if maintenance.Enabled() {
+		log.Warn().Msg("Iniciando em modo manutenção: escritas bloqueadas e fila pausada")
+	}
+	queueService.SetMaxConcurrentJobs(cfg.MaxConcurrentJobs)
+	scheduling, ok := service.ParseSchedulingStrategy(cfg.QueueScheduling)
+	if !ok {
+		log.Fatal().Str("queue_scheduling", cfg.QueueScheduling).Msg("QUEUE_SCHEDULING inválido (use priority, fifo ou fair)")
+	}
+	queueService.SetSchedulingStrategy(scheduling)
 	
 	// Inicializa TaskUpdateService e conecta ao QueueService
 	taskUpdateService := service.NewTaskUpdateService(uploadService, metadataRepo, configRepo, queueRepo, wsHub)
+	taskUpdateService.SetDefaultTimezone(cfg.DefaultTimezone)
+	taskUpdateService.SetDefaultLocale(cfg.DefaultLocale)
+	taskUpdateService.SetClientOptions(clientOptions)
 	queueService.SetJobProcessor(taskUpdateService.ProcessJob)
 	
 	// Inicializa HistoryService
 	historyService := service.NewHistoryService(queueRepo)
+	historyService.SetMaxPerUser(cfg.HistoryMaxPerUser)
+	taskUpdateService.SetHistoryRecorder(historyService)
+	retentionScheduler := service.NewRetentionScheduler(queueRepo, service.RetentionPolicy{
+		Interval:          time.Duration(cfg.RetentionIntervalMinutes) * time.Minute,
+		HistoryMaxPerUser: cfg.HistoryMaxPerUser,
+		FailedJobMaxAge:   time.Duration(cfg.FailedJobRetentionHours) * time.Hour,
+	})
 	
 	// Inicializa MetadataService
 	metadataService := service.NewMetadataService(metadataRepo, configRepo, cfg.EncryptionKey)
+	metadataService.SetPreviousEncryptionKeys(cfg.EncryptionKeysPrevious)
+	metadataService.SetClientOptions(clientOptions)
+	metadataService.SetSyncConcurrency(cfg.MetadataSyncConcurrency)
+	taskUpdateService.SetOptionResolver(metadataService)
+	taskUpdateService.SetListFieldProvider(metadataRepo)
+	taskUpdateService.SetTokenResolver(metadataService)
+	reportService.SetListLookup(metadataService)
+	metadataService.AddSyncListener(service.NewSyncWebhookNotifier(configRepo, webhookService).Listener())
 	
 	// Inicializa handlers
 	reportHandler := handler.NewReportHandler(reportService, webhookService)
 	authHandler := handler.NewAuthHandler(authService)
 	wsHandler := handler.NewWebSocketHandler(wsHub)
+	wsTickets := websocket.NewTicketStore(websocket.DefaultTicketTTL)
+	wsHandler.SetTicketStore(wsTickets)
 	uploadHandler := handler.NewUploadHandler(uploadService)
+	uploadHandler.SetProgressNotifier(wsHub)
 	mappingHandler := handler.NewMappingHandler(mappingService, uploadService)
 	queueHandler := handler.NewQueueHandler(queueService, uploadService, mappingService)
+	queueHandler.SetETAProvider(taskUpdateService)
+	queueHandler.SetProgressEvents(wsHub)
+	queueHandler.SetJobTemplates(service.NewJobTemplateService(repository.NewJobTemplateRepository(db), mappingService, uploadService))
 	historyHandler := handler.NewHistoryHandler(historyService)
+	backupHandler := handler.NewBackupHandler(service.NewBackupService(queueRepo))
 	metadataHandler := handler.NewMetadataHandler(metadataService, wsHub)
 	configHandler := handler.NewConfigHandler(configRepo)
+	configHandler.SetTokenSaver(metadataService)
+	configHandler.SetWebhookValidator(webhookService)
 	webReportHandler := handler.NewWebReportHandler(metadataService)
+	debugHandler := handler.NewDebugHandler(metadataService, cfg.DefaultTimezone)
+	debugHandler.SetDefaultLocale(cfg.DefaultLocale)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenance)
+	maintenanceHandler.SetAnnouncer(wsHub)
+	webReportHandler.SetClientOptions(clientOptions)
+	webReportHandler.SetProgressNotifier(wsHub)
+	reportRegistry := service.NewReportRegistry()
+	webReportHandler.SetReportRegistry(reportRegistry)
+	wsHub.SetReportCanceler(reportRegistry)
 	healthHandler := handler.NewHealthHandlerWithWebSocket(db, wsHub, Version)
+	healthHandler.SetBuildInfo(GitCommit, BuildTime)
+	healthHandler.SetClickUpPinger(clickupClient)
+	healthHandler.MarkMigrationsComplete() // migrator.Run encerra o processo em caso de falha
+	healthHandler.SetQueueProcessor(queueService, handler.DefaultProcessorStaleAfter)
+	healthHandler.SetQueueStats(queueRepo, cfg.QueueMaxPending, time.Duration(cfg.QueueMaxWaitMinutes)*time.Minute)
+	healthHandler.SetDBPoolWaitThreshold(cfg.DBPoolMaxWaits)
+	metrics.Get().StartDBPoolSampler(db)
 
 	// Inicia limpeza de sessões expiradas
 	authService.StartSessionCleanup()
+	uploadService.StartTempFileCleanup()
+	retentionScheduler.Start()
 	
 	// Inicia processador de jobs em background
 	queueService.Start()
@@ -134,19 +288,35 @@ func main() {
 
 	// Inicializa router
 	r := gin.New()
+	r.MaxMultipartMemory = int64(cfg.MaxUploadSizeMB) << 20
+	// Corpos gzip: descompressão das requisições e compressão das respostas
+	r.Use(middleware.Gzip(middleware.GzipConfig{
+		Compress:             cfg.GzipEnabled,
+		MinSize:              cfg.GzipMinSizeBytes,
+		MaxDecompressedBytes: int64(cfg.MaxUploadSizeMB)<<20 + 1<<20,
+	}))
 	r.Use(middleware.RequestID())        // Request ID + logging estruturado
 	r.Use(middleware.MetricsMiddleware()) // Metrics collection
 	r.Use(middleware.AuditMiddleware())   // Audit logging for sensitive operations
 	r.Use(gin.Recovery())
+	// CORS das rotas usadas pela interface web (sem origens configuradas, só a mesma origem)
+	r.Use(middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		MaxAge:           time.Duration(cfg.CORSMaxAgeSeconds) * time.Second,
+	}, "/api/web", "/api/auth"))
 
 	// Health check endpoints (públicos)
 	r.GET("/health", healthHandler.DetailedHealthCheck)
 	r.GET("/health/live", healthHandler.LivenessCheck)
 	r.GET("/health/ready", healthHandler.ReadinessCheck)
+	r.GET("/version", healthHandler.GetVersion)
 	
 	// Metrics endpoints (públicos)
 	r.GET("/metrics", healthHandler.GetMetrics)
 	r.GET("/metrics/summary", healthHandler.GetMetricsSummary)
+	r.GET("/metrics/prometheus", healthHandler.GetPrometheusMetrics)
 	r.GET("/metrics/endpoints", healthHandler.GetEndpointMetrics)
 
 	// Debug memory endpoint (público)
@@ -180,8 +350,47 @@ func main() {
 		c.JSON(200, stats)
 	})
 
+	// Idempotency-Key para criação de jobs e relatórios (evita duplicados em retries)
+	idempotency := middleware.NewIdempotencyMiddleware(middleware.IdempotencyConfig{
+		TTL:             time.Duration(cfg.IdempotencyTTLMinutes) * time.Minute,
+		MaxRequestBytes: int64(cfg.IdempotencyMaxRequestKB) << 10,
+	})
+
+	// Limites por rota: autenticação com corpo pequeno e prazo curto, upload com prazo
+	// longo (o tamanho do arquivo é limitado pelo handler de upload)
+	authLimits := middleware.Limit(middleware.RequestLimits{
+		MaxBodyBytes: int64(cfg.AuthMaxBodyKB) << 10,
+		Timeout:      time.Duration(cfg.AuthTimeoutSeconds) * time.Second,
+	})
+	uploadLimits := middleware.Limit(middleware.RequestLimits{
+		Timeout: time.Duration(cfg.UploadTimeoutSeconds) * time.Second,
+	})
+
+	// Rotas que alteram dados respondem 503 em modo manutenção; ficam liberados os POSTs que
+	// só consultam ou cancelam e o próprio desligamento da manutenção
+	writes := maintenance.BlockWrites(
+		"/api/web/ws/ticket",
+		"/api/web/ws/broadcast",
+		"/api/web/ws/test",
+		"/api/web/upload/cleanup",
+		"/api/web/mapping/validate",
+		"/api/web/mapping/validate/batch",
+		"/api/web/mapping/suggest",
+		"/api/web/mapping/matches",
+		"/api/web/reports/cancel",
+		"/api/web/debug/transform",
+		"/api/web/admin/maintenance",
+	)
+
+	// Limite de requisições por usuário (sessão ou chave de API); health e métricas ficam de fora
+	userRateLimit := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		RequestsPerMinute: cfg.UserRateLimitPerMinute,
+		Burst:             cfg.UserRateLimitBurst,
+	}).Handle()
+
 	// Rotas de autenticação (públicas)
 	auth := r.Group("/api/auth")
+	auth.Use(authLimits)
 	{
 		auth.POST("/login", authHandler.Login)
 		auth.POST("/logout", authHandler.Logout)
@@ -190,27 +399,38 @@ func main() {
 	// Auth status endpoint (requires auth but not CSRF for initial check)
 	webAuth := r.Group("/api/web/auth")
 	webAuth.Use(authService.GetAuthMiddleware().RequireAuth())
+	webAuth.Use(userRateLimit)
 	{
 		webAuth.GET("/status", authHandler.GetCurrentUser)
 	}
 
+	// Conexão WebSocket: autenticada pela sessão ou por um ticket de POST /api/web/ws/ticket
+	r.GET("/api/web/ws", websocket.AuthMiddleware(authService.GetAuthMiddleware(), wsTickets), wsHandler.HandleConnection)
+
 	// Grupo de rotas protegidas por autenticação básica
 	web := r.Group("/api/web")
 	web.Use(authService.GetAuthMiddleware().RequireAuth())
+	web.Use(userRateLimit)
 	web.Use(authService.GetCSRFMiddleware().RequireCSRF())
+	web.Use(writes)
 	{
 		web.GET("/user", authHandler.GetCurrentUser)
 		web.POST("/user/password", authHandler.UpdatePassword)
 		
 		// WebSocket routes
-		web.GET("/ws", websocket.AuthMiddleware(authService.GetAuthMiddleware()), wsHandler.HandleConnection)
+		web.POST("/ws/ticket", wsHandler.IssueTicket)
+		web.POST("/ws/broadcast", middleware.RequireRole(middleware.RoleAdmin), wsHandler.BroadcastAnnouncement)
 		web.GET("/ws/stats", wsHandler.GetConnectionStats)
 		web.GET("/ws/connections", wsHandler.GetUserConnections)
 		web.POST("/ws/test", wsHandler.SendTestMessage)
 		
 		// Upload routes
-		web.POST("/upload", uploadHandler.UploadFile)
+		web.POST("/upload", uploadLimits, uploadHandler.UploadFile)
 		web.POST("/upload/cleanup", uploadHandler.DeleteTempFile)
+		web.POST("/upload/init", uploadHandler.InitChunkedUpload)
+		web.GET("/upload/:id", uploadHandler.GetChunkedUpload)
+		web.PUT("/upload/:id/chunk", uploadLimits, uploadHandler.UploadChunk)
+		web.POST("/upload/:id/complete", uploadLimits, uploadHandler.CompleteChunkedUpload)
 		
 		// Mapping routes
 		web.POST("/mapping", mappingHandler.SaveMapping)
@@ -218,27 +438,56 @@ func main() {
 		web.GET("/mapping/:id", mappingHandler.GetMapping)
 		web.DELETE("/mapping/:id", mappingHandler.DeleteMapping)
 		web.POST("/mapping/validate", mappingHandler.ValidateMapping)
+		web.POST("/mapping/validate/batch", mappingHandler.ValidateMappings)
+		web.POST("/mapping/suggest", mappingHandler.SuggestMappings)
+		web.POST("/mapping/matches", mappingHandler.FindMatchingMappings)
 		
 		// Job queue routes
-		web.POST("/jobs", queueHandler.CreateJob)
+		web.POST("/jobs", idempotency.Handle(), queueHandler.CreateJob)
 		web.GET("/jobs", queueHandler.ListJobs)
 		web.GET("/jobs/:id", queueHandler.GetJob)
+		web.GET("/jobs/:id/events", queueHandler.JobEvents) // SSE: alternativa ao WebSocket
+		
+		// Job template routes
+		web.POST("/job-templates", queueHandler.CreateJobTemplate)
+		web.GET("/job-templates", queueHandler.ListJobTemplates)
+		web.GET("/job-templates/:id", queueHandler.GetJobTemplate)
+		web.PUT("/job-templates/:id", queueHandler.UpdateJobTemplate)
+		web.DELETE("/job-templates/:id", queueHandler.DeleteJobTemplate)
+		web.POST("/job-templates/:id/run", idempotency.Handle(), queueHandler.RunJobTemplate)
 		
 		// History routes
 		web.GET("/history", historyHandler.ListHistory)
+		web.GET("/history/export", historyHandler.ExportHistory)
 		web.GET("/history/:id", historyHandler.GetHistory)
 		web.DELETE("/history", historyHandler.DeleteAllHistory)
 		
 		// Metadata routes
 		web.POST("/metadata/sync", metadataHandler.SyncMetadata)
 		web.GET("/metadata/hierarchy", metadataHandler.GetHierarchy)
+		web.GET("/metadata/fields/:id/options", metadataHandler.GetFieldOptions)
 		
 		// Config routes
 		web.GET("/config", configHandler.GetConfig)
 		web.POST("/config", configHandler.SaveConfig)
+		web.GET("/config/tokens", configHandler.ListTokens)
+		web.DELETE("/config/tokens/:label", configHandler.DeleteToken)
 		
 		// Web report routes
 		web.POST("/reports", webReportHandler.GenerateReport)
+		web.POST("/reports/cancel", webReportHandler.CancelReport)
+		
+		// Diagnostics
+		web.POST("/debug/transform", debugHandler.PreviewTransform)
+		web.GET("/maintenance", maintenanceHandler.GetMaintenance)
+		
+		// Admin routes (role admin)
+		admin := web.Group("/admin", middleware.RequireRole(middleware.RoleAdmin))
+		admin.POST("/users", authHandler.CreateUser)
+		admin.POST("/users/import", authHandler.ImportUsers)
+		admin.GET("/export", backupHandler.Export)
+		admin.POST("/import", backupHandler.Import)
+		admin.POST("/maintenance", maintenanceHandler.SetMaintenance)
 	}
 
 	// Grupo de rotas protegidas por Bearer token (API externa)
@@ -246,10 +495,13 @@ func main() {
 	api.Use(middleware.BearerAuth(middleware.AuthConfig{
 		TokenAPI: cfg.TokenAPI,
 	}))
+	api.Use(userRateLimit)
+	api.Use(writes)
 	{
-		api.POST("/reports", reportHandler.GenerateReport)
-		// Endpoint para criar usuários (admin)
-		api.POST("/users", authHandler.CreateUser)
+		api.POST("/reports", idempotency.Handle(), reportHandler.GenerateReport)
+		// Endpoint para criar usuários (admin; o TOKEN_API tem papel de admin)
+		api.POST("/users", middleware.RequireRole(middleware.RoleAdmin), authHandler.CreateUser)
+		api.POST("/users/import", middleware.RequireRole(middleware.RoleAdmin), authHandler.ImportUsers)
 	}
 
 	// Inicia servidor
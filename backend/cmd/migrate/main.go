@@ -0,0 +1,77 @@
+// Command migrate aplica ou reverte as migrações do banco de dados. Uso:
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate -steps N down
+//	go run ./cmd/migrate status
+//
+// up aplica as migrações pendentes (o mesmo que a API faz ao iniciar); down reverte
+// as últimas N migrações aplicadas (padrão: 1); status lista as migrações e se já
+// foram aplicadas, sem alterar o banco.
+package main
+
+import (
+	"flag"
+	"fmt"
+	stdlog "log"
+	"os"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/config"
+	"github.com/cleberrangel/clickup-excel-api/internal/database"
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/migration"
+)
+
+func main() {
+	steps := flag.Int("steps", 1, "número de migrações a reverter (down)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: %s [-steps N] up|down|status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+	if command != "up" && command != "down" && command != "status" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		stdlog.Fatalf("Erro ao carregar configurações: %v", err)
+	}
+
+	logger.Init(cfg.LogLevel, cfg.LogJSON)
+
+	db, err := database.Connect(database.Config{
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		User:     cfg.DBUser,
+		Password: cfg.DBPassword,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.DBSSLMode,
+	})
+	if err != nil {
+		stdlog.Fatalf("Erro ao conectar com o banco de dados: %v", err)
+	}
+	defer database.Close(db)
+
+	migrator := migration.NewMigrator(db)
+	switch command {
+	case "up":
+		err = migrator.Run()
+	case "down":
+		err = migrator.Rollback(*steps)
+	case "status":
+		var statuses []migration.MigrationStatus
+		if statuses, err = migrator.Status(); err == nil {
+			err = migration.WriteStatus(os.Stdout, statuses)
+		}
+	}
+	if err != nil {
+		stdlog.Fatalf("Erro ao executar migrações: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Command reencrypt-tokens recriptografa os tokens do ClickUp salvos no banco com uma nova
+// chave de criptografia. Uso:
+//
+//	go run ./cmd/reencrypt-tokens -old <chave antiga> -new <chave nova>
+//
+// Sem -new, usa ENCRYPTION_KEY. Pode ser executado de novo com segurança: tokens já
+// cifrados com a nova chave são mantidos.
+package main
+
+import (
+	"flag"
+	stdlog "log"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/config"
+	"github.com/cleberrangel/clickup-excel-api/internal/database"
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/migration"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		stdlog.Fatalf("Erro ao carregar configurações: %v", err)
+	}
+
+	oldKey := flag.String("old", "", "chave de criptografia atual dos tokens (obrigatória)")
+	newKey := flag.String("new", cfg.EncryptionKey, "nova chave de criptografia (padrão: ENCRYPTION_KEY)")
+	flag.Parse()
+
+	if *oldKey == "" || *newKey == "" {
+		flag.Usage()
+		stdlog.Fatal("informe -old e -new")
+	}
+	if *oldKey == *newKey {
+		stdlog.Fatal("a nova chave deve ser diferente da antiga")
+	}
+
+	logger.Init(cfg.LogLevel, cfg.LogJSON)
+
+	db, err := database.Connect(database.Config{
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		User:     cfg.DBUser,
+		Password: cfg.DBPassword,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.DBSSLMode,
+	})
+	if err != nil {
+		stdlog.Fatalf("Erro ao conectar com o banco de dados: %v", err)
+	}
+	defer database.Close(db)
+
+	if err := migration.NewMigrator(db).Run(); err != nil {
+		stdlog.Fatalf("Erro ao executar migrações: %v", err)
+	}
+
+	metadataService := service.NewMetadataService(nil, repository.NewConfigRepository(db), *newKey)
+	if err := metadataService.ReencryptTokens(*oldKey, *newKey); err != nil {
+		stdlog.Fatalf("Erro ao recriptografar tokens: %v", err)
+	}
+}
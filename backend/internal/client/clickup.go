@@ -4,22 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
 	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"golang.org/x/time/rate"
 )
 
 const (
-	baseURL = "https://api.clickup.com/api/v2"
+	defaultBaseURL = "https://api.clickup.com/api/v2"
 
-	// MaxConcurrentRequests limita requisições simultâneas
-	MaxConcurrentRequests = 5
+	// DefaultMaxConcurrentRequests limita requisições simultâneas
+	DefaultMaxConcurrentRequests = 5
+
+	// DefaultMaxIdleConns conexões ociosas mantidas no pool
+	DefaultMaxIdleConns = 10
+
+	// DefaultMaxIdleConnsPerHost conexões ociosas mantidas por host
+	DefaultMaxIdleConnsPerHost = 10
 
 	// RequestsPerMinute limite conservador (ClickUp permite 10k/min)
 	RequestsPerMinute = 2000
@@ -30,40 +41,190 @@ const (
 	// PageSize tamanho padrão da página do ClickUp
 	PageSize = 100
 
-	// RetryMaxAttempts número máximo de tentativas por página
+	// RetryMaxAttempts número padrão de tentativas por requisição (ver RetryPolicy)
 	RetryMaxAttempts = 3
 
-	// RetryBackoff tempo de espera entre retries
+	// RetryBackoff espera padrão entre tentativas (ver RetryPolicy)
 	RetryBackoff = 30 * time.Second
 )
 
+// Locales suportados na conversão de números e datas
+const (
+	LocalePtBR = "pt-BR"
+	LocaleEnUS = "en-US"
+
+	// DefaultLocale locale usado quando nenhum é informado
+	DefaultLocale = LocalePtBR
+)
+
 // Client é o cliente HTTP para a API do ClickUp
 type Client struct {
-	token      string
-	httpClient *http.Client
-	limiter    *rate.Limiter
+	baseURL       string
+	token         string
+	httpClient    *http.Client
+	limiter       *rate.Limiter
+	transform     TransformOptions
+	maxConcurrent int
+	retry         RetryPolicy
+	// customTaskTeamID ativa custom_task_ids nas atualizações de tasks (vazio = IDs padrão)
+	customTaskTeamID string
+	// debug registra cada requisição e resposta no log (ver do)
+	debug bool
+}
+
+// ClientOptions configura timeout, pool de conexões e concorrência do cliente.
+// Valores zerados usam os padrões.
+type ClientOptions struct {
+	BaseURL               string        // URL da API (padrão a do ClickUp; proxy ou servidor de testes)
+	Timeout               time.Duration // timeout de cada requisição (padrão DefaultTimeout)
+	MaxIdleConns          int           // conexões ociosas no pool (padrão DefaultMaxIdleConns)
+	MaxIdleConnsPerHost   int           // conexões ociosas por host (padrão DefaultMaxIdleConnsPerHost)
+	MaxConcurrentRequests int           // requisições simultâneas por operação (padrão DefaultMaxConcurrentRequests)
+	Retry                 RetryPolicy   // novas tentativas em falhas transitórias (padrão DefaultRetryPolicy)
+	Debug                 bool          // registra URL, corpo e status de cada requisição (token sempre omitido)
+}
+
+// DefaultClientOptions retorna as opções usadas por NewClient
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Timeout:               DefaultTimeout,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		MaxConcurrentRequests: DefaultMaxConcurrentRequests,
+		Retry:                 DefaultRetryPolicy(),
+	}
 }
 
-// NewClient cria um novo cliente ClickUp
+// withDefaults preenche os campos não informados com os padrões
+func (o ClientOptions) withDefaults() ClientOptions {
+	defaults := DefaultClientOptions()
+	if o.Timeout <= 0 {
+		o.Timeout = defaults.Timeout
+	}
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost <= 0 {
+		o.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if o.MaxConcurrentRequests <= 0 {
+		o.MaxConcurrentRequests = defaults.MaxConcurrentRequests
+	}
+	o.Retry = o.Retry.withDefaults()
+	return o
+}
+
+// TransformOptions controla a conversão de valores que depende de região
+type TransformOptions struct {
+	Locale   string         // separadores numéricos e ordem de datas (pt-BR, en-US)
+	Location *time.Location // fuso usado em datas sem offset explícito (padrão UTC)
+}
+
+// NewClient cria um novo cliente ClickUp com as opções padrão
 func NewClient(token string) *Client {
+	return NewClientWithOptions(token, DefaultClientOptions())
+}
+
+// NewClientWithOptions cria um novo cliente ClickUp com timeout, pool e concorrência configuráveis
+func NewClientWithOptions(token string, opts ClientOptions) *Client {
+	opts = opts.withDefaults()
+	baseURL := defaultBaseURL
+	if opts.BaseURL != "" {
+		baseURL = strings.TrimRight(opts.BaseURL, "/")
+	}
 	return &Client{
-		token: token,
+		baseURL: baseURL,
+		token:   token,
 		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout: opts.Timeout,
 			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				MaxIdleConnsPerHost: 10,
+				MaxIdleConns:        opts.MaxIdleConns,
+				MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
 				IdleConnTimeout:     30 * time.Second,
 			},
 		},
 		limiter: rate.NewLimiter(rate.Every(time.Minute/RequestsPerMinute), 50),
+		transform: TransformOptions{
+			Locale:   DefaultLocale,
+			Location: time.UTC,
+		},
+		maxConcurrent: opts.MaxConcurrentRequests,
+		retry:         opts.Retry,
+		debug:         opts.Debug,
+	}
+}
+
+// SetBaseURL aponta o cliente para outra URL base (proxy ou servidor de testes)
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetLocale define o locale usado para converter valores de campos (pt-BR ou en-US)
+func (c *Client) SetLocale(locale string) {
+	if IsSupportedLocale(locale) {
+		c.transform.Locale = locale
+	}
+}
+
+// SetCustomTaskIDs faz as atualizações de tasks usarem os IDs personalizados do
+// workspace (ex.: "PROJ-123"). O ClickUp exige o team_id (ID do workspace) junto;
+// teamID vazio volta aos IDs padrão.
+func (c *Client) SetCustomTaskIDs(teamID string) {
+	c.customTaskTeamID = strings.TrimSpace(teamID)
+}
+
+// taskUpdateURL monta a URL de um recurso da task (resource vazio = a própria task),
+// com custom_task_ids e team_id quando o cliente usa IDs personalizados
+func (c *Client) taskUpdateURL(taskID, resource string) string {
+	taskURL := fmt.Sprintf("%s/task/%s", c.baseURL, url.PathEscape(taskID))
+	if resource != "" {
+		taskURL += "/" + resource
 	}
+	if c.customTaskTeamID == "" {
+		return taskURL
+	}
+	return taskURL + "?custom_task_ids=true&team_id=" + url.QueryEscape(c.customTaskTeamID)
+}
+
+// SetTimezone define o fuso horário (nome IANA) usado para interpretar datas sem offset
+func (c *Client) SetTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("timezone inválido '%s': %w", name, err)
+	}
+	c.transform.Location = loc
+	return nil
+}
+
+// IsSupportedLocale indica se o locale é suportado na conversão de valores
+func IsSupportedLocale(locale string) bool {
+	return locale == LocalePtBR || locale == LocaleEnUS
 }
 
 // buildTaskURL constrói a URL para buscar tarefas de uma lista
-func buildTaskURL(listID string, page int, subtasks, includeClosed bool) string {
-	return fmt.Sprintf("%s/list/%s/task?page=%d&subtasks=%t&include_closed=%t",
-		baseURL, listID, page, subtasks, includeClosed)
+func (c *Client) buildTaskURL(listID string, page int, subtasks, includeClosed bool, filters *model.TaskFilters) string {
+	taskURL := fmt.Sprintf("%s/list/%s/task?page=%d&subtasks=%t&include_closed=%t",
+		c.baseURL, listID, page, subtasks, includeClosed)
+
+	if filters == nil {
+		return taskURL
+	}
+
+	var sb strings.Builder
+	sb.WriteString(taskURL)
+	for _, status := range filters.Statuses {
+		sb.WriteString("&statuses%5B%5D=" + url.QueryEscape(status))
+	}
+	for _, assignee := range filters.Assignees {
+		sb.WriteString("&assignees%5B%5D=" + url.QueryEscape(assignee))
+	}
+	if filters.DateUpdatedGt > 0 {
+		sb.WriteString("&date_updated_gt=" + strconv.FormatInt(filters.DateUpdatedGt, 10))
+	}
+	if filters.DateUpdatedLt > 0 {
+		sb.WriteString("&date_updated_lt=" + strconv.FormatInt(filters.DateUpdatedLt, 10))
+	}
+	return sb.String()
 }
 
 // GetTasks busca todas as tarefas de uma lista com paginação automática e retry
@@ -71,6 +232,7 @@ func (c *Client) GetTasks(ctx context.Context, listID string, subtasks, includeC
 	var allTasks []model.Task
 	page := 0
 	totalCollected := 0
+	pageLog := logger.Sampled(logger.Get(ctx)) // "Lista concluída" resume a lista
 
 	for {
 		// Aguarda rate limiter
@@ -78,7 +240,7 @@ func (c *Client) GetTasks(ctx context.Context, listID string, subtasks, includeC
 			return nil, fmt.Errorf("rate limiter: %w", err)
 		}
 
-		url := buildTaskURL(listID, page, subtasks, includeClosed)
+		url := c.buildTaskURL(listID, page, subtasks, includeClosed, nil)
 
 		// Executa request com retry
 		resp, err := c.doRequestWithRetry(ctx, url, listID, page)
@@ -87,7 +249,7 @@ func (c *Client) GetTasks(ctx context.Context, listID string, subtasks, includeC
 			logger.Get(ctx).Error().
 				Str("list_id", listID).
 				Int("page", page).
-				Int("attempts", RetryMaxAttempts).
+				Int("attempts", c.retry.MaxAttempts).
 				Int("collected", totalCollected).
 				Err(err).
 				Msg("Falha definitiva na coleta")
@@ -98,7 +260,7 @@ func (c *Client) GetTasks(ctx context.Context, listID string, subtasks, includeC
 		allTasks = append(allTasks, resp.Tasks...)
 		totalCollected = len(allTasks)
 
-		logger.Get(ctx).Info().
+		pageLog.Info().
 			Str("list_id", listID).
 			Int("page", page).
 			Int("tasks", len(resp.Tasks)).
@@ -126,7 +288,7 @@ func (c *Client) GetTasks(ctx context.Context, listID string, subtasks, includeC
 func (c *Client) doRequestWithRetry(ctx context.Context, url, listID string, page int) (*model.TaskResponse, error) {
 	var lastErr error
 
-	for attempt := 1; attempt <= RetryMaxAttempts; attempt++ {
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
 		resp, err := c.doRequest(ctx, url)
 		if err == nil {
 			return resp, nil
@@ -139,32 +301,32 @@ func (c *Client) doRequestWithRetry(ctx context.Context, url, listID string, pag
 			return nil, err
 		}
 
-		// Se é rate limit ou não autorizado, não faz retry
-		if err == model.ErrRateLimited || err == model.ErrUnauthorized || err == model.ErrNotFound {
+		// Rate limit (espera do limiter), token inválido, recurso inexistente e erros
+		// que dependem de correção (4xx) não têm retry
+		if errors.Is(err, model.ErrRateLimited) || !IsRetryable(err) {
 			return nil, err
 		}
 
 		// Se ainda tem tentativas, aguarda e tenta novamente
-		if attempt < RetryMaxAttempts {
+		if attempt < c.retry.MaxAttempts {
+			backoff := c.retry.Backoff(attempt)
 			logger.Get(ctx).Warn().
 			Str("list_id", listID).
 			Int("page", page).
 			Int("attempt", attempt).
-			Int("max_attempts", RetryMaxAttempts).
+			Int("max_attempts", c.retry.MaxAttempts).
 			Err(err).
-			Dur("backoff", RetryBackoff).
+			Dur("backoff", backoff).
 			Msg("Tentativa falhou, aguardando retry")
 
-			select {
-			case <-time.After(RetryBackoff):
-				logger.Get(ctx).Info().
-					Str("list_id", listID).
-					Int("page", page).
-					Int("attempt", attempt+1).
-					Msg("Retomando tentativa")
-			case <-ctx.Done():
-				return nil, ctx.Err()
+			if err := waitRetry(ctx, backoff); err != nil {
+				return nil, err
 			}
+			logger.Get(ctx).Info().
+				Str("list_id", listID).
+				Int("page", page).
+				Int("attempt", attempt+1).
+				Msg("Retomando tentativa")
 		}
 	}
 
@@ -180,70 +342,153 @@ func (c *Client) GetTasksMultiple(ctx context.Context, listIDs []string, subtask
 	}
 	defer storage.Close()
 
-	if err := c.GetTasksToStorage(ctx, listIDs, storage, subtasks, includeClosed); err != nil {
+	if err := c.GetTasksToStorage(ctx, listIDs, storage, subtasks, includeClosed, false, nil); err != nil {
 		return nil, err
 	}
 
 	return storage.ReadAllTasks()
 }
 
-// GetTasksToStorage busca tarefas e salva diretamente no storage (baixo consumo de memória)
-func (c *Client) GetTasksToStorage(ctx context.Context, listIDs []string, storage *repository.TaskStorage, subtasks, includeClosed bool) error {
+// GetTasksToStorage busca tarefas e salva diretamente no storage (baixo consumo de memória).
+// Listas que falham são registradas no log e a coleta segue para a próxima lista.
+func (c *Client) GetTasksToStorage(ctx context.Context, listIDs []string, storage *repository.TaskStorage, subtasks, includeClosed, includeActivity bool, filters *model.TaskFilters) error {
+	err := c.GetTasksToStorageResumable(ctx, listIDs, storage, subtasks, includeClosed, includeActivity, filters)
+	if errors.Is(err, model.ErrIncompleteFetch) {
+		return nil
+	}
+	return err
+}
+
+// GetTasksToStorageResumable busca tarefas a partir do cursor salvo no storage,
+// pulando listas concluídas e páginas já gravadas. Quando alguma lista falha,
+// as demais continuam sendo coletadas e o retorno envolve model.ErrIncompleteFetch;
+// chamar novamente com o mesmo storage retoma as listas incompletas de onde pararam.
+// Com includeActivity, cada página é enriquecida com as contagens de comentários e
+// anexos antes de ser gravada.
+func (c *Client) GetTasksToStorageResumable(ctx context.Context, listIDs []string, storage *repository.TaskStorage, subtasks, includeClosed, includeActivity bool, filters *model.TaskFilters) error {
+	return c.GetTasksToStorageWithProgress(ctx, listIDs, storage, subtasks, includeClosed, includeActivity, filters, nil)
+}
+
+// ListProgressFunc recebe o andamento da coleta ao fim de cada lista: listas
+// processadas (concluídas ou com falha), total de listas e tasks já gravadas no storage
+type ListProgressFunc func(listsDone, totalLists, tasksCollected int)
+
+// GetTasksToStorageWithProgress é GetTasksToStorageResumable chamando onList (se não
+// nil) a cada lista processada. Listas já concluídas em uma tentativa anterior contam
+// como processadas.
+func (c *Client) GetTasksToStorageWithProgress(ctx context.Context, listIDs []string, storage *repository.TaskStorage, subtasks, includeClosed, includeActivity bool, filters *model.TaskFilters, onList ListProgressFunc) error {
 	totalTasks := 0
+	listsDone := 0
+	var failedLists []string
+
+	// Linhas por lista e por página são amostradas; "Lista concluída" e o total final
+	// resumem a coleta
+	listLog := logger.Sampled(logger.Get(ctx))
+	pageLog := logger.Sampled(logger.Get(ctx))
+
+	reportList := func() {
+		listsDone++
+		if onList != nil {
+			onList(listsDone, len(listIDs), storage.GetTaskCount())
+		}
+	}
 
 	for i, listID := range listIDs {
-		logger.Get(ctx).Info().
+		// Cancelado (cliente desconectou ou relatório cancelado): para antes da próxima lista
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, completed := storage.ResumePoint(listID)
+		if completed {
+			logger.Get(ctx).Debug().
+				Str("list_id", listID).
+				Msg("Lista já coletada, pulando")
+			listsDone++
+			continue
+		}
+
+		listLog.Info().
 			Int("current", i+1).
 			Int("total", len(listIDs)).
 			Str("list_id", listID).
+			Int("start_page", page).
 			Msg("Processando lista")
 
-		page := 0
 		listTasks := 0
+		// A primeira janela busca só a página inicial: listas de uma página não geram
+		// requisições extras. As seguintes buscam até maxConcurrent páginas em paralelo.
+		window := 1
 
+	pages:
 		for {
-			// Aguarda rate limiter
-			if err := c.limiter.Wait(ctx); err != nil {
-				return fmt.Errorf("rate limiter: %w", err)
-			}
-
-			url := buildTaskURL(listID, page, subtasks, includeClosed)
-
-			// Executa request com retry
-			resp, err := c.doRequestWithRetry(ctx, url, listID, page)
-			if err != nil {
-				logger.Get(ctx).Warn().
-				Str("list_id", listID).
-				Int("page", page).
-				Err(err).
-				Int("collected", listTasks).
-				Msg("Falha na lista, continuando")
-				break // Continua para próxima lista
-			}
-
-			// Salva tasks no storage (não acumula em memória)
-			if err := storage.AppendTasks(resp.Tasks); err != nil {
-				return fmt.Errorf("salvar tasks no storage: %w", err)
-			}
-
-			listTasks += len(resp.Tasks)
-			totalTasks += len(resp.Tasks)
-
-			logger.Get(ctx).Info().
-				Str("list_id", listID).
-				Int("page", page).
-				Int("page_tasks", len(resp.Tasks)).
-				Int("list_tasks", listTasks).
-				Int("total_tasks", totalTasks).
-				Bool("last_page", resp.LastPage).
-				Msg("Tasks coletadas")
-
-			// Condição de parada: última página
-			if resp.LastPage {
-				break
+			results := c.fetchPageWindow(ctx, listID, page, window, subtasks, includeClosed, filters)
+			window = c.maxConcurrent
+
+			// Grava as páginas da janela em ordem; a próxima janela começa na primeira
+			// página não buscada
+			for _, result := range results {
+				if result.skipped {
+					continue pages
+				}
+				if result.err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					if result.limiterErr {
+						return result.err
+					}
+					logger.Get(ctx).Warn().
+					Str("list_id", listID).
+					Int("page", page).
+					Err(result.err).
+					Int("collected", listTasks).
+					Msg("Falha na lista, continuando")
+					failedLists = append(failedLists, listID)
+					break pages // Continua para próxima lista
+				}
+				resp := result.resp
+
+				if includeActivity {
+					if err := c.EnrichTaskActivity(ctx, resp.Tasks); err != nil {
+						return fmt.Errorf("buscar atividade das tasks: %w", err)
+					}
+				}
+
+				// Marca a lista de origem: após juntar várias listas no storage ainda
+				// é possível saber de onde cada task veio
+				for t := range resp.Tasks {
+					resp.Tasks[t].SourceListID = listID
+				}
+
+				// Uma página curta também indica o fim, mesmo sem last_page (mesma regra
+				// de fetchPageWindow)
+				end := resp.LastPage || len(resp.Tasks) < PageSize
+
+				// Salva tasks no storage e avança o cursor (não acumula em memória)
+				if err := storage.AppendPage(listID, page, resp.Tasks, end); err != nil {
+					return fmt.Errorf("salvar tasks no storage: %w", err)
+				}
+
+				listTasks += len(resp.Tasks)
+				totalTasks += len(resp.Tasks)
+
+				pageLog.Info().
+					Str("list_id", listID).
+					Int("page", page).
+					Int("page_tasks", len(resp.Tasks)).
+					Int("list_tasks", listTasks).
+					Int("total_tasks", totalTasks).
+					Bool("last_page", end).
+					Msg("Tasks coletadas")
+
+				// Condição de parada: última página
+				if end {
+					break pages
+				}
+
+				page++
 			}
-
-			page++
 		}
 
 		logger.Get(ctx).Info().
@@ -251,22 +496,248 @@ func (c *Client) GetTasksToStorage(ctx context.Context, listIDs []string, storag
 			Int("tasks", listTasks).
 			Int("pages", page+1).
 			Msg("Lista concluída")
+		reportList()
 	}
 
 	logger.Get(ctx).Info().
 		Int("total_tasks", totalTasks).
 		Int("total_lists", len(listIDs)).
+		Int("failed_lists", len(failedLists)).
 		Msg("Todas as listas processadas")
+
+	if len(failedLists) > 0 {
+		return fmt.Errorf("%w: listas %s", model.ErrIncompleteFetch, strings.Join(failedLists, ", "))
+	}
+	return nil
+}
+
+// pageResult é o resultado da busca de uma página dentro de uma janela
+type pageResult struct {
+	resp       *model.TaskResponse
+	err        error
+	limiterErr bool // err veio do rate limiter (prazo do contexto), não da API
+	skipped    bool // não buscada: uma página anterior indicou o fim da lista
+}
+
+// fetchPageWindow busca as páginas [start, start+size) de uma lista em paralelo.
+// As páginas são liberadas em ordem, cada uma após a sua vez no rate limiter, e as
+// requisições correm em paralelo. Uma página marcada como última, ou com menos de
+// PageSize tasks, indica o fim da lista: as páginas seguintes ainda não liberadas
+// não são requisitadas.
+func (c *Client) fetchPageWindow(ctx context.Context, listID string, start, size int, subtasks, includeClosed bool, filters *model.TaskFilters) []pageResult {
+	results := make([]pageResult, size)
+	if size == 1 {
+		results[0] = c.fetchPage(ctx, listID, start, subtasks, includeClosed, filters)
+		return results
+	}
+
+	var mu sync.Mutex
+	endPage := -1
+	pastEnd := func(page int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return endPage >= 0 && page > endPage
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		page := start + i
+		// O fim da lista é verificado depois da espera: páginas anteriores podem ter
+		// terminado enquanto esta aguardava
+		if err := c.limiter.Wait(ctx); err != nil {
+			results[i] = pageResult{err: fmt.Errorf("rate limiter: %w", err), limiterErr: true}
+			markSkipped(results[i+1:])
+			break
+		}
+		if pastEnd(page) {
+			markSkipped(results[i:])
+			break
+		}
+
+		wg.Add(1)
+		go func(i, page int) {
+			defer wg.Done()
+			result := c.requestPage(ctx, listID, page, subtasks, includeClosed, filters)
+			results[i] = result
+			if result.err == nil && (result.resp.LastPage || len(result.resp.Tasks) < PageSize) {
+				mu.Lock()
+				if endPage < 0 || page < endPage {
+					endPage = page
+				}
+				mu.Unlock()
+			}
+		}(i, page)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// markSkipped marca páginas da janela que não serão buscadas
+func markSkipped(results []pageResult) {
+	for i := range results {
+		results[i].skipped = true
+	}
+}
+
+// fetchPage busca uma página de tasks respeitando o rate limiter
+func (c *Client) fetchPage(ctx context.Context, listID string, page int, subtasks, includeClosed bool, filters *model.TaskFilters) pageResult {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return pageResult{err: fmt.Errorf("rate limiter: %w", err), limiterErr: true}
+	}
+	return c.requestPage(ctx, listID, page, subtasks, includeClosed, filters)
+}
+
+// requestPage busca uma página de tasks (a vez no rate limiter já foi obtida)
+func (c *Client) requestPage(ctx context.Context, listID string, page int, subtasks, includeClosed bool, filters *model.TaskFilters) pageResult {
+	url := c.buildTaskURL(listID, page, subtasks, includeClosed, filters)
+
+	// Executa request com retry
+	resp, err := c.doRequestWithRetry(ctx, url, listID, page)
+	return pageResult{resp: resp, err: err}
+}
+
+// commentPageSize quantidade de comentários retornada pelo ClickUp por requisição
+const commentPageSize = 25
+
+// GetTaskComments busca todos os comentários de uma tarefa.
+// O ClickUp retorna os 25 mais recentes; os anteriores são paginados via start/start_id.
+func (c *Client) GetTaskComments(ctx context.Context, taskID string) ([]model.Comment, error) {
+	var comments []model.Comment
+	endpoint := fmt.Sprintf("%s/task/%s/comment", c.baseURL, url.PathEscape(taskID))
+
+	for {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiter: %w", err)
+		}
+
+		reqURL := endpoint
+		if n := len(comments); n > 0 {
+			last := comments[n-1]
+			reqURL = fmt.Sprintf("%s?start=%s&start_id=%s", endpoint, url.QueryEscape(last.Date), url.QueryEscape(last.ID))
+		}
+
+		var resp model.CommentResponse
+		if err := c.doGenericRequest(ctx, reqURL, &resp); err != nil {
+			return nil, fmt.Errorf("buscar comentários: %w", err)
+		}
+
+		comments = append(comments, resp.Comments...)
+		if len(resp.Comments) < commentPageSize {
+			return comments, nil
+		}
+	}
+}
+
+// CreateTaskComment publica um comentário na tarefa, sem notificar os seguidores
+func (c *Client) CreateTaskComment(ctx context.Context, taskID, text string) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+
+	body := map[string]interface{}{
+		"comment_text": text,
+		"notify_all":   false,
+	}
+	if err := c.doPostRequest(ctx, c.taskUpdateURL(taskID, "comment"), body); err != nil {
+		return fmt.Errorf("criar comentário: %w", err)
+	}
 	return nil
 }
 
+// GetTask busca uma tarefa pelo ID (ou pelo ID personalizado, com SetCustomTaskIDs)
+func (c *Client) GetTask(ctx context.Context, taskID string) (*model.Task, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	var task model.Task
+	if err := c.doGenericRequest(ctx, c.taskUpdateURL(taskID, ""), &task); err != nil {
+		return nil, fmt.Errorf("buscar tarefa: %w", err)
+	}
+
+	return &task, nil
+}
+
+// GetTaskAttachments busca os metadados dos anexos de uma tarefa
+func (c *Client) GetTaskAttachments(ctx context.Context, taskID string) ([]model.Attachment, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/task/%s", c.baseURL, url.PathEscape(taskID))
+
+	var resp model.TaskDetailResponse
+	if err := c.doGenericRequest(ctx, reqURL, &resp); err != nil {
+		return nil, fmt.Errorf("buscar anexos: %w", err)
+	}
+
+	return resp.Attachments, nil
+}
+
+// EnrichTaskActivity preenche CommentCount e AttachmentCount das tasks usando até
+// MaxConcurrentRequests workers (ClientOptions). Falhas em uma task são registradas no log e deixam
+// as contagens vazias, sem interromper as demais.
+func (c *Client) EnrichTaskActivity(ctx context.Context, tasks []model.Task) error {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := c.maxConcurrent
+	if len(tasks) < workers {
+		workers = len(tasks)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c.enrichTask(ctx, &tasks[i])
+			}
+		}()
+	}
+
+	for i := range tasks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			return ctx.Err()
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// enrichTask busca comentários e anexos de uma única task
+func (c *Client) enrichTask(ctx context.Context, task *model.Task) {
+	comments, err := c.GetTaskComments(ctx, task.ID)
+	if err != nil {
+		logger.Get(ctx).Warn().Str("task_id", task.ID).Err(err).Msg("Falha ao buscar comentários")
+	} else {
+		count := len(comments)
+		task.CommentCount = &count
+	}
+
+	attachments, err := c.GetTaskAttachments(ctx, task.ID)
+	if err != nil {
+		logger.Get(ctx).Warn().Str("task_id", task.ID).Err(err).Msg("Falha ao buscar anexos")
+	} else {
+		count := len(attachments)
+		task.AttachmentCount = &count
+	}
+}
+
 // GetWorkspaces busca todos os workspaces do usuário
 func (c *Client) GetWorkspaces(ctx context.Context) ([]model.Workspace, error) {
 	if err := c.limiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/team", baseURL)
+	url := fmt.Sprintf("%s/team", c.baseURL)
 	
 	var resp model.WorkspaceResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
@@ -276,13 +747,49 @@ func (c *Client) GetWorkspaces(ctx context.Context) ([]model.Workspace, error) {
 	return resp.Teams, nil
 }
 
+// GetWorkspaceMembers busca os membros de um workspace (de todos os workspaces do
+// token quando workspaceID é vazio, sem repetir usuários)
+func (c *Client) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.UserInfo, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/team", c.baseURL)
+
+	var resp model.WorkspaceMembersResponse
+	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
+		return nil, fmt.Errorf("buscar membros do workspace: %w", err)
+	}
+
+	var members []model.UserInfo
+	seen := make(map[int]bool)
+	found := workspaceID == ""
+	for _, team := range resp.Teams {
+		if workspaceID != "" && team.ID != workspaceID {
+			continue
+		}
+		found = true
+		for _, member := range team.Members {
+			if !seen[member.User.ID] {
+				seen[member.User.ID] = true
+				members = append(members, member.User)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("buscar membros do workspace: workspace %s: %w", workspaceID, model.ErrNotFound)
+	}
+
+	return members, nil
+}
+
 // GetSpaces busca todos os spaces de um workspace
 func (c *Client) GetSpaces(ctx context.Context, workspaceID string) ([]model.Space, error) {
 	if err := c.limiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/team/%s/space", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/team/%s/space", c.baseURL, workspaceID)
 	
 	var resp model.SpaceResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
@@ -298,7 +805,7 @@ func (c *Client) GetFolders(ctx context.Context, spaceID string) ([]model.Folder
 		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/space/%s/folder", baseURL, spaceID)
+	url := fmt.Sprintf("%s/space/%s/folder", c.baseURL, spaceID)
 	
 	var resp model.FolderResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
@@ -314,7 +821,7 @@ func (c *Client) GetLists(ctx context.Context, folderID string) ([]model.List, e
 		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/folder/%s/list", baseURL, folderID)
+	url := fmt.Sprintf("%s/folder/%s/list", c.baseURL, folderID)
 	
 	var resp model.ListResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
@@ -324,13 +831,45 @@ func (c *Client) GetLists(ctx context.Context, folderID string) ([]model.List, e
 	return resp.Lists, nil
 }
 
+// GetFolderlessLists busca as listas criadas direto no space, fora de folders
+func (c *Client) GetFolderlessLists(ctx context.Context, spaceID string) ([]model.List, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/space/%s/list", c.baseURL, spaceID)
+
+	var resp model.ListResponse
+	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
+		return nil, fmt.Errorf("buscar listas sem folder: %w", err)
+	}
+
+	return resp.Lists, nil
+}
+
+// GetList busca uma lista pelo ID (model.ErrNotFound se não existir)
+func (c *Client) GetList(ctx context.Context, listID string) (*model.List, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/list/%s", c.baseURL, listID)
+
+	var list model.List
+	if err := c.doGenericRequest(ctx, url, &list); err != nil {
+		return nil, fmt.Errorf("buscar lista: %w", err)
+	}
+
+	return &list, nil
+}
+
 // GetCustomFields busca todos os campos personalizados de uma lista
 func (c *Client) GetCustomFields(ctx context.Context, listID string) ([]model.CustomFieldMetadata, error) {
 	if err := c.limiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/list/%s/field", baseURL, listID)
+	url := fmt.Sprintf("%s/list/%s/field", c.baseURL, listID)
 	
 	var resp model.CustomFieldResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
@@ -342,18 +881,24 @@ func (c *Client) GetCustomFields(ctx context.Context, listID string) ([]model.Cu
 
 // ValidateToken valida se o token é válido fazendo uma requisição simples
 func (c *Client) ValidateToken(ctx context.Context) error {
+	_, err := c.GetAuthorizedUser(ctx)
+	return err
+}
+
+// GetAuthorizedUser retorna o usuário dono do token (valida o token)
+func (c *Client) GetAuthorizedUser(ctx context.Context) (*model.UserInfo, error) {
 	if err := c.limiter.Wait(ctx); err != nil {
-		return fmt.Errorf("rate limiter: %w", err)
+		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/user", baseURL)
-	
+	url := fmt.Sprintf("%s/user", c.baseURL)
+
 	var resp model.UserResponse
 	if err := c.doGenericRequest(ctx, url, &resp); err != nil {
-		return fmt.Errorf("validar token: %w", err)
+		return nil, fmt.Errorf("validar token: %w", err)
 	}
 
-	return nil
+	return &resp.User, nil
 }
 
 // doGenericRequest executa uma requisição HTTP genérica para a API do ClickUp
@@ -366,7 +911,7 @@ func (c *Client) doGenericRequest(ctx context.Context, url string, result interf
 	req.Header.Set("Authorization", c.token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req, nil)
 	if err != nil {
 		if ctx.Err() != nil {
 			return model.ErrTimeout
@@ -374,6 +919,7 @@ func (c *Client) doGenericRequest(ctx context.Context, url string, result interf
 		return fmt.Errorf("executar request: %w", err)
 	}
 	defer resp.Body.Close()
+	recordRateLimit(resp)
 
 	// Tratamento de erros HTTP
 	switch resp.StatusCode {
@@ -386,8 +932,7 @@ func (c *Client) doGenericRequest(ctx context.Context, url string, result interf
 	case http.StatusNotFound:
 		return model.ErrNotFound
 	default:
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+		return newClickUpError(resp)
 	}
 
 	// Parse da resposta
@@ -398,6 +943,27 @@ func (c *Client) doGenericRequest(ctx context.Context, url string, result interf
 	return nil
 }
 
+// recordRateLimit repassa os headers X-RateLimit-* da resposta para as métricas,
+// mostrando o quanto falta para o limite do ClickUp
+func recordRateLimit(resp *http.Response) {
+	limit := rateLimitHeader(resp, "X-RateLimit-Limit")
+	remaining := rateLimitHeader(resp, "X-RateLimit-Remaining")
+	reset := rateLimitHeader(resp, "X-RateLimit-Reset")
+	if limit < 0 && remaining < 0 && reset < 0 {
+		return
+	}
+	metrics.Get().RecordClickUpRateLimit(limit, remaining, reset)
+}
+
+// rateLimitHeader lê um header numérico, retornando -1 se ausente ou inválido
+func rateLimitHeader(resp *http.Response, name string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(resp.Header.Get(name)), 10, 64)
+	if err != nil || v < 0 {
+		return -1
+	}
+	return v
+}
+
 // doRequest executa uma requisição HTTP para a API do ClickUp
 func (c *Client) doRequest(ctx context.Context, url string) (*model.TaskResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -408,7 +974,7 @@ func (c *Client) doRequest(ctx context.Context, url string) (*model.TaskResponse
 	req.Header.Set("Authorization", c.token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req, nil)
 	if err != nil {
 		if ctx.Err() != nil {
 			return nil, model.ErrTimeout
@@ -416,6 +982,7 @@ func (c *Client) doRequest(ctx context.Context, url string) (*model.TaskResponse
 		return nil, fmt.Errorf("executar request: %w", err)
 	}
 	defer resp.Body.Close()
+	recordRateLimit(resp)
 
 	// Tratamento de erros HTTP
 	switch resp.StatusCode {
@@ -428,8 +995,7 @@ func (c *Client) doRequest(ctx context.Context, url string) (*model.TaskResponse
 	case http.StatusNotFound:
 		return nil, model.ErrNotFound
 	default:
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+		return nil, newClickUpError(resp)
 	}
 
 	// Parse da resposta
@@ -448,89 +1014,208 @@ func (c *Client) SetCustomFieldValue(ctx context.Context, taskID, fieldID string
 		return fmt.Errorf("rate limiter: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/task/%s/field/%s", baseURL, taskID, fieldID)
+	endpoint := c.taskUpdateURL(taskID, "field/"+url.PathEscape(fieldID))
 	
 	// Transform value based on field type
-	transformedValue := TransformFieldValue(value, fieldType)
+	transformedValue := TransformFieldValueWithOptions(value, fieldType, c.transform)
 	
 	// Build request body
 	body := map[string]interface{}{
 		"value": transformedValue,
 	}
 	
-	return c.doPostRequest(ctx, url, body)
+	return c.doPostRequest(ctx, endpoint, body)
+}
+
+// TransformValue returns the value SetCustomFieldValue would send for value, using
+// the client's locale and timezone
+func (c *Client) TransformValue(value interface{}, fieldType string) interface{} {
+	return TransformFieldValueWithOptions(value, fieldType, c.transform)
 }
 
 // TransformFieldValue transforms a value based on the custom field type
 // This handles the different value formats required by ClickUp's API
 func TransformFieldValue(value interface{}, fieldType string) interface{} {
-	strValue := fmt.Sprintf("%v", value)
-	
+	return TransformFieldValueWithOptions(value, fieldType, TransformOptions{Locale: DefaultLocale})
+}
+
+// TransformFieldValueWithOptions transforms a value using the separators, date ordering
+// and timezone given in opts for numeric and date fields. The conversion of each type
+// comes from the FieldTransformer registry (see RegisterFieldTransformer).
+func TransformFieldValueWithOptions(value interface{}, fieldType string, opts TransformOptions) interface{} {
+	return fieldTransformer(fieldType).Transform(fmt.Sprintf("%v", value), opts)
+}
+
+// ValidateFieldValue checks whether a raw value can be converted to the given field type.
+// It follows the same parsing rules as TransformFieldValue, but reports failures
+// instead of falling back to zero values.
+func ValidateFieldValue(value string, fieldType string) error {
+	return ValidateFieldValueWithOptions(value, fieldType, TransformOptions{Locale: DefaultLocale, Location: time.UTC})
+}
+
+// ValidateFieldValueWithOptions is ValidateFieldValue reading numbers and dates with
+// the locale and timezone of opts, as TransformFieldValueWithOptions does
+func ValidateFieldValueWithOptions(value string, fieldType string, opts TransformOptions) error {
+	if !IsSupportedLocale(opts.Locale) {
+		opts.Locale = DefaultLocale
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	v := trimSpace(value)
+
 	switch fieldType {
-	case "text", "short_text", "email", "url", "phone":
-		// Text-based fields use string value directly
-		return strValue
-		
-	case "number", "currency", "percentage":
-		// Numeric fields - try to parse as number
-		return parseNumericValue(strValue)
-		
+	case "number", "currency", "percentage", "manual_progress":
+		if _, _, ok := parseLocaleNumber(v, opts.Locale); !ok {
+			return fmt.Errorf("valor '%s' não é numérico", value)
+		}
+
+	case "rating", "emoji":
+		score := v
+		if i := strings.Index(score, "/"); i > 0 {
+			score = trimSpace(score[:i])
+		}
+		if _, _, ok := parseLocaleNumber(score, opts.Locale); !ok {
+			if _, ok := countRepeatedSymbol(v); !ok {
+				return fmt.Errorf("valor '%s' não é uma avaliação válida", value)
+			}
+		}
+
 	case "checkbox":
-		// Boolean field
-		return parseBooleanValue(strValue)
-		
+		if !isBooleanLiteral(v) {
+			return fmt.Errorf("valor '%s' não é booleano", value)
+		}
+
 	case "date":
-		// Date field - expects Unix timestamp in milliseconds
-		return parseDateValue(strValue)
-		
-	case "drop_down":
-		// Dropdown expects the option ID or name
-		// If it's already an ID (UUID format), use it directly
-		// Otherwise, return as-is and let the API handle it
-		return strValue
-		
-	case "labels":
-		// Labels field expects an array of label IDs or names
-		return parseLabelsValue(strValue)
-		
-	case "rating":
-		// Rating field expects an integer
-		return parseNumericValue(strValue)
-		
-	case "users":
-		// Users field expects an array of user IDs
-		return parseUsersValue(strValue)
-		
-	case "location":
-		// Location field expects a location object
-		return map[string]interface{}{
-			"location": map[string]interface{}{
-				"formatted_address": strValue,
-			},
+		if parseDateValue(v, opts.Locale, opts.Location) == nil {
+			return fmt.Errorf("valor '%s' não é uma data válida", value)
 		}
-		
+
+	case "labels", "users", "tasks":
+		if len(splitAndTrim(v, ",")) == 0 {
+			return fmt.Errorf("valor '%s' não contém itens", value)
+		}
+
 	default:
-		// For unknown types, return as string
-		return strValue
+		// Tipos registrados fora do pacote podem validar seus próprios valores
+		if validator, ok := fieldTransformer(fieldType).(FieldValidator); ok {
+			return validator.Validate(v)
+		}
 	}
+
+	return nil
 }
 
-// parseNumericValue attempts to parse a string as a number
-func parseNumericValue(s string) interface{} {
-	// Try integer first
-	var intVal int64
-	if _, err := fmt.Sscanf(s, "%d", &intVal); err == nil {
-		return intVal
+// isBooleanLiteral reports whether s is a value understood as true or false
+func isBooleanLiteral(s string) bool {
+	if parseBooleanValue(s) {
+		return true
 	}
-	
-	// Try float
-	var floatVal float64
-	if _, err := fmt.Sscanf(s, "%f", &floatVal); err == nil {
-		return floatVal
+	switch s {
+	case "false", "0", "no", "não", "nao", "FALSE", "False", "NO", "No", "NÃO", "Não", "NAO", "Nao":
+		return true
+	default:
+		return false
 	}
-	
-	// Return 0 if parsing fails
-	return 0
+}
+
+// parseNumericValue attempts to parse a string as a number using the locale separators
+func parseNumericValue(s, locale string) interface{} {
+	value, isInt, ok := parseLocaleNumber(s, locale)
+	if !ok {
+		// Return 0 if parsing fails
+		return 0
+	}
+	if isInt && value == float64(int64(value)) {
+		return int64(value)
+	}
+	return value
+}
+
+// currencyReplacer remove símbolos de moeda, percentual e espaços de valores numéricos
+var currencyReplacer = strings.NewReplacer("R$", "", "US$", "", "$", "", "€", "", "%", "", " ", "", "\u00a0", "")
+
+// ParseNumber interpreta um número no formato do locale (pt-BR quando vazio), com as
+// mesmas regras usadas na conversão de campos numéricos
+func ParseNumber(s, locale string) (float64, bool) {
+	value, _, ok := parseLocaleNumber(s, locale)
+	return value, ok
+}
+
+// parseLocaleNumber interpreta números como "1.234,56" (pt-BR) ou "1,234.56" (en-US).
+// Aceita sinal negativo, parênteses contábeis e prefixos de moeda. Um único separador
+// de milhar sem grupos de 3 dígitos (ex.: "12.5" em pt-BR) é tratado como decimal.
+func parseLocaleNumber(s, locale string) (value float64, isInt bool, ok bool) {
+	s = currencyReplacer.Replace(trimSpace(s))
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, false, false
+	}
+
+	decimalSep, thousandsSep := ",", "."
+	if locale == LocaleEnUS {
+		decimalSep, thousandsSep = ".", ","
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.LastIndex(s, decimalSep); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+		if fracPart == "" || strings.Contains(fracPart, thousandsSep) {
+			return 0, false, false
+		}
+		intPart = strings.ReplaceAll(intPart, thousandsSep, "")
+	} else if strings.Contains(s, thousandsSep) {
+		groups := strings.Split(s, thousandsSep)
+		if isThousandsGrouping(groups) {
+			intPart = strings.Join(groups, "")
+		} else if len(groups) == 2 {
+			intPart, fracPart = groups[0], groups[1]
+		} else {
+			return 0, false, false
+		}
+	}
+
+	normalized := intPart
+	if fracPart != "" {
+		normalized += "." + fracPart
+	}
+	if normalized == "" || strings.ContainsAny(normalized, ",") {
+		return 0, false, false
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, false, false
+	}
+	if negative {
+		value = -value
+	}
+	return value, fracPart == "" && !strings.ContainsAny(intPart, "eE."), true
+}
+
+// isThousandsGrouping verifica se os grupos seguem o padrão 1.234.567
+func isThousandsGrouping(groups []string) bool {
+	first := groups[0]
+	if len(first) == 0 || len(first) > 3 || first == "0" {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
 }
 
 // parseBooleanValue parses a string as a boolean
@@ -543,26 +1228,54 @@ func parseBooleanValue(s string) bool {
 	}
 }
 
-// parseDateValue parses a date string and returns Unix timestamp in milliseconds
-func parseDateValue(s string) interface{} {
+// parseDateValue parses a date string and returns Unix timestamp in milliseconds.
+// Ambiguous dates like 01/02/2024 follow the locale ordering (day first for pt-BR).
+// Values without an explicit offset are interpreted in loc (UTC when nil), so a
+// date-only value becomes midnight in that zone; explicit offsets are kept as-is.
+func parseDateValue(s, locale string, loc *time.Location) interface{} {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// Formats carrying their own offset
+	for _, format := range []string{time.RFC3339, time.RFC3339Nano} {
+		if t, err := time.Parse(format, s); err == nil {
+			return t.UnixMilli()
+		}
+	}
+
+	dayFirst := []string{"02/01/2006", "02/01/2006 15:04", "02-01-2006"}
+	monthFirst := []string{"01/02/2006", "01/02/2006 15:04", "01-02-2006"}
+
 	// Common date formats to try
 	formats := []string{
 		"2006-01-02",
-		"02/01/2006",
-		"01/02/2006",
-		"2006-01-02T15:04:05Z",
 		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+	}
+	if locale == LocaleEnUS {
+		formats = append(formats, monthFirst...)
+		formats = append(formats, dayFirst...)
+	} else {
+		formats = append(formats, dayFirst...)
+		formats = append(formats, monthFirst...)
 	}
 	
 	for _, format := range formats {
-		if t, err := time.Parse(format, s); err == nil {
+		if !strings.Contains(format, "15") {
+			// Date-only: midnight of the calendar date in loc
+			if d, err := time.Parse(format, s); err == nil {
+				return startOfDayIn(d, loc).UnixMilli()
+			}
+			continue
+		}
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
 			return t.UnixMilli()
 		}
 	}
 	
 	// If it's already a number (timestamp), return it
-	var timestamp int64
-	if _, err := fmt.Sscanf(s, "%d", &timestamp); err == nil {
+	if timestamp, err := strconv.ParseInt(s, 10, 64); err == nil {
 		return timestamp
 	}
 	
@@ -570,6 +1283,20 @@ func parseDateValue(s string) interface{} {
 	return nil
 }
 
+// startOfDayIn retorna o primeiro instante, no fuso loc, da data civil de t. Quando a meia-noite
+// não existe (início do horário de verão), avança até a primeira hora válida do dia.
+func startOfDayIn(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	for i := 0; i < 3; i++ {
+		if _, _, d := start.Date(); d == day {
+			break
+		}
+		start = start.Add(time.Hour)
+	}
+	return start
+}
+
 // parseLabelsValue parses a comma-separated string into an array
 func parseLabelsValue(s string) []string {
 	if s == "" {
@@ -600,6 +1327,82 @@ func parseUsersValue(s string) []interface{} {
 	return result
 }
 
+// parseTasksValue parses a comma-separated list of task IDs into the relationship
+// payload. IDs prefixed with "-" are removed from the field; the others (optionally
+// prefixed with "+") are added. Both arrays are always present.
+func parseTasksValue(s string) map[string]interface{} {
+	add := make([]string, 0)
+	rem := make([]string, 0)
+	for _, part := range splitAndTrim(s, ",") {
+		switch {
+		case strings.HasPrefix(part, "-"):
+			if id := trimSpace(part[1:]); id != "" {
+				rem = append(rem, id)
+			}
+		case strings.HasPrefix(part, "+"):
+			if id := trimSpace(part[1:]); id != "" {
+				add = append(add, id)
+			}
+		default:
+			add = append(add, part)
+		}
+	}
+	return map[string]interface{}{"add": add, "rem": rem}
+}
+
+// parseProgressValue parses a manual progress value ("45", "45%", "45,5") into the
+// {"current": n} object, clamped to 0-100
+func parseProgressValue(s, locale string) map[string]interface{} {
+	value, _, ok := parseLocaleNumber(s, locale)
+	if !ok {
+		value = 0
+	}
+	if value < 0 {
+		value = 0
+	}
+	if value > 100 {
+		value = 100
+	}
+	return map[string]interface{}{"current": value}
+}
+
+// parseRatingValue parses a rating/emoji field value into an integer count. Accepts
+// numbers (rounded), scores like "3/5" and repeated symbols like "⭐⭐⭐".
+func parseRatingValue(s, locale string) int64 {
+	s = trimSpace(s)
+	if i := strings.Index(s, "/"); i > 0 {
+		s = trimSpace(s[:i])
+	}
+	if value, _, ok := parseLocaleNumber(s, locale); ok {
+		if value < 0 {
+			return 0
+		}
+		return int64(value + 0.5)
+	}
+	if n, ok := countRepeatedSymbol(s); ok {
+		return int64(n)
+	}
+	return 0
+}
+
+// countRepeatedSymbol returns how many times a single symbol (ignoring spaces and
+// emoji variation selectors) repeats in s
+func countRepeatedSymbol(s string) (int, bool) {
+	var symbol rune
+	count := 0
+	for _, r := range s {
+		if r == ' ' || r == '\ufe0f' {
+			continue
+		}
+		if count > 0 && r != symbol {
+			return 0, false
+		}
+		symbol = r
+		count++
+	}
+	return count, count > 0
+}
+
 // splitAndTrim splits a string and trims whitespace from each part
 func splitAndTrim(s, sep string) []string {
 	parts := make([]string, 0)
@@ -662,7 +1465,7 @@ func (c *Client) doPostRequest(ctx context.Context, url string, body interface{}
 	req.Header.Set("Authorization", c.token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req, jsonBody)
 	if err != nil {
 		if ctx.Err() != nil {
 			return model.ErrTimeout
@@ -670,6 +1473,7 @@ func (c *Client) doPostRequest(ctx context.Context, url string, body interface{}
 		return fmt.Errorf("executar request: %w", err)
 	}
 	defer resp.Body.Close()
+	recordRateLimit(resp)
 
 	// Tratamento de erros HTTP
 	switch resp.StatusCode {
@@ -681,12 +1485,8 @@ func (c *Client) doPostRequest(ctx context.Context, url string, body interface{}
 		return model.ErrUnauthorized
 	case http.StatusNotFound:
 		return model.ErrNotFound
-	case http.StatusBadRequest:
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("bad request: %s", string(respBody))
 	default:
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+		return newClickUpError(resp)
 	}
 }
 
@@ -694,7 +1494,7 @@ func (c *Client) doPostRequest(ctx context.Context, url string, body interface{}
 func (c *Client) SetCustomFieldValueWithRetry(ctx context.Context, taskID, fieldID string, value interface{}, fieldType string) error {
 	var lastErr error
 
-	for attempt := 1; attempt <= RetryMaxAttempts; attempt++ {
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
 		err := c.SetCustomFieldValue(ctx, taskID, fieldID, value, fieldType)
 		if err == nil {
 			return nil
@@ -707,43 +1507,28 @@ func (c *Client) SetCustomFieldValueWithRetry(ctx context.Context, taskID, field
 			return err
 		}
 
-		// If it's rate limited, wait and retry
-		if err == model.ErrRateLimited {
-			logger.Get(ctx).Warn().
-				Str("task_id", taskID).
-				Str("field_id", fieldID).
-				Int("attempt", attempt).
-				Dur("backoff", RetryBackoff).
-				Msg("Rate limited, aguardando retry")
-
-			select {
-			case <-time.After(RetryBackoff):
-				continue
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-		}
-
-		// For other errors, don't retry
-		if err == model.ErrUnauthorized || err == model.ErrNotFound {
+		// Errors the user must fix (invalid token, missing task, rejected value) aren't retried
+		if !IsRetryable(err) {
 			return err
 		}
 
-		// For transient errors, retry with backoff
-		if attempt < RetryMaxAttempts {
+		// Rate limits and transient errors are retried with backoff
+		if attempt < c.retry.MaxAttempts {
+			backoff := c.retry.Backoff(attempt)
+			msg := "Tentativa falhou, aguardando retry"
+			if err == model.ErrRateLimited {
+				msg = "Rate limited, aguardando retry"
+			}
 			logger.Get(ctx).Warn().
 				Str("task_id", taskID).
 				Str("field_id", fieldID).
 				Int("attempt", attempt).
 				Err(err).
-				Dur("backoff", RetryBackoff).
-				Msg("Tentativa falhou, aguardando retry")
-
-			select {
-			case <-time.After(RetryBackoff):
-				continue
-			case <-ctx.Done():
-				return ctx.Err()
+				Dur("backoff", backoff).
+				Msg(msg)
+
+			if err := waitRetry(ctx, backoff); err != nil {
+				return err
 			}
 		}
 	}
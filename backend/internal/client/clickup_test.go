@@ -0,0 +1,768 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// mockTaskServer serves paginated tasks for a list, full pages of PageSize tasks and a
+// last page with one, recording requested pages and failing once with 429 at failPage
+// (no retry backoff for rate limit errors)
+type mockTaskServer struct {
+	mu        sync.Mutex
+	pages     int
+	failPage  int
+	failed    bool
+	requested []int
+}
+
+func (m *mockTaskServer) handler(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	m.mu.Lock()
+	m.requested = append(m.requested, page)
+	shouldFail := page == m.failPage && !m.failed
+	if shouldFail {
+		m.failed = true
+	}
+	m.mu.Unlock()
+
+	if shouldFail {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	resp := model.TaskResponse{LastPage: page == m.pages-1}
+	count := PageSize
+	if resp.LastPage {
+		count = 1
+	}
+	for i := 0; i < count; i++ {
+		resp.Tasks = append(resp.Tasks, model.Task{ID: fmt.Sprintf("task-%d-%d", page, i), Name: fmt.Sprintf("Task %d.%d", page, i)})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (m *mockTaskServer) takeRequested() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pages := m.requested
+	m.requested = nil
+	return pages
+}
+
+func newTestClient(serverURL string) *Client {
+	c := NewClient("pk_test")
+	c.baseURL = serverURL
+	return c
+}
+
+// TestGetTasksToStorageResumable verifies that a fetch failing at page N resumes at N, not 0
+func TestGetTasksToStorageResumable(t *testing.T) {
+	const failPage = 3
+	mock := &mockTaskServer{pages: 6, failPage: failPage}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	// Busca sequencial: a retomada é verificada pelas páginas exatas requisitadas
+	c := newTestClient(server.URL)
+	c.maxConcurrent = 1
+	ctx := context.Background()
+
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("criar storage: %v", err)
+	}
+
+	err = c.GetTasksToStorageResumable(ctx, []string{"list-1"}, storage, false, false, false, nil)
+	if !errors.Is(err, model.ErrIncompleteFetch) {
+		t.Fatalf("esperado ErrIncompleteFetch, obtido %v", err)
+	}
+	if got := storage.GetTaskCount(); got != failPage*PageSize {
+		t.Fatalf("esperado %d tasks antes da falha, obtido %d", failPage*PageSize, got)
+	}
+	if page, completed := storage.ResumePoint("list-1"); page != failPage || completed {
+		t.Fatalf("cursor = (%d, %v), esperado (%d, false)", page, completed, failPage)
+	}
+	mock.takeRequested()
+
+	// Retoma a partir de um storage reaberto, simulando reinício do processo
+	resumed, err := repository.OpenTaskStorage(storage.GetFilePath())
+	if err != nil {
+		t.Fatalf("reabrir storage: %v", err)
+	}
+	defer resumed.Close()
+
+	if err := c.GetTasksToStorageResumable(ctx, []string{"list-1"}, resumed, false, false, false, nil); err != nil {
+		t.Fatalf("retomada falhou: %v", err)
+	}
+
+	requested := mock.takeRequested()
+	if len(requested) == 0 || requested[0] != failPage {
+		t.Fatalf("retomada deveria começar na página %d, páginas requisitadas: %v", failPage, requested)
+	}
+	if len(requested) != mock.pages-failPage {
+		t.Errorf("esperado %d páginas na retomada, obtido %v", mock.pages-failPage, requested)
+	}
+
+	tasks, err := resumed.ReadAllTasks()
+	if err != nil {
+		t.Fatalf("ler tasks: %v", err)
+	}
+	if want := (mock.pages-1)*PageSize + 1; len(tasks) != want {
+		t.Fatalf("esperado %d tasks sem duplicatas, obtido %d", want, len(tasks))
+	}
+	for i, task := range tasks {
+		if want := fmt.Sprintf("task-%d-%d", i/PageSize, i%PageSize); task.ID != want {
+			t.Errorf("task %d = %s, esperado %s", i, task.ID, want)
+		}
+	}
+
+	// Lista concluída não é buscada novamente
+	if _, completed := resumed.ResumePoint("list-1"); !completed {
+		t.Error("lista deveria estar marcada como concluída")
+	}
+}
+
+// TestGetTasksToStorageStopsWhenCanceled verifies that canceling the context (client
+// disconnected or report canceled) stops the fetch loop instead of reading every page
+func TestGetTasksToStorageStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Lista com páginas infinitas; o cancelamento acontece ao servir a página 2
+	mock := &mockTaskServer{pages: 1 << 20, failPage: -1}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			cancel()
+		}
+		mock.handler(w, r)
+	}))
+	defer server.Close()
+
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("criar storage: %v", err)
+	}
+	defer storage.Close()
+
+	c := newTestClient(server.URL)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.GetTasksToStorage(ctx, []string{"list-1", "list-2"}, storage, false, false, false, nil)
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a coleta não parou após o cancelamento")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("esperado context.Canceled, obtido %v", err)
+	}
+	// No máximo a primeira página e uma janela de páginas paralelas
+	if requested := mock.takeRequested(); len(requested) > 1+c.maxConcurrent {
+		t.Errorf("páginas requisitadas após o cancelamento: %v", requested)
+	}
+	if _, completed := storage.ResumePoint("list-2"); completed {
+		t.Error("list-2 não deveria ter sido coletada")
+	}
+}
+
+// fullPageServer serves a list of `pages` pages: full pages of PageSize tasks followed
+// by a short last page, recording how many times each page was requested. With
+// noLastPage the last page is only recognizable by its size.
+type fullPageServer struct {
+	mu         sync.Mutex
+	pages      int
+	noLastPage bool
+	requested  map[int]int
+}
+
+func (s *fullPageServer) handler(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	s.mu.Lock()
+	s.requested[page]++
+	s.mu.Unlock()
+
+	resp := model.TaskResponse{LastPage: !s.noLastPage && page >= s.pages-1}
+	count := PageSize
+	if page == s.pages-1 {
+		count = 3
+	} else if page >= s.pages {
+		count = 0
+	}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("task-%d-%d", page, i)
+		resp.Tasks = append(resp.Tasks, model.Task{ID: id, Name: id})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// TestGetTasksToStorageConcurrentPages verifies that fetching pages in parallel stores
+// the same tasks, in the same order, as the sequential path
+func TestGetTasksToStorageConcurrentPages(t *testing.T) {
+	const pages = 12
+
+	collect := func(maxConcurrent int, noLastPage bool) ([]string, *fullPageServer) {
+		mock := &fullPageServer{pages: pages, noLastPage: noLastPage, requested: map[int]int{}}
+		server := httptest.NewServer(http.HandlerFunc(mock.handler))
+		defer server.Close()
+
+		storage, err := repository.NewTaskStorage()
+		if err != nil {
+			t.Fatalf("criar storage: %v", err)
+		}
+		defer storage.Close()
+
+		c := newTestClient(server.URL)
+		c.maxConcurrent = maxConcurrent
+		c.limiter = rate.NewLimiter(rate.Every(5*time.Millisecond), 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.GetTasksToStorageResumable(ctx, []string{"list-1"}, storage, false, false, false, nil); err != nil {
+			t.Fatalf("maxConcurrent %d: %v", maxConcurrent, err)
+		}
+		if _, completed := storage.ResumePoint("list-1"); !completed {
+			t.Errorf("maxConcurrent %d: lista deveria estar concluída", maxConcurrent)
+		}
+
+		tasks, err := storage.ReadAllTasks()
+		if err != nil {
+			t.Fatalf("ler tasks: %v", err)
+		}
+		ids := make([]string, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		return ids, mock
+	}
+
+	sequential, seqMock := collect(1, false)
+	if len(sequential) != (pages-1)*PageSize+3 {
+		t.Fatalf("busca sequencial: %d tasks", len(sequential))
+	}
+	for page := 0; page < pages; page++ {
+		if seqMock.requested[page] != 1 {
+			t.Fatalf("busca sequencial: página %d requisitada %d vezes", page, seqMock.requested[page])
+		}
+	}
+
+	// Sem last_page, a página curta encerra a lista; a busca sequencial não passa dela
+	short, shortMock := collect(1, true)
+	if strings.Join(short, ",") != strings.Join(sequential, ",") || len(shortMock.requested) != pages {
+		t.Fatalf("página curta sem last_page: %d tasks, %d páginas requisitadas", len(short), len(shortMock.requested))
+	}
+
+	const maxConcurrent = 4
+	for _, noLastPage := range []bool{false, true} {
+		concurrent, mock := collect(maxConcurrent, noLastPage)
+		if strings.Join(concurrent, ",") != strings.Join(sequential, ",") {
+			t.Fatalf("busca paralela (sem last_page: %v) difere da sequencial: %d tasks, esperado %d",
+				noLastPage, len(concurrent), len(sequential))
+		}
+
+		// Cada página é buscada uma vez. Requisições já liberadas podem passar da última
+		// página, no máximo até o fim da janela em curso (e não são gravadas)
+		for page, n := range mock.requested {
+			if page >= pages+maxConcurrent-1 || n != 1 {
+				t.Errorf("sem last_page %v: página %d requisitada %d vezes", noLastPage, page, n)
+			}
+		}
+		for page := 0; page < pages; page++ {
+			if mock.requested[page] != 1 {
+				t.Errorf("sem last_page %v: página %d não requisitada", noLastPage, page)
+			}
+		}
+	}
+}
+
+// TestSetCustomFieldValueCustomTaskIDs verifies the query params sent for custom task IDs
+func TestSetCustomFieldValueCustomTaskIDs(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	if err := c.SetCustomFieldValue(context.Background(), "86abc", "field-1", "x", "text"); err != nil {
+		t.Fatalf("SetCustomFieldValue: %v", err)
+	}
+
+	c.SetCustomTaskIDs("9001")
+	if err := c.SetCustomFieldValue(context.Background(), "PROJ-123", "field-1", "x", "text"); err != nil {
+		t.Fatalf("SetCustomFieldValue com ID personalizado: %v", err)
+	}
+
+	expected := []string{
+		"/task/86abc/field/field-1",
+		"/task/PROJ-123/field/field-1?custom_task_ids=true&team_id=9001",
+	}
+	if strings.Join(requests, " ") != strings.Join(expected, " ") {
+		t.Errorf("requisições = %v, esperado %v", requests, expected)
+	}
+}
+
+// TestGetTasksToStorageSamplesPageLogs collects two lists of 100 pages, the first one
+// failing at page 50, and checks sampling cuts the per-page lines while the failure
+// and the per-list summaries are always logged
+func TestGetTasksToStorageSamplesPageLogs(t *testing.T) {
+	defer logger.SetSampleEvery(logger.DefaultSampleEvery)
+
+	collect := func(sampleEvery int) string {
+		logger.SetSampleEvery(sampleEvery)
+		mock := &mockTaskServer{pages: 100, failPage: 50}
+		server := httptest.NewServer(http.HandlerFunc(mock.handler))
+		defer server.Close()
+
+		storage, err := repository.NewTaskStorage()
+		if err != nil {
+			t.Fatalf("criar storage: %v", err)
+		}
+		defer storage.Close()
+
+		var buf bytes.Buffer
+		l := zerolog.New(&buf)
+		ctx := context.WithValue(context.Background(), logger.LoggerKey, &l)
+		c := newTestClient(server.URL)
+		c.maxConcurrent = 1
+		c.limiter = rate.NewLimiter(rate.Inf, 1)
+		if err := c.GetTasksToStorage(ctx, []string{"list-1", "list-2"}, storage, false, false, false, nil); err != nil {
+			t.Fatalf("GetTasksToStorage: %v", err)
+		}
+		return buf.String()
+	}
+
+	full := collect(1)
+	sampled := collect(10)
+
+	fullPages := strings.Count(full, "Tasks coletadas")
+	sampledPages := strings.Count(sampled, "Tasks coletadas")
+	if fullPages != 150 {
+		t.Fatalf("sem amostragem: %d linhas de página, esperado 150", fullPages)
+	}
+	if sampledPages == 0 || sampledPages > fullPages/5 {
+		t.Errorf("com amostragem: %d linhas de página (sem amostragem: %d)", sampledPages, fullPages)
+	}
+
+	for _, msg := range []string{"Falha na lista", "Lista concluída", "Todas as listas processadas"} {
+		if strings.Count(sampled, msg) != strings.Count(full, msg) || !strings.Contains(sampled, msg) {
+			t.Errorf("%q: %d linhas com amostragem, %d sem", msg, strings.Count(sampled, msg), strings.Count(full, msg))
+		}
+	}
+}
+
+// TestGetTasksToStorageIgnoresIncompleteLists keeps the lenient behaviour of GetTasksToStorage
+func TestGetTasksToStorageIgnoresIncompleteLists(t *testing.T) {
+	mock := &mockTaskServer{pages: 3, failPage: 1}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("criar storage: %v", err)
+	}
+	defer storage.Close()
+
+	if err := newTestClient(server.URL).GetTasksToStorage(context.Background(), []string{"list-1"}, storage, false, false, false, nil); err != nil {
+		t.Fatalf("GetTasksToStorage retornou erro: %v", err)
+	}
+	if got := storage.GetTaskCount(); got != PageSize {
+		t.Errorf("esperado %d tasks coletadas antes da falha, obtido %d", PageSize, got)
+	}
+}
+
+// TestGetTasksToStorageTagsSourceList fetches two lists into one storage and checks
+// every task read back carries the list it was fetched from
+func TestGetTasksToStorageTagsSourceList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /list/{id}/task: two tasks per list, all with the same home list
+		listID := strings.Split(strings.TrimPrefix(r.URL.Path, "/list/"), "/")[0]
+		json.NewEncoder(w).Encode(model.TaskResponse{
+			Tasks: []model.Task{
+				{ID: listID + "-a", List: model.ListInfo{ID: "home"}},
+				{ID: listID + "-b", List: model.ListInfo{ID: "home"}},
+			},
+			LastPage: true,
+		})
+	}))
+	defer server.Close()
+
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("criar storage: %v", err)
+	}
+	defer storage.Close()
+
+	if err := newTestClient(server.URL).GetTasksToStorage(context.Background(), []string{"L1", "L2"}, storage, false, false, false, nil); err != nil {
+		t.Fatalf("GetTasksToStorage: %v", err)
+	}
+
+	tasks, err := storage.ReadAllTasks()
+	if err != nil {
+		t.Fatalf("ReadAllTasks: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("esperadas 4 tasks, obtido %d", len(tasks))
+	}
+	for _, task := range tasks {
+		want := strings.Split(task.ID, "-")[0]
+		if task.SourceListID != want {
+			t.Errorf("task %s com lista de origem %q, esperado %q", task.ID, task.SourceListID, want)
+		}
+	}
+}
+
+// TestBuildTaskURLFilters verifies the ClickUp query string built for report filters
+func TestBuildTaskURLFilters(t *testing.T) {
+	c := newTestClient("http://clickup.test")
+
+	tests := []struct {
+		name    string
+		filters *model.TaskFilters
+		want    string
+	}{
+		{
+			name: "no filters",
+			want: "http://clickup.test/list/L1/task?page=2&subtasks=true&include_closed=false",
+		},
+		{
+			name:    "statuses with spaces",
+			filters: &model.TaskFilters{Statuses: []string{"to do", "in progress"}},
+			want:    "http://clickup.test/list/L1/task?page=2&subtasks=true&include_closed=false&statuses%5B%5D=to+do&statuses%5B%5D=in+progress",
+		},
+		{
+			name: "all filters",
+			filters: &model.TaskFilters{
+				Statuses:      []string{"done"},
+				Assignees:     []string{"123", "456"},
+				DateUpdatedGt: 1700000000000,
+				DateUpdatedLt: 1710000000000,
+			},
+			want: "http://clickup.test/list/L1/task?page=2&subtasks=true&include_closed=false&statuses%5B%5D=done&assignees%5B%5D=123&assignees%5B%5D=456&date_updated_gt=1700000000000&date_updated_lt=1710000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.buildTaskURL("L1", 2, true, false, tt.filters); got != tt.want {
+				t.Errorf("buildTaskURL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetTasksSendsFilters asserts the mock server receives the filter query params
+func TestGetTasksSendsFilters(t *testing.T) {
+	var received []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.URL.RawQuery)
+		mu.Unlock()
+
+		q := r.URL.Query()
+		if got := q["statuses[]"]; len(got) != 2 || got[0] != "open" || got[1] != "em revisão" {
+			t.Errorf("statuses[] = %v", got)
+		}
+		if got := q["assignees[]"]; len(got) != 1 || got[0] != "42" {
+			t.Errorf("assignees[] = %v", got)
+		}
+		if got := q.Get("date_updated_gt"); got != "1700000000000" {
+			t.Errorf("date_updated_gt = %s", got)
+		}
+		if q.Has("date_updated_lt") {
+			t.Error("date_updated_lt não deveria ser enviado quando zero")
+		}
+		json.NewEncoder(w).Encode(model.TaskResponse{LastPage: true})
+	}))
+	defer server.Close()
+
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("criar storage: %v", err)
+	}
+	defer storage.Close()
+
+	filters := &model.TaskFilters{
+		Statuses:      []string{"open", "em revisão"},
+		Assignees:     []string{"42"},
+		DateUpdatedGt: 1700000000000,
+	}
+	if err := newTestClient(server.URL).GetTasksToStorage(context.Background(), []string{"L1", "L2"}, storage, false, true, false, filters); err != nil {
+		t.Fatalf("GetTasksToStorage: %v", err)
+	}
+	if len(received) != 2 {
+		t.Errorf("esperado 1 request por lista, obtido %d", len(received))
+	}
+}
+
+// TestTaskFiltersValidate covers the filter combinations accepted by ClickUp
+func TestTaskFiltersValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters model.TaskFilters
+		wantErr bool
+	}{
+		{"empty", model.TaskFilters{}, false},
+		{"statuses and assignees", model.TaskFilters{Statuses: []string{"open"}, Assignees: []string{"1"}}, false},
+		{"only lower bound", model.TaskFilters{DateUpdatedGt: 1}, false},
+		{"date range", model.TaskFilters{DateUpdatedGt: 1, DateUpdatedLt: 2}, false},
+		{"blank status", model.TaskFilters{Statuses: []string{" "}}, true},
+		{"non numeric assignee", model.TaskFilters{Assignees: []string{"joao@x.com"}}, true},
+		{"negative date", model.TaskFilters{DateUpdatedLt: -1}, true},
+		{"inverted range", model.TaskFilters{DateUpdatedGt: 5, DateUpdatedLt: 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filters.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// mockActivityServer serves comments (paginated by start_id) and task details with
+// attachments, tracking the peak number of concurrent requests
+type mockActivityServer struct {
+	mu       sync.Mutex
+	comments int
+	inFlight int
+	peak     int
+	requests int
+}
+
+func (m *mockActivityServer) handler(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.inFlight++
+	m.requests++
+	if m.inFlight > m.peak {
+		m.peak = m.inFlight
+	}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+
+	if strings.HasSuffix(r.URL.Path, "/comment") {
+		start := 0
+		if id := r.URL.Query().Get("start_id"); id != "" {
+			start, _ = strconv.Atoi(id)
+			start++
+		}
+		var resp model.CommentResponse
+		for i := start; i < m.comments && len(resp.Comments) < commentPageSize; i++ {
+			resp.Comments = append(resp.Comments, model.Comment{ID: strconv.Itoa(i), Date: strconv.Itoa(1000 - i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	json.NewEncoder(w).Encode(model.TaskDetailResponse{
+		ID:          strings.TrimPrefix(r.URL.Path, "/task/"),
+		Attachments: []model.Attachment{{ID: "a1", Title: "contrato.pdf"}, {ID: "a2", Title: "foto.png"}},
+	})
+}
+
+// TestGetTaskCommentsPaginates follows start/start_id until a short page is returned
+func TestGetTaskCommentsPaginates(t *testing.T) {
+	mock := &mockActivityServer{comments: 30}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	comments, err := newTestClient(server.URL).GetTaskComments(context.Background(), "task-1")
+	if err != nil {
+		t.Fatalf("GetTaskComments: %v", err)
+	}
+	if len(comments) != 30 {
+		t.Errorf("esperado 30 comentários, obtido %d", len(comments))
+	}
+	if mock.requests != 2 {
+		t.Errorf("esperado 2 requests, obtido %d", mock.requests)
+	}
+}
+
+// TestEnrichTaskActivity fills counts for every task without exceeding DefaultMaxConcurrentRequests
+func TestEnrichTaskActivity(t *testing.T) {
+	mock := &mockActivityServer{comments: 3}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	tasks := make([]model.Task, 20)
+	for i := range tasks {
+		tasks[i].ID = fmt.Sprintf("task-%d", i)
+	}
+
+	if err := newTestClient(server.URL).EnrichTaskActivity(context.Background(), tasks); err != nil {
+		t.Fatalf("EnrichTaskActivity: %v", err)
+	}
+
+	for _, task := range tasks {
+		if task.CommentCount == nil || *task.CommentCount != 3 {
+			t.Errorf("%s: CommentCount = %v", task.ID, task.CommentCount)
+		}
+		if task.AttachmentCount == nil || *task.AttachmentCount != 2 {
+			t.Errorf("%s: AttachmentCount = %v", task.ID, task.AttachmentCount)
+		}
+	}
+	if mock.peak > DefaultMaxConcurrentRequests {
+		t.Errorf("pico de %d requests simultâneas, limite %d", mock.peak, DefaultMaxConcurrentRequests)
+	}
+	if mock.peak < 2 {
+		t.Errorf("esperado requests concorrentes, pico %d", mock.peak)
+	}
+}
+
+// TestActivityCallsUseRateLimiter ensures the per-task calls wait on the client limiter
+func TestActivityCallsUseRateLimiter(t *testing.T) {
+	mock := &mockActivityServer{}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.limiter = rate.NewLimiter(rate.Limit(1), 0) // burst 0: toda espera falha
+
+	if _, err := c.GetTaskComments(context.Background(), "task-1"); err == nil {
+		t.Error("GetTaskComments deveria falhar no rate limiter")
+	}
+	if _, err := c.GetTaskAttachments(context.Background(), "task-1"); err == nil {
+		t.Error("GetTaskAttachments deveria falhar no rate limiter")
+	}
+	if mock.requests != 0 {
+		t.Errorf("nenhuma request deveria chegar ao servidor, obtido %d", mock.requests)
+	}
+}
+
+// TestNewClientWithOptions checks that options reach the http.Client and its Transport
+func TestNewClientWithOptions(t *testing.T) {
+	c := NewClientWithOptions("pk_test", ClientOptions{
+		Timeout:               15 * time.Second,
+		MaxIdleConns:          40,
+		MaxIdleConnsPerHost:   20,
+		MaxConcurrentRequests: 8,
+	})
+
+	if c.httpClient.Timeout != 15*time.Second {
+		t.Errorf("Timeout = %v, esperado 15s", c.httpClient.Timeout)
+	}
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport inesperado: %T", c.httpClient.Transport)
+	}
+	if transport.MaxIdleConns != 40 || transport.MaxIdleConnsPerHost != 20 {
+		t.Errorf("pool = %d/%d, esperado 40/20", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if c.maxConcurrent != 8 {
+		t.Errorf("maxConcurrent = %d, esperado 8", c.maxConcurrent)
+	}
+
+	// Campos zerados usam os padrões, como em NewClient
+	d := NewClientWithOptions("pk_test", ClientOptions{Timeout: time.Second})
+	transport = d.httpClient.Transport.(*http.Transport)
+	if d.httpClient.Timeout != time.Second || transport.MaxIdleConns != DefaultMaxIdleConns ||
+		transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || d.maxConcurrent != DefaultMaxConcurrentRequests {
+		t.Errorf("padrões não aplicados: timeout %v, pool %d/%d, concorrência %d",
+			d.httpClient.Timeout, transport.MaxIdleConns, transport.MaxIdleConnsPerHost, d.maxConcurrent)
+	}
+}
+
+// TestEnrichTaskActivityRespectsMaxConcurrentRequests limits workers to the configured option
+func TestEnrichTaskActivityRespectsMaxConcurrentRequests(t *testing.T) {
+	mock := &mockActivityServer{}
+	server := httptest.NewServer(http.HandlerFunc(mock.handler))
+	defer server.Close()
+
+	c := NewClientWithOptions("pk_test", ClientOptions{MaxConcurrentRequests: 1})
+	c.baseURL = server.URL
+
+	tasks := make([]model.Task, 6)
+	for i := range tasks {
+		tasks[i].ID = fmt.Sprintf("task-%d", i)
+	}
+	if err := c.EnrichTaskActivity(context.Background(), tasks); err != nil {
+		t.Fatalf("EnrichTaskActivity: %v", err)
+	}
+	if mock.peak > 1 {
+		t.Errorf("pico de %d requests simultâneas, limite 1", mock.peak)
+	}
+}
+
+// TestRateLimitHeadersUpdateMetrics feeds X-RateLimit-* headers into the metrics gauges
+func TestRateLimitHeadersUpdateMetrics(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.Write([]byte(`{"teams":[]}`))
+	}))
+	defer server.Close()
+
+	if _, err := newTestClient(server.URL).GetWorkspaces(context.Background()); err != nil {
+		t.Fatalf("GetWorkspaces: %v", err)
+	}
+
+	snapshot := metrics.Get().Snapshot()
+	if snapshot.ClickUpRateLimit.Limit != 100 || snapshot.ClickUpRateLimit.Remaining != 42 {
+		t.Errorf("limite %d, restante %d; esperado 100 e 42", snapshot.ClickUpRateLimit.Limit, snapshot.ClickUpRateLimit.Remaining)
+	}
+	if want := time.Unix(reset, 0).UTC().Format(time.RFC3339); snapshot.ClickUpRateLimit.ResetAt != want {
+		t.Errorf("reset_at = %q, esperado %q", snapshot.ClickUpRateLimit.ResetAt, want)
+	}
+	if snapshot.ClickUpRateLimit.ObservedAt == "" {
+		t.Error("observed_at deveria ser preenchido")
+	}
+
+	var out strings.Builder
+	if err := metrics.Get().WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	if !strings.Contains(out.String(), "\nclickup_rate_limit_remaining 42\n") {
+		t.Errorf("saída Prometheus sem o gauge de restante:\n%s", out.String())
+	}
+}
+
+// TestPostRequestErrorRedactsSecrets verifies that error bodies echoing secrets are masked
+func TestPostRequestErrorRedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"err":"invalid","token":"pk_echoed"}`))
+	}))
+	defer server.Close()
+
+	err := newTestClient(server.URL).doPostRequest(context.Background(), server.URL+"/task/t1/field/f1", map[string]string{"value": "x"})
+	if err == nil {
+		t.Fatal("esperado erro 400")
+	}
+	if strings.Contains(err.Error(), "pk_echoed") || !strings.Contains(err.Error(), `"token":"[REDACTED]"`) {
+		t.Errorf("erro = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+)
+
+// debugBodyLimit tamanho máximo dos corpos de requisição e resposta no log de debug
+const debugBodyLimit = 2048
+
+// redacted substitui valores sensíveis no log de debug
+const redacted = "[REDACTED]"
+
+// do executa a requisição; com ClientOptions.Debug registra método, URL, corpo
+// enviado, status e o início do corpo da resposta. O header Authorization e o
+// token nunca aparecem no log.
+func (c *Client) do(ctx context.Context, req *http.Request, body []byte) (*http.Response, error) {
+	if !c.debug {
+		return c.httpClient.Do(req)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+
+	event := logger.Get(ctx).Info().
+		Str("method", req.Method).
+		Str("url", c.sanitize(req.URL.String())).
+		Str("authorization", redacted)
+	if len(body) > 0 {
+		event = event.Str("request_body", c.sanitize(truncateBody(body)))
+	}
+	event = event.Dur("duration", time.Since(start))
+
+	if err != nil {
+		event.Err(err).Msg("ClickUp request falhou")
+		return nil, err
+	}
+
+	// Lê o corpo para o log e o devolve intacto para quem chamou
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr != nil {
+		event = event.AnErr("read_error", readErr)
+	}
+
+	event.
+		Int("status", resp.StatusCode).
+		Str("response_body", c.sanitize(truncateBody(respBody))).
+		Msg("ClickUp request")
+	return resp, nil
+}
+
+// sanitize remove o token do cliente e os campos sensíveis de um texto que vai
+// para o log
+func (c *Client) sanitize(s string) string {
+	if c.token != "" {
+		s = strings.ReplaceAll(s, c.token, redacted)
+	}
+	return logger.Redact(s)
+}
+
+// truncateBody limita um corpo a debugBodyLimit bytes para o log
+func truncateBody(body []byte) string {
+	if len(body) <= debugBodyLimit {
+		return string(body)
+	}
+	return string(body[:debugBodyLimit]) + "...(truncado)"
+}
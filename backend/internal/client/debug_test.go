@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+func TestDebugLoggingRedactsToken(t *testing.T) {
+	const token = "pk_secret_token_123"
+
+	// O servidor ecoa o Authorization recebido, como algumas mensagens de erro fazem
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != token {
+			t.Errorf("Authorization enviado = %q", r.Header.Get("Authorization"))
+		}
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"err": "Value is not valid", "token": "` + r.Header.Get("Authorization") + `"}`))
+			return
+		}
+		w.Write([]byte(`{"tasks": [{"id": "t1", "name": "Task 1"}], "last_page": true}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	log := zerolog.New(&buf)
+	ctx := context.WithValue(context.Background(), logger.LoggerKey, &log)
+
+	c := NewClientWithOptions(token, ClientOptions{BaseURL: server.URL, Debug: true})
+	if _, err := c.doRequest(ctx, server.URL+"/list/L1/task?page=0"); err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	if err := c.SetCustomFieldValue(ctx, "t1", "f1", "abc", "short_text"); err == nil {
+		t.Fatal("esperado erro 400 do servidor")
+	}
+
+	out := buf.String()
+	if strings.Contains(out, token) {
+		t.Fatalf("token registrado no log de debug:\n%s", out)
+	}
+	for _, want := range []string{
+		`"url":"` + server.URL + `/list/L1/task?page=0"`,
+		`"method":"POST"`,
+		`"status":400`,
+		`"request_body":"{\"value\":\"abc\"}"`,
+		`Value is not valid`,
+		`"authorization":"[REDACTED]"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log de debug sem %s:\n%s", want, out)
+		}
+	}
+
+	// Sem debug nada é registrado por requisição
+	buf.Reset()
+	quiet := NewClientWithOptions(token, ClientOptions{BaseURL: server.URL})
+	if _, err := quiet.doRequest(ctx, server.URL+"/list/L1/task?page=0"); err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("log sem debug: %s", buf.String())
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), debugBodyLimit+10)
+	got := truncateBody(body)
+	if !strings.HasPrefix(got, strings.Repeat("a", debugBodyLimit)) || !strings.HasSuffix(got, "...(truncado)") {
+		t.Errorf("truncateBody = %d bytes", len(got))
+	}
+	if truncateBody([]byte("curto")) != "curto" {
+		t.Error("corpo curto não deveria ser truncado")
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+// maxErrorBody limita o corpo de erro lido do ClickUp
+const maxErrorBody = 64 << 10
+
+// ClickUpError é a resposta de erro do ClickUp com um status sem erro sentinela
+// (429, 401 e 404 continuam sendo model.ErrRateLimited, ErrUnauthorized e ErrNotFound).
+// Use errors.As para ler o status e o código do ClickUp.
+type ClickUpError struct {
+	StatusCode int
+	Code       string // campo "ECODE" da resposta, ex.: "FIELD_033" (vazio se ausente)
+	Message    string // campo "err" da resposta (vazio se ausente)
+	Raw        string // corpo da resposta, com segredos mascarados
+}
+
+// Error mantém o formato "status N: corpo" usado nos detalhes dos jobs
+func (e *ClickUpError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Raw)
+}
+
+// Retryable indica falha transitória do ClickUp (5xx, 408, 429), que pode dar certo
+// numa nova tentativa; os demais 4xx dependem de corrigir o valor ou a configuração
+func (e *ClickUpError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Is faz errors.Is(err, model.ErrRateLimited/ErrUnauthorized/ErrNotFound) valer também
+// para um ClickUpError com o status correspondente
+func (e *ClickUpError) Is(target error) bool {
+	switch target {
+	case model.ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case model.ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case model.ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// newClickUpError lê o corpo de uma resposta de erro; o formato do ClickUp é
+// {"err": "mensagem", "ECODE": "CODIGO"}, mas qualquer corpo é aceito em Raw
+func newClickUpError(resp *http.Response) *ClickUpError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	clickupErr := &ClickUpError{
+		StatusCode: resp.StatusCode,
+		Raw:        logger.Redact(string(body)),
+	}
+
+	var parsed struct {
+		Err   string `json:"err"`
+		ECode string `json:"ECODE"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		clickupErr.Code = parsed.ECode
+		clickupErr.Message = parsed.Err
+	}
+	return clickupErr
+}
+
+// IsRetryable indica se vale tentar de novo uma chamada que falhou com err: rate limit,
+// timeout, falhas de rede e ClickUpError transitórios. Token inválido, recurso
+// inexistente e demais 4xx não mudam com novas tentativas.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var clickupErr *ClickUpError
+	if errors.As(err, &clickupErr) {
+		return clickupErr.Retryable()
+	}
+	if errors.Is(err, model.ErrUnauthorized) || errors.Is(err, model.ErrNotFound) {
+		return false
+	}
+	return true
+}
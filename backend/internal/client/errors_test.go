@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+func TestClickUpErrorCarriesStatusAndCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"err":"Value is not a valid option","ECODE":"FIELD_033"}`))
+	}))
+	defer server.Close()
+
+	err := newTestClient(server.URL).doPostRequest(context.Background(), server.URL+"/task/t1/field/f1", map[string]string{"value": "x"})
+
+	var clickupErr *ClickUpError
+	if !errors.As(err, &clickupErr) {
+		t.Fatalf("esperado *ClickUpError, obtido %T: %v", err, err)
+	}
+	if clickupErr.StatusCode != http.StatusBadRequest || clickupErr.Code != "FIELD_033" || clickupErr.Message != "Value is not a valid option" {
+		t.Errorf("erro = %+v", clickupErr)
+	}
+	if clickupErr.Retryable() || IsRetryable(err) {
+		t.Error("400 depende de corrigir o valor, não deveria ter retry")
+	}
+}
+
+func TestClickUpErrorFromGetRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`upstream unavailable`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	_, err := c.doRequest(context.Background(), server.URL+"/list/1/task")
+	var clickupErr *ClickUpError
+	if !errors.As(err, &clickupErr) || clickupErr.StatusCode != http.StatusBadGateway || clickupErr.Raw != "upstream unavailable" {
+		t.Fatalf("doRequest: erro = %v", err)
+	}
+	if clickupErr.Code != "" || !IsRetryable(err) {
+		t.Errorf("502 sem JSON: esperado sem código e com retry, obtido %+v", clickupErr)
+	}
+
+	var out struct{}
+	if err := c.doGenericRequest(context.Background(), server.URL+"/team", &out); !errors.As(err, &clickupErr) {
+		t.Errorf("doGenericRequest: esperado *ClickUpError, obtido %v", err)
+	}
+}
+
+func TestClickUpErrorMatchesSentinels(t *testing.T) {
+	err := &ClickUpError{StatusCode: http.StatusNotFound}
+	if !errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrUnauthorized) {
+		t.Error("errors.Is deveria seguir o status")
+	}
+	if IsRetryable(model.ErrUnauthorized) || IsRetryable(model.ErrNotFound) || !IsRetryable(model.ErrRateLimited) {
+		t.Error("classificação dos erros sentinela incorreta")
+	}
+}
+
+func TestSetCustomFieldValueDoesNotRetryUserErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"err":"Invalid value","ECODE":"FIELD_012"}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	err := c.SetCustomFieldValueWithRetry(context.Background(), "t1", "f1", "x", "text")
+	if err == nil || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("esperado 1 chamada sem retry, obtido %d (%v)", calls, err)
+	}
+}
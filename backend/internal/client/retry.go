@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controla as novas tentativas após falhas transitórias da API.
+// A espera antes da tentativa n+1 é InitialBackoff * Multiplier^(n-1), limitada
+// a MaxBackoff e variada aleatoriamente em ±Jitter.
+type RetryPolicy struct {
+	MaxAttempts    int           // tentativas no total, incluindo a primeira (padrão RetryMaxAttempts)
+	InitialBackoff time.Duration // espera após a primeira falha (padrão RetryBackoff)
+	Multiplier     float64       // crescimento da espera a cada falha (padrão 1 = espera fixa)
+	MaxBackoff     time.Duration // espera máxima (padrão DefaultMaxBackoff)
+	Jitter         float64       // fração da espera variada aleatoriamente, de 0 a 1 (padrão 0)
+}
+
+// DefaultMaxBackoff limita a espera quando MaxBackoff não é informado, evitando
+// que o crescimento exponencial estoure a duração
+const DefaultMaxBackoff = 5 * time.Minute
+
+// DefaultRetryPolicy retorna a política padrão: RetryMaxAttempts tentativas com
+// espera fixa de RetryBackoff
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    RetryMaxAttempts,
+		InitialBackoff: RetryBackoff,
+		Multiplier:     1,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+// withDefaults preenche os campos não informados com os padrões
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaults.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// Backoff retorna a espera antes da próxima tentativa, após a falha da tentativa
+// attempt (a partir de 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	return p.backoff(attempt, rand.Float64())
+}
+
+// backoff calcula a espera com o valor aleatório random (0 a 1) usado no jitter
+func (p RetryPolicy) backoff(attempt int, random float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	// random 0..1 vira um fator entre 1-Jitter e 1+Jitter
+	delay *= 1 + p.Jitter*(2*random-1)
+	return time.Duration(delay)
+}
+
+// SetRetryPolicy substitui a política de novas tentativas do cliente; campos
+// zerados usam os padrões
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy.withDefaults()
+}
+
+// RetryPolicy retorna a política de novas tentativas em uso
+func (c *Client) RetryPolicy() RetryPolicy {
+	return c.retry
+}
+
+// waitRetry aguarda delay antes da próxima tentativa, retornando ctx.Err() se o
+// contexto for cancelado antes
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		Multiplier:     2,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+	}.withDefaults()
+
+	tests := []struct {
+		attempt int
+		random  float64
+		want    time.Duration
+	}{
+		{1, 0.5, time.Second},
+		{2, 0.5, 2 * time.Second},
+		{3, 0.5, 4 * time.Second},
+		{4, 0.5, 5 * time.Second}, // limitado a MaxBackoff
+		{1, 0, 800 * time.Millisecond},
+		{1, 1, 1200 * time.Millisecond},
+		{4, 1, 6 * time.Second}, // jitter aplicado sobre o limite
+	}
+	for _, tt := range tests {
+		if got := policy.backoff(tt.attempt, tt.random); got != tt.want {
+			t.Errorf("backoff(%d, %.1f) = %v, esperado %v", tt.attempt, tt.random, got, tt.want)
+		}
+	}
+
+	// Sem MaxBackoff, o crescimento exponencial é limitado a DefaultMaxBackoff sem estourar
+	unbounded := RetryPolicy{InitialBackoff: time.Second, Multiplier: 10}.withDefaults()
+	for _, attempt := range []int{10, 100, 10000} {
+		if got := unbounded.backoff(attempt, 0.5); got != DefaultMaxBackoff {
+			t.Errorf("backoff(%d) sem MaxBackoff = %v, esperado %v", attempt, got, DefaultMaxBackoff)
+		}
+	}
+
+	// O padrão mantém a espera fixa de RetryBackoff
+	defaults := RetryPolicy{}.withDefaults()
+	if defaults.MaxAttempts != RetryMaxAttempts {
+		t.Errorf("MaxAttempts padrão = %d", defaults.MaxAttempts)
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		if got := defaults.Backoff(attempt); got != RetryBackoff {
+			t.Errorf("Backoff(%d) padrão = %v, esperado %v", attempt, got, RetryBackoff)
+		}
+	}
+}
+
+// statusSequenceServer responde com os status informados, em ordem, repetindo o último
+func statusSequenceServer(statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+		w.WriteHeader(statuses[n-1])
+		w.Write([]byte(`{"tasks": [], "last_page": true}`))
+	}))
+	return server, &calls
+}
+
+func TestRetryPolicyAppliedToRequests(t *testing.T) {
+	quick := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		server, calls := statusSequenceServer(http.StatusBadGateway, http.StatusBadGateway, http.StatusOK)
+		defer server.Close()
+
+		c := newTestClient(server.URL)
+		c.SetRetryPolicy(quick)
+		if err := c.SetCustomFieldValueWithRetry(context.Background(), "t1", "f1", "x", "text"); err != nil {
+			t.Fatalf("SetCustomFieldValueWithRetry: %v", err)
+		}
+		if *calls != 3 {
+			t.Errorf("requisições = %d, esperado 3", *calls)
+		}
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		server, calls := statusSequenceServer(http.StatusBadGateway)
+		defer server.Close()
+
+		c := newTestClient(server.URL)
+		c.SetRetryPolicy(quick)
+		if _, err := c.doRequestWithRetry(context.Background(), server.URL+"/list/L1/task?page=0", "L1", 0); err == nil {
+			t.Fatal("esperado erro após esgotar as tentativas")
+		}
+		if *calls != 3 {
+			t.Errorf("requisições = %d, esperado 3", *calls)
+		}
+	})
+
+	for _, tt := range []struct {
+		status int
+		err    error
+	}{
+		{http.StatusUnauthorized, model.ErrUnauthorized},
+		{http.StatusNotFound, model.ErrNotFound},
+	} {
+		server, calls := statusSequenceServer(tt.status)
+
+		c := newTestClient(server.URL)
+		c.SetRetryPolicy(quick)
+		if err := c.SetCustomFieldValueWithRetry(context.Background(), "t1", "f1", "x", "text"); !errors.Is(err, tt.err) {
+			t.Errorf("status %d: SetCustomFieldValueWithRetry = %v, esperado %v", tt.status, err, tt.err)
+		}
+		if _, err := c.doRequestWithRetry(context.Background(), server.URL+"/list/L1/task?page=0", "L1", 0); !errors.Is(err, tt.err) {
+			t.Errorf("status %d: doRequestWithRetry = %v, esperado %v", tt.status, err, tt.err)
+		}
+		if *calls != 2 {
+			t.Errorf("status %d: requisições = %d, esperado 1 por chamada (sem retry)", tt.status, *calls)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FieldTransformer converte o valor de uma célula no formato que a API do ClickUp
+// espera para um tipo de campo personalizado
+type FieldTransformer interface {
+	Transform(value string, opts TransformOptions) interface{}
+}
+
+// FieldValidator pode ser implementado por um FieldTransformer para que
+// ValidateFieldValue rejeite valores do seu tipo antes do envio
+type FieldValidator interface {
+	Validate(value string) error
+}
+
+// FieldTransformerFunc permite usar uma função como FieldTransformer
+type FieldTransformerFunc func(value string, opts TransformOptions) interface{}
+
+// Transform chama f(value, opts)
+func (f FieldTransformerFunc) Transform(value string, opts TransformOptions) interface{} {
+	return f(value, opts)
+}
+
+var (
+	transformersMu sync.RWMutex
+	transformers   = defaultFieldTransformers()
+)
+
+// stringTransformer envia o valor como texto; usado também para tipos desconhecidos
+var stringTransformer = FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+	return value
+})
+
+// defaultFieldTransformers retorna as conversões dos tipos nativos do ClickUp
+func defaultFieldTransformers() map[string]FieldTransformer {
+	numeric := FieldTransformerFunc(func(value string, opts TransformOptions) interface{} {
+		return parseNumericValue(value, opts.Locale)
+	})
+
+	return map[string]FieldTransformer{
+		// Campos de texto usam o valor diretamente
+		"text":       stringTransformer,
+		"short_text": stringTransformer,
+		"email":      stringTransformer,
+		"url":        stringTransformer,
+		"phone":      stringTransformer,
+		// Campos numéricos
+		"number":     numeric,
+		"currency":   numeric,
+		"percentage": numeric,
+		// Avaliações esperam um inteiro ("4", "4/5" ou "⭐⭐⭐⭐")
+		"rating": FieldTransformerFunc(func(value string, opts TransformOptions) interface{} {
+			return parseRatingValue(value, opts.Locale)
+		}),
+		"emoji": FieldTransformerFunc(func(value string, opts TransformOptions) interface{} {
+			return parseRatingValue(value, opts.Locale)
+		}),
+		// Progresso manual espera {"current": 0-100}
+		"manual_progress": FieldTransformerFunc(func(value string, opts TransformOptions) interface{} {
+			return parseProgressValue(value, opts.Locale)
+		}),
+		"checkbox": FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+			return parseBooleanValue(value)
+		}),
+		// Datas viram timestamp Unix em milissegundos
+		"date": FieldTransformerFunc(func(value string, opts TransformOptions) interface{} {
+			return parseDateValue(value, opts.Locale, opts.Location)
+		}),
+		// Dropdown recebe o ID da opção (nomes são resolvidos antes pelo serviço)
+		"drop_down": stringTransformer,
+		// Labels e users esperam arrays de IDs
+		"labels": FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+			return parseLabelsValue(value)
+		}),
+		"users": FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+			return parseUsersValue(value)
+		}),
+		// Relacionamento com tasks espera {"add": [ids], "rem": [ids]}
+		"tasks": FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+			return parseTasksValue(value)
+		}),
+		"location": FieldTransformerFunc(func(value string, _ TransformOptions) interface{} {
+			return map[string]interface{}{
+				"location": map[string]interface{}{
+					"formatted_address": value,
+				},
+			}
+		}),
+	}
+}
+
+// RegisterFieldTransformer registra a conversão de um tipo de campo, substituindo a
+// existente. Deve ser chamada na inicialização, antes do processamento de jobs.
+func RegisterFieldTransformer(fieldType string, transformer FieldTransformer) error {
+	if fieldType == "" || transformer == nil {
+		return fmt.Errorf("tipo de campo e conversão são obrigatórios")
+	}
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	transformers[fieldType] = transformer
+	return nil
+}
+
+// fieldTransformer retorna a conversão registrada para o tipo, ou texto para tipos
+// sem conversão
+func fieldTransformer(fieldType string) FieldTransformer {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+	if transformer, ok := transformers[fieldType]; ok {
+		return transformer
+	}
+	return stringTransformer
+}
+
+// readOnlyFieldTypes são calculados pelo ClickUp e não aceitam escrita pela API
+var readOnlyFieldTypes = map[string]bool{
+	"formula":            true,
+	"automatic_progress": true,
+}
+
+// IsReadOnlyFieldType indica se campos do tipo não podem ser atualizados
+func IsReadOnlyFieldType(fieldType string) bool {
+	return readOnlyFieldTypes[fieldType]
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// costCenterTransformer converte "cc-12" no objeto de um tipo de campo da organização
+type costCenterTransformer struct{}
+
+func (costCenterTransformer) Transform(value string, _ TransformOptions) interface{} {
+	return map[string]interface{}{"code": strings.ToUpper(trimSpace(value))}
+}
+
+func (costCenterTransformer) Validate(value string) error {
+	if !strings.HasPrefix(strings.ToLower(value), "cc-") {
+		return fmt.Errorf("valor '%s' não é um centro de custo", value)
+	}
+	return nil
+}
+
+func TestRegisterFieldTransformer(t *testing.T) {
+	if err := RegisterFieldTransformer("cost_center", costCenterTransformer{}); err != nil {
+		t.Fatalf("RegisterFieldTransformer: %v", err)
+	}
+	defer func() {
+		transformersMu.Lock()
+		delete(transformers, "cost_center")
+		transformersMu.Unlock()
+	}()
+
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	if err := c.SetCustomFieldValue(context.Background(), "t1", "f1", " cc-12 ", "cost_center"); err != nil {
+		t.Fatalf("SetCustomFieldValue: %v", err)
+	}
+	// Tipos nativos continuam com a conversão padrão
+	if err := c.SetCustomFieldValue(context.Background(), "t1", "f2", "1.234,5", "number"); err != nil {
+		t.Fatalf("SetCustomFieldValue number: %v", err)
+	}
+
+	expected := []map[string]interface{}{
+		{"value": map[string]interface{}{"code": "CC-12"}},
+		{"value": 1234.5},
+	}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("corpos enviados = %v, esperado %v", bodies, expected)
+	}
+
+	if err := ValidateFieldValue("financeiro", "cost_center"); err == nil || !strings.Contains(err.Error(), "centro de custo") {
+		t.Errorf("ValidateFieldValue deveria usar o validador registrado: %v", err)
+	}
+	if err := ValidateFieldValue("cc-7", "cost_center"); err != nil {
+		t.Errorf("ValidateFieldValue(cc-7): %v", err)
+	}
+
+	if err := RegisterFieldTransformer("", costCenterTransformer{}); err == nil {
+		t.Error("tipo vazio deveria ser rejeitado")
+	}
+	if got := TransformFieldValue("abc", "sem_conversao"); got != "abc" {
+		t.Errorf("tipo desconhecido = %v, esperado texto", got)
+	}
+}
+
+// genTaskRefs gera IDs de task, cada um marcado para adicionar ou remover
+func genTaskRefs() gopter.Gen {
+	return gen.SliceOf(gen.Struct(reflect.TypeOf(taskRef{}), map[string]gopter.Gen{
+		"ID":     gen.Identifier(),
+		"Remove": gen.Bool(),
+	}))
+}
+
+type taskRef struct {
+	ID     string
+	Remove bool
+}
+
+func TestTasksFieldPayload(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 100
+	parameters.MaxSize = 20
+
+	properties := gopter.NewProperties(parameters)
+
+	// O payload sempre tem exatamente add e rem, ambos arrays (nunca null)
+	properties.Property("payload has add and rem arrays", prop.ForAll(
+		func(refs []taskRef) bool {
+			parts := make([]string, len(refs))
+			for i, ref := range refs {
+				parts[i] = ref.ID
+				if ref.Remove {
+					parts[i] = "-" + ref.ID
+				}
+			}
+			encoded, err := json.Marshal(TransformFieldValue(strings.Join(parts, ", "), "tasks"))
+			if err != nil {
+				return false
+			}
+			var payload map[string]json.RawMessage
+			if json.Unmarshal(encoded, &payload) != nil || len(payload) != 2 {
+				return false
+			}
+			for _, key := range []string{"add", "rem"} {
+				if raw, ok := payload[key]; !ok || !strings.HasPrefix(string(raw), "[") {
+					return false
+				}
+			}
+			return true
+		},
+		genTaskRefs(),
+	))
+
+	// Cada ID vai para add ou rem conforme o prefixo, na ordem informada
+	properties.Property("ids are split by prefix preserving order", prop.ForAll(
+		func(refs []taskRef) bool {
+			parts := make([]string, len(refs))
+			add, rem := []string{}, []string{}
+			for i, ref := range refs {
+				if ref.Remove {
+					parts[i] = "-" + ref.ID
+					rem = append(rem, ref.ID)
+				} else {
+					parts[i] = "+" + ref.ID
+					add = append(add, ref.ID)
+				}
+			}
+			payload := parseTasksValue(strings.Join(parts, ","))
+			return reflect.DeepEqual(payload["add"], add) && reflect.DeepEqual(payload["rem"], rem)
+		},
+		genTaskRefs(),
+	))
+
+	properties.TestingRun(t)
+}
+
+func TestProgressAndRatingValues(t *testing.T) {
+	progress := []struct {
+		value string
+		want  float64
+	}{
+		{"45", 45},
+		{"45%", 45},
+		{"45,5", 45.5},
+		{"150", 100},
+		{"-3", 0},
+		{"abc", 0},
+	}
+	for _, tt := range progress {
+		got := TransformFieldValue(tt.value, "manual_progress")
+		if !reflect.DeepEqual(got, map[string]interface{}{"current": tt.want}) {
+			t.Errorf("manual_progress %q = %v, esperado current %v", tt.value, got, tt.want)
+		}
+	}
+
+	ratings := []struct {
+		value string
+		want  int64
+	}{
+		{"4", 4},
+		{"3,6", 4},
+		{"3/5", 3},
+		{"⭐⭐⭐", 3},
+		{"❤️ ❤️", 2},
+		{"-2", 0},
+		{"bom", 0},
+	}
+	for _, tt := range ratings {
+		for _, fieldType := range []string{"rating", "emoji"} {
+			if got := TransformFieldValue(tt.value, fieldType); got != tt.want {
+				t.Errorf("%s %q = %v, esperado %d", fieldType, tt.value, got, tt.want)
+			}
+		}
+	}
+
+	if err := ValidateFieldValue("⭐⭐", "emoji"); err != nil {
+		t.Errorf("ValidateFieldValue(⭐⭐): %v", err)
+	}
+	if err := ValidateFieldValue("bom", "rating"); err == nil {
+		t.Error("avaliação inválida deveria ser rejeitada")
+	}
+	if err := ValidateFieldValue(" , ", "tasks"); err == nil {
+		t.Error("relacionamento sem IDs deveria ser rejeitado")
+	}
+	if !IsReadOnlyFieldType("formula") || IsReadOnlyFieldType("tasks") {
+		t.Error("formula deveria ser somente leitura e tasks não")
+	}
+}
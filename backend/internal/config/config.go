@@ -4,19 +4,119 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config armazena as configurações da aplicação
 type Config struct {
-	TokenClickUp  string
-	TokenAPI      string
-	Port          string
-	GinMode       string
-	LogLevel      string
-	LogJSON       bool
-	EncryptionKey string
+	TokenClickUp string
+	TokenAPI     string
+	Port         string
+	GinMode      string
+	LogLevel     string
+	LogJSON      bool
+	// LogSampleEvery registra 1 de cada N linhas de progresso (páginas, linhas de jobs); 1 registra todas
+	LogSampleEvery int
+	EncryptionKey  string
+	// EncryptionKeysPrevious chaves antigas aceitas na decifragem durante a troca de ENCRYPTION_KEY
+	EncryptionKeysPrevious []string
+	// CSRFStrategy forma de envio do token CSRF: header ou double_submit
+	CSRFStrategy string
+	// CSRFTokenTTLMinutes validade do token CSRF (renovado no login e na troca de senha)
+	CSRFTokenTTLMinutes int
+	// SessionCookie*: atributos dos cookies de sessão e CSRF. Domain vazio = só o host que
+	// respondeu, Path vazio = "/"; SameSite lax (padrão), strict ou none (none exige Secure)
+	SessionCookieDomain   string
+	SessionCookiePath     string
+	SessionCookieSameSite string
+	SessionCookieSecure   bool
+	// CORS: origens que podem chamar /api/web e /api/auth de outro domínio (vazio = só a mesma
+	// origem), envio do cookie de sessão, headers extras e cache do preflight
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	CORSAllowedHeaders   []string
+	CORSMaxAgeSeconds    int
+	// DefaultTimezone fuso usado para datas sem offset quando o job não informa um
+	DefaultTimezone string
+	// DefaultLocale formato de números e datas (pt-BR ou en-US) quando o job não informa um
+	DefaultLocale string
+	// MaxConcurrentJobs jobs de usuários diferentes processados em paralelo
+	MaxConcurrentJobs int
+	// JobPriorityAgingMinutes espera após a qual um job pendente sobe um nível de prioridade (0 desativa)
+	JobPriorityAgingMinutes int
+	// QueueScheduling ordem de início dos jobs pendentes: priority, fifo ou fair (rodízio entre usuários)
+	QueueScheduling string
+	// MaxUploadRows máximo de linhas de dados por arquivo enviado
+	MaxUploadRows int
+	// MaxUploadSizeMB tamanho máximo de arquivo enviado, em MB
+	MaxUploadSizeMB int
+	// TempFileTTLMinutes tempo que arquivos enviados ficam em disco antes da limpeza automática
+	TempFileTTLMinutes int
+	// EncryptUploads cifra os arquivos enviados em disco com ENCRYPTION_KEY
+	EncryptUploads bool
+	// StorageBackend onde ficam os arquivos enviados: local (diretório temporário) ou s3
+	StorageBackend string
+	// S3 (ou compatível, ex.: MinIO) usado quando StorageBackend = s3
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3Prefix          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// QueueMaxPending e QueueMaxWaitMinutes: acima disso o /health marca a fila como degraded
+	QueueMaxPending     int
+	QueueMaxWaitMinutes int
+	// Retenção: intervalo da limpeza automática, registros de histórico mantidos por usuário e
+	// idade a partir da qual jobs com falha são removidos
+	RetentionIntervalMinutes int
+	HistoryMaxPerUser        int
+	FailedJobRetentionHours  int
+	// IdempotencyTTLMinutes tempo que uma Idempotency-Key e sua resposta ficam guardadas
+	IdempotencyTTLMinutes int
+	// IdempotencyMaxRequestKB maior corpo lido para identificar uma requisição com Idempotency-Key
+	IdempotencyMaxRequestKB int
+	// Limites por rota: corpo e tempo das rotas de autenticação (estritos) e do upload
+	// (o corpo do upload segue MaxUploadSizeMB)
+	AuthMaxBodyKB        int
+	AuthTimeoutSeconds   int
+	UploadTimeoutSeconds int
+	// Limite de requisições por usuário (ou chave de API) nas rotas /api/web e /api/v1:
+	// taxa sustentada por minuto (0 desativa) e rajada permitida
+	UserRateLimitPerMinute int
+	UserRateLimitBurst     int
+	// MaintenanceMode liga o modo manutenção ao iniciar: alterações respondem 503 (leituras
+	// continuam) e a fila não inicia jobs novos. O modo fica no banco, valendo para todas
+	// as instâncias; false mantém o estado salvo.
+	MaintenanceMode    bool
+	MaintenanceMessage string
+	// WebhookAllowPrivateNetworks permite webhooks em endereços internos (loopback, redes
+	// privadas, link-local); por padrão são recusados para evitar SSRF
+	WebhookAllowPrivateNetworks bool
+	// ClickUp client: timeout por requisição, pool de conexões e requisições simultâneas
+	ClickUpTimeoutSeconds        int
+	ClickUpMaxIdleConns          int
+	ClickUpMaxIdleConnsPerHost   int
+	ClickUpMaxConcurrentRequests int
+	// ClickUp retry: tentativas e espera exponencial (inicial, multiplicador, máximo e jitter em %)
+	ClickUpRetryMaxAttempts      int
+	ClickUpRetryInitialBackoffMs int
+	ClickUpRetryMultiplier       float64
+	ClickUpRetryMaxBackoffMs     int
+	ClickUpRetryJitterPercent    int
+	// ClickUp debug: registra URL, corpo e status de cada chamada (token omitido)
+	ClickUpDebug bool
+	// MetadataSyncConcurrency requisições simultâneas ao ClickUp na sincronização de metadados
+	MetadataSyncConcurrency int
+	// MappingSuggestMinSimilarity semelhança mínima (0-1) entre nomes de coluna e de campo
+	// para sugerir um mapeamento
+	MappingSuggestMinSimilarity float64
+	// Compressão gzip: respostas a partir de GzipMinSizeBytes e corpos de requisição gzip
+	GzipEnabled      bool
+	GzipMinSizeBytes int
+	// WSProgressIntervalMs intervalo mínimo entre atualizações de progresso de um job via WebSocket
+	WSProgressIntervalMs int
 	// Database configuration
 	DBHost            string
 	DBPort            string
@@ -28,6 +128,8 @@ type Config struct {
 	DBMaxIdleConns    int
 	DBConnMaxLifetime int // in minutes
 	DBConnMaxIdleTime int // in minutes
+	// DBPoolMaxWaits: esperas por conexão entre duas amostras do pool acima das quais o /health fica degraded (0 desativa)
+	DBPoolMaxWaits int
 }
 
 // ErrMissingToken indica que um token obrigatório não foi configurado
@@ -36,17 +138,77 @@ var ErrMissingToken = errors.New("token obrigatório não configurado")
 // Load carrega as configurações do ambiente
 func Load() (*Config, error) {
 	// Tenta carregar .env de múltiplos locais
-	_ = godotenv.Load()        // ./backend/.env
+	_ = godotenv.Load()          // ./backend/.env
 	_ = godotenv.Load("../.env") // ./.env (raiz do projeto)
 
 	cfg := &Config{
-		TokenClickUp:  os.Getenv("TOKEN_CLICKUP"),
-		TokenAPI:      os.Getenv("TOKEN_API"),
-		Port:          os.Getenv("PORT"),
-		GinMode:       os.Getenv("GIN_MODE"),
-		LogLevel:      os.Getenv("LOG_LEVEL"),
-		LogJSON:       os.Getenv("LOG_JSON") != "false", // default: true
-		EncryptionKey: os.Getenv("ENCRYPTION_KEY"),
+		TokenClickUp:             os.Getenv("TOKEN_CLICKUP"),
+		TokenAPI:                 os.Getenv("TOKEN_API"),
+		Port:                     os.Getenv("PORT"),
+		GinMode:                  os.Getenv("GIN_MODE"),
+		LogLevel:                 os.Getenv("LOG_LEVEL"),
+		LogJSON:                  os.Getenv("LOG_JSON") != "false", // default: true
+		LogSampleEvery:           getEnvInt("LOG_SAMPLE_EVERY", 10),
+		EncryptionKey:            os.Getenv("ENCRYPTION_KEY"),
+		EncryptionKeysPrevious:   getEnvList("ENCRYPTION_KEYS_PREVIOUS"),
+		CSRFStrategy:             os.Getenv("CSRF_STRATEGY"),
+		CSRFTokenTTLMinutes:      getEnvInt("CSRF_TOKEN_TTL_MINUTES", 1440),
+		SessionCookieDomain:      os.Getenv("SESSION_COOKIE_DOMAIN"),
+		SessionCookiePath:        os.Getenv("SESSION_COOKIE_PATH"),
+		SessionCookieSameSite:    os.Getenv("SESSION_COOKIE_SAMESITE"),
+		SessionCookieSecure:      os.Getenv("SESSION_COOKIE_SECURE") == "true", // default: false
+		CORSAllowedOrigins:       getEnvList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowCredentials:     os.Getenv("CORS_ALLOW_CREDENTIALS") != "false", // default: true
+		CORSAllowedHeaders:       getEnvList("CORS_ALLOWED_HEADERS"),
+		CORSMaxAgeSeconds:        getEnvInt("CORS_MAX_AGE_SECONDS", 600),
+		DefaultTimezone:          os.Getenv("DEFAULT_TIMEZONE"),
+		DefaultLocale:            os.Getenv("DEFAULT_LOCALE"),
+		MaxConcurrentJobs:        getEnvInt("MAX_CONCURRENT_JOBS", 0),
+		JobPriorityAgingMinutes:  getEnvInt("JOB_PRIORITY_AGING_MINUTES", 30),
+		QueueScheduling:          os.Getenv("QUEUE_SCHEDULING"),
+		MaxUploadRows:            getEnvInt("MAX_UPLOAD_ROWS", 50000),
+		MaxUploadSizeMB:          getEnvInt("MAX_UPLOAD_SIZE_MB", 10),
+		TempFileTTLMinutes:       getEnvInt("TEMP_FILE_TTL_MINUTES", 60),
+		EncryptUploads:           os.Getenv("ENCRYPT_UPLOADS") == "true",
+		StorageBackend:           os.Getenv("STORAGE_BACKEND"),
+		S3Endpoint:               os.Getenv("S3_ENDPOINT"),
+		S3Region:                 os.Getenv("S3_REGION"),
+		S3Bucket:                 os.Getenv("S3_BUCKET"),
+		S3Prefix:                 os.Getenv("S3_PREFIX"),
+		S3AccessKeyID:            os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:        os.Getenv("S3_SECRET_ACCESS_KEY"),
+		IdempotencyTTLMinutes:    getEnvInt("IDEMPOTENCY_TTL_MINUTES", 1440),
+		IdempotencyMaxRequestKB:  getEnvInt("IDEMPOTENCY_MAX_REQUEST_KB", 1024),
+		QueueMaxPending:          getEnvInt("QUEUE_MAX_PENDING", 100),
+		QueueMaxWaitMinutes:      getEnvInt("QUEUE_MAX_WAIT_MINUTES", 15),
+		RetentionIntervalMinutes: getEnvInt("RETENTION_INTERVAL_MINUTES", 60),
+		HistoryMaxPerUser:        getEnvInt("HISTORY_MAX_RECORDS_PER_USER", 1000),
+		FailedJobRetentionHours:  getEnvInt("FAILED_JOB_RETENTION_HOURS", 24),
+		AuthMaxBodyKB:            getEnvInt("AUTH_MAX_BODY_KB", 16),
+		AuthTimeoutSeconds:       getEnvInt("AUTH_TIMEOUT_SECONDS", 10),
+		UploadTimeoutSeconds:     getEnvInt("UPLOAD_TIMEOUT_SECONDS", 300),
+		UserRateLimitPerMinute:   getEnvInt("USER_RATE_LIMIT_PER_MINUTE", 600),
+		UserRateLimitBurst:       getEnvInt("USER_RATE_LIMIT_BURST", 100),
+		MaintenanceMode:          os.Getenv("MAINTENANCE_MODE") == "true", // default: false
+		MaintenanceMessage:       os.Getenv("MAINTENANCE_MESSAGE"),
+		// Webhooks
+		WebhookAllowPrivateNetworks: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true", // default: false
+		// ClickUp client
+		ClickUpTimeoutSeconds:        getEnvInt("CLICKUP_TIMEOUT_SECONDS", 60),
+		ClickUpMaxIdleConns:          getEnvInt("CLICKUP_MAX_IDLE_CONNS", 10),
+		ClickUpMaxIdleConnsPerHost:   getEnvInt("CLICKUP_MAX_IDLE_CONNS_PER_HOST", 10),
+		ClickUpMaxConcurrentRequests: getEnvInt("CLICKUP_MAX_CONCURRENT_REQUESTS", 5),
+		ClickUpRetryMaxAttempts:      getEnvInt("CLICKUP_RETRY_MAX_ATTEMPTS", 3),
+		ClickUpRetryInitialBackoffMs: getEnvInt("CLICKUP_RETRY_INITIAL_BACKOFF_MS", 30000),
+		ClickUpRetryMultiplier:       getEnvFloat("CLICKUP_RETRY_MULTIPLIER", 1),
+		ClickUpRetryMaxBackoffMs:     getEnvInt("CLICKUP_RETRY_MAX_BACKOFF_MS", 0),
+		ClickUpRetryJitterPercent:    getEnvInt("CLICKUP_RETRY_JITTER_PERCENT", 0),
+		ClickUpDebug:                 os.Getenv("CLICKUP_DEBUG") == "true",
+		MetadataSyncConcurrency:      getEnvInt("METADATA_SYNC_CONCURRENCY", 4),
+		MappingSuggestMinSimilarity:  getEnvFloat("MAPPING_SUGGEST_MIN_SIMILARITY", 0.8),
+		GzipEnabled:                  os.Getenv("GZIP_ENABLED") != "false", // default: true
+		GzipMinSizeBytes:             getEnvInt("GZIP_MIN_SIZE_BYTES", 1024),
+		WSProgressIntervalMs:         getEnvInt("WS_PROGRESS_INTERVAL_MS", 250),
 		// Database configuration
 		DBHost:            os.Getenv("DB_HOST"),
 		DBPort:            os.Getenv("DB_PORT"),
@@ -58,6 +220,7 @@ func Load() (*Config, error) {
 		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 0),
 		DBConnMaxLifetime: getEnvInt("DB_CONN_MAX_LIFETIME", 0),
 		DBConnMaxIdleTime: getEnvInt("DB_CONN_MAX_IDLE_TIME", 0),
+		DBPoolMaxWaits:    getEnvInt("DB_POOL_MAX_WAITS", 10),
 	}
 
 	// Validações obrigatórias
@@ -87,6 +250,101 @@ func Load() (*Config, error) {
 		cfg.EncryptionKey = "default-encryption-key-32bytes!!"
 	}
 
+	if cfg.StorageBackend == "" {
+		cfg.StorageBackend = "local"
+	}
+	if cfg.DefaultTimezone == "" {
+		cfg.DefaultTimezone = "America/Sao_Paulo"
+	}
+	if cfg.DefaultLocale != "pt-BR" && cfg.DefaultLocale != "en-US" {
+		cfg.DefaultLocale = "pt-BR"
+	}
+
+	if cfg.MaxConcurrentJobs <= 0 {
+		cfg.MaxConcurrentJobs = 1
+	}
+	if cfg.JobPriorityAgingMinutes < 0 {
+		cfg.JobPriorityAgingMinutes = 0
+	}
+	if cfg.MaxUploadRows <= 0 {
+		cfg.MaxUploadRows = 50000
+	}
+	if cfg.MaxUploadSizeMB <= 0 {
+		cfg.MaxUploadSizeMB = 10
+	}
+	if cfg.TempFileTTLMinutes <= 0 {
+		cfg.TempFileTTLMinutes = 60
+	}
+	if cfg.RetentionIntervalMinutes <= 0 {
+		cfg.RetentionIntervalMinutes = 60
+	}
+	if cfg.HistoryMaxPerUser <= 0 {
+		cfg.HistoryMaxPerUser = 1000
+	}
+	if cfg.FailedJobRetentionHours <= 0 {
+		cfg.FailedJobRetentionHours = 24
+	}
+	if cfg.IdempotencyTTLMinutes <= 0 {
+		cfg.IdempotencyTTLMinutes = 1440
+	}
+	if cfg.IdempotencyMaxRequestKB <= 0 {
+		cfg.IdempotencyMaxRequestKB = 1024
+	}
+	if cfg.AuthMaxBodyKB <= 0 {
+		cfg.AuthMaxBodyKB = 16
+	}
+	if cfg.AuthTimeoutSeconds <= 0 {
+		cfg.AuthTimeoutSeconds = 10
+	}
+	if cfg.UploadTimeoutSeconds <= 0 {
+		cfg.UploadTimeoutSeconds = 300
+	}
+	if cfg.UserRateLimitPerMinute < 0 {
+		cfg.UserRateLimitPerMinute = 0
+	}
+	if cfg.UserRateLimitBurst <= 0 {
+		cfg.UserRateLimitBurst = 100
+	}
+	if cfg.ClickUpTimeoutSeconds <= 0 {
+		cfg.ClickUpTimeoutSeconds = 60
+	}
+	if cfg.ClickUpMaxIdleConns <= 0 {
+		cfg.ClickUpMaxIdleConns = 10
+	}
+	if cfg.ClickUpMaxIdleConnsPerHost <= 0 {
+		cfg.ClickUpMaxIdleConnsPerHost = 10
+	}
+	if cfg.ClickUpMaxConcurrentRequests <= 0 {
+		cfg.ClickUpMaxConcurrentRequests = 5
+	}
+	if cfg.MetadataSyncConcurrency <= 0 {
+		cfg.MetadataSyncConcurrency = 4
+	}
+	if cfg.MappingSuggestMinSimilarity <= 0 || cfg.MappingSuggestMinSimilarity > 1 {
+		cfg.MappingSuggestMinSimilarity = 0.8
+	}
+	if cfg.GzipMinSizeBytes <= 0 {
+		cfg.GzipMinSizeBytes = 1024
+	}
+	if cfg.WSProgressIntervalMs < 0 {
+		cfg.WSProgressIntervalMs = 250
+	}
+	if cfg.ClickUpRetryMaxAttempts <= 0 {
+		cfg.ClickUpRetryMaxAttempts = 3
+	}
+	if cfg.ClickUpRetryInitialBackoffMs <= 0 {
+		cfg.ClickUpRetryInitialBackoffMs = 30000
+	}
+	if cfg.ClickUpRetryMultiplier < 1 {
+		cfg.ClickUpRetryMultiplier = 1
+	}
+	if cfg.ClickUpRetryMaxBackoffMs < 0 {
+		cfg.ClickUpRetryMaxBackoffMs = 0
+	}
+	if cfg.ClickUpRetryJitterPercent < 0 || cfg.ClickUpRetryJitterPercent > 100 {
+		cfg.ClickUpRetryJitterPercent = 0
+	}
+
 	// Database defaults
 	if cfg.DBHost == "" {
 		cfg.DBHost = "localhost"
@@ -129,3 +387,24 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+// getEnvFloat returns a float environment variable, or defaultVal when unset or invalid
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if floatVal, err := strconv.ParseFloat(val, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultVal
+}
+
+// getEnvList returns the non-empty comma-separated values of an environment variable
+func getEnvList(key string) []string {
+	var values []string
+	for _, val := range strings.Split(os.Getenv(key), ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			values = append(values, val)
+		}
+	}
+	return values
+}
@@ -0,0 +1,50 @@
+// Package filestore stores uploaded files outside the process, so any instance can
+// read a file uploaded to another one (e.g. when the job processor resumes a job on a
+// different pod than the one that received the upload).
+package filestore
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound indicates the key does not exist in the store
+	ErrNotFound = errors.New("arquivo não encontrado no armazenamento")
+	// ErrInvalidKey indicates a key with path separators or other unsafe characters
+	ErrInvalidKey = errors.New("chave de arquivo inválida")
+)
+
+// FileStore keeps files by key. Keys are flat names (no directories).
+type FileStore interface {
+	// Put stores the content read from r under key, replacing any previous content
+	Put(key string, r io.Reader) error
+	// Get opens the content of key; the caller must close it
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes key; deleting a missing key is not an error
+	Delete(key string) error
+	// Stat returns the size and modification time of key
+	Stat(key string) (FileInfo, error)
+}
+
+// Lister is implemented by stores that can enumerate their keys (used to adopt files
+// left by a previous run so they expire like the others)
+type Lister interface {
+	List(prefix string) ([]string, error)
+}
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// ValidKey reports whether key is a flat, safe file name
+func ValidKey(key string) bool {
+	if key == "" || key == "." || key == ".." {
+		return false
+	}
+	return !strings.ContainsAny(key, "/\\\x00")
+}
@@ -0,0 +1,125 @@
+package filestore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores files in a directory on the local disk
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a store in dir ("" uses the system temp directory)
+func NewLocal(dir string) *Local {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return &Local{dir: dir}
+}
+
+// Dir returns the directory where files are kept
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(l.dir, key), nil
+}
+
+// Put writes the content next to the destination and renames it, so a failure
+// never leaves a partial file
+func (l *Local) Put(key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(l.dir, ".put_*")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file of key
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes the file of key
+func (l *Local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("erro ao remover arquivo: %w", err)
+	}
+	return nil
+}
+
+// Stat returns the size and modification time of the file of key
+func (l *Local) Stat(key string) (FileInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FileInfo{}, ErrNotFound
+		}
+		return FileInfo{}, fmt.Errorf("erro ao consultar arquivo: %w", err)
+	}
+	if info.IsDir() {
+		return FileInfo{}, ErrNotFound
+	}
+	return FileInfo{Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List returns the keys of the regular files whose name starts with prefix
+func (l *Local) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar arquivos: %w", err)
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), prefix) {
+			keys = append(keys, entry.Name())
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,72 @@
+package filestore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir)
+
+	if err := store.Put("upload_1.csv", strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// Replacing keeps only the new content
+	if err := store.Put("upload_1.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatalf("Put (replace): %v", err)
+	}
+
+	rc, err := store.Get("upload_1.csv")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(content) != "a,b\n" {
+		t.Errorf("content = %q", content)
+	}
+
+	info, err := store.Stat("upload_1.csv")
+	if err != nil || info.Size != 4 || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "upload_1.csv")); err != nil {
+		t.Errorf("file should be kept in the directory: %v", err)
+	}
+
+	store.Put("other.txt", strings.NewReader("x"))
+	keys, err := store.List("upload_")
+	if err != nil || len(keys) != 1 || keys[0] != "upload_1.csv" {
+		t.Errorf("List = %v, %v", keys, err)
+	}
+
+	if err := store.Delete("upload_1.csv"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get("upload_1.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v", err)
+	}
+	if _, err := store.Stat("upload_1.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete: %v", err)
+	}
+	if err := store.Delete("upload_1.csv"); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+}
+
+func TestLocalRejectsUnsafeKeys(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	for _, key := range []string{"", "..", "../secret", "a/b", `a\b`} {
+		if err := store.Put(key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, expected ErrInvalidKey", key, err)
+		}
+		if _, err := store.Get(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) = %v, expected ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package filestore
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal path-style S3 server that keeps objects in memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	if r.Header.Get("x-amz-date") == "" || r.Header.Get("x-amz-content-sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("Last-Modified", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3RoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Region:          "sa-east-1",
+		Bucket:          "uploads",
+		Prefix:          "tmp/",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+
+	if err := store.Put("upload_1.csv", strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := fake.objects["/uploads/tmp/upload_1.csv"]; !ok {
+		t.Fatalf("object stored under unexpected path: %v", fake.objects)
+	}
+	if !strings.HasPrefix(fake.auth[0], "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(fake.auth[0], "/sa-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Authorization = %q", fake.auth[0])
+	}
+
+	rc, err := store.Get("upload_1.csv")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(content) != "a,b\n1,2\n" {
+		t.Errorf("content = %q", content)
+	}
+
+	info, err := store.Stat("upload_1.csv")
+	if err != nil || info.Size != 8 || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+
+	if err := store.Delete("upload_1.csv"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get("upload_1.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v", err)
+	}
+	if _, err := store.Stat("upload_1.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete: %v", err)
+	}
+}
+
+func TestS3SignatureIsDeterministic(t *testing.T) {
+	store, _ := NewS3(S3Config{
+		Endpoint:        "https://s3.example.com",
+		Bucket:          "uploads",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	})
+	store.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }
+
+	sign := func(key string) string {
+		req, _ := http.NewRequest(http.MethodGet, "https://s3.example.com/uploads/"+key, nil)
+		store.sign(req, nil)
+		return req.Header.Get("Authorization")
+	}
+
+	first := sign("upload_1.csv")
+	if first != sign("upload_1.csv") {
+		t.Error("same request should produce the same signature")
+	}
+	if first == sign("upload_2.csv") {
+		t.Error("different keys should produce different signatures")
+	}
+	if !strings.Contains(first, "Credential=AKIDEXAMPLE/20240501/us-east-1/s3/aws4_request") {
+		t.Errorf("Authorization = %q", first)
+	}
+}
@@ -1,8 +1,9 @@
 package handler
 
 import (
+	"io"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
 	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
@@ -31,22 +32,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&loginRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados de login inválidos",
-			"details": err.Error(),
+			"details": errorDetails(err),
 			"code":    "INVALID_INPUT",
 		})
 		return
 	}
 
-	// Sanitize inputs
-	loginRequest.Username = middleware.SanitizeUsername(loginRequest.Username)
+	// Sanitize inputs; usernames are case-insensitive
+	loginRequest.Username = middleware.CanonicalUsername(middleware.SanitizeUsername(loginRequest.Username))
 	loginRequest.Password = middleware.SanitizePassword(loginRequest.Password)
 
 	// Validate username format
 	if !middleware.ValidateUsername(loginRequest.Username) {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Nome de usuário inválido",
 			"code":    "INVALID_USERNAME",
@@ -68,7 +69,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		})
 		metrics.Get().IncrementLogin(false)
 		
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Credenciais inválidas",
 			"code":    "INVALID_CREDENTIALS",
@@ -79,7 +80,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Create session
 	sessionID, err := authMiddleware.CreateSession(loginRequest.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro interno do servidor",
 			"code":    "SESSION_CREATE_ERROR",
@@ -91,7 +92,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	csrfMiddleware := h.authService.GetCSRFMiddleware()
 	csrfToken, err := csrfMiddleware.GenerateToken(loginRequest.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao gerar token CSRF",
 			"code":    "CSRF_TOKEN_ERROR",
@@ -100,15 +101,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Set session cookie
-	c.SetCookie(
-		"session_id",
-		sessionID,
-		int(24*time.Hour.Seconds()),
-		"/",
-		"",
-		false,
-		true,
-	)
+	authMiddleware.SetSessionCookie(c, sessionID)
 
 	// Set CSRF token cookie (readable by JavaScript)
 	csrfMiddleware.SetTokenCookie(c, csrfToken)
@@ -129,6 +122,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		"csrf_token": csrfToken,
 		"user": gin.H{
 			"username": loginRequest.Username,
+			"role":     authMiddleware.UserRole(loginRequest.Username),
 		},
 	})
 }
@@ -136,16 +130,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // Logout handles user logout requests
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Get session ID from cookie
+	authMiddleware := h.authService.GetAuthMiddleware()
 	sessionID, err := c.Cookie("session_id")
 	if err == nil {
+		// The logout route doesn't run RequireAuth, so the user comes from the session
+		if session, ok := authMiddleware.GetSession(sessionID); ok {
+			c.Set("user_id", session.UserID)
+			c.Set("username", session.Username)
+		}
+
 		// Delete session
-		h.authService.GetAuthMiddleware().DeleteSession(sessionID)
+		authMiddleware.DeleteSession(sessionID)
 	}
 
 	// Get user ID for CSRF cleanup
 	userID, exists := c.Get("user_id")
 	username, _ := c.Get("username")
 	if exists {
+		// The token can't be used again, even before it expires
 		h.authService.GetCSRFMiddleware().DeleteToken(userID.(string))
 		
 		// Audit logout
@@ -163,16 +165,8 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		})
 	}
 
-	// Clear session cookie
-	c.SetCookie(
-		"session_id",
-		"",
-		-1,
-		"/",
-		"",
-		false,
-		true,
-	)
+	// Clear session cookie (same domain and path, or the browser keeps it)
+	authMiddleware.ClearSessionCookie(c)
 
 	// Clear CSRF cookie
 	h.authService.GetCSRFMiddleware().ClearTokenCookie(c)
@@ -188,7 +182,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	// Get user info from context (set by RequireAuth middleware)
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Sessão não encontrada",
 			"code":    "SESSION_NOT_FOUND",
@@ -206,7 +200,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		var err error
 		csrfToken, err = csrfMiddleware.GenerateToken(userID.(string))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 				"success": false,
 				"error":   "Erro ao gerar token CSRF",
 				"code":    "CSRF_TOKEN_ERROR",
@@ -222,34 +216,36 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		"user": gin.H{
 			"username": username.(string),
 			"user_id":  userID.(string),
+			"role":     c.GetString("role"),
 		},
 	})
 }
 
-// CreateUser creates a new user (admin endpoint)
+// CreateUser creates a new user (admin endpoint). Role is optional and defaults to user.
 func (h *AuthHandler) CreateUser(c *gin.Context) {
 	var request struct {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required,min=6"`
+		Role     string `json:"role"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados inválidos",
-			"details": err.Error(),
+			"details": errorDetails(err),
 			"code":    "INVALID_INPUT",
 		})
 		return
 	}
 
-	// Sanitize inputs
-	request.Username = middleware.SanitizeUsername(request.Username)
+	// Sanitize inputs; usernames are stored lower-case
+	request.Username = middleware.CanonicalUsername(middleware.SanitizeUsername(request.Username))
 	request.Password = middleware.SanitizePassword(request.Password)
 
 	// Validate username format
 	if !middleware.ValidateUsername(request.Username) {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Nome de usuário inválido (use apenas letras, números, _ e -)",
 			"code":    "INVALID_USERNAME",
@@ -259,7 +255,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 
 	// Validate password
 	if !middleware.ValidatePassword(request.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Senha deve ter entre 6 e 128 caracteres",
 			"code":    "INVALID_PASSWORD",
@@ -267,10 +263,22 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.CreateUser(request.Username, request.Password)
+	if request.Role == "" {
+		request.Role = middleware.RoleUser
+	}
+	if !middleware.ValidRole(request.Role) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Papel inválido (use admin ou user)",
+			"code":    "INVALID_ROLE",
+		})
+		return
+	}
+
+	err := h.authService.CreateUserWithRole(request.Username, request.Password, request.Role)
 	if err != nil {
 		if err == service.ErrUserAlreadyExists {
-			c.JSON(http.StatusConflict, gin.H{
+			middleware.ErrorJSON(c, http.StatusConflict, gin.H{
 				"success": false,
 				"error":   "Usuário já existe",
 				"code":    "USER_ALREADY_EXISTS",
@@ -278,7 +286,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro interno do servidor",
 			"code":    "INTERNAL_ERROR",
@@ -300,6 +308,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 		"message": "Usuário criado com sucesso",
 		"user": gin.H{
 			"username": request.Username,
+			"role":     request.Role,
 		},
 	})
 }
@@ -312,10 +321,10 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados inválidos",
-			"details": err.Error(),
+			"details": errorDetails(err),
 			"code":    "INVALID_INPUT",
 		})
 		return
@@ -327,7 +336,7 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 
 	// Validate new password
 	if !middleware.ValidatePassword(request.NewPassword) {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Nova senha deve ter entre 6 e 128 caracteres",
 			"code":    "INVALID_PASSWORD",
@@ -338,7 +347,7 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 	// Get current user from context
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 			"code":    "NOT_AUTHENTICATED",
@@ -348,7 +357,7 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 
 	// Validate current password
 	if !h.authService.ValidateCredentials(username.(string), request.CurrentPassword) {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Senha atual incorreta",
 			"code":    "INVALID_CURRENT_PASSWORD",
@@ -359,7 +368,7 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 	// Update password
 	err := h.authService.UpdateUserPassword(username.(string), request.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro interno do servidor",
 			"code":    "INTERNAL_ERROR",
@@ -367,6 +376,18 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
+	// Rotate the CSRF token: the one used before the password change stops working
+	userID, _ := c.Get("user_id")
+	csrfToken, err := h.authService.GetCSRFMiddleware().RotateToken(c, userID.(string))
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Erro ao gerar token CSRF",
+			"code":    "CSRF_TOKEN_ERROR",
+		})
+		return
+	}
+
 	// Audit password change
 	logger.Audit(c.Request.Context(), logger.AuditEvent{
 		Action:   logger.AuditActionPasswordChange,
@@ -376,8 +397,67 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 		Success:  true,
 	})
 
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "Senha atualizada com sucesso",
+		"csrf_token": csrfToken,
+	})
+}
+
+// maxUserImportBytes limits the size of a user import CSV
+const maxUserImportBytes = 1 << 20
+
+// ImportUsers creates users in bulk from a CSV (admin endpoint). The CSV has
+// username,password[,role] columns and comes in the "file" form field or as the
+// request body. Each row gets its own result; rows with errors don't stop the others.
+func (h *AuthHandler) ImportUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUserImportBytes)
+
+	var source io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "arquivo não encontrado no formulário",
+				"details": "use o campo 'file' para enviar o CSV (máximo 1MB)",
+				"code":    "INVALID_INPUT",
+			})
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	results, err := h.authService.ImportUsers(source)
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "CSV de usuários inválido",
+			"details": errorDetails(err),
+			"code":    "INVALID_INPUT",
+		})
+		return
+	}
+
+	for _, result := range results {
+		if result.Status != service.UserImportCreated {
+			continue
+		}
+		logger.Audit(c.Request.Context(), logger.AuditEvent{
+			Action:   logger.AuditActionUserCreate,
+			Username: result.Username,
+			Resource: "user",
+			ClientIP: c.ClientIP(),
+			Success:  true,
+		})
+	}
+
+	summary := service.SummarizeUserImport(results)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Senha atualizada com sucesso",
+		"partial": summary.Failed > 0,
+		"summary": summary,
+		"results": results,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackupImportBytes limits the size of a backup import
+const maxBackupImportBytes = 256 << 20
+
+// BackupHandler exports and imports jobs and history (admin endpoints)
+type BackupHandler struct {
+	backupService *service.BackupService
+}
+
+// NewBackupHandler creates a new backup handler
+func NewBackupHandler(backupService *service.BackupService) *BackupHandler {
+	return &BackupHandler{backupService: backupService}
+}
+
+// Export streams the job queue and operation history as newline-delimited JSON
+// @Summary Export jobs and history
+// @Description Streams job_queue and operation_history as NDJSON, one {"kind", "job"|"history"} object per line. Without user_id every user is exported.
+// @Tags admin
+// @Produce application/x-ndjson
+// @Param user_id query string false "Only this user's records"
+// @Success 200 {file} file
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/web/admin/export [get]
+func (h *BackupHandler) Export(c *gin.Context) {
+	log := logger.Get(c.Request.Context())
+	userID := strings.TrimSpace(c.Query("user_id"))
+
+	filename := fmt.Sprintf("backup_%s.ndjson", time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Status(http.StatusOK)
+
+	err := h.backupService.Export(userID, c.Writer)
+
+	logger.Audit(c.Request.Context(), logger.AuditEvent{
+		Action:   logger.AuditActionBackupExport,
+		UserID:   c.GetString("user_id"),
+		Username: c.GetString("username"),
+		Resource: "backup",
+		ClientIP: c.ClientIP(),
+		Success:  err == nil,
+		Details:  map[string]interface{}{"user_id": userID},
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("export_user_id", userID).Msg("Erro ao exportar backup")
+		// Com o corpo já iniciado, só resta interromper o download
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Erro ao exportar backup",
+				"details": errorDetails(err),
+			})
+		}
+	}
+}
+
+// Import inserts the records of an export. The file comes in the "file" form field
+// or as the request body; records whose id already exists are skipped, so the same
+// file can be imported again safely.
+// @Summary Import jobs and history
+// @Tags admin
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} service.BackupImportSummary
+// @Failure 400 {object} ErrorResponse
+// @Router /api/web/admin/import [post]
+func (h *BackupHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupImportBytes)
+
+	var source io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "arquivo não encontrado no formulário",
+				"details": "use o campo 'file' para enviar o backup",
+				"code":    "INVALID_INPUT",
+			})
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	summary, err := h.backupService.Import(source)
+
+	logger.Audit(c.Request.Context(), logger.AuditEvent{
+		Action:   logger.AuditActionBackupImport,
+		UserID:   c.GetString("user_id"),
+		Username: c.GetString("username"),
+		Resource: "backup",
+		ClientIP: c.ClientIP(),
+		Success:  err == nil,
+		Details: map[string]interface{}{
+			"jobs_imported":    summary.JobsImported,
+			"history_imported": summary.HistoryImported,
+			"failed":           summary.Failed,
+		},
+	})
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrEmptyBackup) || isBodyTooLarge(err) {
+			status = http.StatusBadRequest
+		}
+		middleware.ErrorJSON(c, status, gin.H{
+			"success": false,
+			"error":   "Erro ao importar backup",
+			"details": errorDetails(err),
+			"summary": summary,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"partial": summary.Failed > 0,
+		"summary": summary,
+	})
+}
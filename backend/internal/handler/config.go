@@ -1,17 +1,38 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
+// ClickUpTokenSaver validates ClickUp tokens upstream and stores them encrypted,
+// one per label (the empty label is the default token)
+type ClickUpTokenSaver interface {
+	SaveClickUpToken(ctx context.Context, userID, label, token string) (*service.ClickUpTokenInfo, error)
+	ListClickUpTokens(ctx context.Context, userID string) ([]service.ClickUpTokenSummary, error)
+	DeleteClickUpToken(ctx context.Context, userID, label string) error
+}
+
+// WebhookURLValidator checks a webhook URL before it is saved (implemented by *service.WebhookService)
+type WebhookURLValidator interface {
+	ValidateURL(ctx context.Context, raw string) error
+}
+
 // ConfigHandler handles user configuration requests
 type ConfigHandler struct {
 	configRepo *repository.ConfigRepository
+	tokenSaver ClickUpTokenSaver
+	webhooks   WebhookURLValidator
 }
 
 // NewConfigHandler creates a new config handler
@@ -21,6 +42,17 @@ func NewConfigHandler(configRepo *repository.ConfigRepository) *ConfigHandler {
 	}
 }
 
+// SetTokenSaver enables saving the ClickUp token through SaveConfig
+func (h *ConfigHandler) SetTokenSaver(saver ClickUpTokenSaver) {
+	h.tokenSaver = saver
+}
+
+// SetWebhookValidator rejects sync webhooks the server must not call, such as internal
+// addresses; without it only the URL's scheme is checked
+func (h *ConfigHandler) SetWebhookValidator(validator WebhookURLValidator) {
+	h.webhooks = validator
+}
+
 // GetConfig returns the user's configuration
 // @Summary      Get user configuration
 // @Description  Returns the current user's configuration settings
@@ -37,7 +69,7 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -47,10 +79,10 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	config, err := h.configRepo.GetUserConfig(userID.(string))
 	if err != nil {
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao buscar configuração")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao buscar configuração",
-			Details: err.Error(),
+			Details: errorDetails(err),
 		})
 		return
 	}
@@ -70,15 +102,18 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, ConfigResponse{
 		Success: true,
 		Data: ConfigData{
-			HasToken:           config.ClickUpTokenEncrypted != "",
-			RateLimitPerMinute: config.RateLimitPerMinute,
+			HasToken:             config.ClickUpTokenEncrypted != "",
+			RateLimitPerMinute:   config.RateLimitPerMinute,
+			SyncWebhookURL:       config.SyncWebhookURL,
+			DefaultSubtasks:      config.DefaultSubtasks,
+			DefaultIncludeClosed: config.DefaultIncludeClosed,
 		},
 	})
 }
 
 // SaveConfig saves the user's configuration
 // @Summary      Save user configuration
-// @Description  Saves the user's configuration settings
+// @Description  Saves the user's configuration settings. A clickup_token is checked against ClickUp before it is stored.
 // @Tags         config
 // @Accept       json
 // @Produce      json
@@ -88,6 +123,7 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 // @Failure      400 {object} model.ErrorResponse
 // @Failure      401 {object} model.ErrorResponse
 // @Failure      500 {object} model.ErrorResponse
+// @Failure      502 {object} model.ErrorResponse "ClickUp indisponível ao validar o token"
 // @Router       /api/web/config [post]
 func (h *ConfigHandler) SaveConfig(c *gin.Context) {
 	log := logger.FromGin(c)
@@ -95,7 +131,7 @@ func (h *ConfigHandler) SaveConfig(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -104,10 +140,10 @@ func (h *ConfigHandler) SaveConfig(c *gin.Context) {
 	
 	var req SaveConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
-			Details: err.Error(),
+			Details: errorDetails(err),
 		})
 		return
 	}
@@ -115,20 +151,119 @@ func (h *ConfigHandler) SaveConfig(c *gin.Context) {
 	// Validate rate limit range (10-10000)
 	if req.RateLimitPerMinute != nil {
 		if *req.RateLimitPerMinute < 10 || *req.RateLimitPerMinute > 10000 {
-			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 				Success: false,
 				Error:   "rate limit inválido",
 				Details: "rate limit deve estar entre 10 e 10000",
 			})
 			return
 		}
+	}
+	
+	// Validate and save the ClickUp token before anything else is persisted
+	var account *service.ClickUpTokenInfo
+	if req.ClickUpToken != nil {
+		token := strings.TrimSpace(*req.ClickUpToken)
+		if token == "" {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "token do ClickUp inválido",
+				Details: "informe o token pessoal do ClickUp (pk_...)",
+			})
+			return
+		}
+		if h.tokenSaver == nil {
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   "salvamento de token não configurado",
+			})
+			return
+		}
 		
+		var err error
+		account, err = h.tokenSaver.SaveClickUpToken(c.Request.Context(), userID.(string), req.ClickUpTokenLabel, token)
+		if err != nil {
+			log.Warn().Err(err).Str("user_id", userID.(string)).Msg("Token do ClickUp não salvo")
+			switch {
+			case errors.Is(err, service.ErrInvalidTokenLabel):
+				middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+					Success: false,
+					Error:   "rótulo do token inválido",
+					Details: errorDetails(err),
+				})
+			case errors.Is(err, service.ErrInvalidClickUpToken):
+				middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+					Success: false,
+					Error:   "token do ClickUp inválido",
+					Details: "o ClickUp recusou o token; verifique se ele foi copiado corretamente",
+				})
+			case errors.Is(err, model.ErrRateLimited), errors.Is(err, model.ErrTimeout):
+				middleware.ErrorJSON(c, http.StatusBadGateway, model.ErrorResponse{
+					Success: false,
+					Error:   "não foi possível validar o token no ClickUp",
+					Details: errorDetails(err),
+				})
+			default:
+				middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+					Success: false,
+					Error:   "erro ao salvar configuração",
+					Details: errorDetails(err),
+				})
+			}
+			return
+		}
+	}
+	
+	if req.SyncWebhookURL != nil {
+		webhookURL := strings.TrimSpace(*req.SyncWebhookURL)
+		if webhookURL != "" && !isHTTPURL(webhookURL) {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "webhook de sincronização inválido",
+				Details: "informe uma URL http(s) ou vazio para remover",
+			})
+			return
+		}
+		if webhookURL != "" && h.webhooks != nil {
+			if err := h.webhooks.ValidateURL(c.Request.Context(), webhookURL); err != nil {
+				middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+					Success: false,
+					Error:   "webhook de sincronização inválido",
+					Details: err.Error(),
+				})
+				return
+			}
+		}
+		if err := h.configRepo.UpdateSyncWebhookURL(userID.(string), webhookURL); err != nil {
+			log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao atualizar webhook de sincronização")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   "erro ao salvar configuração",
+				Details: errorDetails(err),
+			})
+			return
+		}
+	}
+	
+	if req.DefaultSubtasks != nil || req.DefaultIncludeClosed != nil {
+		if err := h.configRepo.UpdateReportDefaults(userID.(string), req.DefaultSubtasks, req.DefaultIncludeClosed); err != nil {
+			log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao atualizar padrões de relatório")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   "erro ao salvar configuração",
+				Details: errorDetails(err),
+			})
+			return
+		}
+	}
+	
+	if req.RateLimitPerMinute != nil {
 		if err := h.configRepo.UpdateRateLimit(userID.(string), *req.RateLimitPerMinute); err != nil {
 			log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao atualizar rate limit")
-			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 				Success: false,
 				Error:   "erro ao salvar configuração",
-				Details: err.Error(),
+				Details: errorDetails(err),
 			})
 			return
 		}
@@ -150,12 +285,112 @@ func (h *ConfigHandler) SaveConfig(c *gin.Context) {
 		ClientIP: c.ClientIP(),
 		Success:  true,
 		Details: map[string]interface{}{
-			"rate_limit_per_minute": req.RateLimitPerMinute,
+			"rate_limit_per_minute":  req.RateLimitPerMinute,
+			"clickup_token_updated":  account != nil,
+			"clickup_token_label":    req.ClickUpTokenLabel,
+			"sync_webhook_updated":   req.SyncWebhookURL != nil,
+			"default_subtasks":       req.DefaultSubtasks,
+			"default_include_closed": req.DefaultIncludeClosed,
 		},
 	})
 
 	log.Info().Str("user_id", userID.(string)).Msg("Configuração salva com sucesso")
 	
+	response := model.Response{Success: true}
+	if account != nil {
+		response.Data = SaveConfigData{ClickUpAccount: account}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListTokens lists the user's saved ClickUp tokens
+// @Summary      List ClickUp tokens
+// @Description  Lists the labels of the user's saved ClickUp tokens (values are never returned)
+// @Tags         config
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200 {object} model.Response
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/config/tokens [get]
+func (h *ConfigHandler) ListTokens(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   "usuário não autenticado",
+		})
+		return
+	}
+	
+	tokens, err := h.tokenSaver.ListClickUpTokens(c.Request.Context(), userID.(string))
+	if err != nil {
+		logger.FromGin(c).Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao listar tokens")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao listar tokens",
+			Details: errorDetails(err),
+		})
+		return
+	}
+	if tokens == nil {
+		tokens = []service.ClickUpTokenSummary{}
+	}
+	
+	c.JSON(http.StatusOK, model.Response{
+		Success: true,
+		Data:    tokens,
+	})
+}
+
+// DeleteToken removes one of the user's saved ClickUp tokens
+// @Summary      Delete ClickUp token
+// @Description  Removes the user's ClickUp token saved under the label
+// @Tags         config
+// @Produce      json
+// @Security     BasicAuth
+// @Param        label path string true "Token label (default = token padrão)"
+// @Success      200 {object} model.Response
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      404 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/config/tokens/{label} [delete]
+func (h *ConfigHandler) DeleteToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   "usuário não autenticado",
+		})
+		return
+	}
+	
+	label := c.Param("label")
+	if err := h.tokenSaver.DeleteClickUpToken(c.Request.Context(), userID.(string), label); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTokenLabel):
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "rótulo do token inválido",
+				Details: errorDetails(err),
+			})
+		case errors.Is(err, service.ErrTokenNotConfigured):
+			middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
+				Success: false,
+				Error:   "token não encontrado",
+			})
+		default:
+			logger.FromGin(c).Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao remover token")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   "erro ao remover token",
+				Details: errorDetails(err),
+			})
+		}
+		return
+	}
+	
 	c.JSON(http.StatusOK, model.Response{
 		Success: true,
 	})
@@ -169,11 +404,34 @@ type ConfigResponse struct {
 
 // ConfigData contains the user's configuration
 type ConfigData struct {
-	HasToken           bool `json:"has_token"`
-	RateLimitPerMinute int  `json:"rate_limit_per_minute"`
+	HasToken           bool   `json:"has_token"`
+	RateLimitPerMinute int    `json:"rate_limit_per_minute"`
+	SyncWebhookURL     string `json:"sync_webhook_url,omitempty"`
+	// Report defaults used when a report request omits subtasks/include_closed (absent = false)
+	DefaultSubtasks      *bool `json:"default_subtasks,omitempty"`
+	DefaultIncludeClosed *bool `json:"default_include_closed,omitempty"`
 }
 
 // SaveConfigRequest represents the request to save configuration
 type SaveConfigRequest struct {
-	RateLimitPerMinute *int `json:"rate_limit_per_minute,omitempty"`
+	RateLimitPerMinute *int    `json:"rate_limit_per_minute,omitempty"`
+	ClickUpToken       *string `json:"clickup_token,omitempty"`
+	// ClickUpTokenLabel saves the token under a label, for users with several accounts ("" = default token)
+	ClickUpTokenLabel string `json:"clickup_token_label,omitempty"`
+	// SyncWebhookURL receives a JSON summary after each metadata sync ("" removes it)
+	SyncWebhookURL *string `json:"sync_webhook_url,omitempty"`
+	// DefaultSubtasks and DefaultIncludeClosed are applied to reports that omit the option
+	DefaultSubtasks      *bool `json:"default_subtasks,omitempty"`
+	DefaultIncludeClosed *bool `json:"default_include_closed,omitempty"`
+}
+
+// isHTTPURL reports whether raw is an absolute http(s) URL
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// SaveConfigData is returned when a ClickUp token was saved
+type SaveConfigData struct {
+	ClickUpAccount *service.ClickUpTokenInfo `json:"clickup_account"`
 }
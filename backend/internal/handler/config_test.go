@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func postConfig(h *ConfigHandler, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/web/config", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", "user-1")
+	c.Set("username", "ana")
+	h.SaveConfig(c)
+	return w
+}
+
+func TestSaveConfigRejectsInvalidClickUpToken(t *testing.T) {
+	var requests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"err":"Token invalid","ECODE":"OAUTH_025"}`))
+	}))
+	defer upstream.Close()
+
+	// Sem repositório: um token recusado não pode chegar a ser salvo
+	metadataService := service.NewMetadataService(nil, nil, "test-key")
+	metadataService.SetClientOptions(client.ClientOptions{BaseURL: upstream.URL})
+
+	h := NewConfigHandler(nil)
+	h.SetTokenSaver(metadataService)
+
+	w := postConfig(h, `{"clickup_token":"pk_invalido"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("esperado 400, obtido %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "token do ClickUp inválido") {
+		t.Errorf("mensagem inesperada: %s", w.Body.String())
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("esperada 1 chamada ao ClickUp, obtido %d", requests)
+	}
+}
+
+type fakeTokenSaver struct {
+	userID, label, token string
+}
+
+func (f *fakeTokenSaver) SaveClickUpToken(ctx context.Context, userID, label, token string) (*service.ClickUpTokenInfo, error) {
+	f.userID, f.label, f.token = userID, label, token
+	return &service.ClickUpTokenInfo{Username: "ana", WorkspaceCount: 2}, nil
+}
+
+func (f *fakeTokenSaver) ListClickUpTokens(ctx context.Context, userID string) ([]service.ClickUpTokenSummary, error) {
+	return nil, nil
+}
+
+func (f *fakeTokenSaver) DeleteClickUpToken(ctx context.Context, userID, label string) error {
+	return nil
+}
+
+func TestSaveConfigReturnsClickUpAccount(t *testing.T) {
+	saver := &fakeTokenSaver{}
+	h := NewConfigHandler(nil)
+	h.SetTokenSaver(saver)
+
+	w := postConfig(h, `{"clickup_token":"  pk_valido  ","clickup_token_label":"cliente-x"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("esperado 200, obtido %d: %s", w.Code, w.Body.String())
+	}
+	if saver.userID != "user-1" || saver.label != "cliente-x" || saver.token != "pk_valido" {
+		t.Errorf("token salvo para %q/%q = %q", saver.userID, saver.label, saver.token)
+	}
+
+	var resp struct {
+		Data SaveConfigData `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resp.Data.ClickUpAccount == nil || resp.Data.ClickUpAccount.Username != "ana" || resp.Data.ClickUpAccount.WorkspaceCount != 2 {
+		t.Errorf("conta inesperada: %+v", resp.Data.ClickUpAccount)
+	}
+}
+
+func TestSaveConfigRejectsEmptyClickUpToken(t *testing.T) {
+	saver := &fakeTokenSaver{}
+	h := NewConfigHandler(nil)
+	h.SetTokenSaver(saver)
+
+	if w := postConfig(h, `{"clickup_token":"   "}`); w.Code != http.StatusBadRequest {
+		t.Errorf("esperado 400, obtido %d", w.Code)
+	}
+	if saver.token != "" {
+		t.Error("token vazio não deveria ser enviado para validação")
+	}
+}
+
+func TestSaveConfigRejectsInvalidSyncWebhookURL(t *testing.T) {
+	h := NewConfigHandler(nil)
+
+	for _, body := range []string{`{"sync_webhook_url":"ftp://exemplo.com/hook"}`, `{"sync_webhook_url":"exemplo.com/hook"}`} {
+		w := postConfig(h, body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: esperado 400, obtido %d", body, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "webhook de sincronização inválido") {
+			t.Errorf("%s: mensagem inesperada: %s", body, w.Body.String())
+		}
+	}
+
+	// Com o validador, endereços internos também são recusados (antes de tocar no banco)
+	h.SetWebhookValidator(service.NewWebhookService())
+	for _, body := range []string{`{"sync_webhook_url":"http://127.0.0.1:8080/hook"}`, `{"sync_webhook_url":"http://169.254.169.254/latest"}`} {
+		if w := postConfig(h, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: esperado 400, obtido %d", body, w.Code)
+		}
+	}
+}
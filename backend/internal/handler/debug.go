@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// OptionValueResolver resolves dropdown/label option names to option IDs (implemented by *service.MetadataService)
+type OptionValueResolver interface {
+	ResolveOptionValue(fieldID, fieldType, value string) (string, error)
+}
+
+// DebugHandler handles diagnostic requests
+type DebugHandler struct {
+	options         OptionValueResolver
+	defaultTimezone string
+	defaultLocale   string
+}
+
+// NewDebugHandler creates a new debug handler; defaultTimezone is used for date values
+// when the request doesn't give one, like in jobs
+func NewDebugHandler(options OptionValueResolver, defaultTimezone string) *DebugHandler {
+	return &DebugHandler{
+		options:         options,
+		defaultTimezone: defaultTimezone,
+		defaultLocale:   client.DefaultLocale,
+	}
+}
+
+// SetDefaultLocale sets the locale used when the request doesn't give one, like in jobs
+func (h *DebugHandler) SetDefaultLocale(locale string) {
+	if client.IsSupportedLocale(locale) {
+		h.defaultLocale = locale
+	}
+}
+
+// TransformPreviewRequest represents a value to convert as a job would
+type TransformPreviewRequest struct {
+	Value     string `json:"value"`
+	FieldType string `json:"field_type" binding:"required"`
+	Locale    string `json:"locale,omitempty"`   // pt-BR or en-US (server default)
+	Timezone  string `json:"timezone,omitempty"` // e.g. America/Sao_Paulo (server default)
+	// FieldID resolves dropdown/label option names against the synced field first (optional)
+	FieldID string `json:"field_id,omitempty"`
+}
+
+// TransformPreview is the value a job would send to ClickUp
+type TransformPreview struct {
+	Value     string `json:"value"`
+	FieldType string `json:"field_type"`
+	Locale    string `json:"locale"`
+	Timezone  string `json:"timezone"`
+	// ResolvedValue is the value after option resolution, when a field ID was given
+	ResolvedValue *string `json:"resolved_value,omitempty"`
+	// OptionError explains why option resolution failed; nothing would be sent
+	OptionError string `json:"option_error,omitempty"`
+	// Output is the "value" of the request body sent to ClickUp
+	Output interface{} `json:"output"`
+}
+
+// TransformPreviewResponse represents the response for a transformation preview
+type TransformPreviewResponse struct {
+	Success bool              `json:"success"`
+	Data    *TransformPreview `json:"data"`
+}
+
+// PreviewTransform handles POST /api/web/debug/transform - Preview a field value conversion
+// @Summary      Preview field value transformation
+// @Description  Converts a cell value the way a job would for the given field type, locale and timezone, without calling ClickUp. With field_id, dropdown and label option names are resolved against the synced field first.
+// @Tags         debug
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body TransformPreviewRequest true "Value and field type"
+// @Success      200 {object} TransformPreviewResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Router       /api/web/debug/transform [post]
+func (h *DebugHandler) PreviewTransform(c *gin.Context) {
+	var req TransformPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "payload inválido",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	opts := client.TransformOptions{Locale: h.defaultLocale, Location: time.UTC}
+	if req.Locale != "" {
+		if !client.IsSupportedLocale(req.Locale) {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "locale inválido",
+				Details: "use pt-BR ou en-US",
+			})
+			return
+		}
+		opts.Locale = req.Locale
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = h.defaultTimezone
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "timezone inválido",
+				Details: err.Error(),
+			})
+			return
+		}
+		opts.Location = loc
+	}
+
+	fieldType := strings.TrimSpace(req.FieldType)
+	preview := &TransformPreview{
+		Value:     req.Value,
+		FieldType: fieldType,
+		Locale:    opts.Locale,
+		Timezone:  opts.Location.String(),
+	}
+
+	value := req.Value
+	if req.FieldID != "" && h.options != nil {
+		resolved, err := h.options.ResolveOptionValue(req.FieldID, fieldType, value)
+		if err != nil {
+			preview.OptionError = err.Error()
+			c.JSON(http.StatusOK, TransformPreviewResponse{Success: true, Data: preview})
+			return
+		}
+		preview.ResolvedValue = &resolved
+		value = resolved
+	}
+
+	preview.Output = client.TransformFieldValueWithOptions(value, fieldType, opts)
+
+	c.JSON(http.StatusOK, TransformPreviewResponse{
+		Success: true,
+		Data:    preview,
+	})
+}
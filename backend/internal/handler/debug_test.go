@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// fixedOptions resolves option names from a fixed table, like synced dropdown fields
+type fixedOptions map[string]map[string]string
+
+func (f fixedOptions) ResolveOptionValue(fieldID, fieldType, value string) (string, error) {
+	options, ok := f[fieldID]
+	if !ok {
+		return value, nil
+	}
+	id, ok := options[strings.ToLower(value)]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", service.ErrInvalidMapping, value)
+	}
+	return id, nil
+}
+
+func transformPreview(t *testing.T, body string) (int, TransformPreview) {
+	gin.SetMode(gin.TestMode)
+	h := NewDebugHandler(fixedOptions{"f-status": {"aberto": "opt-1"}}, "America/Sao_Paulo")
+	r := gin.New()
+	r.POST("/api/web/debug/transform", h.PreviewTransform)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/web/debug/transform", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var resp TransformPreviewResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data == nil {
+			t.Fatalf("invalid JSON %s: %v", w.Body.String(), err)
+		}
+		return w.Code, *resp.Data
+	}
+	return w.Code, TransformPreview{}
+}
+
+func TestPreviewTransform(t *testing.T) {
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	midnightSP := time.Date(2024, 3, 15, 0, 0, 0, 0, saoPaulo).UnixMilli()
+	midnightUTC := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	tests := []struct {
+		name string
+		body string
+		want interface{} // as decoded from JSON
+	}{
+		{"number pt-BR", `{"value": "1.234,56", "field_type": "number"}`, 1234.56},
+		{"number en-US", `{"value": "1,234.56", "field_type": "currency", "locale": "en-US"}`, 1234.56},
+		{"checkbox", `{"value": "sim", "field_type": "checkbox"}`, true},
+		{"users", `{"value": "12, 34", "field_type": "users"}`, []interface{}{float64(12), float64(34)}},
+		{"date in the default timezone", `{"value": "15/03/2024", "field_type": "date"}`, float64(midnightSP)},
+		{"date in the given timezone", `{"value": "15/03/2024", "field_type": "date", "timezone": "UTC"}`, float64(midnightUTC)},
+		{"text", `{"value": " olá ", "field_type": "text"}`, " olá "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, preview := transformPreview(t, tt.body)
+			if code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", code)
+			}
+			if !reflect.DeepEqual(preview.Output, tt.want) {
+				t.Errorf("output = %#v, want %#v", preview.Output, tt.want)
+			}
+			if preview.ResolvedValue != nil {
+				t.Errorf("unexpected option resolution: %q", *preview.ResolvedValue)
+			}
+		})
+	}
+}
+
+func TestPreviewTransformResolvesOptions(t *testing.T) {
+	code, preview := transformPreview(t, `{"value": "Aberto", "field_type": "drop_down", "field_id": "f-status"}`)
+	if code != http.StatusOK || preview.ResolvedValue == nil || *preview.ResolvedValue != "opt-1" || preview.Output != "opt-1" {
+		t.Errorf("unexpected preview (%d): %+v", code, preview)
+	}
+
+	code, preview = transformPreview(t, `{"value": "Cancelado", "field_type": "drop_down", "field_id": "f-status"}`)
+	if code != http.StatusOK || preview.OptionError == "" || preview.Output != nil {
+		t.Errorf("expected an option error, got (%d): %+v", code, preview)
+	}
+}
+
+func TestPreviewTransformInvalidRequest(t *testing.T) {
+	for _, body := range []string{
+		`{"value": "1"}`,
+		`{"value": "1", "field_type": "number", "locale": "fr-FR"}`,
+		`{"value": "1", "field_type": "date", "timezone": "Mars/Olympus"}`,
+	} {
+		if code, _ := transformPreview(t, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// requireTempPath rejects with 400 a file path outside the upload temp directory (path
+// traversal or any other file on the server); it returns false when the request was
+// answered. All endpoints that accept a file path use it, so the error is the same.
+func requireTempPath(c *gin.Context, uploadService *service.UploadService, path string) bool {
+	if err := uploadService.ValidateTempPath(path); err != nil {
+		logger.FromGin(c).Warn().Str("file_path", path).Msg("Tentativa de path traversal detectada")
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Details: "o arquivo deve ser um upload temporário deste servidor",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// filePathRouter registers every endpoint that accepts a file path, as an authenticated user
+func filePathRouter(uploadService *service.UploadService, mappingService *service.MappingService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+	})
+
+	uploadHandler := NewUploadHandler(uploadService)
+	mappingHandler := NewMappingHandler(mappingService, uploadService)
+	queueHandler := NewQueueHandler(service.NewQueueService(nil, nil), uploadService, mappingService)
+
+	r.POST("/api/web/upload/cleanup", uploadHandler.DeleteTempFile)
+	r.POST("/api/web/mapping", mappingHandler.SaveMapping)
+	r.POST("/api/web/mapping/validate", mappingHandler.ValidateMapping)
+	r.POST("/api/web/mapping/suggest", mappingHandler.SuggestMappings)
+	r.POST("/api/web/jobs", queueHandler.CreateJob)
+	return r
+}
+
+func TestFileEndpointsRejectPathTraversal(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := service.NewUploadService(tempDir, 0)
+	mappingService := service.NewMappingService(nil)
+	r := filePathRouter(uploadService, mappingService)
+
+	// A file next to the temp dir that traversal would reach
+	secret := filepath.Join(filepath.Dir(tempDir), "secret.csv")
+	if err := os.WriteFile(secret, []byte("id task,Valor\nT1,1\n"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	defer os.Remove(secret)
+
+	paths := []string{
+		filepath.Join(tempDir, "..", "secret.csv"),
+		"../../etc/passwd",
+		tempDir + "/../" + filepath.Base(tempDir) + "/upload_x.csv",
+		secret,
+		tempDir + "2/upload_x.csv",
+		"upload_x.csv\x00.csv",
+	}
+
+	for _, path := range paths {
+		bodies := map[string]interface{}{
+			"/api/web/upload/cleanup":   gin.H{"temp_path": path},
+			"/api/web/mapping":          gin.H{"file_path": path, "title": "Mapeamento", "mappings": []gin.H{{"column": "id task", "is_task_id": true}}},
+			"/api/web/mapping/validate": gin.H{"file_path": path, "title": "Mapeamento", "mappings": []gin.H{{"column": "id task", "is_task_id": true}}},
+			"/api/web/mapping/suggest":  gin.H{"file_path": path},
+		}
+
+		// Jobs read the path of a saved mapping; save one directly, bypassing the handler
+		stored, _ := mappingService.SaveMapping("user-1", &service.MappingRequest{FilePath: path, Title: "Mapeamento"})
+		bodies["/api/web/jobs"] = gin.H{"mapping_id": stored.ID, "title": "Job"}
+
+		for endpoint, body := range bodies {
+			data, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data)))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s with %q: expected 400, got %d: %s", endpoint, path, w.Code, w.Body.String())
+				continue
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Success || resp.Error != service.ErrInvalidTempPath.Error() {
+				t.Errorf("%s with %q: unexpected error body %s", endpoint, path, w.Body.String())
+			}
+		}
+	}
+
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("file outside the temp dir was touched: %v", err)
+	}
+}
+
+func TestFileEndpointsAcceptUploadedFile(t *testing.T) {
+	uploadService := service.NewUploadService(t.TempDir(), 0)
+	r := filePathRouter(uploadService, service.NewMappingService(nil))
+
+	csv := "id task,Valor\nT1,1\n"
+	upload, err := uploadService.ProcessFile("dados.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	data, _ := json.Marshal(gin.H{"temp_path": upload.TempPath})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/web/upload/cleanup", bytes.NewReader(data)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the uploaded file to be removed, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(upload.TempPath); !os.IsNotExist(err) {
+		t.Errorf("temp file still exists: %v", err)
+	}
+}
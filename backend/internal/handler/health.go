@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"net/http"
+	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +21,56 @@ type HealthHandler struct {
 	db        *sql.DB
 	wsHub     *websocket.Hub
 	version   string
+	build     metrics.BuildInfo
 	startTime time.Time
+	clickup   *metrics.CachedHealthCheck
+
+	// Readiness gates
+	migrationsDone  int32
+	queueProcessor  QueueProcessorStatus
+	processorMaxAge time.Duration
+
+	// Queue backlog thresholds
+	queueStats       QueueStatsProvider
+	queueMaxPending  int
+	queueMaxWaitTime time.Duration
+
+	// Pool waits per sample above which the database is reported as degraded (0 disables)
+	dbPoolMaxWaits int64
+}
+
+// QueueStatsProvider reports the job queue depth
+type QueueStatsProvider interface {
+	GetQueueStats() (*repository.QueueStats, error)
+}
+
+// Default queue backlog thresholds above which the queue is reported as degraded
+const (
+	DefaultQueueMaxPending  = 100
+	DefaultQueueMaxWaitTime = 15 * time.Minute
+)
+
+// DefaultProcessorStaleAfter is how long the queue processor may go without a
+// heartbeat before readiness fails. The dispatch loop beats every few seconds and
+// never blocks on a job, so a longer gap means it is wedged.
+const DefaultProcessorStaleAfter = 2 * time.Minute
+
+// QueueProcessorStatus exposes the liveness signals of the background job processor
+type QueueProcessorStatus interface {
+	IsRunning() bool
+	LastHeartbeat() time.Time
+}
+
+// ClickUp upstream check settings
+const (
+	clickUpHealthTimeout   = 5 * time.Second
+	clickUpHealthCacheTTL  = 30 * time.Second
+	clickUpDegradedLatency = 2 * time.Second
+)
+
+// ClickUpPinger is the client call used to probe ClickUp reachability
+type ClickUpPinger interface {
+	ValidateToken(ctx context.Context) error
 }
 
 // NewHealthHandler creates a new health handler
@@ -23,6 +78,7 @@ func NewHealthHandler(db *sql.DB, version string) *HealthHandler {
 	return &HealthHandler{
 		db:        db,
 		version:   version,
+		build:     newBuildInfo(version),
 		startTime: time.Now(),
 	}
 }
@@ -33,10 +89,170 @@ func NewHealthHandlerWithWebSocket(db *sql.DB, wsHub *websocket.Hub, version str
 		db:        db,
 		wsHub:     wsHub,
 		version:   version,
+		build:     newBuildInfo(version),
 		startTime: time.Now(),
 	}
 }
 
+// newBuildInfo describes a build of version made with the running Go toolchain
+func newBuildInfo(version string) metrics.BuildInfo {
+	return metrics.BuildInfo{
+		Version:   version,
+		GitCommit: "unknown",
+		GoVersion: runtime.Version(),
+	}
+}
+
+// SetBuildInfo records the git commit and build time of the binary (empty values are ignored)
+func (h *HealthHandler) SetBuildInfo(gitCommit, buildTime string) {
+	if gitCommit != "" {
+		h.build.GitCommit = gitCommit
+	}
+	if buildTime != "" {
+		h.build.BuildTime = buildTime
+	}
+}
+
+// SetClickUpPinger enables the ClickUp component in the detailed health check.
+// Results are cached for clickUpHealthCacheTTL to avoid hammering ClickUp on frequent polls.
+func (h *HealthHandler) SetClickUpPinger(pinger ClickUpPinger) {
+	h.clickup = metrics.NewCachedHealthCheck(clickUpHealthCacheTTL, func() metrics.HealthStatus {
+		return CheckClickUpHealth(pinger, clickUpHealthTimeout)
+	})
+}
+
+// CheckClickUpHealth probes ClickUp and classifies the result:
+// slow answers and rate limiting are degraded, timeouts and server errors are unhealthy
+func CheckClickUpHealth(pinger ClickUpPinger, timeout time.Duration) metrics.HealthStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := pinger.ValidateToken(ctx)
+	elapsed := time.Since(start)
+	latency := elapsed.Milliseconds()
+
+	switch {
+	case err == nil:
+		if elapsed > clickUpDegradedLatency {
+			return metrics.HealthStatus{Status: "degraded", Message: "high latency", Latency: latency}
+		}
+		return metrics.HealthStatus{Status: "healthy", Latency: latency}
+	case errors.Is(err, model.ErrRateLimited):
+		return metrics.HealthStatus{Status: "degraded", Message: "rate limited", Latency: latency}
+	case errors.Is(err, model.ErrUnauthorized):
+		// ClickUp respondeu, mas o token do sistema foi rejeitado
+		return metrics.HealthStatus{Status: "degraded", Message: "system token rejected", Latency: latency}
+	case errors.Is(err, model.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return metrics.HealthStatus{Status: "unhealthy", Message: "timeout", Latency: latency}
+	default:
+		return metrics.HealthStatus{Status: "unhealthy", Message: err.Error(), Latency: latency}
+	}
+}
+
+// MarkMigrationsComplete opens the migrations readiness gate
+func (h *HealthHandler) MarkMigrationsComplete() {
+	atomic.StoreInt32(&h.migrationsDone, 1)
+}
+
+// SetQueueProcessor gates readiness on the job processor running with a fresh heartbeat
+func (h *HealthHandler) SetQueueProcessor(processor QueueProcessorStatus, staleAfter time.Duration) {
+	if staleAfter <= 0 {
+		staleAfter = DefaultProcessorStaleAfter
+	}
+	h.queueProcessor = processor
+	h.processorMaxAge = staleAfter
+}
+
+// SetQueueStats adds the queue depth and the wait of the oldest pending job to the
+// detailed health check, which turns degraded when either passes its threshold
+// (<= 0 uses the defaults)
+func (h *HealthHandler) SetQueueStats(provider QueueStatsProvider, maxPending int, maxWaitTime time.Duration) {
+	if maxPending <= 0 {
+		maxPending = DefaultQueueMaxPending
+	}
+	if maxWaitTime <= 0 {
+		maxWaitTime = DefaultQueueMaxWaitTime
+	}
+	h.queueStats = provider
+	h.queueMaxPending = maxPending
+	h.queueMaxWaitTime = maxWaitTime
+}
+
+// SetDBPoolWaitThreshold marks the database as degraded in the detailed health check
+// when more than maxWaits queries waited for a pool connection since the last sample
+func (h *HealthHandler) SetDBPoolWaitThreshold(maxWaits int) {
+	h.dbPoolMaxWaits = int64(maxWaits)
+}
+
+// checkDatabaseHealth checks connectivity and, when enabled, pool saturation
+func (h *HealthHandler) checkDatabaseHealth() metrics.HealthStatus {
+	status := metrics.CheckDatabaseHealth(h.db)
+	if status.Status != "healthy" || h.dbPoolMaxWaits <= 0 {
+		return status
+	}
+
+	pool := metrics.Get().CheckDBPoolHealth(h.dbPoolMaxWaits)
+	if pool.Status != "healthy" {
+		pool.Latency = status.Latency
+		return pool
+	}
+	return status
+}
+
+// refreshQueueStats reads the queue depth and records it in the metrics
+func (h *HealthHandler) refreshQueueStats() (*repository.QueueStats, error) {
+	stats, err := h.queueStats.GetQueueStats()
+	if err != nil {
+		return nil, err
+	}
+	metrics.Get().RecordQueueStats(stats.Pending, stats.Scheduled, stats.OldestPendingAge)
+	return stats, nil
+}
+
+// checkMigrations reports whether migrations completed at startup
+func (h *HealthHandler) checkMigrations() metrics.HealthStatus {
+	if atomic.LoadInt32(&h.migrationsDone) == 0 {
+		return metrics.HealthStatus{
+			Status:  "unhealthy",
+			Message: "migrations not completed",
+		}
+	}
+	return metrics.HealthStatus{Status: "healthy"}
+}
+
+// checkQueueProcessor reports whether the job processor is running and not wedged
+func (h *HealthHandler) checkQueueProcessor() metrics.HealthStatus {
+	if h.queueProcessor == nil || !h.queueProcessor.IsRunning() {
+		return metrics.HealthStatus{
+			Status:  "unhealthy",
+			Message: "queue processor not running",
+		}
+	}
+
+	age := time.Since(h.queueProcessor.LastHeartbeat())
+	if age > h.processorMaxAge {
+		return metrics.HealthStatus{
+			Status:  "unhealthy",
+			Message: "queue processor heartbeat stale",
+			Latency: age.Milliseconds(),
+		}
+	}
+
+	return metrics.HealthStatus{Status: "healthy"}
+}
+
+// GetVersion returns the build information of the running binary
+// @Summary Version and build information
+// @Description Returns the version, git commit, build time and Go version of the running binary
+// @Tags health
+// @Produce json
+// @Success 200 {object} metrics.BuildInfo
+// @Router /version [get]
+func (h *HealthHandler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, h.build)
+}
+
 // LivenessCheck returns basic liveness status
 // @Summary Liveness check
 // @Description Returns basic liveness status for Kubernetes probes
@@ -52,7 +268,7 @@ func (h *HealthHandler) LivenessCheck(c *gin.Context) {
 
 // ReadinessCheck returns readiness status including dependencies
 // @Summary Readiness check
-// @Description Returns readiness status including database connectivity
+// @Description Returns readiness status including database connectivity, migrations and queue processor heartbeat
 // @Tags health
 // @Produce json
 // @Success 200 {object} metrics.HealthCheck
@@ -67,6 +283,10 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 	// Check memory (512MB limit as per requirements)
 	components["memory"] = metrics.CheckMemoryHealth(512)
 
+	// Gate on startup completion: migrations applied and job processor live
+	components["migrations"] = h.checkMigrations()
+	components["queue_processor"] = h.checkQueueProcessor()
+
 	// Determine overall status
 	overallStatus := metrics.DetermineOverallStatus(components)
 
@@ -76,6 +296,7 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 		Uptime:     time.Since(h.startTime).String(),
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
 		Components: components,
+		Build:      &h.build,
 	}
 
 	statusCode := http.StatusOK
@@ -98,7 +319,7 @@ func (h *HealthHandler) DetailedHealthCheck(c *gin.Context) {
 	components := make(map[string]metrics.HealthStatus)
 
 	// Check database
-	components["database"] = metrics.CheckDatabaseHealth(h.db)
+	components["database"] = h.checkDatabaseHealth()
 
 	// Check memory
 	components["memory"] = metrics.CheckMemoryHealth(512)
@@ -111,6 +332,11 @@ func (h *HealthHandler) DetailedHealthCheck(c *gin.Context) {
 	// Check queue processor
 	components["queue"] = h.checkQueueHealth()
 
+	// Check ClickUp API reachability (cached)
+	if h.clickup != nil {
+		components["clickup"] = h.clickup.Status()
+	}
+
 	// Determine overall status
 	overallStatus := metrics.DetermineOverallStatus(components)
 
@@ -120,6 +346,7 @@ func (h *HealthHandler) DetailedHealthCheck(c *gin.Context) {
 		Uptime:     time.Since(h.startTime).String(),
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
 		Components: components,
+		Build:      &h.build,
 	}
 
 	statusCode := http.StatusOK
@@ -155,8 +382,40 @@ func (h *HealthHandler) checkWebSocketHealth() metrics.HealthStatus {
 	}
 }
 
-// checkQueueHealth checks queue processor health
+// checkQueueHealth checks queue processor health and, when configured, the backlog
 func (h *HealthHandler) checkQueueHealth() metrics.HealthStatus {
+	if h.queueStats != nil {
+		stats, err := h.refreshQueueStats()
+		if err != nil {
+			return metrics.HealthStatus{
+				Status:  "degraded",
+				Message: "queue stats unavailable",
+			}
+		}
+
+		details := map[string]interface{}{
+			"pending":                stats.Pending,
+			"scheduled":              stats.Scheduled,
+			"processing":             stats.Processing,
+			"oldest_pending_seconds": int64(stats.OldestPendingAge / time.Second),
+		}
+		switch {
+		case stats.OldestPendingAge > h.queueMaxWaitTime:
+			return metrics.HealthStatus{Status: "degraded", Message: "oldest pending job waiting too long", Details: details}
+		case stats.Pending > h.queueMaxPending:
+			return metrics.HealthStatus{Status: "degraded", Message: "too many pending jobs", Details: details}
+		}
+
+		status := h.checkQueueMetrics()
+		status.Details = details
+		return status
+	}
+
+	return h.checkQueueMetrics()
+}
+
+// checkQueueMetrics checks the in-process job counters
+func (h *HealthHandler) checkQueueMetrics() metrics.HealthStatus {
 	snapshot := metrics.Get().Snapshot()
 
 	// Check if there are too many jobs processing
@@ -196,6 +455,25 @@ func (h *HealthHandler) GetMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, snapshot)
 }
 
+// GetPrometheusMetrics returns application metrics in the Prometheus text format
+// @Summary Get Prometheus metrics
+// @Description Returns application metrics, including the ClickUp rate limit, in the Prometheus text exposition format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics/prometheus [get]
+func (h *HealthHandler) GetPrometheusMetrics(c *gin.Context) {
+	if h.queueStats != nil {
+		// Stale numbers are kept if the database can't be read
+		_, _ = h.refreshQueueStats()
+	}
+
+	c.Header("Content-Type", metrics.PrometheusContentType)
+	c.Status(http.StatusOK)
+	// Write errors only happen when the client went away
+	_ = metrics.Get().WritePrometheus(c.Writer)
+}
+
 // GetMetricsSummary returns a summary of key metrics
 // @Summary Get metrics summary
 // @Description Returns a summary of key application metrics
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckClickUpHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus string
+		wantMsg    string
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"user":{"id":1,"username":"bot"}}`))
+			},
+			wantStatus: "healthy",
+		},
+		{
+			name: "rate limited",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantStatus: "degraded",
+			wantMsg:    "rate limited",
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(2 * time.Second):
+				case <-r.Context().Done():
+				}
+			},
+			wantStatus: "unhealthy",
+			wantMsg:    "timeout",
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantStatus: "unhealthy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			c := client.NewClient("pk_test")
+			c.SetBaseURL(server.URL)
+
+			status := CheckClickUpHealth(c, 100*time.Millisecond)
+			if status.Status != tt.wantStatus {
+				t.Errorf("status = %s (%s), esperado %s", status.Status, status.Message, tt.wantStatus)
+			}
+			if tt.wantMsg != "" && status.Message != tt.wantMsg {
+				t.Errorf("message = %q, esperado %q", status.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+type countingPinger struct {
+	calls int32
+}
+
+func (p *countingPinger) ValidateToken(ctx context.Context) error {
+	atomic.AddInt32(&p.calls, 1)
+	return nil
+}
+
+func TestClickUpHealthIsCached(t *testing.T) {
+	pinger := &countingPinger{}
+	h := NewHealthHandler(nil, "test")
+	h.SetClickUpPinger(pinger)
+
+	for i := 0; i < 5; i++ {
+		h.clickup.Status()
+	}
+	if calls := atomic.LoadInt32(&pinger.calls); calls != 1 {
+		t.Errorf("esperado 1 chamada ao ClickUp, obtido %d", calls)
+	}
+}
+
+func TestOverallStatusWithClickUpOutage(t *testing.T) {
+	components := map[string]metrics.HealthStatus{
+		"database": {Status: "healthy"},
+		"clickup":  {Status: "unhealthy", Message: "timeout"},
+	}
+	if got := metrics.DetermineOverallStatus(components); got != "degraded" {
+		t.Errorf("overall = %s, esperado degraded", got)
+	}
+
+	components["database"] = metrics.HealthStatus{Status: "unhealthy"}
+	if got := metrics.DetermineOverallStatus(components); got != "unhealthy" {
+		t.Errorf("overall = %s, esperado unhealthy", got)
+	}
+}
+
+// pingDriver is a database/sql driver whose connections always answer Ping
+type pingDriver struct{}
+
+type pingConn struct{}
+
+func (pingDriver) Open(string) (driver.Conn, error)  { return pingConn{}, nil }
+func (pingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (pingConn) Close() error                        { return nil }
+func (pingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("health-ping", pingDriver{})
+}
+
+type fakeProcessor struct {
+	running   bool
+	heartbeat time.Time
+}
+
+func (p *fakeProcessor) IsRunning() bool          { return p.running }
+func (p *fakeProcessor) LastHeartbeat() time.Time { return p.heartbeat }
+
+func readinessCode(h *HealthHandler) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	h.ReadinessCheck(c)
+	return w.Code
+}
+
+func TestReadinessGatesOnProcessorHeartbeat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := sql.Open("health-ping", "")
+	if err != nil {
+		t.Fatalf("abrir db: %v", err)
+	}
+	defer db.Close()
+
+	processor := &fakeProcessor{}
+	h := NewHealthHandler(db, "test")
+	h.SetQueueProcessor(processor, time.Minute)
+
+	if code := readinessCode(h); code != http.StatusServiceUnavailable {
+		t.Errorf("antes das migrações: %d, esperado 503", code)
+	}
+
+	h.MarkMigrationsComplete()
+	if code := readinessCode(h); code != http.StatusServiceUnavailable {
+		t.Errorf("processador parado: %d, esperado 503", code)
+	}
+
+	processor.running = true
+	processor.heartbeat = time.Now()
+	if code := readinessCode(h); code != http.StatusOK {
+		t.Errorf("processador ativo: %d, esperado 200", code)
+	}
+
+	processor.heartbeat = time.Now().Add(-2 * time.Minute)
+	if code := readinessCode(h); code != http.StatusServiceUnavailable {
+		t.Errorf("heartbeat antigo: %d, esperado 503", code)
+	}
+
+	processor.heartbeat = time.Now()
+	if code := readinessCode(h); code != http.StatusOK {
+		t.Errorf("heartbeat renovado: %d, esperado 200", code)
+	}
+}
+
+type fakeQueueStats struct {
+	stats *repository.QueueStats
+	err   error
+}
+
+func (f *fakeQueueStats) GetQueueStats() (*repository.QueueStats, error) { return f.stats, f.err }
+
+func TestQueueHealthThresholds(t *testing.T) {
+	provider := &fakeQueueStats{stats: &repository.QueueStats{Pending: 3, Processing: 1, OldestPendingAge: time.Minute}}
+	h := NewHealthHandler(nil, "test")
+	h.SetQueueStats(provider, 10, 5*time.Minute)
+
+	status := h.checkQueueHealth()
+	if status.Status == "degraded" {
+		t.Errorf("fila dentro dos limites marcada como degraded: %+v", status)
+	}
+	if status.Details["pending"] != 3 || status.Details["oldest_pending_seconds"] != int64(60) {
+		t.Errorf("detalhes = %v", status.Details)
+	}
+
+	provider.stats = &repository.QueueStats{Pending: 11}
+	if status := h.checkQueueHealth(); status.Status != "degraded" {
+		t.Errorf("muitos jobs pendentes: %+v", status)
+	}
+
+	provider.stats = &repository.QueueStats{Pending: 1, OldestPendingAge: 6 * time.Minute}
+	if status := h.checkQueueHealth(); status.Status != "degraded" {
+		t.Errorf("job antigo na fila: %+v", status)
+	}
+
+	provider.err = errors.New("connection refused")
+	if status := h.checkQueueHealth(); status.Status != "degraded" {
+		t.Errorf("erro ao consultar a fila: %+v", status)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHealthHandler(nil, "2.7.1")
+	h.SetBuildInfo("3b0913e", "2024-05-02T10:00:00Z")
+
+	r := gin.New()
+	r.GET("/version", h.GetVersion)
+	r.GET("/health", h.DetailedHealthCheck)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, esperado 200", w.Code)
+	}
+	var build metrics.BuildInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &build); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+	want := metrics.BuildInfo{Version: "2.7.1", GitCommit: "3b0913e", BuildTime: "2024-05-02T10:00:00Z", GoVersion: runtime.Version()}
+	if build != want {
+		t.Errorf("build = %+v, esperado %+v", build, want)
+	}
+
+	// O health check traz as mesmas informações
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health metrics.HealthCheck
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+	if health.Version != "2.7.1" || health.Build == nil || *health.Build != want {
+		t.Errorf("health sem informações de build: %+v", health)
+	}
+}
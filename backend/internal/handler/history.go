@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"github.com/cleberrangel/clickup-excel-api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -38,12 +41,19 @@ type DeleteHistoryRequest struct {
 	Confirm bool `json:"confirm" binding:"required"`
 }
 
-// ListHistory returns operation history for the current user (last 50)
+// ListHistory returns a page of operation history for the current user
 // @Summary List operation history
-// @Description Returns the last 50 operation history entries for the authenticated user
+// @Description Returns operation history entries for the authenticated user, newest first (default: first 50)
 // @Tags history
 // @Produce json
+// @Param limit query int false "Page size (max 200)" default(50)
+// @Param offset query int false "Entries to skip" default(0)
+// @Param status query string false "Filter by status (pending, processing, completed, failed)"
+// @Param operation_type query string false "Filter by operation type (report_generation, field_update)"
+// @Param from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC3339 or YYYY-MM-DD)"
 // @Success 200 {object} []HistoryResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/web/history [get]
@@ -53,17 +63,27 @@ func (h *HistoryHandler) ListHistory(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
 		return
 	}
 
-	history, err := h.historyService.GetHistoryByUser(userID.(string))
+	filter, err := parseHistoryFilter(c)
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Parâmetros inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	history, total, err := h.historyService.ListHistory(userID.(string), filter)
 	if err != nil {
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao listar histórico")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao listar histórico",
 			"details": err.Error(),
@@ -80,12 +100,142 @@ func (h *HistoryHandler) ListHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    responses,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
 	})
 }
 
+// parseHistoryFilter reads pagination and filter query params, applying defaults
+func parseHistoryFilter(c *gin.Context) (repository.HistoryFilter, error) {
+	filter := repository.HistoryFilter{
+		Limit:         repository.DefaultHistoryLimit,
+		Status:        c.Query("status"),
+		OperationType: c.Query("operation_type"),
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > repository.MaxHistoryLimit {
+			return filter, fmt.Errorf("limit deve estar entre 1 e %d", repository.MaxHistoryLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("offset deve ser um inteiro não negativo")
+		}
+		filter.Offset = offset
+	}
+
+	switch filter.Status {
+	case "", service.OperationStatusPending, service.OperationStatusProcessing,
+		service.OperationStatusCompleted, service.OperationStatusFailed:
+	default:
+		return filter, fmt.Errorf("status inválido: %s", filter.Status)
+	}
+
+	switch filter.OperationType {
+	case "", service.OperationTypeReportGeneration, service.OperationTypeFieldUpdate:
+	default:
+		return filter, fmt.Errorf("operation_type inválido: %s", filter.OperationType)
+	}
+
+	var err error
+	if filter.From, err = parseHistoryDate(c.Query("from")); err != nil {
+		return filter, fmt.Errorf("from inválido: %w", err)
+	}
+	if filter.To, err = parseHistoryDate(c.Query("to")); err != nil {
+		return filter, fmt.Errorf("to inválido: %w", err)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("from deve ser anterior a to")
+	}
+
+	return filter, nil
+}
+
+// parseHistoryDate accepts RFC3339 timestamps or plain dates (YYYY-MM-DD, UTC)
+func parseHistoryDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("use RFC3339 ou YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+// ExportHistory streams the full operation history of the current user
+// @Summary Export operation history
+// @Description Downloads all operation history entries for the authenticated user as CSV or JSON
+// @Tags history
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "Export format (csv or json)" default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/web/history/export [get]
+func (h *HistoryHandler) ExportHistory(c *gin.Context) {
+	log := logger.Get(c.Request.Context())
+
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.HistoryExportCSV)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case service.HistoryExportCSV:
+	case service.HistoryExportJSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Formato inválido",
+			"details": "use format=csv ou format=json",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("historico_%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Status(http.StatusOK)
+
+	if err := h.historyService.ExportHistory(userID.(string), format, c.Writer); err != nil {
+		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao exportar histórico")
+		// Com o corpo já iniciado, só resta interromper o download
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Erro ao exportar histórico",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+}
+
 // GetHistory returns a specific operation history entry by ID
 // @Summary Get operation history by ID
-// @Description Returns a specific operation history entry by its ID
+// @Description Returns a specific operation history entry by its ID. Field update entries carry the job summary in details (totals, per-column success/error counts, duration and rows per minute), available after the uploaded file is removed.
 // @Tags history
 // @Produce json
 // @Param id path int true "History ID"
@@ -100,7 +250,7 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
@@ -111,7 +261,7 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	historyIDStr := c.Param("id")
 	historyID, err := strconv.Atoi(historyIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "ID do histórico inválido",
 		})
@@ -121,14 +271,14 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	history, err := h.historyService.GetHistoryByID(historyID)
 	if err != nil {
 		if err == service.ErrHistoryNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
+			middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
 				"success": false,
 				"error":   "Registro de histórico não encontrado",
 			})
 			return
 		}
 		log.Error().Err(err).Int("history_id", historyID).Msg("Erro ao buscar histórico")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao buscar histórico",
 			"details": err.Error(),
@@ -138,7 +288,7 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 
 	// Verify history belongs to user
 	if history.UserID != userID.(string) {
-		c.JSON(http.StatusNotFound, gin.H{
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
 			"success": false,
 			"error":   "Registro de histórico não encontrado",
 		})
@@ -170,7 +320,7 @@ func (h *HistoryHandler) DeleteAllHistory(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
@@ -180,7 +330,7 @@ func (h *HistoryHandler) DeleteAllHistory(c *gin.Context) {
 	var req DeleteHistoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warn().Err(err).Msg("Erro ao fazer bind do request")
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados inválidos",
 			"details": err.Error(),
@@ -190,7 +340,7 @@ func (h *HistoryHandler) DeleteAllHistory(c *gin.Context) {
 
 	// Require explicit confirmation
 	if !req.Confirm {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Confirmação necessária para deletar histórico",
 		})
@@ -200,7 +350,7 @@ func (h *HistoryHandler) DeleteAllHistory(c *gin.Context) {
 	err := h.historyService.DeleteAllHistoryByUser(userID.(string))
 	if err != nil {
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao deletar histórico")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao deletar histórico",
 			"details": err.Error(),
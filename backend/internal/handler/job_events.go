@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSSEHeartbeat is how often an idle event stream gets a comment line, so
+// proxies don't close it
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// JobLookup reads a job by ID
+type JobLookup interface {
+	GetJobByID(jobID int) (*repository.UpdateJob, error)
+}
+
+// ProgressSubscriber relays the progress updates broadcast for a job (implemented by websocket.Hub)
+type ProgressSubscriber interface {
+	SubscribeProgress(userID string, jobID int) (<-chan websocket.ProgressUpdate, func())
+}
+
+// SetProgressEvents enables the server-sent events stream of job progress
+func (h *QueueHandler) SetProgressEvents(subscriber ProgressSubscriber) {
+	h.progressEvents = subscriber
+}
+
+// JobEvents streams a job's progress as server-sent events
+// @Summary Stream job progress (SSE)
+// @Description Server-sent events fallback for WebSocket progress: relays the same progress updates of the job as "progress" events, starting with its current state, and ends when the job completes or fails. Idle streams get a heartbeat comment.
+// @Tags jobs
+// @Produce text/event-stream
+// @Param id path int true "Job ID"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/web/jobs/{id}/events [get]
+func (h *QueueHandler) JobEvents(c *gin.Context) {
+	log := logger.Get(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID do job inválido",
+		})
+		return
+	}
+
+	if h.progressEvents == nil {
+		middleware.ErrorJSON(c, http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "Eventos de progresso indisponíveis",
+		})
+		return
+	}
+
+	// Subscribe before reading the job, so no update is lost in between
+	updates, unsubscribe := h.progressEvents.SubscribeProgress(userID.(string), jobID)
+	defer unsubscribe()
+
+	job, err := h.jobs.GetJobByID(jobID)
+	if err != nil && err != service.ErrJobNotFound {
+		log.Error().Err(err).Int("job_id", jobID).Msg("Erro ao buscar job")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Erro ao buscar job",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err == service.ErrJobNotFound || job.UserID != userID.(string) {
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Job não encontrado",
+		})
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // nginx: don't buffer the stream
+	c.Status(http.StatusOK)
+
+	// The current state first, then live updates
+	current := service.JobProgress(job)
+	if !writeProgressEvent(c, current) || isFinalJobStatus(current.Status) {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case update := <-updates:
+			if !writeProgressEvent(c, update) || isFinalJobStatus(update.Status) {
+				return
+			}
+		}
+	}
+}
+
+// writeProgressEvent writes a "progress" event; false when the client went away
+func writeProgressEvent(c *gin.Context, progress websocket.ProgressUpdate) bool {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: progress\ndata: %s\n\n", data); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// isFinalJobStatus reports whether a job won't get further progress updates
+func isFinalJobStatus(status string) bool {
+	return status == service.JobStatusCompleted || status == service.JobStatusFailed
+}
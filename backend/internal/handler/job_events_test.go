@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// storedJobs serves jobs from memory, like the update_jobs table
+type storedJobs map[int]*repository.UpdateJob
+
+func (s storedJobs) GetJobByID(jobID int) (*repository.UpdateJob, error) {
+	job, ok := s[jobID]
+	if !ok {
+		return nil, service.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// sseFrame is one event read from a stream ("" event for comments)
+type sseFrame struct {
+	event   string
+	data    string
+	comment string
+}
+
+// readSSEFrame reads the next event or comment of a stream
+func readSSEFrame(t *testing.T, r *bufio.Reader) (sseFrame, bool) {
+	var frame sseFrame
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return frame, false
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return frame, true
+		case strings.HasPrefix(line, ":"):
+			frame.comment = strings.TrimSpace(line[1:])
+		case strings.HasPrefix(line, "event: "):
+			frame.event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			frame.data = line[len("data: "):]
+		default:
+			t.Fatalf("unexpected SSE line %q", line)
+		}
+	}
+}
+
+func jobEventsServer(t *testing.T, hub *websocket.Hub) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	h := &QueueHandler{
+		jobs: storedJobs{
+			7: {ID: 7, UserID: "user-1", Status: service.JobStatusProcessing, TotalRows: 100, ProcessedRows: 20, SuccessCount: 20},
+			8: {ID: 8, UserID: "user-2", Status: service.JobStatusProcessing, TotalRows: 10},
+		},
+		progressEvents: hub,
+		sseHeartbeat:   50 * time.Millisecond,
+	}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Next()
+	})
+	r.GET("/api/web/jobs/:id/events", h.JobEvents)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJobEventsStreamsProgress(t *testing.T) {
+	hub := websocket.NewHub()
+	hub.SetProgressInterval(0)
+	server := jobEventsServer(t, hub)
+
+	resp, err := http.Get(server.URL + "/api/web/jobs/7/events")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" ||
+		resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	readProgress := func() websocket.ProgressUpdate {
+		for {
+			frame, ok := readSSEFrame(t, stream)
+			if !ok {
+				t.Fatal("stream ended early")
+			}
+			if frame.comment != "" {
+				continue // heartbeat
+			}
+			if frame.event != "progress" {
+				t.Fatalf("unexpected event %q", frame.event)
+			}
+			var update websocket.ProgressUpdate
+			if err := json.Unmarshal([]byte(frame.data), &update); err != nil {
+				t.Fatalf("invalid event data %s: %v", frame.data, err)
+			}
+			return update
+		}
+	}
+
+	// The stored state comes first
+	if first := readProgress(); first.JobID != 7 || first.ProcessedRows != 20 || first.Progress != 20 {
+		t.Fatalf("unexpected initial state: %+v", first)
+	}
+
+	// Idle streams get heartbeats
+	if frame, ok := readSSEFrame(t, stream); !ok || frame.comment != "heartbeat" {
+		t.Fatalf("expected a heartbeat, got %+v", frame)
+	}
+
+	// Updates of other jobs and users are not relayed
+	hub.SendProgress("user-1", websocket.ProgressUpdate{JobID: 9, Status: "processing", ProcessedRows: 1, TotalRows: 5})
+	hub.SendProgress("user-2", websocket.ProgressUpdate{JobID: 7, Status: "processing", ProcessedRows: 99, TotalRows: 100})
+	hub.SendProgress("user-1", websocket.ProgressUpdate{JobID: 7, Status: "processing", ProcessedRows: 60, TotalRows: 100, SuccessCount: 59, ErrorCount: 1})
+	hub.SendProgress("user-1", websocket.ProgressUpdate{JobID: 7, Status: "completed", ProcessedRows: 100, TotalRows: 100, SuccessCount: 98, ErrorCount: 2})
+
+	if update := readProgress(); update.Type != "progress" || update.ProcessedRows != 60 || update.ErrorCount != 1 {
+		t.Errorf("unexpected update: %+v", update)
+	}
+	if final := readProgress(); final.Status != "completed" || final.SuccessCount != 98 || final.Progress != 100 {
+		t.Errorf("unexpected final update: %+v", final)
+	}
+
+	// The stream ends with the job
+	for {
+		frame, ok := readSSEFrame(t, stream)
+		if !ok {
+			break
+		}
+		if frame.comment == "" {
+			t.Fatalf("unexpected frame after completion: %+v", frame)
+		}
+	}
+}
+
+func TestJobEventsOtherUsersJob(t *testing.T) {
+	server := jobEventsServer(t, websocket.NewHub())
+
+	for _, path := range []string{"/api/web/jobs/8/events", "/api/web/jobs/404/events"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SetJobTemplates enables the job template endpoints
+func (h *QueueHandler) SetJobTemplates(templates *service.JobTemplateService) {
+	h.templates = templates
+}
+
+// JobTemplateRequest represents the request body for creating or replacing a job template.
+// The columns come from mappings or are copied from a saved mapping (mapping_id).
+type JobTemplateRequest struct {
+	Name      string                  `json:"name" binding:"required"`
+	ListID    string                  `json:"list_id,omitempty"`
+	MappingID string                  `json:"mapping_id,omitempty"`
+	Mappings  []service.ColumnMapping `json:"mappings,omitempty"`
+
+	// NormalizeColumns matches columns ignoring case, accents and extra whitespace
+	NormalizeColumns bool `json:"normalize_columns,omitempty"`
+
+	// Job options, with the same meaning and rules as in CreateJobRequest
+	Locale              string                `json:"locale,omitempty"`
+	Timezone            string                `json:"timezone,omitempty"`
+	Priority            string                `json:"priority,omitempty"`
+	DuplicateResolution string                `json:"duplicate_resolution,omitempty"`
+	TokenLabel          string                `json:"token_label,omitempty"`
+	CustomTaskIDs       bool                  `json:"custom_task_ids,omitempty"`
+	TeamID              string                `json:"team_id,omitempty"`
+	RowFilter           *repository.RowFilter `json:"row_filter,omitempty"`
+	CommentOnUpdate     bool                  `json:"comment_on_update,omitempty"`
+}
+
+// RunJobTemplateRequest represents the request body for running a template with a file
+type RunJobTemplateRequest struct {
+	FilePath string `json:"file_path" binding:"required"`
+	Title    string `json:"title,omitempty"` // default: template name
+	// ScheduledAt, ConflictSince, DryRun and PreviewDiff work as in CreateJobRequest
+	ScheduledAt   *time.Time `json:"scheduled_at,omitempty"`
+	ConflictSince *time.Time `json:"conflict_since,omitempty"`
+	DryRun        bool       `json:"dry_run,omitempty"`
+	PreviewDiff   bool       `json:"preview_diff,omitempty"`
+}
+
+// CreateJobTemplate saves a job template
+// @Summary Create job template
+// @Description Saves a whole job configuration (mapping, list, token, filters and options) to run again with new files
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body JobTemplateRequest true "Job template"
+// @Success 201 {object} repository.JobTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/web/job-templates [post]
+func (h *QueueHandler) CreateJobTemplate(c *gin.Context) {
+	h.saveJobTemplate(c, 0)
+}
+
+// UpdateJobTemplate replaces a job template
+// @Summary Update job template
+// @Description Replaces the content of a job template
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param request body JobTemplateRequest true "Job template"
+// @Success 200 {object} repository.JobTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/web/job-templates/{id} [put]
+func (h *QueueHandler) UpdateJobTemplate(c *gin.Context) {
+	templateID, ok := jobTemplateID(c)
+	if !ok {
+		return
+	}
+	h.saveJobTemplate(c, templateID)
+}
+
+// saveJobTemplate creates (templateID 0) or replaces a template
+func (h *QueueHandler) saveJobTemplate(c *gin.Context, templateID int) {
+	log := logger.Get(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req JobTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dados inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Sanitize inputs
+	req.Name = middleware.SanitizeTitle(req.Name)
+	for i := range req.Mappings {
+		req.Mappings[i].FieldID = middleware.SanitizeID(req.Mappings[i].FieldID)
+		req.Mappings[i].FieldName = middleware.SanitizeTitle(req.Mappings[i].FieldName)
+	}
+
+	options := repository.JobTemplateOptions{
+		Locale:              req.Locale,
+		Timezone:            req.Timezone,
+		Priority:            req.Priority,
+		DuplicateResolution: req.DuplicateResolution,
+		TokenLabel:          req.TokenLabel,
+		CustomTaskIDs:       req.CustomTaskIDs,
+		TeamID:              req.TeamID,
+		NormalizeColumns:    req.NormalizeColumns,
+		RowFilter:           req.RowFilter,
+		CommentOnUpdate:     req.CommentOnUpdate,
+	}
+	if !validateJobOptions(c, &options) {
+		return
+	}
+
+	templateReq := service.JobTemplateRequest{
+		Name:      req.Name,
+		ListID:    req.ListID,
+		MappingID: req.MappingID,
+		Mappings:  req.Mappings,
+		Options:   options,
+	}
+
+	var template *repository.JobTemplate
+	var err error
+	if templateID == 0 {
+		template, err = h.templates.CreateTemplate(userID.(string), templateReq)
+	} else {
+		template, err = h.templates.UpdateTemplate(userID.(string), templateID, templateReq)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", userID.(string)).Msg("Template de job não salvo")
+		respondJobTemplateError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if templateID == 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// ListJobTemplates lists the user's job templates
+// @Summary List job templates
+// @Description Returns the job templates of the authenticated user, ordered by name
+// @Tags jobs
+// @Produce json
+// @Success 200 {object} []repository.JobTemplate
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/web/job-templates [get]
+func (h *QueueHandler) ListJobTemplates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	templates, err := h.templates.ListTemplates(userID.(string))
+	if err != nil {
+		logger.Get(c.Request.Context()).Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao listar templates de job")
+		respondJobTemplateError(c, err)
+		return
+	}
+	if templates == nil {
+		templates = []repository.JobTemplate{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// GetJobTemplate gets a job template by ID
+// @Summary Get job template
+// @Description Returns one of the user's job templates
+// @Tags jobs
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} repository.JobTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/web/job-templates/{id} [get]
+func (h *QueueHandler) GetJobTemplate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	templateID, ok := jobTemplateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templates.GetTemplate(userID.(string), templateID)
+	if err != nil {
+		respondJobTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeleteJobTemplate removes a job template
+// @Summary Delete job template
+// @Description Removes one of the user's job templates; jobs created from it are kept
+// @Tags jobs
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/web/job-templates/{id} [delete]
+func (h *QueueHandler) DeleteJobTemplate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	templateID, ok := jobTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templates.DeleteTemplate(userID.(string), templateID); err != nil {
+		respondJobTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RunJobTemplate creates a job from a template and a newly uploaded file
+// @Summary Run job template
+// @Description Maps the uploaded file with the template's columns and queues a job with its options
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param request body RunJobTemplateRequest true "File to process"
+// @Param Idempotency-Key header string false "Retries with the same key return the original job instead of creating another"
+// @Success 201 {object} JobResponse
+// @Failure 400 {object} ErrorResponse "Invalid options or file that doesn't fit the template"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/web/job-templates/{id}/run [post]
+func (h *QueueHandler) RunJobTemplate(c *gin.Context) {
+	log := logger.Get(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+		})
+		return
+	}
+
+	templateID, ok := jobTemplateID(c)
+	if !ok {
+		return
+	}
+
+	var req RunJobTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dados inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !requireTempPath(c, h.uploadService, req.FilePath) {
+		return
+	}
+
+	template, err := h.templates.GetTemplate(userID.(string), templateID)
+	if err != nil {
+		respondJobTemplateError(c, err)
+		return
+	}
+
+	title := middleware.SanitizeTitle(req.Title)
+	if title == "" {
+		title = template.Name
+	}
+	spec := jobSpec{
+		Title:         title,
+		Options:       template.Options,
+		ScheduledAt:   req.ScheduledAt,
+		ConflictSince: req.ConflictSince,
+		DryRun:        req.DryRun,
+		PreviewDiff:   req.PreviewDiff,
+	}
+	if !h.validateJobSpec(c, &spec) {
+		return
+	}
+
+	mapping, validation, err := h.templates.InstantiateTemplate(template, req.FilePath)
+	if err != nil {
+		log.Error().Err(err).Int("template_id", templateID).Msg("Erro ao aplicar template ao arquivo")
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Erro ao aplicar template ao arquivo",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !validation.Valid {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success":    false,
+			"error":      "Arquivo incompatível com o template",
+			"validation": validation,
+		})
+		return
+	}
+
+	job, ok := h.createJobFromMapping(c, userID.(string), spec, mapping)
+	if !ok {
+		return
+	}
+
+	h.auditJobCreate(c, job, map[string]interface{}{
+		"mapping_id":  mapping.ID,
+		"template_id": template.ID,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    toJobResponse(job),
+	})
+}
+
+// jobTemplateID parses the template ID path parameter, answering 400 when invalid
+func jobTemplateID(c *gin.Context) (int, bool) {
+	templateID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || templateID <= 0 {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID do template inválido",
+		})
+		return 0, false
+	}
+	return templateID, true
+}
+
+// respondJobTemplateError maps template errors to HTTP responses
+func respondJobTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrJobTemplateNotFound):
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Template não encontrado",
+		})
+	case errors.Is(err, service.ErrMappingNotFound):
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Mapeamento não encontrado",
+		})
+	case errors.Is(err, repository.ErrJobTemplateNameTaken):
+		middleware.ErrorJSON(c, http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Já existe um template com este nome",
+		})
+	case errors.Is(err, service.ErrInvalidJobTemplate):
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Template inválido",
+			"details": err.Error(),
+		})
+	default:
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Erro ao processar template",
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// Announcer broadcasts a notice to every connected client (implemented by websocket.Hub)
+type Announcer interface {
+	Broadcast(message websocket.Announcement) int
+}
+
+// MaintenanceHandler lets admins turn maintenance mode on and off
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+	announcer   Announcer
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(maintenance *middleware.Maintenance) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance}
+}
+
+// SetAnnouncer tells connected users when maintenance mode changes
+func (h *MaintenanceHandler) SetAnnouncer(announcer Announcer) {
+	h.announcer = announcer
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message,omitempty"` // shown in 503 responses (default message when empty)
+}
+
+// GetMaintenance handles GET /api/web/maintenance - Current maintenance mode
+// @Summary      Get maintenance mode
+// @Description  Whether writes are blocked, why and since when. Available to every user so the UI can show a banner.
+// @Tags         admin
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} model.ErrorResponse
+// @Router       /api/web/maintenance [get]
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.maintenance.State(),
+	})
+}
+
+// SetMaintenance handles POST /api/web/admin/maintenance - Toggle maintenance mode
+// @Summary      Toggle maintenance mode
+// @Description  While enabled, every request that changes data answers 503 with the message, reads keep working and the queue starts no new jobs (running jobs finish). The state is shared by every instance.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body MaintenanceRequest true "Maintenance mode"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      403 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/admin/maintenance [post]
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var request MaintenanceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dados inválidos",
+			"details": err.Error(),
+			"code":    "INVALID_INPUT",
+		})
+		return
+	}
+
+	wasEnabled := h.maintenance.Enabled()
+	state, err := h.maintenance.Set(*request.Enabled, request.Message)
+	if err != nil {
+		logger.FromGin(c).Error().Err(err).Msg("Erro ao alterar modo manutenção")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Erro ao alterar modo manutenção",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	logger.Audit(c.Request.Context(), logger.AuditEvent{
+		Action:   logger.AuditActionMaintenance,
+		UserID:   c.GetString("user_id"),
+		Username: c.GetString("username"),
+		Resource: "maintenance",
+		ClientIP: c.ClientIP(),
+		Success:  true,
+		Details:  map[string]interface{}{"enabled": state.Enabled},
+	})
+
+	if h.announcer != nil && state.Enabled != wasEnabled {
+		announcement := websocket.Announcement{
+			Level:   websocket.AnnouncementMaintenance,
+			Title:   "Manutenção encerrada",
+			Message: "Alterações liberadas novamente.",
+		}
+		if state.Enabled {
+			announcement.Title = "Manutenção iniciada"
+			announcement.Message = state.Message
+		}
+		h.announcer.Broadcast(announcement)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    state,
+	})
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type recordedAnnouncements []websocket.Announcement
+
+func (r *recordedAnnouncements) Broadcast(message websocket.Announcement) int {
+	*r = append(*r, message)
+	return 1
+}
+
+func TestSetMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	maintenance := middleware.NewMaintenance(false, "")
+	h := NewMaintenanceHandler(maintenance)
+	var announced recordedAnnouncements
+	h.SetAnnouncer(&announced)
+
+	r := gin.New()
+	r.POST("/api/web/admin/maintenance", h.SetMaintenance)
+	r.POST("/api/web/jobs", maintenance.BlockWrites(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	toggle := func(body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/web/admin/maintenance", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	createJob := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/web/jobs", nil))
+		return w.Code
+	}
+
+	if code := toggle(`{"message": "sem enabled"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without enabled, got %d", code)
+	}
+
+	if code := toggle(`{"enabled": true, "message": "Migração em andamento"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := createJob(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while in maintenance, got %d", code)
+	}
+
+	if code := toggle(`{"enabled": false}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := createJob(); code != http.StatusCreated {
+		t.Errorf("expected 201 after maintenance, got %d", code)
+	}
+
+	if len(announced) != 2 || announced[0].Level != websocket.AnnouncementMaintenance ||
+		announced[0].Message != "Migração em andamento" {
+		t.Errorf("unexpected announcements: %+v", announced)
+	}
+}
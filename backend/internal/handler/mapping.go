@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
@@ -29,6 +30,12 @@ type SaveMappingRequest struct {
 	FilePath string                   `json:"file_path" binding:"required"`
 	Mappings []service.ColumnMapping  `json:"mappings" binding:"required"`
 	Title    string                   `json:"title" binding:"required"`
+
+	// NormalizeColumns matches columns ignoring case, accents and extra whitespace
+	NormalizeColumns bool `json:"normalize_columns,omitempty"`
+
+	// Locale reads numbers and dates of sampled values: pt-BR or en-US (server default)
+	Locale string `json:"locale,omitempty"`
 }
 
 // MappingResponse represents the response for mapping operations
@@ -64,7 +71,7 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -74,7 +81,7 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	var req SaveMappingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warn().Err(err).Msg("Payload inválido para mapeamento")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -86,12 +93,7 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	req.Title = middleware.SanitizeTitle(req.Title)
 
 	// Validate file path to prevent path traversal
-	if !middleware.ValidateFilePath(req.FilePath, "") {
-		log.Warn().Str("file_path", req.FilePath).Msg("Tentativa de path traversal detectada")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Success: false,
-			Error:   "caminho de arquivo inválido",
-		})
+	if !requireTempPath(c, h.uploadService, req.FilePath) {
 		return
 	}
 
@@ -111,7 +113,7 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	duplicates := h.mappingService.CheckDuplicateMappings(req.Mappings)
 	if len(duplicates) > 0 {
 		log.Warn().Strs("duplicates", duplicates).Msg("Mapeamentos duplicados detectados")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "mapeamento duplicado detectado",
 			Details: "campos duplicados: " + joinStrings(duplicates),
@@ -120,10 +122,10 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	}
 
 	// Get file columns for validation
-	columns, _, err := h.uploadService.GetFileData(req.FilePath)
+	columns, rows, err := h.uploadService.GetFileData(req.FilePath)
 	if err != nil {
 		log.Error().Err(err).Str("file_path", req.FilePath).Msg("Erro ao ler arquivo para validação")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao ler arquivo",
 			Details: err.Error(),
@@ -133,16 +135,21 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 
 	// Convert request to service format
 	mappingReq := &service.MappingRequest{
-		FilePath: req.FilePath,
-		Mappings: req.Mappings,
-		Title:    req.Title,
+		FilePath:         req.FilePath,
+		Mappings:         req.Mappings,
+		Title:            req.Title,
+		SampleRows:       rows,
+		NormalizeColumns: req.NormalizeColumns,
+		Locale:           req.Locale,
+		Columns:          columns,
+		Format:           h.fileFormat(req.FilePath),
 	}
 
 	// Validate and save mapping
 	stored, validation, err := h.mappingService.ValidateAndSaveMapping(userID.(string), mappingReq, columns)
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao validar/salvar mapeamento")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao processar mapeamento",
 			Details: err.Error(),
@@ -153,7 +160,7 @@ func (h *MappingHandler) SaveMapping(c *gin.Context) {
 	// If validation failed, return validation errors
 	if !validation.Valid {
 		log.Warn().Strs("errors", validation.Errors).Msg("Validação de mapeamento falhou")
-		c.JSON(http.StatusBadRequest, MappingResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, MappingResponse{
 			Success:    false,
 			Validation: validation,
 		})
@@ -190,7 +197,7 @@ func (h *MappingHandler) GetMapping(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -199,7 +206,7 @@ func (h *MappingHandler) GetMapping(c *gin.Context) {
 
 	mappingID := middleware.SanitizeID(c.Param("id"))
 	if mappingID == "" || !middleware.ValidateID(mappingID) {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "ID do mapeamento inválido",
 		})
@@ -214,14 +221,14 @@ func (h *MappingHandler) GetMapping(c *gin.Context) {
 	mapping, err := h.mappingService.GetMappingByUser(mappingID, userID.(string))
 	if err != nil {
 		if err == service.ErrMappingNotFound {
-			c.JSON(http.StatusNotFound, model.ErrorResponse{
+			middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
 				Success: false,
 				Error:   "mapeamento não encontrado",
 			})
 			return
 		}
 		log.Error().Err(err).Str("mapping_id", mappingID).Msg("Erro ao buscar mapeamento")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao buscar mapeamento",
 			Details: err.Error(),
@@ -243,6 +250,7 @@ func (h *MappingHandler) GetMapping(c *gin.Context) {
 // @Security     BasicAuth
 // @Success      200 {object} MappingListResponse
 // @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
 // @Router       /api/web/mapping [get]
 func (h *MappingHandler) ListMappings(c *gin.Context) {
 	log := logger.FromGin(c)
@@ -250,7 +258,7 @@ func (h *MappingHandler) ListMappings(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -259,7 +267,16 @@ func (h *MappingHandler) ListMappings(c *gin.Context) {
 
 	log.Info().Str("user_id", userID.(string)).Msg("Listando mapeamentos do usuário")
 
-	mappings := h.mappingService.GetMappingsByUser(userID.(string))
+	mappings, err := h.mappingService.GetMappingsByUser(userID.(string))
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao listar mapeamentos")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao listar mapeamentos",
+			Details: err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, MappingListResponse{
 		Success: true,
@@ -284,7 +301,7 @@ func (h *MappingHandler) DeleteMapping(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -293,7 +310,7 @@ func (h *MappingHandler) DeleteMapping(c *gin.Context) {
 
 	mappingID := middleware.SanitizeID(c.Param("id"))
 	if mappingID == "" || !middleware.ValidateID(mappingID) {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "ID do mapeamento inválido",
 		})
@@ -304,13 +321,13 @@ func (h *MappingHandler) DeleteMapping(c *gin.Context) {
 	_, err := h.mappingService.GetMappingByUser(mappingID, userID.(string))
 	if err != nil {
 		if err == service.ErrMappingNotFound {
-			c.JSON(http.StatusNotFound, model.ErrorResponse{
+			middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
 				Success: false,
 				Error:   "mapeamento não encontrado",
 			})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao verificar mapeamento",
 		})
@@ -319,7 +336,7 @@ func (h *MappingHandler) DeleteMapping(c *gin.Context) {
 
 	if err := h.mappingService.DeleteMapping(mappingID); err != nil {
 		log.Error().Err(err).Str("mapping_id", mappingID).Msg("Erro ao deletar mapeamento")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao deletar mapeamento",
 		})
@@ -354,7 +371,7 @@ func (h *MappingHandler) ValidateMapping(c *gin.Context) {
 	var req SaveMappingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warn().Err(err).Msg("Payload inválido para validação")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -363,23 +380,24 @@ func (h *MappingHandler) ValidateMapping(c *gin.Context) {
 	}
 
 	// Check for duplicate mappings
-	duplicates := h.mappingService.CheckDuplicateMappings(req.Mappings)
-	if len(duplicates) > 0 {
+	if duplicated := h.duplicatedMappings(req.Mappings); duplicated != nil {
 		c.JSON(http.StatusOK, MappingResponse{
-			Success: false,
-			Validation: &service.MappingValidationResult{
-				Valid:  false,
-				Errors: []string{"mapeamento duplicado: " + joinStrings(duplicates)},
-			},
+			Success:    false,
+			Validation: duplicated,
 		})
 		return
 	}
 
+	// Validate file path to prevent path traversal
+	if !requireTempPath(c, h.uploadService, req.FilePath) {
+		return
+	}
+
 	// Get file columns for validation
-	columns, _, err := h.uploadService.GetFileData(req.FilePath)
+	columns, rows, err := h.uploadService.GetFileData(req.FilePath)
 	if err != nil {
 		log.Error().Err(err).Str("file_path", req.FilePath).Msg("Erro ao ler arquivo para validação")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao ler arquivo",
 			Details: err.Error(),
@@ -387,17 +405,10 @@ func (h *MappingHandler) ValidateMapping(c *gin.Context) {
 		return
 	}
 
-	// Convert and validate
-	mappingReq := &service.MappingRequest{
-		FilePath: req.FilePath,
-		Mappings: req.Mappings,
-		Title:    req.Title,
-	}
-
-	validation, err := h.mappingService.ValidateMapping(mappingReq, columns)
+	validation, err := h.validateAgainstFile(&req, columns, rows, h.fileFormat(req.FilePath))
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao validar mapeamento")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao validar mapeamento",
 			Details: err.Error(),
@@ -411,6 +422,292 @@ func (h *MappingHandler) ValidateMapping(c *gin.Context) {
 	})
 }
 
+// maxBatchMappingValidations caps the candidate mappings of one batch validation
+const maxBatchMappingValidations = 20
+
+// BatchMappingValidationResponse represents the response for batch validation: one
+// result per candidate, in request order
+type BatchMappingValidationResponse struct {
+	Success bool                               `json:"success"`
+	Data    []*service.MappingValidationResult `json:"data"`
+}
+
+// ValidateMappings handles POST /api/web/mapping/validate/batch - Validate several mappings without saving
+// @Summary      Validate mappings in batch
+// @Description  Validates several candidate mappings (e.g. one per sheet) in one request. Each candidate is checked like POST /api/web/mapping/validate; a candidate whose file can't be read is reported as invalid instead of failing the batch. success is true only when every candidate is valid.
+// @Tags         mapping
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body []SaveMappingRequest true "Candidate mappings"
+// @Success      200 {object} BatchMappingValidationResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/mapping/validate/batch [post]
+func (h *MappingHandler) ValidateMappings(c *gin.Context) {
+	log := logger.FromGin(c)
+
+	var reqs []SaveMappingRequest
+	if err := c.ShouldBindJSON(&reqs); err != nil || len(reqs) == 0 || len(reqs) > maxBatchMappingValidations {
+		details := fmt.Sprintf("informe de 1 a %d mapeamentos", maxBatchMappingValidations)
+		if err != nil {
+			details = err.Error()
+		}
+		log.Warn().Str("details", details).Msg("Payload inválido para validação em lote")
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "payload inválido",
+			Details: details,
+		})
+		return
+	}
+
+	// Candidates usually share the uploaded file, so each file is read once
+	files := make(map[string]*mappingFile)
+
+	results := make([]*service.MappingValidationResult, len(reqs))
+	allValid := true
+	for i := range reqs {
+		validation, err := h.validateCandidate(c, &reqs[i], files)
+		if err != nil {
+			log.Error().Err(err).Msg("Erro ao validar mapeamento")
+			middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+				Success: false,
+				Error:   "erro ao validar mapeamento",
+				Details: err.Error(),
+			})
+			return
+		}
+		results[i] = validation
+		allValid = allValid && validation.Valid
+	}
+
+	c.JSON(http.StatusOK, BatchMappingValidationResponse{
+		Success: allValid,
+		Data:    results,
+	})
+}
+
+// mappingFile is an uploaded file read for a batch validation
+type mappingFile struct {
+	columns []string
+	rows    [][]string
+	format  *service.FileFormat
+	err     error
+}
+
+// validateCandidate validates one mapping of a batch. Problems of the candidate itself
+// (duplicates, a file outside the temp directory or that can't be read) become an
+// invalid result; only a failure of the validation itself is returned as error.
+func (h *MappingHandler) validateCandidate(c *gin.Context, req *SaveMappingRequest, files map[string]*mappingFile) (*service.MappingValidationResult, error) {
+	if duplicated := h.duplicatedMappings(req.Mappings); duplicated != nil {
+		return duplicated, nil
+	}
+
+	if err := h.uploadService.ValidateTempPath(req.FilePath); err != nil {
+		logger.FromGin(c).Warn().Str("file_path", req.FilePath).Msg("Tentativa de path traversal detectada")
+		return invalidMapping(err.Error() + ": o arquivo deve ser um upload temporário deste servidor"), nil
+	}
+
+	file, ok := files[req.FilePath]
+	if !ok {
+		file = &mappingFile{}
+		file.columns, file.rows, file.err = h.uploadService.GetFileData(req.FilePath)
+		if file.err == nil {
+			file.format = h.fileFormat(req.FilePath)
+		}
+		files[req.FilePath] = file
+	}
+	if file.err != nil {
+		return invalidMapping("erro ao ler arquivo: " + file.err.Error()), nil
+	}
+
+	return h.validateAgainstFile(req, file.columns, file.rows, file.format)
+}
+
+// duplicatedMappings returns an invalid result when a column or field is mapped twice, nil otherwise
+func (h *MappingHandler) duplicatedMappings(mappings []service.ColumnMapping) *service.MappingValidationResult {
+	duplicates := h.mappingService.CheckDuplicateMappings(mappings)
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return invalidMapping("mapeamento duplicado: " + joinStrings(duplicates))
+}
+
+// validateAgainstFile validates a mapping against the columns, rows and format of its file
+func (h *MappingHandler) validateAgainstFile(req *SaveMappingRequest, columns []string, rows [][]string, format *service.FileFormat) (*service.MappingValidationResult, error) {
+	return h.mappingService.ValidateMapping(&service.MappingRequest{
+		FilePath:         req.FilePath,
+		Mappings:         req.Mappings,
+		Title:            req.Title,
+		SampleRows:       rows,
+		NormalizeColumns: req.NormalizeColumns,
+		Locale:           req.Locale,
+		Format:           format,
+	}, columns)
+}
+
+// fileFormat returns how a CSV file was read, for the validation result. The file was
+// just read, so a failure here only leaves the format out.
+func (h *MappingHandler) fileFormat(path string) *service.FileFormat {
+	format, err := h.uploadService.GetFileFormat(path)
+	if err != nil {
+		return nil
+	}
+	return format
+}
+
+// invalidMapping is a validation result with a single error
+func invalidMapping(message string) *service.MappingValidationResult {
+	return &service.MappingValidationResult{
+		Valid:  false,
+		Errors: []string{message},
+	}
+}
+
+// SuggestMappingRequest represents the request body for mapping suggestions: the
+// columns of an uploaded file (file_path) or the column names themselves
+type SuggestMappingRequest struct {
+	FilePath string   `json:"file_path,omitempty"`
+	Columns  []string `json:"columns,omitempty"`
+}
+
+// MappingSuggestionResponse represents the response for mapping suggestions
+type MappingSuggestionResponse struct {
+	Success bool                    `json:"success"`
+	Data    []service.ColumnMapping `json:"data"`
+}
+
+// SuggestMappings handles POST /api/web/mapping/suggest - Suggest a mapping from column names
+// @Summary      Suggest mapping
+// @Description  Suggests the task ID column (by common names like "id task") and the custom field of each column whose name matches a field name closely (ignoring case, accents and separators, tolerating small typos). Suggestions are not saved; confirm them with POST /api/web/mapping.
+// @Tags         mapping
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body SuggestMappingRequest true "File path or column names"
+// @Success      200 {object} MappingSuggestionResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/mapping/suggest [post]
+func (h *MappingHandler) SuggestMappings(c *gin.Context) {
+	log := logger.FromGin(c)
+
+	columns, ok := h.requestColumns(c)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.mappingService.SuggestMappings(columns)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao sugerir mapeamento")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao sugerir mapeamento",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MappingSuggestionResponse{
+		Success: true,
+		Data:    suggestions,
+	})
+}
+
+// FindMatchingMappings handles POST /api/web/mapping/matches - Find mappings saved for the same columns
+// @Summary      Find reusable mappings
+// @Description  Lists the user's saved mappings whose file had the same set of columns (in any order), most recent first, so a re-uploaded spreadsheet can reuse its last mapping. Renamed, added or removed columns don't match.
+// @Tags         mapping
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body SuggestMappingRequest true "File path or column names"
+// @Success      200 {object} MappingListResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/mapping/matches [post]
+func (h *MappingHandler) FindMatchingMappings(c *gin.Context) {
+	log := logger.FromGin(c)
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   "usuário não autenticado",
+		})
+		return
+	}
+
+	columns, ok := h.requestColumns(c)
+	if !ok {
+		return
+	}
+
+	mappings, err := h.mappingService.FindByColumns(userID.(string), columns)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao buscar mapeamentos reutilizáveis")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao buscar mapeamentos",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	log.Info().
+		Str("user_id", userID.(string)).
+		Int("matches", len(mappings)).
+		Msg("Mapeamentos reutilizáveis encontrados")
+
+	c.JSON(http.StatusOK, MappingListResponse{
+		Success: true,
+		Data:    mappings,
+	})
+}
+
+// requestColumns reads a SuggestMappingRequest and returns its columns, read from the
+// uploaded file when file_path is given; on failure the response is already written
+func (h *MappingHandler) requestColumns(c *gin.Context) ([]string, bool) {
+	log := logger.FromGin(c)
+
+	var req SuggestMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.FilePath == "" && len(req.Columns) == 0) {
+		details := "informe file_path ou columns"
+		if err != nil {
+			details = err.Error()
+		}
+		log.Warn().Str("details", details).Msg("Payload inválido para colunas do arquivo")
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "payload inválido",
+			Details: details,
+		})
+		return nil, false
+	}
+
+	if req.FilePath == "" {
+		return req.Columns, true
+	}
+	if !requireTempPath(c, h.uploadService, req.FilePath) {
+		return nil, false
+	}
+	columns, err := h.uploadService.GetColumns(req.FilePath)
+	if err != nil {
+		log.Error().Err(err).Str("file_path", req.FilePath).Msg("Erro ao ler colunas do arquivo")
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao ler arquivo",
+			Details: err.Error(),
+		})
+		return nil, false
+	}
+	return columns, true
+}
+
 // joinStrings joins a slice of strings with comma separator
 func joinStrings(strs []string) string {
 	if len(strs) == 0 {
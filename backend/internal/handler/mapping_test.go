@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// storedCustomFields serves custom fields from memory, like the custom_fields table
+type storedCustomFields []repository.CustomField
+
+func (s storedCustomFields) GetCustomFields() ([]repository.CustomField, error) {
+	return s, nil
+}
+
+func TestValidateMappingsBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uploadService := service.NewUploadService(t.TempDir(), 0)
+	content := "id task,Valor,Status\nabc,10,ok\n"
+	upload, err := uploadService.ProcessFile("dados.csv", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	mappingService := service.NewMappingService(storedCustomFields{
+		{ID: "f-valor", Name: "Valor", Type: "number"},
+	})
+	h := NewMappingHandler(mappingService, uploadService)
+
+	r := gin.New()
+	r.POST("/api/web/mapping/validate/batch", h.ValidateMappings)
+
+	outside, err := os.CreateTemp("", "outside-*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside.Close()
+	defer os.Remove(outside.Name())
+
+	taskID := gin.H{"column": "id task", "is_task_id": true}
+	candidates := []gin.H{
+		// valid
+		{"file_path": upload.TempPath, "title": "A", "mappings": []gin.H{taskID, {"column": "Valor", "field_id": "f-valor"}}},
+		// unknown field
+		{"file_path": upload.TempPath, "title": "B", "mappings": []gin.H{taskID, {"column": "Status", "field_id": "f-status"}}},
+		// same field mapped twice
+		{"file_path": upload.TempPath, "title": "C", "mappings": []gin.H{taskID, {"column": "Valor", "field_id": "f-valor"}, {"column": "Status", "field_id": "f-valor"}}},
+		// file outside the upload directory
+		{"file_path": outside.Name(), "title": "D", "mappings": []gin.H{taskID}},
+		// valid, normalized column names
+		{"file_path": upload.TempPath, "title": "E", "normalize_columns": true, "mappings": []gin.H{{"column": "ID Task", "is_task_id": true}}},
+	}
+	body, _ := json.Marshal(candidates)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/web/mapping/validate/batch", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp BatchMappingValidationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success {
+		t.Error("success should be false when any candidate is invalid")
+	}
+	want := []bool{true, false, false, false, true}
+	if len(resp.Data) != len(want) {
+		t.Fatalf("got %d results, want %d", len(resp.Data), len(want))
+	}
+	for i, valid := range want {
+		if resp.Data[i].Valid != valid {
+			t.Errorf("candidate %d: valid = %v, want %v (errors %v)", i, resp.Data[i].Valid, valid, resp.Data[i].Errors)
+		}
+		if !valid && len(resp.Data[i].Errors) == 0 {
+			t.Errorf("candidate %d: invalid without errors", i)
+		}
+	}
+}
+
+func TestValidateMappingsBatchRejectsBadPayload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewMappingHandler(service.NewMappingService(storedCustomFields{}), service.NewUploadService(t.TempDir(), 0))
+	r := gin.New()
+	r.POST("/api/web/mapping/validate/batch", h.ValidateMappings)
+
+	tooMany := make([]gin.H, maxBatchMappingValidations+1)
+	for i := range tooMany {
+		tooMany[i] = gin.H{"file_path": "x.csv", "title": "T", "mappings": []gin.H{}}
+	}
+	tooManyBody, _ := json.Marshal(tooMany)
+
+	for name, body := range map[string]string{
+		"empty":         `[]`,
+		"not an array":  `{"file_path": "x.csv"}`,
+		"missing title": `[{"file_path": "x.csv", "mappings": []}]`,
+		"too many":      string(tooManyBody),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/web/mapping/validate/batch", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
@@ -16,6 +17,12 @@ import (
 type MetadataHandler struct {
 	metadataService *service.MetadataService
 	wsHub           *websocket.Hub
+	fieldOptions    FieldOptionLister
+}
+
+// FieldOptionLister lists the options of a stored custom field (implemented by *service.MetadataService)
+type FieldOptionLister interface {
+	ListFieldOptions(fieldID string) ([]service.FieldOption, error)
 }
 
 // NewMetadataHandler creates a new metadata handler
@@ -23,6 +30,7 @@ func NewMetadataHandler(metadataService *service.MetadataService, wsHub *websock
 	return &MetadataHandler{
 		metadataService: metadataService,
 		wsHub:           wsHub,
+		fieldOptions:    metadataService,
 	}
 }
 
@@ -45,7 +53,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -54,7 +62,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 	
 	var req SyncMetadataRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -63,7 +71,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 	}
 	
 	if req.Token == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "token é obrigatório",
 		})
@@ -73,7 +81,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 	// Sanitize and validate token
 	req.Token = middleware.SanitizeToken(req.Token)
 	if !middleware.ValidateToken(req.Token) {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "formato de token inválido",
 			Details: "o token deve começar com 'pk_' e conter apenas caracteres alfanuméricos",
@@ -81,6 +89,15 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 		return
 	}
 	
+	if _, err := service.NormalizeTokenLabel(req.Label); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "rótulo do token inválido",
+			Details: err.Error(),
+		})
+		return
+	}
+	
 	log.Info().Str("user_id", userID.(string)).Msg("Iniciando sincronização de metadados")
 	
 	// Send initial progress via WebSocket
@@ -100,7 +117,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 	}
 
 	// Sync metadata
-	err := h.metadataService.SyncMetadata(c.Request.Context(), userID.(string), req.Token)
+	err := h.metadataService.SyncMetadata(c.Request.Context(), userID.(string), req.Label, req.Token)
 	if err != nil {
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro na sincronização de metadados")
 		
@@ -125,7 +142,7 @@ func (h *MetadataHandler) SyncMetadata(c *gin.Context) {
 			},
 		})
 		
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro na sincronização de metadados",
 			Details: err.Error(),
@@ -176,7 +193,7 @@ func (h *MetadataHandler) GetHierarchy(c *gin.Context) {
 	data, err := h.metadataService.GetHierarchicalData(c.Request.Context())
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao buscar dados hierárquicos")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao buscar dados hierárquicos",
 			Details: err.Error(),
@@ -190,9 +207,58 @@ func (h *MetadataHandler) GetHierarchy(c *gin.Context) {
 	})
 }
 
+// GetFieldOptions returns the options of a dropdown or labels field
+// @Summary      Get custom field options
+// @Description  Returns the options of a drop_down or labels custom field from the synced metadata, in ClickUp order, for value pickers and spreadsheet value checks
+// @Tags         metadata
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path string true "Custom field ID"
+// @Success      200 {object} FieldOptionsResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      404 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
+// @Router       /api/web/metadata/fields/{id}/options [get]
+func (h *MetadataHandler) GetFieldOptions(c *gin.Context) {
+	log := logger.FromGin(c)
+	fieldID := middleware.SanitizeID(c.Param("id"))
+
+	options, err := h.fieldOptions.ListFieldOptions(fieldID)
+	if errors.Is(err, service.ErrFieldNotFound) || errors.Is(err, service.ErrFieldWithoutOptions) {
+		middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Details: "field_id: " + fieldID,
+		})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("field_id", fieldID).Msg("Erro ao buscar opções do campo")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao buscar opções do campo",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, FieldOptionsResponse{
+		Success: true,
+		Data:    options,
+	})
+}
+
 // SyncMetadataRequest represents the request to sync metadata
 type SyncMetadataRequest struct {
 	Token string `json:"token" binding:"required"`
+	// Label salva o token com um rótulo, para usuários com várias contas (vazio = token padrão)
+	Label string `json:"label,omitempty"`
+}
+
+// FieldOptionsResponse represents the options of a custom field
+type FieldOptionsResponse struct {
+	Success bool                  `json:"success"`
+	Data    []service.FieldOption `json:"data"`
 }
 
 // HierarchyResponse represents the response for hierarchical data
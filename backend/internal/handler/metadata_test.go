@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// storedFields serves field options from in-memory metadata, like the custom_fields table
+type storedFields map[string]repository.CustomField
+
+func (s storedFields) ListFieldOptions(fieldID string) ([]service.FieldOption, error) {
+	field, ok := s[fieldID]
+	if !ok {
+		return service.FieldOptionList(nil)
+	}
+	return service.FieldOptionList(&field)
+}
+
+func fieldOptionsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	// Options as read back from the JSONB column: numbers are float64
+	h := &MetadataHandler{fieldOptions: storedFields{
+		"f-status": {ID: "f-status", Name: "Status", Type: "drop_down", Options: map[string]interface{}{
+			"options": []interface{}{
+				map[string]interface{}{"id": "opt-2", "name": "Fechado", "color": "#00ff00", "orderindex": float64(1)},
+				map[string]interface{}{"id": "opt-1", "name": "Aberto", "color": "#ff0000", "orderindex": float64(0)},
+			},
+		}},
+		"f-tags": {ID: "f-tags", Name: "Tags", Type: "labels", Options: map[string]interface{}{
+			"options": []interface{}{map[string]interface{}{"id": "lbl-1", "label": "Urgente"}},
+		}},
+		"f-valor": {ID: "f-valor", Name: "Valor", Type: "currency", Options: map[string]interface{}{"precision": float64(2)}},
+	}}
+	r := gin.New()
+	r.GET("/api/web/metadata/fields/:id/options", h.GetFieldOptions)
+	return r
+}
+
+func TestGetFieldOptionsDropdown(t *testing.T) {
+	r := fieldOptionsRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/web/metadata/fields/f-status/options", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp FieldOptionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := []service.FieldOption{
+		{ID: "opt-1", Name: "Aberto", Color: "#ff0000"},
+		{ID: "opt-2", Name: "Fechado", Color: "#00ff00"},
+	}
+	if !resp.Success || !reflect.DeepEqual(resp.Data, want) {
+		t.Errorf("options = %+v, expected %+v", resp.Data, want)
+	}
+
+	// Labels options are named by "label"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/web/metadata/fields/f-tags/options", nil))
+	if w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+		t.Fatalf("expected 200 for a labels field, got %d", w.Code)
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Name != "Urgente" {
+		t.Errorf("labels options = %+v", resp.Data)
+	}
+}
+
+func TestGetFieldOptionsNotFound(t *testing.T) {
+	r := fieldOptionsRouter()
+
+	for _, fieldID := range []string{"f-unknown", "f-valor"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/web/metadata/fields/"+fieldID+"/options", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d: %s", fieldID, w.Code, w.Body.String())
+		}
+	}
+}
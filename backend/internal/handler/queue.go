@@ -3,9 +3,13 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
 	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"github.com/cleberrangel/clickup-excel-api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -16,6 +20,16 @@ type QueueHandler struct {
 	queueService   *service.QueueService
 	uploadService  *service.UploadService
 	mappingService *service.MappingService
+	etaProvider    JobETAProvider
+	jobs           JobLookup
+	progressEvents ProgressSubscriber
+	sseHeartbeat   time.Duration
+	templates      *service.JobTemplateService
+}
+
+// JobETAProvider returns the time estimate of a job being processed
+type JobETAProvider interface {
+	EstimatedSecondsRemaining(jobID int) (int, bool)
 }
 
 // NewQueueHandler creates a new queue handler
@@ -24,13 +38,46 @@ func NewQueueHandler(queueService *service.QueueService, uploadService *service.
 		queueService:   queueService,
 		uploadService:  uploadService,
 		mappingService: mappingService,
+		jobs:           queueService,
+		sseHeartbeat:   DefaultSSEHeartbeat,
 	}
 }
 
+// SetETAProvider sets where GetJob reads the remaining time of processing jobs
+func (h *QueueHandler) SetETAProvider(provider JobETAProvider) {
+	h.etaProvider = provider
+}
+
 // CreateJobRequest represents the request body for creating a job
 type CreateJobRequest struct {
 	MappingID string `json:"mapping_id" binding:"required"`
 	Title     string `json:"title" binding:"required"`
+	Locale    string `json:"locale,omitempty"`   // pt-BR (padrão) ou en-US
+	Timezone  string `json:"timezone,omitempty"` // ex.: America/Sao_Paulo (padrão do servidor)
+	Priority  string `json:"priority,omitempty"` // low, normal (padrão) ou high
+	// ScheduledAt adia o processamento até o horário informado (RFC3339, máximo 90 dias)
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	// DuplicateResolution trata linhas com a mesma task e campo: last-wins (padrão), first-wins ou error
+	DuplicateResolution string `json:"duplicate_resolution,omitempty"`
+	// TokenLabel escolhe qual token do ClickUp do usuário o job usa (vazio = token padrão)
+	TokenLabel string `json:"token_label,omitempty"`
+	// CustomTaskIDs indica que a coluna de task usa IDs personalizados (ex.: PROJ-123);
+	// exige TeamID, o ID do workspace
+	CustomTaskIDs bool   `json:"custom_task_ids,omitempty"`
+	TeamID        string `json:"team_id,omitempty"`
+	// RowFilter processa só as linhas que atendem às condições (coluna, operador, valor)
+	RowFilter *repository.RowFilter `json:"row_filter,omitempty"`
+	// ConflictSince ativa a concorrência otimista: linhas de tasks alteradas no ClickUp
+	// depois deste horário (geração da planilha, RFC3339) não são gravadas e são
+	// listadas como conflitos no resultado
+	ConflictSince *time.Time `json:"conflict_since,omitempty"`
+	// DryRun processa o arquivo sem gravar no ClickUp; com PreviewDiff, o resultado traz
+	// o valor atual e o novo de cada campo (uma leitura a mais por task)
+	DryRun      bool `json:"dry_run,omitempty"`
+	PreviewDiff bool `json:"preview_diff,omitempty"`
+	// CommentOnUpdate comenta em cada task atualizada os campos gravados pela importação
+	// (rastreabilidade); uma falha ao comentar não falha a linha
+	CommentOnUpdate bool `json:"comment_on_update,omitempty"`
 }
 
 // JobResponse represents a job in API responses
@@ -44,9 +91,15 @@ type JobResponse struct {
 	SuccessCount  int      `json:"success_count"`
 	ErrorCount    int      `json:"error_count"`
 	ErrorDetails  []string `json:"error_details,omitempty"`
+	Priority      string   `json:"priority"`
+	ScheduledAt   *string  `json:"scheduled_at,omitempty"`
 	CreatedAt     string   `json:"created_at"`
 	UpdatedAt     string   `json:"updated_at"`
 	CompletedAt   *string  `json:"completed_at,omitempty"`
+	// EstimatedSecondsRemaining is set while the job is processing and a rate is known
+	EstimatedSecondsRemaining *int `json:"estimated_seconds_remaining,omitempty"`
+	// DryRunResult is the summary of a finished dry run (diffs included when requested)
+	DryRunResult map[string]interface{} `json:"dry_run_result,omitempty"`
 }
 
 // CreateJob creates a new update job
@@ -56,6 +109,7 @@ type JobResponse struct {
 // @Accept json
 // @Produce json
 // @Param request body CreateJobRequest true "Job creation request"
+// @Param Idempotency-Key header string false "Retries with the same key return the original job instead of creating another"
 // @Success 201 {object} JobResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
@@ -67,7 +121,7 @@ func (h *QueueHandler) CreateJob(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
@@ -77,7 +131,7 @@ func (h *QueueHandler) CreateJob(c *gin.Context) {
 	var req CreateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warn().Err(err).Msg("Erro ao fazer bind do request")
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados inválidos",
 			"details": err.Error(),
@@ -85,76 +139,272 @@ func (h *QueueHandler) CreateJob(c *gin.Context) {
 		return
 	}
 	
+	spec := jobSpec{
+		Title: req.Title,
+		Options: repository.JobTemplateOptions{
+			Locale:              req.Locale,
+			Timezone:            req.Timezone,
+			Priority:            req.Priority,
+			DuplicateResolution: req.DuplicateResolution,
+			TokenLabel:          req.TokenLabel,
+			CustomTaskIDs:       req.CustomTaskIDs,
+			TeamID:              req.TeamID,
+			RowFilter:           req.RowFilter,
+			CommentOnUpdate:     req.CommentOnUpdate,
+		},
+		ScheduledAt:   req.ScheduledAt,
+		ConflictSince: req.ConflictSince,
+		DryRun:        req.DryRun,
+		PreviewDiff:   req.PreviewDiff,
+	}
+	if !h.validateJobSpec(c, &spec) {
+		return
+	}
+	
 	// Get mapping to retrieve file path and mapping data
 	mapping, err := h.mappingService.GetMappingByUser(req.MappingID, userID.(string))
 	if err != nil {
 		log.Error().Err(err).Str("mapping_id", req.MappingID).Msg("Erro ao buscar mapping")
-		c.JSON(http.StatusNotFound, gin.H{
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
 			"success": false,
 			"error":   "Mapeamento não encontrado",
 		})
 		return
 	}
 	
+	job, ok := h.createJobFromMapping(c, userID.(string), spec, mapping)
+	if !ok {
+		return
+	}
+	
+	h.auditJobCreate(c, job, map[string]interface{}{
+		"mapping_id": req.MappingID,
+	})
+	
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    toJobResponse(job),
+	})
+}
+
+// jobSpec is a job to create from a stored mapping; the options come from the request
+// (CreateJob) or from a job template (RunTemplate)
+type jobSpec struct {
+	Title         string
+	Options       repository.JobTemplateOptions
+	ScheduledAt   *time.Time
+	ConflictSince *time.Time
+	DryRun        bool
+	PreviewDiff   bool
+
+	priority int // parsed Options.Priority, set by validateJobSpec
+}
+
+// validateJobSpec checks the job options and normalizes them, answering 400 when one is invalid
+func (h *QueueHandler) validateJobSpec(c *gin.Context, spec *jobSpec) bool {
+	if !validateJobOptions(c, &spec.Options) {
+		return false
+	}
+	spec.priority, _ = repository.ParseJobPriority(spec.Options.Priority) // checked by validateJobOptions
+	
+	if spec.PreviewDiff && !spec.DryRun {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "preview_diff inválido",
+			"details": "preview_diff só pode ser usado com dry_run",
+		})
+		return false
+	}
+	
+	if err := h.queueService.ValidateSchedule(spec.ScheduledAt); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Agendamento inválido",
+			"details": err.Error(),
+		})
+		return false
+	}
+	
+	return true
+}
+
+// validateJobOptions checks the options shared by jobs and job templates, normalizing
+// the token label and team ID; answers 400 when one is invalid
+func validateJobOptions(c *gin.Context, options *repository.JobTemplateOptions) bool {
+	if options.Locale != "" && !client.IsSupportedLocale(options.Locale) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Locale inválido",
+			"details": "use pt-BR ou en-US",
+		})
+		return false
+	}
+	
+	if options.Timezone != "" {
+		if _, err := time.LoadLocation(options.Timezone); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Timezone inválido",
+				"details": err.Error(),
+			})
+			return false
+		}
+	}
+	
+	if _, err := repository.ParseJobPriority(options.Priority); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Prioridade inválida",
+			"details": err.Error(),
+		})
+		return false
+	}
+	
+	if !service.IsValidDuplicateResolution(options.DuplicateResolution) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Resolução de duplicados inválida",
+			"details": "use last-wins, first-wins ou error",
+		})
+		return false
+	}
+	
+	tokenLabel, err := service.NormalizeTokenLabel(options.TokenLabel)
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Token inválido",
+			"details": err.Error(),
+		})
+		return false
+	}
+	options.TokenLabel = tokenLabel
+	
+	options.TeamID = strings.TrimSpace(options.TeamID)
+	if options.CustomTaskIDs && options.TeamID == "" {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "team_id obrigatório",
+			"details": "IDs personalizados de tasks exigem o ID do workspace (team_id)",
+		})
+		return false
+	}
+	
+	return true
+}
+
+// createJobFromMapping reads the mapped file and queues the job. On failure the error
+// response is already written and ok is false.
+func (h *QueueHandler) createJobFromMapping(c *gin.Context, userID string, spec jobSpec, mapping *service.StoredMapping) (*repository.UpdateJob, bool) {
+	log := logger.Get(c.Request.Context())
+	
+	// The path was checked when the mapping was saved; check again before reading it
+	if !requireTempPath(c, h.uploadService, mapping.FilePath) {
+		return nil, false
+	}
+	
 	// Get file data to count total rows
-	_, data, err := h.uploadService.GetFileData(mapping.FilePath)
+	columns, data, err := h.uploadService.GetFileData(mapping.FilePath)
 	if err != nil {
 		log.Error().Err(err).Str("file_path", mapping.FilePath).Msg("Erro ao ler arquivo")
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Erro ao ler arquivo de dados",
 			"details": err.Error(),
 		})
-		return
+		return nil, false
 	}
 	
 	totalRows := len(data)
 	
+	// The filter may only reference columns of the file
+	if err := service.ValidateRowFilter(spec.Options.RowFilter, columns, mapping.NormalizeColumns, spec.Options.Locale); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Filtro de linhas inválido",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+	
+	if spec.ConflictSince != nil && spec.ConflictSince.After(time.Now()) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "conflict_since inválido",
+			"details": "o horário de geração da planilha não pode estar no futuro",
+		})
+		return nil, false
+	}
+	
 	// Convert mapping to map[string]string
 	mappingMap := h.mappingService.ConvertToJobMapping(mapping.Mappings)
+	options := repository.JobOptions{
+		Defaults:            h.mappingService.ConvertToJobDefaults(mapping.Mappings),
+		Locale:              spec.Options.Locale,
+		Timezone:            spec.Options.Timezone,
+		DuplicateResolution: spec.Options.DuplicateResolution,
+		TokenLabel:          spec.Options.TokenLabel,
+		CustomTaskIDs:       spec.Options.CustomTaskIDs,
+		NormalizeColumns:    mapping.NormalizeColumns,
+		RowFilter:           spec.Options.RowFilter,
+		DryRun:              spec.DryRun,
+		PreviewDiff:         spec.PreviewDiff,
+		CommentOnUpdate:     spec.Options.CommentOnUpdate,
+	}
+	if spec.Options.CustomTaskIDs {
+		options.TeamID = spec.Options.TeamID
+	}
+	if spec.ConflictSince != nil {
+		options.ConflictCheck = &repository.ConflictCheck{Since: *spec.ConflictSince}
+	}
 	
 	// Create job
-	job, err := h.queueService.CreateJob(userID.(string), req.Title, mapping.FilePath, mappingMap, totalRows)
+	job, err := h.queueService.CreateJob(userID, spec.Title, mapping.FilePath, mappingMap, options, totalRows, spec.priority, spec.ScheduledAt)
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao criar job")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao criar job",
 			"details": err.Error(),
 		})
-		return
+		return nil, false
 	}
 	
-	log.Info().Int("job_id", job.ID).Str("user_id", userID.(string)).Msg("Job criado com sucesso")
-	
+	log.Info().Int("job_id", job.ID).Str("user_id", userID).Msg("Job criado com sucesso")
+	return job, true
+}
+
+// auditJobCreate records the creation of a job; details complement the title, rows and priority
+func (h *QueueHandler) auditJobCreate(c *gin.Context, job *repository.UpdateJob, details map[string]interface{}) {
 	// Get username for audit
 	username, _ := c.Get("username")
 	usernameStr := ""
 	if username != nil {
 		usernameStr = username.(string)
 	}
-
+	
+	details["title"] = job.Title
+	details["total_rows"] = job.TotalRows
+	details["priority"] = repository.JobPriorityName(job.Priority)
+	if job.Options.DryRun {
+		details["dry_run"] = true
+	}
+	if job.Options.CommentOnUpdate {
+		details["comment_on_update"] = true
+	}
+	
 	// Audit job creation
 	logger.Audit(c.Request.Context(), logger.AuditEvent{
 		Action:     logger.AuditActionJobCreate,
-		UserID:     userID.(string),
+		UserID:     job.UserID,
 		Username:   usernameStr,
 		Resource:   "job",
 		ResourceID: strconv.Itoa(job.ID),
 		ClientIP:   c.ClientIP(),
 		Success:    true,
-		Details: map[string]interface{}{
-			"title":      req.Title,
-			"total_rows": totalRows,
-			"mapping_id": req.MappingID,
-		},
+		Details:    details,
 	})
 	metrics.Get().IncrementJobCreated()
-
-	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"data":    toJobResponse(job),
-	})
 }
 
 // ListJobs lists all jobs for the current user
@@ -172,7 +422,7 @@ func (h *QueueHandler) ListJobs(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
@@ -182,7 +432,7 @@ func (h *QueueHandler) ListJobs(c *gin.Context) {
 	jobs, err := h.queueService.GetJobsByUser(userID.(string))
 	if err != nil {
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao listar jobs")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao listar jobs",
 			"details": err.Error(),
@@ -219,7 +469,7 @@ func (h *QueueHandler) GetJob(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 		})
@@ -230,7 +480,7 @@ func (h *QueueHandler) GetJob(c *gin.Context) {
 	jobIDStr := c.Param("id")
 	jobID, err := strconv.Atoi(jobIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "ID do job inválido",
 		})
@@ -240,14 +490,14 @@ func (h *QueueHandler) GetJob(c *gin.Context) {
 	job, err := h.queueService.GetJobByID(jobID)
 	if err != nil {
 		if err == service.ErrJobNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
+			middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
 				"success": false,
 				"error":   "Job não encontrado",
 			})
 			return
 		}
 		log.Error().Err(err).Int("job_id", jobID).Msg("Erro ao buscar job")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro ao buscar job",
 			"details": err.Error(),
@@ -257,16 +507,23 @@ func (h *QueueHandler) GetJob(c *gin.Context) {
 	
 	// Verify job belongs to user
 	if job.UserID != userID.(string) {
-		c.JSON(http.StatusNotFound, gin.H{
+		middleware.ErrorJSON(c, http.StatusNotFound, gin.H{
 			"success": false,
 			"error":   "Job não encontrado",
 		})
 		return
 	}
 	
+	resp := toJobResponseFromRepo(job)
+	if h.etaProvider != nil && job.Status == service.JobStatusProcessing {
+		if seconds, ok := h.etaProvider.EstimatedSecondsRemaining(job.ID); ok {
+			resp.EstimatedSecondsRemaining = &seconds
+		}
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    toJobResponseFromRepo(job),
+		"data":    resp,
 	})
 }
 
@@ -287,8 +544,15 @@ func toJobResponseFromRepo(job *repository.UpdateJob) JobResponse {
 		SuccessCount:  job.SuccessCount,
 		ErrorCount:    job.ErrorCount,
 		ErrorDetails:  job.ErrorDetails,
+		Priority:      repository.JobPriorityName(job.Priority),
 		CreatedAt:     job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:     job.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DryRunResult:  job.DryRunResult,
+	}
+	
+	if job.ScheduledAt != nil {
+		scheduledStr := job.ScheduledAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.ScheduledAt = &scheduledStr
 	}
 	
 	if job.CompletedAt != nil {
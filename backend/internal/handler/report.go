@@ -2,15 +2,19 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/cleberrangel/clickup-excel-api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -45,6 +49,7 @@ func NewReportHandler(reportService *service.ReportService, webhookService *serv
 // @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security     BearerAuth
 // @Param        request body model.ReportRequest true "Configuração do relatório"
+// @Param        Idempotency-Key header string false "Chave para repetir a requisição sem gerar outro relatório"
 // @Success      200 {object} model.Response "Quando webhook_url é fornecido"
 // @Success      200 {file} binary "Arquivo Excel quando webhook_url não é fornecido"
 // @Failure      400 {object} model.ErrorResponse
@@ -55,7 +60,7 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 	var req model.ReportRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -65,21 +70,54 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 
 	// Validações adicionais
 	if len(req.ListIDs) == 0 {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "list_ids não pode estar vazio",
 		})
 		return
 	}
 
-	if len(req.Fields) == 0 {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+	if len(req.Fields) == 0 && req.Aggregate == nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "fields não pode estar vazio",
 		})
 		return
 	}
 
+	if req.Aggregate != nil {
+		if err := req.Aggregate.Validate(); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "agregação inválida",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.Filters != nil {
+		if err := req.Filters.Validate(); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "filtros inválidos",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.WebhookURL != "" {
+		if err := h.webhookService.ValidateURL(c.Request.Context(), req.WebhookURL); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "webhook_url inválido",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
 	log := logger.FromGin(c)
 	log.Info().
 		Int("lists", len(req.ListIDs)).
@@ -104,10 +142,17 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 		return
 	}
 
-	log.Info().
-		Int("tasks", result.TotalTasks).
-		Int("lists", result.TotalLists).
-		Msg("Relatório gerado com sucesso")
+	if result.Partial {
+		log.Warn().
+			Strs("failed_lists", result.FailedLists()).
+			Int("tasks", result.TotalTasks).
+			Msg("Relatório gerado com dados parciais")
+	} else {
+		log.Info().
+			Int("tasks", result.TotalTasks).
+			Int("lists", result.TotalLists).
+			Msg("Relatório gerado com sucesso")
+	}
 
 	// Configura headers de resposta
 	filename := fmt.Sprintf("%s.xlsx", result.FolderName)
@@ -127,8 +172,7 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 	if stat != nil {
 		c.Header("Content-Length", fmt.Sprintf("%d", stat.Size()))
 	}
-	c.Header("X-Total-Tasks", fmt.Sprintf("%d", result.TotalTasks))
-	c.Header("X-Total-Lists", fmt.Sprintf("%d", result.TotalLists))
+	setReportResultHeaders(c, result)
 
 	if _, err := io.Copy(c.Writer, file); err != nil {
 		h.handleError(c, err)
@@ -136,6 +180,22 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 	}
 }
 
+// setReportResultHeaders describes the report in the response headers. A partial
+// report (some list failed mid-fetch) still returns the file, flagged with
+// X-Report-Partial, the failed lists and the warnings (RFC 2047 encoded when not ASCII).
+func setReportResultHeaders(c *gin.Context, result *service.ReportResult) {
+	c.Header("X-Total-Tasks", fmt.Sprintf("%d", result.TotalTasks))
+	c.Header("X-Total-Lists", fmt.Sprintf("%d", result.TotalLists))
+	if !result.Partial {
+		return
+	}
+	c.Header("X-Report-Partial", "true")
+	c.Header("X-Failed-Lists", strings.Join(result.FailedLists(), ","))
+	for _, warning := range result.Warnings {
+		c.Writer.Header().Add("X-Report-Warning", mime.QEncoding.Encode("utf-8", warning))
+	}
+}
+
 // processAsync processa o relatório de forma assíncrona e envia para o webhook
 func (h *ReportHandler) processAsync(req model.ReportRequest, requestID string) {
 	// Timeout de 90 minutos para processar até 200k+ tasks com retries
@@ -199,33 +259,42 @@ func (h *ReportHandler) processAsync(req model.ReportRequest, requestID string)
 func (h *ReportHandler) handleError(c *gin.Context, err error) {
 	logger.FromGin(c).Error().Err(err).Msg("Erro ao gerar relatório")
 
+	if errors.Is(err, service.ErrUnknownLists) || errors.Is(err, service.ErrEmptyListIDs) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "list_ids inválidos",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	switch err {
 	case model.ErrRateLimited:
-		c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusTooManyRequests, model.ErrorResponse{
 			Success: false,
 			Error:   "rate limit excedido",
 			Details: "aguarde alguns segundos e tente novamente",
 		})
 	case model.ErrUnauthorized:
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "token do ClickUp inválido",
 			Details: "verifique a variável TOKEN_CLICKUP",
 		})
 	case model.ErrNotFound:
-		c.JSON(http.StatusNotFound, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
 			Success: false,
 			Error:   "lista não encontrada",
 			Details: "verifique os IDs das listas",
 		})
 	case model.ErrTimeout:
-		c.JSON(http.StatusGatewayTimeout, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusGatewayTimeout, model.ErrorResponse{
 			Success: false,
 			Error:   "timeout na requisição",
 			Details: "a API do ClickUp demorou muito para responder",
 		})
 	default:
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro interno",
 			Details: err.Error(),
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGenerateReportReturnsPartialData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// L1 has one short page; L2 starts with a full page, and its second page is always
+	// rate limited
+	clickup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/list/"), "/")
+		listID := parts[0]
+		if len(parts) == 1 {
+			json.NewEncoder(w).Encode(model.List{ID: listID})
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if listID == "L2" && page == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if listID == "L2" {
+			tasks := make([]model.Task, client.PageSize)
+			for i := range tasks {
+				tasks[i] = model.Task{ID: fmt.Sprintf("L2-%d", i), Name: "Task"}
+			}
+			json.NewEncoder(w).Encode(model.TaskResponse{Tasks: tasks})
+			return
+		}
+		json.NewEncoder(w).Encode(model.TaskResponse{
+			Tasks: []model.Task{
+				{ID: listID + "-a", Name: "Task A"},
+				{ID: listID + "-b", Name: "Task B"},
+			},
+			LastPage: true,
+		})
+	}))
+	defer clickup.Close()
+
+	reportService := service.NewReportService(client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: clickup.URL}))
+	h := NewReportHandler(reportService, service.NewWebhookService())
+
+	body, _ := json.Marshal(model.ReportRequest{ListIDs: []string{"L1", "L2"}, Fields: []string{"name"}})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/reports", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.GenerateReport(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w.Body.Len() == 0 {
+		t.Error("the partial report file should be returned")
+	}
+	if got, want := w.Header().Get("X-Total-Tasks"), strconv.Itoa(2+client.PageSize); got != want {
+		t.Errorf("X-Total-Tasks = %q, expected %s (2 from L1 + the first page of L2)", got, want)
+	}
+	if got := w.Header().Get("X-Report-Partial"); got != "true" {
+		t.Errorf("X-Report-Partial = %q", got)
+	}
+	if got := w.Header().Get("X-Failed-Lists"); got != "L2" {
+		t.Errorf("X-Failed-Lists = %q", got)
+	}
+
+	warnings := w.Header().Values("X-Report-Warning")
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning, got %v", warnings)
+	}
+	warning, err := new(mime.WordDecoder).DecodeHeader(warnings[0])
+	if err != nil {
+		t.Fatalf("decoding warning %q: %v", warnings[0], err)
+	}
+	if !strings.Contains(warning, "lista L2") || !strings.Contains(warning, fmt.Sprintf("%d tasks coletadas antes do erro na página 1", client.PageSize)) {
+		t.Errorf("warning = %q", warning)
+	}
+}
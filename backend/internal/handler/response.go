@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/cleberrangel/clickup-excel-api/internal/logger"
+
+// errorDetails devolve a mensagem do erro para o campo details das respostas,
+// com tokens, senhas e headers sensíveis mascarados
+func errorDetails(err error) string {
+	return logger.Redact(err.Error())
+}
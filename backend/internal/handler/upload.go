@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
 	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
@@ -14,6 +19,7 @@ import (
 // UploadHandler handles file upload requests
 type UploadHandler struct {
 	uploadService *service.UploadService
+	progress      UploadProgressNotifier
 }
 
 // NewUploadHandler creates a new upload handler
@@ -23,14 +29,23 @@ func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
 	}
 }
 
+// SetProgressNotifier enables upload progress events for authenticated users
+func (h *UploadHandler) SetProgressNotifier(notifier UploadProgressNotifier) {
+	h.progress = notifier
+}
+
 // UploadFile handles file upload and returns preview
 // @Summary      Upload file for processing
-// @Description  Uploads a CSV or XLSX file and returns column list and preview
+// @Description  Uploads a CSV, XLSX or ODS file and returns column list and preview
 // @Tags         upload
 // @Accept       multipart/form-data
 // @Produce      json
 // @Security     BasicAuth
-// @Param        file formance file true "CSV or XLSX file to upload"
+// @Param        file formance file true "CSV, XLSX or ODS file to upload"
+// @Param        sheet formData string false "XLSX sheet to parse (defaults to the first one)"
+// @Param        skip_rows formData int false "Leading rows to discard before the header (blank rows are not counted)"
+// @Param        header_row formData int false "1-based header row, counted after skip_rows (default 1)"
+// @Param        preview_rows query int false "Rows returned in the preview (default 5, capped at 100); also accepted as form field or previewRows"
 // @Success      200 {object} FileUploadResponse
 // @Failure      400 {object} model.ErrorResponse
 // @Failure      401 {object} model.ErrorResponse
@@ -40,11 +55,39 @@ func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
 func (h *UploadHandler) UploadFile(c *gin.Context) {
 	log := logger.FromGin(c)
 	
+	// Reject oversized bodies before they are buffered; the multipart envelope
+	// (boundaries, headers, form fields) gets some room on top of the file limit
+	maxBody := h.uploadService.MaxFileSize() + uploadMultipartOverhead
+	if c.Request.ContentLength > maxBody {
+		h.respondFileTooLarge(c)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBody)
+	
+	// Report transfer progress while the multipart body is read
+	var progress *progressReader
+	if h.progress != nil {
+		if userID := c.GetString("user_id"); userID != "" {
+			progress = newProgressReader(c.Request.Body, c.Request.ContentLength, func(read, total int64) {
+				h.progress.SendUploadProgress(userID, read, total)
+			})
+			c.Request.Body = progress
+		}
+	}
+	
 	// Get uploaded file
 	file, header, err := c.Request.FormFile("file")
+	if progress != nil && err == nil {
+		progress.finish()
+	}
+	if err != nil && isBodyTooLarge(err) {
+		log.Warn().Int64("limit", h.uploadService.MaxFileSize()).Msg("Upload excede o tamanho máximo")
+		h.respondFileTooLarge(c)
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao obter arquivo do formulário")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "arquivo não encontrado no formulário",
 			Details: "use o campo 'file' para enviar o arquivo",
@@ -59,10 +102,35 @@ func (h *UploadHandler) UploadFile(c *gin.Context) {
 	// Validate file format first
 	if err := h.uploadService.ValidateFileFormat(sanitizedFilename); err != nil {
 		log.Warn().Str("filename", sanitizedFilename).Msg("Formato de arquivo inválido")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "formato de arquivo não suportado",
-			Details: "apenas arquivos CSV e XLSX são aceitos",
+			Details: "apenas arquivos CSV, XLSX e ODS são aceitos",
+		})
+		return
+	}
+	
+	opts := service.UploadOptions{Sheet: c.PostForm("sheet")}
+	skipRows, skipErr := parseOptionalInt(c.PostForm("skip_rows"))
+	headerRow, headerErr := parseOptionalInt(c.PostForm("header_row"))
+	opts.SkipRows, opts.HeaderRow = skipRows, headerRow
+	if skipErr != nil || headerErr != nil || opts.Validate() != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "opções de cabeçalho inválidas",
+			Details: service.ErrInvalidLayout.Error(),
+		})
+		return
+	}
+	
+	// Preview size chosen by the UI (query string or form field)
+	previewRows, previewErr := parseOptionalInt(previewRowsParam(c))
+	opts.PreviewRows = previewRows
+	if previewErr != nil || opts.Validate() != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "quantidade de linhas da prévia inválida",
+			Details: service.ErrInvalidPreviewRows.Error(),
 		})
 		return
 	}
@@ -70,48 +138,97 @@ func (h *UploadHandler) UploadFile(c *gin.Context) {
 	log.Info().
 		Str("filename", sanitizedFilename).
 		Int64("size", header.Size).
+		Str("sheet", opts.Sheet).
+		Int("skip_rows", opts.SkipRows).
+		Int("header_row", opts.HeaderRow).
 		Msg("Processando upload de arquivo")
 	
 	// Process file with sanitized filename
-	result, err := h.uploadService.ProcessFile(sanitizedFilename, file, header.Size)
+	result, err := h.uploadService.ProcessFileContext(c.Request.Context(), sanitizedFilename, file, header.Size, opts)
 	if err != nil {
 		log.Error().Err(err).Str("filename", header.Filename).Msg("Erro ao processar arquivo")
-		
-		switch err {
-		case service.ErrFileTooLarge:
-			c.JSON(http.StatusRequestEntityTooLarge, model.ErrorResponse{
-				Success: false,
-				Error:   "arquivo muito grande",
-				Details: "o limite máximo é 10MB",
-			})
-		case service.ErrEmptyFile:
-			c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Success: false,
-				Error:   "arquivo vazio",
-				Details: "o arquivo não contém dados",
-			})
-		case service.ErrNoColumns:
-			c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Success: false,
-				Error:   "arquivo sem colunas",
-				Details: "o arquivo não contém cabeçalhos de coluna",
-			})
-		case service.ErrUnsupportedType:
-			c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Success: false,
-				Error:   "formato não suportado",
-				Details: "apenas arquivos CSV e XLSX são aceitos",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-				Success: false,
-				Error:   "erro ao processar arquivo",
-				Details: err.Error(),
-			})
-		}
+		h.respondProcessError(c, err)
+		return
+	}
+	
+	h.respondUploaded(c, result)
+}
+
+// respondProcessError maps an error from processing an uploaded file to its response
+func (h *UploadHandler) respondProcessError(c *gin.Context, err error) {
+	// The request deadline passed (the limits middleware answers 504) or the client left
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return
+	}
+	if errors.Is(err, service.ErrSheetNotFound) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "aba não encontrada",
+			Details: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, service.ErrFileRejected) {
+		middleware.ErrorJSON(c, http.StatusUnprocessableEntity, model.ErrorResponse{
+			Success: false,
+			Error:   "arquivo rejeitado",
+			Details: "o arquivo não passou na verificação de segurança",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTooManyRows) {
+		middleware.ErrorJSON(c, http.StatusRequestEntityTooLarge, model.ErrorResponse{
+			Success: false,
+			Error:   "arquivo com linhas demais",
+			Details: fmt.Sprintf("%v; divida o arquivo em partes de até %d linhas e envie cada uma separadamente", err, h.uploadService.MaxRows()),
+		})
+		return
+	}
+	if errors.Is(err, service.ErrFileTooLarge) {
+		h.respondFileTooLarge(c)
+		return
+	}
+	if errors.Is(err, service.ErrHeaderNotFound) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "cabeçalho não encontrado",
+			Details: err.Error(),
+		})
 		return
 	}
 	
+	switch err {
+	case service.ErrEmptyFile:
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "arquivo vazio",
+			Details: "o arquivo não contém dados",
+		})
+	case service.ErrNoColumns:
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "arquivo sem colunas",
+			Details: "o arquivo não contém cabeçalhos de coluna",
+		})
+	case service.ErrUnsupportedType:
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "formato não suportado",
+			Details: "apenas arquivos CSV, XLSX e ODS são aceitos",
+		})
+	default:
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao processar arquivo",
+			Details: err.Error(),
+		})
+	}
+}
+
+// respondUploaded audits a processed upload and returns its preview
+func (h *UploadHandler) respondUploaded(c *gin.Context, result *service.FileUpload) {
+	log := logger.FromGin(c)
+	
 	log.Info().
 		Str("filename", result.Filename).
 		Int("columns", len(result.Columns)).
@@ -144,6 +261,7 @@ func (h *UploadHandler) UploadFile(c *gin.Context) {
 			"size":       result.Size,
 			"columns":    len(result.Columns),
 			"total_rows": result.TotalRows,
+			"sheet":      result.Sheet,
 		},
 	})
 	metrics.Get().IncrementFileUpload(result.Size)
@@ -158,10 +276,60 @@ func (h *UploadHandler) UploadFile(c *gin.Context) {
 			Preview:     result.Preview,
 			TempPath:    result.TempPath,
 			TotalRows:   result.TotalRows,
+			Sheets:      result.Sheets,
+			Sheet:       result.Sheet,
+			ColumnStats: result.ColumnStats,
 		},
 	})
 }
 
+// uploadMultipartOverhead is the room given to the multipart envelope on top of the file size limit
+const uploadMultipartOverhead = 64 << 10
+
+// respondFileTooLarge reports an upload over the configured size limit
+func (h *UploadHandler) respondFileTooLarge(c *gin.Context) {
+	middleware.ErrorJSON(c, http.StatusRequestEntityTooLarge, model.ErrorResponse{
+		Success: false,
+		Error:   "arquivo muito grande",
+		Details: fmt.Sprintf("o limite máximo é %s", formatBytes(h.uploadService.MaxFileSize())),
+	})
+}
+
+// isBodyTooLarge reports whether err comes from http.MaxBytesReader
+// (http.MaxBytesError needs Go 1.19)
+func isBodyTooLarge(err error) bool {
+	return strings.Contains(err.Error(), "http: request body too large")
+}
+
+// formatBytes formats a size limit as MB when it is a whole number of megabytes
+func formatBytes(n int64) string {
+	if n >= 1<<20 && n%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", n>>20)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// previewRowsParam reads the requested preview size from the query string or the form
+func previewRowsParam(c *gin.Context) string {
+	for _, name := range []string{"preview_rows", "previewRows"} {
+		if value := c.Query(name); value != "" {
+			return value
+		}
+		if value := c.PostForm(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseOptionalInt parses an optional form integer ("" means 0)
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
 // FileUploadResponse represents the response for file upload
 type FileUploadResponse struct {
 	Success bool           `json:"success"`
@@ -177,6 +345,10 @@ type FileUploadData struct {
 	Preview     [][]string `json:"preview"`
 	TempPath    string     `json:"temp_path"`
 	TotalRows   int        `json:"total_rows"`
+	Sheets      []string   `json:"sheets,omitempty"`
+	Sheet       string     `json:"sheet,omitempty"`
+	// ColumnStats has distinct/empty counts, value lengths and sample values per column
+	ColumnStats []service.ColumnStats `json:"column_stats,omitempty"`
 }
 
 // DeleteTempFile handles deletion of temporary files
@@ -196,7 +368,7 @@ func (h *UploadHandler) DeleteTempFile(c *gin.Context) {
 	
 	var req DeleteTempFileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -205,18 +377,13 @@ func (h *UploadHandler) DeleteTempFile(c *gin.Context) {
 	}
 
 	// Validate path to prevent path traversal attacks
-	if !middleware.ValidateFilePath(req.TempPath, "") {
-		log.Warn().Str("path", req.TempPath).Msg("Tentativa de path traversal detectada")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Success: false,
-			Error:   "caminho de arquivo inválido",
-			Details: "o caminho contém caracteres não permitidos",
-		})
+	if !requireTempPath(c, h.uploadService, req.TempPath) {
 		return
 	}
 	
 	if err := h.uploadService.RemoveTempFile(req.TempPath); err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		log.Error().Err(err).Str("path", req.TempPath).Msg("Erro ao remover arquivo temporário")
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro ao remover arquivo temporário",
 			Details: err.Error(),
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// InitChunkedUploadRequest starts an upload sent in parts
+type InitChunkedUploadRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	Size        int64  `json:"size" binding:"required"`
+	Sheet       string `json:"sheet"`
+	SkipRows    int    `json:"skip_rows"`
+	HeaderRow   int    `json:"header_row"`
+	PreviewRows int    `json:"preview_rows"`
+}
+
+// ChunkedUploadResponse wraps the state of a chunked upload
+type ChunkedUploadResponse struct {
+	Success bool                         `json:"success"`
+	Data    *service.ChunkedUploadStatus `json:"data"`
+}
+
+// contentRangePattern matches "bytes start-end/total"
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// InitChunkedUpload starts a chunked upload
+// @Summary      Start a chunked upload
+// @Description  Registers a file to be sent in parts and returns its upload_id. Parts are sent with PUT /upload/{id}/chunk and the file is processed by POST /upload/{id}/complete.
+// @Tags         upload
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body InitChunkedUploadRequest true "File name, total size and header options"
+// @Success      201 {object} ChunkedUploadResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      413 {object} model.ErrorResponse
+// @Failure      429 {object} model.ErrorResponse
+// @Router       /api/web/upload/init [post]
+func (h *UploadHandler) InitChunkedUpload(c *gin.Context) {
+	var req InitChunkedUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "payload inválido",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	opts := service.UploadOptions{
+		Sheet:       req.Sheet,
+		SkipRows:    req.SkipRows,
+		HeaderRow:   req.HeaderRow,
+		PreviewRows: req.PreviewRows,
+	}
+	filename := middleware.SanitizeFilename(req.Filename)
+
+	status, err := h.uploadService.InitChunkedUpload(c.GetString("user_id"), filename, req.Size, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLayout), errors.Is(err, service.ErrInvalidPreviewRows):
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "opções de cabeçalho inválidas",
+				Details: err.Error(),
+			})
+		case errors.Is(err, service.ErrTooManyChunkedUploads):
+			h.respondChunkedError(c, err)
+		default:
+			h.respondProcessError(c, err)
+		}
+		return
+	}
+
+	logger.FromGin(c).Info().
+		Str("upload_id", status.UploadID).
+		Str("filename", filename).
+		Int64("size", req.Size).
+		Msg("Upload em partes iniciado")
+
+	c.JSON(http.StatusCreated, ChunkedUploadResponse{Success: true, Data: status})
+}
+
+// UploadChunk stores one part of a chunked upload
+// @Summary      Send a part of a chunked upload
+// @Description  Body is the raw bytes of the part; Content-Range (bytes start-end/total) says where they go. Parts may be sent in any order and sent again after a failure.
+// @Tags         upload
+// @Accept       application/octet-stream
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path string true "Upload ID"
+// @Param        Content-Range header string true "bytes start-end/total"
+// @Success      200 {object} ChunkedUploadResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      404 {object} model.ErrorResponse
+// @Router       /api/web/upload/{id}/chunk [put]
+func (h *UploadHandler) UploadChunk(c *gin.Context) {
+	start, end, total, err := parseContentRange(c.GetHeader("Content-Range"))
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "Content-Range inválido",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	length := end - start + 1
+	if c.Request.ContentLength >= 0 && c.Request.ContentLength != length {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "parte inválida",
+			Details: fmt.Sprintf("o corpo tem %d bytes, mas Content-Range indica %d", c.Request.ContentLength, length),
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	uploadID := c.Param("id")
+	if status, err := h.uploadService.GetChunkedUploadStatus(userID, uploadID); err == nil && status.Size != total {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "parte inválida",
+			Details: fmt.Sprintf("o upload tem %d bytes, mas Content-Range indica %d", status.Size, total),
+		})
+		return
+	}
+
+	status, err := h.uploadService.WriteChunk(userID, uploadID, start, length, c.Request.Body)
+	if err != nil {
+		logger.FromGin(c).Warn().Err(err).Str("upload_id", uploadID).Msg("Erro ao gravar parte do upload")
+		h.respondChunkedError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ChunkedUploadResponse{Success: true, Data: status})
+}
+
+// GetChunkedUpload reports the received and missing ranges of a chunked upload
+// @Summary      Get chunked upload status
+// @Description  Lets an interrupted client find which byte ranges still have to be sent
+// @Tags         upload
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path string true "Upload ID"
+// @Success      200 {object} ChunkedUploadResponse
+// @Failure      404 {object} model.ErrorResponse
+// @Router       /api/web/upload/{id} [get]
+func (h *UploadHandler) GetChunkedUpload(c *gin.Context) {
+	status, err := h.uploadService.GetChunkedUploadStatus(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.respondChunkedError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ChunkedUploadResponse{Success: true, Data: status})
+}
+
+// CompleteChunkedUpload assembles a chunked upload and processes it
+// @Summary      Complete a chunked upload
+// @Description  Processes the assembled file and returns the same preview as a single-request upload
+// @Tags         upload
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path string true "Upload ID"
+// @Success      200 {object} FileUploadResponse
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      404 {object} model.ErrorResponse
+// @Failure      409 {object} model.ErrorResponse
+// @Router       /api/web/upload/{id}/complete [post]
+func (h *UploadHandler) CompleteChunkedUpload(c *gin.Context) {
+	log := logger.FromGin(c)
+	uploadID := c.Param("id")
+
+	result, err := h.uploadService.CompleteChunkedUpload(c.Request.Context(), c.GetString("user_id"), uploadID)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", uploadID).Msg("Erro ao concluir upload em partes")
+		if errors.Is(err, service.ErrChunkedUploadNotFound) || errors.Is(err, service.ErrUploadIncomplete) {
+			h.respondChunkedError(c, err)
+			return
+		}
+		h.respondProcessError(c, err)
+		return
+	}
+
+	h.respondUploaded(c, result)
+}
+
+// respondChunkedError maps chunked upload errors to their responses
+func (h *UploadHandler) respondChunkedError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrChunkedUploadNotFound):
+		middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   "upload não encontrado",
+			Details: err.Error(),
+		})
+	case errors.Is(err, service.ErrUploadIncomplete):
+		middleware.ErrorJSON(c, http.StatusConflict, model.ErrorResponse{
+			Success: false,
+			Error:   "upload incompleto",
+			Details: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidChunk):
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "parte inválida",
+			Details: err.Error(),
+		})
+	case errors.Is(err, service.ErrTooManyChunkedUploads):
+		middleware.ErrorJSON(c, http.StatusTooManyRequests, model.ErrorResponse{
+			Success: false,
+			Error:   "uploads em partes demais",
+			Details: err.Error(),
+		})
+	default:
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   "erro ao gravar parte do upload",
+			Details: err.Error(),
+		})
+	}
+}
+
+// parseContentRange parses a "bytes start-end/total" header
+func parseContentRange(header string) (start, end, total int64, err error) {
+	match := contentRangePattern.FindStringSubmatch(header)
+	if match == nil {
+		return 0, 0, 0, fmt.Errorf("use o formato 'bytes início-fim/total'")
+	}
+	start, _ = strconv.ParseInt(match[1], 10, 64)
+	end, _ = strconv.ParseInt(match[2], 10, 64)
+	total, _ = strconv.ParseInt(match[3], 10, 64)
+	if end < start || end >= total {
+		return 0, 0, 0, fmt.Errorf("intervalo %d-%d inválido para %d bytes", start, end, total)
+	}
+	return start, end, total, nil
+}
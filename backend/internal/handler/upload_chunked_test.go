@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func chunkedUploadRouter(h *UploadHandler, userID string) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", userID) })
+	r.POST("/upload/init", h.InitChunkedUpload)
+	r.GET("/upload/:id", h.GetChunkedUpload)
+	r.PUT("/upload/:id/chunk", h.UploadChunk)
+	r.POST("/upload/:id/complete", h.CompleteChunkedUpload)
+	return r
+}
+
+func TestChunkedUploadEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var csv bytes.Buffer
+	csv.WriteString("id task,Valor\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&csv, "task%03d,%d\n", i, i)
+	}
+	content := csv.Bytes()
+	size := len(content)
+
+	h := NewUploadHandler(service.NewUploadService(t.TempDir(), 0))
+	r := chunkedUploadRouter(h, "ana")
+
+	do := func(method, path string, body []byte, contentRange string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if contentRange != "" {
+			req.Header.Set("Content-Range", contentRange)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/upload/init", []byte(fmt.Sprintf(`{"filename":"dados.csv","size":%d,"preview_rows":2}`, size)), "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("init: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var initResp ChunkedUploadResponse
+	json.Unmarshal(w.Body.Bytes(), &initResp)
+	id := initResp.Data.UploadID
+
+	half := size / 2
+	chunks := []struct{ start, end int }{{half, size - 1}, {0, half - 1}}
+
+	// Completing before the first half arrives reports what is missing
+	w = do(http.MethodPut, "/upload/"+id+"/chunk", content[chunks[0].start:], fmt.Sprintf("bytes %d-%d/%d", chunks[0].start, chunks[0].end, size))
+	if w.Code != http.StatusOK {
+		t.Fatalf("chunk: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w = do(http.MethodPost, "/upload/"+id+"/complete", nil, ""); w.Code != http.StatusConflict {
+		t.Errorf("incomplete: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	// Malformed ranges and a total that doesn't match the upload are rejected
+	for _, contentRange := range []string{"", "bytes 5-1/10", fmt.Sprintf("bytes 0-%d/%d", half-1, size+1)} {
+		if w = do(http.MethodPut, "/upload/"+id+"/chunk", content[:half], contentRange); w.Code != http.StatusBadRequest {
+			t.Errorf("Content-Range %q: status = %d", contentRange, w.Code)
+		}
+	}
+
+	w = do(http.MethodPut, "/upload/"+id+"/chunk", content[:half], fmt.Sprintf("bytes 0-%d/%d", half-1, size))
+	var chunkResp ChunkedUploadResponse
+	json.Unmarshal(w.Body.Bytes(), &chunkResp)
+	if w.Code != http.StatusOK || !chunkResp.Data.Complete {
+		t.Fatalf("last chunk: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	// Other users can't see the upload
+	other := chunkedUploadRouter(h, "bruno")
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/upload/"+id, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("other user: status = %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/upload/"+id+"/complete", nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("complete: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp FileUploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.TotalRows != 200 || len(resp.Data.Preview) != 2 || resp.Data.Size != int64(size) {
+		t.Errorf("unexpected upload data: %+v", resp.Data)
+	}
+}
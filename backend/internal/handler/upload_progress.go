@@ -0,0 +1,72 @@
+package handler
+
+import "io"
+
+const (
+	// uploadProgressSteps is roughly how many progress events a sized upload emits
+	uploadProgressSteps = 20
+	// minUploadProgressStep avoids flooding the socket for small or unsized bodies
+	minUploadProgressStep = 256 * 1024
+)
+
+// UploadProgressNotifier receives upload transfer progress (implemented by websocket.Hub)
+type UploadProgressNotifier interface {
+	SendUploadProgress(userID string, bytesRead, totalBytes int64)
+}
+
+// progressReader counts bytes read from a request body and reports progress every
+// step bytes and once more at EOF. Counting is a single addition per Read, so the
+// happy path is not slowed down.
+type progressReader struct {
+	io.ReadCloser
+	total  int64
+	read   int64
+	next   int64
+	step   int64
+	done   bool
+	report func(read, total int64)
+}
+
+func newProgressReader(body io.ReadCloser, total int64, report func(read, total int64)) *progressReader {
+	step := total / uploadProgressSteps
+	if step < minUploadProgressStep {
+		step = minUploadProgressStep
+	}
+	return &progressReader{
+		ReadCloser: body,
+		total:      total,
+		next:       step,
+		step:       step,
+		report:     report,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	p.read += int64(n)
+
+	if p.read >= p.next {
+		p.report(p.read, p.total)
+		for p.next <= p.read {
+			p.next += p.step
+		}
+	}
+	if err == io.EOF {
+		p.finish()
+	}
+	return n, err
+}
+
+// finish sends the final event once the form is parsed; the multipart reader stops at
+// the closing boundary and may never read the body up to EOF
+func (p *progressReader) finish() {
+	if p.done {
+		return
+	}
+	p.done = true
+	read := p.read
+	if p.total > read {
+		read = p.total
+	}
+	p.report(read, p.total)
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type progressEvent struct {
+	userID      string
+	read, total int64
+}
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []progressEvent
+}
+
+func (n *recordingNotifier) SendUploadProgress(userID string, bytesRead, totalBytes int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, progressEvent{userID, bytesRead, totalBytes})
+}
+
+func TestUploadFileEmitsProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// ~3MB CSV body, large enough for several progress steps
+	var csv bytes.Buffer
+	csv.WriteString("id task,Valor,Descrição\n")
+	for i := 0; csv.Len() < 3<<20; i++ {
+		fmt.Fprintf(&csv, "task%07d,%d,uma descrição razoavelmente longa para a linha %d\n", i, i, i)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "grande.csv")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(csv.Bytes())
+	mw.Close()
+	totalBytes := int64(body.Len())
+
+	uploadService := service.NewUploadService(t.TempDir(), 0)
+	h := NewUploadHandler(uploadService)
+	notifier := &recordingNotifier{}
+	h.SetProgressNotifier(notifier)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/web/upload", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Set("user_id", "user-1")
+	c.Set("username", "user")
+
+	h.UploadFile(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	notifier.mu.Lock()
+	events := notifier.events
+	notifier.mu.Unlock()
+
+	if len(events) < 5 {
+		t.Fatalf("expected several progress events, got %d", len(events))
+	}
+	var last int64
+	for _, e := range events {
+		if e.userID != "user-1" || e.total != totalBytes {
+			t.Errorf("unexpected event %+v", e)
+		}
+		if e.read < last {
+			t.Errorf("progress went backwards: %d after %d", e.read, last)
+		}
+		last = e.read
+	}
+	if last != totalBytes {
+		t.Errorf("last event reported %d bytes, expected %d", last, totalBytes)
+	}
+	if len(events) > uploadProgressSteps+2 {
+		t.Errorf("too many progress events: %d", len(events))
+	}
+}
+
+func TestUploadFileWithoutUserSendsNoProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "small.csv")
+	part.Write([]byte("id task,Valor\nabc,1\n"))
+	mw.Close()
+
+	h := NewUploadHandler(service.NewUploadService(t.TempDir(), 0))
+	notifier := &recordingNotifier{}
+	h.SetProgressNotifier(notifier)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/upload", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+
+	h.UploadFile(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if len(notifier.events) != 0 {
+		t.Errorf("expected no progress events without a user, got %d", len(notifier.events))
+	}
+}
+
+func TestUploadFileRejectsOversizedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "big.csv")
+	part.Write([]byte("id task,Valor\n"))
+	part.Write(bytes.Repeat([]byte("abc,1\n"), 40000)) // ~240KB
+	mw.Close()
+	payload := body.Bytes()
+
+	h := NewUploadHandler(service.NewUploadService(t.TempDir(), 100<<10))
+
+	for _, chunked := range []bool{false, true} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/upload", bytes.NewReader(payload))
+		c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+		if chunked {
+			// Without Content-Length the limit is enforced while reading
+			c.Request.ContentLength = -1
+		}
+
+		h.UploadFile(c)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("chunked=%v: status = %d, body = %s", chunked, w.Code, w.Body.String())
+		}
+		if !bytes.Contains(w.Body.Bytes(), []byte("102400 bytes")) {
+			t.Errorf("chunked=%v: limit missing from response: %s", chunked, w.Body.String())
+		}
+	}
+}
+
+func TestUploadFileTimesOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "slow.csv")
+	part.Write([]byte("id task,Valor\nabc,1\n"))
+	mw.Close()
+
+	tempDir := t.TempDir()
+	uploadService := service.NewUploadService(tempDir, 0)
+	// A slow scanner keeps the upload busy past the deadline
+	uploadService.SetScanFunc(func(path string) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	h := NewUploadHandler(uploadService)
+
+	r := gin.New()
+	r.POST("/api/v1/upload", middleware.Limit(middleware.RequestLimits{Timeout: 10 * time.Millisecond}), h.UploadFile)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("file left after the timeout: %s", entry.Name())
+	}
+}
+
+func TestUploadFilePreviewRows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var csv bytes.Buffer
+	csv.WriteString("id task,Valor\n")
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&csv, "task%03d,%d\n", i, i)
+	}
+	h := NewUploadHandler(service.NewUploadService(t.TempDir(), 0))
+
+	upload := func(query, formValue string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		if formValue != "" {
+			mw.WriteField("preview_rows", formValue)
+		}
+		part, _ := mw.CreateFormFile("file", "linhas.csv")
+		part.Write(csv.Bytes())
+		mw.Close()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/web/upload"+query, &body)
+		c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+		h.UploadFile(c)
+		return w
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		formValue string
+		want      int
+	}{
+		{"default", "", "", service.PreviewRows},
+		{"query", "?preview_rows=12", "", 12},
+		{"camel case query", "?previewRows=20", "", 20},
+		{"form field", "", "3", 3},
+		{"capped", "?preview_rows=1000", "", service.MaxPreviewRows},
+	}
+	for _, tt := range tests {
+		w := upload(tt.query, tt.formValue)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", tt.name, w.Code, w.Body.String())
+		}
+		var resp FileUploadResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.name, err)
+		}
+		if len(resp.Data.Preview) != tt.want || resp.Data.TotalRows != 150 {
+			t.Errorf("%s: %d preview rows of %d, expected %d", tt.name, len(resp.Data.Preview), resp.Data.TotalRows, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?preview_rows=-1", "?preview_rows=abc"} {
+		if w := upload(query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
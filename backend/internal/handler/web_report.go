@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/cleberrangel/clickup-excel-api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -16,8 +19,14 @@ import (
 // WebReportHandler handles report generation for web interface
 type WebReportHandler struct {
 	metadataService *service.MetadataService
+	clientOptions   client.ClientOptions
+	progress        service.ReportProgressNotifier
+	reports         *service.ReportRegistry
 }
 
+// statusClientClosedRequest is returned when a report is canceled before completing
+const statusClientClosedRequest = 499
+
 // NewWebReportHandler creates a new web report handler
 func NewWebReportHandler(metadataService *service.MetadataService) *WebReportHandler {
 	return &WebReportHandler{
@@ -25,6 +34,21 @@ func NewWebReportHandler(metadataService *service.MetadataService) *WebReportHan
 	}
 }
 
+// SetClientOptions sets the timeout, connection pool and concurrency of the per-user ClickUp clients
+func (h *WebReportHandler) SetClientOptions(opts client.ClientOptions) {
+	h.clientOptions = opts
+}
+
+// SetProgressNotifier enables report progress events (lists processed, tasks collected)
+func (h *WebReportHandler) SetProgressNotifier(notifier service.ReportProgressNotifier) {
+	h.progress = notifier
+}
+
+// SetReportRegistry makes running reports cancelable through CancelReport and the WebSocket
+func (h *WebReportHandler) SetReportRegistry(reports *service.ReportRegistry) {
+	h.reports = reports
+}
+
 // GenerateReport generates an Excel report using the user's stored ClickUp token
 // @Summary      Generate Excel report (web)
 // @Description  Generates an Excel report using the user's stored ClickUp token
@@ -44,7 +68,7 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "usuário não autenticado",
 		})
@@ -54,7 +78,7 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 	// Parse request
 	var req model.ReportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "payload inválido",
 			Details: err.Error(),
@@ -64,26 +88,57 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 
 	// Validate request
 	if len(req.ListIDs) == 0 {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "list_ids não pode estar vazio",
 		})
 		return
 	}
 
-	if len(req.Fields) == 0 {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+	if len(req.Fields) == 0 && req.Aggregate == nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "fields não pode estar vazio",
 		})
 		return
 	}
 
+	if req.Aggregate != nil {
+		if err := req.Aggregate.Validate(); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "agregação inválida",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.Filters != nil {
+		if err := req.Filters.Validate(); err != nil {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "filtros inválidos",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
 	// Get user's ClickUp token
-	token, err := h.metadataService.GetUserToken(c.Request.Context(), userID.(string))
+	tokenCtx := service.WithTokenPurpose(c.Request.Context(), service.TokenPurposeReport)
+	token, err := h.metadataService.GetUserToken(tokenCtx, userID.(string), req.TokenLabel)
 	if err != nil {
-		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao obter token do usuário")
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		log.Error().Err(err).Str("user_id", userID.(string)).Str("token_label", req.TokenLabel).Msg("Erro ao obter token do usuário")
+		if errors.Is(err, service.ErrInvalidTokenLabel) {
+			middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "token_label inválido",
+				Details: err.Error(),
+			})
+			return
+		}
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
 			Success: false,
 			Error:   "token ClickUp não configurado",
 			Details: "Configure seu token na aba Configurações",
@@ -97,13 +152,41 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 		Int("fields", len(req.Fields)).
 		Msg("Iniciando geração de relatório web")
 
+	// The fetch stops when the client disconnects or cancels the report
+	ctx := c.Request.Context()
+	reportID := ""
+	if h.reports != nil {
+		var done func()
+		ctx, reportID, done = h.reports.Start(ctx, userID.(string))
+		defer done()
+	}
+
 	// Create ClickUp client with user's token
-	clickupClient := client.NewClient(token)
+	clickupClient := client.NewClientWithOptions(token, h.clientOptions)
 	reportService := service.NewReportService(clickupClient)
+	reportService.SetListLookup(h.metadataService)
+
+	// Options omitted from the request fall back to the user's saved defaults
+	defaults, err := h.metadataService.GetReportDefaults(c.Request.Context(), userID.(string))
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", userID.(string)).Msg("Erro ao obter padrões de relatório, usando padrões do sistema")
+	}
+	reportService.SetUserDefaults(defaults)
+	if h.progress != nil {
+		reportService.SetProgressNotifier(h.progress, userID.(string), reportID)
+	}
 
 	// Generate report
-	result, err := reportService.GenerateReport(c.Request.Context(), req)
+	result, err := reportService.GenerateReport(ctx, req)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Info().Str("user_id", userID.(string)).Str("report_id", reportID).Msg("Relatório cancelado")
+			middleware.ErrorJSON(c, statusClientClosedRequest, model.ErrorResponse{
+				Success: false,
+				Error:   "relatório cancelado",
+			})
+			return
+		}
 		log.Error().Err(err).Str("user_id", userID.(string)).Msg("Erro ao gerar relatório")
 		h.handleError(c, err)
 		return
@@ -113,6 +196,7 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 		Str("user_id", userID.(string)).
 		Int("tasks", result.TotalTasks).
 		Int("lists", result.TotalLists).
+		Strs("failed_lists", result.FailedLists()).
 		Msg("Relatório gerado com sucesso")
 
 	// Configure response headers
@@ -133,45 +217,97 @@ func (h *WebReportHandler) GenerateReport(c *gin.Context) {
 	if stat != nil {
 		c.Header("Content-Length", fmt.Sprintf("%d", stat.Size()))
 	}
-	c.Header("X-Total-Tasks", fmt.Sprintf("%d", result.TotalTasks))
-	c.Header("X-Total-Lists", fmt.Sprintf("%d", result.TotalLists))
+	setReportResultHeaders(c, result)
 
 	if _, err := io.Copy(c.Writer, file); err != nil {
 		log.Error().Err(err).Msg("Erro ao enviar arquivo")
 	}
 }
 
+// CancelReport cancels the user's running reports
+// @Summary      Cancel running report (web)
+// @Description  Cancels a report being generated by the user; without report_id every running report of the user is canceled
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body object false "report_id of the report to cancel (from report_progress messages)"
+// @Success      200 {object} model.Response
+// @Failure      401 {object} model.ErrorResponse
+// @Router       /api/web/reports/cancel [post]
+func (h *WebReportHandler) CancelReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
+			Success: false,
+			Error:   "usuário não autenticado",
+		})
+		return
+	}
+
+	var req struct {
+		ReportID string `json:"report_id"`
+	}
+	// The body is optional
+	c.ShouldBindJSON(&req)
+
+	canceled := 0
+	if h.reports != nil {
+		canceled = h.reports.CancelReports(userID.(string), req.ReportID)
+	}
+
+	logger.FromGin(c).Info().
+		Str("user_id", userID.(string)).
+		Str("report_id", req.ReportID).
+		Int("canceled", canceled).
+		Msg("Cancelamento de relatório solicitado")
+
+	c.JSON(http.StatusOK, model.Response{
+		Success: true,
+		Data:    gin.H{"canceled": canceled},
+	})
+}
+
 // handleError handles errors and returns appropriate response
 func (h *WebReportHandler) handleError(c *gin.Context, err error) {
 	logger.FromGin(c).Error().Err(err).Msg("Erro ao gerar relatório")
 
+	if errors.Is(err, service.ErrUnknownLists) || errors.Is(err, service.ErrEmptyListIDs) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "list_ids inválidos",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	switch err {
 	case model.ErrRateLimited:
-		c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusTooManyRequests, model.ErrorResponse{
 			Success: false,
 			Error:   "rate limit excedido",
 			Details: "aguarde alguns segundos e tente novamente",
 		})
 	case model.ErrUnauthorized:
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, model.ErrorResponse{
 			Success: false,
 			Error:   "token do ClickUp inválido",
 			Details: "verifique seu token na aba Configurações",
 		})
 	case model.ErrNotFound:
-		c.JSON(http.StatusNotFound, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusNotFound, model.ErrorResponse{
 			Success: false,
 			Error:   "lista não encontrada",
 			Details: "verifique os IDs das listas",
 		})
 	case model.ErrTimeout:
-		c.JSON(http.StatusGatewayTimeout, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusGatewayTimeout, model.ErrorResponse{
 			Success: false,
 			Error:   "timeout na requisição",
 			Details: "a API do ClickUp demorou muito para responder",
 		})
 	default:
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		middleware.ErrorJSON(c, http.StatusInternalServerError, model.ErrorResponse{
 			Success: false,
 			Error:   "erro interno",
 			Details: err.Error(),
@@ -2,14 +2,18 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
 	"github.com/gin-gonic/gin"
 )
 
 // WebSocketHandler handles WebSocket-related HTTP requests
 type WebSocketHandler struct {
-	hub *websocket.Hub
+	hub     *websocket.Hub
+	tickets *websocket.TicketStore
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
@@ -19,11 +23,98 @@ func NewWebSocketHandler(hub *websocket.Hub) *WebSocketHandler {
 	}
 }
 
+// SetTicketStore enables connection tickets issued by IssueTicket
+func (h *WebSocketHandler) SetTicketStore(tickets *websocket.TicketStore) {
+	h.tickets = tickets
+}
+
+// IssueTicket issues a single-use, short-lived ticket bound to the current session.
+// The client opens the WebSocket with ?ticket= instead of relying on the cookie.
+func (h *WebSocketHandler) IssueTicket(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if h.tickets == nil || sessionID == "" {
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Usuário não autenticado",
+			"code":    "USER_NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	ticket, expiresAt, err := h.tickets.Issue(sessionID)
+	if err != nil {
+		middleware.ErrorJSON(c, http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Erro ao gerar ticket",
+			"code":    "TICKET_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": map[string]interface{}{
+			"ticket":     ticket,
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
 // HandleConnection handles WebSocket connection upgrades
 func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 	h.hub.ServeWS(c)
 }
 
+// BroadcastAnnouncement sends an announcement to every connected user (admin only)
+func (h *WebSocketHandler) BroadcastAnnouncement(c *gin.Context) {
+	var request struct {
+		Level   string `json:"level"`
+		Title   string `json:"title"`
+		Message string `json:"message" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dados inválidos",
+			"details": err.Error(),
+			"code":    "INVALID_INPUT",
+		})
+		return
+	}
+	if request.Level != "" && !websocket.ValidAnnouncementLevel(request.Level) {
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Nível inválido (use info, warning ou maintenance)",
+			"code":    "INVALID_INPUT",
+		})
+		return
+	}
+
+	delivered := h.hub.Broadcast(websocket.Announcement{
+		Level:   request.Level,
+		Title:   request.Title,
+		Message: request.Message,
+	})
+
+	logger.Audit(c.Request.Context(), logger.AuditEvent{
+		Action:   logger.AuditActionWSBroadcast,
+		UserID:   c.GetString("user_id"),
+		Username: c.GetString("username"),
+		Resource: "websocket",
+		ClientIP: c.ClientIP(),
+		Success:  true,
+		Details:  map[string]interface{}{"delivered": delivered},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": map[string]interface{}{
+			"delivered": delivered,
+		},
+	})
+}
+
 // GetConnectionStats returns WebSocket connection statistics
 func (h *WebSocketHandler) GetConnectionStats(c *gin.Context) {
 	stats := map[string]interface{}{
@@ -41,7 +132,7 @@ func (h *WebSocketHandler) GetConnectionStats(c *gin.Context) {
 func (h *WebSocketHandler) GetUserConnections(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 			"code":    "USER_NOT_AUTHENTICATED",
@@ -65,7 +156,7 @@ func (h *WebSocketHandler) GetUserConnections(c *gin.Context) {
 func (h *WebSocketHandler) SendTestMessage(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 			"code":    "USER_NOT_AUTHENTICATED",
@@ -78,7 +169,7 @@ func (h *WebSocketHandler) SendTestMessage(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		middleware.ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados inválidos",
 			"details": err.Error(),
@@ -112,7 +112,7 @@ func setupTestContext(t *testing.T) *TestContext {
 
 	// Initialize services
 	authService := service.NewAuthService(userRepo)
-	uploadService := service.NewUploadService(tempDir)
+	uploadService := service.NewUploadService(tempDir, 0)
 	mappingService := service.NewMappingService(metadataRepo)
 	queueService := service.NewQueueService(queueRepo, wsHub)
 	metadataService := service.NewMetadataService(metadataRepo, configRepo, "test-encryption-key-32-bytes-long")
@@ -128,6 +128,7 @@ func setupTestContext(t *testing.T) *TestContext {
 	uploadHandler := handler.NewUploadHandler(uploadService)
 	mappingHandler := handler.NewMappingHandler(mappingService, uploadService)
 	queueHandler := handler.NewQueueHandler(queueService, uploadService, mappingService)
+	queueHandler.SetJobTemplates(service.NewJobTemplateService(repository.NewJobTemplateRepository(testDB), mappingService, uploadService))
 	historyHandler := handler.NewHistoryHandler(historyService)
 	metadataHandler := handler.NewMetadataHandler(metadataService, wsHub)
 	wsHandler := handler.NewWebSocketHandler(wsHub)
@@ -165,11 +166,15 @@ func setupTestContext(t *testing.T) *TestContext {
 		web.POST("/jobs", queueHandler.CreateJob)
 		web.GET("/jobs", queueHandler.ListJobs)
 		web.GET("/jobs/:id", queueHandler.GetJob)
+		web.POST("/job-templates", queueHandler.CreateJobTemplate)
+		web.GET("/job-templates/:id", queueHandler.GetJobTemplate)
+		web.PUT("/job-templates/:id", queueHandler.UpdateJobTemplate)
+		web.POST("/job-templates/:id/run", queueHandler.RunJobTemplate)
 		web.GET("/history", historyHandler.ListHistory)
 		web.DELETE("/history", historyHandler.DeleteAllHistory)
 		web.POST("/metadata/sync", metadataHandler.SyncMetadata)
 		web.GET("/metadata/hierarchy", metadataHandler.GetHierarchy)
-		web.GET("/ws", websocket.AuthMiddleware(authService.GetAuthMiddleware()), wsHandler.HandleConnection)
+		web.GET("/ws", websocket.AuthMiddleware(authService.GetAuthMiddleware(), nil), wsHandler.HandleConnection)
 	}
 
 	// Create test user using AuthService (this also adds to middleware)
@@ -0,0 +1,203 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// uploadCSV uploads a CSV file and returns its temp path
+func (tc *TestContext) uploadCSV(t *testing.T, filename string, columns []string, rows [][]string) string {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	io.Copy(part, createTestCSVFile(columns, rows))
+	writer.Close()
+
+	req := tc.makeAuthenticatedRequest("POST", "/api/web/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	tc.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Upload failed: %d - %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			TempPath string `json:"temp_path"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.TempPath == "" {
+		t.Fatalf("Upload response without temp_path: %s", w.Body.String())
+	}
+	return response.Data.TempPath
+}
+
+// postJSON sends an authenticated JSON request
+func (tc *TestContext) postJSON(method, path string, data interface{}) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(data)
+	req := tc.makeAuthenticatedRequest(method, path, bytes.NewReader(jsonData))
+	w := httptest.NewRecorder()
+	tc.Router.ServeHTTP(w, req)
+	return w
+}
+
+// TestJobTemplateWorkflow creates a job template through the API, runs it with a new
+// file and checks that the queued job carries the template's mapping and options
+func TestJobTemplateWorkflow(t *testing.T) {
+	tc := setupTestContext(t)
+	tc.authenticateUser(t, "testuser", "testpassword")
+
+	metadataRepo := repository.NewMetadataRepository(tc.DB)
+	if err := metadataRepo.UpsertCustomField(repository.CustomField{ID: "cf-valor", Name: "Valor", Type: "number", Options: map[string]interface{}{}}); err != nil {
+		t.Fatalf("UpsertCustomField: %v", err)
+	}
+
+	// Step 1: Create the template
+	var templateID int
+	t.Run("Step1_CreateTemplate", func(t *testing.T) {
+		w := tc.postJSON("POST", "/api/web/job-templates", map[string]interface{}{
+			"name": " Mensal ",
+			"mappings": []map[string]interface{}{
+				{"column": "id task", "is_task_id": true},
+				{"column": "valor", "field_id": "cf-valor", "field_name": "Valor", "field_type": "number"},
+			},
+			"normalize_columns": true,
+			"locale":            "pt-BR",
+			"priority":          "high",
+			"token_label":       "cliente-x",
+			"comment_on_update": true,
+			"row_filter": map[string]interface{}{
+				"conditions": []map[string]interface{}{{"column": "valor", "operator": "not_empty"}},
+			},
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Create template failed: %d - %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data repository.JobTemplate `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		templateID = response.Data.ID
+		if templateID == 0 || response.Data.Name != "Mensal" {
+			t.Fatalf("Unexpected template: %+v", response.Data)
+		}
+
+		// The same name can't be used twice
+		w = tc.postJSON("POST", "/api/web/job-templates", map[string]interface{}{
+			"name":     "Mensal",
+			"mappings": []map[string]interface{}{{"column": "id task", "is_task_id": true}, {"column": "valor", "field_id": "cf-valor"}},
+		})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected 409 for a repeated name, got %d - %s", w.Code, w.Body.String())
+		}
+
+		// Invalid options are rejected before saving
+		w = tc.postJSON("POST", "/api/web/job-templates", map[string]interface{}{
+			"name":     "Outro",
+			"mappings": []map[string]interface{}{{"column": "id task", "is_task_id": true}},
+			"locale":   "fr-FR",
+		})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid locale, got %d - %s", w.Code, w.Body.String())
+		}
+	})
+
+	if templateID == 0 {
+		t.Fatal("Template not created")
+	}
+
+	// Step 2: Run it with a new file, whose header uses another case
+	var jobID int
+	t.Run("Step2_RunTemplate", func(t *testing.T) {
+		filePath := tc.uploadCSV(t, "mensal.csv", []string{"ID Task", "Valor"}, [][]string{{"T1", "10"}, {"T2", "20"}})
+
+		w := tc.postJSON("POST", fmt.Sprintf("/api/web/job-templates/%d/run", templateID), map[string]interface{}{
+			"file_path": filePath,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Run template failed: %d - %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data struct {
+				ID    int    `json:"id"`
+				Title string `json:"title"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		jobID = response.Data.ID
+		if jobID == 0 || response.Data.Title != "Mensal" {
+			t.Fatalf("Unexpected job: %s", w.Body.String())
+		}
+
+		job, err := tc.QueueService.GetJobByID(jobID)
+		if err != nil {
+			t.Fatalf("GetJobByID: %v", err)
+		}
+		if job.FilePath != filePath || job.Mapping["valor"] != "cf-valor" || job.TotalRows != 2 {
+			t.Errorf("Unexpected job: %+v", job)
+		}
+		if job.Priority != repository.JobPriorityHigh {
+			t.Errorf("Expected high priority, got %v", job.Priority)
+		}
+		options := job.Options
+		if options.Locale != "pt-BR" || options.TokenLabel != "cliente-x" || !options.NormalizeColumns ||
+			!options.CommentOnUpdate || options.RowFilter == nil || len(options.RowFilter.Conditions) != 1 {
+			t.Errorf("Template options not carried to the job: %+v", options)
+		}
+	})
+
+	// Step 3: A file without the mapped column doesn't fit the template
+	t.Run("Step3_RunWithIncompatibleFile", func(t *testing.T) {
+		filePath := tc.uploadCSV(t, "outro.csv", []string{"id task", "Outro"}, [][]string{{"T1", "x"}})
+
+		w := tc.postJSON("POST", fmt.Sprintf("/api/web/job-templates/%d/run", templateID), map[string]interface{}{
+			"file_path": filePath,
+		})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an incompatible file, got %d - %s", w.Code, w.Body.String())
+		}
+	})
+
+	// Step 4: Update the template and read it back
+	t.Run("Step4_UpdateTemplate", func(t *testing.T) {
+		w := tc.postJSON("PUT", fmt.Sprintf("/api/web/job-templates/%d", templateID), map[string]interface{}{
+			"name": "Mensal",
+			"mappings": []map[string]interface{}{
+				{"column": "id task", "is_task_id": true},
+				{"column": "valor", "field_id": "cf-valor", "field_name": "Valor", "field_type": "number"},
+			},
+			"token_label": "cliente-y",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Update template failed: %d - %s", w.Code, w.Body.String())
+		}
+
+		req := tc.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/web/job-templates/%d", templateID), nil)
+		w = httptest.NewRecorder()
+		tc.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Get template failed: %d - %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data repository.JobTemplate `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Data.Options.TokenLabel != "cliente-y" || response.Data.Options.NormalizeColumns {
+			t.Errorf("Template not replaced: %+v", response.Data.Options)
+		}
+	})
+}
@@ -35,11 +35,16 @@ const (
 	// Configuration operations
 	AuditActionConfigUpdate AuditAction = "CONFIG_UPDATE"
 	AuditActionTokenUpdate  AuditAction = "TOKEN_UPDATE"
+	AuditActionTokenDecrypt AuditAction = "TOKEN_DECRYPT"
 	AuditActionMetadataSync AuditAction = "METADATA_SYNC"
 
 	// History operations
 	AuditActionHistoryClear AuditAction = "HISTORY_CLEAR"
 
+	// Backup operations
+	AuditActionBackupExport AuditAction = "BACKUP_EXPORT"
+	AuditActionBackupImport AuditAction = "BACKUP_IMPORT"
+
 	// Mapping operations
 	AuditActionMappingCreate   AuditAction = "MAPPING_CREATE"
 	AuditActionMappingDelete   AuditAction = "MAPPING_DELETE"
@@ -56,6 +61,10 @@ const (
 	// WebSocket operations
 	AuditActionWSConnect    AuditAction = "WS_CONNECT"
 	AuditActionWSDisconnect AuditAction = "WS_DISCONNECT"
+	AuditActionWSBroadcast  AuditAction = "WS_BROADCAST"
+
+	// Maintenance mode
+	AuditActionMaintenance AuditAction = "MAINTENANCE_TOGGLE"
 
 	// API operations
 	AuditActionAPIRequest AuditAction = "API_REQUEST"
@@ -0,0 +1,34 @@
+package logger
+
+import "regexp"
+
+// Redacted substitui os valores sensíveis mascarados por Redact
+const Redacted = "[REDACTED]"
+
+// sensitiveKey casa apenas os nomes exatos dos campos que guardam segredos;
+// campos como token_label ou has_token são mantidos
+const sensitiveKey = `(?i:token|api_token|access_token|refresh_token|clickup_token|password|current_password|new_password|authorization|csrf_token|x-csrf-token)`
+
+var redactPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// JSON: "token": "valor"
+	{regexp.MustCompile(`("` + sensitiveKey + `"\s*:\s*)"(?:[^"\\]|\\.)*"`), `${1}"` + Redacted + `"`},
+	// JSON dentro de uma string JSON (ex.: corpo de requisição no log): \"token\":\"valor\"
+	{regexp.MustCompile(`(\\"` + sensitiveKey + `\\"\s*:\s*)\\"(?:\\\\\\"|\\\\|[^"\\])*\\"`), `${1}\"` + Redacted + `\"`},
+	// Query string e formulários: token=valor
+	{regexp.MustCompile(`((?:^|[?&\s])` + sensitiveKey + `=)[^&\s"\\]+`), `${1}` + Redacted},
+	// Headers: Authorization: Bearer valor
+	{regexp.MustCompile(`(?i)(\b(?:authorization|x-csrf-token)\s*:\s*)(?:(?:bearer|basic)\s+)?[^\s",}\\]+`), `${1}` + Redacted},
+}
+
+// Redact mascara os valores de campos sensíveis (token, password, authorization,
+// csrf) em JSON, query strings e headers presentes em s. Não é aplicado a toda
+// linha de log: quem grava corpos ou URLs de terceiros chama Redact no campo
+func Redact(s string) string {
+	for _, p := range redactPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
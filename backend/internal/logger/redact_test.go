@@ -0,0 +1,53 @@
+package logger
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{
+			"json",
+			`{"username":"ana","password":"s3cr3t","api_token": "pk_123"}`,
+			`{"username":"ana","password":"[REDACTED]","api_token": "[REDACTED]"}`,
+		},
+		{
+			"escaped json",
+			`{"body":"{\"csrf_token\":\"abc\\\"def\",\"value\":\"ok\"}"}`,
+			`{"body":"{\"csrf_token\":\"[REDACTED]\",\"value\":\"ok\"}"}`,
+		},
+		{
+			"query string",
+			`GET /callback?code=1&access_token=pk_123&page=2`,
+			`GET /callback?code=1&access_token=[REDACTED]&page=2`,
+		},
+		{
+			"headers",
+			`Authorization: Bearer pk_123, X-CSRF-Token: abc`,
+			`Authorization: [REDACTED], X-CSRF-Token: [REDACTED]`,
+		},
+		{
+			"case insensitive keys",
+			`{"Password":"x","NEW_PASSWORD":"y"}`,
+			`{"Password":"[REDACTED]","NEW_PASSWORD":"[REDACTED]"}`,
+		},
+		{
+			"no secrets",
+			`validar token: status 401: {"err":"Token invalid"}`,
+			`validar token: status 401: {"err":"Token invalid"}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.input); got != tt.want {
+			t.Errorf("%s: Redact() = %s, esperado %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedactOnlyKnownFields(t *testing.T) {
+	input := `{"token_label":"Produção","has_token":"sim","password_changed_at":"2024-01-01","tokens_used":"12","token":"pk_123"}`
+	want := `{"token_label":"Produção","has_token":"sim","password_changed_at":"2024-01-01","tokens_used":"12","token":"[REDACTED]"}`
+	if got := Redact(input); got != want {
+		t.Errorf("Redact() = %s, esperado %s", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultSampleEvery registra 1 de cada N linhas de progresso por padrão
+const DefaultSampleEvery = 10
+
+var sampleEvery int32 = DefaultSampleEvery
+
+// SetSampleEvery define de quantas em quantas linhas de progresso uma é registrada
+// (<= 1 registra todas)
+func SetSampleEvery(n int) {
+	if n < 1 {
+		n = 1
+	}
+	atomic.StoreInt32(&sampleEvery, int32(n))
+}
+
+// Sampled retorna um logger para linhas de progresso repetitivas (por página, por
+// linha do arquivo): registra o primeiro evento Debug/Info e depois 1 de cada N
+// (SetSampleEvery). Avisos e erros passam sempre. O contador é do logger retornado,
+// então crie um por laço (coleta, job) e registre o resumo final no logger original.
+func Sampled(l *zerolog.Logger) *zerolog.Logger {
+	n := atomic.LoadInt32(&sampleEvery)
+	if n <= 1 {
+		return l
+	}
+	sampled := l.Sample(zerolog.LevelSampler{
+		DebugSampler: &zerolog.BasicSampler{N: uint32(n)},
+		InfoSampler:  &zerolog.BasicSampler{N: uint32(n)},
+	})
+	return &sampled
+}
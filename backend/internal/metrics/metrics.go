@@ -42,6 +42,8 @@ type Metrics struct {
 	// File upload metrics
 	FilesUploaded      int64
 	TotalBytesUploaded int64
+	TempFilesReclaimed int64
+	TempBytesReclaimed int64
 
 	// WebSocket metrics
 	WSConnections int64
@@ -65,6 +67,35 @@ type Metrics struct {
 	MappingsCreated   int64
 	MappingsValidated int64
 
+	// ClickUp token decryptions (audited access to stored tokens)
+	TokenDecryptions   int64
+	TokenDecryptErrors int64
+
+	// ClickUp rate limit, as reported by the last API response (X-RateLimit-*)
+	ClickUpRateLimit          int64
+	ClickUpRateLimitRemaining int64
+	ClickUpRateLimitReset     int64 // unix seconds
+	ClickUpRateLimitObserved  int64 // unix seconds of the last response with the headers
+
+	// Retention: rows removed by the periodic pruning
+	HistoryRowsPruned int64
+	FailedJobsPruned  int64
+
+	// Job queue depth, as of the last health check or Prometheus scrape
+	QueuePending              int64
+	QueueScheduled            int64
+	QueueOldestPendingSeconds int64
+
+	// Database connection pool, as of the last sample
+	DBPoolMaxOpen        int64
+	DBPoolOpen           int64
+	DBPoolInUse          int64
+	DBPoolIdle           int64
+	DBPoolWaitCount      int64
+	DBPoolWaitDurationMs int64
+	DBPoolRecentWaits    int64 // waits since the previous sample
+	DBPoolSampled        int64 // unix seconds of the last sample
+
 	// Endpoint-specific metrics
 	EndpointMetrics map[string]*EndpointMetrics
 
@@ -110,18 +141,25 @@ func (m *Metrics) IncrementRequests(success bool, latencyMs int64) {
 // IncrementJobCreated increments job created counter
 func (m *Metrics) IncrementJobCreated() {
 	atomic.AddInt64(&m.JobsCreated, 1)
-	atomic.AddInt64(&m.JobsProcessing, 1)
 }
 
 // IncrementJobCompleted increments job completed counter
 func (m *Metrics) IncrementJobCompleted() {
 	atomic.AddInt64(&m.JobsCompleted, 1)
-	atomic.AddInt64(&m.JobsProcessing, -1)
 }
 
 // IncrementJobFailed increments job failed counter
 func (m *Metrics) IncrementJobFailed() {
 	atomic.AddInt64(&m.JobsFailed, 1)
+}
+
+// JobProcessingStarted marks a job as running on a queue worker
+func (m *Metrics) JobProcessingStarted() {
+	atomic.AddInt64(&m.JobsProcessing, 1)
+}
+
+// JobProcessingFinished marks a queue worker as done with its job
+func (m *Metrics) JobProcessingFinished() {
 	atomic.AddInt64(&m.JobsProcessing, -1)
 }
 
@@ -131,6 +169,75 @@ func (m *Metrics) IncrementFileUpload(bytes int64) {
 	atomic.AddInt64(&m.TotalBytesUploaded, bytes)
 }
 
+// IncrementTempFilesReclaimed records expired upload temp files removed by the sweeper
+func (m *Metrics) IncrementTempFilesReclaimed(files int, bytes int64) {
+	atomic.AddInt64(&m.TempFilesReclaimed, int64(files))
+	atomic.AddInt64(&m.TempBytesReclaimed, bytes)
+}
+
+// IncrementRetentionPruned records operation history rows and failed jobs removed
+// by the retention scheduler
+func (m *Metrics) IncrementRetentionPruned(historyRows, failedJobs int64) {
+	atomic.AddInt64(&m.HistoryRowsPruned, historyRows)
+	atomic.AddInt64(&m.FailedJobsPruned, failedJobs)
+}
+
+// RecordClickUpRateLimit stores the rate-limit headers of a ClickUp response.
+// Negative values mean the header was absent and keep the previous value.
+func (m *Metrics) RecordClickUpRateLimit(limit, remaining, resetUnix int64) {
+	if limit >= 0 {
+		atomic.StoreInt64(&m.ClickUpRateLimit, limit)
+	}
+	if remaining >= 0 {
+		atomic.StoreInt64(&m.ClickUpRateLimitRemaining, remaining)
+	}
+	if resetUnix >= 0 {
+		atomic.StoreInt64(&m.ClickUpRateLimitReset, resetUnix)
+	}
+	atomic.StoreInt64(&m.ClickUpRateLimitObserved, time.Now().Unix())
+}
+
+// RecordQueueStats stores the current job queue depth and the wait of the oldest ready job
+func (m *Metrics) RecordQueueStats(pending, scheduled int, oldestPending time.Duration) {
+	atomic.StoreInt64(&m.QueuePending, int64(pending))
+	atomic.StoreInt64(&m.QueueScheduled, int64(scheduled))
+	atomic.StoreInt64(&m.QueueOldestPendingSeconds, int64(oldestPending/time.Second))
+}
+
+// RecordDBPoolStats stores a sample of the database connection pool statistics
+func (m *Metrics) RecordDBPoolStats(stats sql.DBStats) {
+	previous := atomic.SwapInt64(&m.DBPoolWaitCount, stats.WaitCount)
+	recent := stats.WaitCount - previous
+	if atomic.LoadInt64(&m.DBPoolSampled) == 0 || recent < 0 {
+		recent = 0
+	}
+	atomic.StoreInt64(&m.DBPoolRecentWaits, recent)
+
+	atomic.StoreInt64(&m.DBPoolMaxOpen, int64(stats.MaxOpenConnections))
+	atomic.StoreInt64(&m.DBPoolOpen, int64(stats.OpenConnections))
+	atomic.StoreInt64(&m.DBPoolInUse, int64(stats.InUse))
+	atomic.StoreInt64(&m.DBPoolIdle, int64(stats.Idle))
+	atomic.StoreInt64(&m.DBPoolWaitDurationMs, stats.WaitDuration.Milliseconds())
+	atomic.StoreInt64(&m.DBPoolSampled, time.Now().Unix())
+}
+
+// DBPoolSampleInterval is how often StartDBPoolSampler reads the pool statistics
+const DBPoolSampleInterval = 15 * time.Second
+
+// StartDBPoolSampler records the pool statistics of db now and every DBPoolSampleInterval
+func (m *Metrics) StartDBPoolSampler(db *sql.DB) {
+	m.RecordDBPoolStats(db.Stats())
+
+	go func() {
+		ticker := time.NewTicker(DBPoolSampleInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			m.RecordDBPoolStats(db.Stats())
+		}
+	}()
+}
+
 // IncrementWSConnection increments WebSocket connection counter
 func (m *Metrics) IncrementWSConnection() {
 	atomic.AddInt64(&m.WSConnections, 1)
@@ -198,6 +305,14 @@ func (m *Metrics) IncrementMappingValidated() {
 	atomic.AddInt64(&m.MappingsValidated, 1)
 }
 
+// IncrementTokenDecrypt increments token decryption counters
+func (m *Metrics) IncrementTokenDecrypt(success bool) {
+	atomic.AddInt64(&m.TokenDecryptions, 1)
+	if !success {
+		atomic.AddInt64(&m.TokenDecryptErrors, 1)
+	}
+}
+
 // TrackEndpoint tracks metrics for a specific endpoint
 func (m *Metrics) TrackEndpoint(path, method string, statusCode int, latencyMs int64) {
 	key := method + " " + path
@@ -293,8 +408,10 @@ type MetricsSnapshot struct {
 
 	// File metrics
 	Files struct {
-		Uploaded   int64 `json:"uploaded"`
-		TotalBytes int64 `json:"total_bytes"`
+		Uploaded       int64 `json:"uploaded"`
+		TotalBytes     int64 `json:"total_bytes"`
+		ReclaimedFiles int64 `json:"reclaimed_files"`
+		ReclaimedBytes int64 `json:"reclaimed_bytes"`
 	} `json:"files"`
 
 	// WebSocket metrics
@@ -329,6 +446,45 @@ type MetricsSnapshot struct {
 		Validated int64 `json:"validated"`
 	} `json:"mappings"`
 
+	// Token decryption metrics
+	Tokens struct {
+		Decryptions int64 `json:"decryptions"`
+		Errors      int64 `json:"errors"`
+	} `json:"tokens"`
+
+	// ClickUp rate limit (last response seen; empty until the first call)
+	ClickUpRateLimit struct {
+		Limit      int64  `json:"limit"`
+		Remaining  int64  `json:"remaining"`
+		ResetAt    string `json:"reset_at,omitempty"`
+		ObservedAt string `json:"observed_at,omitempty"`
+	} `json:"clickup_rate_limit"`
+
+	// Rows removed by the retention scheduler
+	Retention struct {
+		HistoryRowsPruned int64 `json:"history_rows_pruned"`
+		FailedJobsPruned  int64 `json:"failed_jobs_pruned"`
+	} `json:"retention"`
+
+	// Job queue depth (last health check or Prometheus scrape)
+	Queue struct {
+		Pending              int64 `json:"pending"`
+		Scheduled            int64 `json:"scheduled"`
+		OldestPendingSeconds int64 `json:"oldest_pending_seconds"`
+	} `json:"queue"`
+
+	// Database connection pool (last sample; waits are cumulative)
+	DatabasePool struct {
+		MaxOpen        int64  `json:"max_open"`
+		Open           int64  `json:"open"`
+		InUse          int64  `json:"in_use"`
+		Idle           int64  `json:"idle"`
+		WaitCount      int64  `json:"wait_count"`
+		WaitDurationMs int64  `json:"wait_duration_ms"`
+		RecentWaits    int64  `json:"recent_waits"`
+		SampledAt      string `json:"sampled_at,omitempty"`
+	} `json:"database_pool"`
+
 	// System metrics
 	System struct {
 		Goroutines   int    `json:"goroutines"`
@@ -377,6 +533,8 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 	// File metrics
 	snapshot.Files.Uploaded = atomic.LoadInt64(&m.FilesUploaded)
 	snapshot.Files.TotalBytes = atomic.LoadInt64(&m.TotalBytesUploaded)
+	snapshot.Files.ReclaimedFiles = atomic.LoadInt64(&m.TempFilesReclaimed)
+	snapshot.Files.ReclaimedBytes = atomic.LoadInt64(&m.TempBytesReclaimed)
 
 	// WebSocket metrics
 	snapshot.WebSocket.Connections = atomic.LoadInt64(&m.WSConnections)
@@ -400,6 +558,41 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 	snapshot.Mappings.Created = atomic.LoadInt64(&m.MappingsCreated)
 	snapshot.Mappings.Validated = atomic.LoadInt64(&m.MappingsValidated)
 
+	// Token decryption metrics
+	snapshot.Tokens.Decryptions = atomic.LoadInt64(&m.TokenDecryptions)
+	snapshot.Tokens.Errors = atomic.LoadInt64(&m.TokenDecryptErrors)
+
+	// ClickUp rate limit
+	snapshot.ClickUpRateLimit.Limit = atomic.LoadInt64(&m.ClickUpRateLimit)
+	snapshot.ClickUpRateLimit.Remaining = atomic.LoadInt64(&m.ClickUpRateLimitRemaining)
+	if reset := atomic.LoadInt64(&m.ClickUpRateLimitReset); reset > 0 {
+		snapshot.ClickUpRateLimit.ResetAt = time.Unix(reset, 0).UTC().Format(time.RFC3339)
+	}
+	if observed := atomic.LoadInt64(&m.ClickUpRateLimitObserved); observed > 0 {
+		snapshot.ClickUpRateLimit.ObservedAt = time.Unix(observed, 0).UTC().Format(time.RFC3339)
+	}
+
+	// Retention
+	snapshot.Retention.HistoryRowsPruned = atomic.LoadInt64(&m.HistoryRowsPruned)
+	snapshot.Retention.FailedJobsPruned = atomic.LoadInt64(&m.FailedJobsPruned)
+
+	// Queue depth
+	snapshot.Queue.Pending = atomic.LoadInt64(&m.QueuePending)
+	snapshot.Queue.Scheduled = atomic.LoadInt64(&m.QueueScheduled)
+	snapshot.Queue.OldestPendingSeconds = atomic.LoadInt64(&m.QueueOldestPendingSeconds)
+
+	// Database pool
+	snapshot.DatabasePool.MaxOpen = atomic.LoadInt64(&m.DBPoolMaxOpen)
+	snapshot.DatabasePool.Open = atomic.LoadInt64(&m.DBPoolOpen)
+	snapshot.DatabasePool.InUse = atomic.LoadInt64(&m.DBPoolInUse)
+	snapshot.DatabasePool.Idle = atomic.LoadInt64(&m.DBPoolIdle)
+	snapshot.DatabasePool.WaitCount = atomic.LoadInt64(&m.DBPoolWaitCount)
+	snapshot.DatabasePool.WaitDurationMs = atomic.LoadInt64(&m.DBPoolWaitDurationMs)
+	snapshot.DatabasePool.RecentWaits = atomic.LoadInt64(&m.DBPoolRecentWaits)
+	if sampled := atomic.LoadInt64(&m.DBPoolSampled); sampled > 0 {
+		snapshot.DatabasePool.SampledAt = time.Unix(sampled, 0).UTC().Format(time.RFC3339)
+	}
+
 	// System metrics
 	snapshot.System.Goroutines = runtime.NumGoroutine()
 	snapshot.System.HeapAllocMB = memStats.HeapAlloc / 1024 / 1024
@@ -432,6 +625,8 @@ type HealthStatus struct {
 	Status  string `json:"status"` // "healthy", "degraded", "unhealthy"
 	Message string `json:"message,omitempty"`
 	Latency int64  `json:"latency_ms,omitempty"`
+	// Details holds component-specific numbers (e.g. queue depth)
+	Details map[string]interface{} `json:"details,omitempty"`
 }
 
 // HealthCheck represents the overall health check response
@@ -441,6 +636,15 @@ type HealthCheck struct {
 	Uptime     string                  `json:"uptime"`
 	Timestamp  string                  `json:"timestamp"`
 	Components map[string]HealthStatus `json:"components"`
+	Build      *BuildInfo              `json:"build,omitempty"`
+}
+
+// BuildInfo identifies the running build (commit and build time are injected via ldflags)
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
 }
 
 // CheckDatabaseHealth checks database connectivity
@@ -480,6 +684,24 @@ func CheckDatabaseHealth(db *sql.DB) HealthStatus {
 	}
 }
 
+// CheckDBPoolHealth reports the pool as degraded when more than maxRecentWaits
+// queries had to wait for a free connection since the previous sample
+func (m *Metrics) CheckDBPoolHealth(maxRecentWaits int64) HealthStatus {
+	recent := atomic.LoadInt64(&m.DBPoolRecentWaits)
+	if recent > maxRecentWaits {
+		return HealthStatus{
+			Status:  "degraded",
+			Message: "connection pool saturated",
+			Details: map[string]interface{}{
+				"recent_waits": recent,
+				"in_use":       atomic.LoadInt64(&m.DBPoolInUse),
+				"max_open":     atomic.LoadInt64(&m.DBPoolMaxOpen),
+			},
+		}
+	}
+	return HealthStatus{Status: "healthy"}
+}
+
 // CheckMemoryHealth checks memory usage
 func CheckMemoryHealth(maxHeapMB uint64) HealthStatus {
 	var memStats runtime.MemStats
@@ -507,15 +729,25 @@ func CheckMemoryHealth(maxHeapMB uint64) HealthStatus {
 	}
 }
 
+// ExternalComponents lists upstream dependencies whose outage degrades the service
+// instead of making it unhealthy: the API itself keeps serving requests
+var ExternalComponents = map[string]bool{
+	"clickup": true,
+}
+
 // DetermineOverallStatus determines overall health from component statuses
 func DetermineOverallStatus(components map[string]HealthStatus) string {
 	hasUnhealthy := false
 	hasDegraded := false
 
-	for _, status := range components {
+	for name, status := range components {
 		switch status.Status {
 		case "unhealthy":
-			hasUnhealthy = true
+			if ExternalComponents[name] {
+				hasDegraded = true
+			} else {
+				hasUnhealthy = true
+			}
 		case "degraded":
 			hasDegraded = true
 		}
@@ -529,3 +761,33 @@ func DetermineOverallStatus(components map[string]HealthStatus) string {
 	}
 	return "healthy"
 }
+
+// CachedHealthCheck reuses the last result of an expensive check for a TTL.
+// Concurrent callers wait for a single in-flight check instead of starting their own.
+type CachedHealthCheck struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	check     func() HealthStatus
+	last      HealthStatus
+	checkedAt time.Time
+}
+
+// NewCachedHealthCheck creates a cached wrapper around check
+func NewCachedHealthCheck(ttl time.Duration, check func() HealthStatus) *CachedHealthCheck {
+	return &CachedHealthCheck{
+		ttl:   ttl,
+		check: check,
+	}
+}
+
+// Status returns the cached status, running the check when it is missing or expired
+func (c *CachedHealthCheck) Status() HealthStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checkedAt.IsZero() || time.Since(c.checkedAt) > c.ttl {
+		c.last = c.check()
+		c.checkedAt = time.Now()
+	}
+	return c.last
+}
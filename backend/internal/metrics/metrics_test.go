@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordDBPoolStats(t *testing.T) {
+	m := &Metrics{StartTime: time.Now()}
+
+	m.RecordDBPoolStats(sql.DBStats{
+		MaxOpenConnections: 25,
+		OpenConnections:    12,
+		InUse:              9,
+		Idle:               3,
+		WaitCount:          40,
+		WaitDuration:       1500 * time.Millisecond,
+	})
+
+	pool := m.Snapshot().DatabasePool
+	if pool.MaxOpen != 25 || pool.Open != 12 || pool.InUse != 9 || pool.Idle != 3 {
+		t.Errorf("conexões = %+v", pool)
+	}
+	if pool.WaitCount != 40 || pool.WaitDurationMs != 1500 {
+		t.Errorf("esperas = %d (%dms)", pool.WaitCount, pool.WaitDurationMs)
+	}
+	// A primeira amostra não tem base de comparação
+	if pool.RecentWaits != 0 || pool.SampledAt == "" {
+		t.Errorf("primeira amostra: recent_waits=%d sampled_at=%q", pool.RecentWaits, pool.SampledAt)
+	}
+	if status := m.CheckDBPoolHealth(10); status.Status != "healthy" {
+		t.Errorf("primeira amostra: %+v", status)
+	}
+
+	m.RecordDBPoolStats(sql.DBStats{MaxOpenConnections: 25, OpenConnections: 25, InUse: 25, WaitCount: 75})
+	if recent := m.Snapshot().DatabasePool.RecentWaits; recent != 35 {
+		t.Errorf("recent_waits = %d, esperado 35", recent)
+	}
+	if status := m.CheckDBPoolHealth(10); status.Status != "degraded" || status.Details["recent_waits"] != int64(35) {
+		t.Errorf("pool saturado: %+v", status)
+	}
+
+	m.RecordDBPoolStats(sql.DBStats{MaxOpenConnections: 25, OpenConnections: 5, InUse: 1, Idle: 4, WaitCount: 76})
+	if status := m.CheckDBPoolHealth(10); status.Status != "healthy" {
+		t.Errorf("pool recuperado: %+v", status)
+	}
+
+	var buf bytes.Buffer
+	if err := m.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	for _, line := range []string{"app_db_pool_in_use_connections 1\n", "app_db_pool_wait_total 76\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("saída Prometheus sem %q", line)
+		}
+	}
+}
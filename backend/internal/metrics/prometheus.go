@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// promMetric is a single series in the Prometheus text format
+type promMetric struct {
+	name  string
+	help  string
+	kind  string // counter or gauge
+	value float64
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+
+	series := []promMetric{
+		{"app_uptime_seconds", "Seconds since the process started", "gauge", s.UptimeSeconds},
+		{"app_requests_total", "HTTP requests handled", "counter", float64(s.Requests.Total)},
+		{"app_requests_failed_total", "HTTP requests that returned an error", "counter", float64(s.Requests.Failed)},
+		{"app_jobs_created_total", "Update jobs created", "counter", float64(s.Jobs.Created)},
+		{"app_jobs_completed_total", "Update jobs completed", "counter", float64(s.Jobs.Completed)},
+		{"app_jobs_failed_total", "Update jobs failed", "counter", float64(s.Jobs.Failed)},
+		{"app_history_rows_pruned_total", "Operation history rows removed by retention", "counter", float64(s.Retention.HistoryRowsPruned)},
+		{"app_failed_jobs_pruned_total", "Failed jobs removed by retention", "counter", float64(s.Retention.FailedJobsPruned)},
+		{"app_jobs_processing", "Update jobs being processed", "gauge", float64(s.Jobs.Processing)},
+		{"app_queue_pending_jobs", "Jobs waiting in the queue and ready to run", "gauge", float64(s.Queue.Pending)},
+		{"app_queue_scheduled_jobs", "Jobs waiting for their scheduled time", "gauge", float64(s.Queue.Scheduled)},
+		{"app_queue_oldest_pending_age_seconds", "Wait of the oldest job ready to run", "gauge", float64(s.Queue.OldestPendingSeconds)},
+		{"app_db_pool_open_connections", "Open database connections", "gauge", float64(s.DatabasePool.Open)},
+		{"app_db_pool_in_use_connections", "Database connections in use", "gauge", float64(s.DatabasePool.InUse)},
+		{"app_db_pool_idle_connections", "Idle database connections", "gauge", float64(s.DatabasePool.Idle)},
+		{"app_db_pool_max_open_connections", "Maximum open database connections", "gauge", float64(s.DatabasePool.MaxOpen)},
+		{"app_db_pool_wait_total", "Queries that waited for a free database connection", "counter", float64(s.DatabasePool.WaitCount)},
+		{"app_db_pool_wait_seconds_total", "Time spent waiting for a free database connection", "counter", float64(s.DatabasePool.WaitDurationMs) / 1000},
+		{"app_task_updates_total", "ClickUp tasks updated", "counter", float64(s.TaskUpdates.Updated)},
+		{"app_task_update_errors_total", "ClickUp task updates that failed", "counter", float64(s.TaskUpdates.Errors)},
+		{"app_files_uploaded_total", "Files uploaded", "counter", float64(s.Files.Uploaded)},
+		{"app_files_uploaded_bytes_total", "Bytes uploaded", "counter", float64(s.Files.TotalBytes)},
+		{"app_temp_files_reclaimed_total", "Expired temp files removed", "counter", float64(s.Files.ReclaimedFiles)},
+		{"app_websocket_connections", "Open WebSocket connections", "gauge", float64(s.WebSocket.Connections)},
+		{"app_reports_generated_total", "Reports generated", "counter", float64(s.Reports.Generated)},
+		{"app_report_errors_total", "Reports that failed", "counter", float64(s.Reports.Errors)},
+		{"app_token_decryptions_total", "Stored ClickUp tokens decrypted", "counter", float64(s.Tokens.Decryptions)},
+		{"app_token_decrypt_errors_total", "Stored ClickUp tokens that failed to decrypt", "counter", float64(s.Tokens.Errors)},
+		{"app_goroutines", "Goroutines running", "gauge", float64(s.System.Goroutines)},
+		{"app_heap_alloc_megabytes", "Heap allocated in MB", "gauge", float64(s.System.HeapAllocMB)},
+		{"clickup_rate_limit", "Requests per window allowed by ClickUp (last response)", "gauge", float64(s.ClickUpRateLimit.Limit)},
+		{"clickup_rate_limit_remaining", "Requests left in the current ClickUp window (last response)", "gauge", float64(s.ClickUpRateLimit.Remaining)},
+		{"clickup_rate_limit_reset_timestamp_seconds", "Unix time when the ClickUp window resets", "gauge", float64(atomic.LoadInt64(&m.ClickUpRateLimitReset))},
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, p := range series {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+			p.name, p.help, p.name, p.kind, p.name, strconv.FormatFloat(p.value, 'g', -1, 64))
+	}
+
+	// Per-endpoint request counters
+	if len(s.Endpoints) > 0 {
+		keys := make([]string, 0, len(s.Endpoints))
+		for k := range s.Endpoints {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprint(bw, "# HELP app_endpoint_requests_total HTTP requests per endpoint\n# TYPE app_endpoint_requests_total counter\n")
+		for _, k := range keys {
+			fmt.Fprintf(bw, "app_endpoint_requests_total{endpoint=%q} %d\n", k, s.Endpoints[k].Requests)
+		}
+	}
+
+	return bw.Flush()
+}
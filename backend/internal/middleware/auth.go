@@ -18,7 +18,7 @@ func BearerAuth(cfg AuthConfig) gin.HandlerFunc {
 		authHeader := c.GetHeader("Authorization")
 
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"error": "header Authorization ausente",
 			})
 			return
@@ -27,7 +27,7 @@ func BearerAuth(cfg AuthConfig) gin.HandlerFunc {
 		// Extrai o token do formato "Bearer {token}"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"error": "formato inválido, esperado: Bearer {token}",
 			})
 			return
@@ -36,12 +36,15 @@ func BearerAuth(cfg AuthConfig) gin.HandlerFunc {
 		token := parts[1]
 
 		if token != cfg.TokenAPI {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"error": "token inválido",
 			})
 			return
 		}
 
+		// O TOKEN_API é a credencial da implantação, com acesso de administrador
+		c.Set("role", RoleAdmin)
+
 		c.Next()
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +15,7 @@ import (
 type Session struct {
 	UserID    string    `json:"user_id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -26,27 +28,57 @@ type BasicAuthConfig struct {
 	CookieDomain    string           // cookie domain
 	CookieSecure    bool             // secure cookie flag
 	CookieHTTPOnly  bool             // httponly cookie flag
+	CookiePath      string           // cookie path (default "/")
+	CookieSameSite  http.SameSite    // SameSite mode (default Lax)
 }
 
 // BasicAuthMiddleware handles basic authentication with sessions
 type BasicAuthMiddleware struct {
 	config   BasicAuthConfig
 	sessions map[string]*Session // sessionID -> Session
+	roles    map[string]string   // username -> role (RoleUser when absent)
+}
+
+// CanonicalUsername returns the form usernames are stored and compared in: trimmed and
+// lower-case, so "Admin" and "admin" are the same user
+func CanonicalUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
 }
 
 // AddUser adds a user to the middleware's user map
 func (m *BasicAuthMiddleware) AddUser(username, passwordHash string) {
-	m.config.Users[username] = passwordHash
+	m.config.Users[CanonicalUsername(username)] = passwordHash
+}
+
+// HasUser reports whether a user with the same canonical username is loaded
+func (m *BasicAuthMiddleware) HasUser(username string) bool {
+	_, exists := m.config.Users[CanonicalUsername(username)]
+	return exists
+}
+
+// SetUserRole sets the role given to the user's next sessions
+func (m *BasicAuthMiddleware) SetUserRole(username, role string) {
+	m.roles[CanonicalUsername(username)] = role
+}
+
+// UserRole returns the user's role (RoleUser if none was set)
+func (m *BasicAuthMiddleware) UserRole(username string) string {
+	if role, ok := m.roles[CanonicalUsername(username)]; ok {
+		return role
+	}
+	return RoleUser
 }
 
 // RemoveUser removes a user from the middleware's user map
 func (m *BasicAuthMiddleware) RemoveUser(username string) {
-	delete(m.config.Users, username)
+	delete(m.config.Users, CanonicalUsername(username))
+	delete(m.roles, CanonicalUsername(username))
 }
 
 // ClearUsers clears all users from the middleware
 func (m *BasicAuthMiddleware) ClearUsers() {
 	m.config.Users = make(map[string]string)
+	m.roles = make(map[string]string)
 }
 
 // NewBasicAuthMiddleware creates a new basic auth middleware
@@ -61,10 +93,13 @@ func NewBasicAuthMiddleware(config BasicAuthConfig) *BasicAuthMiddleware {
 	if config.Users == nil {
 		config.Users = make(map[string]string)
 	}
+	attrs := config.cookieAttributes()
+	config.CookiePath, config.CookieSameSite = attrs.Path, attrs.SameSite
 
 	return &BasicAuthMiddleware{
 		config:   config,
 		sessions: make(map[string]*Session),
+		roles:    make(map[string]string),
 	}
 }
 
@@ -89,8 +124,9 @@ func (m *BasicAuthMiddleware) generateSessionID() (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-// CreateSession creates a new session for the user
+// CreateSession creates a new session for the user (identified by the canonical username)
 func (m *BasicAuthMiddleware) CreateSession(username string) (string, error) {
+	username = CanonicalUsername(username)
 	sessionID, err := m.generateSessionID()
 	if err != nil {
 		return "", err
@@ -99,6 +135,7 @@ func (m *BasicAuthMiddleware) CreateSession(username string) (string, error) {
 	session := &Session{
 		UserID:    username, // Using username as userID for simplicity
 		Username:  username,
+		Role:      m.UserRole(username),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(m.config.SessionDuration),
 	}
@@ -130,7 +167,7 @@ func (m *BasicAuthMiddleware) DeleteSession(sessionID string) {
 
 // ValidateCredentials checks if username and password are valid
 func (m *BasicAuthMiddleware) ValidateCredentials(username, password string) bool {
-	hash, exists := m.config.Users[username]
+	hash, exists := m.config.Users[CanonicalUsername(username)]
 	if !exists {
 		return false
 	}
@@ -143,7 +180,7 @@ func (m *BasicAuthMiddleware) RequireAuth() gin.HandlerFunc {
 		// Check for session cookie
 		sessionID, err := c.Cookie(m.config.CookieName)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Sessão não encontrada",
 				"code":    "SESSION_NOT_FOUND",
@@ -154,7 +191,7 @@ func (m *BasicAuthMiddleware) RequireAuth() gin.HandlerFunc {
 		// Validate session
 		session, valid := m.GetSession(sessionID)
 		if !valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Sessão inválida ou expirada",
 				"code":    "SESSION_INVALID",
@@ -164,8 +201,10 @@ func (m *BasicAuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// Add session info to context
 		c.Set("session", session)
+		c.Set("session_id", sessionID)
 		c.Set("user_id", session.UserID)
 		c.Set("username", session.Username)
+		c.Set("role", session.Role)
 
 		c.Next()
 	}
@@ -179,7 +218,7 @@ func (m *BasicAuthMiddleware) Login(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&loginRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		ErrorJSON(c, http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Dados de login inválidos",
 			"details": err.Error(),
@@ -190,7 +229,7 @@ func (m *BasicAuthMiddleware) Login(c *gin.Context) {
 
 	// Validate credentials
 	if !m.ValidateCredentials(loginRequest.Username, loginRequest.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Credenciais inválidas",
 			"code":    "INVALID_CREDENTIALS",
@@ -201,7 +240,7 @@ func (m *BasicAuthMiddleware) Login(c *gin.Context) {
 	// Create session
 	sessionID, err := m.CreateSession(loginRequest.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		ErrorJSON(c, http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Erro interno do servidor",
 			"code":    "SESSION_CREATE_ERROR",
@@ -210,21 +249,13 @@ func (m *BasicAuthMiddleware) Login(c *gin.Context) {
 	}
 
 	// Set session cookie
-	c.SetCookie(
-		m.config.CookieName,
-		sessionID,
-		int(m.config.SessionDuration.Seconds()),
-		"/",
-		m.config.CookieDomain,
-		m.config.CookieSecure,
-		m.config.CookieHTTPOnly,
-	)
+	m.SetSessionCookie(c, sessionID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Login realizado com sucesso",
 		"user": gin.H{
-			"username": loginRequest.Username,
+			"username": CanonicalUsername(loginRequest.Username),
 		},
 	})
 }
@@ -239,15 +270,7 @@ func (m *BasicAuthMiddleware) Logout(c *gin.Context) {
 	}
 
 	// Clear cookie
-	c.SetCookie(
-		m.config.CookieName,
-		"",
-		-1,
-		"/",
-		m.config.CookieDomain,
-		m.config.CookieSecure,
-		m.config.CookieHTTPOnly,
-	)
+	m.ClearSessionCookie(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -255,6 +278,37 @@ func (m *BasicAuthMiddleware) Logout(c *gin.Context) {
 	})
 }
 
+// cookieAttributes returns the session cookie attributes, with defaults applied
+func (c BasicAuthConfig) cookieAttributes() CookieAttributes {
+	return CookieAttributes{
+		Domain:   c.CookieDomain,
+		Path:     c.CookiePath,
+		Secure:   c.CookieSecure,
+		SameSite: c.CookieSameSite,
+	}.withDefaults()
+}
+
+// SetCookieAttributes sets the domain, path, Secure flag and SameSite mode of the
+// session cookie
+func (m *BasicAuthMiddleware) SetCookieAttributes(attrs CookieAttributes) {
+	attrs = attrs.withDefaults()
+	m.config.CookieDomain = attrs.Domain
+	m.config.CookiePath = attrs.Path
+	m.config.CookieSecure = attrs.Secure
+	m.config.CookieSameSite = attrs.SameSite
+}
+
+// SetSessionCookie sends the session cookie, valid for the session duration
+func (m *BasicAuthMiddleware) SetSessionCookie(c *gin.Context, sessionID string) {
+	m.config.cookieAttributes().setCookie(c, m.config.CookieName, sessionID,
+		int(m.config.SessionDuration.Seconds()), m.config.CookieHTTPOnly)
+}
+
+// ClearSessionCookie removes the session cookie, with the attributes it was set with
+func (m *BasicAuthMiddleware) ClearSessionCookie(c *gin.Context) {
+	m.config.cookieAttributes().setCookie(c, m.config.CookieName, "", -1, m.config.CookieHTTPOnly)
+}
+
 // CleanupExpiredSessions removes expired sessions (should be called periodically)
 func (m *BasicAuthMiddleware) CleanupExpiredSessions() {
 	now := time.Now()
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -61,8 +62,8 @@ func TestAuthenticationStateConsistency(t *testing.T) {
 				return false
 			}
 
-			// Test 3: Session should contain correct user information
-			if session.Username != username || session.UserID != username {
+			// Test 3: Session should contain correct user information (canonical username)
+			if session.Username != CanonicalUsername(username) || session.UserID != CanonicalUsername(username) {
 				t.Logf("Session contains incorrect user information")
 				return false
 			}
@@ -237,8 +238,8 @@ func TestInvalidInputRejection(t *testing.T) {
 				}
 			}
 
-			// Test 3: Non-existent user should be rejected
-			if invalidUsername != validUsername && invalidUsername != "" {
+			// Test 3: Non-existent user should be rejected (usernames are case-insensitive)
+			if !strings.EqualFold(invalidUsername, validUsername) && invalidUsername != "" {
 				if middleware.ValidateCredentials(invalidUsername, validPassword) {
 					t.Logf("Non-existent user was accepted")
 					return false
@@ -356,4 +357,51 @@ func TestInvalidInputRejection(t *testing.T) {
 	))
 
 	properties.TestingRun(t, gopter.ConsoleReporter(false))
-}
\ No newline at end of file
+}
+
+// TestLoginCaseInsensitiveUsername verifies that a user logs in whatever the case of the username
+func TestLoginCaseInsensitiveUsername(t *testing.T) {
+	auth := NewBasicAuthMiddleware(BasicAuthConfig{})
+	hash, err := HashPassword("senha123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	auth.AddUser("Ana", hash)
+	auth.SetUserRole("ana", RoleAdmin)
+
+	if !auth.HasUser("ANA") || !auth.HasUser(" ana ") {
+		t.Error("HasUser should ignore case and surrounding whitespace")
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/login", auth.Login)
+
+	for _, username := range []string{"ana", "ANA", "aNa"} {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": "senha123"})
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("login as %q: expected 200, got %d", username, w.Code)
+		}
+
+		// Every spelling gets the same user, so jobs and tokens stay with one account
+		var sessionID string
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session_id" {
+				sessionID, _ = url.QueryUnescape(cookie.Value) // gin escapes cookie values
+			}
+		}
+		session, ok := auth.GetSession(sessionID)
+		if !ok || session.UserID != "ana" || session.Role != RoleAdmin {
+			t.Errorf("login as %q: unexpected session %+v", username, session)
+		}
+	}
+
+	auth.RemoveUser("ANA")
+	if auth.HasUser("ana") {
+		t.Error("RemoveUser should ignore case")
+	}
+}
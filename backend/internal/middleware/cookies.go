@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CookieAttributes are the attributes of the session and CSRF cookies. Embedding the
+// app in another subdomain needs a parent Domain; cross-site embeds need
+// SameSite=None, which browsers only accept with Secure.
+type CookieAttributes struct {
+	Domain   string        // empty: the host that set the cookie
+	Path     string        // default "/"
+	Secure   bool          // send only over HTTPS
+	SameSite http.SameSite // default Lax
+}
+
+// withDefaults fills in the default path and SameSite mode
+func (a CookieAttributes) withDefaults() CookieAttributes {
+	if a.Path == "" {
+		a.Path = "/"
+	}
+	if a.SameSite == 0 || a.SameSite == http.SameSiteDefaultMode {
+		a.SameSite = http.SameSiteLaxMode
+	}
+	return a
+}
+
+// ParseSameSite converts a config value (lax, strict or none; empty means lax)
+func ParseSameSite(value string) (http.SameSite, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "lax":
+		return http.SameSiteLaxMode, true
+	case "strict":
+		return http.SameSiteStrictMode, true
+	case "none":
+		return http.SameSiteNoneMode, true
+	default:
+		return 0, false
+	}
+}
+
+// setCookie writes a cookie with these attributes; maxAge < 0 deletes it (the
+// browser only drops a cookie when domain and path match the ones it was set with)
+func (a CookieAttributes) setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	c.SetSameSite(a.SameSite)
+	c.SetCookie(name, value, maxAge, a.Path, a.Domain, a.Secure, httpOnly)
+}
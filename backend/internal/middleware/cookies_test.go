@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionCookie logs in (or out) through the middleware and returns the session cookie it sent
+func sessionCookie(t *testing.T, auth *BasicAuthMiddleware, path string) *http.Cookie {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/login", auth.Login)
+	router.POST("/logout", auth.Logout)
+
+	body, _ := json.Marshal(map[string]string{"username": "ana", "password": "senha123"})
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: expected 200, got %d", path, w.Code)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			return cookie
+		}
+	}
+	t.Fatalf("%s: no session cookie in %v", path, w.Header().Values("Set-Cookie"))
+	return nil
+}
+
+func newCookieTestAuth(t *testing.T) *BasicAuthMiddleware {
+	auth := NewBasicAuthMiddleware(BasicAuthConfig{CookieHTTPOnly: true})
+	hash, err := HashPassword("senha123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	auth.AddUser("ana", hash)
+	return auth
+}
+
+func TestSessionCookieDefaults(t *testing.T) {
+	cookie := sessionCookie(t, newCookieTestAuth(t), "/login")
+	if cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("SameSite = %v, want Lax", cookie.SameSite)
+	}
+	if cookie.Path != "/" || cookie.Domain != "" || cookie.Secure || !cookie.HttpOnly {
+		t.Errorf("unexpected default attributes: %+v", cookie)
+	}
+}
+
+func TestSessionCookieAttributes(t *testing.T) {
+	auth := newCookieTestAuth(t)
+	auth.SetCookieAttributes(CookieAttributes{
+		Domain:   "example.com",
+		Path:     "/app",
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+
+	login := sessionCookie(t, auth, "/login")
+	if login.Domain != "example.com" || login.Path != "/app" || !login.Secure || login.SameSite != http.SameSiteNoneMode {
+		t.Errorf("login cookie has unexpected attributes: %+v", login)
+	}
+	if login.MaxAge <= 0 || login.Value == "" {
+		t.Errorf("login cookie should carry the session: %+v", login)
+	}
+
+	// The browser only drops the cookie when domain and path match
+	logout := sessionCookie(t, auth, "/logout")
+	if logout.Domain != login.Domain || logout.Path != login.Path || logout.Secure != login.Secure || logout.SameSite != login.SameSite {
+		t.Errorf("logout cookie %+v doesn't match login cookie %+v", logout, login)
+	}
+	if logout.MaxAge >= 0 || logout.Value != "" {
+		t.Errorf("logout cookie should expire the session: %+v", logout)
+	}
+}
+
+func TestCSRFCookieAttributes(t *testing.T) {
+	csrf := NewCSRFMiddleware(CSRFConfig{})
+	csrf.SetCookieAttributes(CookieAttributes{Domain: "example.com", SameSite: http.SameSiteStrictMode})
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	csrf.SetTokenCookie(c, "token")
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %v", cookies)
+	}
+	cookie := cookies[0]
+	if cookie.Name != CSRFCookieName || cookie.Domain != "example.com" || cookie.Path != "/" ||
+		cookie.SameSite != http.SameSiteStrictMode || cookie.HttpOnly {
+		t.Errorf("unexpected CSRF cookie: %+v", cookie)
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	tests := map[string]http.SameSite{
+		"":        http.SameSiteLaxMode,
+		"lax":     http.SameSiteLaxMode,
+		" Strict": http.SameSiteStrictMode,
+		"NONE":    http.SameSiteNoneMode,
+	}
+	for value, want := range tests {
+		if got, ok := ParseSameSite(value); !ok || got != want {
+			t.Errorf("ParseSameSite(%q) = %v, %v; want %v", value, got, ok, want)
+		}
+	}
+	if _, ok := ParseSameSite("always"); ok {
+		t.Error("ParseSameSite should reject unknown modes")
+	}
+}
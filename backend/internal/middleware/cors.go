@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// corsAllowedMethods are the methods the web API answers to
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsDefaultHeaders are always allowed in cross-origin requests
+var corsDefaultHeaders = []string{"Content-Type", CSRFTokenHeader, HeaderRequestID, "Idempotency-Key"}
+
+// CORSConfig lists the origins allowed to call the API from a browser
+type CORSConfig struct {
+	AllowedOrigins   []string      // e.g. https://app.example.com; "*" allows any origin (empty = same origin only)
+	AllowCredentials bool          // lets the browser send the session cookie (listed origins only, never "*")
+	AllowedHeaders   []string      // request headers allowed besides corsDefaultHeaders
+	MaxAge           time.Duration // how long browsers may cache a preflight (0 = browser default)
+}
+
+// CORS answers preflights and adds the Access-Control headers for allowed origins on
+// the paths under prefixes. Preflights from other origins are rejected with 403; their
+// simple requests go through without CORS headers, so the browser hides the response
+// (same-origin pages, which send an Origin on POST too, are unaffected). It must be
+// registered on the router, not on a group, so preflights reach it even though no
+// OPTIONS route exists.
+func CORS(config CORSConfig, prefixes ...string) gin.HandlerFunc {
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(origin)] = true
+	}
+	allowedHeaders := strings.Join(append(append([]string{}, corsDefaultHeaders...), config.AllowedHeaders...), ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge / time.Second))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !hasPathPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !anyOrigin && !origins[strings.ToLower(origin)] {
+			if !preflight {
+				c.Next()
+				return
+			}
+			logger.Get(c.Request.Context()).Warn().
+				Str("origin", origin).
+				Str("path", c.Request.URL.Path).
+				Msg("Origem não permitida pelo CORS")
+			AbortWithErrorJSON(c, http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Origem não permitida",
+			})
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		if config.AllowCredentials && origins[strings.ToLower(origin)] {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Preflight: answered here, before authentication, since browsers send no cookies on it
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			if config.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", strings.Join([]string{HeaderRequestID, CSRFTokenHeader, "Content-Disposition"}, ", "))
+		c.Next()
+	}
+}
+
+// hasPathPrefix reports whether path is one of prefixes or below one of them
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimRight(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
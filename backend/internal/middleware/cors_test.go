@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func corsRouter(config CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS(config, "/api/web", "/api/auth"))
+	web := r.Group("/api/web")
+	web.Use(func(c *gin.Context) {
+		// Stands in for RequireAuth: preflights must never get here
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	web.POST("/jobs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r
+}
+
+func preflight(path, origin string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-csrf-token")
+	return req
+}
+
+func TestCORSPreflightAllowedOrigin(t *testing.T) {
+	r := corsRouter(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com/"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, preflight("/api/web/jobs", "https://app.example.com"))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+		"Vary":                             "Origin",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, expected %q", header, got, want)
+		}
+	}
+	if allowed := w.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(allowed, CSRFTokenHeader) {
+		t.Errorf("Access-Control-Allow-Headers = %q, expected %s", allowed, CSRFTokenHeader)
+	}
+
+	// The actual request gets the headers too
+	req := httptest.NewRequest(http.MethodPost, "/api/web/jobs", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("expected CORS headers on the request, got %d %v", w.Code, w.Header())
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), HeaderRequestID) {
+		t.Errorf("Access-Control-Expose-Headers = %q", w.Header().Get("Access-Control-Expose-Headers"))
+	}
+}
+
+func TestCORSRejectsDisallowedOrigin(t *testing.T) {
+	r := corsRouter(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, preflight("/api/auth/login", "https://evil.example.org"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin got Access-Control-Allow-Origin %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// A simple request is not blocked here, but without CORS headers the browser hides the response
+	req := httptest.NewRequest(http.MethodPost, "/api/web/jobs", nil)
+	req.Header.Set("Origin", "https://evil.example.org")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("disallowed origin got CORS headers: %v", w.Header())
+	}
+}
+
+func TestCORSDefaultsToSameOrigin(t *testing.T) {
+	r := corsRouter(CORSConfig{AllowCredentials: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, preflight("/api/web/jobs", "https://app.example.com"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 with no origins configured, got %d", w.Code)
+	}
+
+	// Same-origin pages send Origin on POST too and must keep working
+	req := httptest.NewRequest(http.MethodPost, "/api/web/jobs", nil)
+	req.Header.Set("Origin", "http://"+req.Host)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a same-origin request, got %d", w.Code)
+	}
+
+	// Paths outside the configured prefixes are not touched
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, preflight("/health", "https://app.example.com"))
+	if w.Code == http.StatusForbidden || w.Code == http.StatusNoContent {
+		t.Errorf("CORS handled a path outside its prefixes: %d", w.Code)
+	}
+}
+
+func TestCORSWildcardNeverSendsCredentials(t *testing.T) {
+	r := corsRouter(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, preflight("/api/web/jobs", "https://anything.example.net"))
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://anything.example.net" {
+		t.Fatalf("expected the wildcard to allow the origin, got %d %v", w.Code, w.Header())
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("wildcard origin must not be allowed to send credentials")
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"net/http"
 	"sync"
@@ -19,18 +20,48 @@ const (
 	CSRFTokenLength = 32
 )
 
+// CSRFStrategy selects how the client proves it holds the session's CSRF token
+type CSRFStrategy string
+
+const (
+	// CSRFStrategyHeader requires the token returned at login in the X-CSRF-Token header
+	CSRFStrategyHeader CSRFStrategy = "header"
+	// CSRFStrategyDoubleSubmit requires the X-CSRF-Token header to repeat the value of
+	// the csrf_token cookie, so clients can read the token from the cookie instead of
+	// keeping the login response. Another site can send the cookie but can't read it.
+	CSRFStrategyDoubleSubmit CSRFStrategy = "double_submit"
+)
+
+// ParseCSRFStrategy converts a config value into a strategy (empty means header)
+func ParseCSRFStrategy(value string) (CSRFStrategy, bool) {
+	switch CSRFStrategy(value) {
+	case "", CSRFStrategyHeader:
+		return CSRFStrategyHeader, true
+	case CSRFStrategyDoubleSubmit:
+		return CSRFStrategyDoubleSubmit, true
+	default:
+		return "", false
+	}
+}
+
 // CSRFToken represents a CSRF token with expiration
 type CSRFToken struct {
 	Token     string
 	ExpiresAt time.Time
 }
 
-// CSRFConfig contains configuration for CSRF protection
+// CSRFConfig contains configuration for CSRF protection.
+// A session's token expires after TokenDuration (24h by default, the session length);
+// it is replaced at login and on password change and removed at logout, so a token
+// that was rotated out is rejected even before it expires.
 type CSRFConfig struct {
 	TokenDuration time.Duration // How long tokens are valid
+	Strategy      CSRFStrategy  // How tokens are submitted (default: header)
 	CookieDomain  string        // Cookie domain
 	CookieSecure  bool          // Secure cookie flag
 	CookiePath    string        // Cookie path
+	// CookieSameSite SameSite mode of the token cookie (default Lax)
+	CookieSameSite http.SameSite
 }
 
 // CSRFMiddleware handles CSRF protection
@@ -46,8 +77,10 @@ func NewCSRFMiddleware(config CSRFConfig) *CSRFMiddleware {
 	if config.TokenDuration == 0 {
 		config.TokenDuration = 24 * time.Hour
 	}
-	if config.CookiePath == "" {
-		config.CookiePath = "/"
+	attrs := config.cookieAttributes()
+	config.CookiePath, config.CookieSameSite = attrs.Path, attrs.SameSite
+	if config.Strategy == "" {
+		config.Strategy = CSRFStrategyHeader
 	}
 
 	return &CSRFMiddleware{
@@ -63,7 +96,9 @@ func (m *CSRFMiddleware) GenerateToken(sessionID string) (string, error) {
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-	token := base64.URLEncoding.EncodeToString(bytes)
+	// Unpadded, so the cookie value is sent as is (not escaped) and JavaScript
+	// can copy it to the header in the double submit strategy
+	token := base64.RawURLEncoding.EncodeToString(bytes)
 
 	m.mu.Lock()
 	m.tokens[sessionID] = &CSRFToken{
@@ -93,7 +128,18 @@ func (m *CSRFMiddleware) ValidateToken(sessionID, token string) bool {
 		return false
 	}
 
-	return csrfToken.Token == token
+	return subtle.ConstantTimeCompare([]byte(csrfToken.Token), []byte(token)) == 1
+}
+
+// RotateToken replaces the session's token, invalidating the previous one, and sends
+// the new token in the cookie. Used after privilege-changing actions like a password change.
+func (m *CSRFMiddleware) RotateToken(c *gin.Context, sessionID string) (string, error) {
+	token, err := m.GenerateToken(sessionID)
+	if err != nil {
+		return "", err
+	}
+	m.SetTokenCookie(c, token)
+	return token, nil
 }
 
 // DeleteToken removes a CSRF token for a session
@@ -116,30 +162,35 @@ func (m *CSRFMiddleware) GetToken(sessionID string) (string, bool) {
 	return csrfToken.Token, true
 }
 
+// cookieAttributes returns the token cookie attributes, with defaults applied
+func (c CSRFConfig) cookieAttributes() CookieAttributes {
+	return CookieAttributes{
+		Domain:   c.CookieDomain,
+		Path:     c.CookiePath,
+		Secure:   c.CookieSecure,
+		SameSite: c.CookieSameSite,
+	}.withDefaults()
+}
+
+// SetCookieAttributes sets the domain, path, Secure flag and SameSite mode of the
+// token cookie
+func (m *CSRFMiddleware) SetCookieAttributes(attrs CookieAttributes) {
+	attrs = attrs.withDefaults()
+	m.config.CookieDomain = attrs.Domain
+	m.config.CookiePath = attrs.Path
+	m.config.CookieSecure = attrs.Secure
+	m.config.CookieSameSite = attrs.SameSite
+}
+
 // SetTokenCookie sets the CSRF token as a cookie
 func (m *CSRFMiddleware) SetTokenCookie(c *gin.Context, token string) {
-	c.SetCookie(
-		CSRFCookieName,
-		token,
-		int(m.config.TokenDuration.Seconds()),
-		m.config.CookiePath,
-		m.config.CookieDomain,
-		m.config.CookieSecure,
-		false, // Not HTTPOnly - JavaScript needs to read it
-	)
+	// Not HTTPOnly - JavaScript needs to read it
+	m.config.cookieAttributes().setCookie(c, CSRFCookieName, token, int(m.config.TokenDuration.Seconds()), false)
 }
 
 // ClearTokenCookie clears the CSRF token cookie
 func (m *CSRFMiddleware) ClearTokenCookie(c *gin.Context) {
-	c.SetCookie(
-		CSRFCookieName,
-		"",
-		-1,
-		m.config.CookiePath,
-		m.config.CookieDomain,
-		m.config.CookieSecure,
-		false,
-	)
+	m.config.cookieAttributes().setCookie(c, CSRFCookieName, "", -1, false)
 }
 
 // RequireCSRF middleware that validates CSRF tokens for state-changing requests
@@ -155,7 +206,7 @@ func (m *CSRFMiddleware) RequireCSRF() gin.HandlerFunc {
 		// Get session ID from context (set by RequireAuth middleware)
 		sessionID, exists := c.Get("user_id")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Sessão não encontrada",
 				"code":    "SESSION_NOT_FOUND",
@@ -163,15 +214,11 @@ func (m *CSRFMiddleware) RequireCSRF() gin.HandlerFunc {
 			return
 		}
 
-		// Get CSRF token from header
+		// The token must come in the header: the cookie alone is sent by the browser
+		// on cross-site requests too, so it proves nothing by itself
 		token := c.GetHeader(CSRFTokenHeader)
 		if token == "" {
-			// Also check cookie as fallback
-			token, _ = c.Cookie(CSRFCookieName)
-		}
-
-		if token == "" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			AbortWithErrorJSON(c, http.StatusForbidden, gin.H{
 				"success": false,
 				"error":   "Token CSRF ausente",
 				"code":    "CSRF_TOKEN_MISSING",
@@ -179,9 +226,22 @@ func (m *CSRFMiddleware) RequireCSRF() gin.HandlerFunc {
 			return
 		}
 
+		// Double submit: the header must repeat the cookie set by this server
+		if m.config.Strategy == CSRFStrategyDoubleSubmit {
+			cookie, _ := c.Cookie(CSRFCookieName)
+			if subtle.ConstantTimeCompare([]byte(cookie), []byte(token)) != 1 {
+				AbortWithErrorJSON(c, http.StatusForbidden, gin.H{
+					"success": false,
+					"error":   "Token CSRF não confere com o cookie",
+					"code":    "CSRF_TOKEN_MISMATCH",
+				})
+				return
+			}
+		}
+
 		// Validate token
 		if !m.ValidateToken(sessionID.(string), token) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			AbortWithErrorJSON(c, http.StatusForbidden, gin.H{
 				"success": false,
 				"error":   "Token CSRF inválido ou expirado",
 				"code":    "CSRF_TOKEN_INVALID",
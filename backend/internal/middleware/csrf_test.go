@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csrfRouter mounts a state-changing route behind RequireCSRF for user-1, plus a route
+// that rotates the token like a password change
+func csrfRouter(m *CSRFMiddleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Next()
+	}, m.RequireCSRF())
+	r.POST("/action", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.POST("/password", func(c *gin.Context) {
+		token, err := m.RotateToken(c, "user-1")
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"csrf_token": token})
+	})
+	return r
+}
+
+func csrfRequest(r *gin.Engine, path, header, cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if header != "" {
+		req.Header.Set(CSRFTokenHeader, header)
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: cookie})
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCSRFHeaderStrategy(t *testing.T) {
+	m := NewCSRFMiddleware(CSRFConfig{})
+	r := csrfRouter(m)
+	token, _ := m.GenerateToken("user-1")
+
+	if w := csrfRequest(r, "/action", token, ""); w.Code != http.StatusOK {
+		t.Errorf("token válido no header: esperado 200, obtido %d", w.Code)
+	}
+	if w := csrfRequest(r, "/action", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("sem token: esperado 403, obtido %d", w.Code)
+	}
+	// O cookie é enviado pelo navegador em requisições de outros sites, então sozinho não vale
+	if w := csrfRequest(r, "/action", "", token); w.Code != http.StatusForbidden {
+		t.Errorf("token só no cookie: esperado 403, obtido %d", w.Code)
+	}
+}
+
+func TestCSRFRotatedTokenRejected(t *testing.T) {
+	m := NewCSRFMiddleware(CSRFConfig{})
+	r := csrfRouter(m)
+	oldToken, _ := m.GenerateToken("user-1")
+
+	w := csrfRequest(r, "/password", oldToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("rotação: esperado 200, obtido %d", w.Code)
+	}
+	newToken, ok := m.GetToken("user-1")
+	if !ok || newToken == oldToken {
+		t.Fatal("token não foi rotacionado")
+	}
+	if cookie := w.Result().Cookies(); len(cookie) != 1 || cookie[0].Value != newToken {
+		t.Errorf("cookie deveria trazer o novo token: %v", cookie)
+	}
+
+	if w := csrfRequest(r, "/action", oldToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("token antigo após rotação: esperado 403, obtido %d", w.Code)
+	}
+	if w := csrfRequest(r, "/action", newToken, ""); w.Code != http.StatusOK {
+		t.Errorf("novo token: esperado 200, obtido %d", w.Code)
+	}
+
+	// Logout remove o token
+	m.DeleteToken("user-1")
+	if w := csrfRequest(r, "/action", newToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("token após logout: esperado 403, obtido %d", w.Code)
+	}
+}
+
+func TestCSRFDoubleSubmitStrategy(t *testing.T) {
+	m := NewCSRFMiddleware(CSRFConfig{Strategy: CSRFStrategyDoubleSubmit})
+	r := csrfRouter(m)
+	token, _ := m.GenerateToken("user-1")
+
+	if w := csrfRequest(r, "/action", token, token); w.Code != http.StatusOK {
+		t.Errorf("header igual ao cookie: esperado 200, obtido %d", w.Code)
+	}
+	if w := csrfRequest(r, "/action", token, ""); w.Code != http.StatusForbidden {
+		t.Errorf("sem cookie: esperado 403, obtido %d", w.Code)
+	}
+	if w := csrfRequest(r, "/action", token, "outro-valor"); w.Code != http.StatusForbidden {
+		t.Errorf("cookie diferente do header: esperado 403, obtido %d", w.Code)
+	}
+
+	// Um par antigo header+cookie também deixa de valer após a rotação
+	newToken, _ := m.GenerateToken("user-1")
+	if w := csrfRequest(r, "/action", token, token); w.Code != http.StatusForbidden {
+		t.Errorf("par antigo após rotação: esperado 403, obtido %d", w.Code)
+	}
+	if w := csrfRequest(r, "/action", newToken, newToken); w.Code != http.StatusOK {
+		t.Errorf("novo par: esperado 200, obtido %d", w.Code)
+	}
+}
+
+func TestCSRFTokenExpires(t *testing.T) {
+	m := NewCSRFMiddleware(CSRFConfig{TokenDuration: time.Millisecond})
+	token, _ := m.GenerateToken("user-1")
+	time.Sleep(5 * time.Millisecond)
+
+	if m.ValidateToken("user-1", token) {
+		t.Error("token expirado não deveria ser aceito")
+	}
+}
+
+func TestParseCSRFStrategy(t *testing.T) {
+	for value, want := range map[string]CSRFStrategy{
+		"":              CSRFStrategyHeader,
+		"header":        CSRFStrategyHeader,
+		"double_submit": CSRFStrategyDoubleSubmit,
+	} {
+		if got, ok := ParseCSRFStrategy(value); !ok || got != want {
+			t.Errorf("ParseCSRFStrategy(%q) = %q, %v", value, got, ok)
+		}
+	}
+	if _, ok := ParseCSRFStrategy("cookie"); ok {
+		t.Error("estratégia desconhecida deveria ser rejeitada")
+	}
+}
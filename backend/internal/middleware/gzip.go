@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultGzipMinSize is the smallest response body compressed by default
+const DefaultGzipMinSize = 1024
+
+// compressedContentTypes are response types already compressed (spreadsheets and
+// archives are zip files); compressing them again only costs CPU
+var compressedContentTypes = []string{
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/vnd.openxmlformats-officedocument.",
+	"application/vnd.oasis.opendocument.",
+	"image/",
+	"video/",
+	"audio/",
+}
+
+// GzipConfig controls response compression and request decompression
+type GzipConfig struct {
+	Compress             bool  // compress responses (false = only decompress requests)
+	MinSize              int   // smallest response body compressed (0 = DefaultGzipMinSize)
+	MaxDecompressedBytes int64 // largest decompressed request body (0 = unlimited; routes may still apply Limit)
+}
+
+// Gzip compresses responses for clients that send "Accept-Encoding: gzip" once the
+// body reaches MinSize, except already compressed content (XLSX, ZIP, images), event
+// streams and WebSocket upgrades. Request bodies sent with "Content-Encoding: gzip"
+// are decompressed before the handlers read them, so uploads and job creation accept
+// compressed payloads transparently; the route limits then apply to the decompressed
+// size.
+func Gzip(config GzipConfig) gin.HandlerFunc {
+	if config.MinSize <= 0 {
+		config.MinSize = DefaultGzipMinSize
+	}
+
+	return func(c *gin.Context) {
+		if strings.EqualFold(strings.TrimSpace(c.GetHeader("Content-Encoding")), "gzip") && c.Request.Body != nil {
+			if !decompressRequest(c, config.MaxDecompressedBytes) {
+				return
+			}
+		}
+
+		if !config.Compress || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" ||
+			!acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: config.MinSize}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// decompressRequest replaces a gzip request body by its decompressed stream; it
+// answers 400 and returns false when the body isn't valid gzip
+func decompressRequest(c *gin.Context, maxBytes int64) bool {
+	zr, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		logger.Get(c.Request.Context()).Warn().Err(err).Msg("Corpo gzip inválido")
+		AbortWithErrorJSON(c, http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Corpo gzip inválido",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	var body io.ReadCloser = &gzipRequestBody{Reader: zr, compressed: c.Request.Body}
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, maxBytes)
+	}
+	c.Request.Body = body
+	c.Request.ContentLength = -1 // the decompressed size is only known once read
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	return true
+}
+
+// gzipRequestBody closes both the gzip reader and the original body
+type gzipRequestBody struct {
+	*gzip.Reader
+	compressed io.ReadCloser
+}
+
+func (b *gzipRequestBody) Close() error {
+	b.Reader.Close()
+	return b.compressed.Close()
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip (q > 0)
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter buffers the start of the body until minSize bytes decide whether it is
+// worth compressing; smaller bodies are written as they are
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written also counts the buffered bytes, so later middlewares don't write a second body
+func (w *gzipWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Size also counts the buffered bytes (compressed bodies report the compressed size)
+func (w *gzipWriter) Size() int {
+	if w.buf.Len() == 0 {
+		return w.ResponseWriter.Size()
+	}
+	size := w.ResponseWriter.Size()
+	if size < 0 {
+		size = 0
+	}
+	return size + w.buf.Len()
+}
+
+// Flush sends what was buffered so far (compressing it if it's already large enough)
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses compression for the buffered body and writes it
+func (w *gzipWriter) decide() error {
+	w.decided = true
+	if w.buf.Len() >= w.minSize && w.compressible() {
+		header := w.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// compressible reports whether the response may be compressed
+func (w *gzipWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, prefix := range compressedContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish writes a body that never reached minSize and completes the gzip stream
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type gzipReportTask struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+func gzipRouter(config GzipConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Gzip(config))
+	r.Use(RequestID())
+	r.POST("/api/web/jobs", func(c *gin.Context) {
+		var req struct {
+			Title string `json:"title" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ErrorJSON(c, http.StatusBadRequest, gin.H{"success": false, "error": "Dados inválidos"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "title": req.Title})
+	})
+	r.GET("/api/web/reports", func(c *gin.Context) {
+		tasks := make([]gzipReportTask, 2000)
+		for i := range tasks {
+			tasks[i] = gzipReportTask{ID: fmt.Sprintf("task%05d", i), Name: "Tarefa do relatório", Status: "em andamento"}
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "tasks": tasks})
+	})
+	r.GET("/api/web/reports/file", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", bytes.Repeat([]byte("x"), 4096))
+	})
+	r.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	return r
+}
+
+func gzipBytes(t *testing.T, data []byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	zw.Close()
+	return &buf
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return plain
+}
+
+func TestGzipRequestRoundTrip(t *testing.T) {
+	r := gzipRouter(GzipConfig{Compress: true, MinSize: 16})
+
+	title := strings.Repeat("Atualização de campos ", 20)
+	body, _ := json.Marshal(gin.H{"mapping_id": "map_1", "title": title})
+	req := httptest.NewRequest(http.MethodPost, "/api/web/jobs", gzipBytes(t, body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip response, got headers %v", w.Header())
+	}
+	var resp struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(gunzip(t, w.Body.Bytes()), &resp); err != nil || resp.Title != title {
+		t.Errorf("round trip lost the payload: %+v, %v", resp, err)
+	}
+}
+
+func TestGzipLargeReportResponse(t *testing.T) {
+	r := gzipRouter(GzipConfig{Compress: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/web/reports", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" || !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Fatalf("expected a gzip response, got headers %v", w.Header())
+	}
+	plain := gunzip(t, w.Body.Bytes())
+	if w.Body.Len() >= len(plain)/4 {
+		t.Errorf("report barely compressed: %d -> %d bytes", len(plain), w.Body.Len())
+	}
+	var resp struct {
+		Tasks []gzipReportTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(plain, &resp); err != nil || len(resp.Tasks) != 2000 {
+		t.Errorf("invalid report after gunzip: %d tasks, %v", len(resp.Tasks), err)
+	}
+
+	// Without Accept-Encoding the same report goes out as plain JSON
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/web/reports", nil))
+	if w.Header().Get("Content-Encoding") != "" || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("expected plain JSON without Accept-Encoding, got %v", w.Header())
+	}
+}
+
+func TestGzipSkipsSmallAndCompressedResponses(t *testing.T) {
+	r := gzipRouter(GzipConfig{Compress: true})
+
+	for _, path := range []string{"/small", "/api/web/reports/file"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected an uncompressed 200, got %d %v", path, w.Code, w.Header())
+		}
+	}
+
+	// q=0 refuses gzip
+	req := httptest.NewRequest(http.MethodGet, "/api/web/reports", nil)
+	req.Header.Set("Accept-Encoding", "gzip;q=0, identity")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("gzip;q=0 got a compressed response")
+	}
+
+	// Compression disabled: requests are still decompressed
+	r = gzipRouter(GzipConfig{})
+	req = httptest.NewRequest(http.MethodPost, "/api/web/jobs", gzipBytes(t, []byte(`{"title":"Job"}`)))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected an uncompressed 201, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestGzipRejectsInvalidAndOversizedBodies(t *testing.T) {
+	r := gzipRouter(GzipConfig{Compress: true, MaxDecompressedBytes: 1024})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/web/jobs", strings.NewReader(`{"title":"not gzip"}`))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Corpo gzip inválido") {
+		t.Errorf("expected 400 for an invalid gzip body, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A small compressed body that expands past the limit is cut off
+	bomb, _ := json.Marshal(gin.H{"title": strings.Repeat("a", 64<<10)})
+	req = httptest.NewRequest(http.MethodPost, "/api/web/jobs", gzipBytes(t, bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 past the decompressed limit, got %d", w.Code)
+	}
+
+	// Error bodies keep their request_id
+	body := w.Body.Bytes()
+	if w.Header().Get("Content-Encoding") == "gzip" {
+		body = gunzip(t, body)
+	}
+	if !strings.Contains(string(body), `"request_id":"`+w.Header().Get(HeaderRequestID)+`"`) {
+		t.Errorf("error body without request_id: %s", body)
+	}
+}
@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header that identifies a retried submission
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed from a previous request
+	IdempotentReplayHeader = "Idempotent-Replayed"
+	// MaxIdempotencyKeyLength is the longest accepted Idempotency-Key
+	MaxIdempotencyKeyLength = 255
+)
+
+// IdempotencyConfig contains configuration for idempotent request handling
+type IdempotencyConfig struct {
+	TTL             time.Duration // How long a key and its response are kept
+	MaxBodyBytes    int           // Largest response body kept for replay; larger responses aren't replayed
+	MaxTotalBytes   int           // Largest sum of the bodies kept; the oldest responses are dropped past it
+	MaxRequestBytes int64         // Largest request body read to fingerprint it; larger requests get 413
+}
+
+// idempotencyEntry is the state of one key: in flight until done, then the stored response
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// IdempotencyMiddleware replays the original response when a request is retried
+// with the same Idempotency-Key, so network retries don't create a second job or report
+type IdempotencyMiddleware struct {
+	config    IdempotencyConfig
+	entries   map[string]*idempotencyEntry
+	total     int // bytes of the stored bodies
+	lastPrune time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewIdempotencyMiddleware creates a new idempotency middleware
+func NewIdempotencyMiddleware(config IdempotencyConfig) *IdempotencyMiddleware {
+	// Set defaults
+	if config.TTL == 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = 256 << 10
+	}
+	if config.MaxTotalBytes == 0 {
+		config.MaxTotalBytes = 32 << 20
+	}
+	if config.MaxRequestBytes <= 0 {
+		config.MaxRequestBytes = 1 << 20
+	}
+
+	return &IdempotencyMiddleware{
+		config:  config,
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// idempotencyState is the outcome of looking up a key
+type idempotencyState int
+
+const (
+	idempotencyNew idempotencyState = iota
+	idempotencyInFlight
+	idempotencyReplay
+	idempotencyMismatch
+)
+
+// rejectTooLarge answers 413 for a request body over MaxRequestBytes
+func (m *IdempotencyMiddleware) rejectTooLarge(c *gin.Context) {
+	logger.Get(c.Request.Context()).Warn().
+		Int64("content_length", c.Request.ContentLength).
+		Int64("max_bytes", m.config.MaxRequestBytes).
+		Msg("Corpo da requisição idempotente acima do limite")
+	AbortWithErrorJSON(c, http.StatusRequestEntityTooLarge, gin.H{
+		"success": false,
+		"error":   "Corpo da requisição muito grande",
+		"details": fmt.Sprintf("máximo de %d bytes", m.config.MaxRequestBytes),
+	})
+}
+
+// begin registers key as in flight, or reports what is already stored for it
+func (m *IdempotencyMiddleware) begin(key string, fingerprint [sha256.Size]byte) (idempotencyState, *idempotencyEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.pruneLocked(now)
+
+	if entry, ok := m.entries[key]; ok && now.Before(entry.expiresAt) {
+		switch {
+		case entry.fingerprint != fingerprint:
+			return idempotencyMismatch, nil
+		case !entry.done:
+			return idempotencyInFlight, nil
+		default:
+			return idempotencyReplay, entry
+		}
+	}
+
+	m.deleteLocked(key) // an expired entry not pruned yet
+	m.entries[key] = &idempotencyEntry{
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(m.config.TTL),
+	}
+	return idempotencyNew, nil
+}
+
+// complete stores the response for key
+func (m *IdempotencyMiddleware) complete(key string, status int, header http.Header, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok {
+		entry.done = true
+		entry.status = status
+		entry.header = header
+		entry.body = body
+		entry.expiresAt = m.now().Add(m.config.TTL)
+		m.total += len(body)
+		m.evictLocked()
+	}
+}
+
+// release forgets key so the request can be retried
+func (m *IdempotencyMiddleware) release(key string) {
+	m.mu.Lock()
+	m.deleteLocked(key)
+	m.mu.Unlock()
+}
+
+// deleteLocked forgets key and the size of its body
+func (m *IdempotencyMiddleware) deleteLocked(key string) {
+	if entry, ok := m.entries[key]; ok {
+		m.total -= len(entry.body)
+		delete(m.entries, key)
+	}
+}
+
+// evictLocked drops the stored responses closest to expiring until the bodies fit in
+// MaxTotalBytes; a retry of a dropped key runs the request again
+func (m *IdempotencyMiddleware) evictLocked() {
+	for m.total > m.config.MaxTotalBytes {
+		oldest := ""
+		for key, entry := range m.entries {
+			if entry.done && (oldest == "" || entry.expiresAt.Before(m.entries[oldest].expiresAt)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			return
+		}
+		m.deleteLocked(oldest)
+	}
+}
+
+// pruneLocked removes expired keys, at most once a minute
+func (m *IdempotencyMiddleware) pruneLocked(now time.Time) {
+	if now.Sub(m.lastPrune) < time.Minute {
+		return
+	}
+	m.lastPrune = now
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			m.deleteLocked(key)
+		}
+	}
+}
+
+// Handle returns the middleware function. Requests without the header pass through.
+// Keys are scoped by user and route; reusing a key with a different payload is rejected.
+// Only responses below 500 are stored, so failed requests can be retried with the same key,
+// and only up to MaxBodyBytes each: a larger response (e.g. a report file) runs again on retry.
+// The request body is buffered to fingerprint it, so bodies over MaxRequestBytes get 413.
+func (m *IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > MaxIdempotencyKeyLength {
+			AbortWithErrorJSON(c, http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Idempotency-Key inválida",
+				"details": "máximo de 255 caracteres",
+			})
+			return
+		}
+
+		maxBytes := m.config.MaxRequestBytes
+		if c.Request.ContentLength > maxBytes {
+			m.rejectTooLarge(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil && int64(len(body)) >= maxBytes {
+			// MaxBytesReader stops at the limit when the body is longer
+			m.rejectTooLarge(c)
+			return
+		}
+		if err != nil {
+			AbortWithErrorJSON(c, http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "erro ao ler requisição",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := c.GetString("user_id") + "\x00" + c.Request.Method + " " + c.FullPath() + "\x00" + idempotencyKey
+		state, entry := m.begin(key, sha256.Sum256(body))
+
+		switch state {
+		case idempotencyMismatch:
+			AbortWithErrorJSON(c, http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error":   "Idempotency-Key já usada com outro conteúdo",
+			})
+			return
+		case idempotencyInFlight:
+			AbortWithErrorJSON(c, http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "requisição com esta Idempotency-Key ainda em processamento",
+			})
+			return
+		case idempotencyReplay:
+			logger.Get(c.Request.Context()).Info().
+				Str("idempotency_key", idempotencyKey).
+				Msg("Respondendo com resultado de requisição idempotente anterior")
+			for name, values := range entry.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(IdempotentReplayHeader, "true")
+			c.Writer.WriteHeader(entry.status)
+			c.Writer.Write(entry.body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, limit: m.config.MaxBodyBytes}
+		c.Writer = recorder
+
+		// The key is released unless a response is stored, including when the handler panics
+		stored := false
+		defer func() {
+			if !stored {
+				m.release(key)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || recorder.overflow {
+			return
+		}
+		m.complete(key, status, replayHeader(c.Writer.Header()), recorder.body.Bytes())
+		stored = true
+	}
+}
+
+// replayHeader copies the response headers, leaving out the ones set per request
+func replayHeader(header http.Header) http.Header {
+	replay := header.Clone()
+	replay.Del(HeaderRequestID)
+	replay.Del(HeaderTraceID)
+	replay.Del(HeaderSpanID)
+	replay.Del("Set-Cookie")
+	return replay
+}
+
+// responseRecorder keeps a copy of the response body up to limit bytes
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(data []byte) {
+	if r.overflow {
+		return
+	}
+	if r.body.Len()+len(data) > r.limit {
+		r.overflow = true
+		r.body = bytes.Buffer{}
+		return
+	}
+	r.body.Write(data)
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyRouter mounts a job-creating handler behind the middleware and counts the jobs
+func idempotencyRouter(m *IdempotencyMiddleware, userID string, jobs *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/jobs", func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	}, m.Handle(), func(c *gin.Context) {
+		*jobs++
+		c.JSON(http.StatusCreated, gin.H{"id": *jobs})
+	})
+	return r
+}
+
+func postJob(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencySameKeyCreatesSingleJob(t *testing.T) {
+	jobs := 0
+	r := idempotencyRouter(NewIdempotencyMiddleware(IdempotencyConfig{}), "user-1", &jobs)
+
+	first := postJob(r, "key-1", `{"mapping_id":"m1"}`)
+	second := postJob(r, "key-1", `{"mapping_id":"m1"}`)
+
+	if jobs != 1 {
+		t.Fatalf("esperado 1 job criado, obtido %d", jobs)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("resposta repetida difere: %d %q, original %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Error("resposta repetida deveria ter o header Idempotent-Replayed")
+	}
+	if first.Header().Get(IdempotentReplayHeader) != "" {
+		t.Error("primeira resposta não deveria ser marcada como repetida")
+	}
+
+	// Outra chave cria outro job
+	postJob(r, "key-2", `{"mapping_id":"m1"}`)
+	if jobs != 2 {
+		t.Errorf("esperado 2 jobs após nova chave, obtido %d", jobs)
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	jobs := 0
+	r := idempotencyRouter(NewIdempotencyMiddleware(IdempotencyConfig{}), "user-1", &jobs)
+
+	postJob(r, "", `{}`)
+	postJob(r, "", `{}`)
+	if jobs != 2 {
+		t.Errorf("sem Idempotency-Key cada requisição deve criar um job, obtido %d", jobs)
+	}
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	jobs := 0
+	r := idempotencyRouter(NewIdempotencyMiddleware(IdempotencyConfig{}), "user-1", &jobs)
+
+	postJob(r, "key-1", `{"mapping_id":"m1"}`)
+	w := postJob(r, "key-1", `{"mapping_id":"m2"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("esperado 422, obtido %d", w.Code)
+	}
+	if jobs != 1 {
+		t.Errorf("esperado 1 job criado, obtido %d", jobs)
+	}
+}
+
+func TestIdempotencyKeysAreScopedByUser(t *testing.T) {
+	m := NewIdempotencyMiddleware(IdempotencyConfig{})
+	jobs := 0
+	postJob(idempotencyRouter(m, "user-1", &jobs), "key-1", `{}`)
+	postJob(idempotencyRouter(m, "user-2", &jobs), "key-1", `{}`)
+
+	if jobs != 2 {
+		t.Errorf("a mesma chave de usuários diferentes deve criar jobs distintos, obtido %d", jobs)
+	}
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewIdempotencyMiddleware(IdempotencyConfig{TTL: time.Hour})
+	m.now = func() time.Time { return now }
+
+	jobs := 0
+	r := idempotencyRouter(m, "user-1", &jobs)
+
+	postJob(r, "key-1", `{}`)
+	now = now.Add(59 * time.Minute)
+	postJob(r, "key-1", `{}`)
+	if jobs != 1 {
+		t.Fatalf("esperado 1 job antes de expirar, obtido %d", jobs)
+	}
+
+	now = now.Add(2 * time.Minute)
+	postJob(r, "key-1", `{}`)
+	if jobs != 2 {
+		t.Errorf("chave expirada deveria criar outro job, obtido %d", jobs)
+	}
+}
+
+func TestIdempotencyFailedRequestCanBeRetried(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewIdempotencyMiddleware(IdempotencyConfig{})
+	calls := 0
+	r := gin.New()
+	r.POST("/reports", m.Handle(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "falha"})
+			return
+		}
+		c.String(http.StatusOK, "relatório "+strconv.Itoa(calls))
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("esperado 500, obtido %d", w.Code)
+	}
+	if w := send(); w.Code != http.StatusOK || w.Body.String() != "relatório 2" {
+		t.Fatalf("retry após erro deveria executar de novo: %d %q", w.Code, w.Body.String())
+	}
+	if w := send(); w.Body.String() != "relatório 2" || calls != 2 {
+		t.Errorf("resultado bem-sucedido deveria ser repetido: %q, chamadas %d", w.Body.String(), calls)
+	}
+}
+
+func TestIdempotencyKeyInFlight(t *testing.T) {
+	m := NewIdempotencyMiddleware(IdempotencyConfig{})
+	key := "user-1\x00POST /jobs\x00key-1"
+	var fingerprint [32]byte
+	if state, _ := m.begin(key, fingerprint); state != idempotencyNew {
+		t.Fatalf("primeira chamada deveria ser nova, obtido %v", state)
+	}
+	if state, _ := m.begin(key, fingerprint); state != idempotencyInFlight {
+		t.Errorf("chave em processamento deveria ser reportada, obtido %v", state)
+	}
+}
+
+func TestIdempotencyStoredBodiesAreCapped(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Each response ({"id":N}) has 8 bytes: two fit in the total
+	m := NewIdempotencyMiddleware(IdempotencyConfig{MaxTotalBytes: 16})
+	m.now = func() time.Time { return now }
+
+	jobs := 0
+	r := idempotencyRouter(m, "user-1", &jobs)
+	for i := 1; i <= 3; i++ {
+		postJob(r, "key-"+strconv.Itoa(i), `{}`)
+		now = now.Add(time.Minute)
+	}
+	if m.total > 16 || len(m.entries) != 2 {
+		t.Fatalf("esperadas 2 respostas em até 16 bytes, obtidas %d em %d bytes", len(m.entries), m.total)
+	}
+
+	// The oldest response was dropped: its retry runs again, the others are replayed
+	postJob(r, "key-3", `{}`)
+	postJob(r, "key-1", `{}`)
+	if jobs != 4 {
+		t.Errorf("esperado 4 jobs (só key-1 repetida), obtido %d", jobs)
+	}
+
+	// Responses above MaxBodyBytes are not kept
+	small := NewIdempotencyMiddleware(IdempotencyConfig{MaxBodyBytes: 4})
+	jobs = 0
+	r = idempotencyRouter(small, "user-1", &jobs)
+	postJob(r, "key-1", `{}`)
+	postJob(r, "key-1", `{}`)
+	if jobs != 2 || small.total != 0 {
+		t.Errorf("resposta grande não deveria ser guardada: %d jobs, %d bytes", jobs, small.total)
+	}
+}
+
+func TestIdempotencyRequestBodyIsCapped(t *testing.T) {
+	jobs := 0
+	r := idempotencyRouter(NewIdempotencyMiddleware(IdempotencyConfig{MaxRequestBytes: 16}), "user-1", &jobs)
+
+	if w := postJob(r, "key-1", `{"mapping_id":"m1"}`); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("corpo com Content-Length acima do limite: status %d", w.Code)
+	}
+
+	// Without Content-Length the body is cut off while reading
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"mapping_id":"m1"}`))
+	req.ContentLength = -1
+	req.Header.Set(IdempotencyKeyHeader, "key-2")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("corpo sem Content-Length acima do limite: status %d", w.Code)
+	}
+
+	if w := postJob(r, "key-3", `{"id":"m1"}`); w.Code != http.StatusCreated {
+		t.Errorf("corpo dentro do limite: status %d", w.Code)
+	}
+	if jobs != 1 {
+		t.Errorf("esperado 1 job criado, obtido %d", jobs)
+	}
+}
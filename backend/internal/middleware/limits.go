@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLimits bounds the body size and processing time of a route
+type RequestLimits struct {
+	MaxBodyBytes int64         // Largest accepted body (0 = unlimited)
+	Timeout      time.Duration // Deadline set on the request context (0 = none)
+}
+
+// Limit applies limits to the routes it wraps. Bodies declared larger than MaxBodyBytes
+// are rejected with 413 before the handler runs; bodies of unknown length are cut off
+// at the limit, so reading past it fails. The deadline is set on the request context:
+// handlers that honor it return early, and if nothing was written yet the response
+// is a 504.
+func Limit(limits RequestLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limits.MaxBodyBytes > 0 && c.Request.Body != nil {
+			if c.Request.ContentLength > limits.MaxBodyBytes {
+				logger.Get(c.Request.Context()).Warn().
+					Int64("content_length", c.Request.ContentLength).
+					Int64("max_bytes", limits.MaxBodyBytes).
+					Msg("Corpo da requisição acima do limite")
+				AbortWithErrorJSON(c, http.StatusRequestEntityTooLarge, gin.H{
+					"success": false,
+					"error":   "Corpo da requisição muito grande",
+					"details": fmt.Sprintf("máximo de %d bytes", limits.MaxBodyBytes),
+				})
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBodyBytes)
+		}
+
+		if limits.Timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limits.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			logger.Get(ctx).Warn().Dur("timeout", limits.Timeout).Msg("Requisição excedeu o tempo limite")
+			AbortWithErrorJSON(c, http.StatusGatewayTimeout, gin.H{
+				"success": false,
+				"error":   "Tempo limite da requisição excedido",
+			})
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limitsRouter mounts a login route with strict limits and an upload route with generous ones
+func limitsRouter(calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	auth := r.Group("/api/auth")
+	auth.Use(Limit(RequestLimits{MaxBodyBytes: 1 << 10, Timeout: time.Second}))
+	auth.POST("/login", func(c *gin.Context) {
+		*calls++
+		var req struct {
+			Username string `json:"username"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"username": req.Username})
+	})
+
+	r.POST("/upload", Limit(RequestLimits{MaxBodyBytes: 1 << 20, Timeout: time.Minute}), func(c *gin.Context) {
+		*calls++
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"size": file.Size})
+	})
+	return r
+}
+
+func TestLimitRejectsLargeLoginBody(t *testing.T) {
+	calls := 0
+	r := limitsRouter(&calls)
+
+	body := `{"username":"` + strings.Repeat("a", 2<<10) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", w.Code, w.Body.String())
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times for an oversized body", calls)
+	}
+
+	// A normal login still goes through
+	req = httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"ana"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLimitCutsOffBodyOfUnknownLength(t *testing.T) {
+	calls := 0
+	r := limitsRouter(&calls)
+
+	body := `{"username":"` + strings.Repeat("a", 2<<10) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1 // chunked
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "too large") {
+		t.Errorf("expected the handler to fail reading past the limit, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLimitAllowsUploadWithinLimit(t *testing.T) {
+	calls := 0
+	r := limitsRouter(&calls)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "dados.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte("id task,valor\n"), 20000)) // ~280 KB, larger than the login limit
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"size":280000`) {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestLimitTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/slow", Limit(RequestLimits{Timeout: 20 * time.Millisecond}), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	r.GET("/fast", Limit(RequestLimits{Timeout: time.Second}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
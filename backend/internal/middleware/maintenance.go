@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaintenanceMessage is shown when maintenance is enabled without a message
+const DefaultMaintenanceMessage = "Sistema em manutenção. Consultas continuam disponíveis; alterações estão suspensas temporariamente."
+
+// maintenanceRefreshInterval is how long the state read from the store is reused, so
+// a switch made on another instance takes effect here within this interval
+const maintenanceRefreshInterval = 5 * time.Second
+
+// MaintenanceState is the current maintenance mode
+type MaintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// MaintenanceStore shares the maintenance mode between instances (implemented by *repository.MaintenanceRepository)
+type MaintenanceStore interface {
+	GetMaintenanceMode() (*repository.MaintenanceMode, error)
+	SetMaintenanceMode(mode repository.MaintenanceMode) error
+}
+
+// Maintenance is a switch that blocks writes (see BlockWrites) while operators run
+// migrations or handle incidents. The state is kept in memory until SetStore is called;
+// with a store, every instance follows the state saved there.
+type Maintenance struct {
+	state     MaintenanceState
+	store     MaintenanceStore
+	refreshed time.Time
+	mu        sync.RWMutex
+}
+
+// NewMaintenance creates a maintenance switch, optionally already enabled (e.g. from config)
+func NewMaintenance(enabled bool, message string) *Maintenance {
+	m := &Maintenance{}
+	m.Set(enabled, message)
+	return m
+}
+
+// SetStore keeps the maintenance mode in store, shared by every instance. The state
+// already set (e.g. from config) is kept only when it enables maintenance.
+func (m *Maintenance) SetStore(store MaintenanceStore) error {
+	m.mu.Lock()
+	m.store = store
+	m.refreshed = time.Time{}
+	state := m.state
+	m.mu.Unlock()
+
+	if !state.Enabled {
+		return nil
+	}
+	_, err := m.Set(true, state.Message)
+	return err
+}
+
+// Set enables or disables maintenance mode; an empty message uses DefaultMaintenanceMessage
+func (m *Maintenance) Set(enabled bool, message string) (MaintenanceState, error) {
+	current := m.State()
+
+	state := MaintenanceState{}
+	if enabled {
+		if message == "" {
+			message = DefaultMaintenanceMessage
+		}
+		since := time.Now()
+		if current.Enabled && current.Since != nil {
+			since = *current.Since
+		}
+		state = MaintenanceState{Enabled: true, Message: message, Since: &since}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store != nil {
+		err := m.store.SetMaintenanceMode(repository.MaintenanceMode{
+			Enabled: state.Enabled,
+			Message: state.Message,
+			Since:   state.Since,
+		})
+		if err != nil {
+			return current, err
+		}
+		m.refreshed = time.Now()
+	}
+	m.state = state
+	return state, nil
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *Maintenance) Enabled() bool {
+	return m.State().Enabled
+}
+
+// State returns the current maintenance mode. With a store, it is read again once
+// maintenanceRefreshInterval has passed; if the store fails, the last state is kept.
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	state, store, stale := m.state, m.store, time.Since(m.refreshed) > maintenanceRefreshInterval
+	m.mu.RUnlock()
+	if store == nil || !stale {
+		return state
+	}
+
+	mode, err := store.GetMaintenanceMode()
+	if err != nil {
+		logger.Global().Warn().Err(err).Msg("Erro ao ler modo manutenção; mantendo o último estado")
+		return state
+	}
+	state = MaintenanceState{Enabled: mode.Enabled, Message: mode.Message, Since: mode.Since}
+
+	m.mu.Lock()
+	m.state = state
+	m.refreshed = time.Now()
+	m.mu.Unlock()
+	return state
+}
+
+// BlockWrites rejects POST, PUT, PATCH and DELETE requests with 503 while maintenance
+// mode is on; GET, HEAD and OPTIONS always pass. Add it to the groups of routes that
+// change data; exempt lists the routes (as registered, e.g. "/api/web/admin/maintenance")
+// that don't change data or must keep working, like turning maintenance off.
+func (m *Maintenance) BlockWrites(exempt ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		allowed[path] = true
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if allowed[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		state := m.State()
+		if !state.Enabled {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", "300")
+		AbortWithErrorJSON(c, http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   state.Message,
+			"code":    "MAINTENANCE",
+		})
+	}
+}
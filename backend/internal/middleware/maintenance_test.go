@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func maintenanceRouter(m *Maintenance) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+	writes := m.BlockWrites()
+	r.GET("/health", ok)
+	r.GET("/jobs", writes, ok)
+	r.POST("/jobs", writes, ok)
+	r.DELETE("/mapping/1", writes, ok)
+	r.PUT("/mapping/1", writes, ok)
+	return r
+}
+
+func serve(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestMaintenanceBlocksWrites(t *testing.T) {
+	m := NewMaintenance(true, "")
+	r := maintenanceRouter(m)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/jobs"},
+		{http.MethodPut, "/mapping/1"},
+		{http.MethodDelete, "/mapping/1"},
+	} {
+		w := serve(r, tc.method, tc.path)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tc.method, tc.path, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s %s: missing Retry-After", tc.method, tc.path)
+		}
+	}
+
+	for _, path := range []string{"/jobs", "/health"} {
+		if w := serve(r, http.MethodGet, path); w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200 during maintenance, got %d", path, w.Code)
+		}
+	}
+
+	// Turning it off lets writes through again
+	m.Set(false, "")
+	if w := serve(r, http.MethodPost, "/jobs"); w.Code != http.StatusOK {
+		t.Errorf("POST /jobs after maintenance: expected 200, got %d", w.Code)
+	}
+}
+
+func TestMaintenanceState(t *testing.T) {
+	m := NewMaintenance(false, "")
+	if m.Enabled() || m.State().Since != nil {
+		t.Fatalf("expected maintenance off, got %+v", m.State())
+	}
+
+	first, _ := m.Set(true, "Migração do banco até 22h")
+	if !first.Enabled || first.Message != "Migração do banco até 22h" || first.Since == nil {
+		t.Fatalf("unexpected state: %+v", first)
+	}
+
+	// Changing the message keeps the start time
+	second, _ := m.Set(true, "")
+	if second.Message != DefaultMaintenanceMessage || !second.Since.Equal(*first.Since) {
+		t.Errorf("unexpected state after update: %+v", second)
+	}
+}
+
+func TestMaintenanceExemptRoutes(t *testing.T) {
+	m := NewMaintenance(true, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.BlockWrites("/admin/maintenance", "/mapping/validate"))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+	r.POST("/admin/maintenance", ok)
+	r.POST("/mapping/validate", ok)
+	r.POST("/admin/import", ok)
+	r.POST("/user/password", ok)
+
+	for path, want := range map[string]int{
+		"/admin/maintenance": http.StatusOK,
+		"/mapping/validate":  http.StatusOK,
+		"/admin/import":      http.StatusServiceUnavailable,
+		"/user/password":     http.StatusServiceUnavailable,
+	} {
+		if w := serve(r, http.MethodPost, path); w.Code != want {
+			t.Errorf("POST %s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+// memoryMaintenanceStore stands in for the database shared by the instances
+type memoryMaintenanceStore struct {
+	mu   sync.Mutex
+	mode repository.MaintenanceMode
+	err  error
+}
+
+func (s *memoryMaintenanceStore) GetMaintenanceMode() (*repository.MaintenanceMode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	mode := s.mode
+	return &mode, nil
+}
+
+func (s *memoryMaintenanceStore) SetMaintenanceMode(mode repository.MaintenanceMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.mode = mode
+	return nil
+}
+
+func TestMaintenanceSharedThroughStore(t *testing.T) {
+	store := &memoryMaintenanceStore{}
+
+	// The instance started with maintenance on saves it for the others
+	first := NewMaintenance(true, "Migração")
+	if err := first.SetStore(store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	if !store.mode.Enabled || store.mode.Message != "Migração" || store.mode.Since == nil {
+		t.Fatalf("state not saved: %+v", store.mode)
+	}
+
+	// An instance started with maintenance off follows the saved state
+	second := NewMaintenance(false, "")
+	if err := second.SetStore(store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	if state := second.State(); !state.Enabled || state.Message != "Migração" {
+		t.Fatalf("expected the saved state, got %+v", state)
+	}
+
+	// Turning it off on one instance reaches the other once its state is refreshed
+	if _, err := second.Set(false, ""); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !first.Enabled() {
+		t.Errorf("expected the cached state before the refresh interval")
+	}
+	first.mu.Lock()
+	first.refreshed = time.Now().Add(-2 * maintenanceRefreshInterval)
+	first.mu.Unlock()
+	if first.Enabled() {
+		t.Errorf("expected maintenance off after the refresh")
+	}
+
+	// A store failure keeps the last state and fails the switch
+	store.err = errors.New("banco indisponível")
+	if _, err := first.Set(true, ""); err == nil {
+		t.Errorf("expected the store error")
+	}
+	first.mu.Lock()
+	first.refreshed = time.Time{}
+	first.mu.Unlock()
+	if first.Enabled() {
+		t.Errorf("expected the last state while the store fails")
+	}
+}
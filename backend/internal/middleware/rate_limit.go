@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig contains configuration for per-user request throttling
+type RateLimitConfig struct {
+	RequestsPerMinute int           // Sustained rate per user (<= 0 = unlimited)
+	Burst             int           // Requests allowed at once after idling (default: RequestsPerMinute/6, at least 1)
+	IdleTTL           time.Duration // Buckets unused this long are dropped (default: 10 minutes)
+}
+
+// userBucket is the token bucket of one user or API key
+type userBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter throttles requests with one token bucket per authenticated user, so a
+// single user (or a leaked session) can't starve the others on a shared deployment
+type RateLimiter struct {
+	config    RateLimitConfig
+	buckets   map[string]*userBucket
+	lastPrune time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewRateLimiter creates a new per-user rate limiter
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	// Set defaults
+	if config.Burst <= 0 {
+		config.Burst = config.RequestsPerMinute / 6
+		if config.Burst < 1 {
+			config.Burst = 1
+		}
+	}
+	if config.IdleTTL == 0 {
+		config.IdleTTL = 10 * time.Minute
+	}
+
+	return &RateLimiter{
+		config:  config,
+		buckets: make(map[string]*userBucket),
+		now:     time.Now,
+	}
+}
+
+// rateLimitKey identifies who a request counts against: the session user, else the
+// API key (hashed, so tokens aren't kept in memory), else the client IP
+func rateLimitKey(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(authHeader)))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// reserve takes a token from key's bucket; when none is available it returns how
+// long until one is, and takes nothing
+func (m *RateLimiter) reserve(key string) (bool, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.pruneLocked(now)
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		limit := rate.Limit(float64(m.config.RequestsPerMinute) / 60)
+		bucket = &userBucket{limiter: rate.NewLimiter(limit, m.config.Burst)}
+		m.buckets[key] = bucket
+	}
+	bucket.lastSeen = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// pruneLocked removes idle buckets, at most once a minute. A dropped bucket comes back
+// full, which is what it would have refilled to anyway after IdleTTL.
+func (m *RateLimiter) pruneLocked(now time.Time) {
+	if now.Sub(m.lastPrune) < time.Minute {
+		return
+	}
+	m.lastPrune = now
+	for key, bucket := range m.buckets {
+		if now.Sub(bucket.lastSeen) >= m.config.IdleTTL {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// Handle returns the middleware function. It must run after authentication, so the
+// user is known; routes outside the groups it's added to (health, metrics) aren't limited.
+// Throttled requests get 429 with Retry-After in seconds.
+func (m *RateLimiter) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.config.RequestsPerMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		key := rateLimitKey(c)
+		allowed, retryAfter := m.reserve(key)
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			logger.Get(c.Request.Context()).Warn().
+				Str("rate_limit_key", key).
+				Int("retry_after_seconds", seconds).
+				Msg("Limite de requisições por usuário excedido")
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			AbortWithErrorJSON(c, http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   "Muitas requisições",
+				"details": fmt.Sprintf("tente novamente em %d segundos", seconds),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
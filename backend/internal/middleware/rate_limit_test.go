@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitRouter mounts the limiter after a fake authentication that takes the user from a header
+func rateLimitRouter(m *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/jobs", func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	}, m.Handle(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	return r
+}
+
+func getJobs(r *gin.Engine, userID, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitThrottlesOnlyTheNoisyUser(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	m := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 60, Burst: 3})
+	m.now = func() time.Time { return now }
+	r := rateLimitRouter(m)
+
+	for i := 0; i < 3; i++ {
+		if w := getJobs(r, "user-1", ""); w.Code != http.StatusOK {
+			t.Fatalf("requisição %d dentro do burst: esperado 200, obtido %d", i+1, w.Code)
+		}
+	}
+
+	w := getJobs(r, "user-1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("esperado 429 acima do limite, obtido %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, esperado \"1\" (1 requisição por segundo)", w.Header().Get("Retry-After"))
+	}
+
+	// Outro usuário tem o próprio bucket
+	if w := getJobs(r, "user-2", ""); w.Code != http.StatusOK {
+		t.Errorf("outro usuário não deveria ser limitado, obtido %d", w.Code)
+	}
+
+	// Requisições rejeitadas não consomem tokens: após a espera, volta a passar
+	now = now.Add(time.Second)
+	if w := getJobs(r, "user-1", ""); w.Code != http.StatusOK {
+		t.Errorf("esperado 200 após Retry-After, obtido %d", w.Code)
+	}
+	if w := getJobs(r, "user-1", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("esperado 429 sem novos tokens, obtido %d", w.Code)
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	m := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 60, Burst: 1})
+	m.now = func() time.Time { return now }
+	r := rateLimitRouter(m)
+
+	if w := getJobs(r, "", "Bearer token-a"); w.Code != http.StatusOK {
+		t.Fatalf("esperado 200, obtido %d", w.Code)
+	}
+	if w := getJobs(r, "", "Bearer token-a"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("mesma chave de API: esperado 429, obtido %d", w.Code)
+	}
+	if w := getJobs(r, "", "Bearer token-b"); w.Code != http.StatusOK {
+		t.Errorf("outra chave de API não deveria ser limitada, obtido %d", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	r := rateLimitRouter(NewRateLimiter(RateLimitConfig{}))
+	for i := 0; i < 20; i++ {
+		if w := getJobs(r, "user-1", ""); w.Code != http.StatusOK {
+			t.Fatalf("sem limite configurado: esperado 200, obtido %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitPrunesIdleBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	m := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 60, IdleTTL: 5 * time.Minute})
+	m.now = func() time.Time { return now }
+	r := rateLimitRouter(m)
+
+	getJobs(r, "user-1", "")
+	now = now.Add(10 * time.Minute)
+	getJobs(r, "user-2", "")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.buckets["user:user-1"]; ok || len(m.buckets) != 1 {
+		t.Errorf("bucket ocioso deveria ser removido, restam %d", len(m.buckets))
+	}
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -15,16 +17,23 @@ const (
 	HeaderTraceID = "X-Trace-ID"
 	// HeaderSpanID é o header HTTP para span ID
 	HeaderSpanID = "X-Span-ID"
+	// ContextRequestID é a chave do request ID no contexto do gin
+	ContextRequestID = "request_id"
 )
 
-// RequestID adiciona request_id único a cada requisição
+// validRequestID limita o ID aceito do cliente: ele é repetido em headers, logs e respostas
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID adiciona request_id único a cada requisição. O ID volta no header
+// X-Request-ID de toda resposta e, pelas respostas montadas com ErrorJSON, no campo
+// request_id dos erros, para o cliente correlacionar uma falha com os logs do servidor.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Usa ID do header se existir, senão gera novo (8 chars)
+		// Usa ID do header se for válido, senão gera novo (8 chars)
 		requestID := c.GetHeader(HeaderRequestID)
-		if requestID == "" {
+		if !validRequestID.MatchString(requestID) {
 			requestID = uuid.New().String()[:8]
 		}
 
@@ -38,6 +47,7 @@ func RequestID() gin.HandlerFunc {
 		ctx := logger.WithRequestID(c.Request.Context(), requestID)
 		ctx = logger.WithTraceID(ctx, traceID)
 		c.Request = c.Request.WithContext(ctx)
+		c.Set(ContextRequestID, requestID)
 		c.Header(HeaderRequestID, requestID)
 		c.Header(HeaderTraceID, traceID)
 
@@ -77,6 +87,44 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
+// ErrorJSON responde um erro em JSON com o request_id da requisição no corpo
+func ErrorJSON(c *gin.Context, status int, body interface{}) {
+	c.JSON(status, withRequestID(c, body))
+}
+
+// AbortWithErrorJSON interrompe a requisição com um erro em JSON, como ErrorJSON
+func AbortWithErrorJSON(c *gin.Context, status int, body interface{}) {
+	c.AbortWithStatusJSON(status, withRequestID(c, body))
+}
+
+// withRequestID preenche o request_id de model.ErrorResponse e gin.H que ainda não o
+// tenham; outros corpos são devolvidos sem alteração
+func withRequestID(c *gin.Context, body interface{}) interface{} {
+	requestID := c.GetString(ContextRequestID)
+	if requestID == "" {
+		return body
+	}
+
+	switch b := body.(type) {
+	case model.ErrorResponse:
+		if b.RequestID == "" {
+			b.RequestID = requestID
+		}
+		return b
+	case gin.H:
+		if _, ok := b["request_id"]; ok {
+			return b
+		}
+		withID := make(gin.H, len(b)+1)
+		for k, v := range b {
+			withID[k] = v
+		}
+		withID["request_id"] = requestID
+		return withID
+	}
+	return body
+}
+
 // EnhancedRequestLogging adiciona logging detalhado para operações críticas
 func EnhancedRequestLogging() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func requestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		ErrorJSON(c, http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "Requisição inválida"})
+	})
+	r.GET("/empty", func(c *gin.Context) {
+		ErrorJSON(c, http.StatusConflict, gin.H{})
+	})
+	return r
+}
+
+func TestRequestIDOnSuccessAndErrorResponses(t *testing.T) {
+	r := requestIDRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusOK || w.Header().Get(HeaderRequestID) == "" {
+		t.Fatalf("expected a generated request ID on success, got %d %q", w.Code, w.Header().Get(HeaderRequestID))
+	}
+	if strings.Contains(w.Body.String(), "request_id") {
+		t.Errorf("success body should be untouched: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	id := w.Header().Get(HeaderRequestID)
+	if w.Code != http.StatusBadRequest || id == "" {
+		t.Fatalf("expected a request ID on error, got %d %q", w.Code, id)
+	}
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if resp.RequestID != id || resp.Error != "Requisição inválida" {
+		t.Errorf("error body = %+v, expected request_id %q", resp, id)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	if want := `{"request_id":"` + w.Header().Get(HeaderRequestID) + `"}`; w.Body.String() != want {
+		t.Errorf("body = %s, expected %s", w.Body.String(), want)
+	}
+
+	// Unknown routes are errors too
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get(HeaderRequestID) == "" {
+		t.Errorf("expected a request ID on 404, got %d %q", w.Code, w.Header().Get(HeaderRequestID))
+	}
+}
+
+func TestRequestIDFromClient(t *testing.T) {
+	r := requestIDRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(HeaderRequestID, "support-1234")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); got != "support-1234" {
+		t.Errorf("expected the client's ID to be echoed, got %q", got)
+	}
+	if !strings.Contains(w.Body.String(), `"request_id":"support-1234"`) {
+		t.Errorf("error body without the client's ID: %s", w.Body.String())
+	}
+
+	// IDs that could inject into logs or headers are replaced
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(HeaderRequestID, `bad id"\n`+strings.Repeat("x", 100))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); got == "" || strings.Contains(got, "bad") {
+		t.Errorf("expected an invalid ID to be replaced, got %q", got)
+	}
+}
+
+func TestRequestIDInContextAndAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/denied", func(c *gin.Context) {
+		c.Header("X-Context-ID", c.GetString(ContextRequestID))
+		AbortWithErrorJSON(c, http.StatusForbidden, gin.H{"success": false, "error": "Acesso negado"})
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		// Error bodies that are not built with ErrorJSON are sent as written, even in parts
+		c.Status(http.StatusBadGateway)
+		c.Header("Content-Type", "application/json")
+		c.Writer.WriteString(`{"error":`)
+		c.Writer.WriteString(`"falha"}`)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/denied", nil))
+	id := w.Header().Get(HeaderRequestID)
+	if w.Code != http.StatusForbidden || id == "" || w.Header().Get("X-Context-ID") != id {
+		t.Fatalf("expected the request ID in the gin context, got %d %q %q", w.Code, id, w.Header().Get("X-Context-ID"))
+	}
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.RequestID != id {
+		t.Errorf("aborted body = %s, expected request_id %q", w.Body.String(), id)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if w.Body.String() != `{"error":"falha"}` {
+		t.Errorf("body = %s, expected it untouched", w.Body.String())
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RoleAdmin can manage users and other administrative resources
+	RoleAdmin = "admin"
+	// RoleUser is the default role of new users
+	RoleUser = "user"
+)
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleUser
+}
+
+// RequireRole allows the request only when the authenticated caller has one of roles.
+// Must run after RequireAuth or BearerAuth, which set the caller's role in the context.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		AbortWithErrorJSON(c, http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Permissão insuficiente para esta operação",
+			"code":    "FORBIDDEN",
+		})
+	}
+}
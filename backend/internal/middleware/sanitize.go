@@ -80,14 +80,20 @@ func SanitizeFilename(filename string) string {
 
 // ValidateFilePath validates that a file path is safe and within allowed directories
 func ValidateFilePath(path string, allowedDir string) bool {
-	// Clean the path
-	cleanPath := filepath.Clean(path)
-
-	// Check for path traversal
-	if strings.Contains(cleanPath, "..") {
+	if path == "" || strings.ContainsRune(path, '\x00') {
 		return false
 	}
 
+	// Check for path traversal (before cleaning, which would resolve "a/../b")
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return false
+		}
+	}
+
+	// Clean the path
+	cleanPath := filepath.Clean(path)
+
 	// If allowedDir is specified, ensure path is within it
 	if allowedDir != "" {
 		absPath, err := filepath.Abs(cleanPath)
@@ -98,7 +104,9 @@ func ValidateFilePath(path string, allowedDir string) bool {
 		if err != nil {
 			return false
 		}
-		if !strings.HasPrefix(absPath, absAllowed) {
+		// Compare whole path elements: /tmp/data must not admit /tmp/data2
+		rel, err := filepath.Rel(absAllowed, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
 			return false
 		}
 	}
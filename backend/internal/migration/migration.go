@@ -2,8 +2,11 @@ package migration
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"text/tabwriter"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
@@ -18,12 +21,30 @@ type Migration struct {
 	Down    string
 }
 
+var (
+	// ErrUnknownMigration indica uma versão aplicada no banco que não existe no código
+	ErrUnknownMigration = errors.New("migração aplicada não encontrada no código")
+	// ErrIrreversibleMigration indica uma migração sem SQL de Down
+	ErrIrreversibleMigration = errors.New("migração não pode ser revertida")
+)
+
 // Migrator gerencia as migrações do banco de dados
 type Migrator struct {
 	db         *sql.DB
 	migrations []Migration
 }
 
+// MigrationStatus indica se uma migração já foi aplicada no banco
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Unknown marca versões aplicadas no banco que não existem no código
+	// (ex.: banco migrado por uma versão mais nova da aplicação)
+	Unknown bool `json:"unknown,omitempty"`
+}
+
 // NewMigrator cria um novo migrator
 func NewMigrator(db *sql.DB) *Migrator {
 	return &Migrator{
@@ -49,34 +70,229 @@ func (m *Migrator) Run() error {
 
 	log.Info().Int("current_version", currentVersion).Msg("Versão atual do banco de dados")
 
-	// Ordena migrações por versão
+	// Executa migrações pendentes
+	for _, migration := range m.pendingMigrations(currentVersion) {
+		log.Info().
+			Int("version", migration.Version).
+			Str("name", migration.Name).
+			Msg("Executando migração")
+
+		if err := m.runMigration(migration); err != nil {
+			return fmt.Errorf("erro ao executar migração %d (%s): %w", 
+				migration.Version, migration.Name, err)
+		}
+
+		log.Info().
+			Int("version", migration.Version).
+			Str("name", migration.Name).
+			Msg("Migração executada com sucesso")
+	}
+
+	return nil
+}
+
+// pendingMigrations retorna, em ordem, as migrações que Run executaria
+// (as posteriores à versão atual do banco)
+func (m *Migrator) pendingMigrations(currentVersion int) []Migration {
 	sort.Slice(m.migrations, func(i, j int) bool {
 		return m.migrations[i].Version < m.migrations[j].Version
 	})
 
-	// Executa migrações pendentes
+	var pending []Migration
 	for _, migration := range m.migrations {
 		if migration.Version > currentVersion {
-			log.Info().
-				Int("version", migration.Version).
-				Str("name", migration.Name).
-				Msg("Executando migração")
-
-			if err := m.runMigration(migration); err != nil {
-				return fmt.Errorf("erro ao executar migração %d (%s): %w", 
-					migration.Version, migration.Name, err)
-			}
-
-			log.Info().
-				Int("version", migration.Version).
-				Str("name", migration.Name).
-				Msg("Migração executada com sucesso")
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// Plan registra no log as migrações que Run executaria, sem executá-las
+func (m *Migrator) Plan() ([]Migration, error) {
+	log := logger.Global()
+
+	if err := m.createMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("erro ao criar tabela de migrações: %w", err)
+	}
+
+	currentVersion, err := m.getCurrentVersion()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter versão atual: %w", err)
+	}
+
+	pending := m.pendingMigrations(currentVersion)
+	if len(pending) == 0 {
+		log.Info().Int("current_version", currentVersion).Msg("Nenhuma migração pendente")
+		return nil, nil
+	}
+
+	for _, migration := range pending {
+		log.Info().
+			Int("version", migration.Version).
+			Str("name", migration.Name).
+			Msg("Migração pendente")
+	}
+	log.Info().
+		Int("current_version", currentVersion).
+		Int("pending", len(pending)).
+		Msg("Migrações a executar")
+
+	return pending, nil
+}
+
+// Status retorna cada migração conhecida com seu estado no banco, em ordem de versão
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.createMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("erro ao criar tabela de migrações: %w", err)
+	}
+
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter migrações aplicadas: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]*time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("erro ao obter migrações aplicadas: %w", err)
+		}
+		applied[version] = nil
+		if appliedAt.Valid {
+			applied[version] = &appliedAt.Time
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao obter migrações aplicadas: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+		delete(applied, migration.Version)
+	}
+	for version, appliedAt := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Applied:   true,
+			AppliedAt: appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// WriteStatus escreve o resultado de Status como uma tabela de texto
+func WriteStatus(w io.Writer, statuses []MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSÃO\tNOME\tESTADO\tAPLICADA EM")
+	for _, status := range statuses {
+		state := "pendente"
+		if status.Applied {
+			state = "aplicada"
+		}
+		if status.Unknown {
+			state = "desconhecida"
+		}
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return tw.Flush()
+}
+
+// Rollback reverte as últimas steps migrações aplicadas, da mais recente para a mais
+// antiga, executando o Down de cada uma. As versões revertidas são lidas da tabela
+// schema_migrations, então o resultado não depende da ordem da lista em código.
+func (m *Migrator) Rollback(steps int) error {
+	log := logger.Global()
+
+	if steps <= 0 {
+		return fmt.Errorf("número de migrações a reverter deve ser positivo: %d", steps)
+	}
+
+	if err := m.createMigrationsTable(); err != nil {
+		return fmt.Errorf("erro ao criar tabela de migrações: %w", err)
+	}
+
+	applied, err := m.AppliedVersions()
+	if err != nil {
+		return fmt.Errorf("erro ao obter migrações aplicadas: %w", err)
+	}
+
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	// Valida todas antes de reverter qualquer uma
+	var pending []Migration
+	for i := len(applied) - 1; i >= 0 && len(pending) < steps; i-- {
+		migration, ok := byVersion[applied[i]]
+		if !ok {
+			return fmt.Errorf("%w: versão %d", ErrUnknownMigration, applied[i])
+		}
+		if migration.Down == "" {
+			return fmt.Errorf("%w: %d (%s)", ErrIrreversibleMigration, migration.Version, migration.Name)
+		}
+		pending = append(pending, migration)
+	}
+
+	if len(pending) < steps {
+		log.Warn().
+			Int("requested", steps).
+			Int("applied", len(pending)).
+			Msg("Menos migrações aplicadas do que o solicitado para reverter")
+	}
+
+	for _, migration := range pending {
+		log.Info().
+			Int("version", migration.Version).
+			Str("name", migration.Name).
+			Msg("Revertendo migração")
+
+		if err := m.revertMigration(migration); err != nil {
+			return fmt.Errorf("erro ao reverter migração %d (%s): %w",
+				migration.Version, migration.Name, err)
 		}
 	}
 
 	return nil
 }
 
+// AppliedVersions retorna as versões aplicadas no banco, em ordem crescente
+func (m *Migrator) AppliedVersions() ([]int, error) {
+	rows, err := m.db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
 // createMigrationsTable cria a tabela de controle de migrações
 func (m *Migrator) createMigrationsTable() error {
 	query := `
@@ -122,4 +338,23 @@ func (m *Migrator) runMigration(migration Migration) error {
 	}
 
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// revertMigration executa o Down de uma migração e remove seu registro
+func (m *Migrator) revertMigration(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.Down); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
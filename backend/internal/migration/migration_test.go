@@ -0,0 +1,219 @@
+package migration
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/database"
+)
+
+// setupTestDB cria um banco vazio (sem migrações) e o remove ao final do teste
+func setupTestDB(t *testing.T) *sql.DB {
+	dbConfig := database.Config{
+		Host:     getEnvOrDefault("TEST_DB_HOST", "127.0.0.1"),
+		Port:     getEnvOrDefault("TEST_DB_PORT", "5432"),
+		User:     getEnvOrDefault("TEST_DB_USER", "postgres"),
+		Password: getEnvOrDefault("TEST_DB_PASSWORD", "postgres"),
+		DBName:   fmt.Sprintf("test_migration_%d", time.Now().UnixNano()),
+		SSLMode:  "disable",
+	}
+
+	adminConfig := dbConfig
+	adminConfig.DBName = "postgres"
+
+	adminDB, err := database.Connect(adminConfig)
+	if err != nil {
+		t.Skipf("Pulando teste: não foi possível conectar ao PostgreSQL: %v", err)
+	}
+	defer adminDB.Close()
+
+	if _, err := adminDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbConfig.DBName)); err != nil {
+		t.Fatalf("Erro ao criar banco de teste: %v", err)
+	}
+
+	testDB, err := database.Connect(dbConfig)
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao banco de teste: %v", err)
+	}
+
+	t.Cleanup(func() {
+		testDB.Close()
+		adminDB, _ := database.Connect(adminConfig)
+		if adminDB != nil {
+			adminDB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbConfig.DBName))
+			adminDB.Close()
+		}
+	})
+
+	return testDB
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func columnExists(t *testing.T, db *sql.DB, table, column string) bool {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns WHERE table_name = $1 AND column_name = $2
+		)`, table, column).Scan(&exists)
+	if err != nil {
+		t.Fatalf("Erro ao consultar colunas: %v", err)
+	}
+	return exists
+}
+
+func TestMigrationsAreReversible(t *testing.T) {
+	migrations := getAllMigrations()
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migração %q tem versão %d, esperado %d", migration.Name, migration.Version, i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migração %d (%s) sem Up ou Down", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestRollbackRevertsSchema(t *testing.T) {
+	db := setupTestDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !columnExists(t, db, "users", "role") {
+		t.Fatal("coluna users.role deveria existir após as migrações")
+	}
+
+	latest := len(getAllMigrations())
+	if err := migrator.Rollback(1); err != nil {
+		t.Fatalf("Rollback(1): %v", err)
+	}
+	if columnExists(t, db, "users", "role") {
+		t.Error("coluna users.role deveria ter sido removida")
+	}
+	if !columnExists(t, db, "users", "username") {
+		t.Error("tabela users não deveria ter sido afetada")
+	}
+	applied, err := migrator.AppliedVersions()
+	if err != nil {
+		t.Fatalf("AppliedVersions: %v", err)
+	}
+	if len(applied) != latest-1 || applied[len(applied)-1] != latest-1 {
+		t.Errorf("versões aplicadas após rollback = %v", applied)
+	}
+
+	// Reaplicar só executa a migração revertida
+	if err := migrator.Run(); err != nil {
+		t.Fatalf("Run após rollback: %v", err)
+	}
+	if !columnExists(t, db, "users", "role") {
+		t.Error("coluna users.role deveria ter sido recriada")
+	}
+
+	// Reverter tudo deixa apenas a tabela de controle
+	if err := migrator.Rollback(latest); err != nil {
+		t.Fatalf("Rollback(%d): %v", latest, err)
+	}
+	if columnExists(t, db, "users", "username") {
+		t.Error("tabela users deveria ter sido removida")
+	}
+	if applied, _ := migrator.AppliedVersions(); len(applied) != 0 {
+		t.Errorf("versões aplicadas após reverter tudo = %v", applied)
+	}
+}
+
+func TestRollbackRejectsUnknownVersion(t *testing.T) {
+	db := setupTestDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version) VALUES (9999)"); err != nil {
+		t.Fatalf("Erro ao registrar versão: %v", err)
+	}
+
+	if err := migrator.Rollback(1); !errors.Is(err, ErrUnknownMigration) {
+		t.Errorf("esperado ErrUnknownMigration, obtido %v", err)
+	}
+	if !columnExists(t, db, "users", "role") {
+		t.Error("nenhuma migração deveria ter sido revertida")
+	}
+}
+
+func TestStatusOfPartiallyMigratedDatabase(t *testing.T) {
+	db := setupTestDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := migrator.Rollback(2); err != nil {
+		t.Fatalf("Rollback(2): %v", err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	latest := len(getAllMigrations())
+	if len(statuses) != latest {
+		t.Fatalf("esperadas %d migrações, obtidas %d", latest, len(statuses))
+	}
+	for _, status := range statuses {
+		wantApplied := status.Version <= latest-2
+		if status.Applied != wantApplied || (status.AppliedAt != nil) != wantApplied || status.Unknown {
+			t.Errorf("migração %d (%s): %+v", status.Version, status.Name, status)
+		}
+	}
+
+	pending, err := migrator.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Version != latest-1 || pending[1].Version != latest {
+		t.Errorf("Plan = %+v", pending)
+	}
+	// Plan não executa nada
+	if statuses, _ := migrator.Status(); statuses[latest-1].Applied {
+		t.Error("Plan não deveria aplicar migrações")
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	appliedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := WriteStatus(&buf, []MigrationStatus{
+		{Version: 1, Name: "create_initial_tables", Applied: true, AppliedAt: &appliedAt},
+		{Version: 2, Name: "add_indexes"},
+		{Version: 3, Applied: true, Unknown: true},
+	})
+	if err != nil {
+		t.Fatalf("WriteStatus: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("esperadas 4 linhas, obtidas %q", buf.String())
+	}
+	for i, want := range []string{"aplicada", "pendente", "desconhecida"} {
+		if !strings.Contains(lines[i+1], want) {
+			t.Errorf("linha %d = %q, esperado %q", i+1, lines[i+1], want)
+		}
+	}
+	if !strings.Contains(lines[1], "2024-03-01T12:00:00Z") {
+		t.Errorf("data de aplicação ausente: %q", lines[1])
+	}
+}
@@ -191,5 +191,252 @@ func getAllMigrations() []Migration {
 				DROP INDEX IF EXISTS idx_spaces_workspace_id;
 			`,
 		},
+		{
+			Version: 6,
+			Name:    "add_job_queue_options",
+			Up: `
+				-- Opções de processamento do job (valores padrão por coluna, etc.)
+				ALTER TABLE job_queue ADD COLUMN options JSONB NOT NULL DEFAULT '{}'::jsonb;
+			`,
+			Down: `
+				ALTER TABLE job_queue DROP COLUMN IF EXISTS options;
+			`,
+		},
+		{
+			Version: 7,
+			Name:    "add_job_queue_priority",
+			Up: `
+				-- Prioridade do job: 0 = low, 1 = normal, 2 = high
+				ALTER TABLE job_queue ADD COLUMN priority SMALLINT NOT NULL DEFAULT 1;
+				CREATE INDEX idx_job_queue_pending_priority ON job_queue(status, priority DESC, created_at);
+			`,
+			Down: `
+				DROP INDEX IF EXISTS idx_job_queue_pending_priority;
+				ALTER TABLE job_queue DROP COLUMN IF EXISTS priority;
+			`,
+		},
+		{
+			Version: 8,
+			Name:    "add_job_queue_scheduled_at",
+			Up: `
+				-- Horário a partir do qual o job pode ser processado (NULL = imediato)
+				ALTER TABLE job_queue ADD COLUMN scheduled_at TIMESTAMP NULL;
+				CREATE INDEX idx_job_queue_scheduled_at ON job_queue(scheduled_at) WHERE status = 'pending';
+			`,
+			Down: `
+				DROP INDEX IF EXISTS idx_job_queue_scheduled_at;
+				ALTER TABLE job_queue DROP COLUMN IF EXISTS scheduled_at;
+			`,
+		},
+		{
+			Version: 9,
+			Name:    "create_user_tokens",
+			Up: `
+				-- Tokens adicionais do ClickUp por usuário (um por conta/workspace).
+				-- O token padrão continua em user_config.clickup_token_encrypted
+				CREATE TABLE user_tokens (
+					id SERIAL PRIMARY KEY,
+					user_id VARCHAR(100) NOT NULL,
+					label VARCHAR(100) NOT NULL,
+					token_encrypted TEXT NOT NULL,
+					created_at TIMESTAMP DEFAULT NOW(),
+					updated_at TIMESTAMP DEFAULT NOW(),
+					CONSTRAINT uq_user_tokens_user_label UNIQUE (user_id, label)
+				);
+			`,
+			Down: `
+				DROP TABLE IF EXISTS user_tokens;
+			`,
+		},
+		{
+			Version: 10,
+			Name:    "add_users_role",
+			Up: `
+				-- Papel do usuário: admin pode gerenciar usuários, user é o padrão
+				ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+				ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('admin', 'user'));
+			`,
+			Down: `
+				ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+				ALTER TABLE users DROP COLUMN IF EXISTS role;
+			`,
+		},
+		{
+			Version: 11,
+			Name:    "add_custom_fields_workspace",
+			Up: `
+				-- Workspace onde o campo foi visto na sincronização; permite remover
+				-- campos excluídos no ClickUp sem afetar outros workspaces
+				ALTER TABLE custom_fields ADD COLUMN workspace_id VARCHAR(50);
+				CREATE INDEX idx_custom_fields_workspace ON custom_fields(workspace_id);
+			`,
+			Down: `
+				DROP INDEX IF EXISTS idx_custom_fields_workspace;
+				ALTER TABLE custom_fields DROP COLUMN IF EXISTS workspace_id;
+			`,
+		},
+		{
+			Version: 12,
+			Name:    "add_user_config_sync_webhook",
+			Up: `
+				-- URL chamada ao fim de cada sincronização de metadados do usuário
+				ALTER TABLE user_config ADD COLUMN sync_webhook_url TEXT;
+			`,
+			Down: `
+				ALTER TABLE user_config DROP COLUMN IF EXISTS sync_webhook_url;
+			`,
+		},
+		{
+			Version: 13,
+			Name:    "create_list_custom_fields",
+			Up: `
+				-- Listas em que cada campo aparece: campos com o mesmo nome em listas
+				-- diferentes ficam distinguíveis e o mapeamento pode fixar a lista
+				CREATE TABLE list_custom_fields (
+					list_id VARCHAR(50) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+					field_id VARCHAR(50) NOT NULL REFERENCES custom_fields(id) ON DELETE CASCADE,
+					PRIMARY KEY (list_id, field_id)
+				);
+				CREATE INDEX idx_list_custom_fields_field ON list_custom_fields(field_id);
+			`,
+			Down: `
+				DROP TABLE IF EXISTS list_custom_fields;
+			`,
+		},
+		{
+			Version: 14,
+			Name:    "add_lists_space_id",
+			Up: `
+				-- Space de cada lista: listas fora de folders ficam com folder_id nulo
+				ALTER TABLE lists ADD COLUMN space_id VARCHAR(50) REFERENCES spaces(id) ON DELETE CASCADE;
+				UPDATE lists SET space_id = folders.space_id FROM folders WHERE folders.id = lists.folder_id;
+				CREATE INDEX idx_lists_space_id ON lists(space_id);
+			`,
+			Down: `
+				DELETE FROM lists WHERE folder_id IS NULL;
+				DROP INDEX IF EXISTS idx_lists_space_id;
+				ALTER TABLE lists DROP COLUMN IF EXISTS space_id;
+			`,
+		},
+		{
+			Version: 15,
+			Name:    "lowercase_usernames",
+			Up: `
+				-- Usernames passam a ser únicos sem diferenciar maiúsculas e são gravados em
+				-- minúsculas. Contas que só diferem na caixa ("Admin" e "admin") precisam ser
+				-- resolvidas antes: a migração falha sem alterar nada.
+				DO $$
+				BEGIN
+					IF EXISTS (SELECT 1 FROM users GROUP BY LOWER(username) HAVING COUNT(*) > 1) THEN
+						RAISE EXCEPTION 'existem usuários que só diferem em maiúsculas/minúsculas; renomeie ou remova as contas repetidas antes de migrar';
+					END IF;
+				END $$;
+
+				-- Os dados do usuário são chaveados pelo username (user_id)
+				CREATE TEMP TABLE renamed_users ON COMMIT DROP AS
+					SELECT username AS old_name, LOWER(username) AS new_name
+					FROM users WHERE username <> LOWER(username);
+				UPDATE job_queue SET user_id = r.new_name FROM renamed_users r WHERE user_id = r.old_name;
+				UPDATE operation_history SET user_id = r.new_name FROM renamed_users r WHERE user_id = r.old_name;
+				UPDATE user_config SET user_id = r.new_name FROM renamed_users r WHERE user_id = r.old_name;
+				UPDATE user_tokens SET user_id = r.new_name FROM renamed_users r WHERE user_id = r.old_name;
+				UPDATE users SET username = r.new_name, updated_at = NOW() FROM renamed_users r WHERE username = r.old_name;
+
+				CREATE UNIQUE INDEX uq_users_username_lower ON users(LOWER(username));
+			`,
+			Down: `
+				-- Irreversível em parte: só remove o índice. Os usernames continuam em
+				-- minúsculas, pois a caixa original não foi guardada.
+				DROP INDEX IF EXISTS uq_users_username_lower;
+			`,
+		},
+		{
+			Version: 16,
+			Name:    "add_user_config_report_defaults",
+			Up: `
+				-- Padrões de subtasks/include_closed dos relatórios do usuário;
+				-- NULL = usa o padrão do sistema (false)
+				ALTER TABLE user_config ADD COLUMN default_subtasks BOOLEAN;
+				ALTER TABLE user_config ADD COLUMN default_include_closed BOOLEAN;
+			`,
+			Down: `
+				ALTER TABLE user_config DROP COLUMN IF EXISTS default_include_closed;
+				ALTER TABLE user_config DROP COLUMN IF EXISTS default_subtasks;
+			`,
+		},
+		{
+			Version: 17,
+			Name:    "create_job_templates",
+			Up: `
+				-- Configurações completas de job (mapeamento, token, filtros e opções)
+				-- reutilizadas em importações recorrentes com arquivos novos
+				CREATE TABLE job_templates (
+					id SERIAL PRIMARY KEY,
+					user_id VARCHAR(100) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					list_id VARCHAR(50),
+					mappings JSONB NOT NULL,
+					options JSONB NOT NULL DEFAULT '{}',
+					created_at TIMESTAMP DEFAULT NOW(),
+					updated_at TIMESTAMP DEFAULT NOW(),
+					CONSTRAINT uq_job_templates_user_name UNIQUE (user_id, name)
+				);
+			`,
+			Down: `
+				DROP TABLE IF EXISTS job_templates;
+			`,
+		},
+		{
+			Version: 18,
+			Name:    "add_job_queue_dry_run_result",
+			Up: `
+				-- Resumo de jobs dry-run (valores de/para): não gravam nada no ClickUp e
+				-- por isso não entram no histórico de atualizações
+				ALTER TABLE job_queue ADD COLUMN dry_run_result JSONB;
+			`,
+			Down: `
+				ALTER TABLE job_queue DROP COLUMN IF EXISTS dry_run_result;
+			`,
+		},
+		{
+			Version: 19,
+			Name:    "create_saved_mappings",
+			Up: `
+				-- Mapeamentos de colunas salvos para criar jobs; a assinatura das colunas
+				-- do arquivo permite reutilizá-los com planilhas reenviadas
+				CREATE TABLE saved_mappings (
+					id VARCHAR(50) PRIMARY KEY,
+					user_id VARCHAR(100) NOT NULL,
+					file_path TEXT NOT NULL,
+					title VARCHAR(255) NOT NULL,
+					mappings JSONB NOT NULL,
+					validated BOOLEAN NOT NULL DEFAULT FALSE,
+					normalize_columns BOOLEAN NOT NULL DEFAULT FALSE,
+					signature VARCHAR(64),
+					created_at TIMESTAMP DEFAULT NOW()
+				);
+				CREATE INDEX idx_saved_mappings_user_signature ON saved_mappings(user_id, signature);
+			`,
+			Down: `
+				DROP TABLE IF EXISTS saved_mappings;
+			`,
+		},
+		{
+			Version: 20,
+			Name:    "create_maintenance_mode",
+			Up: `
+				-- Modo manutenção compartilhado por todas as instâncias (uma única linha)
+				CREATE TABLE maintenance_mode (
+					id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+					enabled BOOLEAN NOT NULL DEFAULT FALSE,
+					message TEXT NOT NULL DEFAULT '',
+					since TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT NOW()
+				);
+			`,
+			Down: `
+				DROP TABLE IF EXISTS maintenance_mode;
+			`,
+		},
 	}
-}
\ No newline at end of file
+}
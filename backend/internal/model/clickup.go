@@ -25,6 +25,44 @@ type Task struct {
 	Folder       FolderInfo    `json:"folder"`
 	Space        SpaceInfo     `json:"space"`
 	URL          string        `json:"url"`
+
+	// SourceListID lista consultada em que a task foi encontrada, preenchida na coleta
+	// (uma task pode aparecer em várias listas; List é a lista principal dela)
+	SourceListID string `json:"source_list_id,omitempty"`
+
+	// Preenchidos apenas quando o relatório pede include_activity
+	CommentCount    *int `json:"comment_count,omitempty"`
+	AttachmentCount *int `json:"attachment_count,omitempty"`
+}
+
+// TaskDetailResponse representa a resposta da API para uma tarefa individual
+// (apenas os campos usados além da listagem)
+type TaskDetailResponse struct {
+	ID          string       `json:"id"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment representa os metadados de um anexo da tarefa
+type Attachment struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Extension string `json:"extension"`
+	Size      int64  `json:"size"`
+	Date      string `json:"date"`
+	URL       string `json:"url"`
+}
+
+// CommentResponse representa a resposta da API para comentários de uma tarefa
+type CommentResponse struct {
+	Comments []Comment `json:"comments"`
+}
+
+// Comment representa um comentário da tarefa
+type Comment struct {
+	ID          string   `json:"id"`
+	CommentText string   `json:"comment_text"`
+	User        UserInfo `json:"user"`
+	Date        string   `json:"date"`
 }
 
 // Status representa o status de uma tarefa
@@ -95,6 +133,7 @@ type TypeConfig struct {
 type Option struct {
 	ID         string `json:"id"`
 	Name       string `json:"name"`
+	Label      string `json:"label"` // campos do tipo labels usam "label" no lugar de "name"
 	Color      string `json:"color"`
 	Orderindex int    `json:"orderindex"`
 }
@@ -132,6 +171,19 @@ type Workspace struct {
 	Name string `json:"name"`
 }
 
+// WorkspaceMembersResponse representa a resposta de /team com os membros de cada workspace
+type WorkspaceMembersResponse struct {
+	Teams []struct {
+		ID      string            `json:"id"`
+		Members []WorkspaceMember `json:"members"`
+	} `json:"teams"`
+}
+
+// WorkspaceMember representa um membro de um workspace
+type WorkspaceMember struct {
+	User UserInfo `json:"user"`
+}
+
 // SpaceResponse representa a resposta da API para spaces
 type SpaceResponse struct {
 	Spaces []Space `json:"spaces"`
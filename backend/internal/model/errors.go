@@ -17,4 +17,7 @@ var (
 
 	// ErrInvalidResponse indica resposta inválida da API
 	ErrInvalidResponse = errors.New("resposta inválida da API do ClickUp")
+
+	// ErrIncompleteFetch indica que algumas listas não foram coletadas por completo
+	ErrIncompleteFetch = errors.New("coleta de tarefas incompleta")
 )
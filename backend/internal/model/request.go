@@ -1,12 +1,109 @@
 package model
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // ReportRequest representa o payload de entrada para geração de relatório
 type ReportRequest struct {
-	ListIDs       []string `json:"list_ids" binding:"required,min=1"`
-	Fields        []string `json:"fields" binding:"required,min=1"`
-	WebhookURL    string   `json:"webhook_url" binding:"omitempty,url"`
-	Subtasks      *bool    `json:"subtasks,omitempty"`       // nil = false (default: apenas main tasks)
-	IncludeClosed *bool    `json:"include_closed,omitempty"` // nil = false (default: apenas tasks abertas)
+	ListIDs       []string     `json:"list_ids" binding:"required,min=1"`
+	Fields        []string     `json:"fields" binding:"required_without=Aggregate"`
+	WebhookURL    string       `json:"webhook_url" binding:"omitempty,url"`
+	Subtasks      *bool        `json:"subtasks,omitempty"`       // nil = padrão do usuário ou false (apenas main tasks)
+	IncludeClosed *bool        `json:"include_closed,omitempty"` // nil = padrão do usuário ou false (apenas tasks abertas)
+	Filters       *TaskFilters `json:"filters,omitempty"`        // nil = sem filtros
+	// IncludeActivity busca contagem de comentários e anexos de cada task
+	// (uma requisição extra por task); nil = false
+	IncludeActivity *bool `json:"include_activity,omitempty"`
+	// TokenLabel escolhe o token salvo do usuário em /api/web/reports (vazio = token padrão)
+	TokenLabel string `json:"token_label,omitempty"`
+	// Aggregate gera um resumo agrupado por um campo em vez de uma linha por task
+	// (fields é ignorado); nil = relatório padrão
+	Aggregate *ReportAggregate `json:"aggregate,omitempty"`
+}
+
+// Operações de agregação suportadas
+const (
+	AggregateCount = "count"
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+)
+
+// ReportAggregate agrupa as tasks pelo valor de um campo (nativo ou personalizado)
+// e calcula as métricas de cada grupo
+type ReportAggregate struct {
+	GroupBy string            `json:"group_by"`
+	Metrics []AggregateMetric `json:"metrics,omitempty"` // vazio = apenas contagem
+}
+
+// AggregateMetric é uma métrica calculada por grupo. sum e avg usam um campo numérico
+// (number, currency, comment_count...); tasks sem valor numérico não entram na soma/média.
+type AggregateMetric struct {
+	Op    string `json:"op"`
+	Field string `json:"field,omitempty"`
+}
+
+// Validate verifica o campo de agrupamento e as métricas
+func (a *ReportAggregate) Validate() error {
+	if strings.TrimSpace(a.GroupBy) == "" {
+		return errors.New("aggregate.group_by é obrigatório")
+	}
+	for _, metric := range a.Metrics {
+		switch metric.Op {
+		case AggregateCount:
+		case AggregateSum, AggregateAvg:
+			if strings.TrimSpace(metric.Field) == "" {
+				return fmt.Errorf("aggregate: métrica %s exige field", metric.Op)
+			}
+		default:
+			return fmt.Errorf("aggregate: operação %q inválida (use count, sum ou avg)", metric.Op)
+		}
+	}
+	return nil
+}
+
+// TaskFilters são repassados como query params na busca de tarefas do ClickUp.
+//
+// Combinações suportadas pelo ClickUp:
+//   - valores dentro do mesmo filtro são combinados com OU (qualquer status da lista);
+//   - filtros diferentes são combinados com E (status E responsável E data);
+//   - status fechados só retornam com include_closed=true;
+//   - assignees usa IDs numéricos de usuário, não nomes ou e-mails;
+//   - date_updated_gt/lt são Unix timestamps em milissegundos e podem ser usados
+//     isoladamente ou juntos para um intervalo (gt deve ser menor que lt).
+type TaskFilters struct {
+	Statuses      []string `json:"statuses,omitempty"`
+	Assignees     []string `json:"assignees,omitempty"`
+	DateUpdatedGt int64    `json:"date_updated_gt,omitempty"`
+	DateUpdatedLt int64    `json:"date_updated_lt,omitempty"`
+}
+
+// Validate verifica se os filtros podem ser enviados ao ClickUp
+func (f *TaskFilters) Validate() error {
+	for _, status := range f.Statuses {
+		if strings.TrimSpace(status) == "" {
+			return errors.New("statuses não pode conter valores vazios")
+		}
+	}
+
+	for _, assignee := range f.Assignees {
+		if _, err := strconv.ParseInt(assignee, 10, 64); err != nil {
+			return errors.New("assignees deve conter IDs numéricos de usuário")
+		}
+	}
+
+	if f.DateUpdatedGt < 0 || f.DateUpdatedLt < 0 {
+		return errors.New("date_updated_gt/lt devem ser timestamps positivos em milissegundos")
+	}
+
+	if f.DateUpdatedGt > 0 && f.DateUpdatedLt > 0 && f.DateUpdatedGt >= f.DateUpdatedLt {
+		return errors.New("date_updated_gt deve ser menor que date_updated_lt")
+	}
+
+	return nil
 }
 
 // Response representa a resposta padrão da API
@@ -29,6 +126,8 @@ type ErrorResponse struct {
 	Success bool   `json:"success"`
 	Error   string `json:"error"`
 	Details string `json:"details,omitempty"`
+	// RequestID é preenchido pelo middleware RequestID quando vazio (mesmo valor do header X-Request-ID)
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // WebhookPayload representa o payload enviado para o webhook
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BackupBatchSize quantidade de linhas lidas por consulta ao exportar
+const BackupBatchSize = 500
+
+// StreamJobs percorre os jobs de um usuário (todos quando userID é vazio) em ordem
+// de id, chamando fn para cada um. Lê em lotes por keyset (id > último lido), sem
+// manter a tabela inteira em memória nem uma transação longa aberta.
+func (r *QueueRepository) StreamJobs(userID string, fn func(UpdateJob) error) error {
+	query := `SELECT ` + jobColumns + `
+		FROM job_queue
+		WHERE id > $1 AND ($2 = '' OR user_id = $2)
+		ORDER BY id
+		LIMIT $3`
+
+	lastID := 0
+	for {
+		rows, err := r.db.Query(query, lastID, userID, BackupBatchSize)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar jobs: %w", err)
+		}
+
+		var batch []UpdateJob
+		for rows.Next() {
+			job, err := scanJob(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("erro ao escanear job: %w", err)
+			}
+			batch = append(batch, *job)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("erro ao iterar jobs: %w", err)
+		}
+
+		for _, job := range batch {
+			if err := fn(job); err != nil {
+				return err
+			}
+			lastID = job.ID
+		}
+		if len(batch) < BackupBatchSize {
+			return nil
+		}
+	}
+}
+
+// StreamOperationHistory percorre o histórico de um usuário (todos quando userID é
+// vazio) em ordem de id, em lotes por keyset como StreamJobs
+func (r *QueueRepository) StreamOperationHistory(userID string, fn func(OperationHistory) error) error {
+	query := `
+		SELECT id, user_id, operation_type, title, status, details, created_at
+		FROM operation_history
+		WHERE id > $1 AND ($2 = '' OR user_id = $2)
+		ORDER BY id
+		LIMIT $3`
+
+	lastID := 0
+	for {
+		rows, err := r.db.Query(query, lastID, userID, BackupBatchSize)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar histórico: %w", err)
+		}
+
+		var batch []OperationHistory
+		for rows.Next() {
+			var h OperationHistory
+			var detailsJSON []byte
+			if err := rows.Scan(&h.ID, &h.UserID, &h.OperationType, &h.Title, &h.Status,
+				&detailsJSON, &h.CreatedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("erro ao escanear histórico: %w", err)
+			}
+			if len(detailsJSON) > 0 {
+				if err := json.Unmarshal(detailsJSON, &h.Details); err != nil {
+					rows.Close()
+					return fmt.Errorf("erro ao deserializar details: %w", err)
+				}
+			}
+			batch = append(batch, h)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("erro ao iterar histórico: %w", err)
+		}
+
+		for _, h := range batch {
+			if err := fn(h); err != nil {
+				return err
+			}
+			lastID = h.ID
+		}
+		if len(batch) < BackupBatchSize {
+			return nil
+		}
+	}
+}
+
+// ImportJob insere um job exportado mantendo id e datas. Um job com o mesmo id já
+// existente é mantido como está, para que importar o mesmo arquivo de novo não
+// duplique nada; retorna false nesse caso.
+func (r *QueueRepository) ImportJob(job UpdateJob) (bool, error) {
+	mappingJSON, err := json.Marshal(job.Mapping)
+	if err != nil {
+		return false, fmt.Errorf("erro ao serializar mapping: %w", err)
+	}
+	errorDetailsJSON, err := json.Marshal(job.ErrorDetails)
+	if err != nil {
+		return false, fmt.Errorf("erro ao serializar error details: %w", err)
+	}
+	optionsJSON, err := json.Marshal(job.Options)
+	if err != nil {
+		return false, fmt.Errorf("erro ao serializar options: %w", err)
+	}
+
+	query := `
+		INSERT INTO job_queue (id, user_id, title, status, file_path, mapping, total_rows,
+			processed_rows, success_count, error_count, error_details, options, priority,
+			scheduled_at, created_at, updated_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(query, job.ID, job.UserID, job.Title, job.Status, job.FilePath,
+		mappingJSON, job.TotalRows, job.ProcessedRows, job.SuccessCount, job.ErrorCount,
+		errorDetailsJSON, optionsJSON, job.Priority, utcOrNil(job.ScheduledAt),
+		job.CreatedAt.UTC(), job.UpdatedAt.UTC(), utcOrNil(job.CompletedAt))
+	if err != nil {
+		return false, fmt.Errorf("erro ao importar job %d: %w", job.ID, err)
+	}
+	inserted, _ := result.RowsAffected()
+	return inserted > 0, nil
+}
+
+// ImportOperationHistory insere uma entrada exportada do histórico mantendo id e
+// data; como ImportJob, ignora ids já existentes e retorna false nesse caso
+func (r *QueueRepository) ImportOperationHistory(history OperationHistory) (bool, error) {
+	detailsJSON, err := json.Marshal(history.Details)
+	if err != nil {
+		return false, fmt.Errorf("erro ao serializar details: %w", err)
+	}
+
+	query := `
+		INSERT INTO operation_history (id, user_id, operation_type, title, status, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(query, history.ID, history.UserID, history.OperationType,
+		history.Title, history.Status, detailsJSON, history.CreatedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("erro ao importar histórico %d: %w", history.ID, err)
+	}
+	inserted, _ := result.RowsAffected()
+	return inserted > 0, nil
+}
+
+// ResetImportSequences avança as sequências de id de job_queue e operation_history
+// para depois do maior id, evitando conflitos nos próximos INSERTs após uma importação
+func (r *QueueRepository) ResetImportSequences() error {
+	for _, table := range []string{"job_queue", "operation_history"} {
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'),
+			COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)`, table)
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("erro ao ajustar sequência de %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
 )
 
+// ErrUserTokenNotFound indica um rótulo de token inexistente para o usuário
+var ErrUserTokenNotFound = errors.New("token não encontrado")
+
 // ConfigRepository gerencia configurações de usuário no banco
 type ConfigRepository struct {
 	db *sql.DB
@@ -23,6 +27,9 @@ type UserConfig struct {
 	UserID                string    `json:"user_id" db:"user_id"`
 	ClickUpTokenEncrypted string    `json:"clickup_token_encrypted" db:"clickup_token_encrypted"`
 	RateLimitPerMinute    int       `json:"rate_limit_per_minute" db:"rate_limit_per_minute"`
+	SyncWebhookURL        string    `json:"sync_webhook_url,omitempty" db:"sync_webhook_url"`             // vazio = sem webhook
+	DefaultSubtasks       *bool     `json:"default_subtasks,omitempty" db:"default_subtasks"`             // padrão dos relatórios; nil = sistema
+	DefaultIncludeClosed  *bool     `json:"default_include_closed,omitempty" db:"default_include_closed"` // padrão dos relatórios; nil = sistema
 	CreatedAt             time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -53,16 +60,22 @@ func (r *ConfigRepository) UpsertUserConfig(config UserConfig) error {
 // GetUserConfig obtém configurações de um usuário
 func (r *ConfigRepository) GetUserConfig(userID string) (*UserConfig, error) {
 	query := `
-		SELECT user_id, clickup_token_encrypted, rate_limit_per_minute, created_at, updated_at
+		SELECT user_id, clickup_token_encrypted, rate_limit_per_minute,
+			COALESCE(sync_webhook_url, ''), default_subtasks, default_include_closed,
+			created_at, updated_at
 		FROM user_config 
 		WHERE user_id = $1
 	`
 	
 	var config UserConfig
+	var defaultSubtasks, defaultIncludeClosed sql.NullBool
 	err := r.db.QueryRow(query, userID).Scan(
 		&config.UserID, 
 		&config.ClickUpTokenEncrypted, 
 		&config.RateLimitPerMinute, 
+		&config.SyncWebhookURL,
+		&defaultSubtasks,
+		&defaultIncludeClosed,
 		&config.CreatedAt, 
 		&config.UpdatedAt,
 	)
@@ -74,6 +87,13 @@ func (r *ConfigRepository) GetUserConfig(userID string) (*UserConfig, error) {
 		return nil, fmt.Errorf("erro ao buscar configuração do usuário: %w", err)
 	}
 	
+	if defaultSubtasks.Valid {
+		config.DefaultSubtasks = &defaultSubtasks.Bool
+	}
+	if defaultIncludeClosed.Valid {
+		config.DefaultIncludeClosed = &defaultIncludeClosed.Bool
+	}
+	
 	return &config, nil
 }
 
@@ -126,6 +146,60 @@ func (r *ConfigRepository) UpdateRateLimit(userID string, rateLimit int) error {
 	return nil
 }
 
+// UpdateSyncWebhookURL define a URL notificada ao fim da sincronização (vazio remove)
+func (r *ConfigRepository) UpdateSyncWebhookURL(userID, webhookURL string) error {
+	log := logger.Global()
+	
+	query := `
+		INSERT INTO user_config (user_id, rate_limit_per_minute, sync_webhook_url, created_at, updated_at)
+		VALUES ($1, 2000, NULLIF($2, ''), NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			sync_webhook_url = EXCLUDED.sync_webhook_url,
+			updated_at = NOW()
+	`
+	
+	_, err := r.db.Exec(query, userID, webhookURL)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Erro ao atualizar webhook de sincronização")
+		return fmt.Errorf("erro ao atualizar webhook de sincronização: %w", err)
+	}
+	
+	log.Info().Str("user_id", userID).Bool("enabled", webhookURL != "").Msg("Webhook de sincronização atualizado")
+	return nil
+}
+
+// UpdateReportDefaults define os padrões de subtasks e include_closed dos relatórios.
+// nil mantém o valor atual.
+func (r *ConfigRepository) UpdateReportDefaults(userID string, subtasks, includeClosed *bool) error {
+	log := logger.Global()
+	
+	query := `
+		INSERT INTO user_config (user_id, rate_limit_per_minute, default_subtasks, default_include_closed, created_at, updated_at)
+		VALUES ($1, 2000, $2, $3, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			default_subtasks = COALESCE(EXCLUDED.default_subtasks, user_config.default_subtasks),
+			default_include_closed = COALESCE(EXCLUDED.default_include_closed, user_config.default_include_closed),
+			updated_at = NOW()
+	`
+	
+	_, err := r.db.Exec(query, userID, nullBool(subtasks), nullBool(includeClosed))
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Erro ao atualizar padrões de relatório")
+		return fmt.Errorf("erro ao atualizar padrões de relatório: %w", err)
+	}
+	
+	log.Info().Str("user_id", userID).Msg("Padrões de relatório atualizados")
+	return nil
+}
+
+// nullBool converte um booleano opcional para gravação (nil = NULL)
+func nullBool(value *bool) sql.NullBool {
+	if value == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *value, Valid: true}
+}
+
 // DeleteUserConfig remove configurações de um usuário
 func (r *ConfigRepository) DeleteUserConfig(userID string) error {
 	log := logger.Global()
@@ -145,4 +219,172 @@ func (r *ConfigRepository) DeleteUserConfig(userID string) error {
 	
 	log.Info().Str("user_id", userID).Msg("Configuração do usuário removida")
 	return nil
-}
\ No newline at end of file
+}
+
+// UserToken é um token adicional do ClickUp de um usuário, identificado por um rótulo
+type UserToken struct {
+	UserID         string    `json:"user_id" db:"user_id"`
+	Label          string    `json:"label" db:"label"`
+	TokenEncrypted string    `json:"-" db:"token_encrypted"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpsertUserToken insere ou substitui o token de um rótulo do usuário
+func (r *ConfigRepository) UpsertUserToken(userID, label, encryptedToken string) error {
+	query := `
+		INSERT INTO user_tokens (user_id, label, token_encrypted, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (user_id, label) DO UPDATE SET
+			token_encrypted = EXCLUDED.token_encrypted,
+			updated_at = NOW()
+	`
+
+	if _, err := r.db.Exec(query, userID, label, encryptedToken); err != nil {
+		return fmt.Errorf("erro ao salvar token do ClickUp: %w", err)
+	}
+
+	logger.Global().Info().Str("user_id", userID).Str("label", label).Msg("Token do ClickUp salvo")
+	return nil
+}
+
+// GetUserTokenByLabel obtém o token de um rótulo do usuário (nil se não existir)
+func (r *ConfigRepository) GetUserTokenByLabel(userID, label string) (*UserToken, error) {
+	query := `
+		SELECT user_id, label, token_encrypted, created_at, updated_at
+		FROM user_tokens
+		WHERE user_id = $1 AND label = $2
+	`
+
+	var token UserToken
+	err := r.db.QueryRow(query, userID, label).Scan(
+		&token.UserID,
+		&token.Label,
+		&token.TokenEncrypted,
+		&token.CreatedAt,
+		&token.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar token do ClickUp: %w", err)
+	}
+
+	return &token, nil
+}
+
+// ListUserTokens lista os tokens adicionais do usuário, ordenados por rótulo
+func (r *ConfigRepository) ListUserTokens(userID string) ([]UserToken, error) {
+	query := `
+		SELECT user_id, label, token_encrypted, created_at, updated_at
+		FROM user_tokens
+		WHERE user_id = $1
+		ORDER BY label
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tokens do ClickUp: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []UserToken
+	for rows.Next() {
+		var token UserToken
+		if err := rows.Scan(&token.UserID, &token.Label, &token.TokenEncrypted, &token.CreatedAt, &token.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler token do ClickUp: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar tokens do ClickUp: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeleteUserToken remove o token de um rótulo do usuário
+func (r *ConfigRepository) DeleteUserToken(userID, label string) error {
+	result, err := r.db.Exec("DELETE FROM user_tokens WHERE user_id = $1 AND label = $2", userID, label)
+	if err != nil {
+		return fmt.Errorf("erro ao remover token do ClickUp: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserTokenNotFound
+	}
+
+	logger.Global().Info().Str("user_id", userID).Str("label", label).Msg("Token do ClickUp removido")
+	return nil
+}
+
+// RewriteEncryptedTokens aplica rewrite a todos os tokens cifrados (user_config e user_tokens)
+// em uma única transação e retorna quantos valores mudaram. Qualquer erro desfaz tudo.
+func (r *ConfigRepository) RewriteEncryptedTokens(rewrite func(encryptedToken string) (string, error)) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	tables := []struct {
+		selectQuery string
+		updateQuery string
+	}{
+		{
+			selectQuery: "SELECT user_id, clickup_token_encrypted FROM user_config WHERE clickup_token_encrypted <> '' FOR UPDATE",
+			updateQuery: "UPDATE user_config SET clickup_token_encrypted = $2 WHERE user_id = $1",
+		},
+		{
+			selectQuery: "SELECT id, token_encrypted FROM user_tokens FOR UPDATE",
+			updateQuery: "UPDATE user_tokens SET token_encrypted = $2 WHERE id = $1",
+		},
+	}
+
+	changed := 0
+	for _, table := range tables {
+		rows, err := tx.Query(table.selectQuery)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao ler tokens: %w", err)
+		}
+
+		type storedToken struct {
+			id        string
+			encrypted string
+		}
+		var tokens []storedToken
+		for rows.Next() {
+			var token storedToken
+			if err := rows.Scan(&token.id, &token.encrypted); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("erro ao ler token: %w", err)
+			}
+			tokens = append(tokens, token)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("erro ao ler tokens: %w", err)
+		}
+
+		for _, token := range tokens {
+			rewritten, err := rewrite(token.encrypted)
+			if err != nil {
+				return 0, fmt.Errorf("erro ao converter token %s: %w", token.id, err)
+			}
+			if rewritten == token.encrypted {
+				continue
+			}
+			if _, err := tx.Exec(table.updateQuery, token.id, rewritten); err != nil {
+				return 0, fmt.Errorf("erro ao atualizar token %s: %w", token.id, err)
+			}
+			changed++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return changed, nil
+}
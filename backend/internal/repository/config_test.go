@@ -0,0 +1,55 @@
+package repository
+
+import "testing"
+
+func TestUpdateReportDefaults(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewConfigRepository(db)
+
+	// Sem configuração salva: padrões do sistema
+	if err := repo.UpdateRateLimit("user-1", 100); err != nil {
+		t.Fatalf("UpdateRateLimit: %v", err)
+	}
+	config, err := repo.GetUserConfig("user-1")
+	if err != nil {
+		t.Fatalf("GetUserConfig: %v", err)
+	}
+	if config.DefaultSubtasks != nil || config.DefaultIncludeClosed != nil {
+		t.Fatalf("padrões deveriam estar vazios: %v, %v", config.DefaultSubtasks, config.DefaultIncludeClosed)
+	}
+
+	yes, no := true, false
+	if err := repo.UpdateReportDefaults("user-1", &yes, &no); err != nil {
+		t.Fatalf("UpdateReportDefaults: %v", err)
+	}
+	// nil mantém o valor salvo
+	if err := repo.UpdateReportDefaults("user-1", nil, &yes); err != nil {
+		t.Fatalf("UpdateReportDefaults: %v", err)
+	}
+
+	config, err = repo.GetUserConfig("user-1")
+	if err != nil {
+		t.Fatalf("GetUserConfig: %v", err)
+	}
+	if config.DefaultSubtasks == nil || !*config.DefaultSubtasks {
+		t.Errorf("default_subtasks = %v, esperava true", config.DefaultSubtasks)
+	}
+	if config.DefaultIncludeClosed == nil || !*config.DefaultIncludeClosed {
+		t.Errorf("default_include_closed = %v, esperava true", config.DefaultIncludeClosed)
+	}
+	if config.RateLimitPerMinute != 100 {
+		t.Errorf("rate limit não deveria mudar: %d", config.RateLimitPerMinute)
+	}
+
+	// Usuário novo criado só com os padrões
+	if err := repo.UpdateReportDefaults("user-2", nil, &no); err != nil {
+		t.Fatalf("UpdateReportDefaults: %v", err)
+	}
+	config, err = repo.GetUserConfig("user-2")
+	if err != nil {
+		t.Fatalf("GetUserConfig: %v", err)
+	}
+	if config.DefaultSubtasks != nil || config.DefaultIncludeClosed == nil || *config.DefaultIncludeClosed {
+		t.Errorf("padrões inesperados: %v, %v", config.DefaultSubtasks, config.DefaultIncludeClosed)
+	}
+}
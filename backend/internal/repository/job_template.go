@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrJobTemplateNotFound indica um template inexistente ou de outro usuário
+	ErrJobTemplateNotFound = errors.New("template de job não encontrado")
+	// ErrJobTemplateNameTaken indica outro template do usuário com o mesmo nome
+	ErrJobTemplateNameTaken = errors.New("já existe um template com este nome")
+)
+
+// JobTemplate é a configuração completa de um job (lista, token, mapeamento, filtros
+// e opções) salva para ser executada novamente com outros arquivos
+type JobTemplate struct {
+	ID     int    `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	// ListID vincula os campos mapeados sem lista própria a esta lista (vazio = sem vínculo)
+	ListID string `json:"list_id,omitempty"`
+	// Mappings são as colunas mapeadas (service.ColumnMapping serializado)
+	Mappings  json.RawMessage    `json:"mappings"`
+	Options   JobTemplateOptions `json:"options"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// JobTemplateOptions são as opções de criação de job guardadas no template
+type JobTemplateOptions struct {
+	Locale              string     `json:"locale,omitempty"`
+	Timezone            string     `json:"timezone,omitempty"`
+	Priority            string     `json:"priority,omitempty"`
+	DuplicateResolution string     `json:"duplicate_resolution,omitempty"`
+	TokenLabel          string     `json:"token_label,omitempty"`
+	CustomTaskIDs       bool       `json:"custom_task_ids,omitempty"`
+	TeamID              string     `json:"team_id,omitempty"`
+	NormalizeColumns    bool       `json:"normalize_columns,omitempty"`
+	RowFilter           *RowFilter `json:"row_filter,omitempty"`
+	CommentOnUpdate     bool       `json:"comment_on_update,omitempty"`
+}
+
+// JobTemplateRepository gerencia os templates de job no banco
+type JobTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewJobTemplateRepository cria um novo repositório de templates de job
+func NewJobTemplateRepository(db *sql.DB) *JobTemplateRepository {
+	return &JobTemplateRepository{db: db}
+}
+
+// jobTemplateColumns lista as colunas lidas de job_templates, na ordem esperada por scanJobTemplate
+const jobTemplateColumns = `id, user_id, name, COALESCE(list_id, ''), mappings, options, created_at, updated_at`
+
+// scanJobTemplate lê um template e deserializa as opções
+func scanJobTemplate(scanner rowScanner) (*JobTemplate, error) {
+	var template JobTemplate
+	var mappingsJSON, optionsJSON []byte
+
+	err := scanner.Scan(&template.ID, &template.UserID, &template.Name, &template.ListID,
+		&mappingsJSON, &optionsJSON, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Mappings = json.RawMessage(mappingsJSON)
+	if len(optionsJSON) > 0 {
+		if err := json.Unmarshal(optionsJSON, &template.Options); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar opções do template: %w", err)
+		}
+	}
+
+	return &template, nil
+}
+
+// CreateJobTemplate salva um novo template; o nome é único por usuário
+func (r *JobTemplateRepository) CreateJobTemplate(template JobTemplate) (*JobTemplate, error) {
+	optionsJSON, err := json.Marshal(template.Options)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar opções do template: %w", err)
+	}
+
+	query := `
+		INSERT INTO job_templates (user_id, name, list_id, mappings, options, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW(), NOW())
+		RETURNING ` + jobTemplateColumns
+
+	created, err := scanJobTemplate(r.db.QueryRow(query, template.UserID, template.Name, template.ListID,
+		[]byte(template.Mappings), optionsJSON))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return nil, ErrJobTemplateNameTaken
+		}
+		return nil, fmt.Errorf("erro ao criar template de job: %w", err)
+	}
+
+	logger.Global().Info().Int("template_id", created.ID).Str("user_id", created.UserID).Msg("Template de job criado")
+	return created, nil
+}
+
+// UpdateJobTemplate substitui o conteúdo de um template do usuário
+func (r *JobTemplateRepository) UpdateJobTemplate(template JobTemplate) (*JobTemplate, error) {
+	optionsJSON, err := json.Marshal(template.Options)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar opções do template: %w", err)
+	}
+
+	query := `
+		UPDATE job_templates
+		SET name = $3, list_id = NULLIF($4, ''), mappings = $5, options = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + jobTemplateColumns
+
+	updated, err := scanJobTemplate(r.db.QueryRow(query, template.ID, template.UserID, template.Name,
+		template.ListID, []byte(template.Mappings), optionsJSON))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrJobTemplateNotFound
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return nil, ErrJobTemplateNameTaken
+		}
+		return nil, fmt.Errorf("erro ao atualizar template de job: %w", err)
+	}
+
+	return updated, nil
+}
+
+// GetJobTemplate obtém um template do usuário
+func (r *JobTemplateRepository) GetJobTemplate(id int, userID string) (*JobTemplate, error) {
+	query := `SELECT ` + jobTemplateColumns + ` FROM job_templates WHERE id = $1 AND user_id = $2`
+
+	template, err := scanJobTemplate(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrJobTemplateNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar template de job: %w", err)
+	}
+
+	return template, nil
+}
+
+// ListJobTemplates lista os templates do usuário, ordenados por nome
+func (r *JobTemplateRepository) ListJobTemplates(userID string) ([]JobTemplate, error) {
+	query := `SELECT ` + jobTemplateColumns + ` FROM job_templates WHERE user_id = $1 ORDER BY name`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar templates de job: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []JobTemplate
+	for rows.Next() {
+		template, err := scanJobTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler template de job: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar templates de job: %w", err)
+	}
+
+	return templates, nil
+}
+
+// DeleteJobTemplate remove um template do usuário
+func (r *JobTemplateRepository) DeleteJobTemplate(id int, userID string) error {
+	result, err := r.db.Exec("DELETE FROM job_templates WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover template de job: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrJobTemplateNotFound
+	}
+
+	logger.Global().Info().Int("template_id", id).Str("user_id", userID).Msg("Template de job removido")
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MaintenanceMode é o modo manutenção salvo no banco, compartilhado pelas instâncias
+type MaintenanceMode struct {
+	Enabled bool
+	Message string
+	Since   *time.Time // início da manutenção; nil quando desligada
+}
+
+// MaintenanceRepository gerencia o modo manutenção no banco
+type MaintenanceRepository struct {
+	db *sql.DB
+}
+
+// NewMaintenanceRepository cria um novo repositório do modo manutenção
+func NewMaintenanceRepository(db *sql.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// GetMaintenanceMode obtém o modo manutenção; sem registro, a manutenção está desligada
+func (r *MaintenanceRepository) GetMaintenanceMode() (*MaintenanceMode, error) {
+	var mode MaintenanceMode
+	var since sql.NullTime
+
+	err := r.db.QueryRow(`SELECT enabled, message, since FROM maintenance_mode WHERE id = 1`).
+		Scan(&mode.Enabled, &mode.Message, &since)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &MaintenanceMode{}, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar modo manutenção: %w", err)
+	}
+
+	if since.Valid {
+		mode.Since = &since.Time
+	}
+	return &mode, nil
+}
+
+// SetMaintenanceMode grava o modo manutenção
+func (r *MaintenanceRepository) SetMaintenanceMode(mode MaintenanceMode) error {
+	query := `
+		INSERT INTO maintenance_mode (id, enabled, message, since, updated_at)
+		VALUES (1, $1, $2, $3, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			message = EXCLUDED.message,
+			since = EXCLUDED.since,
+			updated_at = NOW()`
+
+	var since sql.NullTime
+	if mode.Since != nil {
+		since = sql.NullTime{Time: *mode.Since, Valid: true}
+	}
+
+	if _, err := r.db.Exec(query, mode.Enabled, mode.Message, since); err != nil {
+		return fmt.Errorf("erro ao salvar modo manutenção: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMaintenanceRepository(db)
+
+	// Sem registro, a manutenção está desligada
+	mode, err := repo.GetMaintenanceMode()
+	if err != nil {
+		t.Fatalf("GetMaintenanceMode: %v", err)
+	}
+	if mode.Enabled || mode.Since != nil {
+		t.Fatalf("esperado modo desligado, obtido %+v", mode)
+	}
+
+	since := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)
+	if err := repo.SetMaintenanceMode(MaintenanceMode{Enabled: true, Message: "Migração", Since: &since}); err != nil {
+		t.Fatalf("SetMaintenanceMode: %v", err)
+	}
+	mode, err = repo.GetMaintenanceMode()
+	if err != nil {
+		t.Fatalf("GetMaintenanceMode: %v", err)
+	}
+	if !mode.Enabled || mode.Message != "Migração" || mode.Since == nil || !mode.Since.Equal(since) {
+		t.Errorf("modo inesperado: %+v", mode)
+	}
+
+	// Desligar substitui a mesma linha
+	if err := repo.SetMaintenanceMode(MaintenanceMode{}); err != nil {
+		t.Fatalf("SetMaintenanceMode: %v", err)
+	}
+	mode, _ = repo.GetMaintenanceMode()
+	if mode.Enabled || mode.Message != "" || mode.Since != nil {
+		t.Errorf("esperado modo desligado, obtido %+v", mode)
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSavedMappingNotFound indica um mapeamento inexistente
+var ErrSavedMappingNotFound = errors.New("mapeamento não encontrado")
+
+// SavedMapping é um mapeamento de colunas salvo pelo usuário para criar jobs
+type SavedMapping struct {
+	ID       string
+	UserID   string
+	FilePath string
+	Title    string
+	// Mappings são as colunas mapeadas (service.ColumnMapping serializado)
+	Mappings         json.RawMessage
+	Validated        bool
+	NormalizeColumns bool
+	// Signature identifica o conjunto de colunas do arquivo mapeado (vazio = desconhecido)
+	Signature string
+	CreatedAt time.Time
+}
+
+// MappingRepository gerencia os mapeamentos salvos no banco
+type MappingRepository struct {
+	db *sql.DB
+}
+
+// NewMappingRepository cria um novo repositório de mapeamentos
+func NewMappingRepository(db *sql.DB) *MappingRepository {
+	return &MappingRepository{db: db}
+}
+
+// savedMappingColumns lista as colunas lidas de saved_mappings, na ordem esperada por scanSavedMapping
+const savedMappingColumns = `id, user_id, file_path, title, mappings, validated, normalize_columns, COALESCE(signature, ''), created_at`
+
+// scanSavedMapping lê um mapeamento salvo
+func scanSavedMapping(scanner rowScanner) (*SavedMapping, error) {
+	var m SavedMapping
+	var mappingsJSON []byte
+
+	err := scanner.Scan(&m.ID, &m.UserID, &m.FilePath, &m.Title, &mappingsJSON,
+		&m.Validated, &m.NormalizeColumns, &m.Signature, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Mappings = json.RawMessage(mappingsJSON)
+	return &m, nil
+}
+
+// SaveMapping grava um novo mapeamento
+func (r *MappingRepository) SaveMapping(m SavedMapping) error {
+	query := `
+		INSERT INTO saved_mappings (id, user_id, file_path, title, mappings, validated, normalize_columns, signature, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)`
+
+	_, err := r.db.Exec(query, m.ID, m.UserID, m.FilePath, m.Title, []byte(m.Mappings),
+		m.Validated, m.NormalizeColumns, m.Signature, m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar mapeamento: %w", err)
+	}
+
+	return nil
+}
+
+// GetMapping obtém um mapeamento pelo ID
+func (r *MappingRepository) GetMapping(id string) (*SavedMapping, error) {
+	query := `SELECT ` + savedMappingColumns + ` FROM saved_mappings WHERE id = $1`
+
+	m, err := scanSavedMapping(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSavedMappingNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar mapeamento: %w", err)
+	}
+
+	return m, nil
+}
+
+// ListMappings lista os mapeamentos do usuário, mais recentes primeiro; com
+// signature preenchida, apenas os de arquivos com as mesmas colunas
+func (r *MappingRepository) ListMappings(userID, signature string) ([]SavedMapping, error) {
+	query := `SELECT ` + savedMappingColumns + ` FROM saved_mappings
+		WHERE user_id = $1 AND ($2 = '' OR signature = $2)
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, userID, signature)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar mapeamentos: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []SavedMapping
+	for rows.Next() {
+		m, err := scanSavedMapping(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler mapeamento: %w", err)
+		}
+		mappings = append(mappings, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar mapeamentos: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// DeleteMapping remove um mapeamento
+func (r *MappingRepository) DeleteMapping(id string) error {
+	result, err := r.db.Exec("DELETE FROM saved_mappings WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("erro ao remover mapeamento: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrSavedMappingNotFound
+	}
+
+	return nil
+}
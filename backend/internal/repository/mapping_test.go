@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSavedMappingsBySignature(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMappingRepository(db)
+
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	for i, m := range []SavedMapping{
+		{ID: "map_1", UserID: "user-1", Title: "janeiro", Signature: "sig-a"},
+		{ID: "map_2", UserID: "user-1", Title: "fevereiro", Signature: "sig-a", Validated: true},
+		{ID: "map_3", UserID: "user-1", Title: "outro", Signature: "sig-b"},
+		{ID: "map_4", UserID: "user-2", Title: "de outro usuário", Signature: "sig-a"},
+	} {
+		m.FilePath = "/tmp/" + m.ID + ".csv"
+		m.Mappings = json.RawMessage(`[{"column":"id task","is_task_id":true}]`)
+		m.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		if err := repo.SaveMapping(m); err != nil {
+			t.Fatalf("SaveMapping: %v", err)
+		}
+	}
+
+	matches, err := repo.ListMappings("user-1", "sig-a")
+	if err != nil {
+		t.Fatalf("ListMappings: %v", err)
+	}
+	if len(matches) != 2 || matches[0].ID != "map_2" || matches[1].ID != "map_1" || !matches[0].Validated {
+		t.Fatalf("esperado [map_2 map_1], obtido %+v", matches)
+	}
+
+	all, err := repo.ListMappings("user-1", "")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("esperados 3 mapeamentos do usuário, obtidos %d (%v)", len(all), err)
+	}
+
+	if err := repo.DeleteMapping("map_1"); err != nil {
+		t.Fatalf("DeleteMapping: %v", err)
+	}
+	if _, err := repo.GetMapping("map_1"); !errors.Is(err, ErrSavedMappingNotFound) {
+		t.Errorf("esperava ErrSavedMappingNotFound, obteve %v", err)
+	}
+	if err := repo.DeleteMapping("map_1"); !errors.Is(err, ErrSavedMappingNotFound) {
+		t.Errorf("esperava ErrSavedMappingNotFound, obteve %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/lib/pq"
 )
 
 // MetadataRepository gerencia operações de metadados no banco
@@ -48,7 +49,8 @@ type Folder struct {
 // List representa uma lista do ClickUp
 type List struct {
 	ID        string    `json:"id" db:"id"`
-	FolderID  string    `json:"folder_id" db:"folder_id"`
+	FolderID  string    `json:"folder_id" db:"folder_id"` // vazio para listas direto no space
+	SpaceID   string    `json:"space_id" db:"space_id"`
 	Name      string    `json:"name" db:"name"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -61,8 +63,33 @@ type CustomField struct {
 	Type       string                 `json:"type" db:"type"`
 	Options    map[string]interface{} `json:"options" db:"options"`
 	OrderIndex int                    `json:"orderindex" db:"orderindex"`
-	CreatedAt  time.Time             `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time             `json:"updated_at" db:"updated_at"`
+	// WorkspaceID workspace em que o campo foi sincronizado (vazio mantém o atual)
+	WorkspaceID string    `json:"workspace_id,omitempty" db:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// ListIDs listas em que o campo aparece (somente leitura; ver SetListCustomFields)
+	ListIDs []string `json:"list_ids,omitempty"`
+}
+
+// customFieldColumns colunas lidas por scanCustomField, incluindo as listas do campo
+const customFieldColumns = `id, name, type, options, orderindex, created_at, updated_at,
+		ARRAY(SELECT lcf.list_id FROM list_custom_fields lcf WHERE lcf.field_id = custom_fields.id ORDER BY lcf.list_id)`
+
+// scanCustomField lê uma linha com customFieldColumns
+func scanCustomField(row rowScanner) (CustomField, error) {
+	var f CustomField
+	var optionsJSON []byte
+	if err := row.Scan(&f.ID, &f.Name, &f.Type, &optionsJSON, &f.OrderIndex, &f.CreatedAt, &f.UpdatedAt, pq.Array(&f.ListIDs)); err != nil {
+		return f, err
+	}
+
+	// Deserializa options
+	if len(optionsJSON) > 0 {
+		if err := json.Unmarshal(optionsJSON, &f.Options); err != nil {
+			return f, fmt.Errorf("erro ao deserializar options: %w", err)
+		}
+	}
+	return f, nil
 }
 
 // UpsertWorkspace insere ou atualiza um workspace
@@ -130,20 +157,21 @@ func (r *MetadataRepository) UpsertFolder(folder Folder) error {
 	return nil
 }
 
-// UpsertList insere ou atualiza uma lista
+// UpsertList insere ou atualiza uma lista; FolderID vazio grava a lista sem folder
 func (r *MetadataRepository) UpsertList(list List) error {
 	log := logger.Global()
 	
 	query := `
-		INSERT INTO lists (id, folder_id, name, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
+		INSERT INTO lists (id, folder_id, space_id, name, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, NOW(), NOW())
 		ON CONFLICT (id) DO UPDATE SET
 			folder_id = EXCLUDED.folder_id,
+			space_id = COALESCE(EXCLUDED.space_id, lists.space_id),
 			name = EXCLUDED.name,
 			updated_at = NOW()
 	`
 	
-	_, err := r.db.Exec(query, list.ID, list.FolderID, list.Name)
+	_, err := r.db.Exec(query, list.ID, list.FolderID, list.SpaceID, list.Name)
 	if err != nil {
 		log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao inserir/atualizar list")
 		return fmt.Errorf("erro ao inserir/atualizar list: %w", err)
@@ -163,17 +191,18 @@ func (r *MetadataRepository) UpsertCustomField(field CustomField) error {
 	}
 	
 	query := `
-		INSERT INTO custom_fields (id, name, type, options, orderindex, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		INSERT INTO custom_fields (id, name, type, options, orderindex, workspace_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW(), NOW())
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			type = EXCLUDED.type,
 			options = EXCLUDED.options,
 			orderindex = EXCLUDED.orderindex,
+			workspace_id = COALESCE(EXCLUDED.workspace_id, custom_fields.workspace_id),
 			updated_at = NOW()
 	`
 	
-	_, err = r.db.Exec(query, field.ID, field.Name, field.Type, optionsJSON, field.OrderIndex)
+	_, err = r.db.Exec(query, field.ID, field.Name, field.Type, optionsJSON, field.OrderIndex, field.WorkspaceID)
 	if err != nil {
 		log.Error().Err(err).Str("field_id", field.ID).Msg("Erro ao inserir/atualizar custom field")
 		return fmt.Errorf("erro ao inserir/atualizar custom field: %w", err)
@@ -182,6 +211,33 @@ func (r *MetadataRepository) UpsertCustomField(field CustomField) error {
 	return nil
 }
 
+// SetListCustomFields define os campos vistos em uma lista, removendo vínculos de campos
+// que saíram dela. Os campos precisam já estar salvos (UpsertCustomField).
+func (r *MetadataRepository) SetListCustomFields(listID string, fieldIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM list_custom_fields WHERE list_id = $1 AND NOT (field_id = ANY($2))`,
+		listID, idArray(fieldIDs)); err != nil {
+		return fmt.Errorf("erro ao remover campos da lista: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO list_custom_fields (list_id, field_id)
+		SELECT $1, unnest($2::varchar[])
+		ON CONFLICT DO NOTHING
+	`, listID, idArray(fieldIDs)); err != nil {
+		return fmt.Errorf("erro ao vincular campos à lista: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return nil
+}
+
 // GetWorkspaces retorna todos os workspaces
 func (r *MetadataRepository) GetWorkspaces() ([]Workspace, error) {
 	query := "SELECT id, name, created_at, updated_at FROM workspaces ORDER BY name"
@@ -261,13 +317,30 @@ func (r *MetadataRepository) GetFoldersBySpace(spaceID string) ([]Folder, error)
 // GetListsByFolder retorna listas de um folder
 func (r *MetadataRepository) GetListsByFolder(folderID string) ([]List, error) {
 	query := `
-		SELECT id, folder_id, name, created_at, updated_at 
+		SELECT id, COALESCE(folder_id, ''), COALESCE(space_id, ''), name, created_at, updated_at 
 		FROM lists 
 		WHERE folder_id = $1 
 		ORDER BY name
 	`
 	
-	rows, err := r.db.Query(query, folderID)
+	return r.queryLists(query, folderID)
+}
+
+// GetFolderlessListsBySpace retorna as listas criadas direto no space, fora de folders
+func (r *MetadataRepository) GetFolderlessListsBySpace(spaceID string) ([]List, error) {
+	query := `
+		SELECT id, '', COALESCE(space_id, ''), name, created_at, updated_at 
+		FROM lists 
+		WHERE space_id = $1 AND folder_id IS NULL 
+		ORDER BY name
+	`
+	
+	return r.queryLists(query, spaceID)
+}
+
+// queryLists executa uma consulta que seleciona id, folder_id, space_id, name e datas
+func (r *MetadataRepository) queryLists(query string, args ...interface{}) ([]List, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar lists: %w", err)
 	}
@@ -276,24 +349,153 @@ func (r *MetadataRepository) GetListsByFolder(folderID string) ([]List, error) {
 	var lists []List
 	for rows.Next() {
 		var l List
-		if err := rows.Scan(&l.ID, &l.FolderID, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
+		if err := rows.Scan(&l.ID, &l.FolderID, &l.SpaceID, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("erro ao escanear list: %w", err)
 		}
 		lists = append(lists, l)
 	}
 	
-	return lists, nil
+	return lists, rows.Err()
+}
+
+// FindListIDs retorna quais dos IDs informados existem nas listas sincronizadas
+func (r *MetadataRepository) FindListIDs(listIDs []string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT id FROM lists WHERE id = ANY($1)`, pq.Array(listIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lists: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(listIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao escanear list: %w", err)
+		}
+		found[id] = true
+	}
+
+	return found, rows.Err()
+}
+
+// WorkspaceSnapshot registra os IDs vistos na sincronização de um workspace.
+// Só entram nos mapas os pais cuja listagem no ClickUp foi completa: um pai que
+// falhou ao listar os filhos fica de fora e seus filhos são mantidos.
+type WorkspaceSnapshot struct {
+	WorkspaceID    string
+	SpacesComplete bool                // spaces do workspace listados sem erro
+	Spaces         []string            // spaces vistos
+	Folders        map[string][]string // space_id -> folders vistos
+	Lists          map[string][]string // folder_id -> listas vistas
+	SpaceLists     map[string][]string // space_id -> listas sem folder vistas
+	FieldsComplete bool                // campos de todas as listas do workspace listados sem erro
+	Fields         []string            // campos vistos
+}
+
+// PruneResult quantidade de entidades removidas por PruneWorkspace
+type PruneResult struct {
+	Spaces  int64 `json:"spaces"`
+	Folders int64 `json:"folders"`
+	Lists   int64 `json:"lists"`
+	Fields  int64 `json:"fields"`
+}
+
+// Total soma as entidades removidas
+func (p PruneResult) Total() int64 {
+	return p.Spaces + p.Folders + p.Lists + p.Fields
+}
+
+// PruneWorkspace remove do workspace as entidades que não aparecem no snapshot,
+// em uma única transação: um erro no meio não deixa a hierarquia pela metade.
+// Spaces e folders removidos levam seus filhos (ON DELETE CASCADE).
+func (r *MetadataRepository) PruneWorkspace(snapshot WorkspaceSnapshot) (PruneResult, error) {
+	var result PruneResult
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	exec := func(count *int64, query string, args ...interface{}) error {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		*count += n
+		return nil
+	}
+
+	if snapshot.SpacesComplete {
+		if err := exec(&result.Spaces, `DELETE FROM spaces WHERE workspace_id = $1 AND NOT (id = ANY($2))`,
+			snapshot.WorkspaceID, idArray(snapshot.Spaces)); err != nil {
+			return PruneResult{}, fmt.Errorf("erro ao remover spaces: %w", err)
+		}
+	}
+	for spaceID, folders := range snapshot.Folders {
+		if err := exec(&result.Folders, `DELETE FROM folders WHERE space_id = $1 AND NOT (id = ANY($2))`,
+			spaceID, idArray(folders)); err != nil {
+			return PruneResult{}, fmt.Errorf("erro ao remover folders: %w", err)
+		}
+	}
+	for folderID, lists := range snapshot.Lists {
+		if err := exec(&result.Lists, `DELETE FROM lists WHERE folder_id = $1 AND NOT (id = ANY($2))`,
+			folderID, idArray(lists)); err != nil {
+			return PruneResult{}, fmt.Errorf("erro ao remover lists: %w", err)
+		}
+	}
+	for spaceID, lists := range snapshot.SpaceLists {
+		if err := exec(&result.Lists, `DELETE FROM lists WHERE space_id = $1 AND folder_id IS NULL AND NOT (id = ANY($2))`,
+			spaceID, idArray(lists)); err != nil {
+			return PruneResult{}, fmt.Errorf("erro ao remover lists: %w", err)
+		}
+	}
+	if snapshot.FieldsComplete {
+		if err := exec(&result.Fields, `DELETE FROM custom_fields WHERE workspace_id = $1 AND NOT (id = ANY($2))`,
+			snapshot.WorkspaceID, idArray(snapshot.Fields)); err != nil {
+			return PruneResult{}, fmt.Errorf("erro ao remover custom fields: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PruneResult{}, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return result, nil
+}
+
+// idArray converte IDs para um array do Postgres; nunca NULL, para que um
+// conjunto vazio signifique "nenhum visto" em NOT (id = ANY(...))
+func idArray(ids []string) interface{} {
+	return pq.Array(append([]string{}, ids...))
 }
 
-// GetCustomFields retorna todos os campos personalizados
+// GetCustomFields retorna todos os campos personalizados, com as listas de cada um
 func (r *MetadataRepository) GetCustomFields() ([]CustomField, error) {
 	query := `
-		SELECT id, name, type, options, orderindex, created_at, updated_at 
+		SELECT ` + customFieldColumns + `
 		FROM custom_fields 
 		ORDER BY orderindex, name
 	`
 	
-	rows, err := r.db.Query(query)
+	return r.queryCustomFields(query)
+}
+
+// GetCustomFieldsByList retorna os campos personalizados de uma lista
+func (r *MetadataRepository) GetCustomFieldsByList(listID string) ([]CustomField, error) {
+	query := `
+		SELECT ` + customFieldColumns + `
+		FROM custom_fields 
+		WHERE id IN (SELECT field_id FROM list_custom_fields WHERE list_id = $1)
+		ORDER BY orderindex, name
+	`
+	
+	return r.queryCustomFields(query, listID)
+}
+
+// queryCustomFields executa uma consulta que seleciona customFieldColumns
+func (r *MetadataRepository) queryCustomFields(query string, args ...interface{}) ([]CustomField, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar custom fields: %w", err)
 	}
@@ -301,52 +503,32 @@ func (r *MetadataRepository) GetCustomFields() ([]CustomField, error) {
 	
 	var fields []CustomField
 	for rows.Next() {
-		var f CustomField
-		var optionsJSON []byte
-		
-		if err := rows.Scan(&f.ID, &f.Name, &f.Type, &optionsJSON, &f.OrderIndex, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		f, err := scanCustomField(rows)
+		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear custom field: %w", err)
 		}
-		
-		// Deserializa options
-		if len(optionsJSON) > 0 {
-			if err := json.Unmarshal(optionsJSON, &f.Options); err != nil {
-				return nil, fmt.Errorf("erro ao deserializar options: %w", err)
-			}
-		}
-		
 		fields = append(fields, f)
 	}
 	
-	return fields, nil
+	return fields, rows.Err()
 }
 
 
 // GetCustomFieldByID retorna um campo personalizado pelo ID
 func (r *MetadataRepository) GetCustomFieldByID(fieldID string) (*CustomField, error) {
 	query := `
-		SELECT id, name, type, options, orderindex, created_at, updated_at 
+		SELECT ` + customFieldColumns + `
 		FROM custom_fields 
 		WHERE id = $1
 	`
 	
-	var f CustomField
-	var optionsJSON []byte
-	
-	err := r.db.QueryRow(query, fieldID).Scan(&f.ID, &f.Name, &f.Type, &optionsJSON, &f.OrderIndex, &f.CreatedAt, &f.UpdatedAt)
+	f, err := scanCustomField(r.db.QueryRow(query, fieldID))
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
+		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("erro ao buscar custom field: %w", err)
 	}
 	
-	// Deserializa options
-	if len(optionsJSON) > 0 {
-		if err := json.Unmarshal(optionsJSON, &f.Options); err != nil {
-			return nil, fmt.Errorf("erro ao deserializar options: %w", err)
-		}
-	}
-	
 	return &f, nil
 }
@@ -79,6 +79,187 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// TestPruneWorkspaceRemovesDeletedEntities simula a exclusão de entidades no ClickUp
+// entre duas sincronizações
+func TestPruneWorkspaceRemovesDeletedEntities(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMetadataRepository(db)
+
+	// Primeira sincronização: W1 com S1/F1/{L1,L2}, S2/F2/L3 e campos C1, C2; W2 com C3
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(repo.UpsertWorkspace(Workspace{ID: "W1", Name: "Workspace 1"}))
+	must(repo.UpsertWorkspace(Workspace{ID: "W2", Name: "Workspace 2"}))
+	must(repo.UpsertSpace(Space{ID: "S1", WorkspaceID: "W1", Name: "Space 1"}))
+	must(repo.UpsertSpace(Space{ID: "S2", WorkspaceID: "W1", Name: "Space 2"}))
+	must(repo.UpsertFolder(Folder{ID: "F1", SpaceID: "S1", Name: "Folder 1"}))
+	must(repo.UpsertFolder(Folder{ID: "F2", SpaceID: "S2", Name: "Folder 2"}))
+	must(repo.UpsertList(List{ID: "L1", FolderID: "F1", Name: "Lista 1"}))
+	must(repo.UpsertList(List{ID: "L2", FolderID: "F1", Name: "Lista 2"}))
+	must(repo.UpsertList(List{ID: "L3", FolderID: "F2", Name: "Lista 3"}))
+	must(repo.UpsertCustomField(CustomField{ID: "C1", Name: "Campo 1", Type: "text", WorkspaceID: "W1"}))
+	must(repo.UpsertCustomField(CustomField{ID: "C2", Name: "Campo 2", Type: "text", WorkspaceID: "W1"}))
+	must(repo.UpsertCustomField(CustomField{ID: "C3", Name: "Campo 3", Type: "text", WorkspaceID: "W2"}))
+
+	// Segunda sincronização: L2 e C2 foram excluídas; a listagem de F2 falhou
+	result, err := repo.PruneWorkspace(WorkspaceSnapshot{
+		WorkspaceID:    "W1",
+		SpacesComplete: true,
+		Spaces:         []string{"S1", "S2"},
+		Folders:        map[string][]string{"S1": {"F1"}, "S2": {"F2"}},
+		Lists:          map[string][]string{"F1": {"L1"}},
+		FieldsComplete: true,
+		Fields:         []string{"C1"},
+	})
+	if err != nil {
+		t.Fatalf("PruneWorkspace: %v", err)
+	}
+	if result != (PruneResult{Lists: 1, Fields: 1}) {
+		t.Errorf("resultado = %+v", result)
+	}
+
+	found, err := repo.FindListIDs([]string{"L1", "L2", "L3"})
+	if err != nil {
+		t.Fatalf("FindListIDs: %v", err)
+	}
+	if !found["L1"] || found["L2"] || !found["L3"] {
+		t.Errorf("listas após a sincronização: %v (L3 deveria ficar, a listagem de F2 falhou)", found)
+	}
+	if field, err := repo.GetCustomFieldByID("C2"); err != nil || field != nil {
+		t.Errorf("C2 deveria ter sido removido: %+v, %v", field, err)
+	}
+	if field, err := repo.GetCustomFieldByID("C3"); err != nil || field == nil {
+		t.Errorf("campo de outro workspace não deveria ser removido: %v", err)
+	}
+
+	// Space excluído leva folders e listas junto
+	if _, err := repo.PruneWorkspace(WorkspaceSnapshot{WorkspaceID: "W1", SpacesComplete: true, Spaces: []string{"S1"}}); err != nil {
+		t.Fatalf("PruneWorkspace: %v", err)
+	}
+	if found, _ := repo.FindListIDs([]string{"L3"}); found["L3"] {
+		t.Error("L3 deveria ter sido removida junto com S2")
+	}
+}
+
+// TestFolderlessLists verifica que listas direto no space ficam separadas das listas
+// em folders e são removidas pelo snapshot do space
+func TestFolderlessLists(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMetadataRepository(db)
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(repo.UpsertWorkspace(Workspace{ID: "W1", Name: "Workspace 1"}))
+	must(repo.UpsertSpace(Space{ID: "S1", WorkspaceID: "W1", Name: "Space 1"}))
+	must(repo.UpsertFolder(Folder{ID: "F1", SpaceID: "S1", Name: "Folder 1"}))
+	must(repo.UpsertList(List{ID: "L1", FolderID: "F1", SpaceID: "S1", Name: "Em folder"}))
+	must(repo.UpsertList(List{ID: "L2", SpaceID: "S1", Name: "Sem folder A"}))
+	must(repo.UpsertList(List{ID: "L3", SpaceID: "S1", Name: "Sem folder B"}))
+
+	inFolder, err := repo.GetListsByFolder("F1")
+	if err != nil {
+		t.Fatalf("GetListsByFolder: %v", err)
+	}
+	if len(inFolder) != 1 || inFolder[0].ID != "L1" || inFolder[0].SpaceID != "S1" {
+		t.Errorf("listas do folder = %+v", inFolder)
+	}
+
+	folderless, err := repo.GetFolderlessListsBySpace("S1")
+	if err != nil {
+		t.Fatalf("GetFolderlessListsBySpace: %v", err)
+	}
+	if len(folderless) != 2 || folderless[0].ID != "L2" || folderless[1].ID != "L3" || folderless[0].FolderID != "" {
+		t.Errorf("listas sem folder = %+v", folderless)
+	}
+
+	// L3 foi excluída no ClickUp; a lista do folder não é afetada pelo snapshot do space
+	result, err := repo.PruneWorkspace(WorkspaceSnapshot{
+		WorkspaceID: "W1",
+		SpaceLists:  map[string][]string{"S1": {"L2"}},
+	})
+	if err != nil {
+		t.Fatalf("PruneWorkspace: %v", err)
+	}
+	if result != (PruneResult{Lists: 1}) {
+		t.Errorf("resultado = %+v", result)
+	}
+	found, err := repo.FindListIDs([]string{"L1", "L2", "L3"})
+	if err != nil {
+		t.Fatalf("FindListIDs: %v", err)
+	}
+	if !found["L1"] || !found["L2"] || found["L3"] {
+		t.Errorf("listas após a limpeza: %v", found)
+	}
+}
+
+// TestListScopedCustomFields verifica que campos com o mesmo nome em listas diferentes
+// são retornados com a lista de cada um
+func TestListScopedCustomFields(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMetadataRepository(db)
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(repo.UpsertWorkspace(Workspace{ID: "W1", Name: "Workspace 1"}))
+	must(repo.UpsertSpace(Space{ID: "S1", WorkspaceID: "W1", Name: "Space 1"}))
+	must(repo.UpsertFolder(Folder{ID: "F1", SpaceID: "S1", Name: "Folder 1"}))
+	must(repo.UpsertList(List{ID: "L1", FolderID: "F1", Name: "Lista 1"}))
+	must(repo.UpsertList(List{ID: "L2", FolderID: "F1", Name: "Lista 2"}))
+	must(repo.UpsertCustomField(CustomField{ID: "status-1", Name: "Status", Type: "drop_down", WorkspaceID: "W1"}))
+	must(repo.UpsertCustomField(CustomField{ID: "status-2", Name: "Status", Type: "drop_down", WorkspaceID: "W1"}))
+	must(repo.UpsertCustomField(CustomField{ID: "valor", Name: "Valor", Type: "number", WorkspaceID: "W1"}))
+	must(repo.SetListCustomFields("L1", []string{"status-1", "valor"}))
+	must(repo.SetListCustomFields("L2", []string{"status-2", "valor"}))
+
+	fields, err := repo.GetCustomFields()
+	if err != nil {
+		t.Fatalf("GetCustomFields: %v", err)
+	}
+	lists := make(map[string][]string)
+	for _, f := range fields {
+		lists[f.ID] = f.ListIDs
+	}
+	want := map[string][]string{"status-1": {"L1"}, "status-2": {"L2"}, "valor": {"L1", "L2"}}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("listas dos campos = %v, esperado %v", lists, want)
+	}
+
+	l2, err := repo.GetCustomFieldsByList("L2")
+	if err != nil {
+		t.Fatalf("GetCustomFieldsByList: %v", err)
+	}
+	if len(l2) != 2 || l2[0].ID != "status-2" && l2[1].ID != "status-2" {
+		t.Errorf("campos de L2 = %+v", l2)
+	}
+
+	// Um campo que sai da lista perde o vínculo; o campo continua nas outras
+	must(repo.SetListCustomFields("L1", []string{"status-1"}))
+	field, err := repo.GetCustomFieldByID("valor")
+	if err != nil || field == nil || !reflect.DeepEqual(field.ListIDs, []string{"L2"}) {
+		t.Errorf("valor após sair de L1 = %+v, %v", field, err)
+	}
+
+	// Lista removida leva os vínculos junto
+	if _, err := db.Exec(`DELETE FROM lists WHERE id = 'L2'`); err != nil {
+		t.Fatal(err)
+	}
+	if l2, _ := repo.GetCustomFieldsByList("L2"); len(l2) != 0 {
+		t.Errorf("campos de lista removida = %+v", l2)
+	}
+}
+
 // TestMetadataSynchronizationCompleteness testa a propriedade de completude da sincronização de metadados
 func TestMetadataSynchronizationCompleteness(t *testing.T) {
 	db := setupTestDB(t)
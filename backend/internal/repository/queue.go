@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
@@ -12,11 +13,67 @@ import (
 // QueueRepository gerencia operações da fila no banco
 type QueueRepository struct {
 	db *sql.DB
+
+	// priorityAging tempo de espera após o qual um job pendente sobe um nível de prioridade
+	priorityAging time.Duration
+
+	// now relógio usado para decidir se jobs agendados já podem rodar (injetável em testes)
+	now func() time.Time
 }
 
 // NewQueueRepository cria um novo repositório de fila
 func NewQueueRepository(db *sql.DB) *QueueRepository {
-	return &QueueRepository{db: db}
+	return &QueueRepository{
+		db:            db,
+		priorityAging: DefaultPriorityAging,
+		now:           time.Now,
+	}
+}
+
+// SetClock substitui o relógio usado na seleção de jobs agendados
+func (r *QueueRepository) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Níveis de prioridade dos jobs (maior valor é processado antes)
+const (
+	JobPriorityLow    = 0
+	JobPriorityNormal = 1
+	JobPriorityHigh   = 2
+)
+
+// DefaultPriorityAging a cada 30 minutos na fila um job sobe um nível, evitando starvation
+const DefaultPriorityAging = 30 * time.Minute
+
+// ParseJobPriority converte low/normal/high no nível numérico; vazio equivale a normal
+func ParseJobPriority(name string) (int, error) {
+	switch name {
+	case "", "normal":
+		return JobPriorityNormal, nil
+	case "low":
+		return JobPriorityLow, nil
+	case "high":
+		return JobPriorityHigh, nil
+	default:
+		return 0, fmt.Errorf("prioridade inválida: %s (use low, normal ou high)", name)
+	}
+}
+
+// JobPriorityName retorna o nome do nível de prioridade
+func JobPriorityName(priority int) string {
+	switch {
+	case priority <= JobPriorityLow:
+		return "low"
+	case priority >= JobPriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// SetPriorityAging define o intervalo de envelhecimento da prioridade (0 desativa)
+func (r *QueueRepository) SetPriorityAging(aging time.Duration) {
+	r.priorityAging = aging
 }
 
 // UpdateJob representa um job de atualização na fila
@@ -32,9 +89,123 @@ type UpdateJob struct {
 	SuccessCount  int                    `json:"success_count" db:"success_count"`
 	ErrorCount    int                    `json:"error_count" db:"error_count"`
 	ErrorDetails  []string               `json:"error_details" db:"error_details"`
+	Options       JobOptions             `json:"options" db:"options"`
+	Priority      int                    `json:"priority" db:"priority"`
+	ScheduledAt   *time.Time             `json:"scheduled_at" db:"scheduled_at"` // nil = assim que possível
 	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time             `json:"updated_at" db:"updated_at"`
 	CompletedAt   *time.Time            `json:"completed_at" db:"completed_at"`
+	// DryRunResult resumo de um job dry-run concluído (nil nos demais jobs)
+	DryRunResult map[string]interface{} `json:"dry_run_result,omitempty" db:"dry_run_result"`
+}
+
+// FieldDefault representa o valor padrão aplicado a uma coluna mapeada
+type FieldDefault struct {
+	Value string `json:"value"`
+	When  string `json:"when"` // "empty", "missing" ou "always"
+}
+
+// JobOptions agrupa opções de processamento persistidas junto ao job
+type JobOptions struct {
+	Defaults map[string]FieldDefault `json:"defaults,omitempty"` // coluna -> valor padrão
+	Locale   string                  `json:"locale,omitempty"`   // formato de números e datas (pt-BR, en-US)
+	Timezone string                  `json:"timezone,omitempty"` // fuso IANA para datas sem offset
+	// DuplicateResolution linhas com a mesma task e campo: last-wins (padrão), first-wins ou error
+	DuplicateResolution string `json:"duplicate_resolution,omitempty"`
+	// TokenLabel rótulo do token do ClickUp usado no job (vazio = token padrão)
+	TokenLabel string `json:"token_label,omitempty"`
+	// CustomTaskIDs a coluna de task contém IDs personalizados (ex.: "PROJ-123");
+	// TeamID é o workspace deles, exigido pelo ClickUp
+	CustomTaskIDs bool   `json:"custom_task_ids,omitempty"`
+	TeamID        string `json:"team_id,omitempty"`
+	// NormalizeColumns colunas do mapeamento casam com o cabeçalho ignorando
+	// maiúsculas, acentos e espaços extras
+	NormalizeColumns bool `json:"normalize_columns,omitempty"`
+	// RowFilter processa apenas as linhas que atendem às condições (nil = todas)
+	RowFilter *RowFilter `json:"row_filter,omitempty"`
+	// ConflictCheck concorrência otimista: linhas de tasks alteradas no ClickUp depois
+	// da geração da planilha não são gravadas (nil = sem verificação)
+	ConflictCheck *ConflictCheck `json:"conflict_check,omitempty"`
+	// DryRun resolve e valida os valores de cada linha sem gravar nada no ClickUp
+	DryRun bool `json:"dry_run,omitempty"`
+	// PreviewDiff no dry-run, lê o valor atual de cada campo no ClickUp para mostrar
+	// de/para (uma leitura a mais por task)
+	PreviewDiff bool `json:"preview_diff,omitempty"`
+	// CommentOnUpdate publica em cada task atualizada um comentário com os campos gravados
+	// (falhas do comentário não falham a linha)
+	CommentOnUpdate bool `json:"comment_on_update,omitempty"`
+}
+
+// ConflictCheck compara a data de atualização de cada task com Since antes de gravar
+type ConflictCheck struct {
+	Since time.Time `json:"since"` // quando a planilha foi gerada (ex.: exportação do relatório)
+}
+
+// RowFilter seleciona as linhas do arquivo atualizadas por um job
+type RowFilter struct {
+	Combine    string         `json:"combine,omitempty"` // "and" (padrão: todas as condições) ou "or" (qualquer uma)
+	Conditions []RowCondition `json:"conditions"`
+}
+
+// RowCondition compara o valor de uma coluna da linha com Value
+type RowCondition struct {
+	Column   string `json:"column"`
+	Operator string `json:"operator"` // eq, ne, contains, not_contains, gt, gte, lt, lte, empty, not_empty
+	Value    string `json:"value,omitempty"`
+}
+
+// jobColumns lista as colunas lidas de job_queue, na ordem esperada por scanJob
+const jobColumns = `id, user_id, title, status, file_path, mapping, total_rows, 
+			processed_rows, success_count, error_count, error_details, options, priority,
+			scheduled_at, created_at, updated_at, completed_at, dry_run_result`
+
+// rowScanner abstrai *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob lê um job e deserializa os campos JSONB
+func scanJob(scanner rowScanner) (*UpdateJob, error) {
+	var job UpdateJob
+	var mappingJSON, errorDetailsJSON, optionsJSON, dryRunJSON []byte
+
+	err := scanner.Scan(&job.ID, &job.UserID, &job.Title, &job.Status, &job.FilePath,
+		&mappingJSON, &job.TotalRows, &job.ProcessedRows, &job.SuccessCount,
+		&job.ErrorCount, &errorDetailsJSON, &optionsJSON, &job.Priority, &job.ScheduledAt, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt,
+		&dryRunJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserializa mapping
+	if len(mappingJSON) > 0 {
+		if err := json.Unmarshal(mappingJSON, &job.Mapping); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar mapping: %w", err)
+		}
+	}
+
+	// Deserializa error details
+	if len(errorDetailsJSON) > 0 {
+		if err := json.Unmarshal(errorDetailsJSON, &job.ErrorDetails); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar error details: %w", err)
+		}
+	}
+
+	// Deserializa options
+	if len(optionsJSON) > 0 {
+		if err := json.Unmarshal(optionsJSON, &job.Options); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar options: %w", err)
+		}
+	}
+
+	// Deserializa o resumo do dry-run
+	if len(dryRunJSON) > 0 {
+		if err := json.Unmarshal(dryRunJSON, &job.DryRunResult); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar resumo do dry-run: %w", err)
+		}
+	}
+
+	return &job, nil
 }
 
 // OperationHistory representa uma entrada no histórico de operações
@@ -64,17 +235,23 @@ func (r *QueueRepository) CreateJob(job UpdateJob) (*UpdateJob, error) {
 		return nil, fmt.Errorf("erro ao serializar error details: %w", err)
 	}
 	
+	// Serializa options para JSONB
+	optionsJSON, err := json.Marshal(job.Options)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar options: %w", err)
+	}
+	
 	query := `
 		INSERT INTO job_queue (user_id, title, status, file_path, mapping, total_rows, 
-			processed_rows, success_count, error_count, error_details, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+			processed_rows, success_count, error_count, error_details, options, priority, scheduled_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
 		RETURNING id, created_at, updated_at
 	`
 	
 	var createdJob UpdateJob = job
 	err = r.db.QueryRow(query, job.UserID, job.Title, job.Status, job.FilePath, 
 		mappingJSON, job.TotalRows, job.ProcessedRows, job.SuccessCount, 
-		job.ErrorCount, errorDetailsJSON).Scan(&createdJob.ID, &createdJob.CreatedAt, &createdJob.UpdatedAt)
+		job.ErrorCount, errorDetailsJSON, optionsJSON, job.Priority, utcOrNil(job.ScheduledAt)).Scan(&createdJob.ID, &createdJob.CreatedAt, &createdJob.UpdatedAt)
 	
 	if err != nil {
 		log.Error().Err(err).Str("user_id", job.UserID).Msg("Erro ao criar job")
@@ -111,6 +288,20 @@ func (r *QueueRepository) UpdateJobProgress(jobID int, processedRows, successCou
 	return nil
 }
 
+// SaveDryRunResult grava o resumo de um job dry-run
+func (r *QueueRepository) SaveDryRunResult(jobID int, result map[string]interface{}) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar resumo do dry-run: %w", err)
+	}
+	
+	query := `UPDATE job_queue SET dry_run_result = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := r.db.Exec(query, jobID, resultJSON); err != nil {
+		return fmt.Errorf("erro ao gravar resumo do dry-run: %w", err)
+	}
+	return nil
+}
+
 // UpdateJobStatus atualiza o status de um job
 func (r *QueueRepository) UpdateJobStatus(jobID int, status string) error {
 	log := logger.Global()
@@ -143,23 +334,57 @@ func (r *QueueRepository) UpdateJobStatus(jobID int, status string) error {
 	return nil
 }
 
+// GetNextScheduledTime retorna o scheduled_at mais próximo entre os jobs pendentes
+// ainda no futuro, ou nil se não houver nenhum
+func (r *QueueRepository) GetNextScheduledTime() (*time.Time, error) {
+	query := `
+		SELECT MIN(scheduled_at)
+		FROM job_queue
+		WHERE status = 'pending' AND scheduled_at > $1
+	`
+	
+	var next sql.NullTime
+	if err := r.db.QueryRow(query, r.now().UTC()).Scan(&next); err != nil {
+		return nil, fmt.Errorf("erro ao buscar próximo agendamento: %w", err)
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+	return &next.Time, nil
+}
+
+// IsFileInUse indica se algum job pendente ou em processamento ainda usa o arquivo
+func (r *QueueRepository) IsFileInUse(filePath string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM job_queue
+			WHERE file_path = $1 AND status IN ('pending', 'processing')
+		)
+	`
+	
+	var inUse bool
+	if err := r.db.QueryRow(query, filePath).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("erro ao verificar uso do arquivo: %w", err)
+	}
+	return inUse, nil
+}
+
+// utcOrNil normaliza horários para UTC antes de gravar em colunas TIMESTAMP sem fuso
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
 // GetJobByID retorna um job pelo ID
 func (r *QueueRepository) GetJobByID(jobID int) (*UpdateJob, error) {
-	query := `
-		SELECT id, user_id, title, status, file_path, mapping, total_rows, 
-			processed_rows, success_count, error_count, error_details, 
-			created_at, updated_at, completed_at
+	query := `SELECT ` + jobColumns + `
 		FROM job_queue 
 		WHERE id = $1
 	`
 	
-	var job UpdateJob
-	var mappingJSON, errorDetailsJSON []byte
-	
-	err := r.db.QueryRow(query, jobID).Scan(&job.ID, &job.UserID, &job.Title, &job.Status, &job.FilePath,
-		&mappingJSON, &job.TotalRows, &job.ProcessedRows, &job.SuccessCount,
-		&job.ErrorCount, &errorDetailsJSON, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt)
-	
+	job, err := scanJob(r.db.QueryRow(query, jobID))
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			return nil, nil
@@ -167,35 +392,27 @@ func (r *QueueRepository) GetJobByID(jobID int) (*UpdateJob, error) {
 		return nil, fmt.Errorf("erro ao buscar job: %w", err)
 	}
 	
-	// Deserializa mapping
-	if len(mappingJSON) > 0 {
-		if err := json.Unmarshal(mappingJSON, &job.Mapping); err != nil {
-			return nil, fmt.Errorf("erro ao deserializar mapping: %w", err)
-		}
-	}
-	
-	// Deserializa error details
-	if len(errorDetailsJSON) > 0 {
-		if err := json.Unmarshal(errorDetailsJSON, &job.ErrorDetails); err != nil {
-			return nil, fmt.Errorf("erro ao deserializar error details: %w", err)
-		}
-	}
-	
-	return &job, nil
+	return job, nil
 }
 
-// GetPendingJobs retorna jobs pendentes na ordem FIFO
+// GetPendingJobs retorna jobs pendentes por prioridade e, dentro da mesma prioridade,
+// na ordem FIFO. A prioridade efetiva sobe um nível a cada priorityAging de espera
+// (limitada a high), para que jobs de baixa prioridade não fiquem parados indefinidamente.
+// Jobs agendados só são retornados quando scheduled_at já passou.
 func (r *QueueRepository) GetPendingJobs() ([]UpdateJob, error) {
-	query := `
-		SELECT id, user_id, title, status, file_path, mapping, total_rows, 
-			processed_rows, success_count, error_count, error_details, 
-			created_at, updated_at, completed_at
+	agingSeconds := int64(r.priorityAging / time.Second)
+	
+	query := `SELECT ` + jobColumns + `
 		FROM job_queue 
 		WHERE status = 'pending'
-		ORDER BY created_at ASC
+			AND (scheduled_at IS NULL OR scheduled_at <= $3)
+		ORDER BY CASE WHEN $1::bigint > 0
+				THEN LEAST(priority + FLOOR(EXTRACT(EPOCH FROM (NOW() - created_at)) / GREATEST($1::bigint, 1))::int, $2)
+				ELSE priority
+			END DESC, created_at ASC
 	`
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, agingSeconds, JobPriorityHigh, r.now().UTC())
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar jobs pendentes: %w", err)
 	}
@@ -203,43 +420,57 @@ func (r *QueueRepository) GetPendingJobs() ([]UpdateJob, error) {
 	
 	var jobs []UpdateJob
 	for rows.Next() {
-		var job UpdateJob
-		var mappingJSON, errorDetailsJSON []byte
-		
-		err := rows.Scan(&job.ID, &job.UserID, &job.Title, &job.Status, &job.FilePath,
-			&mappingJSON, &job.TotalRows, &job.ProcessedRows, &job.SuccessCount,
-			&job.ErrorCount, &errorDetailsJSON, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt)
-		
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear job: %w", err)
 		}
 		
-		// Deserializa mapping
-		if len(mappingJSON) > 0 {
-			if err := json.Unmarshal(mappingJSON, &job.Mapping); err != nil {
-				return nil, fmt.Errorf("erro ao deserializar mapping: %w", err)
-			}
-		}
-		
-		// Deserializa error details
-		if len(errorDetailsJSON) > 0 {
-			if err := json.Unmarshal(errorDetailsJSON, &job.ErrorDetails); err != nil {
-				return nil, fmt.Errorf("erro ao deserializar error details: %w", err)
-			}
-		}
-		
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 	
 	return jobs, nil
 }
 
+// QueueStats resume o estado da fila para health checks e métricas
+type QueueStats struct {
+	Pending          int           // jobs prontos para rodar (sem agendamento futuro)
+	Scheduled        int           // jobs pendentes agendados para depois
+	Processing       int           // jobs em processamento
+	OldestPendingAge time.Duration // espera do job pronto mais antigo (0 se não houver)
+}
+
+// GetQueueStats conta os jobs pendentes e em processamento e mede há quanto tempo o
+// job pronto mais antigo espera. Um job agendado conta a espera a partir de scheduled_at.
+func (r *QueueRepository) GetQueueStats() (*QueueStats, error) {
+	now := r.now().UTC()
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'pending' AND (scheduled_at IS NULL OR scheduled_at <= $1)),
+			COUNT(*) FILTER (WHERE status = 'pending' AND scheduled_at > $1),
+			COUNT(*) FILTER (WHERE status = 'processing'),
+			MIN(COALESCE(scheduled_at, created_at)) FILTER (WHERE status = 'pending' AND (scheduled_at IS NULL OR scheduled_at <= $1))
+		FROM job_queue
+		WHERE status IN ('pending', 'processing')
+	`
+	
+	var stats QueueStats
+	var oldest sql.NullTime
+	if err := r.db.QueryRow(query, now).Scan(&stats.Pending, &stats.Scheduled, &stats.Processing, &oldest); err != nil {
+		return nil, fmt.Errorf("erro ao calcular estatísticas da fila: %w", err)
+	}
+	if oldest.Valid {
+		if age := now.Sub(oldest.Time); age > 0 {
+			stats.OldestPendingAge = age
+		}
+	}
+	
+	return &stats, nil
+}
+
 // GetJobsByUser retorna jobs de um usuário
 func (r *QueueRepository) GetJobsByUser(userID string) ([]UpdateJob, error) {
-	query := `
-		SELECT id, user_id, title, status, file_path, mapping, total_rows, 
-			processed_rows, success_count, error_count, error_details, 
-			created_at, updated_at, completed_at
+	query := `SELECT ` + jobColumns + `
 		FROM job_queue 
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -253,37 +484,43 @@ func (r *QueueRepository) GetJobsByUser(userID string) ([]UpdateJob, error) {
 	
 	var jobs []UpdateJob
 	for rows.Next() {
-		var job UpdateJob
-		var mappingJSON, errorDetailsJSON []byte
-		
-		err := rows.Scan(&job.ID, &job.UserID, &job.Title, &job.Status, &job.FilePath,
-			&mappingJSON, &job.TotalRows, &job.ProcessedRows, &job.SuccessCount,
-			&job.ErrorCount, &errorDetailsJSON, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt)
-		
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear job: %w", err)
 		}
 		
-		// Deserializa mapping
-		if len(mappingJSON) > 0 {
-			if err := json.Unmarshal(mappingJSON, &job.Mapping); err != nil {
-				return nil, fmt.Errorf("erro ao deserializar mapping: %w", err)
-			}
-		}
-		
-		// Deserializa error details
-		if len(errorDetailsJSON) > 0 {
-			if err := json.Unmarshal(errorDetailsJSON, &job.ErrorDetails); err != nil {
-				return nil, fmt.Errorf("erro ao deserializar error details: %w", err)
-			}
-		}
-		
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 	
 	return jobs, nil
 }
 
+// GetActiveJobsByUser retorna os jobs pendentes e em processamento de um usuário
+func (r *QueueRepository) GetActiveJobsByUser(userID string) ([]UpdateJob, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM job_queue
+		WHERE user_id = $1 AND status IN ('pending', 'processing')
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar jobs ativos do usuário: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []UpdateJob
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, rows.Err()
+}
+
 // DeleteCompletedJobs remove jobs concluídos
 func (r *QueueRepository) DeleteCompletedJobs() error {
 	query := "DELETE FROM job_queue WHERE status = 'completed'"
@@ -300,23 +537,24 @@ func (r *QueueRepository) DeleteCompletedJobs() error {
 	return nil
 }
 
-// DeleteOldFailedJobs remove jobs que falharam há mais de 24 horas
-func (r *QueueRepository) DeleteOldFailedJobs() error {
+// DeleteOldFailedJobs remove jobs que falharam há mais de maxAge e retorna quantos
+// foram removidos
+func (r *QueueRepository) DeleteOldFailedJobs(maxAge time.Duration) (int64, error) {
 	query := `
 		DELETE FROM job_queue 
-		WHERE status = 'failed' AND updated_at < NOW() - INTERVAL '24 hours'
+		WHERE status = 'failed' AND updated_at < NOW() - $1 * INTERVAL '1 second'
 	`
 	
-	result, err := r.db.Exec(query)
+	result, err := r.db.Exec(query, int64(maxAge/time.Second))
 	if err != nil {
-		return fmt.Errorf("erro ao deletar jobs antigos: %w", err)
+		return 0, fmt.Errorf("erro ao deletar jobs antigos: %w", err)
 	}
 	
 	rowsAffected, _ := result.RowsAffected()
 	log := logger.Global()
 	log.Info().Int64("rows_deleted", rowsAffected).Msg("Jobs antigos removidos")
 	
-	return nil
+	return rowsAffected, nil
 }
 
 // CreateOperationHistory cria uma entrada no histórico
@@ -388,6 +626,149 @@ func (r *QueueRepository) GetOperationHistoryByUser(userID string) ([]OperationH
 	return history, nil
 }
 
+// Limites da paginação do histórico
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+)
+
+// HistoryFilter filtros e paginação da listagem de histórico; campos vazios são ignorados
+type HistoryFilter struct {
+	Limit         int
+	Offset        int
+	Status        string
+	OperationType string
+	From          *time.Time // created_at >= From
+	To            *time.Time // created_at < To
+}
+
+// buildHistoryWhere monta a cláusula WHERE parametrizada para os filtros informados
+func buildHistoryWhere(userID string, filter HistoryFilter) (string, []interface{}) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.OperationType != "" {
+		add("operation_type = $%d", filter.OperationType)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListOperationHistory retorna uma página do histórico de um usuário e o total de
+// entradas que atendem aos filtros
+func (r *QueueRepository) ListOperationHistory(userID string, filter HistoryFilter) ([]OperationHistory, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultHistoryLimit
+	}
+	if filter.Limit > MaxHistoryLimit {
+		filter.Limit = MaxHistoryLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	where, args := buildHistoryWhere(userID, filter)
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM operation_history "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar histórico: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, operation_type, title, status, details, created_at
+		FROM operation_history
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar histórico: %w", err)
+	}
+	defer rows.Close()
+
+	history := []OperationHistory{}
+	for rows.Next() {
+		var h OperationHistory
+		var detailsJSON []byte
+
+		if err := rows.Scan(&h.ID, &h.UserID, &h.OperationType, &h.Title, &h.Status,
+			&detailsJSON, &h.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("erro ao escanear histórico: %w", err)
+		}
+
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &h.Details); err != nil {
+				return nil, 0, fmt.Errorf("erro ao deserializar details: %w", err)
+			}
+		}
+
+		history = append(history, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("erro ao iterar histórico: %w", err)
+	}
+	return history, total, nil
+}
+
+// GetAllOperationHistoryByUser percorre todo o histórico de um usuário (sem limite),
+// do mais recente para o mais antigo, chamando fn para cada entrada sem acumular em memória
+func (r *QueueRepository) GetAllOperationHistoryByUser(userID string, fn func(OperationHistory) error) error {
+	query := `
+		SELECT id, user_id, operation_type, title, status, details, created_at
+		FROM operation_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar histórico: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h OperationHistory
+		var detailsJSON []byte
+
+		if err := rows.Scan(&h.ID, &h.UserID, &h.OperationType, &h.Title, &h.Status,
+			&detailsJSON, &h.CreatedAt); err != nil {
+			return fmt.Errorf("erro ao escanear histórico: %w", err)
+		}
+
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &h.Details); err != nil {
+				return fmt.Errorf("erro ao deserializar details: %w", err)
+			}
+		}
+
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao iterar histórico: %w", err)
+	}
+	return nil
+}
+
 // DeleteAllHistoryByUser remove todo histórico de um usuário
 func (r *QueueRepository) DeleteAllHistoryByUser(userID string) error {
 	query := "DELETE FROM operation_history WHERE user_id = $1"
@@ -404,30 +785,34 @@ func (r *QueueRepository) DeleteAllHistoryByUser(userID string) error {
 	return nil
 }
 
-// CleanupOldHistory remove registros antigos mantendo apenas os últimos 1000
-func (r *QueueRepository) CleanupOldHistory() error {
+// CleanupHistoryPerUser remove registros antigos mantendo os keepPerUser mais
+// recentes de cada usuário e retorna quantos foram removidos
+func (r *QueueRepository) CleanupHistoryPerUser(keepPerUser int) (int64, error) {
 	query := `
-		DELETE FROM operation_history 
-		WHERE id NOT IN (
-			SELECT id FROM operation_history 
-			ORDER BY created_at DESC 
-			LIMIT 1000
+		DELETE FROM operation_history
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY user_id ORDER BY created_at DESC, id DESC
+				) AS position
+				FROM operation_history
+			) ranked
+			WHERE ranked.position > $1
 		)
 	`
 	
-	result, err := r.db.Exec(query)
+	result, err := r.db.Exec(query, keepPerUser)
 	if err != nil {
-		return fmt.Errorf("erro ao limpar histórico antigo: %w", err)
+		return 0, fmt.Errorf("erro ao limpar histórico antigo: %w", err)
 	}
 	
 	rowsAffected, _ := result.RowsAffected()
 	log := logger.Global()
-	log.Info().Int64("rows_deleted", rowsAffected).Msg("Histórico antigo removido")
+	log.Info().Int64("rows_deleted", rowsAffected).Int("keep_per_user", keepPerUser).Msg("Histórico antigo removido")
 	
-	return nil
+	return rowsAffected, nil
 }
 
-
 // GetOperationHistoryByID retorna uma entrada específica do histórico pelo ID
 func (r *QueueRepository) GetOperationHistoryByID(historyID int) (*OperationHistory, error) {
 	query := `
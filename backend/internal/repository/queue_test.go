@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// seedHistory insere entradas com created_at controlado para os testes de listagem
+func seedHistory(t *testing.T, repo *QueueRepository, base time.Time) {
+	t.Helper()
+
+	entries := []struct {
+		userID        string
+		operationType string
+		status        string
+		offset        time.Duration
+	}{
+		{"user-1", "report_generation", "completed", 0},
+		{"user-1", "field_update", "failed", 1 * time.Hour},
+		{"user-1", "field_update", "completed", 2 * time.Hour},
+		{"user-1", "report_generation", "failed", 3 * time.Hour},
+		{"user-1", "field_update", "pending", 4 * time.Hour},
+		{"user-2", "field_update", "completed", 0},
+	}
+
+	for i, e := range entries {
+		created, err := repo.CreateOperationHistory(OperationHistory{
+			UserID:        e.userID,
+			OperationType: e.operationType,
+			Title:         "op",
+			Status:        e.status,
+			Details:       map[string]interface{}{"index": i},
+		})
+		if err != nil {
+			t.Fatalf("criar histórico: %v", err)
+		}
+		if _, err := repo.db.Exec("UPDATE operation_history SET created_at = $1 WHERE id = $2", base.Add(e.offset), created.ID); err != nil {
+			t.Fatalf("ajustar created_at: %v", err)
+		}
+	}
+}
+
+func TestListOperationHistoryFilters(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	seedHistory(t, repo, base)
+
+	from := base.Add(1 * time.Hour)
+	to := base.Add(3 * time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    HistoryFilter
+		wantTotal int
+		wantPage  int
+	}{
+		{"default", HistoryFilter{}, 5, 5},
+		{"limit", HistoryFilter{Limit: 2}, 5, 2},
+		{"offset", HistoryFilter{Limit: 2, Offset: 4}, 5, 1},
+		{"status", HistoryFilter{Status: "failed"}, 2, 2},
+		{"operation_type", HistoryFilter{OperationType: "field_update"}, 3, 3},
+		{"from", HistoryFilter{From: &from}, 4, 4},
+		{"to", HistoryFilter{To: &to}, 2, 2},
+		{"date range", HistoryFilter{From: &from, To: &to}, 2, 2},
+		{"combined", HistoryFilter{Status: "completed", OperationType: "field_update"}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total, err := repo.ListOperationHistory("user-1", tt.filter)
+			if err != nil {
+				t.Fatalf("ListOperationHistory: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, esperado %d", total, tt.wantTotal)
+			}
+			if len(page) != tt.wantPage {
+				t.Errorf("página com %d entradas, esperado %d", len(page), tt.wantPage)
+			}
+			for i := 1; i < len(page); i++ {
+				if page[i].CreatedAt.After(page[i-1].CreatedAt) {
+					t.Errorf("página fora de ordem decrescente")
+				}
+			}
+		})
+	}
+}
+
+func TestBuildHistoryWhere(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := buildHistoryWhere("u1", HistoryFilter{})
+	if where != "WHERE user_id = $1" || len(args) != 1 {
+		t.Errorf("sem filtros: %s %v", where, args)
+	}
+
+	where, args = buildHistoryWhere("u1", HistoryFilter{Status: "failed' OR '1'='1", From: &from})
+	wantWhere := "WHERE user_id = $1 AND status = $2 AND created_at >= $3"
+	if where != wantWhere {
+		t.Errorf("where = %s, esperado %s", where, wantWhere)
+	}
+	wantArgs := []interface{}{"u1", "failed' OR '1'='1", from}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+// createPendingJob insere um job pendente com prioridade e idade controladas
+func createPendingJob(t *testing.T, repo *QueueRepository, title string, priority int, age time.Duration) int {
+	t.Helper()
+
+	job, err := repo.CreateJob(UpdateJob{
+		UserID:   "user-1",
+		Title:    title,
+		Status:   "pending",
+		FilePath: "/tmp/" + title + ".xlsx",
+		Mapping:  map[string]string{"id task": "id"},
+		Priority: priority,
+	})
+	if err != nil {
+		t.Fatalf("criar job: %v", err)
+	}
+	if _, err := repo.db.Exec("UPDATE job_queue SET created_at = NOW() - $1::interval WHERE id = $2",
+		fmt.Sprintf("%d seconds", int(age.Seconds())), job.ID); err != nil {
+		t.Fatalf("ajustar created_at: %v", err)
+	}
+	return job.ID
+}
+
+func pendingTitles(t *testing.T, repo *QueueRepository) []string {
+	t.Helper()
+	jobs, err := repo.GetPendingJobs()
+	if err != nil {
+		t.Fatalf("GetPendingJobs: %v", err)
+	}
+	titles := make([]string, len(jobs))
+	for i, job := range jobs {
+		titles[i] = job.Title
+	}
+	return titles
+}
+
+func TestGetPendingJobsPriorityOrder(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+	repo.SetPriorityAging(0)
+
+	createPendingJob(t, repo, "normal-old", JobPriorityNormal, 3*time.Minute)
+	createPendingJob(t, repo, "low", JobPriorityLow, 2*time.Minute)
+	createPendingJob(t, repo, "high", JobPriorityHigh, 1*time.Minute)
+	createPendingJob(t, repo, "normal-new", JobPriorityNormal, 0)
+
+	want := []string{"high", "normal-old", "normal-new", "low"}
+	if got := pendingTitles(t, repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("ordem = %v, esperado %v", got, want)
+	}
+}
+
+func TestGetPendingJobsPriorityAging(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+	repo.SetPriorityAging(30 * time.Minute)
+
+	// low esperando 1h sobe dois níveis e, por ser mais antigo, passa à frente do high recente
+	createPendingJob(t, repo, "low-aged", JobPriorityLow, time.Hour)
+	createPendingJob(t, repo, "high", JobPriorityHigh, time.Minute)
+	// low recente continua atrás
+	createPendingJob(t, repo, "low-new", JobPriorityLow, 0)
+
+	want := []string{"low-aged", "high", "low-new"}
+	if got := pendingTitles(t, repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("ordem = %v, esperado %v", got, want)
+	}
+}
+
+func TestParseJobPriority(t *testing.T) {
+	for name, want := range map[string]int{"": JobPriorityNormal, "low": JobPriorityLow, "normal": JobPriorityNormal, "high": JobPriorityHigh} {
+		got, err := ParseJobPriority(name)
+		if err != nil || got != want {
+			t.Errorf("ParseJobPriority(%q) = %d, %v", name, got, err)
+		}
+		if name != "" && JobPriorityName(got) != name {
+			t.Errorf("JobPriorityName(%d) = %s", got, JobPriorityName(got))
+		}
+	}
+	if _, err := ParseJobPriority("urgent"); err == nil {
+		t.Error("prioridade desconhecida deveria falhar")
+	}
+}
+
+func TestGetPendingJobsSkipsFutureScheduled(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	repo.SetClock(func() time.Time { return now })
+
+	runAt := now.Add(2 * time.Hour)
+	if _, err := repo.CreateJob(UpdateJob{
+		UserID:      "user-1",
+		Title:       "agendado",
+		Status:      "pending",
+		FilePath:    "/tmp/agendado.xlsx",
+		ScheduledAt: &runAt,
+	}); err != nil {
+		t.Fatalf("criar job: %v", err)
+	}
+	createPendingJob(t, repo, "imediato", JobPriorityNormal, 0)
+
+	if got := pendingTitles(t, repo); !reflect.DeepEqual(got, []string{"imediato"}) {
+		t.Errorf("antes do horário: %v", got)
+	}
+
+	next, err := repo.GetNextScheduledTime()
+	if err != nil || next == nil || !next.Equal(runAt) {
+		t.Errorf("próximo agendamento = %v, %v; esperado %v", next, err, runAt)
+	}
+
+	// avança o relógio além do horário agendado
+	now = runAt.Add(time.Second)
+	got := pendingTitles(t, repo)
+	if len(got) != 2 {
+		t.Errorf("após o horário: %v", got)
+	}
+	if next, _ := repo.GetNextScheduledTime(); next != nil {
+		t.Errorf("não deveria haver agendamentos futuros, obtido %v", next)
+	}
+}
+
+func TestGetQueueStats(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+
+	if stats, err := repo.GetQueueStats(); err != nil || *stats != (QueueStats{}) {
+		t.Fatalf("fila vazia: %+v, %v", stats, err)
+	}
+
+	createPendingJob(t, repo, "antigo", JobPriorityNormal, 40*time.Minute)
+	createPendingJob(t, repo, "recente", JobPriorityNormal, time.Minute)
+	processing := createPendingJob(t, repo, "rodando", JobPriorityNormal, 2*time.Hour)
+	if err := repo.UpdateJobStatus(processing, "processing"); err != nil {
+		t.Fatalf("UpdateJobStatus: %v", err)
+	}
+	done := createPendingJob(t, repo, "concluido", JobPriorityNormal, 3*time.Hour)
+	if err := repo.UpdateJobStatus(done, "completed"); err != nil {
+		t.Fatalf("UpdateJobStatus: %v", err)
+	}
+	runAt := time.Now().Add(time.Hour)
+	if _, err := repo.CreateJob(UpdateJob{
+		UserID:      "user-1",
+		Title:       "agendado",
+		Status:      "pending",
+		FilePath:    "/tmp/agendado.xlsx",
+		ScheduledAt: &runAt,
+	}); err != nil {
+		t.Fatalf("criar job: %v", err)
+	}
+
+	stats, err := repo.GetQueueStats()
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	if stats.Pending != 2 || stats.Scheduled != 1 || stats.Processing != 1 {
+		t.Errorf("contagens = %+v", stats)
+	}
+	// O mais antigo pronto espera ~40min; jobs em processamento e concluídos não contam
+	if stats.OldestPendingAge < 39*time.Minute || stats.OldestPendingAge > 42*time.Minute {
+		t.Errorf("idade do mais antigo = %v, esperado ~40m", stats.OldestPendingAge)
+	}
+}
+
+func TestCleanupHistoryPerUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewQueueRepository(db)
+
+	// user-1 tem 5 registros, user-2 tem 1 e user-3 tem 3
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	seedHistory(t, repo, base)
+	for i := 0; i < 3; i++ {
+		created, err := repo.CreateOperationHistory(OperationHistory{
+			UserID: "user-3", OperationType: "report_generation", Title: fmt.Sprintf("op-%d", i), Status: "completed",
+		})
+		if err != nil {
+			t.Fatalf("criar histórico: %v", err)
+		}
+		if _, err := repo.db.Exec("UPDATE operation_history SET created_at = $1 WHERE id = $2", base.Add(time.Duration(i)*time.Hour), created.ID); err != nil {
+			t.Fatalf("ajustar created_at: %v", err)
+		}
+	}
+
+	removed, err := repo.CleanupHistoryPerUser(2)
+	if err != nil {
+		t.Fatalf("CleanupHistoryPerUser: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("removidos = %d, esperado 4 (3 de user-1 e 1 de user-3)", removed)
+	}
+
+	expected := map[string][]time.Time{
+		"user-1": {base.Add(4 * time.Hour), base.Add(3 * time.Hour)},
+		"user-2": {base},
+		"user-3": {base.Add(2 * time.Hour), base.Add(1 * time.Hour)},
+	}
+	for userID, want := range expected {
+		history, err := repo.GetOperationHistoryByUser(userID)
+		if err != nil {
+			t.Fatalf("GetOperationHistoryByUser(%s): %v", userID, err)
+		}
+		var got []time.Time
+		for _, h := range history {
+			got = append(got, h.CreatedAt.UTC())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: mantidos %v, esperado %v", userID, got, want)
+		}
+	}
+}
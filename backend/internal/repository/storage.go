@@ -47,6 +47,22 @@ type TaskStorage struct {
 	taskCount  int
 	folderName string
 	requestID  int32
+	cursor     FetchCursor
+}
+
+// FetchCursor registra o progresso da coleta por lista, permitindo retomar de onde parou
+type FetchCursor struct {
+	NextPage  map[string]int  `json:"next_page"` // listID -> próxima página a buscar
+	Completed map[string]bool `json:"completed"` // listas coletadas por completo
+	Tasks     map[string]int  `json:"tasks"`     // listID -> tasks gravadas
+}
+
+func newFetchCursor() FetchCursor {
+	return FetchCursor{
+		NextPage:  make(map[string]int),
+		Completed: make(map[string]bool),
+		Tasks:     make(map[string]int),
+	}
 }
 
 // NewTaskStorage cria um novo storage temporário com ID único
@@ -68,9 +84,66 @@ func NewTaskStorage() (*TaskStorage, error) {
 		filePath:  filePath,
 		encoder:   json.NewEncoder(file),
 		requestID: reqID,
+		cursor:    newFetchCursor(),
 	}, nil
 }
 
+// OpenTaskStorage reabre um storage existente para retomar a coleta,
+// recuperando as tasks já gravadas e o cursor salvo ao lado do arquivo
+func OpenTaskStorage(filePath string) (*TaskStorage, error) {
+	reqID := nextRequestID()
+
+	storage := &TaskStorage{
+		filePath:  filePath,
+		requestID: reqID,
+		cursor:    newFetchCursor(),
+	}
+
+	// Carrega cursor salvo (ausente = coleta do zero)
+	if data, err := os.ReadFile(storage.cursorPath()); err == nil {
+		if err := json.Unmarshal(data, &storage.cursor); err != nil {
+			return nil, fmt.Errorf("ler cursor: %w", err)
+		}
+		if storage.cursor.NextPage == nil {
+			storage.cursor.NextPage = make(map[string]int)
+		}
+		if storage.cursor.Completed == nil {
+			storage.cursor.Completed = make(map[string]bool)
+		}
+		if storage.cursor.Tasks == nil {
+			storage.cursor.Tasks = make(map[string]int)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("ler cursor: %w", err)
+	}
+
+	// Reconta tasks já gravadas e recupera folder_name
+	iter, err := storage.iterate()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		storage.taskCount++
+		if storage.folderName == "" {
+			storage.folderName = reportFolderName(iter.Task())
+		}
+	}
+	iter.Close()
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("ler tasks existentes: %w", err)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("abrir arquivo para escrita: %w", err)
+	}
+	storage.file = file
+	storage.encoder = json.NewEncoder(file)
+
+	log.Printf("[Storage] Requisição #%d - Arquivo reaberto para retomada: %s (%d tasks)", reqID, filePath, storage.taskCount)
+	return storage, nil
+}
+
 // AppendTasks adiciona tasks ao storage (append, não carrega em memória)
 func (s *TaskStorage) AppendTasks(tasks []model.Task) error {
 	s.mu.Lock()
@@ -83,8 +156,8 @@ func (s *TaskStorage) AppendTasks(tasks []model.Task) error {
 		s.taskCount++
 
 		// Captura folder_name da primeira task
-		if s.folderName == "" && task.Folder.Name != "" {
-			s.folderName = task.Folder.Name
+		if s.folderName == "" {
+			s.folderName = reportFolderName(task)
 		}
 	}
 
@@ -96,6 +169,62 @@ func (s *TaskStorage) AppendTasks(tasks []model.Task) error {
 	return nil
 }
 
+// AppendPage grava as tasks de uma página e avança o cursor da lista.
+// O cursor só avança após as tasks estarem em disco, então uma falha
+// no meio da coleta nunca pula nem duplica páginas ao retomar.
+func (s *TaskStorage) AppendPage(listID string, page int, tasks []model.Task, lastPage bool) error {
+	if err := s.AppendTasks(tasks); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursor.NextPage[listID] = page + 1
+	s.cursor.Tasks[listID] += len(tasks)
+	if lastPage {
+		s.cursor.Completed[listID] = true
+	}
+
+	return s.saveCursor()
+}
+
+// ResumePoint retorna a próxima página a buscar de uma lista e se ela já foi concluída
+func (s *TaskStorage) ResumePoint(listID string) (page int, completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor.NextPage[listID], s.cursor.Completed[listID]
+}
+
+// ListTaskCount retorna quantas tasks de uma lista já foram gravadas
+func (s *TaskStorage) ListTaskCount(listID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor.Tasks[listID]
+}
+
+// cursorPath retorna o caminho do arquivo de cursor associado ao storage
+func (s *TaskStorage) cursorPath() string {
+	return s.filePath + ".cursor"
+}
+
+// saveCursor persiste o cursor em disco (deve ser chamado com s.mu travado)
+func (s *TaskStorage) saveCursor() error {
+	data, err := json.Marshal(s.cursor)
+	if err != nil {
+		return fmt.Errorf("serializar cursor: %w", err)
+	}
+
+	tmpPath := s.cursorPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("salvar cursor: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.cursorPath()); err != nil {
+		return fmt.Errorf("salvar cursor: %w", err)
+	}
+	return nil
+}
+
 // GetTaskCount retorna o número total de tasks armazenadas
 func (s *TaskStorage) GetTaskCount() int {
 	s.mu.Lock()
@@ -110,6 +239,15 @@ func (s *TaskStorage) GetFolderName() string {
 	return s.folderName
 }
 
+// reportFolderName nome usado no arquivo do relatório: a pasta da task ou, para listas
+// direto no space (pasta oculta no ClickUp), o nome da lista
+func reportFolderName(task model.Task) string {
+	if task.Folder.Hidden {
+		return task.List.Name
+	}
+	return task.Folder.Name
+}
+
 // TaskIterator permite iterar sobre tasks sem carregar tudo em memória
 type TaskIterator struct {
 	file    *os.File
@@ -125,6 +263,11 @@ func (s *TaskStorage) NewIterator() (*TaskIterator, error) {
 		return nil, fmt.Errorf("fechar arquivo de escrita: %w", err)
 	}
 
+	return s.iterate()
+}
+
+// iterate abre o arquivo de tasks para leitura em streaming
+func (s *TaskStorage) iterate() (*TaskIterator, error) {
 	// Abre para leitura
 	file, err := os.Open(s.filePath)
 	if err != nil {
@@ -176,6 +319,11 @@ func (s *TaskStorage) Close() error {
 	// Tenta fechar o arquivo (pode já estar fechado)
 	s.file.Close()
 
+	// Remove o cursor da coleta, se existir
+	if err := os.Remove(s.cursorPath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("[Storage] Aviso: não foi possível remover cursor: %v", err)
+	}
+
 	// Remove o arquivo temporário
 	if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
 		log.Printf("[Storage] Aviso: não foi possível remover arquivo temporário: %v", err)
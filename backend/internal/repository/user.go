@@ -2,14 +2,21 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrUsernameTaken is returned when another user has the same username, ignoring case
+var ErrUsernameTaken = errors.New("nome de usuário já cadastrado")
+
 // User represents a user in the system
 type User struct {
 	ID           int       `json:"id" db:"id"`
 	Username     string    `json:"username" db:"username"`
 	PasswordHash string    `json:"-" db:"password_hash"` // Never expose password hash in JSON
+	Role         string    `json:"role" db:"role"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -24,12 +31,12 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// GetByUsername retrieves a user by username
+// GetByUsername retrieves a user by username, ignoring case
 func (r *UserRepository) GetByUsername(username string) (*User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT id, username, password_hash, role, created_at, updated_at
 		FROM users
-		WHERE username = $1
+		WHERE LOWER(username) = LOWER($1)
 	`
 
 	var user User
@@ -37,6 +44,7 @@ func (r *UserRepository) GetByUsername(username string) (*User, error) {
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -51,24 +59,29 @@ func (r *UserRepository) GetByUsername(username string) (*User, error) {
 	return &user, nil
 }
 
-// Create creates a new user
-func (r *UserRepository) Create(username, passwordHash string) (*User, error) {
+// Create creates a new user with the given role
+func (r *UserRepository) Create(username, passwordHash, role string) (*User, error) {
 	query := `
-		INSERT INTO users (username, password_hash, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, username, password_hash, created_at, updated_at
+		INSERT INTO users (username, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, username, password_hash, role, created_at, updated_at
 	`
 
 	var user User
-	err := r.db.QueryRow(query, username, passwordHash).Scan(
+	err := r.db.QueryRow(query, username, passwordHash, role).Scan(
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return nil, ErrUsernameTaken
+		}
 		return nil, err
 	}
 
@@ -80,7 +93,7 @@ func (r *UserRepository) UpdatePassword(username, passwordHash string) error {
 	query := `
 		UPDATE users
 		SET password_hash = $1, updated_at = NOW()
-		WHERE username = $2
+		WHERE LOWER(username) = LOWER($2)
 	`
 
 	result, err := r.db.Exec(query, passwordHash, username)
@@ -102,7 +115,7 @@ func (r *UserRepository) UpdatePassword(username, passwordHash string) error {
 
 // Delete removes a user
 func (r *UserRepository) Delete(username string) error {
-	query := `DELETE FROM users WHERE username = $1`
+	query := `DELETE FROM users WHERE LOWER(username) = LOWER($1)`
 
 	result, err := r.db.Exec(query, username)
 	if err != nil {
@@ -124,7 +137,7 @@ func (r *UserRepository) Delete(username string) error {
 // List returns all users (without password hashes)
 func (r *UserRepository) List() ([]*User, error) {
 	query := `
-		SELECT id, username, created_at, updated_at
+		SELECT id, username, role, created_at, updated_at
 		FROM users
 		ORDER BY username
 	`
@@ -141,6 +154,7 @@ func (r *UserRepository) List() ([]*User, error) {
 		err := rows.Scan(
 			&user.ID,
 			&user.Username,
+			&user.Role,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
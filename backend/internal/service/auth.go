@@ -12,6 +12,7 @@ var (
 	ErrUserNotFound      = errors.New("usuário não encontrado")
 	ErrInvalidCredentials = errors.New("credenciais inválidas")
 	ErrUserAlreadyExists = errors.New("usuário já existe")
+	ErrInvalidRole       = errors.New("papel de usuário inválido (use admin ou user)")
 )
 
 // AuthService handles authentication business logic
@@ -19,6 +20,7 @@ type AuthService struct {
 	userRepo       *repository.UserRepository
 	authMiddleware *middleware.BasicAuthMiddleware
 	csrfMiddleware *middleware.CSRFMiddleware
+	cookies        middleware.CookieAttributes // shared by the session and CSRF cookies
 }
 
 // NewAuthService creates a new authentication service
@@ -67,6 +69,30 @@ func (s *AuthService) GetCSRFMiddleware() *middleware.CSRFMiddleware {
 	return s.csrfMiddleware
 }
 
+// SetCSRFConfig sets the CSRF strategy and token duration (<= 0 keeps 24h).
+// Must be called before the routes are registered, since it replaces the middleware.
+func (s *AuthService) SetCSRFConfig(strategy middleware.CSRFStrategy, tokenDuration time.Duration) {
+	if tokenDuration <= 0 {
+		tokenDuration = 24 * time.Hour
+	}
+	s.csrfMiddleware = middleware.NewCSRFMiddleware(middleware.CSRFConfig{
+		TokenDuration:  tokenDuration,
+		Strategy:       strategy,
+		CookieDomain:   s.cookies.Domain,
+		CookieSecure:   s.cookies.Secure,
+		CookiePath:     s.cookies.Path,
+		CookieSameSite: s.cookies.SameSite,
+	})
+}
+
+// SetCookieAttributes sets the domain, path, Secure flag and SameSite mode of the
+// session and CSRF cookies (defaults: host-only, "/", not Secure, Lax)
+func (s *AuthService) SetCookieAttributes(attrs middleware.CookieAttributes) {
+	s.cookies = attrs
+	s.authMiddleware.SetCookieAttributes(attrs)
+	s.csrfMiddleware.SetCookieAttributes(attrs)
+}
+
 // loadUsersIntoMiddleware loads all users from database into middleware
 func (s *AuthService) loadUsersIntoMiddleware() error {
 	users, err := s.userRepo.List()
@@ -85,14 +111,29 @@ func (s *AuthService) loadUsersIntoMiddleware() error {
 			continue // Skip users that can't be loaded
 		}
 		s.authMiddleware.AddUser(user.Username, fullUser.PasswordHash)
+		s.authMiddleware.SetUserRole(user.Username, user.Role)
 	}
 
 	return nil
 }
 
-// CreateUser creates a new user with hashed password
+// CreateUser creates a new user with hashed password and the default role
 func (s *AuthService) CreateUser(username, password string) error {
+	return s.CreateUserWithRole(username, password, middleware.RoleUser)
+}
+
+// CreateUserWithRole creates a new user with hashed password and the given role.
+// Usernames are stored in canonical (lower) case, so "Admin" conflicts with "admin".
+func (s *AuthService) CreateUserWithRole(username, password, role string) error {
+	if !middleware.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	username = middleware.CanonicalUsername(username)
+
 	// Check if user already exists
+	if s.authMiddleware.HasUser(username) {
+		return ErrUserAlreadyExists
+	}
 	existingUser, err := s.userRepo.GetByUsername(username)
 	if err != nil {
 		return err
@@ -107,14 +148,18 @@ func (s *AuthService) CreateUser(username, password string) error {
 		return err
 	}
 
-	// Create user in database
-	_, err = s.userRepo.Create(username, passwordHash)
+	// Create user in database (the unique index catches concurrent creations)
+	_, err = s.userRepo.Create(username, passwordHash, role)
+	if errors.Is(err, repository.ErrUsernameTaken) {
+		return ErrUserAlreadyExists
+	}
 	if err != nil {
 		return err
 	}
 
 	// Add user to middleware
 	s.authMiddleware.AddUser(username, passwordHash)
+	s.authMiddleware.SetUserRole(username, role)
 
 	return nil
 }
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TestCreateUserCaseInsensitive verifica que usernames que só diferem na caixa são o
+// mesmo usuário: a criação repetida é rejeitada e o login aceita qualquer caixa
+func TestCreateUserCaseInsensitive(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewAuthService(userRepo)
+
+	if err := svc.CreateUserWithRole("Admin", "senha123", middleware.RoleAdmin); err != nil {
+		t.Fatalf("CreateUserWithRole: %v", err)
+	}
+
+	stored, err := userRepo.GetByUsername("ADMIN")
+	if err != nil || stored == nil || stored.Username != "admin" {
+		t.Fatalf("usuário deveria ser gravado em minúsculas: %+v, %v", stored, err)
+	}
+
+	for _, duplicate := range []string{"admin", "ADMIN", " aDmIn "} {
+		if err := svc.CreateUser(duplicate, "outra123"); !errors.Is(err, ErrUserAlreadyExists) {
+			t.Errorf("%q: esperado ErrUserAlreadyExists, obtido %v", duplicate, err)
+		}
+	}
+
+	// A restrição do banco vale mesmo sem passar pelo serviço
+	if _, err := userRepo.Create("ADMIN", "hash", middleware.RoleUser); !errors.Is(err, repository.ErrUsernameTaken) {
+		t.Errorf("esperado ErrUsernameTaken do banco, obtido %v", err)
+	}
+
+	// Um novo serviço carrega os usuários do banco com a mesma regra
+	reloaded := NewAuthService(userRepo)
+	for _, login := range []string{"admin", "Admin", "ADMIN"} {
+		if !reloaded.ValidateCredentials(login, "senha123") {
+			t.Errorf("login %q deveria ser aceito", login)
+		}
+	}
+	if role := reloaded.GetAuthMiddleware().UserRole("AdMiN"); role != middleware.RoleAdmin {
+		t.Errorf("papel = %q, esperado admin", role)
+	}
+}
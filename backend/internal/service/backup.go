@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// Backup record kinds, one JSON object per line
+const (
+	BackupKindJob     = "job"
+	BackupKindHistory = "history"
+)
+
+// maxBackupImportErrors caps the per-line errors returned by an import
+const maxBackupImportErrors = 100
+
+// importedJobNote is added to jobs that were still pending or running at the source
+const importedJobNote = "importado de backup: job não concluído na origem"
+
+// ErrEmptyBackup is returned when an import file has no records
+var ErrEmptyBackup = errors.New("arquivo de backup sem registros")
+
+// BackupRecord is one line of the export: a job or a history entry
+type BackupRecord struct {
+	Kind    string                       `json:"kind"`
+	Job     *repository.UpdateJob        `json:"job,omitempty"`
+	History *repository.OperationHistory `json:"history,omitempty"`
+}
+
+// BackupImportError describes a line that could not be imported
+type BackupImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// BackupImportSummary counts the outcomes of an import. Skipped records already
+// existed (same id), so importing the same file twice changes nothing.
+type BackupImportSummary struct {
+	JobsImported    int                 `json:"jobs_imported"`
+	JobsSkipped     int                 `json:"jobs_skipped"`
+	HistoryImported int                 `json:"history_imported"`
+	HistorySkipped  int                 `json:"history_skipped"`
+	Failed          int                 `json:"failed"`
+	Errors          []BackupImportError `json:"errors,omitempty"` // first maxBackupImportErrors failures
+}
+
+// backupStore is the part of QueueRepository used by backups (faked in tests)
+type backupStore interface {
+	StreamJobs(userID string, fn func(repository.UpdateJob) error) error
+	StreamOperationHistory(userID string, fn func(repository.OperationHistory) error) error
+	ImportJob(job repository.UpdateJob) (bool, error)
+	ImportOperationHistory(history repository.OperationHistory) (bool, error)
+	ResetImportSequences() error
+}
+
+// BackupService exports and imports the job queue and operation history, for
+// backups and for moving data between environments
+type BackupService struct {
+	store backupStore
+}
+
+// NewBackupService creates a backup service
+func NewBackupService(queueRepo *repository.QueueRepository) *BackupService {
+	return &BackupService{store: queueRepo}
+}
+
+// Export writes the jobs and history of userID (every user when empty) to w as
+// newline-delimited JSON, streaming rows from the database in batches
+func (s *BackupService) Export(userID string, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	if err := s.store.StreamJobs(userID, func(job repository.UpdateJob) error {
+		return encoder.Encode(BackupRecord{Kind: BackupKindJob, Job: &job})
+	}); err != nil {
+		return err
+	}
+
+	return s.store.StreamOperationHistory(userID, func(history repository.OperationHistory) error {
+		return encoder.Encode(BackupRecord{Kind: BackupKindHistory, History: &history})
+	})
+}
+
+// Import reads an export produced by Export and inserts its records keeping their
+// ids. Each line is validated and handled on its own: invalid lines are reported
+// and the rest of the file is still imported. Records whose id already exists are
+// skipped. The error is only set when the file can't be read or has no records.
+func (s *BackupService) Import(r io.Reader) (BackupImportSummary, error) {
+	var summary BackupImportSummary
+	reader := bufio.NewReader(r)
+	records := 0
+
+	fail := func(line int, err error) {
+		summary.Failed++
+		if len(summary.Errors) < maxBackupImportErrors {
+			summary.Errors = append(summary.Errors, BackupImportError{Line: line, Error: err.Error()})
+		}
+	}
+
+	for line := 1; ; line++ {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return summary, fmt.Errorf("erro ao ler backup: %w", readErr)
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			records++
+			if err := s.importRecord(data, &summary); err != nil {
+				fail(line, err)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if records == 0 {
+		return summary, ErrEmptyBackup
+	}
+	if summary.JobsImported > 0 || summary.HistoryImported > 0 {
+		if err := s.store.ResetImportSequences(); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// importRecord validates and inserts one line of the backup
+func (s *BackupService) importRecord(data []byte, summary *BackupImportSummary) error {
+	var record BackupRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("JSON inválido: %v", err)
+	}
+
+	switch record.Kind {
+	case BackupKindJob:
+		if record.Job == nil {
+			return errors.New("registro job sem o campo job")
+		}
+		job := *record.Job
+		if err := validateBackupJob(job); err != nil {
+			return err
+		}
+		// Jobs that never finished at the source would be picked up by this queue,
+		// usually without their file: they are imported as failed instead
+		if job.Status == JobStatusPending || job.Status == JobStatusProcessing {
+			job.Status = JobStatusFailed
+			job.ErrorDetails = append(job.ErrorDetails, importedJobNote)
+		}
+		inserted, err := s.store.ImportJob(job)
+		if err != nil {
+			return err
+		}
+		if inserted {
+			summary.JobsImported++
+		} else {
+			summary.JobsSkipped++
+		}
+
+	case BackupKindHistory:
+		if record.History == nil {
+			return errors.New("registro history sem o campo history")
+		}
+		if err := validateBackupHistory(*record.History); err != nil {
+			return err
+		}
+		inserted, err := s.store.ImportOperationHistory(*record.History)
+		if err != nil {
+			return err
+		}
+		if inserted {
+			summary.HistoryImported++
+		} else {
+			summary.HistorySkipped++
+		}
+
+	default:
+		return fmt.Errorf("tipo de registro desconhecido: %q", record.Kind)
+	}
+	return nil
+}
+
+// validJobStatus reports whether status is one of the job statuses
+func validJobStatus(status string) bool {
+	switch status {
+	case JobStatusPending, JobStatusProcessing, JobStatusCompleted, JobStatusFailed:
+		return true
+	}
+	return false
+}
+
+// validOperationStatus reports whether status is one of the history statuses
+func validOperationStatus(status string) bool {
+	switch status {
+	case OperationStatusPending, OperationStatusProcessing, OperationStatusCompleted, OperationStatusFailed:
+		return true
+	}
+	return false
+}
+
+func validateBackupJob(job repository.UpdateJob) error {
+	switch {
+	case job.ID <= 0:
+		return errors.New("job sem id")
+	case strings.TrimSpace(job.UserID) == "":
+		return fmt.Errorf("job %d sem user_id", job.ID)
+	case strings.TrimSpace(job.Title) == "":
+		return fmt.Errorf("job %d sem título", job.ID)
+	case !validJobStatus(job.Status):
+		return fmt.Errorf("job %d com status inválido: %q", job.ID, job.Status)
+	case job.CreatedAt.IsZero():
+		return fmt.Errorf("job %d sem created_at", job.ID)
+	}
+	return nil
+}
+
+func validateBackupHistory(history repository.OperationHistory) error {
+	switch {
+	case history.ID <= 0:
+		return errors.New("histórico sem id")
+	case strings.TrimSpace(history.UserID) == "":
+		return fmt.Errorf("histórico %d sem user_id", history.ID)
+	case strings.TrimSpace(history.Title) == "":
+		return fmt.Errorf("histórico %d sem título", history.ID)
+	case history.OperationType != OperationTypeReportGeneration && history.OperationType != OperationTypeFieldUpdate:
+		return fmt.Errorf("histórico %d com operation_type inválido: %q", history.ID, history.OperationType)
+	case !validOperationStatus(history.Status):
+		return fmt.Errorf("histórico %d com status inválido: %q", history.ID, history.Status)
+	case history.CreatedAt.IsZero():
+		return fmt.Errorf("histórico %d sem created_at", history.ID)
+	}
+	return nil
+}
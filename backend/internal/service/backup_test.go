@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// memBackupStore keeps jobs and history in memory, keyed by id like the tables
+type memBackupStore struct {
+	jobs    map[int]repository.UpdateJob
+	history map[int]repository.OperationHistory
+	resets  int
+}
+
+func newMemBackupStore() *memBackupStore {
+	return &memBackupStore{jobs: map[int]repository.UpdateJob{}, history: map[int]repository.OperationHistory{}}
+}
+
+func (m *memBackupStore) StreamJobs(userID string, fn func(repository.UpdateJob) error) error {
+	var ids []int
+	for id, job := range m.jobs {
+		if userID == "" || job.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if err := fn(m.jobs[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memBackupStore) StreamOperationHistory(userID string, fn func(repository.OperationHistory) error) error {
+	var ids []int
+	for id, h := range m.history {
+		if userID == "" || h.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if err := fn(m.history[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memBackupStore) ImportJob(job repository.UpdateJob) (bool, error) {
+	if _, exists := m.jobs[job.ID]; exists {
+		return false, nil
+	}
+	m.jobs[job.ID] = job
+	return true, nil
+}
+
+func (m *memBackupStore) ImportOperationHistory(history repository.OperationHistory) (bool, error) {
+	if _, exists := m.history[history.ID]; exists {
+		return false, nil
+	}
+	m.history[history.ID] = history
+	return true, nil
+}
+
+func (m *memBackupStore) ResetImportSequences() error {
+	m.resets++
+	return nil
+}
+
+func TestBackupExportImportRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	completed := created.Add(time.Hour)
+
+	source := newMemBackupStore()
+	source.jobs[1] = repository.UpdateJob{
+		ID: 1, UserID: "ana", Title: "Atualização", Status: JobStatusCompleted, FilePath: "/tmp/a.xlsx",
+		Mapping: map[string]string{"Prazo": "field-1"}, TotalRows: 10, ProcessedRows: 10, SuccessCount: 9, ErrorCount: 1,
+		ErrorDetails: []string{"linha 3: valor inválido"}, Options: repository.JobOptions{Locale: "pt-BR"},
+		Priority: 1, CreatedAt: created, UpdatedAt: completed, CompletedAt: &completed,
+	}
+	source.jobs[2] = repository.UpdateJob{ID: 2, UserID: "bia", Title: "Pendente", Status: JobStatusPending, CreatedAt: created, UpdatedAt: created}
+	source.history[7] = repository.OperationHistory{
+		ID: 7, UserID: "ana", OperationType: OperationTypeFieldUpdate, Title: "Atualização", Status: OperationStatusCompleted,
+		Details: map[string]interface{}{"success_count": float64(9)}, CreatedAt: created,
+	}
+
+	svc := &BackupService{store: source}
+	var exported bytes.Buffer
+	if err := svc.Export("", &exported); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if lines := strings.Count(exported.String(), "\n"); lines != 3 {
+		t.Fatalf("esperado 3 linhas NDJSON, obtido %d:\n%s", lines, exported.String())
+	}
+
+	target := newMemBackupStore()
+	importer := &BackupService{store: target}
+	summary, err := importer.Import(bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if summary.JobsImported != 2 || summary.HistoryImported != 1 || summary.Failed != 0 {
+		t.Errorf("resumo = %+v", summary)
+	}
+	if target.resets != 1 {
+		t.Errorf("sequências ajustadas %d vezes", target.resets)
+	}
+
+	if !reflect.DeepEqual(target.jobs[1], source.jobs[1]) {
+		t.Errorf("job 1 importado = %+v\nesperado %+v", target.jobs[1], source.jobs[1])
+	}
+	if !reflect.DeepEqual(target.history[7], source.history[7]) {
+		t.Errorf("histórico importado = %+v", target.history[7])
+	}
+	// Job pendente na origem não volta para a fila
+	if job := target.jobs[2]; job.Status != JobStatusFailed || len(job.ErrorDetails) != 1 {
+		t.Errorf("job pendente importado = %+v", job)
+	}
+
+	// Importar de novo não duplica nada
+	summary, err = importer.Import(bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatalf("segundo Import: %v", err)
+	}
+	if summary.JobsImported != 0 || summary.JobsSkipped != 2 || summary.HistorySkipped != 1 {
+		t.Errorf("segundo resumo = %+v", summary)
+	}
+
+	// Exportação de um usuário só
+	exported.Reset()
+	if err := svc.Export("bia", &exported); err != nil {
+		t.Fatalf("Export bia: %v", err)
+	}
+	if strings.Count(exported.String(), "\n") != 1 || !strings.Contains(exported.String(), `"user_id":"bia"`) {
+		t.Errorf("exportação de bia:\n%s", exported.String())
+	}
+}
+
+func TestBackupImportReportsInvalidLines(t *testing.T) {
+	input := strings.Join([]string{
+		`{"kind":"history","history":{"id":1,"user_id":"ana","operation_type":"field_update","title":"ok","status":"completed","created_at":"2024-05-10T12:00:00Z"}}`,
+		`não é json`,
+		``,
+		`{"kind":"job","job":{"id":2,"user_id":"ana","title":"x","status":"unknown","created_at":"2024-05-10T12:00:00Z"}}`,
+		`{"kind":"user"}`,
+		`{"kind":"history","history":{"id":3,"user_id":"ana","operation_type":"delete","title":"x","status":"completed","created_at":"2024-05-10T12:00:00Z"}}`,
+	}, "\n")
+
+	store := newMemBackupStore()
+	summary, err := (&BackupService{store: store}).Import(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if summary.HistoryImported != 1 || summary.Failed != 4 {
+		t.Fatalf("resumo = %+v", summary)
+	}
+	var lines []int
+	for _, e := range summary.Errors {
+		lines = append(lines, e.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 4, 5, 6}) {
+		t.Errorf("linhas com erro = %v (%+v)", lines, summary.Errors)
+	}
+
+	if _, err := (&BackupService{store: store}).Import(strings.NewReader("\n\n")); err != ErrEmptyBackup {
+		t.Errorf("arquivo vazio: esperado ErrEmptyBackup, obtido %v", err)
+	}
+}
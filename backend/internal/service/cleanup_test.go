@@ -638,7 +638,7 @@ func TestUploadServiceTempFileCleanup(t *testing.T) {
 				return true
 			}
 
-			uploadService := NewUploadService(tempDir)
+			uploadService := NewUploadService(tempDir, 0)
 
 			// Create and process a file
 			csvContent := createCSVContent(testData.Columns, testData.Rows)
@@ -681,3 +681,82 @@ func TestUploadServiceTempFileCleanup(t *testing.T) {
 func createTempFilePath(tempDir, prefix string) string {
 	return filepath.Join(tempDir, prefix+"_"+time.Now().Format("20060102150405")+".tmp")
 }
+
+func TestUploadServiceTempFileSweeper(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+	uploadService.SetTempFileTTL(time.Hour)
+
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	uploadService.SetClock(func() time.Time { return now })
+
+	upload := func(name string) string {
+		content := "id task,Valor\nabc,1\n"
+		result, err := uploadService.ProcessFileWithOptions(name, strings.NewReader(content), int64(len(content)), UploadOptions{})
+		if err != nil {
+			t.Fatalf("ProcessFile: %v", err)
+		}
+		return result.TempPath
+	}
+
+	oldPath := upload("old.csv")
+	queuedPath := upload("queued.csv")
+	now = start.Add(50 * time.Minute)
+	recentPath := upload("recent.csv")
+
+	uploadService.SetInUseCheck(func(path string) (bool, error) {
+		return path == queuedPath, nil
+	})
+
+	now = start.Add(70 * time.Minute)
+	removed, reclaimed := uploadService.CleanupExpiredFiles()
+
+	if removed != 1 || reclaimed <= 0 {
+		t.Errorf("removed %d files (%d bytes), expected 1 file", removed, reclaimed)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("expired file should be removed, stat err = %v", err)
+	}
+	for _, path := range []string{queuedPath, recentPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(path), err)
+		}
+	}
+
+	// The recent file expires once it passes the TTL
+	now = start.Add(111 * time.Minute)
+	if removed, _ := uploadService.CleanupExpiredFiles(); removed != 1 {
+		t.Errorf("removed %d files, expected the recent one to expire", removed)
+	}
+	if _, err := os.Stat(recentPath); !os.IsNotExist(err) {
+		t.Errorf("recent file should be removed after its TTL, stat err = %v", err)
+	}
+}
+
+func TestUploadServiceAdoptsLeftoverTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	leftover := filepath.Join(tempDir, "upload_123.csv")
+	unrelated := filepath.Join(tempDir, "other_123.csv")
+	for _, path := range []string{leftover, unrelated} {
+		if err := os.WriteFile(path, []byte("id task\nabc\n"), 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	modTime := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(leftover, modTime, modTime)
+
+	uploadService := NewUploadService(tempDir, 0)
+	uploadService.adoptExistingTempFiles()
+
+	if removed, _ := uploadService.CleanupExpiredFiles(); removed != 1 {
+		t.Errorf("removed %d files, expected the leftover upload", removed)
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("leftover upload should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("files not created by uploads must be kept: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// accentReplacer maps accented Latin letters to their base letter
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y",
+)
+
+// NormalizeColumnName returns the form used to compare column names when a mapping
+// enables normalization: trimmed, inner whitespace collapsed, lowercase and without
+// accents, so "Descrição  Task " and "descricao task" are the same column.
+func NormalizeColumnName(name string) string {
+	name = strings.Join(strings.FieldsFunc(name, unicode.IsSpace), " ")
+	return accentReplacer.Replace(strings.ToLower(name))
+}
+
+// columnKey is the lookup key of a column name: only trimmed for exact matching,
+// fully normalized otherwise
+func columnKey(name string, normalize bool) string {
+	if normalize {
+		return NormalizeColumnName(name)
+	}
+	return strings.TrimSpace(name)
+}
+
+// mappingColumnKey is the lookup key used when validating mappings: without
+// normalization, validation has always ignored case and surrounding whitespace
+func mappingColumnKey(name string, normalize bool) string {
+	if normalize {
+		return NormalizeColumnName(name)
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// columnMatcher finds file columns by name, exactly or normalized
+type columnMatcher struct {
+	key   func(string) string
+	index map[string]int
+}
+
+// newColumnMatcher indexes columns; with repeated keys the first column wins
+func newColumnMatcher(columns []string, normalize bool) *columnMatcher {
+	return indexColumns(columns, func(name string) string { return columnKey(name, normalize) })
+}
+
+// newMappingColumnMatcher indexes columns for mapping validation (see mappingColumnKey)
+func newMappingColumnMatcher(columns []string, normalize bool) *columnMatcher {
+	return indexColumns(columns, func(name string) string { return mappingColumnKey(name, normalize) })
+}
+
+// indexColumns indexes columns by key; with repeated keys the first column wins
+func indexColumns(columns []string, key func(string) string) *columnMatcher {
+	m := &columnMatcher{key: key, index: make(map[string]int, len(columns))}
+	for i, col := range columns {
+		k := key(col)
+		if _, seen := m.index[k]; !seen {
+			m.index[k] = i
+		}
+	}
+	return m
+}
+
+// find returns the index of the column matching name
+func (m *columnMatcher) find(name string) (int, bool) {
+	i, ok := m.index[m.key(name)]
+	return i, ok
+}
+
+// resolveJobColumns rewrites the column names of a job mapping and its defaults to the
+// names found in the file header, so processing can use exact lookups. Names without a
+// matching column are kept as they are.
+func resolveJobColumns(columns []string, mapping map[string]string, defaults map[string]repository.FieldDefault) (map[string]string, map[string]repository.FieldDefault) {
+	matcher := newColumnMatcher(columns, true)
+	resolve := func(name string) string {
+		if i, ok := matcher.find(name); ok {
+			return columns[i]
+		}
+		return name
+	}
+
+	resolvedMapping := make(map[string]string, len(mapping))
+	for col, fieldID := range mapping {
+		resolvedMapping[resolve(col)] = fieldID
+	}
+	if defaults == nil {
+		return resolvedMapping, nil
+	}
+	resolvedDefaults := make(map[string]repository.FieldDefault, len(defaults))
+	for col, def := range defaults {
+		resolvedDefaults[resolve(col)] = def
+	}
+	return resolvedMapping, resolvedDefaults
+}
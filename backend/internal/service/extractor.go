@@ -25,8 +25,13 @@ var NativeFields = map[string]string{
 	"assignees":    "RESPONSÁVEIS",
 	"tags":         "TAGS",
 	"list":         "LISTA",
+	"list_id":      "ID DA LISTA",
 	"folder":       "PASTA",
 	"url":          "URL",
+
+	// Exigem include_activity no relatório
+	"comment_count":    "COMENTÁRIOS",
+	"attachment_count": "ANEXOS",
 }
 
 // Extractor é o serviço de extração de valores
@@ -103,10 +108,22 @@ func (e *Extractor) ExtractNativeValue(fieldKey string, task model.Task) string
 		return e.formatTags(task.Tags)
 	case "list":
 		return task.List.Name
+	case "list_id":
+		if task.SourceListID != "" {
+			return task.SourceListID
+		}
+		return task.List.ID
 	case "folder":
+		if task.Folder.Hidden {
+			return "" // lista direto no space
+		}
 		return task.Folder.Name
 	case "url":
 		return task.URL
+	case "comment_count":
+		return formatCount(task.CommentCount)
+	case "attachment_count":
+		return formatCount(task.AttachmentCount)
 	default:
 		return ""
 	}
@@ -424,3 +441,11 @@ func (e *Extractor) formatTags(tags []model.Tag) string {
 
 	return strings.Join(names, ", ")
 }
+
+// formatCount formata contagens opcionais (vazio quando não foram buscadas)
+func formatCount(count *int) string {
+	if count == nil {
+		return ""
+	}
+	return strconv.Itoa(*count)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 )
 
@@ -31,7 +32,7 @@ var (
 // HistoryService manages operation history tracking
 type HistoryService struct {
 	queueRepo       *repository.QueueRepository
-	maxRecords      int
+	maxPerUser      int
 	cleanupInterval time.Duration
 }
 
@@ -39,11 +40,18 @@ type HistoryService struct {
 func NewHistoryService(queueRepo *repository.QueueRepository) *HistoryService {
 	return &HistoryService{
 		queueRepo:       queueRepo,
-		maxRecords:      1000,
+		maxPerUser:      DefaultHistoryMaxPerUser,
 		cleanupInterval: 1 * time.Hour,
 	}
 }
 
+// SetMaxPerUser sets how many history records are kept for each user
+func (s *HistoryService) SetMaxPerUser(n int) {
+	if n > 0 {
+		s.maxPerUser = n
+	}
+}
+
 // CreateHistoryRecord creates a new operation history record on operation start
 func (s *HistoryService) CreateHistoryRecord(userID, operationType, title string, details map[string]interface{}) (*repository.OperationHistory, error) {
 	log := logger.Global()
@@ -75,7 +83,7 @@ func (s *HistoryService) CreateHistoryRecord(userID, operationType, title string
 		Msg("Registro de histórico criado")
 
 	// Trigger cleanup if needed (async)
-	go s.cleanupIfNeeded()
+	go s.cleanupIfNeeded(userID)
 
 	return createdHistory, nil
 }
@@ -106,6 +114,11 @@ func (s *HistoryService) GetHistoryByUser(userID string) ([]repository.Operation
 	return s.queueRepo.GetOperationHistoryByUser(userID)
 }
 
+// ListHistory retrieves a filtered page of history for a user and the total matching entries
+func (s *HistoryService) ListHistory(userID string, filter repository.HistoryFilter) ([]repository.OperationHistory, int, error) {
+	return s.queueRepo.ListOperationHistory(userID, filter)
+}
+
 // GetHistoryByID retrieves a specific operation history record by ID
 func (s *HistoryService) GetHistoryByID(historyID int) (*repository.OperationHistory, error) {
 	history, err := s.queueRepo.GetOperationHistoryByID(historyID)
@@ -132,21 +145,24 @@ func (s *HistoryService) DeleteAllHistoryByUser(userID string) error {
 	return nil
 }
 
-// cleanupIfNeeded checks if cleanup is needed and performs it
-func (s *HistoryService) cleanupIfNeeded() {
+// cleanupIfNeeded prunes the history when the user has more records than allowed
+func (s *HistoryService) cleanupIfNeeded(userID string) {
 	log := logger.Global()
 
-	count, err := s.queueRepo.GetOperationHistoryCount()
+	_, count, err := s.queueRepo.ListOperationHistory(userID, repository.HistoryFilter{Limit: 1})
 	if err != nil {
 		log.Warn().Err(err).Msg("Erro ao verificar contagem de histórico")
 		return
 	}
 
-	if count > s.maxRecords {
-		log.Info().Int("count", count).Int("max", s.maxRecords).Msg("Iniciando limpeza de histórico")
-		if err := s.queueRepo.CleanupOldHistory(); err != nil {
+	if count > s.maxPerUser {
+		log.Info().Str("user_id", userID).Int("count", count).Int("max", s.maxPerUser).Msg("Iniciando limpeza de histórico")
+		removed, err := s.queueRepo.CleanupHistoryPerUser(s.maxPerUser)
+		if err != nil {
 			log.Error().Err(err).Msg("Erro ao limpar histórico antigo")
+			return
 		}
+		metrics.Get().IncrementRetentionPruned(removed, 0)
 	}
 }
 
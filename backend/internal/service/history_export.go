@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// History export formats
+const (
+	HistoryExportCSV  = "csv"
+	HistoryExportJSON = "json"
+)
+
+// ErrInvalidExportFormat is returned for formats other than csv and json
+var ErrInvalidExportFormat = errors.New("formato de exportação inválido")
+
+// historyExportHeader lists the CSV columns, in order
+var historyExportHeader = []string{"id", "operation_type", "title", "status", "success_count", "error_count", "created_at"}
+
+// HistoryExportWriter writes history entries one at a time to the underlying writer
+type HistoryExportWriter interface {
+	Write(history repository.OperationHistory) error
+	Close() error
+}
+
+// NewHistoryExportWriter creates a streaming writer for the given format
+func NewHistoryExportWriter(format string, w io.Writer) (HistoryExportWriter, error) {
+	switch format {
+	case HistoryExportCSV:
+		return &csvHistoryWriter{w: csv.NewWriter(w)}, nil
+	case HistoryExportJSON:
+		return &jsonHistoryWriter{w: w}, nil
+	default:
+		return nil, ErrInvalidExportFormat
+	}
+}
+
+// ExportHistory streams the full history of a user in the given format
+func (s *HistoryService) ExportHistory(userID, format string, w io.Writer) error {
+	writer, err := NewHistoryExportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queueRepo.GetAllOperationHistoryByUser(userID, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// historyExportEntry is the flattened shape used by both formats
+type historyExportEntry struct {
+	ID            int    `json:"id"`
+	OperationType string `json:"operation_type"`
+	Title         string `json:"title"`
+	Status        string `json:"status"`
+	SuccessCount  *int   `json:"success_count"`
+	ErrorCount    *int   `json:"error_count"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func toHistoryExportEntry(history repository.OperationHistory) historyExportEntry {
+	return historyExportEntry{
+		ID:            history.ID,
+		OperationType: history.OperationType,
+		Title:         history.Title,
+		Status:        history.Status,
+		SuccessCount:  detailCount(history.Details, "success_count"),
+		ErrorCount:    detailCount(history.Details, "error_count"),
+		CreatedAt:     history.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// detailCount reads a numeric counter from Details (JSON numbers decode as float64)
+func detailCount(details map[string]interface{}, key string) *int {
+	switch v := details[key].(type) {
+	case float64:
+		n := int(v)
+		return &n
+	case int:
+		return &v
+	default:
+		return nil
+	}
+}
+
+type csvHistoryWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvHistoryWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write(historyExportHeader)
+}
+
+func (c *csvHistoryWriter) Write(history repository.OperationHistory) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	entry := toHistoryExportEntry(history)
+	return c.w.Write([]string{
+		strconv.Itoa(entry.ID),
+		entry.OperationType,
+		entry.Title,
+		entry.Status,
+		formatCount(entry.SuccessCount),
+		formatCount(entry.ErrorCount),
+		entry.CreatedAt,
+	})
+}
+
+func (c *csvHistoryWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonHistoryWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonHistoryWriter) Write(history repository.OperationHistory) error {
+	prefix := ","
+	if !j.started {
+		prefix = "["
+		j.started = true
+	}
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(toHistoryExportEntry(history))
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonHistoryWriter) Close() error {
+	if !j.started {
+		_, err := io.WriteString(j.w, "[]")
+		return err
+	}
+	_, err := io.WriteString(j.w, "]")
+	return err
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+func sampleHistory(n int) []repository.OperationHistory {
+	history := make([]repository.OperationHistory, n)
+	for i := range history {
+		history[i] = repository.OperationHistory{
+			ID:            i + 1,
+			OperationType: OperationTypeFieldUpdate,
+			Title:         "Atualização, lote \"A\"",
+			Status:        OperationStatusCompleted,
+			Details:       map[string]interface{}{"success_count": float64(10), "error_count": float64(i)},
+			CreatedAt:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		}
+	}
+	return history
+}
+
+// TestHistoryExportCSV checks the CSV header and that every entry becomes one row
+func TestHistoryExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewHistoryExportWriter(HistoryExportCSV, &buf)
+	if err != nil {
+		t.Fatalf("NewHistoryExportWriter: %v", err)
+	}
+
+	for _, h := range sampleHistory(120) {
+		if err := writer.Write(h); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if !reflect.DeepEqual(records[0], historyExportHeader) {
+		t.Errorf("header = %v", records[0])
+	}
+	if len(records)-1 != 120 {
+		t.Errorf("esperado 120 linhas, obtido %d", len(records)-1)
+	}
+	if got := records[3]; got[4] != "10" || got[5] != "2" {
+		t.Errorf("contagens = %s/%s, esperado 10/2", got[4], got[5])
+	}
+}
+
+// TestHistoryExportEmpty keeps both formats valid when the user has no history
+func TestHistoryExportEmpty(t *testing.T) {
+	var csvBuf, jsonBuf bytes.Buffer
+
+	csvWriter, _ := NewHistoryExportWriter(HistoryExportCSV, &csvBuf)
+	if err := csvWriter.Close(); err != nil {
+		t.Fatalf("Close csv: %v", err)
+	}
+	records, _ := csv.NewReader(&csvBuf).ReadAll()
+	if len(records) != 1 {
+		t.Errorf("esperado apenas o header, obtido %d linhas", len(records))
+	}
+
+	jsonWriter, _ := NewHistoryExportWriter(HistoryExportJSON, &jsonBuf)
+	if err := jsonWriter.Close(); err != nil {
+		t.Fatalf("Close json: %v", err)
+	}
+	if jsonBuf.String() != "[]" {
+		t.Errorf("json vazio = %q", jsonBuf.String())
+	}
+}
+
+// TestHistoryExportJSON checks that the streamed array decodes with all entries
+func TestHistoryExportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	writer, _ := NewHistoryExportWriter(HistoryExportJSON, &buf)
+	for _, h := range sampleHistory(3) {
+		if err := writer.Write(h); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var entries []historyExportEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+	if len(entries) != 3 || entries[2].ErrorCount == nil || *entries[2].ErrorCount != 2 {
+		t.Errorf("entradas = %+v", entries)
+	}
+}
+
+func TestHistoryExportInvalidFormat(t *testing.T) {
+	if _, err := NewHistoryExportWriter("xml", &bytes.Buffer{}); err != ErrInvalidExportFormat {
+		t.Errorf("esperado ErrInvalidExportFormat, obtido %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// ETA smoothing: the rate is measured over the last etaWindowSamples progress
+// samples, limited to etaWindow, so one slow or fast batch doesn't swing the estimate
+const (
+	etaWindowSamples = 10
+	etaWindow        = 2 * time.Minute
+)
+
+// etaSample is the number of processed rows at a point in time
+type etaSample struct {
+	at        time.Time
+	processed int
+}
+
+// etaEstimator estimates the remaining time of a job from its recent throughput
+type etaEstimator struct {
+	samples []etaSample
+	// maxRowsPerSecond is the ceiling imposed by the rate limiter (0 = unknown).
+	// The limiter's initial burst makes the first rows faster than the steady pace.
+	maxRowsPerSecond float64
+}
+
+// newETAEstimator creates an estimator for a job sending fieldsPerRow ClickUp requests
+// per row under a limit of requestsPerMinute
+func newETAEstimator(requestsPerMinute, fieldsPerRow int) *etaEstimator {
+	e := &etaEstimator{}
+	if requestsPerMinute > 0 && fieldsPerRow > 0 {
+		e.maxRowsPerSecond = float64(requestsPerMinute) / 60 / float64(fieldsPerRow)
+	}
+	return e
+}
+
+// observe records the processed rows at the given time
+func (e *etaEstimator) observe(at time.Time, processed int) {
+	e.samples = append(e.samples, etaSample{at: at, processed: processed})
+	if len(e.samples) > etaWindowSamples {
+		e.samples = e.samples[len(e.samples)-etaWindowSamples:]
+	}
+	// Keep at least two samples even when progress stalls longer than the window
+	for len(e.samples) > 2 && at.Sub(e.samples[0].at) > etaWindow {
+		e.samples = e.samples[1:]
+	}
+}
+
+// estimate returns the seconds left to process total rows, or false while there
+// isn't enough progress to measure a rate
+func (e *etaEstimator) estimate(total int) (int, bool) {
+	if len(e.samples) < 2 {
+		return 0, false
+	}
+	first, last := e.samples[0], e.samples[len(e.samples)-1]
+	elapsed := last.at.Sub(first.at).Seconds()
+	rows := last.processed - first.processed
+	if elapsed <= 0 || rows <= 0 {
+		return 0, false
+	}
+
+	rate := float64(rows) / elapsed
+	if e.maxRowsPerSecond > 0 && rate > e.maxRowsPerSecond {
+		rate = e.maxRowsPerSecond
+	}
+	remaining := total - last.processed
+	if remaining <= 0 {
+		return 0, true
+	}
+	return int(math.Ceil(float64(remaining) / rate)), true
+}
+
+// jobETAs keeps the latest estimate of each job being processed
+type jobETAs struct {
+	mu      sync.Mutex
+	seconds map[int]int
+}
+
+func (j *jobETAs) set(jobID, seconds int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.seconds == nil {
+		j.seconds = make(map[int]int)
+	}
+	j.seconds[jobID] = seconds
+}
+
+func (j *jobETAs) get(jobID int) (int, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	seconds, ok := j.seconds[jobID]
+	return seconds, ok
+}
+
+func (j *jobETAs) remove(jobID int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.seconds, jobID)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestETAEstimatorDecreasesAtSteadyRate(t *testing.T) {
+	// 10 linhas a cada 5 segundos = 2 linhas/s, bem abaixo do limite de 600 req/min
+	e := newETAEstimator(600, 1)
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	e.observe(start, 0)
+
+	if _, ok := e.estimate(200); ok {
+		t.Fatal("sem progresso não deveria haver estimativa")
+	}
+
+	previous := -1
+	for step := 1; step <= 19; step++ {
+		e.observe(start.Add(time.Duration(step)*5*time.Second), step*10)
+		seconds, ok := e.estimate(200)
+		if !ok {
+			t.Fatalf("passo %d: sem estimativa", step)
+		}
+		if want := (200 - step*10) / 2; seconds != want {
+			t.Errorf("passo %d: estimativa %ds, esperado %ds", step, seconds, want)
+		}
+		if previous >= 0 && seconds >= previous {
+			t.Errorf("passo %d: estimativa %ds não diminuiu (anterior %ds)", step, seconds, previous)
+		}
+		previous = seconds
+	}
+}
+
+func TestETAEstimatorSmoothsAndRespectsRateLimit(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	// Um lote lento isolado pesa pouco na janela
+	e := newETAEstimator(0, 1)
+	for step := 0; step <= 9; step++ {
+		e.observe(start.Add(time.Duration(step)*time.Second), step*10)
+	}
+	e.observe(start.Add(19*time.Second), 100) // 10 linhas em 10 segundos
+	seconds, _ := e.estimate(200)
+	if seconds > 25 {
+		t.Errorf("estimativa %ds oscilou demais após um lote lento", seconds)
+	}
+
+	// O burst inicial do rate limiter não vale como ritmo: 60 req/min com 2 campos
+	// por linha limita a 0,5 linha/s
+	limited := newETAEstimator(60, 2)
+	limited.observe(start, 0)
+	limited.observe(start.Add(time.Second), 25)
+	if seconds, _ := limited.estimate(125); seconds != 200 {
+		t.Errorf("estimativa com rate limit = %ds, esperado 200s", seconds)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// jobSummaryMaxErrors caps the error messages kept in a job summary
+const jobSummaryMaxErrors = 100
+
+// HistoryRecorder stores the outcome of finished operations (implemented by *HistoryService)
+type HistoryRecorder interface {
+	CreateHistoryRecord(userID, operationType, title string, details map[string]interface{}) (*repository.OperationHistory, error)
+	UpdateHistoryStatus(historyID int, status string, details map[string]interface{}) error
+}
+
+// SetHistoryRecorder sets where job summaries are persisted when a job finishes
+func (s *TaskUpdateService) SetHistoryRecorder(recorder HistoryRecorder) {
+	s.historyRecorder = recorder
+}
+
+// FieldUpdateStats counts the outcome of one mapped column across the job
+type FieldUpdateStats struct {
+	Column  string `json:"column"`
+	FieldID string `json:"field_id"`
+	Success int    `json:"success"`
+	Errors  int    `json:"errors"`
+	Skipped int    `json:"skipped"` // empty cells without default and superseded duplicates
+}
+
+// JobSummary is the outcome of a job, kept in the operation history details so it can
+// be reviewed after the uploaded file is removed
+type JobSummary struct {
+	JobID           int                `json:"job_id"`
+	TotalRows       int                `json:"total_rows"`
+	ProcessedRows   int                `json:"processed_rows"`
+	SuccessCount    int                `json:"success_count"`
+	ErrorCount      int                `json:"error_count"`
+	FilteredCount   int                `json:"filtered_count,omitempty"` // rows skipped by the row filter
+	ConflictCount   int                `json:"conflict_count,omitempty"` // rows not written: task changed in ClickUp
+	Conflicts       []string           `json:"conflicts,omitempty"`      // first jobSummaryMaxErrors conflicts
+	DryRun          bool               `json:"dry_run,omitempty"`        // nothing was written to ClickUp
+	DiffCount       int                `json:"diff_count,omitempty"`     // field changes previewed by the dry run
+	Diffs           []FieldDiff        `json:"diffs,omitempty"`          // first maxFieldDiffs changes
+	CommentErrors   int                `json:"comment_errors,omitempty"` // updated tasks whose comment_on_update comment failed
+	Fields          []FieldUpdateStats `json:"fields"`
+	Errors          []string           `json:"errors,omitempty"` // first jobSummaryMaxErrors row errors
+	Error           string             `json:"error,omitempty"`  // what stopped the job early, if anything
+	StartedAt       time.Time          `json:"started_at"`
+	CompletedAt     time.Time          `json:"completed_at"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	RowsPerMinute   float64            `json:"rows_per_minute"`
+}
+
+// fieldStats returns the counters of a mapped column, creating them on first use
+func (r *BatchUpdateResult) fieldStats(column, fieldID string) *FieldUpdateStats {
+	if r.Fields == nil {
+		r.Fields = make(map[string]*FieldUpdateStats)
+	}
+	stats, ok := r.Fields[column]
+	if !ok {
+		stats = &FieldUpdateStats{Column: column, FieldID: fieldID}
+		r.Fields[column] = stats
+	}
+	return stats
+}
+
+// buildJobSummary summarizes a batch result; runErr is the error that stopped the job
+func buildJobSummary(jobID int, result *BatchUpdateResult, started, completed time.Time, runErr error) JobSummary {
+	summary := JobSummary{
+		JobID:           jobID,
+		TotalRows:       result.TotalRows,
+		ProcessedRows:   result.ProcessedRows,
+		SuccessCount:    result.SuccessCount,
+		ErrorCount:      result.ErrorCount,
+		FilteredCount:   result.FilteredCount,
+		ConflictCount:   result.ConflictCount,
+		DryRun:          result.DryRun,
+		DiffCount:       result.DiffCount,
+		Diffs:           result.Diffs,
+		CommentErrors:   result.CommentErrors,
+		Fields:          make([]FieldUpdateStats, 0, len(result.Fields)),
+		StartedAt:       started,
+		CompletedAt:     completed,
+		DurationSeconds: completed.Sub(started).Seconds(),
+	}
+	for _, stats := range result.Fields {
+		summary.Fields = append(summary.Fields, *stats)
+	}
+	sort.Slice(summary.Fields, func(i, j int) bool { return summary.Fields[i].Column < summary.Fields[j].Column })
+
+	for _, rowErr := range result.Errors {
+		if len(summary.Errors) == jobSummaryMaxErrors {
+			break
+		}
+		summary.Errors = append(summary.Errors, rowErr.Error)
+	}
+	for _, conflict := range result.Conflicts {
+		if len(summary.Conflicts) == jobSummaryMaxErrors {
+			break
+		}
+		summary.Conflicts = append(summary.Conflicts, conflict.Error)
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	if summary.DurationSeconds > 0 {
+		summary.RowsPerMinute = float64(result.ProcessedRows) / summary.DurationSeconds * 60
+	}
+	return summary
+}
+
+// recordJobSummary saves the summary as a field update entry of the user's history.
+// Dry runs changed nothing, so their summary is kept with the job instead. Failures
+// are only logged: the job itself already finished.
+func (s *TaskUpdateService) recordJobSummary(ctx context.Context, job *repository.UpdateJob, summary JobSummary) {
+	if job.Options.DryRun {
+		s.recordDryRunResult(ctx, job, summary)
+		return
+	}
+	if s.historyRecorder == nil {
+		return
+	}
+	log := logger.Get(ctx)
+
+	details, err := summaryDetails(summary)
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", job.ID).Msg("Erro ao serializar resumo do job")
+		return
+	}
+	status := OperationStatusCompleted
+	if summary.Error != "" {
+		status = OperationStatusFailed
+	}
+
+	record, err := s.historyRecorder.CreateHistoryRecord(job.UserID, OperationTypeFieldUpdate, job.Title, details)
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", job.ID).Msg("Erro ao salvar resumo do job no histórico")
+		return
+	}
+	if err := s.historyRecorder.UpdateHistoryStatus(record.ID, status, details); err != nil {
+		log.Warn().Err(err).Int("job_id", job.ID).Msg("Erro ao atualizar status do resumo do job")
+	}
+}
+
+// recordDryRunResult saves the summary of a dry run on its job
+func (s *TaskUpdateService) recordDryRunResult(ctx context.Context, job *repository.UpdateJob, summary JobSummary) {
+	if s.queueRepo == nil {
+		return
+	}
+	log := logger.Get(ctx)
+
+	details, err := summaryDetails(summary)
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", job.ID).Msg("Erro ao serializar resumo do dry-run")
+		return
+	}
+	if err := s.queueRepo.SaveDryRunResult(job.ID, details); err != nil {
+		log.Warn().Err(err).Int("job_id", job.ID).Msg("Erro ao salvar resumo do dry-run")
+	}
+}
+
+// summaryDetails converts a summary to the JSON object stored in operation_history.details
+func summaryDetails(summary JobSummary) (map[string]interface{}, error) {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// fakeHistoryRecorder guarda os registros em memória, como a tabela operation_history
+type fakeHistoryRecorder struct {
+	records []*repository.OperationHistory
+}
+
+func (f *fakeHistoryRecorder) CreateHistoryRecord(userID, operationType, title string, details map[string]interface{}) (*repository.OperationHistory, error) {
+	record := &repository.OperationHistory{
+		ID:            len(f.records) + 1,
+		UserID:        userID,
+		OperationType: operationType,
+		Title:         title,
+		Status:        OperationStatusPending,
+		Details:       details,
+	}
+	f.records = append(f.records, record)
+	return record, nil
+}
+
+func (f *fakeHistoryRecorder) UpdateHistoryStatus(historyID int, status string, details map[string]interface{}) error {
+	record := f.records[historyID-1]
+	record.Status = status
+	record.Details = details
+	return nil
+}
+
+// TestJobSummaryReadableAfterFileRemoval processa um arquivo em que o campo "Valor"
+// falha na task T2 e confere que o resumo salvo no histórico continua legível depois
+// que o arquivo temporário é removido. A linha para no primeiro erro: "Zona", depois
+// de "Valor", conta como ignorada na T2.
+func TestJobSummaryReadableAfterFileRemoval(t *testing.T) {
+	clickup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/task/T2/field/field-valor" {
+			http.Error(w, `{"err":"Task not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer clickup.Close()
+
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	csvContent := "id task,Status,Valor,Zona\nT1,aberto,10,sul\nT2,fechado,20,norte\nT3,aberto,,leste\n"
+	upload, err := uploadService.ProcessFile("jobs.csv", strings.NewReader(csvContent), int64(len(csvContent)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	recorder := &fakeHistoryRecorder{}
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	svc.SetHistoryRecorder(recorder)
+
+	job := &repository.UpdateJob{
+		ID:       42,
+		UserID:   "user-1",
+		Title:    "Atualização de status",
+		FilePath: upload.TempPath,
+		Mapping: map[string]string{
+			"id task": "task_id",
+			"Status":  "field-status",
+			"Valor":   "field-valor",
+			"Zona":    "field-zona",
+		},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: clickup.URL})
+	fieldTypes := map[string]string{"field-status": "short_text", "field-valor": "number", "field-zona": "short_text"}
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, fieldTypes, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+	if result.SuccessCount != 2 || result.ErrorCount != 1 {
+		t.Fatalf("resultado = %d sucesso, %d erros; esperado 2 e 1", result.SuccessCount, result.ErrorCount)
+	}
+
+	if err := uploadService.RemoveTempFile(upload.TempPath); err != nil {
+		t.Fatalf("RemoveTempFile: %v", err)
+	}
+	if _, err := os.Stat(upload.TempPath); !os.IsNotExist(err) {
+		t.Fatalf("arquivo temporário não foi removido: %v", err)
+	}
+
+	if len(recorder.records) != 1 {
+		t.Fatalf("esperado 1 registro no histórico, obtido %d", len(recorder.records))
+	}
+	record := recorder.records[0]
+	if record.Status != OperationStatusCompleted || record.OperationType != OperationTypeFieldUpdate || record.UserID != "user-1" {
+		t.Errorf("registro inesperado: %+v", record)
+	}
+
+	// Os detalhes passam pelo JSON como na coluna details
+	data, err := json.Marshal(record.Details)
+	if err != nil {
+		t.Fatalf("details não serializável: %v", err)
+	}
+	var summary JobSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("resumo ilegível %s: %v", data, err)
+	}
+	if summary.JobID != 42 || summary.TotalRows != 3 || summary.ProcessedRows != 3 ||
+		summary.SuccessCount != 2 || summary.ErrorCount != 1 {
+		t.Errorf("totais inesperados: %+v", summary)
+	}
+	if len(summary.Errors) != 1 || !strings.Contains(summary.Errors[0], "task T2") {
+		t.Errorf("erros inesperados: %v", summary.Errors)
+	}
+	if summary.CompletedAt.Before(summary.StartedAt) || summary.DurationSeconds < 0 {
+		t.Errorf("duração inválida: %+v", summary)
+	}
+
+	// T3 sem valor conta como ignorada
+	expected := map[string]FieldUpdateStats{
+		"Status": {Column: "Status", FieldID: "field-status", Success: 3},
+		"Valor":  {Column: "Valor", FieldID: "field-valor", Success: 1, Errors: 1, Skipped: 1},
+		"Zona":   {Column: "Zona", FieldID: "field-zona", Success: 2, Skipped: 1},
+	}
+	if len(summary.Fields) != len(expected) {
+		t.Fatalf("campos = %+v, esperado %+v", summary.Fields, expected)
+	}
+	for _, stats := range summary.Fields {
+		if stats != expected[stats.Column] {
+			t.Errorf("campo %s = %+v, esperado %+v", stats.Column, stats, expected[stats.Column])
+		}
+	}
+}
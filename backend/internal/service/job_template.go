@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// ErrInvalidJobTemplate is returned when a template can't be saved as requested
+var ErrInvalidJobTemplate = errors.New("template de job inválido")
+
+// JobTemplateStore persists job templates (implemented by *repository.JobTemplateRepository)
+type JobTemplateStore interface {
+	CreateJobTemplate(template repository.JobTemplate) (*repository.JobTemplate, error)
+	UpdateJobTemplate(template repository.JobTemplate) (*repository.JobTemplate, error)
+	GetJobTemplate(id int, userID string) (*repository.JobTemplate, error)
+	ListJobTemplates(userID string) ([]repository.JobTemplate, error)
+	DeleteJobTemplate(id int, userID string) error
+}
+
+// JobTemplateRequest is the content of a template as sent by the user. The columns
+// come from Mappings or, when MappingID is set, are copied from that saved mapping.
+type JobTemplateRequest struct {
+	Name      string
+	ListID    string
+	MappingID string
+	Mappings  []ColumnMapping
+	Options   repository.JobTemplateOptions
+}
+
+// JobTemplateService saves whole job configurations (mapping, token, filters and
+// options) and turns them into mappings for newly uploaded files, the job-level
+// counterpart of reusing a mapping through FindByColumns
+type JobTemplateService struct {
+	store          JobTemplateStore
+	mappingService *MappingService
+	uploadService  *UploadService
+}
+
+// NewJobTemplateService creates a new job template service
+func NewJobTemplateService(store JobTemplateStore, mappingService *MappingService, uploadService *UploadService) *JobTemplateService {
+	return &JobTemplateService{
+		store:          store,
+		mappingService: mappingService,
+		uploadService:  uploadService,
+	}
+}
+
+// CreateTemplate saves a new template for the user
+func (s *JobTemplateService) CreateTemplate(userID string, req JobTemplateRequest) (*repository.JobTemplate, error) {
+	template, err := s.buildTemplate(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.CreateJobTemplate(*template)
+}
+
+// UpdateTemplate replaces the content of one of the user's templates
+func (s *JobTemplateService) UpdateTemplate(userID string, id int, req JobTemplateRequest) (*repository.JobTemplate, error) {
+	template, err := s.buildTemplate(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	template.ID = id
+	return s.store.UpdateJobTemplate(*template)
+}
+
+// GetTemplate returns one of the user's templates
+func (s *JobTemplateService) GetTemplate(userID string, id int) (*repository.JobTemplate, error) {
+	return s.store.GetJobTemplate(id, userID)
+}
+
+// ListTemplates returns the user's templates, ordered by name
+func (s *JobTemplateService) ListTemplates(userID string) ([]repository.JobTemplate, error) {
+	return s.store.ListJobTemplates(userID)
+}
+
+// DeleteTemplate removes one of the user's templates
+func (s *JobTemplateService) DeleteTemplate(userID string, id int) error {
+	return s.store.DeleteJobTemplate(id, userID)
+}
+
+// buildTemplate checks the request and resolves its columns. Options are checked by
+// the caller with the same rules as job creation.
+func (s *JobTemplateService) buildTemplate(userID string, req JobTemplateRequest) (*repository.JobTemplate, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: informe o nome do template", ErrInvalidJobTemplate)
+	}
+
+	mappings := req.Mappings
+	options := req.Options
+	if req.MappingID != "" {
+		stored, err := s.mappingService.GetMappingByUser(req.MappingID, userID)
+		if err != nil {
+			return nil, err
+		}
+		mappings = stored.Mappings
+		options.NormalizeColumns = options.NormalizeColumns || stored.NormalizeColumns
+	}
+
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("%w: nenhum mapeamento definido", ErrInvalidJobTemplate)
+	}
+	if _, ok := s.mappingService.FindTaskIDColumn(mappings); !ok {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobTemplate, ErrMissingTaskID)
+	}
+	if duplicates := s.mappingService.CheckDuplicateMappings(mappings); len(duplicates) > 0 {
+		return nil, fmt.Errorf("%w: campos duplicados: %s", ErrInvalidJobTemplate, strings.Join(duplicates, ", "))
+	}
+
+	mappingsJSON, err := json.Marshal(mappings)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar mapeamento: %w", err)
+	}
+
+	return &repository.JobTemplate{
+		UserID:   userID,
+		Name:     name,
+		ListID:   strings.TrimSpace(req.ListID),
+		Mappings: mappingsJSON,
+		Options:  options,
+	}, nil
+}
+
+// TemplateMappings decodes the columns of a template. Fields without a list of their
+// own are bound to the template's list.
+func TemplateMappings(template *repository.JobTemplate) ([]ColumnMapping, error) {
+	var mappings []ColumnMapping
+	if err := json.Unmarshal(template.Mappings, &mappings); err != nil {
+		return nil, fmt.Errorf("erro ao ler mapeamento do template: %w", err)
+	}
+	if template.ListID != "" {
+		for i := range mappings {
+			if mappings[i].FieldID != "" && !mappings[i].IsTaskID && !mappings[i].IsListID && mappings[i].ListID == "" {
+				mappings[i].ListID = template.ListID
+			}
+		}
+	}
+	return mappings, nil
+}
+
+// InstantiateTemplate validates the template's columns against an uploaded file and
+// saves them as a mapping of that file, ready for job creation. When the file doesn't
+// fit the template the validation result is returned with a nil mapping.
+func (s *JobTemplateService) InstantiateTemplate(template *repository.JobTemplate, filePath string) (*StoredMapping, *MappingValidationResult, error) {
+	mappings, err := TemplateMappings(template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, rows, err := s.uploadService.GetFileData(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao ler arquivo: %w", err)
+	}
+
+	return s.mappingService.ValidateAndSaveMapping(template.UserID, &MappingRequest{
+		FilePath:         filePath,
+		Mappings:         mappings,
+		Title:            template.Name,
+		NormalizeColumns: template.Options.NormalizeColumns,
+		SampleRows:       rows,
+		Columns:          columns,
+	}, columns)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TestCreateJobTemplateFromSavedMapping cria um template no banco a partir de um mapeamento salvo
+func TestCreateJobTemplateFromSavedMapping(t *testing.T) {
+	mappingService := NewMappingService(nil)
+	stored, err := mappingService.SaveMapping("user-1", &MappingRequest{
+		FilePath: "/tmp/antigo.csv",
+		Title:    "Importação mensal",
+		Mappings: []ColumnMapping{
+			{Column: "id task", IsTaskID: true},
+			{Column: "Valor", FieldID: "cf-valor", FieldName: "Valor", FieldType: "currency"},
+			{Column: "Status", FieldID: "cf-status", FieldName: "Status", ListID: "L9"},
+		},
+		NormalizeColumns: true,
+	})
+	if err != nil {
+		t.Fatalf("SaveMapping: %v", err)
+	}
+
+	svc := NewJobTemplateService(repository.NewJobTemplateRepository(setupTestDB(t)), mappingService, nil)
+
+	template, err := svc.CreateTemplate("user-1", JobTemplateRequest{
+		Name:      " Mensal ",
+		ListID:    "L1",
+		MappingID: stored.ID,
+		Options:   repository.JobTemplateOptions{TokenLabel: "cliente-x", Priority: "high"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+	if template.Name != "Mensal" || !template.Options.NormalizeColumns || template.Options.TokenLabel != "cliente-x" {
+		t.Errorf("template inesperado: %+v", template)
+	}
+
+	// Campos sem lista própria passam a usar a lista do template
+	mappings, err := TemplateMappings(template)
+	if err != nil {
+		t.Fatalf("TemplateMappings: %v", err)
+	}
+	lists := map[string]string{}
+	for _, m := range mappings {
+		lists[m.Column] = m.ListID
+	}
+	if lists["id task"] != "" || lists["Valor"] != "L1" || lists["Status"] != "L9" {
+		t.Errorf("listas dos campos: %v", lists)
+	}
+
+	// Outro usuário não vê o template nem usa o mapeamento
+	if _, err := svc.GetTemplate("user-2", template.ID); !errors.Is(err, repository.ErrJobTemplateNotFound) {
+		t.Errorf("esperava ErrJobTemplateNotFound, obteve %v", err)
+	}
+	if _, err := svc.CreateTemplate("user-2", JobTemplateRequest{Name: "x", MappingID: stored.ID}); !errors.Is(err, ErrMappingNotFound) {
+		t.Errorf("esperava ErrMappingNotFound, obteve %v", err)
+	}
+
+	if _, err := svc.CreateTemplate("user-1", JobTemplateRequest{Name: "Mensal", MappingID: stored.ID}); !errors.Is(err, repository.ErrJobTemplateNameTaken) {
+		t.Errorf("nome repetido: esperava ErrJobTemplateNameTaken, obteve %v", err)
+	}
+}
+
+func TestCreateJobTemplateValidation(t *testing.T) {
+	// Requisições inválidas são recusadas antes de chegar ao banco
+	svc := NewJobTemplateService(nil, NewMappingService(nil), nil)
+
+	tests := []struct {
+		name string
+		req  JobTemplateRequest
+		want string
+	}{
+		{"sem nome", JobTemplateRequest{Mappings: []ColumnMapping{{Column: "id task", IsTaskID: true}}}, "nome"},
+		{"sem colunas", JobTemplateRequest{Name: "t"}, "nenhum mapeamento"},
+		{"sem id task", JobTemplateRequest{Name: "t", Mappings: []ColumnMapping{{Column: "Valor", FieldID: "cf-1"}}}, "id task"},
+		{"campo duplicado", JobTemplateRequest{Name: "t", Mappings: []ColumnMapping{
+			{Column: "id task", IsTaskID: true},
+			{Column: "A", FieldID: "cf-1", FieldName: "Valor"},
+			{Column: "B", FieldID: "cf-1", FieldName: "Valor"},
+		}}, "duplicados"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateTemplate("user-1", tt.req)
+			if !errors.Is(err, ErrInvalidJobTemplate) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("esperava ErrInvalidJobTemplate com %q, obteve %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 )
 
@@ -19,6 +20,13 @@ var (
 	ErrMappingNotFound  = errors.New("mapeamento não encontrado")
 )
 
+// Default value application modes
+const (
+	DefaultWhenEmpty   = "empty"   // apply when the cell is blank
+	DefaultWhenMissing = "missing" // apply when the column or cell is absent from the file
+	DefaultWhenAlways  = "always"  // always apply, ignoring the cell value
+)
+
 // ColumnMapping represents a mapping between a file column and a custom field
 type ColumnMapping struct {
 	Column     string `json:"column"`
@@ -27,6 +35,29 @@ type ColumnMapping struct {
 	FieldType  string `json:"field_type"`
 	IsRequired bool   `json:"is_required"`
 	IsTaskID   bool   `json:"is_task_id"`
+
+	// IsListID marks the column holding each task's list ID (optional); dropdown and
+	// label options are then resolved against that list without fetching the task
+	IsListID bool `json:"is_list_id,omitempty"`
+
+	// ListID binds the mapping to the field of a specific list, for field names that
+	// exist in several lists (optional)
+	ListID string `json:"list_id,omitempty"`
+
+	// DefaultValue is sent instead of the cell value according to ApplyDefaultWhen
+	DefaultValue     string `json:"default_value,omitempty"`
+	ApplyDefaultWhen string `json:"apply_default_when,omitempty"`
+}
+
+// defaultMode returns the effective ApplyDefaultWhen, or "" when no default is configured
+func (m ColumnMapping) defaultMode() string {
+	if m.DefaultValue == "" {
+		return ""
+	}
+	if m.ApplyDefaultWhen == "" {
+		return DefaultWhenEmpty
+	}
+	return m.ApplyDefaultWhen
 }
 
 // MappingRequest represents a request to create a mapping
@@ -34,6 +65,26 @@ type MappingRequest struct {
 	FilePath string          `json:"file_path" binding:"required"`
 	Mappings []ColumnMapping `json:"mappings" binding:"required"`
 	Title    string          `json:"title" binding:"required"`
+
+	// NormalizeColumns matches columns ignoring case, accents and extra whitespace
+	// ("Id Task " matches "id task"); by default names must match exactly
+	NormalizeColumns bool `json:"normalize_columns,omitempty"`
+
+	// Locale reads numbers and dates of sampled and default values (pt-BR or en-US);
+	// the server default when empty
+	Locale string `json:"locale,omitempty"`
+
+	// SampleRows are data rows of the file; when set, mapped columns are checked
+	// against their field types and likely incompatibilities become warnings
+	SampleRows [][]string `json:"-"`
+
+	// Format is how the CSV file was read (see GetFileFormat); it is returned with the
+	// validation and format problems become warnings
+	Format *FileFormat `json:"-"`
+
+	// Columns is the file header; it signs the stored mapping so files with the same
+	// columns can reuse it (see FindByColumns)
+	Columns []string `json:"-"`
 }
 
 // MappingValidationResult represents the result of mapping validation
@@ -43,9 +94,12 @@ type MappingValidationResult struct {
 	Warnings    []string `json:"warnings,omitempty"`
 	HasTaskID   bool     `json:"has_task_id"`
 	TotalFields int      `json:"total_fields"`
+	// File is the delimiter, encoding and raw header line of a CSV file, to explain
+	// columns that were not found (omitted for spreadsheets)
+	File *FileFormat `json:"file,omitempty"`
 }
 
-// StoredMapping represents a mapping stored in memory (temporary)
+// StoredMapping represents a saved mapping
 type StoredMapping struct {
 	ID        string          `json:"id"`
 	UserID    string          `json:"user_id"`
@@ -53,22 +107,51 @@ type StoredMapping struct {
 	Title     string          `json:"title"`
 	Mappings  []ColumnMapping `json:"mappings"`
 	Validated bool            `json:"validated"`
+
+	// NormalizeColumns column names are matched normalized (see MappingRequest)
+	NormalizeColumns bool `json:"normalize_columns,omitempty"`
+
+	// Signature identifies the columns of the mapped file (see ColumnSignature)
+	Signature string    `json:"signature,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustomFieldLister lists the stored custom fields (implemented by *repository.MetadataRepository)
+type CustomFieldLister interface {
+	GetCustomFields() ([]repository.CustomField, error)
 }
 
 // MappingService handles column to custom field mapping operations
 type MappingService struct {
-	metadataRepo *repository.MetadataRepository
-	mappings     map[string]*StoredMapping // In-memory storage for temporary mappings
+	metadataRepo CustomFieldLister
+	store        MappingStore // saved mappings; in memory until SetMappingStore
+
+	suggestMinSimilarity float64 // see SetSuggestMinSimilarity
+	defaultLocale        string  // see SetDefaultLocale
 }
 
 // NewMappingService creates a new mapping service
-func NewMappingService(metadataRepo *repository.MetadataRepository) *MappingService {
+func NewMappingService(metadataRepo CustomFieldLister) *MappingService {
 	return &MappingService{
-		metadataRepo: metadataRepo,
-		mappings:     make(map[string]*StoredMapping),
+		metadataRepo:         metadataRepo,
+		store:                newMemoryMappingStore(),
+		suggestMinSimilarity: DefaultSuggestMinSimilarity,
+		defaultLocale:        client.DefaultLocale,
 	}
 }
 
+// SetMappingStore persists saved mappings in store instead of memory
+func (s *MappingService) SetMappingStore(store MappingStore) {
+	s.store = store
+}
+
+
+// SetDefaultLocale sets the locale used to check values when the request doesn't specify one
+func (s *MappingService) SetDefaultLocale(locale string) {
+	if client.IsSupportedLocale(locale) {
+		s.defaultLocale = locale
+	}
+}
 
 // ValidateMapping validates a mapping request
 func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []string) (*MappingValidationResult, error) {
@@ -78,6 +161,7 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 		Warnings:    []string{},
 		HasTaskID:   false,
 		TotalFields: len(req.Mappings),
+		File:        req.Format,
 	}
 
 	if len(req.Mappings) == 0 {
@@ -86,6 +170,16 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 		return result, nil
 	}
 
+	locale := s.defaultLocale
+	if req.Locale != "" {
+		if !client.IsSupportedLocale(req.Locale) {
+			result.Valid = false
+			result.Errors = append(result.Errors, "locale '"+req.Locale+"' inválido: use pt-BR ou en-US")
+			return result, nil
+		}
+		locale = req.Locale
+	}
+
 	// Get available custom fields from database
 	customFields, err := s.metadataRepo.GetCustomFields()
 	if err != nil {
@@ -97,31 +191,53 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 	for _, f := range customFields {
 		fieldMap[f.ID] = f
 	}
+	fieldNameCount := countFieldNames(customFields)
 
-	// Create a map for quick column lookup
-	columnSet := make(map[string]bool)
-	for _, col := range fileColumns {
-		columnSet[strings.ToLower(strings.TrimSpace(col))] = true
-	}
+	// Create a matcher for quick column lookup (ignoring case, or normalized when enabled)
+	columns := newMappingColumnMatcher(fileColumns, req.NormalizeColumns)
 
 	// Track mapped fields to detect duplicates
 	mappedFields := make(map[string]string) // fieldID -> column
+	missingColumns := false
 
 	for _, mapping := range req.Mappings {
-		// Check if this is the task ID column
+		// Check if this is the task ID column (default or custom task IDs)
 		if mapping.IsTaskID {
+			if _, found := columns.find(mapping.Column); !found {
+				missingColumns = true
+				result.Valid = false
+				result.Errors = append(result.Errors, "coluna de ID da task '"+mapping.Column+"' não encontrada no arquivo")
+				continue
+			}
 			result.HasTaskID = true
 			continue
 		}
+		if mapping.IsListID {
+			if _, found := columns.find(mapping.Column); !found {
+				missingColumns = true
+				result.Valid = false
+				result.Errors = append(result.Errors, "coluna de ID da lista '"+mapping.Column+"' não encontrada no arquivo")
+			}
+			continue
+		}
 
 		// Skip empty mappings
 		if mapping.FieldID == "" {
 			continue
 		}
 
-		// Validate column exists in file
-		colLower := strings.ToLower(strings.TrimSpace(mapping.Column))
-		if !columnSet[colLower] {
+		// Validate default configuration
+		if errMsg := validateDefaultMode(mapping); errMsg != "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, errMsg)
+			continue
+		}
+		mode := mapping.defaultMode()
+
+		// Validate column exists in file (defaults for missing columns allow it to be absent)
+		colIndex, found := columns.find(mapping.Column)
+		if !found && mode != DefaultWhenMissing && mode != DefaultWhenAlways {
+			missingColumns = true
 			result.Valid = false
 			result.Errors = append(result.Errors, "coluna '"+mapping.Column+"' não encontrada no arquivo")
 			continue
@@ -135,6 +251,15 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 			continue
 		}
 
+		// Fields bound to a list must belong to it; unbound names shared by several lists are ambiguous
+		if errMsg, warning := listScopeCheck(mapping, field, fieldNameCount); errMsg != "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, errMsg)
+			continue
+		} else if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+
 		// Check for duplicate mappings (same field mapped to multiple columns)
 		if existingCol, isDuplicate := mappedFields[mapping.FieldID]; isDuplicate {
 			result.Valid = false
@@ -143,10 +268,32 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 		}
 		mappedFields[mapping.FieldID] = mapping.Column
 
+		// Formula and automatic progress are computed by ClickUp
+		if client.IsReadOnlyFieldType(field.Type) {
+			result.Valid = false
+			result.Errors = append(result.Errors, "campo '"+field.Name+"' é calculado pelo ClickUp ("+field.Type+") e não pode ser atualizado")
+			continue
+		}
+
 		// Validate type compatibility
 		if !s.isTypeCompatible(mapping.FieldType, field.Type) {
 			result.Warnings = append(result.Warnings, "tipo do campo '"+field.Name+"' pode ser incompatível com dados da coluna")
 		}
+
+		// Check sampled values unless a default always replaces them
+		if found && len(req.SampleRows) > 0 && mode != DefaultWhenAlways {
+			if warning := sampleCompatibilityWarning(mapping.Column, field.Name, field.Type, columnSample(req.SampleRows, colIndex), locale); warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+		}
+
+		// Validate default value against the field type
+		if mode != "" {
+			if err := client.ValidateFieldValueWithOptions(mapping.DefaultValue, field.Type, client.TransformOptions{Locale: locale}); err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, "valor padrão inválido para o campo '"+field.Name+"': "+err.Error())
+			}
+		}
 	}
 
 	// Check for required task ID column
@@ -155,21 +302,40 @@ func (s *MappingService) ValidateMapping(req *MappingRequest, fileColumns []stri
 		result.Errors = append(result.Errors, "coluna 'id task' é obrigatória para identificar as tarefas")
 	}
 
+	// Explain how the file was read when that may be why columns don't match
+	if req.Format != nil {
+		result.Warnings = append(result.Warnings, req.Format.warnings(missingColumns)...)
+	}
+
 	return result, nil
 }
 
+// validateDefaultMode checks the DefaultValue/ApplyDefaultWhen pair of a mapping.
+// Returns an error message, or "" when the configuration is valid.
+func validateDefaultMode(m ColumnMapping) string {
+	switch m.ApplyDefaultWhen {
+	case "", DefaultWhenEmpty, DefaultWhenMissing, DefaultWhenAlways:
+	default:
+		return "apply_default_when inválido para coluna '" + m.Column + "': use empty, missing ou always"
+	}
+	if m.ApplyDefaultWhen != "" && m.DefaultValue == "" {
+		return "apply_default_when definido sem default_value para coluna '" + m.Column + "'"
+	}
+	return ""
+}
+
 // isTypeCompatible checks if a column type is compatible with a field type
 func (s *MappingService) isTypeCompatible(columnType, fieldType string) bool {
 	// Most types are compatible with string data from CSV/XLSX
 	// This is a basic compatibility check
 	compatibleTypes := map[string][]string{
 		"text":          {"text", "short_text", "email", "url", "phone"},
-		"number":        {"number", "currency", "percentage"},
+		"number":        {"number", "currency", "percentage", "manual_progress", "rating", "emoji"},
 		"date":          {"date"},
 		"dropdown":      {"drop_down", "labels"},
 		"checkbox":      {"checkbox"},
 		"users":         {"users"},
-		"automatic":     {"automatic_progress", "tasks", "formula"},
+		"relationship":  {"tasks"},
 	}
 
 	// If no specific type is provided, assume compatible
@@ -204,38 +370,57 @@ func (s *MappingService) isTypeCompatible(columnType, fieldType string) bool {
 }
 
 
-// SaveMapping saves a mapping temporarily
+// SaveMapping saves a mapping
 func (s *MappingService) SaveMapping(userID string, req *MappingRequest) (*StoredMapping, error) {
+	return s.saveMapping(userID, req, false)
+}
+
+// saveMapping saves a mapping, marked as validated or not
+func (s *MappingService) saveMapping(userID string, req *MappingRequest, validated bool) (*StoredMapping, error) {
 	// Generate a unique ID for the mapping
 	id := generateMappingID()
 
 	stored := &StoredMapping{
-		ID:        id,
-		UserID:    userID,
-		FilePath:  req.FilePath,
-		Title:     req.Title,
-		Mappings:  req.Mappings,
-		Validated: false,
+		ID:               id,
+		UserID:           userID,
+		FilePath:         req.FilePath,
+		Title:            req.Title,
+		Mappings:         req.Mappings,
+		Validated:        validated,
+		NormalizeColumns: req.NormalizeColumns,
+		CreatedAt:        time.Now(),
+	}
+	if len(req.Columns) > 0 {
+		stored.Signature = ColumnSignature(req.Columns)
 	}
 
-	s.mappings[id] = stored
+	saved, err := toSavedMapping(stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveMapping(saved); err != nil {
+		return nil, err
+	}
 	return stored, nil
 }
 
 // GetMapping retrieves a mapping by ID
 func (s *MappingService) GetMapping(id string) (*StoredMapping, error) {
-	mapping, exists := s.mappings[id]
-	if !exists {
-		return nil, ErrMappingNotFound
+	saved, err := s.store.GetMapping(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSavedMappingNotFound) {
+			return nil, ErrMappingNotFound
+		}
+		return nil, err
 	}
-	return mapping, nil
+	return fromSavedMapping(*saved)
 }
 
 // GetMappingByUser retrieves a mapping by ID and validates user ownership
 func (s *MappingService) GetMappingByUser(id, userID string) (*StoredMapping, error) {
-	mapping, exists := s.mappings[id]
-	if !exists {
-		return nil, ErrMappingNotFound
+	mapping, err := s.GetMapping(id)
+	if err != nil {
+		return nil, err
 	}
 	if mapping.UserID != userID {
 		return nil, ErrMappingNotFound
@@ -245,22 +430,48 @@ func (s *MappingService) GetMappingByUser(id, userID string) (*StoredMapping, er
 
 // DeleteMapping removes a mapping
 func (s *MappingService) DeleteMapping(id string) error {
-	if _, exists := s.mappings[id]; !exists {
-		return ErrMappingNotFound
+	if err := s.store.DeleteMapping(id); err != nil {
+		if errors.Is(err, repository.ErrSavedMappingNotFound) {
+			return ErrMappingNotFound
+		}
+		return err
 	}
-	delete(s.mappings, id)
 	return nil
 }
 
-// GetMappingsByUser returns all mappings for a user
-func (s *MappingService) GetMappingsByUser(userID string) []*StoredMapping {
-	var result []*StoredMapping
-	for _, m := range s.mappings {
-		if m.UserID == userID {
-			result = append(result, m)
+// GetMappingsByUser returns all mappings for a user, most recent first
+func (s *MappingService) GetMappingsByUser(userID string) ([]*StoredMapping, error) {
+	saved, err := s.store.ListMappings(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return fromSavedMappings(saved)
+}
+
+// countFieldNames counts the fields sharing each (case-insensitive) name
+func countFieldNames(fields []repository.CustomField) map[string]int {
+	counts := make(map[string]int, len(fields))
+	for _, f := range fields {
+		counts[strings.ToLower(strings.TrimSpace(f.Name))]++
+	}
+	return counts
+}
+
+// listScopeCheck returns an error when the mapping is bound to a list the field isn't
+// in, or a warning when it isn't bound and other fields share the field's name
+func listScopeCheck(mapping ColumnMapping, field repository.CustomField, nameCount map[string]int) (string, string) {
+	if mapping.ListID != "" {
+		for _, listID := range field.ListIDs {
+			if listID == mapping.ListID {
+				return "", ""
+			}
 		}
+		return "campo '" + field.Name + "' não pertence à lista '" + mapping.ListID + "'", ""
 	}
-	return result
+	if nameCount[strings.ToLower(strings.TrimSpace(field.Name))] > 1 {
+		return "", "existe mais de um campo '" + field.Name + "' em listas diferentes; informe a lista (list_id) para evitar ambiguidade"
+	}
+	return "", ""
 }
 
 // ValidateAndSaveMapping validates and saves a mapping
@@ -277,12 +488,11 @@ func (s *MappingService) ValidateAndSaveMapping(userID string, req *MappingReque
 	}
 
 	// Save the mapping
-	stored, err := s.SaveMapping(userID, req)
+	stored, err := s.saveMapping(userID, req, true)
 	if err != nil {
 		return nil, validationResult, err
 	}
 
-	stored.Validated = true
 	return stored, validationResult, nil
 }
 
@@ -293,7 +503,7 @@ func (s *MappingService) CheckDuplicateMappings(mappings []ColumnMapping) []stri
 	fieldNames := make(map[string]string)
 
 	for _, m := range mappings {
-		if m.FieldID == "" || m.IsTaskID {
+		if m.FieldID == "" || m.IsTaskID || m.IsListID {
 			continue
 		}
 		fieldCount[m.FieldID]++
@@ -323,17 +533,37 @@ func (s *MappingService) FindTaskIDColumn(mappings []ColumnMapping) (string, boo
 	return "", false
 }
 
-// ConvertToJobMapping converts column mappings to a simple map for job processing
+// ConvertToJobMapping converts column mappings to a simple map for job processing.
+// The task ID column is kept under the "task_id" marker, so any column (e.g. one with
+// custom task IDs) can identify the tasks; the list ID column under "list_id".
 func (s *MappingService) ConvertToJobMapping(mappings []ColumnMapping) map[string]string {
 	result := make(map[string]string)
 	for _, m := range mappings {
-		if m.FieldID != "" && !m.IsTaskID {
+		if m.IsTaskID {
+			result[m.Column] = taskIDMarker
+		} else if m.IsListID {
+			result[m.Column] = listIDMarker
+		} else if m.FieldID != "" {
 			result[m.Column] = m.FieldID
 		}
 	}
 	return result
 }
 
+// ConvertToJobDefaults extracts the default values of column mappings for job processing
+func (s *MappingService) ConvertToJobDefaults(mappings []ColumnMapping) map[string]repository.FieldDefault {
+	result := make(map[string]repository.FieldDefault)
+	for _, m := range mappings {
+		if m.FieldID == "" || m.IsTaskID || m.IsListID {
+			continue
+		}
+		if mode := m.defaultMode(); mode != "" {
+			result[m.Column] = repository.FieldDefault{Value: m.DefaultValue, When: mode}
+		}
+	}
+	return result
+}
+
 // generateMappingID generates a unique ID for a mapping
 func generateMappingID() string {
 	// Simple ID generation using timestamp
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+)
+
+// compatibilitySampleRows is how many data rows are checked against the mapped field types
+const compatibilitySampleRows = 50
+
+// sampleCompatibilityWarning checks the non-empty values of a column sample with the
+// same parsers used when sending them (client.ValidateFieldValueWithOptions), reading
+// numbers and dates in locale. Returns a warning naming the first incompatible value,
+// or "" when every value converts.
+func sampleCompatibilityWarning(column, fieldName, fieldType string, values []string, locale string) string {
+	checked, failed := 0, 0
+	example := ""
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		checked++
+		if err := client.ValidateFieldValueWithOptions(value, fieldType, client.TransformOptions{Locale: locale}); err != nil {
+			if failed == 0 {
+				example = value
+			}
+			failed++
+		}
+	}
+	if failed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("coluna '%s': %d de %d valores da amostra não parecem compatíveis com o campo '%s' (%s), ex.: '%s'",
+		column, failed, checked, fieldName, fieldType, example)
+}
+
+// columnSample returns the values of column index col in the first
+// compatibilitySampleRows rows
+func columnSample(rows [][]string, col int) []string {
+	if len(rows) > compatibilitySampleRows {
+		rows = rows[:compatibilitySampleRows]
+	}
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if col < len(row) {
+			values = append(values, row[col])
+		}
+	}
+	return values
+}
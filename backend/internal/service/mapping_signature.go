@@ -0,0 +1,32 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// ColumnSignature identifies a file layout by its set of column names: the hash of
+// the sorted, trimmed names. Reordering columns keeps the signature; renaming, adding
+// or removing one changes it.
+func ColumnSignature(columns []string) string {
+	names := make([]string, 0, len(columns))
+	for _, col := range columns {
+		names = append(names, strings.TrimSpace(col))
+	}
+	sort.Strings(names)
+
+	sum := sha256.Sum256([]byte(strings.Join(names, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindByColumns returns the user's mappings saved for files with the same columns,
+// most recent first, so a re-uploaded spreadsheet can reuse its last mapping
+func (s *MappingService) FindByColumns(userID string, columns []string) ([]*StoredMapping, error) {
+	saved, err := s.store.ListMappings(userID, ColumnSignature(columns))
+	if err != nil {
+		return nil, err
+	}
+	return fromSavedMappings(saved)
+}
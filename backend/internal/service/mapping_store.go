@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// MappingStore persists saved mappings (implemented by *repository.MappingRepository)
+type MappingStore interface {
+	SaveMapping(m repository.SavedMapping) error
+	GetMapping(id string) (*repository.SavedMapping, error)
+	ListMappings(userID, signature string) ([]repository.SavedMapping, error)
+	DeleteMapping(id string) error
+}
+
+// memoryMappingStore keeps mappings in memory; used until SetMappingStore is called
+type memoryMappingStore struct {
+	mu       sync.RWMutex
+	mappings map[string]repository.SavedMapping
+}
+
+func newMemoryMappingStore() *memoryMappingStore {
+	return &memoryMappingStore{mappings: make(map[string]repository.SavedMapping)}
+}
+
+func (s *memoryMappingStore) SaveMapping(m repository.SavedMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mappings[m.ID] = m
+	return nil
+}
+
+func (s *memoryMappingStore) GetMapping(id string) (*repository.SavedMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.mappings[id]
+	if !ok {
+		return nil, repository.ErrSavedMappingNotFound
+	}
+	return &m, nil
+}
+
+func (s *memoryMappingStore) ListMappings(userID, signature string) ([]repository.SavedMapping, error) {
+	s.mu.RLock()
+	var result []repository.SavedMapping
+	for _, m := range s.mappings {
+		if m.UserID == userID && (signature == "" || m.Signature == signature) {
+			result = append(result, m)
+		}
+	}
+	s.mu.RUnlock()
+
+	// Same order as the repository: most recent first
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+func (s *memoryMappingStore) DeleteMapping(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mappings[id]; !ok {
+		return repository.ErrSavedMappingNotFound
+	}
+	delete(s.mappings, id)
+	return nil
+}
+
+// toSavedMapping converts a mapping to its stored form
+func toSavedMapping(m *StoredMapping) (repository.SavedMapping, error) {
+	mappingsJSON, err := json.Marshal(m.Mappings)
+	if err != nil {
+		return repository.SavedMapping{}, fmt.Errorf("erro ao serializar mapeamento: %w", err)
+	}
+	return repository.SavedMapping{
+		ID:               m.ID,
+		UserID:           m.UserID,
+		FilePath:         m.FilePath,
+		Title:            m.Title,
+		Mappings:         mappingsJSON,
+		Validated:        m.Validated,
+		NormalizeColumns: m.NormalizeColumns,
+		Signature:        m.Signature,
+		CreatedAt:        m.CreatedAt,
+	}, nil
+}
+
+// fromSavedMapping converts a stored mapping back
+func fromSavedMapping(saved repository.SavedMapping) (*StoredMapping, error) {
+	var mappings []ColumnMapping
+	if err := json.Unmarshal(saved.Mappings, &mappings); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar mapeamento %s: %w", saved.ID, err)
+	}
+	return &StoredMapping{
+		ID:               saved.ID,
+		UserID:           saved.UserID,
+		FilePath:         saved.FilePath,
+		Title:            saved.Title,
+		Mappings:         mappings,
+		Validated:        saved.Validated,
+		NormalizeColumns: saved.NormalizeColumns,
+		Signature:        saved.Signature,
+		CreatedAt:        saved.CreatedAt,
+	}, nil
+}
+
+// fromSavedMappings converts a list of stored mappings back
+func fromSavedMappings(saved []repository.SavedMapping) ([]*StoredMapping, error) {
+	result := make([]*StoredMapping, 0, len(saved))
+	for _, m := range saved {
+		stored, err := fromSavedMapping(m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, stored)
+	}
+	return result, nil
+}
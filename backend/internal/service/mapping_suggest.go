@@ -0,0 +1,188 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// DefaultSuggestMinSimilarity is the similarity (0-1) a column name needs to be
+// suggested for a field whose name isn't an exact match
+const DefaultSuggestMinSimilarity = 0.8
+
+// suggestTaskIDAliases are column names that usually hold the task ID, suggested as the
+// task ID column. Jobs detect the column on their own (see findTaskIDColumnIndex).
+var suggestTaskIDAliases = []string{
+	"id task", "id_task", "task_id", "task id", "taskid", "id", "custom id", "custom_id",
+	"custom task id", "id personalizado", "id tarefa", "id da task", "id da tarefa",
+}
+
+// SetSuggestMinSimilarity sets how close a column name must be to a field name to be
+// suggested (1 = only names equal after normalization)
+func (s *MappingService) SetSuggestMinSimilarity(similarity float64) {
+	if similarity > 0 && similarity <= 1 {
+		s.suggestMinSimilarity = similarity
+	}
+}
+
+// SuggestMappings proposes mappings for the file columns: the task ID column by its
+// usual names and each other column to the custom field with the closest name. The
+// suggestions are advisory; the user reviews them before saving.
+func (s *MappingService) SuggestMappings(fileColumns []string) ([]ColumnMapping, error) {
+	customFields, err := s.metadataRepo.GetCustomFields()
+	if err != nil {
+		return nil, err
+	}
+	return suggestMappings(fileColumns, customFields, s.suggestMinSimilarity), nil
+}
+
+// suggestMappings matches columns to fields, best matches first, so each column and
+// each field appears in at most one suggestion
+func suggestMappings(fileColumns []string, fields []repository.CustomField, minSimilarity float64) []ColumnMapping {
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		minSimilarity = DefaultSuggestMinSimilarity
+	}
+
+	suggestions := make([]ColumnMapping, 0, len(fileColumns))
+	taskIDColumn := -1
+	for i, column := range fileColumns {
+		if isSuggestedTaskIDColumn(column) {
+			taskIDColumn = i
+			suggestions = append(suggestions, ColumnMapping{Column: column, IsTaskID: true, IsRequired: true})
+			break
+		}
+	}
+
+	// Fields sorted by ID keep the choice stable among fields sharing a name
+	candidates := make([]repository.CustomField, 0, len(fields))
+	for _, field := range fields {
+		if !client.IsReadOnlyFieldType(field.Type) {
+			candidates = append(candidates, field)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	type match struct {
+		column, field int
+		score         float64
+	}
+	var matches []match
+	for i, column := range fileColumns {
+		if i == taskIDColumn {
+			continue
+		}
+		for j, field := range candidates {
+			if score := nameSimilarity(column, field.Name); score >= minSimilarity {
+				matches = append(matches, match{column: i, field: j, score: score})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return matches[a].column < matches[b].column
+	})
+
+	usedColumns := make(map[int]bool)
+	usedFields := make(map[string]bool)
+	for _, m := range matches {
+		field := candidates[m.field]
+		key := strings.ToLower(strings.TrimSpace(field.Name))
+		if usedColumns[m.column] || usedFields[field.ID] || usedFields[key] {
+			continue
+		}
+		usedColumns[m.column] = true
+		usedFields[field.ID] = true
+		usedFields[key] = true // fields sharing a name in other lists are the same choice
+		suggestions = append(suggestions, ColumnMapping{
+			Column:    fileColumns[m.column],
+			FieldID:   field.ID,
+			FieldName: field.Name,
+			FieldType: field.Type,
+		})
+	}
+
+	// Keep the file's column order
+	position := make(map[string]int, len(fileColumns))
+	for i, column := range fileColumns {
+		if _, ok := position[column]; !ok {
+			position[column] = i
+		}
+	}
+	sort.SliceStable(suggestions, func(a, b int) bool {
+		return position[suggestions[a].Column] < position[suggestions[b].Column]
+	})
+	return suggestions
+}
+
+// isSuggestedTaskIDColumn reports whether a column name is one of suggestTaskIDAliases
+func isSuggestedTaskIDColumn(name string) bool {
+	key := suggestKey(name)
+	for _, alias := range suggestTaskIDAliases {
+		if key == suggestKey(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey normalizes a name for matching and treats "_", "-", "." and "/" as spaces
+func suggestKey(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return r
+	}, name)
+	return NormalizeColumnName(name)
+}
+
+// nameSimilarity compares two names from 0 to 1: 1 when equal after normalization
+// (ignoring spaces too), otherwise by edit distance relative to the longer name
+func nameSimilarity(a, b string) float64 {
+	a, b = suggestKey(a), suggestKey(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if strings.ReplaceAll(a, " ", "") == strings.ReplaceAll(b, " ", "") {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between two rune slices
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
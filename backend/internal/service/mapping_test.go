@@ -1,7 +1,10 @@
 package service
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
@@ -43,12 +46,10 @@ func (s *MappingServiceForTest) ValidateMappingForTest(req *MappingRequest, file
 	for _, f := range s.customFields {
 		fieldMap[f.ID] = f
 	}
+	fieldNameCount := countFieldNames(s.customFields)
 
-	// Create a map for quick column lookup
-	columnSet := make(map[string]bool)
-	for _, col := range fileColumns {
-		columnSet[col] = true
-	}
+	// Create a matcher for quick column lookup
+	columns := newColumnMatcher(fileColumns, req.NormalizeColumns)
 
 	// Track mapped fields to detect duplicates
 	mappedFields := make(map[string]string) // fieldID -> column
@@ -66,7 +67,8 @@ func (s *MappingServiceForTest) ValidateMappingForTest(req *MappingRequest, file
 		}
 
 		// Validate column exists in file
-		if !columnSet[mapping.Column] {
+		colIndex, found := columns.find(mapping.Column)
+		if !found {
 			result.Valid = false
 			result.Errors = append(result.Errors, "coluna '"+mapping.Column+"' não encontrada no arquivo")
 			continue
@@ -80,6 +82,15 @@ func (s *MappingServiceForTest) ValidateMappingForTest(req *MappingRequest, file
 			continue
 		}
 
+		// Validate list scope
+		if errMsg, warning := listScopeCheck(mapping, field, fieldNameCount); errMsg != "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, errMsg)
+			continue
+		} else if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+
 		// Check for duplicate mappings (same field mapped to multiple columns)
 		if existingCol, isDuplicate := mappedFields[mapping.FieldID]; isDuplicate {
 			result.Valid = false
@@ -87,6 +98,13 @@ func (s *MappingServiceForTest) ValidateMappingForTest(req *MappingRequest, file
 			continue
 		}
 		mappedFields[mapping.FieldID] = mapping.Column
+
+		// Check sampled values against the field type
+		if len(req.SampleRows) > 0 {
+			if warning := sampleCompatibilityWarning(mapping.Column, field.Name, field.Type, columnSample(req.SampleRows, colIndex), req.Locale); warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+		}
 	}
 
 	// Check for required task ID column
@@ -522,3 +540,321 @@ func extractColumnsFromMappings(mappings []ColumnMapping) []string {
 	}
 	return columns
 }
+
+// TestMappingSampleCompatibility checks that sampled column values are compared with
+// the mapped field type: non-numeric values mapped to a number field always produce a
+// warning, while numeric ones never do
+func TestMappingSampleCompatibility(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 100
+	parameters.MaxSize = 20
+
+	properties := gopter.NewProperties(parameters)
+
+	fields := []repository.CustomField{{ID: "f-valor", Name: "Valor", Type: "number"}}
+	validate := func(values []string) *MappingValidationResult {
+		rows := make([][]string, len(values))
+		for i, v := range values {
+			rows[i] = []string{"task-" + v, v}
+		}
+		req := &MappingRequest{
+			FilePath: "/tmp/test.csv",
+			Title:    "Test Mapping",
+			Mappings: []ColumnMapping{
+				{Column: "id task", IsTaskID: true},
+				{Column: "Valor", FieldID: "f-valor"},
+			},
+			SampleRows: rows,
+		}
+		return NewMappingServiceForTest(fields).ValidateMappingForTest(req, []string{"id task", "Valor"})
+	}
+
+	properties.Property("non-numeric column mapped to number field warns", prop.ForAll(
+		func(numbers []int, text string) bool {
+			values := make([]string, 0, len(numbers)+1)
+			for _, n := range numbers {
+				values = append(values, fmt.Sprintf("%d", n))
+			}
+			values = append(values, text)
+
+			result := validate(values)
+			return result.Valid && len(result.Warnings) == 1 && strings.Contains(result.Warnings[0], "'"+text+"'")
+		},
+		gen.SliceOf(gen.IntRange(-100000, 100000)),
+		gen.AlphaString().SuchThat(func(s string) bool {
+			_, err := strconv.ParseFloat(s, 64) // "inf" and "nan" are numbers to ParseFloat
+			return s != "" && err != nil
+		}),
+	))
+
+	properties.Property("numeric column mapped to number field does not warn", prop.ForAll(
+		func(numbers []float64) bool {
+			values := make([]string, len(numbers))
+			for i, n := range numbers {
+				values[i] = strings.Replace(fmt.Sprintf("%.2f", n), ".", ",", 1)
+			}
+			return len(validate(values).Warnings) == 0
+		},
+		gen.SliceOf(gen.Float64Range(-1e6, 1e6)),
+	))
+
+	properties.TestingRun(t)
+
+	// Blank cells are not checked and only the first rows are sampled
+	values := make([]string, compatibilitySampleRows+10)
+	for i := range values {
+		values[i] = "10"
+	}
+	values[3] = " "
+	values[compatibilitySampleRows+5] = "abc"
+	if result := validate(values); len(result.Warnings) != 0 {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
+
+// TestMappingNormalizeColumns verifies that headers differing by accents or inner
+// whitespace validate only when the mapping enables normalization, while case and
+// surrounding whitespace are always ignored
+func TestMappingNormalizeColumns(t *testing.T) {
+	svc := NewMappingService(customFieldList{{ID: "f-desc", Name: "Descrição", Type: "text"}})
+	fileColumns := []string{"Id Task", "Descrição  Completa"}
+	validate := func(req *MappingRequest) *MappingValidationResult {
+		t.Helper()
+		result, err := svc.ValidateMapping(req, fileColumns)
+		if err != nil {
+			t.Fatalf("ValidateMapping: %v", err)
+		}
+		return result
+	}
+
+	req := &MappingRequest{
+		FilePath: "/tmp/test.csv",
+		Title:    "Test Mapping",
+		Mappings: []ColumnMapping{
+			{Column: "ID TASK ", IsTaskID: true},
+			{Column: " descrição  completa", FieldID: "f-desc"},
+		},
+	}
+	if result := validate(req); !result.Valid || len(result.Errors) != 0 {
+		t.Errorf("sem normalização, caixa e espaços nas pontas deveriam ser ignorados: %+v", result)
+	}
+
+	req.Mappings[1].Column = "descricao completa"
+	if result := validate(req); result.Valid {
+		t.Errorf("sem normalização o mapeamento sem acentos deveria falhar: %+v", result)
+	}
+
+	req.NormalizeColumns = true
+	if result := validate(req); !result.Valid || len(result.Errors) != 0 {
+		t.Errorf("com normalização o mapeamento deveria passar: %+v", result)
+	}
+
+	for in, want := range map[string]string{
+		" Id Task ":           "id task",
+		"ID\tTASK":            "id task",
+		"Descrição  Completa": "descricao completa",
+		"AÇÃO":                "acao",
+		"valor":               "valor",
+	} {
+		if got := NormalizeColumnName(in); got != want {
+			t.Errorf("NormalizeColumnName(%q) = %q, esperado %q", in, got, want)
+		}
+	}
+}
+
+// TestMappingListScopedFields verifies that fields with the same name in different
+// lists can be bound to a list, and that the binding is checked
+func TestMappingListScopedFields(t *testing.T) {
+	fields := []repository.CustomField{
+		{ID: "status-a", Name: "Status", Type: "drop_down", ListIDs: []string{"lista-a"}},
+		{ID: "status-b", Name: "Status", Type: "drop_down", ListIDs: []string{"lista-b"}},
+		{ID: "valor", Name: "Valor", Type: "number", ListIDs: []string{"lista-a", "lista-b"}},
+	}
+	svc := NewMappingServiceForTest(fields)
+	validate := func(mappings ...ColumnMapping) *MappingValidationResult {
+		req := &MappingRequest{
+			FilePath: "/tmp/test.csv",
+			Title:    "Test Mapping",
+			Mappings: append([]ColumnMapping{{Column: "id task", IsTaskID: true}}, mappings...),
+		}
+		return svc.ValidateMappingForTest(req, []string{"id task", "Status", "Valor"})
+	}
+
+	// Bound to the field's own list: valid, no ambiguity warning
+	if result := validate(ColumnMapping{Column: "Status", FieldID: "status-b", ListID: "lista-b"}); !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("mapeamento com lista: %+v", result)
+	}
+
+	// Bound to another list: error
+	result := validate(ColumnMapping{Column: "Status", FieldID: "status-b", ListID: "lista-a"})
+	if result.Valid || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "não pertence à lista 'lista-a'") {
+		t.Errorf("mapeamento em lista errada: %+v", result)
+	}
+
+	// Unbound with a repeated name: valid with a warning
+	result = validate(ColumnMapping{Column: "Status", FieldID: "status-a"})
+	if !result.Valid || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "list_id") {
+		t.Errorf("mapeamento ambíguo: %+v", result)
+	}
+
+	// Unbound with a unique name: no warning
+	if result := validate(ColumnMapping{Column: "Valor", FieldID: "valor"}); !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("mapeamento sem ambiguidade: %+v", result)
+	}
+}
+
+// suggestFields são os campos disponíveis nos testes de sugestão de mapeamento
+func suggestFields() []repository.CustomField {
+	return []repository.CustomField{
+		{ID: "f-status", Name: "Status", Type: "drop_down"},
+		{ID: "f-valor", Name: "Valor Total", Type: "currency"},
+		{ID: "f-prioridade", Name: "Prioridade", Type: "drop_down"},
+		{ID: "f-descricao", Name: "Descrição", Type: "text"},
+		{ID: "f-responsavel", Name: "Responsável", Type: "users"},
+		{ID: "f-formula", Name: "Margem", Type: "formula"},
+	}
+}
+
+// suggestionsByColumn indexa as sugestões pela coluna
+func suggestionsByColumn(suggestions []ColumnMapping) map[string]ColumnMapping {
+	byColumn := make(map[string]ColumnMapping, len(suggestions))
+	for _, s := range suggestions {
+		byColumn[s.Column] = s
+	}
+	return byColumn
+}
+
+func TestSuggestMappingsExactAndCaseInsensitive(t *testing.T) {
+	columns := []string{"ID Task", "Status", "VALOR TOTAL", "descricao", "Observações"}
+	suggestions := suggestMappings(columns, suggestFields(), DefaultSuggestMinSimilarity)
+
+	byColumn := suggestionsByColumn(suggestions)
+	if !byColumn["ID Task"].IsTaskID {
+		t.Errorf("coluna de ID da task não detectada: %+v", suggestions)
+	}
+	expected := map[string]string{"Status": "f-status", "VALOR TOTAL": "f-valor", "descricao": "f-descricao"}
+	for column, fieldID := range expected {
+		if got := byColumn[column]; got.FieldID != fieldID || got.IsTaskID {
+			t.Errorf("coluna %q sugerida como %+v, esperado %s", column, got, fieldID)
+		}
+	}
+	if s, ok := byColumn["Observações"]; ok {
+		t.Errorf("coluna sem campo parecido não deveria ter sugestão: %+v", s)
+	}
+	if byColumn["VALOR TOTAL"].FieldName != "Valor Total" || byColumn["VALOR TOTAL"].FieldType != "currency" {
+		t.Errorf("sugestão sem nome/tipo do campo: %+v", byColumn["VALOR TOTAL"])
+	}
+
+	// Sugestões seguem a ordem das colunas do arquivo
+	var order []string
+	for _, s := range suggestions {
+		order = append(order, s.Column)
+	}
+	if want := []string{"ID Task", "Status", "VALOR TOTAL", "descricao"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("ordem %v, esperado %v", order, want)
+	}
+}
+
+func TestSuggestMappingsNearMisses(t *testing.T) {
+	columns := []string{"task_id", "Prioridde", "valor_total", "Responsavel ", "Stat", "Margem"}
+	byColumn := suggestionsByColumn(suggestMappings(columns, suggestFields(), DefaultSuggestMinSimilarity))
+
+	if !byColumn["task_id"].IsTaskID {
+		t.Errorf("task_id não detectada como ID da task: %+v", byColumn)
+	}
+	expected := map[string]string{"Prioridde": "f-prioridade", "valor_total": "f-valor", "Responsavel ": "f-responsavel"}
+	for column, fieldID := range expected {
+		if got := byColumn[column]; got.FieldID != fieldID {
+			t.Errorf("coluna %q sugerida como %+v, esperado %s", column, got, fieldID)
+		}
+	}
+	// "Stat" está longe demais de "Status"; campos calculados nunca são sugeridos
+	for _, column := range []string{"Stat", "Margem"} {
+		if s, ok := byColumn[column]; ok {
+			t.Errorf("coluna %q não deveria ter sugestão: %+v", column, s)
+		}
+	}
+
+	// Com limiar menor a coluna abreviada passa a ser sugerida
+	byColumn = suggestionsByColumn(suggestMappings(columns, suggestFields(), 0.6))
+	if byColumn["Stat"].FieldID != "f-status" {
+		t.Errorf("com limiar 0.6 esperado Stat -> f-status, obtido %+v", byColumn["Stat"])
+	}
+}
+
+func TestSuggestMappingsEachFieldOnce(t *testing.T) {
+	// "Statuss" e "Status" disputam o mesmo campo: fica com a melhor correspondência
+	columns := []string{"Statuss", "Status", "Id"}
+	suggestions := suggestMappings(columns, suggestFields(), DefaultSuggestMinSimilarity)
+
+	byColumn := suggestionsByColumn(suggestions)
+	if byColumn["Status"].FieldID != "f-status" {
+		t.Errorf("coluna exata deveria ficar com o campo: %+v", suggestions)
+	}
+	if s, ok := byColumn["Statuss"]; ok {
+		t.Errorf("campo sugerido para duas colunas: %+v", s)
+	}
+	if !byColumn["Id"].IsTaskID {
+		t.Errorf("coluna Id não detectada como ID da task: %+v", suggestions)
+	}
+}
+
+// TestFindByColumns verifies that mappings are offered again for files with the same
+// set of columns (in any order) and not after a column is renamed, added or removed
+func TestFindByColumns(t *testing.T) {
+	svc := NewMappingService(nil)
+	save := func(title string, columns []string) *StoredMapping {
+		stored, err := svc.SaveMapping("user-1", &MappingRequest{
+			FilePath: "/tmp/" + title + ".csv",
+			Title:    title,
+			Mappings: []ColumnMapping{{Column: "id task", IsTaskID: true}},
+			Columns:  columns,
+		})
+		if err != nil {
+			t.Fatalf("SaveMapping: %v", err)
+		}
+		return stored
+	}
+
+	find := func(columns []string) []*StoredMapping {
+		t.Helper()
+		matches, err := svc.FindByColumns("user-1", columns)
+		if err != nil {
+			t.Fatalf("FindByColumns: %v", err)
+		}
+		return matches
+	}
+
+	first := save("janeiro", []string{"id task", "Status", "Valor"})
+	second := save("fevereiro", []string{"Valor", "id task", " Status"})
+	other := save("outro", []string{"id task", "Responsável"})
+
+	matches := find([]string{"Status", "Valor", "id task"})
+	if len(matches) != 2 || matches[0].ID != second.ID || matches[1].ID != first.ID {
+		t.Fatalf("esperado [fevereiro janeiro], obtido %v", mappingTitles(matches))
+	}
+	if matches, _ := svc.FindByColumns("user-2", []string{"Status", "Valor", "id task"}); len(matches) != 0 {
+		t.Errorf("mapeamentos de outro usuário não deveriam ser oferecidos: %v", mappingTitles(matches))
+	}
+	if first.Signature != second.Signature || first.Signature == other.Signature {
+		t.Errorf("assinaturas inesperadas: %q %q %q", first.Signature, second.Signature, other.Signature)
+	}
+
+	for name, columns := range map[string][]string{
+		"coluna renomeada":  {"id task", "Situação", "Valor"},
+		"coluna adicionada": {"id task", "Status", "Valor", "Prazo"},
+		"coluna removida":   {"id task", "Status"},
+	} {
+		if matches := find(columns); len(matches) != 0 {
+			t.Errorf("%s: nenhum mapeamento esperado, obtido %v", name, mappingTitles(matches))
+		}
+	}
+}
+
+func mappingTitles(mappings []*StoredMapping) []string {
+	titles := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		titles = append(titles, m.Title)
+	}
+	return titles
+}
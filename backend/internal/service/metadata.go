@@ -2,17 +2,17 @@ package service
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"io"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/cache"
 	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 )
 
@@ -20,33 +20,111 @@ const (
 	// Cache keys
 	cacheKeyHierarchy    = "metadata:hierarchy"
 	cacheKeyCustomFields = "metadata:custom_fields"
+	cacheKeyFieldOptions = "metadata:field_options:" // + field ID
 	
 	// Default cache TTL
 	defaultCacheTTL = 5 * time.Minute
 )
 
+// ErrOptionNotFound indica um valor que não corresponde a nenhuma opção do campo
+var ErrOptionNotFound = errors.New("opção não encontrada no campo")
+
+// ErrFieldWithoutOptions indica um campo que não é drop_down nem labels
+var ErrFieldWithoutOptions = errors.New("campo não possui lista de opções")
+
+// FieldOption é uma opção de um campo drop_down ou labels
+type FieldOption struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// ErrInvalidClickUpToken indica que o ClickUp recusou o token informado
+var ErrInvalidClickUpToken = errors.New("token do ClickUp inválido")
+
+// Erros de seleção de token
+var (
+	ErrInvalidTokenLabel  = errors.New("rótulo de token inválido (até 50 letras, números, espaços, - ou _)")
+	ErrTokenNotConfigured = errors.New("token não configurado para usuário")
+)
+
+// DefaultTokenLabel é o rótulo do token único salvo em user_config; rótulo vazio significa ele
+const DefaultTokenLabel = "default"
+
+// maxTokenLabelLength limita o tamanho do rótulo de um token
+const maxTokenLabelLength = 50
+
+// NormalizeTokenLabel valida um rótulo de token, retornando DefaultTokenLabel para vazio
+func NormalizeTokenLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" || strings.EqualFold(label, DefaultTokenLabel) {
+		return DefaultTokenLabel, nil
+	}
+	if len([]rune(label)) > maxTokenLabelLength {
+		return "", ErrInvalidTokenLabel
+	}
+	for _, r := range label {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' && r != '_' {
+			return "", ErrInvalidTokenLabel
+		}
+	}
+	return label, nil
+}
+
+// ClickUpTokenSummary descreve um token salvo, sem o valor
+type ClickUpTokenSummary struct {
+	Label     string    `json:"label"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ClickUpTokenInfo identifica a conta de um token validado
+type ClickUpTokenInfo struct {
+	Username       string `json:"username"`
+	Email          string `json:"email,omitempty"`
+	WorkspaceCount int    `json:"workspace_count"`
+}
+
 // MetadataService gerencia sincronização de metadados do ClickUp
 type MetadataService struct {
-	metadataRepo  *repository.MetadataRepository
-	configRepo    *repository.ConfigRepository
-	encryptionKey []byte
-	cache         *cache.Cache
+	metadataRepo     *repository.MetadataRepository
+	configRepo       *repository.ConfigRepository
+	tokenCipher      *tokenCipher
+	cache            *cache.Cache
+	clientOptions    client.ClientOptions
+	syncListeners    []SyncListener
+	tokenAccessHooks []TokenAccessHook
+	syncConcurrency  int
 }
 
 // NewMetadataService cria um novo serviço de metadados
 func NewMetadataService(metadataRepo *repository.MetadataRepository, configRepo *repository.ConfigRepository, encryptionKey string) *MetadataService {
-	// Usa uma chave de 32 bytes para AES-256
-	key := make([]byte, 32)
-	copy(key, []byte(encryptionKey))
-	
 	return &MetadataService{
-		metadataRepo:  metadataRepo,
-		configRepo:    configRepo,
-		encryptionKey: key,
-		cache:         cache.NewCache(defaultCacheTTL),
+		metadataRepo:    metadataRepo,
+		configRepo:      configRepo,
+		tokenCipher:     newTokenCipher(encryptionKey),
+		cache:           cache.NewCache(defaultCacheTTL),
+		syncConcurrency: DefaultSyncConcurrency,
 	}
 }
 
+// SetPreviousEncryptionKeys aceita chaves antigas na decifragem durante a troca de ENCRYPTION_KEY
+func (s *MetadataService) SetPreviousEncryptionKeys(keys []string) {
+	s.tokenCipher = newTokenCipher(s.tokenCipher.primarySecret, keys...)
+}
+
+// SetClientOptions define timeout e pool de conexões dos clientes ClickUp criados na sincronização
+func (s *MetadataService) SetClientOptions(opts client.ClientOptions) {
+	s.clientOptions = opts
+}
+
+// SetSyncConcurrency define quantas requisições ao ClickUp a sincronização faz ao mesmo tempo
+func (s *MetadataService) SetSyncConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.syncConcurrency = n
+}
+
 // InvalidateCache clears all cached metadata
 func (s *MetadataService) InvalidateCache() {
 	s.cache.Clear()
@@ -57,14 +135,26 @@ func (s *MetadataService) GetCacheStats() int {
 	return s.cache.Size()
 }
 
-// SyncMetadata sincroniza todos os metadados do ClickUp para um usuário
-func (s *MetadataService) SyncMetadata(ctx context.Context, userID, token string) error {
+// SyncMetadata sincroniza todos os metadados do ClickUp para um usuário, salvando
+// o token sob o rótulo informado (vazio = token padrão)
+func (s *MetadataService) SyncMetadata(ctx context.Context, userID, label, token string) error {
 	log := logger.Get(ctx)
 	
-	log.Info().Str("user_id", userID).Msg("Iniciando sincronização de metadados")
+	label, err := NormalizeTokenLabel(label)
+	if err != nil {
+		return err
+	}
+	
+	log.Info().Str("user_id", userID).Str("label", label).Msg("Iniciando sincronização de metadados")
+	summary := SyncSummary{
+		Event:      SyncEventCompleted,
+		UserID:     userID,
+		TokenLabel: label,
+		StartedAt:  time.Now(),
+	}
 	
 	// Cria cliente ClickUp
-	clickupClient := client.NewClient(token)
+	clickupClient := client.NewClientWithOptions(token, s.clientOptions)
 	
 	// Valida token primeiro
 	if err := clickupClient.ValidateToken(ctx); err != nil {
@@ -73,155 +163,180 @@ func (s *MetadataService) SyncMetadata(ctx context.Context, userID, token string
 	}
 	
 	// Salva token criptografado
-	encryptedToken, err := s.encryptToken(token)
-	if err != nil {
-		return fmt.Errorf("erro ao criptografar token: %w", err)
+	if err := s.storeToken(userID, label, token); err != nil {
+		return err
 	}
 	
-	if err := s.configRepo.UpdateClickUpToken(userID, encryptedToken); err != nil {
-		return fmt.Errorf("erro ao salvar token: %w", err)
+	// Percorre a hierarquia; subárvores independentes são buscadas em paralelo
+	if err := newMetadataSync(clickupClient, s.metadataRepo, s.syncConcurrency, &summary).run(ctx); err != nil {
+		return err
 	}
 	
-	// Busca workspaces
-	workspaces, err := clickupClient.GetWorkspaces(ctx)
+	// Invalidate cache after sync
+	s.InvalidateCache()
+	
+	log.Info().Str("user_id", userID).Msg("Sincronização de metadados concluída, cache invalidado")
+
+	summary.CompletedAt = time.Now()
+	s.notifySyncCompleted(ctx, summary)
+	return nil
+}
+
+// KnownListIDs retorna quais dos IDs informados estão nos metadados sincronizados
+func (s *MetadataService) KnownListIDs(listIDs []string) (map[string]bool, error) {
+	return s.metadataRepo.FindListIDs(listIDs)
+}
+
+// GetFieldOptions retorna o mapa nome/ID (minúsculo) -> ID das opções de um campo.
+// O mapa é mantido em cache por campo e invalidado a cada sincronização.
+// Retorna nil quando o campo não existe nos metadados.
+func (s *MetadataService) GetFieldOptions(fieldID string) (map[string]string, error) {
+	cacheKey := cacheKeyFieldOptions + fieldID
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		return cached.(map[string]string), nil
+	}
+
+	field, err := s.metadataRepo.GetCustomFieldByID(fieldID)
 	if err != nil {
-		return fmt.Errorf("erro ao buscar workspaces: %w", err)
+		return nil, err
 	}
-	
-	log.Info().Int("count", len(workspaces)).Msg("Workspaces encontrados")
-	
-	// Salva workspaces e busca spaces
-	for _, workspace := range workspaces {
-		// Salva workspace
-		if err := s.metadataRepo.UpsertWorkspace(repository.Workspace{
-			ID:   workspace.ID,
-			Name: workspace.Name,
-		}); err != nil {
-			log.Error().Err(err).Str("workspace_id", workspace.ID).Msg("Erro ao salvar workspace")
+	if field == nil {
+		return nil, nil
+	}
+
+	optionMap := buildOptionMap(field.Options)
+	s.cache.Set(cacheKey, optionMap)
+	return optionMap, nil
+}
+
+// ListFieldOptions retorna as opções de um campo drop_down ou labels a partir dos
+// metadados sincronizados. Retorna ErrFieldNotFound para campos desconhecidos e
+// ErrFieldWithoutOptions para campos de outros tipos.
+func (s *MetadataService) ListFieldOptions(fieldID string) ([]FieldOption, error) {
+	field, err := s.metadataRepo.GetCustomFieldByID(fieldID)
+	if err != nil {
+		return nil, err
+	}
+	return FieldOptionList(field)
+}
+
+// FieldOptionList extrai as opções armazenadas de um campo, na ordem do ClickUp
+func FieldOptionList(field *repository.CustomField) ([]FieldOption, error) {
+	if field == nil {
+		return nil, ErrFieldNotFound
+	}
+	if field.Type != "drop_down" && field.Type != "labels" {
+		return nil, ErrFieldWithoutOptions
+	}
+
+	list, _ := field.Options["options"].([]interface{})
+	type ordered struct {
+		option FieldOption
+		index  float64
+	}
+	items := make([]ordered, 0, len(list))
+	for i, item := range list {
+		opt, ok := item.(map[string]interface{})
+		if !ok {
 			continue
 		}
-		
-		// Busca spaces do workspace
-		spaces, err := clickupClient.GetSpaces(ctx, workspace.ID)
-		if err != nil {
-			log.Error().Err(err).Str("workspace_id", workspace.ID).Msg("Erro ao buscar spaces")
+		id, _ := opt["id"].(string)
+		if id == "" {
 			continue
 		}
-		
-		log.Info().Str("workspace_id", workspace.ID).Int("count", len(spaces)).Msg("Spaces encontrados")
-		
-		// Salva spaces e busca folders
-		for _, space := range spaces {
-			// Salva space
-			if err := s.metadataRepo.UpsertSpace(repository.Space{
-				ID:          space.ID,
-				WorkspaceID: workspace.ID,
-				Name:        space.Name,
-			}); err != nil {
-				log.Error().Err(err).Str("space_id", space.ID).Msg("Erro ao salvar space")
-				continue
+		option := FieldOption{ID: id}
+		for _, key := range []string{"name", "label"} {
+			if name, ok := opt[key].(string); ok && name != "" {
+				option.Name = name
+				break
 			}
-			
-			// Busca folders do space
-			folders, err := clickupClient.GetFolders(ctx, space.ID)
-			if err != nil {
-				log.Error().Err(err).Str("space_id", space.ID).Msg("Erro ao buscar folders")
-				continue
+		}
+		option.Color, _ = opt["color"].(string)
+		index, ok := opt["orderindex"].(float64)
+		if !ok {
+			index = float64(i)
+		}
+		items = append(items, ordered{option: option, index: index})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].index < items[j].index })
+
+	options := make([]FieldOption, len(items))
+	for i, item := range items {
+		options[i] = item.option
+	}
+	return options, nil
+}
+
+// ResolveOptionValue converte nomes de opções de campos drop_down e labels para os IDs
+// esperados pelo ClickUp. A comparação ignora maiúsculas/minúsculas; valores de outros
+// tipos e campos sem metadados são retornados sem alteração.
+func (s *MetadataService) ResolveOptionValue(fieldID, fieldType, value string) (string, error) {
+	if fieldType != "drop_down" && fieldType != "labels" {
+		return value, nil
+	}
+
+	optionMap, err := s.GetFieldOptions(fieldID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar opções do campo: %w", err)
+	}
+	if optionMap == nil {
+		return value, nil
+	}
+
+	return resolveOptionNames(optionMap, fieldType, value)
+}
+
+// buildOptionMap extrai as opções armazenadas de um campo, indexadas por nome e por ID
+func buildOptionMap(options map[string]interface{}) map[string]string {
+	optionMap := make(map[string]string)
+
+	list, ok := options["options"].([]interface{})
+	if !ok {
+		return optionMap
+	}
+
+	for _, item := range list {
+		opt, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := opt["id"].(string)
+		if id == "" {
+			continue
+		}
+		optionMap[strings.ToLower(id)] = id
+		for _, key := range []string{"name", "label"} {
+			if name, ok := opt[key].(string); ok && name != "" {
+				optionMap[strings.ToLower(strings.TrimSpace(name))] = id
 			}
-			
-			log.Info().Str("space_id", space.ID).Int("count", len(folders)).Msg("Folders encontrados")
-			
-			// Salva folders e busca listas
-			for _, folder := range folders {
-				// Salva folder
-				if err := s.metadataRepo.UpsertFolder(repository.Folder{
-					ID:      folder.ID,
-					SpaceID: space.ID,
-					Name:    folder.Name,
-				}); err != nil {
-					log.Error().Err(err).Str("folder_id", folder.ID).Msg("Erro ao salvar folder")
-					continue
-				}
-				
-				// Busca listas do folder
-				lists, err := clickupClient.GetLists(ctx, folder.ID)
-				if err != nil {
-					log.Error().Err(err).Str("folder_id", folder.ID).Msg("Erro ao buscar listas")
-					continue
-				}
-				
-				log.Info().Str("folder_id", folder.ID).Int("count", len(lists)).Msg("Listas encontradas")
-				
-				// Salva listas e busca campos personalizados
-				for _, list := range lists {
-					// Salva lista
-					if err := s.metadataRepo.UpsertList(repository.List{
-						ID:       list.ID,
-						FolderID: folder.ID,
-						Name:     list.Name,
-					}); err != nil {
-						log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao salvar lista")
-						continue
-					}
-					
-					// Busca campos personalizados da lista
-					fields, err := clickupClient.GetCustomFields(ctx, list.ID)
-					if err != nil {
-						log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao buscar campos personalizados")
-						continue
-					}
-					
-					// Salva campos personalizados
-					for _, field := range fields {
-						options := make(map[string]interface{})
-						
-						// Converte TypeConfig para options
-						if field.TypeConfig != nil {
-							if field.TypeConfig.Options != nil {
-								optionsList := make([]map[string]interface{}, len(field.TypeConfig.Options))
-								for i, opt := range field.TypeConfig.Options {
-									optionsList[i] = map[string]interface{}{
-										"id":         opt.ID,
-										"name":       opt.Name,
-										"color":      opt.Color,
-										"orderindex": opt.Orderindex,
-									}
-								}
-								options["options"] = optionsList
-							}
-							
-							if field.TypeConfig.Precision > 0 {
-								options["precision"] = field.TypeConfig.Precision
-							}
-							
-							if field.TypeConfig.CurrencyType != "" {
-								options["currency_type"] = field.TypeConfig.CurrencyType
-							}
-							
-							options["include_time"] = field.TypeConfig.IncludeTime
-							options["is_time"] = field.TypeConfig.IsTime
-						}
-						
-						if err := s.metadataRepo.UpsertCustomField(repository.CustomField{
-							ID:      field.ID,
-							Name:    field.Name,
-							Type:    field.Type,
-							Options: options,
-						}); err != nil {
-							log.Error().Err(err).Str("field_id", field.ID).Msg("Erro ao salvar campo personalizado")
-							continue
-						}
-					}
-				}
+		}
+	}
+
+	return optionMap
+}
+
+// resolveOptionNames resolve um valor (ou lista separada por vírgula, para labels) em IDs de opção
+func resolveOptionNames(optionMap map[string]string, fieldType, value string) (string, error) {
+	names := []string{strings.TrimSpace(value)}
+	if fieldType == "labels" {
+		names = names[:0]
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				names = append(names, part)
 			}
 		}
 	}
-	
-	// Invalidate cache after sync
-	s.InvalidateCache()
-	
-	log.Info().Str("user_id", userID).Msg("Sincronização de metadados concluída, cache invalidado")
-	return nil
+
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := optionMap[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("%w: '%s'", ErrOptionNotFound, name)
+		}
+		ids = append(ids, id)
+	}
+
+	return strings.Join(ids, ","), nil
 }
 
 // GetHierarchicalData retorna dados hierárquicos para interface
@@ -245,6 +360,42 @@ func (s *MetadataService) GetHierarchicalData(ctx context.Context) (*Hierarchica
 		Workspaces: make([]WorkspaceData, len(workspaces)),
 	}
 	
+	// Busca campos personalizados, aninhados depois sob as listas em que aparecem
+	fields, err := s.metadataRepo.GetCustomFields()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar campos personalizados: %w", err)
+	}
+	fieldsByList := make(map[string][]CustomFieldData)
+	listData := func(lists []repository.List) []ListData {
+		result := make([]ListData, len(lists))
+		for i, list := range lists {
+			listFields := fieldsByList[list.ID]
+			if listFields == nil {
+				listFields = []CustomFieldData{}
+			}
+			result[i] = ListData{
+				ID:           list.ID,
+				Name:         list.Name,
+				CustomFields: listFields,
+			}
+		}
+		return result
+	}
+	data.CustomFields = make([]CustomFieldData, len(fields))
+	for i, field := range fields {
+		fieldData := CustomFieldData{
+			ID:      field.ID,
+			Name:    field.Name,
+			Type:    field.Type,
+			Options: field.Options,
+			ListIDs: field.ListIDs,
+		}
+		data.CustomFields[i] = fieldData
+		for _, listID := range field.ListIDs {
+			fieldsByList[listID] = append(fieldsByList[listID], fieldData)
+		}
+	}
+	
 	for i, workspace := range workspaces {
 		spaces, err := s.metadataRepo.GetSpacesByWorkspace(workspace.ID)
 		if err != nil {
@@ -263,10 +414,16 @@ func (s *MetadataService) GetHierarchicalData(ctx context.Context) (*Hierarchica
 				return nil, fmt.Errorf("erro ao buscar folders: %w", err)
 			}
 			
+			folderless, err := s.metadataRepo.GetFolderlessListsBySpace(space.ID)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao buscar listas sem folder: %w", err)
+			}
+			
 			spaceData := SpaceData{
 				ID:      space.ID,
 				Name:    space.Name,
 				Folders: make([]FolderData, len(folders)),
+				Lists:   listData(folderless),
 			}
 			
 			for k, folder := range folders {
@@ -275,20 +432,11 @@ func (s *MetadataService) GetHierarchicalData(ctx context.Context) (*Hierarchica
 					return nil, fmt.Errorf("erro ao buscar listas: %w", err)
 				}
 				
-				folderData := FolderData{
+				spaceData.Folders[k] = FolderData{
 					ID:    folder.ID,
 					Name:  folder.Name,
-					Lists: make([]ListData, len(lists)),
-				}
-				
-				for l, list := range lists {
-					folderData.Lists[l] = ListData{
-						ID:   list.ID,
-						Name: list.Name,
-					}
+					Lists: listData(lists),
 				}
-				
-				spaceData.Folders[k] = folderData
 			}
 			
 			workspaceData.Spaces[j] = spaceData
@@ -297,22 +445,6 @@ func (s *MetadataService) GetHierarchicalData(ctx context.Context) (*Hierarchica
 		data.Workspaces[i] = workspaceData
 	}
 	
-	// Busca campos personalizados
-	fields, err := s.metadataRepo.GetCustomFields()
-	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar campos personalizados: %w", err)
-	}
-	
-	data.CustomFields = make([]CustomFieldData, len(fields))
-	for i, field := range fields {
-		data.CustomFields[i] = CustomFieldData{
-			ID:      field.ID,
-			Name:    field.Name,
-			Type:    field.Type,
-			Options: field.Options,
-		}
-	}
-	
 	// Store in cache
 	s.cache.Set(cacheKeyHierarchy, data)
 	log.Debug().Int("workspaces", len(data.Workspaces)).Int("custom_fields", len(data.CustomFields)).Msg("Dados hierárquicos armazenados no cache")
@@ -320,78 +452,194 @@ func (s *MetadataService) GetHierarchicalData(ctx context.Context) (*Hierarchica
 	return data, nil
 }
 
-// GetUserToken retorna o token descriptografado do usuário
-func (s *MetadataService) GetUserToken(ctx context.Context, userID string) (string, error) {
-	config, err := s.configRepo.GetUserConfig(userID)
+// SaveClickUpToken valida o token no ClickUp e, se aceito, salva criptografado sob o
+// rótulo (vazio = token padrão). Retorna a conta do token para o usuário confirmar que é a esperada.
+func (s *MetadataService) SaveClickUpToken(ctx context.Context, userID, label, token string) (*ClickUpTokenInfo, error) {
+	label, err := NormalizeTokenLabel(label)
 	if err != nil {
-		return "", fmt.Errorf("erro ao buscar configuração: %w", err)
+		return nil, err
 	}
-	
-	if config == nil || config.ClickUpTokenEncrypted == "" {
-		return "", fmt.Errorf("token não configurado para usuário")
+
+	clickupClient := client.NewClientWithOptions(token, s.clientOptions)
+
+	user, err := clickupClient.GetAuthorizedUser(ctx)
+	if err != nil {
+		if errors.Is(err, model.ErrUnauthorized) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidClickUpToken, err)
+		}
+		return nil, fmt.Errorf("erro ao validar token: %w", err)
 	}
-	
-	token, err := s.decryptToken(config.ClickUpTokenEncrypted)
+
+	workspaces, err := clickupClient.GetWorkspaces(ctx)
 	if err != nil {
-		return "", fmt.Errorf("erro ao descriptografar token: %w", err)
+		return nil, fmt.Errorf("erro ao buscar workspaces: %w", err)
 	}
-	
-	return token, nil
+
+	if err := s.storeToken(userID, label, token); err != nil {
+		return nil, err
+	}
+
+	return &ClickUpTokenInfo{
+		Username:       user.Username,
+		Email:          user.Email,
+		WorkspaceCount: len(workspaces),
+	}, nil
 }
 
-// encryptToken criptografa um token usando AES
-func (s *MetadataService) encryptToken(token string) (string, error) {
-	block, err := aes.NewCipher(s.encryptionKey)
+// storeToken criptografa o token e salva no rótulo já normalizado
+func (s *MetadataService) storeToken(userID, label, token string) error {
+	encryptedToken, err := s.encryptToken(token)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("erro ao criptografar token: %w", err)
 	}
-	
-	gcm, err := cipher.NewGCM(block)
+
+	if label == DefaultTokenLabel {
+		err = s.configRepo.UpdateClickUpToken(userID, encryptedToken)
+	} else {
+		err = s.configRepo.UpsertUserToken(userID, label, encryptedToken)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar token: %w", err)
+	}
+	return nil
+}
+
+// GetUserToken retorna o token descriptografado do usuário para o rótulo (vazio = token padrão).
+// Só os tokens do próprio usuário são encontrados. Cada descriptografia é auditada com a
+// finalidade marcada por WithTokenPurpose.
+func (s *MetadataService) GetUserToken(ctx context.Context, userID, label string) (string, error) {
+	label, err := NormalizeTokenLabel(label)
 	if err != nil {
 		return "", err
 	}
+
+	var encrypted string
+	if label == DefaultTokenLabel {
+		config, err := s.configRepo.GetUserConfig(userID)
+		if err != nil {
+			return "", fmt.Errorf("erro ao buscar configuração: %w", err)
+		}
+		if config != nil {
+			encrypted = config.ClickUpTokenEncrypted
+		}
+	} else {
+		userToken, err := s.configRepo.GetUserTokenByLabel(userID, label)
+		if err != nil {
+			return "", err
+		}
+		if userToken != nil {
+			encrypted = userToken.TokenEncrypted
+		}
+	}
+
+	if encrypted == "" {
+		return "", fmt.Errorf("%w: %s", ErrTokenNotConfigured, label)
+	}
 	
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	token, err := s.openUserToken(ctx, userID, label, encrypted)
+	if err != nil {
+		return "", fmt.Errorf("erro ao descriptografar token: %w", err)
 	}
 	
-	ciphertext := gcm.Seal(nonce, nonce, []byte(token), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return token, nil
 }
 
-// decryptToken descriptografa um token usando AES
-func (s *MetadataService) decryptToken(encryptedToken string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(encryptedToken)
+// GetReportDefaults retorna os padrões de relatório do usuário (vazio se não configurados)
+func (s *MetadataService) GetReportDefaults(ctx context.Context, userID string) (ReportDefaults, error) {
+	config, err := s.configRepo.GetUserConfig(userID)
 	if err != nil {
-		return "", err
+		return ReportDefaults{}, fmt.Errorf("erro ao buscar configuração: %w", err)
 	}
-	
-	block, err := aes.NewCipher(s.encryptionKey)
+	if config == nil {
+		return ReportDefaults{}, nil
+	}
+	return ReportDefaults{
+		Subtasks:      config.DefaultSubtasks,
+		IncludeClosed: config.DefaultIncludeClosed,
+	}, nil
+}
+
+// ListClickUpTokens lista os tokens salvos do usuário (o padrão primeiro), sem os valores
+func (s *MetadataService) ListClickUpTokens(ctx context.Context, userID string) ([]ClickUpTokenSummary, error) {
+	var summaries []ClickUpTokenSummary
+
+	config, err := s.configRepo.GetUserConfig(userID)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("erro ao buscar configuração: %w", err)
 	}
-	
-	gcm, err := cipher.NewGCM(block)
+	if config != nil && config.ClickUpTokenEncrypted != "" {
+		summaries = append(summaries, ClickUpTokenSummary{Label: DefaultTokenLabel, UpdatedAt: config.UpdatedAt})
+	}
+
+	tokens, err := s.configRepo.ListUserTokens(userID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	for _, t := range tokens {
+		summaries = append(summaries, ClickUpTokenSummary{Label: t.Label, UpdatedAt: t.UpdatedAt})
 	}
-	
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+
+	return summaries, nil
+}
+
+// DeleteClickUpToken remove o token do rótulo; o padrão é apenas esvaziado em user_config
+func (s *MetadataService) DeleteClickUpToken(ctx context.Context, userID, label string) error {
+	label, err := NormalizeTokenLabel(label)
 	if err != nil {
-		return "", err
+		return err
 	}
-	
-	return string(plaintext), nil
+
+	if label != DefaultTokenLabel {
+		if err := s.configRepo.DeleteUserToken(userID, label); err != nil {
+			if errors.Is(err, repository.ErrUserTokenNotFound) {
+				return fmt.Errorf("%w: %s", ErrTokenNotConfigured, label)
+			}
+			return err
+		}
+		return nil
+	}
+
+	config, err := s.configRepo.GetUserConfig(userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar configuração: %w", err)
+	}
+	if config == nil || config.ClickUpTokenEncrypted == "" {
+		return fmt.Errorf("%w: %s", ErrTokenNotConfigured, label)
+	}
+	return s.configRepo.UpdateClickUpToken(userID, "")
+}
+
+// encryptToken criptografa um token com a chave atual (AES-GCM, com id da chave)
+func (s *MetadataService) encryptToken(token string) (string, error) {
+	return s.tokenCipher.encrypt(token)
 }
 
-// HierarchicalData representa dados hierárquicos para a interface
+// decryptToken descriptografa um token com a chave atual ou uma das anteriores
+func (s *MetadataService) decryptToken(encryptedToken string) (string, error) {
+	return s.tokenCipher.decrypt(encryptedToken)
+}
+
+// ReencryptTokens decifra todos os tokens salvos com oldKey e os cifra novamente com newKey,
+// em uma transação. Tokens já cifrados com newKey são mantidos, então pode ser executado
+// de novo após uma falha. Depois, configure newKey como ENCRYPTION_KEY.
+func (s *MetadataService) ReencryptTokens(oldKey, newKey string) error {
+	from := newTokenCipher(oldKey)
+	to := newTokenCipher(newKey)
+
+	count, err := s.configRepo.RewriteEncryptedTokens(func(ciphertext string) (string, error) {
+		return reencryptToken(from, to, ciphertext)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao recriptografar tokens: %w", err)
+	}
+
+	logger.Global().Info().Int("tokens", count).Msg("Tokens recriptografados com a nova chave")
+	return nil
+}
+
+// HierarchicalData representa dados hierárquicos para a interface. Os campos ficam
+// sob cada lista; CustomFields é a lista plana de todos eles (com list_ids), mantida
+// para clientes que ainda não usam os campos por lista
 type HierarchicalData struct {
 	Workspaces   []WorkspaceData   `json:"workspaces"`
 	CustomFields []CustomFieldData `json:"custom_fields"`
@@ -409,6 +657,7 @@ type SpaceData struct {
 	ID      string       `json:"id"`
 	Name    string       `json:"name"`
 	Folders []FolderData `json:"folders"`
+	Lists   []ListData   `json:"lists"` // listas direto no space, fora de folders
 }
 
 // FolderData representa dados de folder para interface
@@ -420,8 +669,9 @@ type FolderData struct {
 
 // ListData representa dados de lista para interface
 type ListData struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	CustomFields []CustomFieldData `json:"custom_fields"`
 }
 
 // CustomFieldData representa dados de campo personalizado para interface
@@ -430,4 +680,5 @@ type CustomFieldData struct {
 	Name    string                 `json:"name"`
 	Type    string                 `json:"type"`
 	Options map[string]interface{} `json:"options"`
+	ListIDs []string               `json:"list_ids,omitempty"`
 }
\ No newline at end of file
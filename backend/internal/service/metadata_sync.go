@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// DefaultSyncConcurrency requisições simultâneas ao ClickUp durante a sincronização
+const DefaultSyncConcurrency = 4
+
+// metadataSyncSource busca a hierarquia no ClickUp (implementado por *client.Client)
+type metadataSyncSource interface {
+	GetWorkspaces(ctx context.Context) ([]model.Workspace, error)
+	GetSpaces(ctx context.Context, workspaceID string) ([]model.Space, error)
+	GetFolders(ctx context.Context, spaceID string) ([]model.Folder, error)
+	GetLists(ctx context.Context, folderID string) ([]model.List, error)
+	GetFolderlessLists(ctx context.Context, spaceID string) ([]model.List, error)
+	GetCustomFields(ctx context.Context, listID string) ([]model.CustomFieldMetadata, error)
+}
+
+// metadataSyncStore grava o que a sincronização encontra (implementado por *repository.MetadataRepository)
+type metadataSyncStore interface {
+	UpsertWorkspace(workspace repository.Workspace) error
+	UpsertSpace(space repository.Space) error
+	UpsertFolder(folder repository.Folder) error
+	UpsertList(list repository.List) error
+	UpsertCustomField(field repository.CustomField) error
+	SetListCustomFields(listID string, fieldIDs []string) error
+	PruneWorkspace(snapshot repository.WorkspaceSnapshot) (repository.PruneResult, error)
+}
+
+// metadataSync percorre a hierarquia de um usuário. Spaces, folders e listas de um
+// workspace são buscados em paralelo, com no máximo concurrency requisições ao ClickUp
+// ao mesmo tempo (o rate limiter do cliente continua valendo). A falha de uma subárvore
+// só marca o snapshot como incompleto; as demais seguem normalmente.
+type metadataSync struct {
+	source metadataSyncSource
+	store  metadataSyncStore
+	sem    chan struct{}
+
+	mu         sync.Mutex // protege summary, fieldsSeen e o snapshot do workspace em curso
+	summary    *SyncSummary
+	fieldsSeen map[string]bool // o mesmo campo aparece em várias listas
+}
+
+// newMetadataSync cria o percurso com o limite de requisições simultâneas (mínimo 1)
+func newMetadataSync(source metadataSyncSource, store metadataSyncStore, concurrency int, summary *SyncSummary) *metadataSync {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &metadataSync{
+		source:     source,
+		store:      store,
+		sem:        make(chan struct{}, concurrency),
+		summary:    summary,
+		fieldsSeen: make(map[string]bool),
+	}
+}
+
+// fetch executa uma chamada ao ClickUp ocupando uma vaga do limite de concorrência;
+// retorna ctx.Err() se o contexto for cancelado enquanto espera a vaga
+func (m *metadataSync) fetch(ctx context.Context, call func() error) error {
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-m.sem }()
+	return call()
+}
+
+// locked executa fn com o estado compartilhado protegido
+func (m *metadataSync) locked(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn()
+}
+
+// run sincroniza todos os workspaces do token; só a falha ao listá-los interrompe
+func (m *metadataSync) run(ctx context.Context) error {
+	log := logger.Get(ctx)
+
+	var workspaces []model.Workspace
+	if err := m.fetch(ctx, func() (err error) {
+		workspaces, err = m.source.GetWorkspaces(ctx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("erro ao buscar workspaces: %w", err)
+	}
+
+	log.Info().Int("count", len(workspaces)).Msg("Workspaces encontrados")
+
+	for _, workspace := range workspaces {
+		m.syncWorkspace(ctx, workspace)
+	}
+	m.summary.Fields = len(m.fieldsSeen)
+	return nil
+}
+
+// syncWorkspace salva o workspace, sincroniza seus spaces em paralelo e remove o que
+// foi excluído no ClickUp
+func (m *metadataSync) syncWorkspace(ctx context.Context, workspace model.Workspace) {
+	log := logger.Get(ctx)
+
+	// IDs vistos no ClickUp; o que sobrar no banco foi excluído lá
+	snapshot := &repository.WorkspaceSnapshot{
+		WorkspaceID:    workspace.ID,
+		Folders:        make(map[string][]string),
+		Lists:          make(map[string][]string),
+		SpaceLists:     make(map[string][]string),
+		FieldsComplete: true,
+	}
+
+	// Salva workspace
+	if err := m.store.UpsertWorkspace(repository.Workspace{
+		ID:   workspace.ID,
+		Name: workspace.Name,
+	}); err != nil {
+		log.Error().Err(err).Str("workspace_id", workspace.ID).Msg("Erro ao salvar workspace")
+		return
+	}
+	m.locked(func() { m.summary.Workspaces++ })
+
+	// Busca spaces do workspace
+	var spaces []model.Space
+	if err := m.fetch(ctx, func() (err error) {
+		spaces, err = m.source.GetSpaces(ctx, workspace.ID)
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("workspace_id", workspace.ID).Msg("Erro ao buscar spaces")
+		return
+	}
+
+	log.Info().Str("workspace_id", workspace.ID).Int("count", len(spaces)).Msg("Spaces encontrados")
+	snapshot.SpacesComplete = true
+
+	var wg sync.WaitGroup
+	for _, space := range spaces {
+		wg.Add(1)
+		go func(space model.Space) {
+			defer wg.Done()
+			m.syncSpace(ctx, workspace.ID, space, snapshot)
+		}(space)
+	}
+	wg.Wait()
+
+	// Remove o que foi excluído no ClickUp; pais com listagem incompleta ficam intactos
+	pruned, err := m.store.PruneWorkspace(*snapshot)
+	if err != nil {
+		log.Error().Err(err).Str("workspace_id", workspace.ID).Msg("Erro ao remover metadados excluídos")
+		return
+	}
+	m.summary.Pruned += pruned.Total()
+	if pruned.Total() > 0 {
+		log.Info().
+			Str("workspace_id", workspace.ID).
+			Int64("spaces", pruned.Spaces).
+			Int64("folders", pruned.Folders).
+			Int64("lists", pruned.Lists).
+			Int64("fields", pruned.Fields).
+			Msg("Metadados excluídos no ClickUp removidos")
+	}
+}
+
+// syncSpace salva o space e sincroniza seus folders e listas sem folder em paralelo
+func (m *metadataSync) syncSpace(ctx context.Context, workspaceID string, space model.Space, snapshot *repository.WorkspaceSnapshot) {
+	log := logger.Get(ctx)
+	m.locked(func() { snapshot.Spaces = append(snapshot.Spaces, space.ID) })
+
+	// Salva space
+	if err := m.store.UpsertSpace(repository.Space{
+		ID:          space.ID,
+		WorkspaceID: workspaceID,
+		Name:        space.Name,
+	}); err != nil {
+		log.Error().Err(err).Str("space_id", space.ID).Msg("Erro ao salvar space")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+	m.locked(func() { m.summary.Spaces++ })
+
+	// Busca folders do space
+	var folders []model.Folder
+	if err := m.fetch(ctx, func() (err error) {
+		folders, err = m.source.GetFolders(ctx, space.ID)
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("space_id", space.ID).Msg("Erro ao buscar folders")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+
+	log.Info().Str("space_id", space.ID).Int("count", len(folders)).Msg("Folders encontrados")
+	m.locked(func() { snapshot.Folders[space.ID] = []string{} })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.syncFolderlessLists(ctx, workspaceID, space.ID, snapshot)
+	}()
+	for _, folder := range folders {
+		wg.Add(1)
+		go func(folder model.Folder) {
+			defer wg.Done()
+			m.syncFolder(ctx, workspaceID, space.ID, folder, snapshot)
+		}(folder)
+	}
+	wg.Wait()
+}
+
+// syncFolderlessLists sincroniza as listas criadas direto no space
+func (m *metadataSync) syncFolderlessLists(ctx context.Context, workspaceID, spaceID string, snapshot *repository.WorkspaceSnapshot) {
+	log := logger.Get(ctx)
+
+	var lists []model.List
+	if err := m.fetch(ctx, func() (err error) {
+		lists, err = m.source.GetFolderlessLists(ctx, spaceID)
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("space_id", spaceID).Msg("Erro ao buscar listas sem folder")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+
+	log.Info().Str("space_id", spaceID).Int("count", len(lists)).Msg("Listas sem folder encontradas")
+	m.locked(func() { snapshot.SpaceLists[spaceID] = []string{} })
+
+	var wg sync.WaitGroup
+	for _, list := range lists {
+		wg.Add(1)
+		go func(list model.List) {
+			defer wg.Done()
+			m.syncList(ctx, workspaceID, spaceID, "", list, snapshot)
+		}(list)
+	}
+	wg.Wait()
+}
+
+// syncFolder salva o folder e sincroniza suas listas em paralelo
+func (m *metadataSync) syncFolder(ctx context.Context, workspaceID, spaceID string, folder model.Folder, snapshot *repository.WorkspaceSnapshot) {
+	log := logger.Get(ctx)
+	m.locked(func() { snapshot.Folders[spaceID] = append(snapshot.Folders[spaceID], folder.ID) })
+
+	// Salva folder
+	if err := m.store.UpsertFolder(repository.Folder{
+		ID:      folder.ID,
+		SpaceID: spaceID,
+		Name:    folder.Name,
+	}); err != nil {
+		log.Error().Err(err).Str("folder_id", folder.ID).Msg("Erro ao salvar folder")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+	m.locked(func() { m.summary.Folders++ })
+
+	// Busca listas do folder
+	var lists []model.List
+	if err := m.fetch(ctx, func() (err error) {
+		lists, err = m.source.GetLists(ctx, folder.ID)
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("folder_id", folder.ID).Msg("Erro ao buscar listas")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+
+	log.Info().Str("folder_id", folder.ID).Int("count", len(lists)).Msg("Listas encontradas")
+	m.locked(func() { snapshot.Lists[folder.ID] = []string{} })
+
+	var wg sync.WaitGroup
+	for _, list := range lists {
+		wg.Add(1)
+		go func(list model.List) {
+			defer wg.Done()
+			m.syncList(ctx, workspaceID, spaceID, folder.ID, list, snapshot)
+		}(list)
+	}
+	wg.Wait()
+}
+
+// syncList salva a lista, seus campos personalizados e o vínculo entre eles.
+// folderID vazio indica lista direto no space.
+func (m *metadataSync) syncList(ctx context.Context, workspaceID, spaceID, folderID string, list model.List, snapshot *repository.WorkspaceSnapshot) {
+	log := logger.Get(ctx)
+	m.locked(func() {
+		if folderID == "" {
+			snapshot.SpaceLists[spaceID] = append(snapshot.SpaceLists[spaceID], list.ID)
+		} else {
+			snapshot.Lists[folderID] = append(snapshot.Lists[folderID], list.ID)
+		}
+	})
+
+	// Salva lista
+	if err := m.store.UpsertList(repository.List{
+		ID:       list.ID,
+		FolderID: folderID,
+		SpaceID:  spaceID,
+		Name:     list.Name,
+	}); err != nil {
+		log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao salvar lista")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+	m.locked(func() { m.summary.Lists++ })
+
+	// Busca campos personalizados da lista
+	var fields []model.CustomFieldMetadata
+	if err := m.fetch(ctx, func() (err error) {
+		fields, err = m.source.GetCustomFields(ctx, list.ID)
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao buscar campos personalizados")
+		m.locked(func() { snapshot.FieldsComplete = false })
+		return
+	}
+
+	// Salva campos personalizados
+	listFieldIDs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		m.locked(func() { snapshot.Fields = append(snapshot.Fields, field.ID) })
+
+		if err := m.store.UpsertCustomField(repository.CustomField{
+			ID:          field.ID,
+			Name:        field.Name,
+			Type:        field.Type,
+			Options:     customFieldOptions(field),
+			WorkspaceID: workspaceID,
+		}); err != nil {
+			log.Error().Err(err).Str("field_id", field.ID).Msg("Erro ao salvar campo personalizado")
+			continue
+		}
+		m.locked(func() { m.fieldsSeen[field.ID] = true })
+		listFieldIDs = append(listFieldIDs, field.ID)
+	}
+
+	// Vincula os campos à lista (o mesmo nome pode existir em outras listas)
+	if err := m.store.SetListCustomFields(list.ID, listFieldIDs); err != nil {
+		log.Error().Err(err).Str("list_id", list.ID).Msg("Erro ao vincular campos à lista")
+	}
+}
+
+// customFieldOptions converte o TypeConfig do ClickUp para as options salvas no banco
+func customFieldOptions(field model.CustomFieldMetadata) map[string]interface{} {
+	options := make(map[string]interface{})
+	if field.TypeConfig == nil {
+		return options
+	}
+
+	if field.TypeConfig.Options != nil {
+		optionsList := make([]map[string]interface{}, len(field.TypeConfig.Options))
+		for i, opt := range field.TypeConfig.Options {
+			name := opt.Name
+			if name == "" {
+				name = opt.Label
+			}
+			optionsList[i] = map[string]interface{}{
+				"id":         opt.ID,
+				"name":       name,
+				"color":      opt.Color,
+				"orderindex": opt.Orderindex,
+			}
+		}
+		options["options"] = optionsList
+	}
+
+	if field.TypeConfig.Precision > 0 {
+		options["precision"] = field.TypeConfig.Precision
+	}
+
+	if field.TypeConfig.CurrencyType != "" {
+		options["currency_type"] = field.TypeConfig.CurrencyType
+	}
+
+	options["include_time"] = field.TypeConfig.IncludeTime
+	options["is_time"] = field.TypeConfig.IsTime
+	return options
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// fakeMetadataStore guarda em memória o que a sincronização grava
+type fakeMetadataStore struct {
+	mu         sync.Mutex
+	workspaces map[string]repository.Workspace
+	spaces     map[string]repository.Space
+	folders    map[string]repository.Folder
+	lists      map[string]repository.List
+	fields     map[string]repository.CustomField
+	listFields map[string][]string
+	snapshots  []repository.WorkspaceSnapshot
+}
+
+func newFakeMetadataStore() *fakeMetadataStore {
+	return &fakeMetadataStore{
+		workspaces: make(map[string]repository.Workspace),
+		spaces:     make(map[string]repository.Space),
+		folders:    make(map[string]repository.Folder),
+		lists:      make(map[string]repository.List),
+		fields:     make(map[string]repository.CustomField),
+		listFields: make(map[string][]string),
+	}
+}
+
+func (f *fakeMetadataStore) UpsertWorkspace(w repository.Workspace) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workspaces[w.ID] = w
+	return nil
+}
+
+func (f *fakeMetadataStore) UpsertSpace(s repository.Space) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spaces[s.ID] = s
+	return nil
+}
+
+func (f *fakeMetadataStore) UpsertFolder(folder repository.Folder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.folders[folder.ID] = folder
+	return nil
+}
+
+func (f *fakeMetadataStore) UpsertList(l repository.List) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists[l.ID] = l
+	return nil
+}
+
+func (f *fakeMetadataStore) UpsertCustomField(field repository.CustomField) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fields[field.ID] = field
+	return nil
+}
+
+func (f *fakeMetadataStore) SetListCustomFields(listID string, fieldIDs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := append([]string{}, fieldIDs...)
+	sort.Strings(ids)
+	f.listFields[listID] = ids
+	return nil
+}
+
+func (f *fakeMetadataStore) PruneWorkspace(snapshot repository.WorkspaceSnapshot) (repository.PruneResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// A ordem depende de qual goroutine terminou primeiro
+	sort.Strings(snapshot.Spaces)
+	sort.Strings(snapshot.Fields)
+	for _, ids := range snapshot.Folders {
+		sort.Strings(ids)
+	}
+	for _, ids := range snapshot.Lists {
+		sort.Strings(ids)
+	}
+	for _, ids := range snapshot.SpaceLists {
+		sort.Strings(ids)
+	}
+	f.snapshots = append(f.snapshots, snapshot)
+	return repository.PruneResult{}, nil
+}
+
+// syncHierarchyServer simula o ClickUp com três spaces, com listas em folders e direto no
+// space; a listagem de listas do folder f2b falha. maxInFlight registra o máximo de requisições atendidas ao mesmo tempo.
+func syncHierarchyServer(t *testing.T, maxInFlight *int32) *httptest.Server {
+	t.Helper()
+	responses := map[string]string{
+		"/team":            `{"teams":[{"id":"t1","name":"Equipe"}]}`,
+		"/team/t1/space":   `{"spaces":[{"id":"s1","name":"Vendas"},{"id":"s2","name":"Suporte"},{"id":"s3","name":"Vazio"}]}`,
+		"/space/s1/folder": `{"folders":[{"id":"f1a","name":"2024"},{"id":"f1b","name":"2025"}]}`,
+		"/space/s2/folder": `{"folders":[{"id":"f2a","name":"Chamados"},{"id":"f2b","name":"Quebrado"}]}`,
+		"/space/s3/folder": `{"folders":[]}`,
+		"/space/s1/list":   `{"lists":[{"id":"l6","name":"Backlog"}]}`,
+		"/space/s2/list":   `{"lists":[]}`,
+		"/space/s3/list":   `{"lists":[{"id":"l7","name":"Avulsa"}]}`,
+		"/folder/f1a/list": `{"lists":[{"id":"l1","name":"Jan"},{"id":"l2","name":"Fev"}]}`,
+		"/folder/f1b/list": `{"lists":[{"id":"l3","name":"Mar"}]}`,
+		"/folder/f2a/list": `{"lists":[{"id":"l4","name":"Abertos"},{"id":"l5","name":"Fechados"}]}`,
+		"/list/l1/field":   `{"fields":[{"id":"cf1","name":"Valor","type":"currency","type_config":{"currency_type":"BRL","precision":2}}]}`,
+		"/list/l2/field":   `{"fields":[{"id":"cf1","name":"Valor","type":"currency","type_config":{"currency_type":"BRL","precision":2}},{"id":"cf2","name":"Etapa","type":"drop_down","type_config":{"options":[{"id":"o1","name":"Novo","orderindex":0},{"id":"o2","name":"Fechado","orderindex":1}]}}]}`,
+		"/list/l3/field":   `{"fields":[]}`,
+		"/list/l4/field":   `{"fields":[{"id":"cf3","name":"Prioridade","type":"labels","type_config":{"options":[{"id":"p1","label":"Alta"}]}}]}`,
+		"/list/l5/field":   `{"fields":[{"id":"cf3","name":"Prioridade","type":"labels","type_config":{"options":[{"id":"p1","label":"Alta"}]}},{"id":"cf4","name":"Prazo","type":"date"}]}`,
+		"/list/l6/field":   `{"fields":[{"id":"cf1","name":"Valor","type":"currency","type_config":{"currency_type":"BRL","precision":2}}]}`,
+		"/list/l7/field":   `{"fields":[{"id":"cf5","name":"Observação","type":"text"}]}`,
+	}
+
+	var inFlight int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+// runMetadataSync sincroniza a hierarquia simulada com o limite de concorrência informado
+func runMetadataSync(t *testing.T, concurrency int) (*fakeMetadataStore, SyncSummary, int32) {
+	t.Helper()
+	var maxInFlight int32
+	server := syncHierarchyServer(t, &maxInFlight)
+	defer server.Close()
+
+	source := client.NewClientWithOptions("pk_test_token", client.ClientOptions{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 1},
+	})
+	store := newFakeMetadataStore()
+	var summary SyncSummary
+	if err := newMetadataSync(source, store, concurrency, &summary).run(context.Background()); err != nil {
+		t.Fatalf("run (concorrência %d): %v", concurrency, err)
+	}
+	return store, summary, atomic.LoadInt32(&maxInFlight)
+}
+
+// TestMetadataSyncParallelMatchesSequential sincroniza a mesma hierarquia em sequência e em
+// paralelo e compara o resultado gravado
+func TestMetadataSyncParallelMatchesSequential(t *testing.T) {
+	sequential, seqSummary, seqInFlight := runMetadataSync(t, 1)
+	parallel, parSummary, parInFlight := runMetadataSync(t, 8)
+
+	if seqInFlight != 1 {
+		t.Errorf("sincronização sequencial fez %d requisições simultâneas", seqInFlight)
+	}
+	if parInFlight < 2 || parInFlight > 8 {
+		t.Errorf("sincronização paralela fez %d requisições simultâneas, esperado entre 2 e 8", parInFlight)
+	}
+
+	if seqSummary != parSummary {
+		t.Errorf("resumos diferentes: sequencial %+v, paralelo %+v", seqSummary, parSummary)
+	}
+	want := SyncSummary{Workspaces: 1, Spaces: 3, Folders: 4, Lists: 7, Fields: 5}
+	if parSummary != want {
+		t.Errorf("resumo = %+v, esperado %+v", parSummary, want)
+	}
+
+	checks := []struct {
+		name     string
+		seq, par interface{}
+	}{
+		{"workspaces", sequential.workspaces, parallel.workspaces},
+		{"spaces", sequential.spaces, parallel.spaces},
+		{"folders", sequential.folders, parallel.folders},
+		{"lists", sequential.lists, parallel.lists},
+		{"fields", sequential.fields, parallel.fields},
+		{"listFields", sequential.listFields, parallel.listFields},
+		{"snapshots", sequential.snapshots, parallel.snapshots},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.seq, c.par) {
+			t.Errorf("%s diferentes:\nsequencial %+v\nparalelo   %+v", c.name, c.seq, c.par)
+		}
+	}
+
+	if got := parallel.listFields["l2"]; !reflect.DeepEqual(got, []string{"cf1", "cf2"}) {
+		t.Errorf("campos da lista l2 = %v", got)
+	}
+	if field := parallel.fields["cf1"]; field.Options["currency_type"] != "BRL" || field.WorkspaceID != "t1" {
+		t.Errorf("campo cf1 = %+v", field)
+	}
+}
+
+// TestMetadataSyncIsolatesFailures garante que a falha de um folder não impede as demais
+// subárvores e marca o snapshot como incompleto para a limpeza
+func TestMetadataSyncIsolatesFailures(t *testing.T) {
+	store, _, _ := runMetadataSync(t, 4)
+
+	if _, ok := store.folders["f2b"]; !ok {
+		t.Error("folder f2b deveria ser salvo mesmo com a listagem de listas falhando")
+	}
+	for _, id := range []string{"l1", "l2", "l3", "l4", "l5", "l6", "l7"} {
+		if _, ok := store.lists[id]; !ok {
+			t.Errorf("lista %s não sincronizada", id)
+		}
+	}
+
+	if len(store.snapshots) != 1 {
+		t.Fatalf("PruneWorkspace chamado %d vezes, esperado 1", len(store.snapshots))
+	}
+	snapshot := store.snapshots[0]
+	if snapshot.FieldsComplete {
+		t.Error("snapshot deveria ficar com campos incompletos após a falha")
+	}
+	if _, ok := snapshot.Lists["f2b"]; ok {
+		t.Error("listas do folder que falhou não devem entrar no snapshot")
+	}
+	if !reflect.DeepEqual(snapshot.Lists["f2a"], []string{"l4", "l5"}) {
+		t.Errorf("listas do folder f2a no snapshot = %v", snapshot.Lists["f2a"])
+	}
+	if !reflect.DeepEqual(snapshot.Folders["s3"], []string{}) {
+		t.Errorf("space vazio deveria constar com listagem completa, obtido %v", snapshot.Folders["s3"])
+	}
+}
+
+// TestMetadataSyncFolderlessLists garante que listas direto no space são gravadas sem folder,
+// com seus campos, e entram no snapshot do space
+func TestMetadataSyncFolderlessLists(t *testing.T) {
+	store, _, _ := runMetadataSync(t, 4)
+
+	for id, spaceID := range map[string]string{"l6": "s1", "l7": "s3"} {
+		list, ok := store.lists[id]
+		if !ok {
+			t.Errorf("lista sem folder %s não sincronizada", id)
+			continue
+		}
+		if list.FolderID != "" || list.SpaceID != spaceID {
+			t.Errorf("lista %s gravada com folder %q e space %q", id, list.FolderID, list.SpaceID)
+		}
+	}
+	if list := store.lists["l1"]; list.FolderID != "f1a" || list.SpaceID != "s1" {
+		t.Errorf("lista em folder gravada como %+v", list)
+	}
+	if got := store.listFields["l7"]; !reflect.DeepEqual(got, []string{"cf5"}) {
+		t.Errorf("campos da lista l7 = %v", got)
+	}
+
+	snapshot := store.snapshots[0]
+	want := map[string][]string{"s1": {"l6"}, "s2": {}, "s3": {"l7"}}
+	if !reflect.DeepEqual(snapshot.SpaceLists, want) {
+		t.Errorf("listas sem folder no snapshot = %v, esperado %v", snapshot.SpaceLists, want)
+	}
+}
+
+// TestMetadataSyncFetchHonorsContext garante que a espera por uma vaga termina
+// quando o contexto é cancelado
+func TestMetadataSyncFetchHonorsContext(t *testing.T) {
+	m := newMetadataSync(nil, nil, 1, &SyncSummary{})
+	m.sem <- struct{}{} // ocupa a única vaga
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.fetch(ctx, func() error {
+			t.Error("chamada executada sem vaga")
+			return nil
+		})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("fetch = %v, esperado context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fetch continuou bloqueado após o cancelamento")
+	}
+}
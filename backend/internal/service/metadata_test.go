@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -269,7 +271,7 @@ func TestConfigurationPersistenceAndValidation(t *testing.T) {
 			}
 			
 			// Verifica se token pode ser recuperado
-			retrievedToken, err := service.GetUserToken(ctx, userID)
+			retrievedToken, err := service.GetUserToken(ctx, userID, "")
 			if err != nil {
 				return false
 			}
@@ -462,4 +464,220 @@ func genOptionsMap() gopter.Gen {
 		}
 		return result
 	})
-}
\ No newline at end of file
+}
+// TestOptionResolution verifies option name -> option ID resolution for drop_down and labels fields
+func TestOptionResolution(t *testing.T) {
+	optionMap := buildOptionMap(map[string]interface{}{
+		"options": []interface{}{
+			map[string]interface{}{"id": "uuid-high", "name": "High"},
+			map[string]interface{}{"id": "uuid-low", "name": "Low"},
+			map[string]interface{}{"id": "uuid-bug", "label": "Bug"},
+			map[string]interface{}{"id": "uuid-feat", "name": "Feature Request"},
+			map[string]interface{}{"name": "sem id"},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		fieldType string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{"dropdown exact name", "drop_down", "High", "uuid-high", false},
+		{"dropdown case insensitive", "drop_down", "  hIGH ", "uuid-high", false},
+		{"dropdown option id passes through", "drop_down", "uuid-low", "uuid-low", false},
+		{"dropdown name with spaces", "drop_down", "feature request", "uuid-feat", false},
+		{"dropdown mismatch", "drop_down", "Urgent", "", true},
+		{"single label", "labels", "bug", "uuid-bug", false},
+		{"multiple labels", "labels", "Bug, high ,LOW", "uuid-bug,uuid-high,uuid-low", false},
+		{"labels ignore empty items", "labels", "Bug,,", "uuid-bug", false},
+		{"labels with one mismatch", "labels", "Bug, Urgent", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOptionNames(optionMap, tt.fieldType, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveOptionNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrOptionNotFound) {
+					t.Errorf("expected ErrOptionNotFound, got %v", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resolveOptionNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := optionMap["sem id"]; ok {
+		t.Error("options without id should be ignored")
+	}
+}
+
+func TestNormalizeTokenLabel(t *testing.T) {
+	tests := []struct {
+		label   string
+		want    string
+		wantErr bool
+	}{
+		{"", DefaultTokenLabel, false},
+		{"   ", DefaultTokenLabel, false},
+		{"default", DefaultTokenLabel, false},
+		{"Default", DefaultTokenLabel, false},
+		{" Cliente X ", "Cliente X", false},
+		{"agência_2024-b", "agência_2024-b", false},
+		{"cliente/x", "", true},
+		{"../etc", "", true},
+		{strings.Repeat("a", 51), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTokenLabel(tt.label)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeTokenLabel(%q) error = %v, wantErr %v", tt.label, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr && !errors.Is(err, ErrInvalidTokenLabel) {
+			t.Errorf("NormalizeTokenLabel(%q) error = %v, want ErrInvalidTokenLabel", tt.label, err)
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeTokenLabel(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+// TestUserTokensEncryptedPerLabel stores several tokens for a user and checks they are
+// encrypted at rest and returned by label
+func TestUserTokensEncryptedPerLabel(t *testing.T) {
+	db := setupTestDB(t)
+	configRepo := repository.NewConfigRepository(db)
+	ctx := context.Background()
+	service := NewMetadataService(repository.NewMetadataRepository(db), configRepo, "test-encryption-key-32-bytes-long")
+
+	tokens := map[string]string{
+		DefaultTokenLabel: "pk_default_token",
+		"Cliente A":       "pk_cliente_a_token",
+		"Cliente B":       "pk_cliente_b_token",
+	}
+	for label, token := range tokens {
+		if err := service.storeToken("ana", label, token); err != nil {
+			t.Fatalf("storeToken(%q): %v", label, err)
+		}
+	}
+
+	for label, token := range tokens {
+		got, err := service.GetUserToken(ctx, "ana", label)
+		if err != nil {
+			t.Fatalf("GetUserToken(%q): %v", label, err)
+		}
+		if got != token {
+			t.Errorf("GetUserToken(%q) = %q, esperado %q", label, got, token)
+		}
+	}
+
+	// Rótulo vazio é o token padrão (caminho de token único)
+	if got, _ := service.GetUserToken(ctx, "ana", ""); got != tokens[DefaultTokenLabel] {
+		t.Errorf("rótulo vazio retornou %q", got)
+	}
+
+	// Nada é salvo em texto puro
+	stored, err := configRepo.GetUserTokenByLabel("ana", "Cliente A")
+	if err != nil || stored == nil {
+		t.Fatalf("GetUserTokenByLabel: %v %v", stored, err)
+	}
+	if stored.TokenEncrypted == tokens["Cliente A"] || strings.Contains(stored.TokenEncrypted, "pk_") {
+		t.Errorf("token salvo sem criptografia: %q", stored.TokenEncrypted)
+	}
+	config, err := configRepo.GetUserConfig("ana")
+	if err != nil || config == nil || config.ClickUpTokenEncrypted == tokens[DefaultTokenLabel] {
+		t.Errorf("token padrão salvo sem criptografia: %+v %v", config, err)
+	}
+
+	summaries, err := service.ListClickUpTokens(ctx, "ana")
+	if err != nil {
+		t.Fatalf("ListClickUpTokens: %v", err)
+	}
+	var labels []string
+	for _, s := range summaries {
+		labels = append(labels, s.Label)
+	}
+	if strings.Join(labels, ",") != "default,Cliente A,Cliente B" {
+		t.Errorf("rótulos = %v", labels)
+	}
+}
+
+// TestUserTokensOwnership ensures a user can't read or delete another user's tokens
+func TestUserTokensOwnership(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	service := NewMetadataService(repository.NewMetadataRepository(db), repository.NewConfigRepository(db), "test-encryption-key-32-bytes-long")
+
+	if err := service.storeToken("ana", "Cliente A", "pk_ana_token"); err != nil {
+		t.Fatalf("storeToken: %v", err)
+	}
+
+	if _, err := service.GetUserToken(ctx, "bruno", "Cliente A"); !errors.Is(err, ErrTokenNotConfigured) {
+		t.Errorf("outro usuário leu o token: err = %v", err)
+	}
+	if err := service.DeleteClickUpToken(ctx, "bruno", "Cliente A"); !errors.Is(err, ErrTokenNotConfigured) {
+		t.Errorf("outro usuário removeu o token: err = %v", err)
+	}
+	if summaries, _ := service.ListClickUpTokens(ctx, "bruno"); len(summaries) != 0 {
+		t.Errorf("outro usuário listou tokens: %v", summaries)
+	}
+
+	if got, err := service.GetUserToken(ctx, "ana", "Cliente A"); err != nil || got != "pk_ana_token" {
+		t.Errorf("token do dono foi afetado: %q %v", got, err)
+	}
+	if err := service.DeleteClickUpToken(ctx, "ana", "Cliente A"); err != nil {
+		t.Fatalf("DeleteClickUpToken: %v", err)
+	}
+	if _, err := service.GetUserToken(ctx, "ana", "Cliente A"); !errors.Is(err, ErrTokenNotConfigured) {
+		t.Errorf("token removido ainda encontrado: err = %v", err)
+	}
+}
+
+// TestReencryptTokensRotatesKey ensures saved tokens stay readable after the encryption key changes
+func TestReencryptTokensRotatesKey(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	configRepo := repository.NewConfigRepository(db)
+	metadataRepo := repository.NewMetadataRepository(db)
+	oldService := NewMetadataService(metadataRepo, configRepo, oldTestKey)
+
+	if err := oldService.storeToken("ana", DefaultTokenLabel, "pk_default_token"); err != nil {
+		t.Fatalf("storeToken: %v", err)
+	}
+	if err := oldService.storeToken("ana", "Cliente A", "pk_cliente_a_token"); err != nil {
+		t.Fatalf("storeToken: %v", err)
+	}
+
+	// Durante a troca, a chave antiga continua aceita para decifrar
+	rollout := NewMetadataService(metadataRepo, configRepo, newTestKey)
+	rollout.SetPreviousEncryptionKeys([]string{oldTestKey})
+	if got, err := rollout.GetUserToken(ctx, "ana", "Cliente A"); err != nil || got != "pk_cliente_a_token" {
+		t.Fatalf("token com chave anterior = %q, %v", got, err)
+	}
+
+	if err := rollout.ReencryptTokens(oldTestKey, newTestKey); err != nil {
+		t.Fatalf("ReencryptTokens: %v", err)
+	}
+	// Pode ser executado de novo sem efeito
+	if err := rollout.ReencryptTokens(oldTestKey, newTestKey); err != nil {
+		t.Fatalf("segunda execução de ReencryptTokens: %v", err)
+	}
+
+	newService := NewMetadataService(metadataRepo, configRepo, newTestKey)
+	for label, want := range map[string]string{DefaultTokenLabel: "pk_default_token", "Cliente A": "pk_cliente_a_token"} {
+		if got, err := newService.GetUserToken(ctx, "ana", label); err != nil || got != want {
+			t.Errorf("GetUserToken(%q) após troca = %q, %v", label, got, err)
+		}
+	}
+	if _, err := oldService.GetUserToken(ctx, "ana", "Cliente A"); err == nil {
+		t.Error("a chave antiga não deveria mais decifrar os tokens")
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ODS (OpenDocument Spreadsheet) files are zip archives whose content.xml holds every
+// sheet as table:table > table:table-row > table:table-cell. They are parsed with the
+// standard library so LibreOffice exports work without an extra dependency.
+
+const (
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+
+	// odsMaxColumns and odsMaxRows bound number-*-repeated expansion, since
+	// LibreOffice pads sheets with huge repeated ranges (same limits as XLSX)
+	odsMaxColumns = 16384
+	odsMaxRows    = 1048576
+)
+
+// odsSheet is a parsed ODS sheet
+type odsSheet struct {
+	Name string
+	Rows [][]string
+}
+
+// readODSSheets returns all sheets of an .ods archive with their rows.
+// Trailing empty rows and cells are dropped, matching excelize's GetRows.
+func readODSSheets(zr *zip.Reader) ([]odsSheet, error) {
+	for _, f := range zr.File {
+		if f.Name != "content.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir arquivo ODS: %w", err)
+		}
+		defer rc.Close()
+		return parseODSContent(rc)
+	}
+
+	return nil, ErrInvalidFile
+}
+
+// readODSFirstSheet returns the rows of the first sheet of an .ods file
+func (s *UploadService) readODSFirstSheet(filePath string) ([][]string, error) {
+	zr, closeFn, err := s.openODS(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo ODS: %w", err)
+	}
+	defer closeFn()
+
+	sheets, err := readODSSheets(zr)
+	if err != nil {
+		return nil, err
+	}
+	if len(sheets) == 0 {
+		return nil, ErrEmptyFile
+	}
+	return sheets[0].Rows, nil
+}
+
+// parseODSContent streams content.xml and builds the sheets
+func parseODSContent(r io.Reader) ([]odsSheet, error) {
+	dec := xml.NewDecoder(r)
+
+	var (
+		sheets []odsSheet
+		sheet  *odsSheet
+
+		row          []string
+		rowRepeat    int
+		pendingRows  int
+		pendingCells int
+
+		cell       strings.Builder
+		cellRepeat int
+		inCell     bool
+		paragraphs int
+		textDepth  int
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsTableNS && t.Name.Local == "table":
+				sheet = &odsSheet{Name: odsAttr(t, odsTableNS, "name")}
+				pendingRows = 0
+			case t.Name.Space == odsTableNS && t.Name.Local == "table-row" && sheet != nil:
+				row = nil
+				rowRepeat = odsRepeat(t, "number-rows-repeated")
+				pendingCells = 0
+			case t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell") && sheet != nil:
+				inCell = true
+				cell.Reset()
+				cellRepeat = odsRepeat(t, "number-columns-repeated")
+				paragraphs = 0
+				textDepth = 0
+			case t.Name.Space == odsOfficeNS && t.Name.Local == "annotation":
+				// Cell comments also contain text:p; they are not cell content
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+				}
+			case inCell && t.Name.Space == odsTextNS:
+				switch t.Name.Local {
+				case "p", "h":
+					if textDepth == 0 && paragraphs > 0 {
+						cell.WriteByte('\n')
+					}
+					paragraphs++
+					textDepth++
+				case "s":
+					n := 1
+					if c := odsAttr(t, odsTextNS, "c"); c != "" {
+						if v, err := strconv.Atoi(c); err == nil && v > 0 {
+							n = v
+						}
+					}
+					cell.WriteString(strings.Repeat(" ", n))
+				case "tab":
+					cell.WriteByte('\t')
+				case "line-break":
+					cell.WriteByte('\n')
+				}
+			}
+
+		case xml.EndElement:
+			switch {
+			case inCell && t.Name.Space == odsTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				textDepth--
+			case inCell && t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				inCell = false
+				value := cell.String()
+				if value == "" {
+					pendingCells += cellRepeat
+					break
+				}
+				for i := 0; i < pendingCells && len(row) < odsMaxColumns; i++ {
+					row = append(row, "")
+				}
+				pendingCells = 0
+				for i := 0; i < cellRepeat && len(row) < odsMaxColumns; i++ {
+					row = append(row, value)
+				}
+			case sheet != nil && t.Name.Space == odsTableNS && t.Name.Local == "table-row":
+				if len(row) == 0 {
+					pendingRows += rowRepeat
+					break
+				}
+				for i := 0; i < pendingRows && len(sheet.Rows) < odsMaxRows; i++ {
+					sheet.Rows = append(sheet.Rows, nil)
+				}
+				pendingRows = 0
+				// Repeated rows share the same backing slice; callers copy via normalizeRow
+				for i := 0; i < rowRepeat && len(sheet.Rows) < odsMaxRows; i++ {
+					sheet.Rows = append(sheet.Rows, row)
+				}
+			case sheet != nil && t.Name.Space == odsTableNS && t.Name.Local == "table":
+				sheets = append(sheets, *sheet)
+				sheet = nil
+			}
+
+		case xml.CharData:
+			if inCell && textDepth > 0 {
+				cell.Write(t)
+			}
+		}
+	}
+
+	return sheets, nil
+}
+
+// odsAttr returns the value of a namespaced attribute
+func odsAttr(el xml.StartElement, space, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// odsRepeat parses a table:number-*-repeated attribute, defaulting to 1
+func odsRepeat(el xml.StartElement, local string) int {
+	v, err := strconv.Atoi(odsAttr(el, odsTableNS, local))
+	if err != nil || v < 1 {
+		return 1
+	}
+	return v
+}
+
+// processODS processes an ODS file and extracts columns, preview and column statistics
+func (s *UploadService) processODS(filePath string, opts UploadOptions) (*previewBuilder, error) {
+	rows, err := s.readODSFirstSheet(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, dataRows, err := splitHeader(rows, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := newPreviewBuilder(columns, opts.previewRowCount())
+	for _, row := range dataRows {
+		preview.add(row)
+	}
+
+	return preview, nil
+}
+
+// readAllODS reads all data from an ODS file
+func (s *UploadService) readAllODS(filePath string, opts UploadOptions) ([]string, [][]string, error) {
+	rows, err := s.readODSFirstSheet(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, rows, err := splitHeader(rows, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rest is data
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, normalizeRow(row, len(columns)))
+	}
+
+	return columns, data, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/prop"
+)
+
+// createODSFile writes a minimal .ods archive with one sheet per entry in sheets
+func createODSFile(tempDir string, sheets map[string][][]string, order []string) (string, error) {
+	file, err := os.CreateTemp(tempDir, "test_ods_*.ods")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return "", err
+	}
+	mimetype.Write([]byte("application/vnd.oasis.opendocument.spreadsheet"))
+
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+		`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">` +
+		`<office:body><office:spreadsheet>`)
+	for _, name := range order {
+		content.WriteString(`<table:table table:name="` + xmlEscape(name) + `">`)
+		for _, row := range sheets[name] {
+			content.WriteString(`<table:table-row>`)
+			for _, value := range row {
+				content.WriteString(`<table:table-cell office:value-type="string"><text:p>` + xmlEscape(value) + `</text:p></table:table-cell>`)
+			}
+			content.WriteString(`</table:table-row>`)
+		}
+		// LibreOffice pads sheets with a huge repeated empty range
+		content.WriteString(`<table:table-row table:number-rows-repeated="1048000"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>`)
+		content.WriteString(`</table:table>`)
+	}
+	content.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+
+	w, err := zw.Create("content.xml")
+	if err != nil {
+		return "", err
+	}
+	w.Write([]byte(content.String()))
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// **Feature: clickup-field-updater, Property 4: File processing consistency (ODS)**
+func TestODSFileProcessingConsistency(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "upload_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	uploadService := NewUploadService(tempDir, 0)
+
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 100
+	properties := gopter.NewProperties(parameters)
+
+	properties.Property("ODS file processing extracts all columns and generates correct preview", prop.ForAll(
+		func(testData FileTestData) bool {
+			if len(testData.Columns) == 0 {
+				return true
+			}
+
+			rows := append([][]string{testData.Columns}, testData.Rows...)
+			odsPath, err := createODSFile(tempDir, map[string][][]string{"Planilha1": rows}, []string{"Planilha1"})
+			if err != nil {
+				t.Logf("Error creating ODS: %v", err)
+				return false
+			}
+			defer os.Remove(odsPath)
+
+			file, err := os.Open(odsPath)
+			if err != nil {
+				t.Logf("Error opening ODS: %v", err)
+				return false
+			}
+
+			stat, _ := file.Stat()
+
+			result, err := uploadService.ProcessFile("test.ods", file, stat.Size())
+			file.Close()
+
+			if err != nil {
+				t.Logf("Error processing ODS: %v", err)
+				return false
+			}
+			defer uploadService.RemoveTempFile(result.TempPath)
+
+			if len(result.Columns) != len(testData.Columns) {
+				t.Logf("Column count mismatch: expected %d, got %d", len(testData.Columns), len(result.Columns))
+				return false
+			}
+
+			for i, col := range testData.Columns {
+				if result.Columns[i] != col {
+					t.Logf("Column mismatch at %d: expected %q, got %q", i, col, result.Columns[i])
+					return false
+				}
+			}
+
+			expectedPreviewRows := len(testData.Rows)
+			if expectedPreviewRows > PreviewRows {
+				expectedPreviewRows = PreviewRows
+			}
+
+			if len(result.Preview) != expectedPreviewRows {
+				t.Logf("Preview row count mismatch: expected %d, got %d", expectedPreviewRows, len(result.Preview))
+				return false
+			}
+
+			if result.TotalRows != len(testData.Rows) {
+				t.Logf("Total rows mismatch: expected %d, got %d", len(testData.Rows), result.TotalRows)
+				return false
+			}
+
+			columns, data, err := uploadService.GetFileData(result.TempPath)
+			if err != nil {
+				t.Logf("Error getting file data: %v", err)
+				return false
+			}
+			if len(columns) != len(testData.Columns) || len(data) != len(testData.Rows) {
+				t.Logf("Retrieved shape mismatch: %d cols, %d rows", len(columns), len(data))
+				return false
+			}
+			for i, row := range data {
+				for j, cell := range row {
+					if cell != testData.Rows[i][j] {
+						t.Logf("Data mismatch at [%d][%d]: expected %q, got %q", i, j, testData.Rows[i][j], cell)
+						return false
+					}
+				}
+			}
+
+			return true
+		},
+		genFileTestData(),
+	))
+
+	properties.TestingRun(t, gopter.ConsoleReporter(false))
+}
+
+func TestParseODSContent(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+  xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+  xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+ <office:body>
+  <office:spreadsheet>
+   <table:table table:name="Dados">
+    <table:table-row>
+     <table:table-cell><text:p>id task</text:p></table:table-cell>
+     <table:table-cell table:number-columns-repeated="2"/>
+     <table:table-cell><text:p>Valor</text:p></table:table-cell>
+    </table:table-row>
+    <table:table-row table:number-rows-repeated="2">
+     <table:table-cell><text:p>abc</text:p></table:table-cell>
+     <table:table-cell table:number-columns-repeated="2"><text:p>x</text:p></table:table-cell>
+     <table:table-cell office:value-type="float" office:value="10.5"><text:p>10,5</text:p></table:table-cell>
+    </table:table-row>
+    <table:table-row table:number-rows-repeated="3"><table:table-cell/></table:table-row>
+    <table:table-row>
+     <table:table-cell>
+      <office:annotation><text:p>comentário</text:p></office:annotation>
+      <text:p>a<text:s text:c="2"/>b</text:p><text:p><text:span>linha 2</text:span></text:p>
+     </table:table-cell>
+    </table:table-row>
+    <table:table-row table:number-rows-repeated="1048000"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+   </table:table>
+  </office:spreadsheet>
+ </office:body>
+</office:document-content>`
+
+	sheets, err := parseODSContent(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseODSContent: %v", err)
+	}
+	if len(sheets) != 1 || sheets[0].Name != "Dados" {
+		t.Fatalf("sheets = %+v", sheets)
+	}
+
+	rows := sheets[0].Rows
+	if len(rows) != 7 {
+		t.Fatalf("esperado 7 linhas (sem o preenchimento final), obtido %d", len(rows))
+	}
+
+	want := [][]string{
+		{"id task", "", "", "Valor"},
+		{"abc", "x", "x", "10,5"},
+		{"abc", "x", "x", "10,5"},
+		nil, nil, nil,
+		{"a  b\nlinha 2"},
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") || len(rows[i]) != len(want[i]) {
+			t.Errorf("linha %d = %q, esperado %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestReadODSInvalidArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	_, err := uploadService.ProcessFile("broken.ods", strings.NewReader("not a zip"), 9)
+	if err == nil {
+		t.Fatal("esperado erro para ODS inválido")
+	}
+}
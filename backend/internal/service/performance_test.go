@@ -18,7 +18,7 @@ func TestMemoryUsageDuringLargeFileProcessing(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Force GC before measuring baseline
 	runtime.GC()
@@ -79,7 +79,7 @@ func TestMemoryUsageWithMultipleFiles(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Process multiple files sequentially
 	numFiles := 5
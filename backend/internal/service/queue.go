@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"github.com/cleberrangel/clickup-excel-api/internal/websocket"
 )
@@ -24,6 +26,17 @@ var (
 	ErrJobNotFound     = errors.New("job não encontrado")
 	ErrQueueFull       = errors.New("fila de processamento cheia")
 	ErrInvalidJobState = errors.New("estado do job inválido para esta operação")
+	ErrScheduleTooFar  = errors.New("agendamento muito distante: máximo de 90 dias")
+)
+
+// Queue timing
+const (
+	// MaxScheduleAhead is how far in the future a job may be scheduled
+	MaxScheduleAhead = 90 * 24 * time.Hour
+
+	// queuePollInterval is the fallback wake-up for jobs created by other instances;
+	// local job creation/completion and scheduled times wake the loop directly
+	queuePollInterval = 15 * time.Second
 )
 
 // QueueService manages job queue processing
@@ -41,6 +54,21 @@ type QueueService struct {
 	
 	// Cleanup interval
 	cleanupInterval time.Duration
+
+	// Readiness signals: running is set once the processor loop is live and
+	// heartbeat (unix nano) is refreshed on every loop iteration
+	running   int32
+	heartbeat int64
+
+	// scheduler limits parallel jobs and serializes jobs of the same user
+	scheduler *jobScheduler
+
+	// wakeCh wakes the processor loop early (new job, finished job)
+	wakeCh chan struct{}
+	clock  func() time.Time
+
+	// paused reports whether new jobs must wait (maintenance mode); nil = never
+	paused func() bool
 }
 
 // NewQueueService creates a new queue service
@@ -53,9 +81,33 @@ func NewQueueService(queueRepo *repository.QueueRepository, wsHub *websocket.Hub
 		processorCtx:    ctx,
 		processorCancel: cancel,
 		cleanupInterval: 1 * time.Hour,
+		scheduler:       newJobScheduler(DefaultMaxConcurrentJobs),
+		wakeCh:          make(chan struct{}, 1),
+		clock:           time.Now,
 	}
 }
 
+// SetClock replaces the clock used for scheduling decisions (tests)
+func (s *QueueService) SetClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// SetMaxConcurrentJobs sets how many jobs (from different users) may run in parallel
+func (s *QueueService) SetMaxConcurrentJobs(n int) {
+	s.scheduler.setMax(n)
+}
+
+// SetSchedulingStrategy sets the order in which pending jobs are started
+func (s *QueueService) SetSchedulingStrategy(strategy SchedulingStrategy) {
+	s.scheduler.setStrategy(strategy)
+}
+
+// SetPauseCheck makes the processor start no new jobs while paused returns true
+// (running jobs finish normally). Pending jobs start at the next poll after it returns false.
+func (s *QueueService) SetPauseCheck(paused func() bool) {
+	s.paused = paused
+}
+
 // SetJobProcessor sets the callback function for processing jobs
 func (s *QueueService) SetJobProcessor(processor func(ctx context.Context, job *repository.UpdateJob) error) {
 	s.jobProcessor = processor
@@ -88,9 +140,14 @@ func (s *QueueService) Stop() {
 
 
 // CreateJob creates a new job in the queue
-func (s *QueueService) CreateJob(userID, title, filePath string, mapping map[string]string, totalRows int) (*repository.UpdateJob, error) {
+// A non-nil scheduledAt defers processing until that time.
+func (s *QueueService) CreateJob(userID, title, filePath string, mapping map[string]string, options repository.JobOptions, totalRows, priority int, scheduledAt *time.Time) (*repository.UpdateJob, error) {
 	log := logger.Global()
 	
+	if err := s.ValidateSchedule(scheduledAt); err != nil {
+		return nil, err
+	}
+	
 	job := repository.UpdateJob{
 		UserID:        userID,
 		Title:         title,
@@ -102,6 +159,9 @@ func (s *QueueService) CreateJob(userID, title, filePath string, mapping map[str
 		SuccessCount:  0,
 		ErrorCount:    0,
 		ErrorDetails:  []string{},
+		Options:       options,
+		Priority:      priority,
+		ScheduledAt:   scheduledAt,
 	}
 	
 	createdJob, err := s.queueRepo.CreateJob(job)
@@ -115,21 +175,35 @@ func (s *QueueService) CreateJob(userID, title, filePath string, mapping map[str
 		Str("user_id", userID).
 		Str("title", title).
 		Int("total_rows", totalRows).
+		Str("priority", repository.JobPriorityName(priority)).
 		Msg("Job criado com sucesso")
 	
 	// Send WebSocket notification
 	if s.wsHub != nil {
+		message := "Job adicionado à fila"
+		if scheduledAt != nil && scheduledAt.After(s.clock()) {
+			message = "Job agendado para " + scheduledAt.Format(time.RFC3339)
+		}
 		s.wsHub.SendProgress(userID, websocket.ProgressUpdate{
 			JobID:     createdJob.ID,
 			Status:    JobStatusPending,
 			TotalRows: totalRows,
-			Message:   "Job adicionado à fila",
+			Message:   message,
 		})
 	}
 	
+	s.wake()
 	return createdJob, nil
 }
 
+// ValidateSchedule rejects run-at times beyond MaxScheduleAhead; past times run immediately
+func (s *QueueService) ValidateSchedule(scheduledAt *time.Time) error {
+	if scheduledAt != nil && scheduledAt.Sub(s.clock()) > MaxScheduleAhead {
+		return ErrScheduleTooFar
+	}
+	return nil
+}
+
 // GetJobByID retrieves a job by its ID
 func (s *QueueService) GetJobByID(jobID int) (*repository.UpdateJob, error) {
 	job, err := s.queueRepo.GetJobByID(jobID)
@@ -142,6 +216,53 @@ func (s *QueueService) GetJobByID(jobID int) (*repository.UpdateJob, error) {
 	return job, nil
 }
 
+// JobProgress describes a job's stored state as a progress update
+func JobProgress(job *repository.UpdateJob) websocket.ProgressUpdate {
+	progress := websocket.ProgressUpdate{
+		Type:          "progress",
+		JobID:         job.ID,
+		Status:        job.Status,
+		ProcessedRows: job.ProcessedRows,
+		TotalRows:     job.TotalRows,
+		SuccessCount:  job.SuccessCount,
+		ErrorCount:    job.ErrorCount,
+		Timestamp:     job.UpdatedAt,
+	}
+	if job.TotalRows > 0 {
+		progress.Progress = float64(job.ProcessedRows) / float64(job.TotalRows) * 100
+	}
+	return progress
+}
+
+// JobProgressSnapshot returns the stored progress of one of the user's jobs
+func (s *QueueService) JobProgressSnapshot(userID string, jobID int) (websocket.ProgressUpdate, bool) {
+	job, err := s.GetJobByID(jobID)
+	if err != nil {
+		if err != ErrJobNotFound {
+			logger.Global().Warn().Err(err).Int("job_id", jobID).Msg("Erro ao buscar job para snapshot de progresso")
+		}
+		return websocket.ProgressUpdate{}, false
+	}
+	if job.UserID != userID {
+		return websocket.ProgressUpdate{}, false
+	}
+	return JobProgress(job), true
+}
+
+// ActiveJobSnapshots returns the stored progress of the user's pending and running jobs
+func (s *QueueService) ActiveJobSnapshots(userID string) []websocket.ProgressUpdate {
+	jobs, err := s.queueRepo.GetActiveJobsByUser(userID)
+	if err != nil {
+		logger.Global().Warn().Err(err).Str("user_id", userID).Msg("Erro ao buscar jobs ativos para snapshot de progresso")
+		return nil
+	}
+	snapshots := make([]websocket.ProgressUpdate, 0, len(jobs))
+	for i := range jobs {
+		snapshots = append(snapshots, JobProgress(&jobs[i]))
+	}
+	return snapshots
+}
+
 // GetJobsByUser retrieves all jobs for a user
 func (s *QueueService) GetJobsByUser(userID string) ([]repository.UpdateJob, error) {
 	return s.queueRepo.GetJobsByUser(userID)
@@ -252,32 +373,101 @@ func (s *QueueService) FailJob(jobID int, errorMsg string) error {
 }
 
 
-// processJobsLoop is the background goroutine that processes jobs in FIFO order
+// processJobsLoop is the background goroutine that dispatches pending jobs to workers
 func (s *QueueService) processJobsLoop() {
 	defer s.processorWg.Done()
 	
 	log := logger.Global()
 	log.Info().Msg("Job processor iniciado")
 	
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	s.beat()
+	atomic.StoreInt32(&s.running, 1)
+	defer atomic.StoreInt32(&s.running, 0)
+	
+	timer := time.NewTimer(queuePollInterval)
+	defer timer.Stop()
 	
 	for {
 		select {
 		case <-s.processorCtx.Done():
 			log.Info().Msg("Job processor parando")
 			return
-		case <-ticker.C:
-			s.processNextJob()
+		case <-timer.C:
+		case <-s.wakeCh:
+			if !timer.Stop() {
+				<-timer.C
+			}
 		}
+		
+		s.beat()
+		s.dispatchJobs()
+		timer.Reset(s.nextWakeDelay())
+	}
+}
+
+// wake asks the processor loop to dispatch without waiting for the timer
+func (s *QueueService) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default: // já existe um wake pendente
+	}
+}
+
+// nextWakeDelay sleeps until the next scheduled job when it is due before the poll interval
+func (s *QueueService) nextWakeDelay() time.Duration {
+	next, err := s.queueRepo.GetNextScheduledTime()
+	if err != nil {
+		logger.Global().Warn().Err(err).Msg("Erro ao buscar próximo agendamento")
+		return queuePollInterval
 	}
+	return nextWakeDelay(s.clock(), next, queuePollInterval)
 }
 
-// processNextJob processes the next pending job in the queue
-func (s *QueueService) processNextJob() {
+// nextWakeDelay returns how long to sleep given the next scheduled time (nil = none)
+func nextWakeDelay(now time.Time, next *time.Time, poll time.Duration) time.Duration {
+	if next == nil {
+		return poll
+	}
+	delay := next.Sub(now)
+	if delay < 0 {
+		return 0
+	}
+	if delay < poll {
+		return delay
+	}
+	return poll
+}
+
+// beat records that the processor loop is alive
+func (s *QueueService) beat() {
+	atomic.StoreInt64(&s.heartbeat, time.Now().UnixNano())
+}
+
+// IsRunning reports whether the background job processor loop is live
+func (s *QueueService) IsRunning() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
+
+// LastHeartbeat returns when the processor loop last iterated (zero if never)
+func (s *QueueService) LastHeartbeat() time.Time {
+	nanos := atomic.LoadInt64(&s.heartbeat)
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// dispatchJobs starts every pending job allowed by the scheduler, in the order of its strategy.
+// Jobs run in their own goroutines so the loop keeps beating while they work.
+func (s *QueueService) dispatchJobs() {
 	log := logger.Global()
+
+	if s.paused != nil && s.paused() {
+		log.Debug().Msg("Processamento de jobs pausado (modo manutenção)")
+		return
+	}
 	
-	// Get pending jobs (FIFO order)
+	// Get pending jobs (priority order; the scheduler reorders them per strategy)
 	jobs, err := s.queueRepo.GetPendingJobs()
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao buscar jobs pendentes")
@@ -288,13 +478,26 @@ func (s *QueueService) processNextJob() {
 		return // No pending jobs
 	}
 	
-	// Process first job (FIFO)
-	job := jobs[0]
+	s.scheduler.dispatch(jobs, func(job repository.UpdateJob) {
+		s.processorWg.Add(1)
+		go func() {
+			defer s.processorWg.Done()
+			defer s.wake() // o próximo job do usuário pode começar
+			defer s.scheduler.release(job.UserID)
+			s.runJob(job)
+		}()
+	})
+}
+
+// runJob processes a single job, marking it processing, completed or failed
+func (s *QueueService) runJob(job repository.UpdateJob) {
+	log := logger.Global()
 	
 	log.Info().
 		Int("job_id", job.ID).
 		Str("user_id", job.UserID).
 		Str("title", job.Title).
+		Int("running", s.scheduler.runningCount()).
 		Msg("Processando job")
 	
 	// Update status to processing
@@ -303,6 +506,9 @@ func (s *QueueService) processNextJob() {
 		return
 	}
 	
+	metrics.Get().JobProcessingStarted()
+	defer metrics.Get().JobProcessingFinished()
+	
 	// Send WebSocket notification
 	if s.wsHub != nil {
 		s.wsHub.SendProgress(job.UserID, websocket.ProgressUpdate{
@@ -350,7 +556,8 @@ func (s *QueueService) cleanupLoop() {
 	}
 }
 
-// runCleanup performs cleanup of completed and old failed jobs
+// runCleanup removes completed jobs; old failed jobs are pruned by the
+// RetentionScheduler
 func (s *QueueService) runCleanup() {
 	log := logger.Global()
 	log.Info().Msg("Executando limpeza de jobs")
@@ -360,11 +567,6 @@ func (s *QueueService) runCleanup() {
 		log.Error().Err(err).Msg("Erro ao deletar jobs concluídos")
 	}
 	
-	// Delete old failed jobs (older than 24 hours)
-	if err := s.queueRepo.DeleteOldFailedJobs(); err != nil {
-		log.Error().Err(err).Msg("Erro ao deletar jobs antigos")
-	}
-	
 	log.Info().Msg("Limpeza de jobs concluída")
 }
 
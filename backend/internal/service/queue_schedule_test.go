@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWakeDelay(t *testing.T) {
+	now := time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)
+	poll := 15 * time.Second
+
+	soon := now.Add(3 * time.Second)
+	later := now.Add(time.Hour)
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		next *time.Time
+		want time.Duration
+	}{
+		{"no scheduled jobs", nil, poll},
+		{"due before poll", &soon, 3 * time.Second},
+		{"due after poll", &later, poll},
+		{"already due", &past, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextWakeDelay(now, tt.next, poll); got != tt.want {
+				t.Errorf("nextWakeDelay = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScheduleUsesClock(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := NewQueueService(nil, nil)
+	s.SetClock(func() time.Time { return now })
+
+	offHours := now.Add(10 * time.Hour)
+	if err := s.ValidateSchedule(&offHours); err != nil {
+		t.Errorf("agendamento em 10h rejeitado: %v", err)
+	}
+	if err := s.ValidateSchedule(nil); err != nil {
+		t.Errorf("sem agendamento rejeitado: %v", err)
+	}
+
+	tooFar := now.Add(MaxScheduleAhead + time.Hour)
+	if err := s.ValidateSchedule(&tooFar); err != ErrScheduleTooFar {
+		t.Errorf("esperado ErrScheduleTooFar, obtido %v", err)
+	}
+}
+
+func TestWakeDoesNotBlock(t *testing.T) {
+	s := NewQueueService(nil, nil)
+	for i := 0; i < 3; i++ {
+		s.wake() // canal com buffer 1: wakes extras são descartados
+	}
+	select {
+	case <-s.wakeCh:
+	default:
+		t.Fatal("esperado wake pendente")
+	}
+}
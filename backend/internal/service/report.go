@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/client"
 	"github.com/cleberrangel/clickup-excel-api/internal/logger"
@@ -10,10 +12,49 @@ import (
 	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 )
 
+var (
+	ErrEmptyListIDs = errors.New("list_ids não pode estar vazio")
+	ErrUnknownLists = errors.New("listas não encontradas")
+)
+
 // ReportService orquestra a geração de relatórios
 type ReportService struct {
 	clickupClient  *client.Client
 	excelGenerator *ExcelGenerator
+	lists          ListLookup
+	progress       ReportProgressNotifier
+	progressUserID string
+	reportID       string
+	defaults       ReportDefaults
+}
+
+// ReportDefaults são os padrões do usuário para as opções que a requisição omitiu
+// (nil = padrão do sistema, false)
+type ReportDefaults struct {
+	Subtasks      *bool
+	IncludeClosed *bool
+}
+
+// SetUserDefaults aplica os padrões do usuário quando a requisição não informa
+// subtasks ou include_closed; valores explícitos da requisição prevalecem
+func (s *ReportService) SetUserDefaults(defaults ReportDefaults) {
+	s.defaults = defaults
+}
+
+// resolveReportFlag escolhe o valor de uma opção: requisição > padrão do usuário > false
+func resolveReportFlag(requested, userDefault *bool) bool {
+	if requested != nil {
+		return *requested
+	}
+	if userDefault != nil {
+		return *userDefault
+	}
+	return false
+}
+
+// ReportProgressNotifier recebe o andamento da coleta de tasks (implementado por websocket.Hub)
+type ReportProgressNotifier interface {
+	SendReportProgress(userID, reportID string, listsProcessed, totalLists, tasksCollected int)
 }
 
 // NewReportService cria um novo serviço de relatórios
@@ -24,18 +65,75 @@ func NewReportService(clickupClient *client.Client) *ReportService {
 	}
 }
 
+// ListLookup consulta as listas conhecidas nos metadados sincronizados (implementado por MetadataService)
+type ListLookup interface {
+	KnownListIDs(listIDs []string) (map[string]bool, error)
+}
+
+// SetListLookup valida as listas pelos metadados antes de consultar o ClickUp
+func (s *ReportService) SetListLookup(lists ListLookup) {
+	s.lists = lists
+}
+
+// SetProgressNotifier envia ao usuário o andamento da coleta a cada lista processada.
+// reportID identifica o relatório nas mensagens (para cancelá-lo; pode ser vazio).
+func (s *ReportService) SetProgressNotifier(notifier ReportProgressNotifier, userID, reportID string) {
+	s.progress = notifier
+	s.progressUserID = userID
+	s.reportID = reportID
+}
+
+// reportFetchAttempts número de vezes que a coleta é retomada a partir do cursor
+// antes de seguir com os dados parciais
+const reportFetchAttempts = 3
+
 // ReportResult contém o resultado da geração do relatório
 type ReportResult struct {
 	FilePath   string
 	TotalTasks int
 	TotalLists int
 	FolderName string
+	// Lists traz o resultado da coleta de cada lista, na ordem pedida
+	Lists []ListFetchResult
+	// Partial indica que alguma lista falhou no meio: o arquivo tem os dados coletados
+	// até a falha e Warnings descreve o que faltou
+	Partial  bool
+	Warnings []string
+}
+
+// ListFetchResult resume a coleta de uma lista
+type ListFetchResult struct {
+	ListID   string `json:"list_id"`
+	Tasks    int    `json:"tasks"`    // tasks coletadas
+	Complete bool   `json:"complete"` // todas as páginas foram coletadas
+	Pages    int    `json:"pages"`    // páginas coletadas
+}
+
+// FailedLists retorna os IDs das listas coletadas parcialmente
+func (r *ReportResult) FailedLists() []string {
+	var failed []string
+	for _, list := range r.Lists {
+		if !list.Complete {
+			failed = append(failed, list.ListID)
+		}
+	}
+	return failed
 }
 
 // GenerateReport gera um relatório Excel a partir das listas e campos solicitados
 // Usa streaming para baixo consumo de memória
 func (s *ReportService) GenerateReport(ctx context.Context, req model.ReportRequest) (*ReportResult, error) {
 	log := logger.Get(ctx)
+
+	// Falha antes da coleta se alguma lista não existe
+	req.ListIDs = normalizeListIDs(req.ListIDs)
+	if len(req.ListIDs) == 0 {
+		return nil, ErrEmptyListIDs
+	}
+	if err := s.validateListIDs(ctx, req.ListIDs); err != nil {
+		return nil, err
+	}
+
 	log.Info().
 		Int("lists", len(req.ListIDs)).
 		Int("fields", len(req.Fields)).
@@ -49,37 +147,50 @@ func (s *ReportService) GenerateReport(ctx context.Context, req model.ReportRequ
 	defer storage.Close() // Cleanup automático
 
 	// 2. Coleta tasks e salva no storage (não acumula em memória)
-	// Default: false (apenas main tasks, sem subtasks)
-	subtasks := false
-	if req.Subtasks != nil {
-		subtasks = *req.Subtasks
-	}
+	// Default: padrão do usuário ou false (apenas main tasks, sem subtasks)
+	subtasks := resolveReportFlag(req.Subtasks, s.defaults.Subtasks)
 
-	// Default: false (apenas tasks abertas)
-	includeClosed := false
-	if req.IncludeClosed != nil {
-		includeClosed = *req.IncludeClosed
+	// Default: padrão do usuário ou false (apenas tasks abertas)
+	includeClosed := resolveReportFlag(req.IncludeClosed, s.defaults.IncludeClosed)
+
+	// Default: false (sem requisições extras por task)
+	includeActivity := false
+	if req.IncludeActivity != nil {
+		includeActivity = *req.IncludeActivity
 	}
 
 	log.Info().
 		Bool("subtasks", subtasks).
 		Bool("include_closed", includeClosed).
+		Bool("include_activity", includeActivity).
 		Msg("Fase 1: Coletando tasks do ClickUp")
-	if err := s.clickupClient.GetTasksToStorage(ctx, req.ListIDs, storage, subtasks, includeClosed); err != nil {
+	if err := s.collectTasks(ctx, req.ListIDs, storage, subtasks, includeClosed, includeActivity, req.Filters); err != nil {
 		return nil, fmt.Errorf("coletar tasks: %w", err)
 	}
 
 	totalTasks := storage.GetTaskCount()
 	folderName := storage.GetFolderName()
+	lists, warnings := listFetchResults(req.ListIDs, storage)
 
 	log.Info().
 		Int("tasks", totalTasks).
 		Str("folder", folderName).
+		Int("incomplete_lists", len(warnings)).
 		Msg("Fase 1 concluída: tasks coletadas")
 
-	// 3. Gera Excel via streaming do storage
-	log.Info().Msg("Fase 2: Gerando Excel via streaming")
-	excelPath, err := s.excelGenerator.GenerateFromStorage(storage, req.Fields)
+	// Relatório cancelado durante a coleta parcial: não gera o Excel
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3. Gera Excel via streaming do storage (resumo agrupado ou uma linha por task)
+	log.Info().Bool("aggregate", req.Aggregate != nil).Msg("Fase 2: Gerando Excel via streaming")
+	var excelPath string
+	if req.Aggregate != nil {
+		excelPath, err = s.excelGenerator.GenerateAggregateFromStorage(storage, *req.Aggregate)
+	} else {
+		excelPath, err = s.excelGenerator.GenerateFromStorage(storage, req.Fields)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("gerar excel: %w", err)
 	}
@@ -91,5 +202,106 @@ func (s *ReportService) GenerateReport(ctx context.Context, req model.ReportRequ
 		TotalTasks: totalTasks,
 		TotalLists: len(req.ListIDs),
 		FolderName: folderName,
+		Lists:      lists,
+		Partial:    len(warnings) > 0,
+		Warnings:   warnings,
 	}, nil
 }
+
+// listFetchResults monta o resultado de cada lista a partir do cursor do storage,
+// com um aviso para cada lista que não foi coletada por completo
+func listFetchResults(listIDs []string, storage *repository.TaskStorage) ([]ListFetchResult, []string) {
+	lists := make([]ListFetchResult, 0, len(listIDs))
+	var warnings []string
+	for _, id := range listIDs {
+		pages, complete := storage.ResumePoint(id)
+		list := ListFetchResult{
+			ListID:   id,
+			Tasks:    storage.ListTaskCount(id),
+			Complete: complete,
+			Pages:    pages,
+		}
+		lists = append(lists, list)
+		if !complete {
+			warnings = append(warnings, fmt.Sprintf("lista %s: coleta incompleta, %d tasks coletadas antes do erro na página %d", id, list.Tasks, list.Pages))
+		}
+	}
+	return lists, warnings
+}
+
+// normalizeListIDs remove espaços, IDs vazios e duplicados, mantendo a ordem
+func normalizeListIDs(listIDs []string) []string {
+	seen := make(map[string]bool, len(listIDs))
+	normalized := make([]string, 0, len(listIDs))
+	for _, id := range listIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+	return normalized
+}
+
+// validateListIDs confere se as listas existem: primeiro nos metadados sincronizados
+// e, para as que não estão lá (metadados desatualizados ou não sincronizados), no
+// ClickUp. Retorna ErrUnknownLists com os IDs inexistentes.
+func (s *ReportService) validateListIDs(ctx context.Context, listIDs []string) error {
+	known := map[string]bool{}
+	if s.lists != nil {
+		found, err := s.lists.KnownListIDs(listIDs)
+		if err != nil {
+			logger.Get(ctx).Warn().Err(err).Msg("Erro ao consultar listas nos metadados, validando no ClickUp")
+		} else {
+			known = found
+		}
+	}
+
+	var unknown []string
+	for _, id := range listIDs {
+		if known[id] {
+			continue
+		}
+		if _, err := s.clickupClient.GetList(ctx, id); err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				unknown = append(unknown, id)
+				continue
+			}
+			return fmt.Errorf("validar lista %s: %w", id, err)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownLists, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// collectTasks coleta as tasks no storage, retomando listas incompletas a partir da
+// última página gravada. Após reportFetchAttempts, segue com os dados já coletados.
+func (s *ReportService) collectTasks(ctx context.Context, listIDs []string, storage *repository.TaskStorage, subtasks, includeClosed, includeActivity bool, filters *model.TaskFilters) error {
+	log := logger.Get(ctx)
+
+	var onList client.ListProgressFunc
+	if s.progress != nil {
+		onList = func(listsDone, totalLists, tasksCollected int) {
+			s.progress.SendReportProgress(s.progressUserID, s.reportID, listsDone, totalLists, tasksCollected)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.clickupClient.GetTasksToStorageWithProgress(ctx, listIDs, storage, subtasks, includeClosed, includeActivity, filters, onList)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, model.ErrIncompleteFetch) {
+			return err
+		}
+		if attempt >= reportFetchAttempts {
+			log.Warn().Err(err).Int("attempts", attempt).Msg("Coleta incompleta, seguindo com dados parciais")
+			return nil
+		}
+		log.Warn().Err(err).Int("attempt", attempt).Msg("Coleta incompleta, retomando a partir do cursor")
+	}
+}
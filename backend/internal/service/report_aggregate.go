@@ -0,0 +1,210 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/xuri/excelize/v2"
+)
+
+// emptyGroupLabel identifica as tasks sem valor no campo de agrupamento
+const emptyGroupLabel = "(vazio)"
+
+// aggregateGroup acumula as métricas de um grupo: a memória cresce com o número de
+// grupos, não com o número de tasks
+type aggregateGroup struct {
+	Key    string
+	Count  int
+	Sums   []float64 // por métrica
+	Values []int     // tasks com valor numérico, por métrica (divisor da média)
+}
+
+// Value retorna o resultado da métrica i do grupo
+func (g *aggregateGroup) Value(metric model.AggregateMetric, i int) interface{} {
+	switch metric.Op {
+	case model.AggregateSum:
+		return g.Sums[i]
+	case model.AggregateAvg:
+		if g.Values[i] == 0 {
+			return ""
+		}
+		return g.Sums[i] / float64(g.Values[i])
+	default:
+		return g.Count
+	}
+}
+
+// aggregateResult são os grupos ordenados pela chave e os cabeçalhos do resumo
+type aggregateResult struct {
+	Headers []string
+	Metrics []model.AggregateMetric
+	Groups  []*aggregateGroup
+	Tasks   int
+}
+
+// aggregateMetrics retorna as métricas pedidas, com contagem quando nenhuma foi informada
+func aggregateMetrics(spec model.ReportAggregate) []model.AggregateMetric {
+	if len(spec.Metrics) == 0 {
+		return []model.AggregateMetric{{Op: model.AggregateCount}}
+	}
+	return spec.Metrics
+}
+
+// aggregateTasks percorre o storage em streaming e acumula as métricas por grupo
+func (g *ExcelGenerator) aggregateTasks(storage *repository.TaskStorage, spec model.ReportAggregate) (*aggregateResult, error) {
+	iter, err := storage.NewIterator()
+	if err != nil {
+		return nil, fmt.Errorf("criar iterador: %w", err)
+	}
+	defer iter.Close()
+
+	metrics := aggregateMetrics(spec)
+	groups := make(map[string]*aggregateGroup)
+	result := &aggregateResult{Metrics: metrics}
+
+	for iter.Next() {
+		task := iter.Task()
+		result.Tasks++
+		if result.Tasks == 1 {
+			// Nomes dos campos personalizados vêm da primeira task
+			result.Headers = g.aggregateHeaders(spec.GroupBy, metrics, task)
+		}
+
+		key := strings.TrimSpace(g.extractor.ExtractValue(spec.GroupBy, task))
+		if key == "" {
+			key = emptyGroupLabel
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &aggregateGroup{
+				Key:    key,
+				Sums:   make([]float64, len(metrics)),
+				Values: make([]int, len(metrics)),
+			}
+			groups[key] = group
+		}
+
+		group.Count++
+		for i, metric := range metrics {
+			if metric.Op == model.AggregateCount {
+				continue
+			}
+			if value, ok := g.numericValue(metric.Field, task); ok {
+				group.Sums[i] += value
+				group.Values[i]++
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar tasks: %w", err)
+	}
+
+	result.Groups = make([]*aggregateGroup, 0, len(groups))
+	for _, group := range groups {
+		result.Groups = append(result.Groups, group)
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].Key < result.Groups[j].Key
+	})
+
+	if result.Tasks == 0 {
+		result.Headers = g.aggregateHeaders(spec.GroupBy, metrics, model.Task{})
+	}
+	return result, nil
+}
+
+// aggregateHeaders retorna os cabeçalhos do resumo: o campo de agrupamento e uma coluna por métrica
+func (g *ExcelGenerator) aggregateHeaders(groupBy string, metrics []model.AggregateMetric, task model.Task) []string {
+	headers := []string{g.extractor.ResolveHeader(groupBy, task)}
+	for _, metric := range metrics {
+		headers = append(headers, g.metricHeader(metric, task))
+	}
+	return headers
+}
+
+// metricHeader retorna o nome da coluna de uma métrica
+func (g *ExcelGenerator) metricHeader(metric model.AggregateMetric, task model.Task) string {
+	switch metric.Op {
+	case model.AggregateSum:
+		return "SOMA " + g.extractor.ResolveHeader(metric.Field, task)
+	case model.AggregateAvg:
+		return "MÉDIA " + g.extractor.ResolveHeader(metric.Field, task)
+	default:
+		return "QUANTIDADE"
+	}
+}
+
+// numericValue lê o valor numérico de um campo. Campos personalizados usam o valor
+// bruto do ClickUp (o extrator formata moeda como "R$ ..."); nativos, o valor extraído.
+func (g *ExcelGenerator) numericValue(fieldKey string, task model.Task) (float64, bool) {
+	if _, isNative := NativeFields[fieldKey]; isNative {
+		value, err := strconv.ParseFloat(g.extractor.ExtractNativeValue(fieldKey, task), 64)
+		return value, err == nil
+	}
+
+	for _, cf := range task.CustomFields {
+		if cf.ID != fieldKey {
+			continue
+		}
+		switch v := cf.Value.(type) {
+		case float64:
+			return v, true
+		case string:
+			value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return value, err == nil
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// GenerateAggregateFromStorage gera um Excel com uma linha por grupo, lendo as tasks
+// do storage via streaming
+func (g *ExcelGenerator) GenerateAggregateFromStorage(storage *repository.TaskStorage, spec model.ReportAggregate) (string, error) {
+	result, err := g.aggregateTasks(storage, spec)
+	if err != nil {
+		return "", err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	defaultSheet := f.GetSheetName(0)
+	if err := f.SetSheetName(defaultSheet, sheetName); err != nil {
+		return "", fmt.Errorf("renomear sheet: %w", err)
+	}
+
+	if err := g.writeHeaders(f, result.Headers); err != nil {
+		return "", fmt.Errorf("escrever headers: %w", err)
+	}
+
+	for i, group := range result.Groups {
+		values := make([]interface{}, 0, len(result.Metrics)+1)
+		values = append(values, group.Key)
+		for j, metric := range result.Metrics {
+			values = append(values, group.Value(metric, j))
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheetName, cell, &values); err != nil {
+			return "", fmt.Errorf("escrever grupo %s: %w", group.Key, err)
+		}
+	}
+
+	tmpFile, err := os.CreateTemp("", "report_*.xlsx")
+	if err != nil {
+		return "", fmt.Errorf("criar arquivo temp: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+
+	if err := f.SaveAs(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("salvar excel: %w", err)
+	}
+	return tmpPath, nil
+}
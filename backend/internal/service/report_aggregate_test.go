@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+	"github.com/xuri/excelize/v2"
+)
+
+// aggregateTask monta uma task com status e um campo personalizado de valor (currency)
+func aggregateTask(id, status string, value interface{}) model.Task {
+	task := model.Task{ID: id, Name: "Task " + id}
+	task.Status.Status = status
+	task.CustomFields = []model.CustomField{{ID: "cf-valor", Name: "Valor", Type: "currency", Value: value}}
+	return task
+}
+
+func newAggregateStorage(t *testing.T, tasks []model.Task) *repository.TaskStorage {
+	t.Helper()
+	storage, err := repository.NewTaskStorage()
+	if err != nil {
+		t.Fatalf("NewTaskStorage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	if err := storage.AppendTasks(tasks); err != nil {
+		t.Fatalf("AppendTasks: %v", err)
+	}
+	return storage
+}
+
+func TestAggregateTasksGroupTotals(t *testing.T) {
+	var tasks []model.Task
+	// 300 tasks "aberta" com valores 1..300, 200 "fechada" com valor "10" (string do ClickUp)
+	for i := 1; i <= 300; i++ {
+		tasks = append(tasks, aggregateTask(fmt.Sprintf("a%d", i), "aberta", float64(i)))
+	}
+	for i := 1; i <= 200; i++ {
+		tasks = append(tasks, aggregateTask(fmt.Sprintf("f%d", i), "fechada", "10"))
+	}
+	// Sem status e sem valor: grupo vazio, fora da soma e da média
+	tasks = append(tasks, aggregateTask("v1", "", nil), aggregateTask("v2", "", nil))
+
+	storage := newAggregateStorage(t, tasks)
+	result, err := NewExcelGenerator().aggregateTasks(storage, model.ReportAggregate{
+		GroupBy: "status",
+		Metrics: []model.AggregateMetric{
+			{Op: model.AggregateCount},
+			{Op: model.AggregateSum, Field: "cf-valor"},
+			{Op: model.AggregateAvg, Field: "cf-valor"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("aggregateTasks: %v", err)
+	}
+
+	if result.Tasks != 502 {
+		t.Errorf("Tasks = %d, esperava 502", result.Tasks)
+	}
+	expectedHeaders := []string{"STATUS", "QUANTIDADE", "SOMA Valor", "MÉDIA Valor"}
+	if fmt.Sprint(result.Headers) != fmt.Sprint(expectedHeaders) {
+		t.Errorf("Headers = %v, esperava %v", result.Headers, expectedHeaders)
+	}
+
+	expected := []struct {
+		key   string
+		count int
+		sum   float64
+		avg   interface{}
+	}{
+		{emptyGroupLabel, 2, 0, ""},
+		{"aberta", 300, 45150, 150.5},
+		{"fechada", 200, 2000, 10.0},
+	}
+	if len(result.Groups) != len(expected) {
+		t.Fatalf("esperava %d grupos, obteve %d", len(expected), len(result.Groups))
+	}
+	for i, want := range expected {
+		group := result.Groups[i]
+		if group.Key != want.key {
+			t.Errorf("grupo %d = %q, esperava %q", i, group.Key, want.key)
+			continue
+		}
+		if got := group.Value(result.Metrics[0], 0); got != want.count {
+			t.Errorf("%s: count = %v, esperava %d", want.key, got, want.count)
+		}
+		if got := group.Value(result.Metrics[1], 1).(float64); math.Abs(got-want.sum) > 1e-9 {
+			t.Errorf("%s: sum = %v, esperava %v", want.key, got, want.sum)
+		}
+		if got := group.Value(result.Metrics[2], 2); got != want.avg {
+			t.Errorf("%s: avg = %v, esperava %v", want.key, got, want.avg)
+		}
+	}
+}
+
+func TestGenerateAggregateFromStorageWritesOneRowPerGroup(t *testing.T) {
+	storage := newAggregateStorage(t, []model.Task{
+		aggregateTask("1", "aberta", 5.0),
+		aggregateTask("2", "fechada", 7.0),
+		aggregateTask("3", "aberta", 1.0),
+	})
+
+	path, err := NewExcelGenerator().GenerateAggregateFromStorage(storage, model.ReportAggregate{GroupBy: "status"})
+	if err != nil {
+		t.Fatalf("GenerateAggregateFromStorage: %v", err)
+	}
+	defer os.Remove(path)
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("abrir excel: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	expected := [][]string{
+		{"STATUS", "QUANTIDADE"},
+		{"aberta", "2"},
+		{"fechada", "1"},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("linhas = %v, esperava %v", rows, expected)
+	}
+}
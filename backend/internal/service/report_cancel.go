@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// ReportRegistry guarda o cancelamento dos relatórios web em andamento, para que o
+// usuário interrompa a coleta pelo endpoint ou pelo WebSocket
+type ReportRegistry struct {
+	mu      sync.Mutex
+	reports map[string]map[string]context.CancelFunc // user_id -> report_id -> cancel
+}
+
+// NewReportRegistry cria um registro vazio
+func NewReportRegistry() *ReportRegistry {
+	return &ReportRegistry{reports: make(map[string]map[string]context.CancelFunc)}
+}
+
+// Start registra um relatório do usuário e retorna o contexto cancelável, o id do
+// relatório e a função que o remove do registro (deve ser chamada ao terminar)
+func (r *ReportRegistry) Start(ctx context.Context, userID string) (context.Context, string, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	reportID := newReportID()
+
+	r.mu.Lock()
+	if r.reports[userID] == nil {
+		r.reports[userID] = make(map[string]context.CancelFunc)
+	}
+	r.reports[userID][reportID] = cancel
+	r.mu.Unlock()
+
+	done := func() {
+		r.mu.Lock()
+		delete(r.reports[userID], reportID)
+		if len(r.reports[userID]) == 0 {
+			delete(r.reports, userID)
+		}
+		r.mu.Unlock()
+		cancel()
+	}
+	return ctx, reportID, done
+}
+
+// CancelReports cancela o relatório reportID do usuário, ou todos os relatórios dele
+// quando reportID é vazio. Retorna quantos foram cancelados.
+func (r *ReportRegistry) CancelReports(userID, reportID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	canceled := 0
+	for id, cancel := range r.reports[userID] {
+		if reportID == "" || id == reportID {
+			cancel()
+			canceled++
+		}
+	}
+	return canceled
+}
+
+// newReportID gera um identificador aleatório para um relatório
+func newReportID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
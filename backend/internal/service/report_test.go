@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+// reportProgressEvent é uma chamada recebida por recordingReportProgress
+type reportProgressEvent struct {
+	userID, reportID                           string
+	listsProcessed, totalLists, tasksCollected int
+}
+
+type recordingReportProgress struct {
+	mu     sync.Mutex
+	events []reportProgressEvent
+}
+
+func (r *recordingReportProgress) SendReportProgress(userID, reportID string, listsProcessed, totalLists, tasksCollected int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, reportProgressEvent{userID, reportID, listsProcessed, totalLists, tasksCollected})
+}
+
+func TestGenerateReportSendsProgressPerList(t *testing.T) {
+	// Cada lista responde com uma página de duas tasks
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listID := strings.Split(strings.TrimPrefix(r.URL.Path, "/list/"), "/")[0]
+		json.NewEncoder(w).Encode(model.TaskResponse{
+			Tasks: []model.Task{
+				{ID: listID + "-1", Name: "Task 1"},
+				{ID: listID + "-2", Name: "Task 2"},
+			},
+			LastPage: true,
+		})
+	}))
+	defer server.Close()
+
+	notifier := &recordingReportProgress{}
+	svc := NewReportService(client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL}))
+	svc.SetProgressNotifier(notifier, "user-1", "rep-1")
+
+	result, err := svc.GenerateReport(context.Background(), model.ReportRequest{
+		ListIDs: []string{"L1", "L2", "L3"},
+		Fields:  []string{"name"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateReport: %v", err)
+	}
+	defer os.Remove(result.FilePath)
+
+	expected := []reportProgressEvent{
+		{"user-1", "rep-1", 1, 3, 2},
+		{"user-1", "rep-1", 2, 3, 4},
+		{"user-1", "rep-1", 3, 3, 6},
+	}
+	if len(notifier.events) != len(expected) {
+		t.Fatalf("esperava %d eventos de progresso, recebeu %v", len(expected), notifier.events)
+	}
+	for i, event := range notifier.events {
+		if event != expected[i] {
+			t.Errorf("evento %d = %+v, esperava %+v", i, event, expected[i])
+		}
+	}
+}
+
+func TestReportRegistryCancel(t *testing.T) {
+	registry := NewReportRegistry()
+
+	ctx1, id1, done1 := registry.Start(context.Background(), "user-1")
+	defer done1()
+	ctx2, _, done2 := registry.Start(context.Background(), "user-1")
+	defer done2()
+	other, _, doneOther := registry.Start(context.Background(), "user-2")
+	defer doneOther()
+
+	if n := registry.CancelReports("user-1", id1); n != 1 || ctx1.Err() == nil || ctx2.Err() != nil {
+		t.Fatalf("cancelar por id: %d cancelados, ctx1=%v ctx2=%v", n, ctx1.Err(), ctx2.Err())
+	}
+	if n := registry.CancelReports("user-1", ""); n != 2 || ctx2.Err() == nil {
+		t.Fatalf("cancelar todos: %d cancelados, ctx2=%v", n, ctx2.Err())
+	}
+	if other.Err() != nil {
+		t.Error("relatório de outro usuário não deveria ser cancelado")
+	}
+
+	done1()
+	done2()
+	if n := registry.CancelReports("user-1", ""); n != 0 {
+		t.Errorf("relatórios concluídos ainda registrados: %d", n)
+	}
+}
+
+// listServer simula o ClickUp: GET /list/{id} responde 404 para listas fora de existing
+// e GET /list/{id}/task devolve uma task, contando as requisições de cada tipo
+type listServer struct {
+	mu         sync.Mutex
+	existing   map[string]bool
+	listChecks []string
+	taskPages  map[string]int
+}
+
+func (s *listServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/list/"), "/")
+	listID := parts[0]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(parts) == 1 {
+		s.listChecks = append(s.listChecks, listID)
+		if !s.existing[listID] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(model.List{ID: listID, Name: "Lista " + listID})
+		return
+	}
+
+	s.taskPages[listID]++
+	json.NewEncoder(w).Encode(model.TaskResponse{
+		Tasks:    []model.Task{{ID: listID + "-1", Name: "Task"}},
+		LastPage: true,
+	})
+}
+
+type staticListLookup map[string]bool
+
+func (l staticListLookup) KnownListIDs(listIDs []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	for _, id := range listIDs {
+		if l[id] {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+func TestGenerateReportDeduplicatesListIDs(t *testing.T) {
+	mock := &listServer{existing: map[string]bool{"L1": true, "L2": true}, taskPages: map[string]int{}}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	svc := NewReportService(client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL}))
+	result, err := svc.GenerateReport(context.Background(), model.ReportRequest{
+		ListIDs: []string{"L1", " L1", "L2", "", "L1"},
+		Fields:  []string{"name"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateReport: %v", err)
+	}
+	defer os.Remove(result.FilePath)
+
+	if result.TotalLists != 2 || result.TotalTasks != 2 {
+		t.Errorf("esperava 2 listas e 2 tasks, obteve %d listas e %d tasks", result.TotalLists, result.TotalTasks)
+	}
+	if mock.taskPages["L1"] != 1 || mock.taskPages["L2"] != 1 {
+		t.Errorf("cada lista deveria ser buscada uma vez: %v", mock.taskPages)
+	}
+	if len(mock.listChecks) != 2 {
+		t.Errorf("cada lista deveria ser validada uma vez: %v", mock.listChecks)
+	}
+}
+
+func TestGenerateReportRejectsUnknownListIDs(t *testing.T) {
+	mock := &listServer{existing: map[string]bool{"L1": true}, taskPages: map[string]int{}}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	svc := NewReportService(client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL}))
+	// L1 está nos metadados e não é consultada no ClickUp
+	svc.SetListLookup(staticListLookup{"L1": true})
+
+	_, err := svc.GenerateReport(context.Background(), model.ReportRequest{
+		ListIDs: []string{"L1", "L404", "L405"},
+		Fields:  []string{"name"},
+	})
+	if !errors.Is(err, ErrUnknownLists) {
+		t.Fatalf("esperava ErrUnknownLists, obteve %v", err)
+	}
+	if !strings.Contains(err.Error(), "L404, L405") {
+		t.Errorf("o erro deveria listar os IDs desconhecidos: %v", err)
+	}
+	if len(mock.taskPages) != 0 {
+		t.Errorf("nenhuma task deveria ser buscada: %v", mock.taskPages)
+	}
+	if strings.Join(mock.listChecks, ",") != "L404,L405" {
+		t.Errorf("listas validadas no ClickUp: %v", mock.listChecks)
+	}
+
+	if _, err := svc.GenerateReport(context.Background(), model.ReportRequest{ListIDs: []string{" ", ""}, Fields: []string{"name"}}); !errors.Is(err, ErrEmptyListIDs) {
+		t.Errorf("IDs vazios: esperava ErrEmptyListIDs, obteve %v", err)
+	}
+}
+
+func TestGenerateReportFlagPrecedence(t *testing.T) {
+	var mu sync.Mutex
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		query = r.URL.RawQuery
+		mu.Unlock()
+		json.NewEncoder(w).Encode(model.TaskResponse{Tasks: []model.Task{{ID: "T1", Name: "Task 1"}}, LastPage: true})
+	}))
+	defer server.Close()
+
+	yes, no := true, false
+	tests := []struct {
+		name          string
+		defaults      ReportDefaults
+		subtasks      *bool
+		includeClosed *bool
+		want          string
+	}{
+		{"padrão do sistema", ReportDefaults{}, nil, nil, "subtasks=false&include_closed=false"},
+		{"padrão do usuário", ReportDefaults{Subtasks: &yes, IncludeClosed: &yes}, nil, nil, "subtasks=true&include_closed=true"},
+		{"requisição prevalece", ReportDefaults{Subtasks: &yes, IncludeClosed: &yes}, &no, &no, "subtasks=false&include_closed=false"},
+		{"mistura", ReportDefaults{IncludeClosed: &yes}, &yes, nil, "subtasks=true&include_closed=true"},
+		{"false explícito do usuário", ReportDefaults{Subtasks: &no}, nil, &yes, "subtasks=false&include_closed=true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewReportService(client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL}))
+			svc.SetListLookup(staticListLookup{"L1": true})
+			svc.SetUserDefaults(tt.defaults)
+
+			result, err := svc.GenerateReport(context.Background(), model.ReportRequest{
+				ListIDs:       []string{"L1"},
+				Fields:        []string{"name"},
+				Subtasks:      tt.subtasks,
+				IncludeClosed: tt.includeClosed,
+			})
+			if err != nil {
+				t.Fatalf("GenerateReport: %v", err)
+			}
+			defer os.Remove(result.FilePath)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !strings.Contains(query, tt.want) {
+				t.Errorf("query = %q, esperava %q", query, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// Retention defaults, matching the limits used before they became configurable
+const (
+	DefaultRetentionInterval = 1 * time.Hour
+	DefaultHistoryMaxPerUser = 1000
+	DefaultFailedJobMaxAge   = 24 * time.Hour
+)
+
+// RetentionPolicy sets how often the pruning runs and what it keeps
+type RetentionPolicy struct {
+	Interval          time.Duration // time between runs (default DefaultRetentionInterval)
+	HistoryMaxPerUser int           // most recent operation history rows kept per user (default DefaultHistoryMaxPerUser)
+	FailedJobMaxAge   time.Duration // failed jobs older than this are removed (default DefaultFailedJobMaxAge)
+}
+
+// withDefaults fills unset fields with the defaults
+func (p RetentionPolicy) withDefaults() RetentionPolicy {
+	if p.Interval <= 0 {
+		p.Interval = DefaultRetentionInterval
+	}
+	if p.HistoryMaxPerUser <= 0 {
+		p.HistoryMaxPerUser = DefaultHistoryMaxPerUser
+	}
+	if p.FailedJobMaxAge <= 0 {
+		p.FailedJobMaxAge = DefaultFailedJobMaxAge
+	}
+	return p
+}
+
+// RetentionResult counts the rows removed by one run
+type RetentionResult struct {
+	HistoryRows int64
+	FailedJobs  int64
+}
+
+// retentionStore is the subset of QueueRepository used by the scheduler
+type retentionStore interface {
+	CleanupHistoryPerUser(keepPerUser int) (int64, error)
+	DeleteOldFailedJobs(maxAge time.Duration) (int64, error)
+}
+
+// RetentionScheduler periodically prunes old operation history and failed jobs
+type RetentionScheduler struct {
+	store  retentionStore
+	policy RetentionPolicy
+
+	// newTicker returns the tick channel and its stop function (replaced in tests)
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetentionScheduler creates a scheduler for the queue repository; zero fields in
+// policy use the defaults
+func NewRetentionScheduler(queueRepo *repository.QueueRepository, policy RetentionPolicy) *RetentionScheduler {
+	return newRetentionScheduler(queueRepo, policy)
+}
+
+func newRetentionScheduler(store retentionStore, policy RetentionPolicy) *RetentionScheduler {
+	return &RetentionScheduler{
+		store:  store,
+		policy: policy.withDefaults(),
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(d)
+			return ticker.C, ticker.Stop
+		},
+	}
+}
+
+// Policy returns the policy in use
+func (s *RetentionScheduler) Policy() RetentionPolicy {
+	return s.policy
+}
+
+// Start runs the pruning once and then on every interval until Stop
+func (s *RetentionScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	ticks, stopTicker := s.newTicker(s.policy.Interval)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer stopTicker()
+
+		s.RunOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				s.RunOnce()
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop and waits for a run in progress
+func (s *RetentionScheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RunOnce prunes history and failed jobs according to the policy. A failure in one
+// step is logged and does not skip the other.
+func (s *RetentionScheduler) RunOnce() RetentionResult {
+	log := logger.Global()
+	var result RetentionResult
+
+	history, err := s.store.CleanupHistoryPerUser(s.policy.HistoryMaxPerUser)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao limpar histórico antigo")
+	} else {
+		result.HistoryRows = history
+	}
+
+	jobs, err := s.store.DeleteOldFailedJobs(s.policy.FailedJobMaxAge)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao deletar jobs antigos")
+	} else {
+		result.FailedJobs = jobs
+	}
+
+	metrics.Get().IncrementRetentionPruned(result.HistoryRows, result.FailedJobs)
+	log.Info().
+		Int64("history_rows", result.HistoryRows).
+		Int64("failed_jobs", result.FailedJobs).
+		Int("history_max_per_user", s.policy.HistoryMaxPerUser).
+		Dur("failed_job_max_age", s.policy.FailedJobMaxAge).
+		Msg("Limpeza de retenção concluída")
+	return result
+}
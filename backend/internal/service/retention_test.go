@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+)
+
+// retentionCall registra os limites recebidos em uma execução da limpeza
+type retentionCall struct {
+	keep   int
+	maxAge time.Duration
+}
+
+type fakeRetentionStore struct {
+	calls      chan retentionCall
+	historyErr error
+}
+
+func (f *fakeRetentionStore) CleanupHistoryPerUser(keep int) (int64, error) {
+	if f.historyErr != nil {
+		return 0, f.historyErr
+	}
+	f.calls <- retentionCall{keep: keep}
+	return 5, nil
+}
+
+func (f *fakeRetentionStore) DeleteOldFailedJobs(maxAge time.Duration) (int64, error) {
+	f.calls <- retentionCall{maxAge: maxAge}
+	return 2, nil
+}
+
+func TestRetentionSchedulerRunsOnEveryTick(t *testing.T) {
+	store := &fakeRetentionStore{calls: make(chan retentionCall, 10)}
+	policy := RetentionPolicy{Interval: 10 * time.Minute, HistoryMaxPerUser: 50, FailedJobMaxAge: 6 * time.Hour}
+	scheduler := newRetentionScheduler(store, policy)
+
+	ticks := make(chan time.Time)
+	var interval time.Duration
+	stopped := false
+	scheduler.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		interval = d
+		return ticks, func() { stopped = true }
+	}
+
+	before := metrics.Get().Snapshot().Retention
+	scheduler.Start()
+
+	expectRun := func(run string) {
+		t.Helper()
+		for _, want := range []retentionCall{{keep: 50}, {maxAge: 6 * time.Hour}} {
+			select {
+			case got := <-store.calls:
+				if got != want {
+					t.Errorf("%s: chamada %+v, esperado %+v", run, got, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: limpeza não executada", run)
+			}
+		}
+	}
+
+	// Uma execução ao iniciar e uma por tick
+	expectRun("início")
+	ticks <- time.Now()
+	expectRun("primeiro tick")
+	ticks <- time.Now()
+	expectRun("segundo tick")
+
+	scheduler.Stop()
+	if interval != 10*time.Minute {
+		t.Errorf("intervalo do ticker = %v", interval)
+	}
+	if !stopped {
+		t.Error("ticker não foi parado")
+	}
+
+	after := metrics.Get().Snapshot().Retention
+	if after.HistoryRowsPruned-before.HistoryRowsPruned != 15 || after.FailedJobsPruned-before.FailedJobsPruned != 6 {
+		t.Errorf("métricas de retenção: antes %+v, depois %+v", before, after)
+	}
+}
+
+func TestRetentionRunOnceContinuesAfterFailure(t *testing.T) {
+	store := &fakeRetentionStore{calls: make(chan retentionCall, 10), historyErr: errors.New("falha no banco")}
+	scheduler := newRetentionScheduler(store, RetentionPolicy{})
+
+	result := scheduler.RunOnce()
+	if result.HistoryRows != 0 || result.FailedJobs != 2 {
+		t.Errorf("resultado = %+v", result)
+	}
+	// Política vazia usa os padrões
+	if got := <-store.calls; got.maxAge != DefaultFailedJobMaxAge {
+		t.Errorf("idade máxima = %v, esperado %v", got.maxAge, DefaultFailedJobMaxAge)
+	}
+	if p := scheduler.Policy(); p.Interval != DefaultRetentionInterval || p.HistoryMaxPerUser != DefaultHistoryMaxPerUser {
+		t.Errorf("política padrão = %+v", p)
+	}
+}
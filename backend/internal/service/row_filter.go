@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// ErrInvalidRowFilter is returned for filters with unknown columns, operators or values
+var ErrInvalidRowFilter = errors.New("filtro de linhas inválido")
+
+// Row filter operators
+const (
+	FilterEquals      = "eq"
+	FilterNotEquals   = "ne"
+	FilterContains    = "contains"
+	FilterNotContains = "not_contains"
+	FilterGreater     = "gt"
+	FilterGreaterEq   = "gte"
+	FilterLess        = "lt"
+	FilterLessEq      = "lte"
+	FilterEmpty       = "empty"
+	FilterNotEmpty    = "not_empty"
+)
+
+// filterOperatorAliases maps the symbolic spelling of operators to their names
+var filterOperatorAliases = map[string]string{
+	"=":  FilterEquals,
+	"==": FilterEquals,
+	"!=": FilterNotEquals,
+	"<>": FilterNotEquals,
+	">":  FilterGreater,
+	">=": FilterGreaterEq,
+	"<":  FilterLess,
+	"<=": FilterLessEq,
+}
+
+// rowFilter is a RowFilter bound to the columns of a file
+type rowFilter struct {
+	any        bool
+	conditions []rowCondition
+	locale     string
+}
+
+// rowCondition is a condition with its column index and parsed value
+type rowCondition struct {
+	index    int
+	operator string
+	value    string  // lower-case, for text operators
+	number   float64 // for numeric operators
+}
+
+// ValidateRowFilter checks that a job filter only references columns of the file and
+// uses known operators with valid values (nil filters are valid)
+func ValidateRowFilter(filter *repository.RowFilter, columns []string, normalizeColumns bool, locale string) error {
+	_, err := compileRowFilter(filter, columns, normalizeColumns, locale)
+	return err
+}
+
+// compileRowFilter binds a filter to the file columns; nil means every row matches
+func compileRowFilter(filter *repository.RowFilter, columns []string, normalizeColumns bool, locale string) (*rowFilter, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	if len(filter.Conditions) == 0 {
+		return nil, fmt.Errorf("%w: nenhuma condição informada", ErrInvalidRowFilter)
+	}
+
+	compiled := &rowFilter{locale: locale}
+	switch strings.ToLower(strings.TrimSpace(filter.Combine)) {
+	case "", "and":
+	case "or":
+		compiled.any = true
+	default:
+		return nil, fmt.Errorf("%w: combine deve ser 'and' ou 'or'", ErrInvalidRowFilter)
+	}
+
+	matcher := newColumnMatcher(columns, normalizeColumns)
+	for _, cond := range filter.Conditions {
+		index, found := matcher.find(cond.Column)
+		if !found {
+			return nil, fmt.Errorf("%w: coluna '%s' não encontrada no arquivo", ErrInvalidRowFilter, cond.Column)
+		}
+
+		operator := strings.ToLower(strings.TrimSpace(cond.Operator))
+		if alias, ok := filterOperatorAliases[operator]; ok {
+			operator = alias
+		}
+		c := rowCondition{index: index, operator: operator}
+		switch operator {
+		case FilterEquals, FilterNotEquals, FilterContains, FilterNotContains:
+			c.value = strings.ToLower(strings.TrimSpace(cond.Value))
+		case FilterGreater, FilterGreaterEq, FilterLess, FilterLessEq:
+			number, ok := client.ParseNumber(cond.Value, locale)
+			if !ok {
+				return nil, fmt.Errorf("%w: valor '%s' da coluna '%s' não é numérico", ErrInvalidRowFilter, cond.Value, cond.Column)
+			}
+			c.number = number
+		case FilterEmpty, FilterNotEmpty:
+		default:
+			return nil, fmt.Errorf("%w: operador '%s' desconhecido", ErrInvalidRowFilter, cond.Operator)
+		}
+		compiled.conditions = append(compiled.conditions, c)
+	}
+	return compiled, nil
+}
+
+// matches reports whether a row passes the filter (a nil filter passes every row)
+func (f *rowFilter) matches(row []string) bool {
+	if f == nil {
+		return true
+	}
+	for _, c := range f.conditions {
+		if c.matches(row, f.locale) == f.any {
+			return f.any
+		}
+	}
+	return !f.any
+}
+
+// matches evaluates the condition on a row; missing cells are empty and non-numeric
+// cells never match numeric comparisons
+func (c rowCondition) matches(row []string, locale string) bool {
+	cell := ""
+	if c.index < len(row) {
+		cell = strings.TrimSpace(row[c.index])
+	}
+
+	switch c.operator {
+	case FilterEquals:
+		return strings.ToLower(cell) == c.value
+	case FilterNotEquals:
+		return strings.ToLower(cell) != c.value
+	case FilterContains:
+		return strings.Contains(strings.ToLower(cell), c.value)
+	case FilterNotContains:
+		return !strings.Contains(strings.ToLower(cell), c.value)
+	case FilterEmpty:
+		return cell == ""
+	case FilterNotEmpty:
+		return cell != ""
+	}
+
+	number, ok := client.ParseNumber(cell, locale)
+	if !ok {
+		return false
+	}
+	switch c.operator {
+	case FilterGreater:
+		return number > c.number
+	case FilterGreaterEq:
+		return number >= c.number
+	case FilterLess:
+		return number < c.number
+	case FilterLessEq:
+		return number <= c.number
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+func TestRowFilterMatches(t *testing.T) {
+	columns := []string{"id task", "Status", "Cliente", "Valor"}
+
+	tests := []struct {
+		name   string
+		filter repository.RowFilter
+		locale string
+		row    []string
+		want   bool
+	}{
+		{
+			name:   "igualdade ignora maiúsculas e espaços",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Status", Operator: "eq", Value: "aberto"}}},
+			row:    []string{"T1", "  ABERTO ", "Acme", "10"},
+			want:   true,
+		},
+		{
+			name:   "igualdade não atendida",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Status", Operator: "=", Value: "aberto"}}},
+			row:    []string{"T1", "Fechado", "Acme", "10"},
+			want:   false,
+		},
+		{
+			name:   "contém",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Cliente", Operator: "contains", Value: "ltda"}}},
+			row:    []string{"T1", "Aberto", "Acme Ltda.", "10"},
+			want:   true,
+		},
+		{
+			name:   "não contém",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Cliente", Operator: "not_contains", Value: "ltda"}}},
+			row:    []string{"T1", "Aberto", "Acme Ltda.", "10"},
+			want:   false,
+		},
+		{
+			name:   "maior que com número pt-BR",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Valor", Operator: "gt", Value: "1.000"}}},
+			row:    []string{"T1", "Aberto", "Acme", "R$ 1.500,50"},
+			want:   true,
+		},
+		{
+			name:   "menor ou igual com número en-US",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Valor", Operator: "<=", Value: "1,000.5"}}},
+			locale: client.LocaleEnUS,
+			row:    []string{"T1", "Aberto", "Acme", "1,000.50"},
+			want:   true,
+		},
+		{
+			name:   "célula não numérica não atende comparação",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Valor", Operator: "lt", Value: "100"}}},
+			row:    []string{"T1", "Aberto", "Acme", "n/a"},
+			want:   false,
+		},
+		{
+			name:   "célula ausente é vazia",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{{Column: "Valor", Operator: "empty"}}},
+			row:    []string{"T1", "Aberto"},
+			want:   true,
+		},
+		{
+			name: "and exige todas as condições",
+			filter: repository.RowFilter{Conditions: []repository.RowCondition{
+				{Column: "Status", Operator: "eq", Value: "Aberto"},
+				{Column: "Valor", Operator: "gte", Value: "50"},
+			}},
+			row:  []string{"T1", "Aberto", "Acme", "10"},
+			want: false,
+		},
+		{
+			name: "or basta uma condição",
+			filter: repository.RowFilter{Combine: "OR", Conditions: []repository.RowCondition{
+				{Column: "Status", Operator: "eq", Value: "Aberto"},
+				{Column: "Valor", Operator: "gte", Value: "50"},
+			}},
+			row:  []string{"T1", "Aberto", "Acme", "10"},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := compileRowFilter(&tt.filter, columns, false, tt.locale)
+			if err != nil {
+				t.Fatalf("compileRowFilter: %v", err)
+			}
+			if got := filter.matches(tt.row); got != tt.want {
+				t.Errorf("matches(%v) = %v, esperado %v", tt.row, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRowFilter(t *testing.T) {
+	columns := []string{"id task", "Status", "Valor"}
+
+	if err := ValidateRowFilter(nil, columns, false, ""); err != nil {
+		t.Errorf("filtro nulo deveria ser válido: %v", err)
+	}
+
+	// Com normalização, a coluna é encontrada pelas variações do nome
+	normalized := &repository.RowFilter{Conditions: []repository.RowCondition{{Column: " status ", Operator: "ne", Value: "x"}}}
+	if err := ValidateRowFilter(normalized, columns, true, ""); err != nil {
+		t.Errorf("coluna normalizada deveria ser aceita: %v", err)
+	}
+
+	invalid := map[string]*repository.RowFilter{
+		"coluna inexistente":  {Conditions: []repository.RowCondition{{Column: "Prioridade", Operator: "eq", Value: "alta"}}},
+		"operador inválido":   {Conditions: []repository.RowCondition{{Column: "Status", Operator: "like", Value: "a"}}},
+		"valor não numérico":  {Conditions: []repository.RowCondition{{Column: "Valor", Operator: "gt", Value: "muito"}}},
+		"sem condições":       {},
+		"combinação inválida": {Combine: "xor", Conditions: []repository.RowCondition{{Column: "Status", Operator: "empty"}}},
+	}
+	for name, filter := range invalid {
+		if err := ValidateRowFilter(filter, columns, false, ""); !errors.Is(err, ErrInvalidRowFilter) {
+			t.Errorf("%s: esperado ErrInvalidRowFilter, obtido %v", name, err)
+		}
+	}
+}
+
+// TestProcessFileRowFilter só envia as linhas que atendem ao filtro; as demais contam
+// como processadas e filtradas, sem sucesso nem erro
+func TestProcessFileRowFilter(t *testing.T) {
+	var mu sync.Mutex
+	var written []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		written = append(written, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	csv := "id task,Status,Valor,Nota\nT1,Aberto,1.500,a\nT2,Fechado,2.000,b\nT3,aberto,10,c\nT4,Aberto,3.000,d\n"
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("filtro.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	job := &repository.UpdateJob{
+		ID:       9,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Nota": "f-nota"},
+		Options: repository.JobOptions{RowFilter: &repository.RowFilter{Conditions: []repository.RowCondition{
+			{Column: "Status", Operator: "eq", Value: "Aberto"},
+			{Column: "Valor", Operator: "gt", Value: "1000"},
+		}}},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-nota": "text"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+
+	if result.TotalRows != 4 || result.ProcessedRows != 4 || result.SuccessCount != 2 ||
+		result.ErrorCount != 0 || result.FilteredCount != 2 {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+	want := []string{"/task/T1/field/f-nota", "/task/T4/field/f-nota"}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Errorf("gravações = %v, esperado %v", written, want)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// DefaultMaxConcurrentJobs keeps the historical one-job-at-a-time behaviour
+const DefaultMaxConcurrentJobs = 1
+
+// SchedulingStrategy decides the order in which pending jobs are offered to the scheduler
+type SchedulingStrategy string
+
+const (
+	// SchedulingPriority follows the repository order: priority (with aging), then oldest first
+	SchedulingPriority SchedulingStrategy = "priority"
+	// SchedulingFIFO starts jobs strictly by creation time, ignoring priority
+	SchedulingFIFO SchedulingStrategy = "fifo"
+	// SchedulingFair takes the oldest job of each user in rotation, so one user's
+	// large backlog can't hold back everybody else
+	SchedulingFair SchedulingStrategy = "fair"
+)
+
+// ParseSchedulingStrategy converts a config value into a strategy (empty means priority)
+func ParseSchedulingStrategy(value string) (SchedulingStrategy, bool) {
+	switch SchedulingStrategy(value) {
+	case "", SchedulingPriority:
+		return SchedulingPriority, true
+	case SchedulingFIFO:
+		return SchedulingFIFO, true
+	case SchedulingFair:
+		return SchedulingFair, true
+	default:
+		return "", false
+	}
+}
+
+// jobScheduler decides which pending jobs may start, running up to max jobs in
+// parallel while keeping each user's jobs strictly sequential (FIFO per user),
+// since two jobs from the same user may update the same tasks.
+type jobScheduler struct {
+	mu          sync.Mutex
+	max         int
+	running     int
+	activeUsers map[string]bool
+	strategy    SchedulingStrategy
+	// lastServed orders users for fair scheduling: the user whose job started least
+	// recently (or never) goes first
+	lastServed map[string]uint64
+	turn       uint64
+}
+
+func newJobScheduler(max int) *jobScheduler {
+	if max < 1 {
+		max = DefaultMaxConcurrentJobs
+	}
+	return &jobScheduler{
+		max:         max,
+		activeUsers: make(map[string]bool),
+		strategy:    SchedulingPriority,
+		lastServed:  make(map[string]uint64),
+	}
+}
+
+// setMax changes the concurrency limit; running jobs are not affected
+func (s *jobScheduler) setMax(max int) {
+	if max < 1 {
+		max = DefaultMaxConcurrentJobs
+	}
+	s.mu.Lock()
+	s.max = max
+	s.mu.Unlock()
+}
+
+// setStrategy changes the order used by the next dispatch
+func (s *jobScheduler) setStrategy(strategy SchedulingStrategy) {
+	s.mu.Lock()
+	s.strategy = strategy
+	s.mu.Unlock()
+}
+
+// dispatch walks pending jobs in the order of the strategy and calls start for each
+// job that can run now. A user with a running job is skipped entirely, so their next
+// job only starts after the current one finishes.
+func (s *jobScheduler) dispatch(pending []repository.UpdateJob, start func(job repository.UpdateJob)) int {
+	started := 0
+	for _, job := range s.order(pending) {
+		if !s.tryAcquire(job.UserID) {
+			continue
+		}
+		start(job)
+		started++
+	}
+	return started
+}
+
+// order returns pending (in repository order) in the order the strategy starts them
+func (s *jobScheduler) order(pending []repository.UpdateJob) []repository.UpdateJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.strategy {
+	case SchedulingFIFO:
+		return oldestFirst(pending)
+	case SchedulingFair:
+		return s.fairOrder(pending)
+	default:
+		return pending
+	}
+}
+
+// fairOrder interleaves users round-robin, each one contributing its oldest remaining
+// job per round. Users are ranked by when one of their jobs last started, so the
+// rotation carries over between dispatches; ties go to the user with the oldest job.
+func (s *jobScheduler) fairOrder(pending []repository.UpdateJob) []repository.UpdateJob {
+	var users []string
+	byUser := make(map[string][]repository.UpdateJob)
+	for _, job := range oldestFirst(pending) {
+		if _, ok := byUser[job.UserID]; !ok {
+			users = append(users, job.UserID)
+		}
+		byUser[job.UserID] = append(byUser[job.UserID], job)
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return s.lastServed[users[i]] < s.lastServed[users[j]]
+	})
+
+	ordered := make([]repository.UpdateJob, 0, len(pending))
+	for round := 0; len(ordered) < len(pending); round++ {
+		for _, user := range users {
+			if round < len(byUser[user]) {
+				ordered = append(ordered, byUser[user][round])
+			}
+		}
+	}
+	return ordered
+}
+
+// oldestFirst returns a copy of jobs sorted by creation time
+func oldestFirst(jobs []repository.UpdateJob) []repository.UpdateJob {
+	sorted := append([]repository.UpdateJob(nil), jobs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// tryAcquire reserves a slot for the user if one is free and the user is idle
+func (s *jobScheduler) tryAcquire(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running >= s.max || s.activeUsers[userID] {
+		return false
+	}
+	s.running++
+	s.activeUsers[userID] = true
+	s.turn++
+	s.lastServed[userID] = s.turn
+	return true
+}
+
+// release frees the slot held by the user's running job
+func (s *jobScheduler) release(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeUsers[userID] {
+		delete(s.activeUsers, userID)
+		s.running--
+	}
+}
+
+// runningCount returns the number of jobs currently holding a slot
+func (s *jobScheduler) runningCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// simulatedQueue runs jobs through a jobScheduler the same way QueueService does,
+// recording per-user execution order and concurrency
+type simulatedQueue struct {
+	mu          sync.Mutex
+	pending     []repository.UpdateJob
+	claimed     map[int]bool
+	order       map[string][]int
+	sequence    []int
+	userActive  map[string]int
+	maxPerUser  int
+	active      int
+	maxParallel int
+}
+
+func newSimulatedQueue(jobs []repository.UpdateJob) *simulatedQueue {
+	return &simulatedQueue{
+		pending:    jobs,
+		claimed:    make(map[int]bool),
+		order:      make(map[string][]int),
+		userActive: make(map[string]int),
+	}
+}
+
+func (q *simulatedQueue) pendingJobs() []repository.UpdateJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []repository.UpdateJob
+	for _, job := range q.pending {
+		if !q.claimed[job.ID] {
+			out = append(out, job)
+		}
+	}
+	return out
+}
+
+func (q *simulatedQueue) run(job repository.UpdateJob) {
+	q.mu.Lock()
+	q.claimed[job.ID] = true
+	q.order[job.UserID] = append(q.order[job.UserID], job.ID)
+	q.sequence = append(q.sequence, job.ID)
+	q.active++
+	q.userActive[job.UserID]++
+	if q.active > q.maxParallel {
+		q.maxParallel = q.active
+	}
+	if q.userActive[job.UserID] > q.maxPerUser {
+		q.maxPerUser = q.userActive[job.UserID]
+	}
+	q.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	q.mu.Lock()
+	q.active--
+	q.userActive[job.UserID]--
+	q.mu.Unlock()
+}
+
+// drain dispatches until every job ran, like processJobsLoop ticking
+func (q *simulatedQueue) drain(t *testing.T, scheduler *jobScheduler) {
+	t.Helper()
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(5 * time.Second)
+
+	for len(q.pendingJobs()) > 0 || scheduler.runningCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fila não esvaziou a tempo")
+		}
+		scheduler.dispatch(q.pendingJobs(), func(job repository.UpdateJob) {
+			q.mu.Lock()
+			q.claimed[job.ID] = true
+			q.mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer scheduler.release(job.UserID)
+				q.run(job)
+			}()
+		})
+		time.Sleep(2 * time.Millisecond)
+	}
+	wg.Wait()
+}
+
+func TestSchedulerSerializesJobsPerUser(t *testing.T) {
+	var jobs []repository.UpdateJob
+	for i := 1; i <= 6; i++ {
+		jobs = append(jobs, repository.UpdateJob{ID: i, UserID: "user-a"})
+	}
+
+	q := newSimulatedQueue(jobs)
+	q.drain(t, newJobScheduler(4))
+
+	if q.maxPerUser != 1 {
+		t.Errorf("usuário teve %d jobs simultâneos, esperado 1", q.maxPerUser)
+	}
+	want := []int{1, 2, 3, 4, 5, 6}
+	for i, id := range q.order["user-a"] {
+		if id != want[i] {
+			t.Fatalf("ordem = %v, esperado %v", q.order["user-a"], want)
+		}
+	}
+}
+
+func TestSchedulerRunsDifferentUsersInParallel(t *testing.T) {
+	var jobs []repository.UpdateJob
+	users := []string{"user-a", "user-b", "user-c", "user-d", "user-e"}
+	id := 1
+	for round := 0; round < 3; round++ {
+		for _, u := range users {
+			jobs = append(jobs, repository.UpdateJob{ID: id, UserID: u})
+			id++
+		}
+	}
+
+	q := newSimulatedQueue(jobs)
+	q.drain(t, newJobScheduler(3))
+
+	if q.maxParallel < 2 {
+		t.Errorf("esperado paralelismo entre usuários, pico %d", q.maxParallel)
+	}
+	if q.maxParallel > 3 {
+		t.Errorf("limite de 3 jobs excedido: pico %d", q.maxParallel)
+	}
+	if q.maxPerUser != 1 {
+		t.Errorf("usuário teve %d jobs simultâneos, esperado 1", q.maxPerUser)
+	}
+	for _, u := range users {
+		order := q.order[u]
+		for i := 1; i < len(order); i++ {
+			if order[i] < order[i-1] {
+				t.Errorf("%s fora de ordem: %v", u, order)
+			}
+		}
+	}
+}
+
+func TestSchedulerDefaultIsSequential(t *testing.T) {
+	s := newJobScheduler(0)
+	if !s.tryAcquire("a") {
+		t.Fatal("primeiro job deveria iniciar")
+	}
+	if s.tryAcquire("b") {
+		t.Error("com o limite padrão apenas um job deveria rodar")
+	}
+	s.release("a")
+	if !s.tryAcquire("b") {
+		t.Error("slot liberado deveria permitir o próximo job")
+	}
+}
+
+// interleavedJobs: user-a enfileira um lote grande antes dos outros usuários
+func interleavedJobs() []repository.UpdateJob {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	users := []string{"user-a", "user-a", "user-a", "user-a", "user-b", "user-c", "user-b"}
+	var jobs []repository.UpdateJob
+	for i, u := range users {
+		jobs = append(jobs, repository.UpdateJob{ID: i + 1, UserID: u, CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	return jobs
+}
+
+func TestSchedulerFairRotatesBetweenUsers(t *testing.T) {
+	s := newJobScheduler(1)
+	s.setStrategy(SchedulingFair)
+	q := newSimulatedQueue(interleavedJobs())
+	q.drain(t, s)
+
+	// A fila fica em rodízio: o lote de user-a não atrasa user-b e user-c
+	want := []int{1, 5, 6, 2, 7, 3, 4}
+	if fmt.Sprint(q.sequence) != fmt.Sprint(want) {
+		t.Errorf("ordem = %v, esperado %v", q.sequence, want)
+	}
+}
+
+func TestSchedulerFIFOKeepsCreationOrder(t *testing.T) {
+	s := newJobScheduler(1)
+	s.setStrategy(SchedulingFIFO)
+	q := newSimulatedQueue(interleavedJobs())
+	q.drain(t, s)
+
+	want := []int{1, 2, 3, 4, 5, 6, 7}
+	if fmt.Sprint(q.sequence) != fmt.Sprint(want) {
+		t.Errorf("ordem = %v, esperado %v", q.sequence, want)
+	}
+}
+
+func TestSchedulerStrategyOrder(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	// Ordem do repositório: prioridade primeiro, então o job 3 vem antes dos mais antigos
+	pending := []repository.UpdateJob{
+		{ID: 3, UserID: "user-b", Priority: 2, CreatedAt: base.Add(2 * time.Minute)},
+		{ID: 1, UserID: "user-a", CreatedAt: base},
+		{ID: 2, UserID: "user-a", CreatedAt: base.Add(time.Minute)},
+	}
+
+	tests := []struct {
+		strategy SchedulingStrategy
+		want     []int
+	}{
+		{SchedulingPriority, []int{3, 1, 2}},
+		{SchedulingFIFO, []int{1, 2, 3}},
+		{SchedulingFair, []int{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		s := newJobScheduler(1)
+		s.setStrategy(tt.strategy)
+		var got []int
+		for _, job := range s.order(pending) {
+			got = append(got, job.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: ordem = %v, esperado %v", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedulingStrategy(t *testing.T) {
+	if s, ok := ParseSchedulingStrategy(""); !ok || s != SchedulingPriority {
+		t.Errorf("vazio = %q, %v; esperado priority", s, ok)
+	}
+	if s, ok := ParseSchedulingStrategy("fair"); !ok || s != SchedulingFair {
+		t.Errorf("fair = %q, %v", s, ok)
+	}
+	if _, ok := ParseSchedulingStrategy("lifo"); ok {
+		t.Error("lifo deveria ser rejeitado")
+	}
+}
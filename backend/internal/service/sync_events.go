@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// SyncEventCompleted identifica o evento emitido ao fim de uma sincronização de metadados
+const SyncEventCompleted = "metadata.sync.completed"
+
+// syncWebhookTimeout limita o envio do webhook de sincronização
+const syncWebhookTimeout = 30 * time.Second
+
+// SyncSummary resume uma sincronização de metadados concluída
+type SyncSummary struct {
+	Event       string    `json:"event"`
+	UserID      string    `json:"user_id"`
+	TokenLabel  string    `json:"token_label"`
+	Workspaces  int       `json:"workspaces"`
+	Spaces      int       `json:"spaces"`
+	Folders     int       `json:"folders"`
+	Lists       int       `json:"lists"`
+	Fields      int       `json:"fields"`
+	Pruned      int64     `json:"pruned"` // itens excluídos no ClickUp e removidos do banco
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// SyncListener é chamado, em processo, ao fim de cada sincronização bem-sucedida
+type SyncListener func(ctx context.Context, summary SyncSummary)
+
+// AddSyncListener registra um listener de sincronização concluída (ex.: caches externos)
+func (s *MetadataService) AddSyncListener(listener SyncListener) {
+	s.syncListeners = append(s.syncListeners, listener)
+}
+
+// notifySyncCompleted entrega o resumo a todos os listeners registrados
+func (s *MetadataService) notifySyncCompleted(ctx context.Context, summary SyncSummary) {
+	for _, listener := range s.syncListeners {
+		listener(ctx, summary)
+	}
+}
+
+// syncWebhookStore obtém a configuração do usuário com a URL do webhook
+type syncWebhookStore interface {
+	GetUserConfig(userID string) (*repository.UserConfig, error)
+}
+
+// SyncWebhookNotifier envia o resumo da sincronização para o webhook configurado pelo usuário
+type SyncWebhookNotifier struct {
+	store   syncWebhookStore
+	webhook *WebhookService
+}
+
+// NewSyncWebhookNotifier cria o notificador usando a configuração salva em user_config
+func NewSyncWebhookNotifier(configRepo *repository.ConfigRepository, webhook *WebhookService) *SyncWebhookNotifier {
+	return &SyncWebhookNotifier{store: configRepo, webhook: webhook}
+}
+
+// Listener retorna o SyncListener que envia o webhook em segundo plano, sem atrasar
+// a resposta da sincronização (os logs mantêm o request_id da sincronização)
+func (n *SyncWebhookNotifier) Listener() SyncListener {
+	return func(ctx context.Context, summary SyncSummary) {
+		requestID := logger.GetRequestID(ctx)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), syncWebhookTimeout)
+			defer cancel()
+			if requestID != "" {
+				ctx = logger.WithRequestID(ctx, requestID)
+			}
+			n.Notify(ctx, summary)
+		}()
+	}
+}
+
+// Notify envia o resumo para a URL do usuário, se houver; falhas são apenas registradas
+func (n *SyncWebhookNotifier) Notify(ctx context.Context, summary SyncSummary) {
+	log := logger.Get(ctx)
+
+	config, err := n.store.GetUserConfig(summary.UserID)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", summary.UserID).Msg("Erro ao buscar webhook de sincronização")
+		return
+	}
+	if config == nil || config.SyncWebhookURL == "" {
+		return
+	}
+
+	if err := n.webhook.SendSyncCompleted(ctx, config.SyncWebhookURL, summary); err != nil {
+		log.Warn().Err(err).Str("user_id", summary.UserID).Msg("Erro ao enviar webhook de sincronização")
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+type fakeSyncWebhookStore struct {
+	config *repository.UserConfig
+}
+
+func (f *fakeSyncWebhookStore) GetUserConfig(userID string) (*repository.UserConfig, error) {
+	return f.config, nil
+}
+
+// TestSyncWebhookNotifierDeliversSummary checks the JSON summary posted to the user's URL
+func TestSyncWebhookNotifierDeliversSummary(t *testing.T) {
+	received := make(chan SyncSummary, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		var summary SyncSummary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("payload inválido: %v", err)
+		}
+		received <- summary
+	}))
+	defer server.Close()
+
+	store := &fakeSyncWebhookStore{config: &repository.UserConfig{UserID: "ana", SyncWebhookURL: server.URL}}
+	webhook := NewWebhookService()
+	webhook.SetAllowPrivateNetworks(true) // o servidor de teste escuta em 127.0.0.1
+	notifier := &SyncWebhookNotifier{store: store, webhook: webhook}
+
+	sent := SyncSummary{Event: SyncEventCompleted, UserID: "ana", TokenLabel: DefaultTokenLabel, Workspaces: 1, Spaces: 2, Folders: 3, Lists: 4, Fields: 5}
+	notifier.Notify(context.Background(), sent)
+
+	select {
+	case got := <-received:
+		if got != sent {
+			t.Errorf("resumo = %+v, esperado %+v", got, sent)
+		}
+	default:
+		t.Fatal("webhook não recebeu o resumo")
+	}
+
+	// Sem URL configurada nada é enviado
+	store.config.SyncWebhookURL = ""
+	notifier.Notify(context.Background(), sent)
+	if len(received) != 0 {
+		t.Error("webhook chamado sem URL configurada")
+	}
+}
+
+// TestSyncMetadataEmitsCompletion syncs from a fake ClickUp and checks the in-process
+// listener and the user's webhook both receive the counts
+func TestSyncMetadataEmitsCompletion(t *testing.T) {
+	db := setupTestDB(t)
+
+	clickup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses := map[string]string{
+			"/user":            `{"user":{"id":1,"username":"ana"}}`,
+			"/team":            `{"teams":[{"id":"t1","name":"Equipe"}]}`,
+			"/team/t1/space":   `{"spaces":[{"id":"s1","name":"Space"}]}`,
+			"/space/s1/folder": `{"folders":[{"id":"f1","name":"Folder"}]}`,
+			"/folder/f1/list":  `{"lists":[{"id":"l1","name":"Lista 1"},{"id":"l2","name":"Lista 2"}]}`,
+			"/list/l1/field":   `{"fields":[{"id":"cf1","name":"Valor","type":"number"}]}`,
+			"/list/l2/field":   `{"fields":[{"id":"cf1","name":"Valor","type":"number"},{"id":"cf2","name":"Nota","type":"text"}]}`,
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer clickup.Close()
+
+	var deliveries int32
+	delivered := make(chan SyncSummary, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&deliveries, 1)
+		var summary SyncSummary
+		json.NewDecoder(r.Body).Decode(&summary)
+		delivered <- summary
+	}))
+	defer webhook.Close()
+
+	configRepo := repository.NewConfigRepository(db)
+	if err := configRepo.UpdateSyncWebhookURL("ana", webhook.URL); err != nil {
+		t.Fatalf("UpdateSyncWebhookURL: %v", err)
+	}
+
+	service := NewMetadataService(repository.NewMetadataRepository(db), configRepo, "test-encryption-key-32-bytes-long")
+	service.SetClientOptions(client.ClientOptions{BaseURL: clickup.URL})
+	var inProcess []SyncSummary
+	service.AddSyncListener(func(ctx context.Context, summary SyncSummary) {
+		inProcess = append(inProcess, summary)
+	})
+	webhookService := NewWebhookService()
+	webhookService.SetAllowPrivateNetworks(true) // o servidor de teste escuta em 127.0.0.1
+	service.AddSyncListener(NewSyncWebhookNotifier(configRepo, webhookService).Listener())
+
+	if err := service.SyncMetadata(context.Background(), "ana", "", "pk_test_token"); err != nil {
+		t.Fatalf("SyncMetadata: %v", err)
+	}
+
+	if len(inProcess) != 1 {
+		t.Fatalf("listener chamado %d vezes, esperado 1", len(inProcess))
+	}
+	summary := inProcess[0]
+	if summary.Event != SyncEventCompleted || summary.UserID != "ana" ||
+		summary.Workspaces != 1 || summary.Spaces != 1 || summary.Folders != 1 || summary.Lists != 2 || summary.Fields != 2 {
+		t.Errorf("resumo = %+v", summary)
+	}
+
+	select {
+	case got := <-delivered:
+		if got.Lists != 2 || got.Fields != 2 || got.UserID != "ana" {
+			t.Errorf("webhook recebeu %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook de sincronização não foi entregue")
+	}
+	if n := atomic.LoadInt32(&deliveries); n != 1 {
+		t.Errorf("webhook chamado %d vezes, esperado 1", n)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+// ErrUnknownAssignee is returned for users cells naming people who aren't workspace members
+var ErrUnknownAssignee = errors.New("usuário não encontrado no workspace")
+
+// WorkspaceMemberSource lists the members of a workspace (implemented by *client.Client)
+type WorkspaceMemberSource interface {
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.UserInfo, error)
+}
+
+// assigneeResolver maps the emails and usernames of "users" cells to ClickUp member
+// IDs. Members are fetched once per job, and only when a cell holds something other
+// than numeric IDs.
+type assigneeResolver struct {
+	source      WorkspaceMemberSource
+	workspaceID string // empty: members of every workspace of the token
+
+	members map[string]int // lower-case email/username -> member ID (0 when ambiguous)
+}
+
+func newAssigneeResolver(source WorkspaceMemberSource, workspaceID string) *assigneeResolver {
+	return &assigneeResolver{source: source, workspaceID: workspaceID}
+}
+
+// resolve returns the comma-separated member IDs for a users cell. Numeric entries are
+// kept as IDs; the others are looked up by email, then by username (case-insensitive).
+func (r *assigneeResolver) resolve(ctx context.Context, value string) (string, error) {
+	parts := splitAndTrimNonEmpty(value, ",")
+	ids := make([]string, 0, len(parts))
+	var unknown, ambiguous []string
+
+	for _, part := range parts {
+		if _, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, part)
+			continue
+		}
+		if err := r.load(ctx); err != nil {
+			return "", err
+		}
+		id, ok := r.members[strings.ToLower(part)]
+		switch {
+		case !ok:
+			unknown = append(unknown, part)
+		case id == 0:
+			ambiguous = append(ambiguous, part)
+		default:
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUnknownAssignee, strings.Join(unknown, ", "))
+	}
+	if len(ambiguous) > 0 {
+		return "", fmt.Errorf("mais de um membro com o nome %s; use o email ou o ID", strings.Join(ambiguous, ", "))
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// load fetches the workspace members on first use; a failed fetch is retried on the
+// next lookup
+func (r *assigneeResolver) load(ctx context.Context) error {
+	if r.members != nil {
+		return nil
+	}
+	users, err := r.source.GetWorkspaceMembers(ctx, r.workspaceID)
+	if err != nil {
+		return err
+	}
+
+	members := make(map[string]int, len(users)*2)
+	for _, user := range users {
+		if email := strings.ToLower(strings.TrimSpace(user.Email)); email != "" {
+			members[email] = user.ID
+		}
+	}
+	// Usernames never override emails, and names shared by several members are ambiguous
+	for _, user := range users {
+		name := strings.ToLower(strings.TrimSpace(user.Username))
+		if name == "" {
+			continue
+		}
+		if id, taken := members[name]; taken && id != user.ID {
+			members[name] = 0
+			continue
+		}
+		members[name] = user.ID
+	}
+	r.members = members
+	return nil
+}
+
+// splitAndTrimNonEmpty splits s by sep, trimming the parts and dropping empty ones
+func splitAndTrimNonEmpty(s, sep string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
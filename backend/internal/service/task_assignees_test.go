@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// membersServer simula o ClickUp com os membros de dois workspaces e guarda os valores
+// enviados por caminho, além de quantas vezes /team foi consultado
+func membersServer(t *testing.T) (*httptest.Server, map[string]string, *int) {
+	var mu sync.Mutex
+	written := make(map[string]string)
+	teamFetches := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet && r.URL.Path == "/team" {
+			teamFetches++
+			w.Write([]byte(`{"teams": [
+				{"id": "w1", "members": [
+					{"user": {"id": 101, "username": "Ana Souza", "email": "ana@example.com"}},
+					{"user": {"id": 102, "username": "Bruno", "email": "bruno@example.com"}},
+					{"user": {"id": 103, "username": "Carla", "email": "carla@example.com"}},
+					{"user": {"id": 104, "username": "Carla", "email": "carla.lima@example.com"}}
+				]},
+				{"id": "w2", "members": [
+					{"user": {"id": 201, "username": "Davi", "email": "davi@example.com"}}
+				]}
+			]}`))
+			return
+		}
+		var body struct {
+			Value interface{} `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		value, _ := json.Marshal(body.Value)
+		written[r.URL.Path] = string(value)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, written, &teamFetches
+}
+
+// TestProcessFileResolvesAssignees envia os IDs dos membros citados por email ou nome
+// e marca como erro as linhas com pessoas que não são membros do workspace
+func TestProcessFileResolvesAssignees(t *testing.T) {
+	server, written, teamFetches := membersServer(t)
+
+	csv := "id task,Responsáveis\n" +
+		"T1,ANA@example.com\n" +
+		"T2,\"bruno, 300\"\n" +
+		"T3,\"ana@example.com, fulano@example.com\"\n" +
+		"T4,Carla\n" +
+		"T5,999\n"
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("responsaveis.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	job := &repository.UpdateJob{
+		ID:       5,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Responsáveis": "f-resp"},
+		Options:  repository.JobOptions{TeamID: "w1"},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-resp": "users"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+
+	want := map[string]string{
+		"/task/T1/field/f-resp": `[101]`,
+		"/task/T2/field/f-resp": `[102,300]`,
+		"/task/T5/field/f-resp": `[999]`,
+	}
+	if len(written) != len(want) {
+		t.Errorf("gravações = %v, esperado %v", written, want)
+	}
+	for path, value := range want {
+		if written[path] != value {
+			t.Errorf("%s = %s, esperado %s", path, written[path], value)
+		}
+	}
+
+	if result.SuccessCount != 3 || result.ErrorCount != 2 {
+		t.Fatalf("esperado 3 sucessos e 2 erros, obtido %+v", result)
+	}
+	errorsByTask := make(map[string]string)
+	for _, e := range result.Errors {
+		errorsByTask[e.TaskID] = e.Error
+	}
+	if msg := errorsByTask["T3"]; !strings.Contains(msg, "fulano@example.com") || strings.Contains(msg, "ana@example.com") {
+		t.Errorf("erro da T3 deveria citar só o usuário desconhecido: %q", msg)
+	}
+	if msg := errorsByTask["T4"]; !strings.Contains(msg, "Carla") {
+		t.Errorf("erro da T4 deveria citar o nome ambíguo: %q", msg)
+	}
+
+	if *teamFetches != 1 {
+		t.Errorf("membros buscados %d vezes, esperado 1", *teamFetches)
+	}
+}
+
+func TestAssigneeResolverWorkspaceScope(t *testing.T) {
+	server, _, teamFetches := membersServer(t)
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	// Sem workspace, valem os membros de todos os workspaces do token
+	all := newAssigneeResolver(clickupClient, "")
+	if ids, err := all.resolve(context.Background(), "davi@example.com, Ana Souza"); err != nil || ids != "201,101" {
+		t.Errorf("resolve = %q, %v; esperado \"201,101\"", ids, err)
+	}
+
+	// Com workspace, membros de outros workspaces são desconhecidos
+	scoped := newAssigneeResolver(clickupClient, "w1")
+	if _, err := scoped.resolve(context.Background(), "davi@example.com"); !errors.Is(err, ErrUnknownAssignee) {
+		t.Errorf("esperado ErrUnknownAssignee, obtido %v", err)
+	}
+
+	// IDs numéricos não exigem buscar os membros
+	fetches := *teamFetches
+	if ids, err := newAssigneeResolver(clickupClient, "w1").resolve(context.Background(), "1, 2"); err != nil || ids != "1,2" {
+		t.Errorf("resolve = %q, %v; esperado \"1,2\"", ids, err)
+	}
+	if *teamFetches != fetches {
+		t.Error("membros não deveriam ser buscados para IDs numéricos")
+	}
+}
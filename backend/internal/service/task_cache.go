@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+// jobTasks reads the tasks of a job from ClickUp for the checks that need their current
+// state (the task's list, conflicts, dry run diffs), so each task is read once however
+// many of them are enabled. Rows of the same task are usually together, so only the last
+// task read is kept; the list of every task read is kept for the whole job.
+type jobTasks struct {
+	source TaskSource
+	lastID string
+	last   *model.Task
+	lists  map[string]string // task ID -> list ID
+}
+
+func newJobTasks(source TaskSource) *jobTasks {
+	return &jobTasks{source: source, lists: make(map[string]string)}
+}
+
+// get returns the task, reading it unless it was the last one read
+func (t *jobTasks) get(ctx context.Context, taskID string) (*model.Task, error) {
+	if t.last != nil && t.lastID == taskID {
+		return t.last, nil
+	}
+	task, err := t.source.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	t.lastID, t.last = taskID, task
+	t.lists[taskID] = task.List.ID
+	return task, nil
+}
+
+// list returns the ID of the task's list, read at most once per job
+func (t *jobTasks) list(ctx context.Context, taskID string) (string, error) {
+	if listID, ok := t.lists[taskID]; ok {
+		return listID, nil
+	}
+	task, err := t.get(ctx, taskID)
+	if err != nil {
+		return "", err
+	}
+	return task.List.ID, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TaskCommenter posts a comment on a task (implemented by *client.Client)
+type TaskCommenter interface {
+	CreateTaskComment(ctx context.Context, taskID, text string) error
+}
+
+// fieldChange is a value written to a task, as it was in the spreadsheet
+type fieldChange struct {
+	Column string
+	Value  string
+}
+
+// updateCommentText describes the fields written to a task by a job, for the comment
+// posted with comment_on_update. Values are the cells of the spreadsheet (option names,
+// not their IDs), so the comment reads like the file.
+func updateCommentText(job *repository.UpdateJob, changes []fieldChange) string {
+	sorted := append([]fieldChange(nil), changes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Column < sorted[j].Column })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Campos atualizados via importação por %s (job #%d", job.UserID, job.ID)
+	if job.Title != "" {
+		fmt.Fprintf(&b, " \"%s\"", job.Title)
+	}
+	b.WriteString("):")
+	for _, change := range sorted {
+		fmt.Fprintf(&b, "\n- %s: %s", change.Column, change.Value)
+	}
+	return b.String()
+}
+
+// commentUpdate posts the summary comment of a task. Comments are best effort: a failure
+// is logged and counted, never failing the row whose fields were already written.
+func commentUpdate(ctx context.Context, commenter TaskCommenter, job *repository.UpdateJob, taskID string, changes []fieldChange, result *BatchUpdateResult) {
+	if err := commenter.CreateTaskComment(ctx, taskID, updateCommentText(job, changes)); err != nil {
+		result.CommentErrors++
+		logger.Get(ctx).Warn().
+			Int("job_id", job.ID).
+			Str("task_id", taskID).
+			Err(err).
+			Msg("Erro ao comentar atualização na task")
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// commentServer simula o ClickUp: grava campos e comentários, recusando os comentários de failTask
+type commentServer struct {
+	mu       sync.Mutex
+	writes   map[string]int
+	comments map[string]map[string]interface{}
+}
+
+func newCommentServer(t *testing.T, failTask string) (*commentServer, *httptest.Server) {
+	s := &commentServer{writes: make(map[string]int), comments: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/task/"), "/")
+		taskID := parts[0]
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(parts) == 2 && parts[1] == "comment" {
+			if taskID == failTask {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"err": "falha", "ECODE": "X"}`))
+				return
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("corpo do comentário inválido: %v", err)
+			}
+			s.comments[taskID] = body
+			w.Write([]byte(`{"id": "c1"}`))
+			return
+		}
+		s.writes[taskID]++
+		w.Write([]byte("{}"))
+	}))
+	return s, server
+}
+
+func commentJob(t *testing.T, csv string, options repository.JobOptions) (*TaskUpdateService, *repository.UpdateJob) {
+	t.Helper()
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("comentarios.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	return NewTaskUpdateService(uploadService, nil, nil, nil, nil), &repository.UpdateJob{
+		ID:       31,
+		UserID:   "ana",
+		Title:    "Importação de março",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Valor": "f-valor", "Nota": "f-nota"},
+		Options:  options,
+	}
+}
+
+// TestCommentOnUpdate verifica o comentário publicado após gravar os campos de cada task
+// e que a falha de um comentário não falha a linha
+func TestCommentOnUpdate(t *testing.T) {
+	state, server := newCommentServer(t, "T2")
+	defer server.Close()
+
+	svc, job := commentJob(t, "id task,Valor,Nota\nT1,10,ok\nT2,20,\n", repository.JobOptions{CommentOnUpdate: true})
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "number", "f-nota": "text"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+
+	if result.SuccessCount != 2 || result.ErrorCount != 0 {
+		t.Errorf("falha no comentário não deveria falhar a linha: %+v", result)
+	}
+	if result.CommentErrors != 1 {
+		t.Errorf("CommentErrors = %d, esperado 1", result.CommentErrors)
+	}
+	if state.writes["T1"] != 2 || state.writes["T2"] != 1 {
+		t.Errorf("gravações inesperadas: %v", state.writes)
+	}
+
+	comment, ok := state.comments["T1"]
+	if !ok {
+		t.Fatalf("comentário da T1 não publicado: %v", state.comments)
+	}
+	want := "Campos atualizados via importação por ana (job #31 \"Importação de março\"):\n- Nota: ok\n- Valor: 10"
+	if comment["comment_text"] != want {
+		t.Errorf("comment_text = %q, esperado %q", comment["comment_text"], want)
+	}
+	if comment["notify_all"] != false {
+		t.Errorf("notify_all = %v, esperado false", comment["notify_all"])
+	}
+
+	summary := buildJobSummary(job.ID, result, time.Time{}, time.Time{}, nil)
+	if summary.CommentErrors != 1 {
+		t.Errorf("resumo deveria trazer a falha de comentário: %+v", summary)
+	}
+}
+
+// TestNoCommentByDefault: sem comment_on_update nenhum comentário é publicado
+func TestNoCommentByDefault(t *testing.T) {
+	state, server := newCommentServer(t, "")
+	defer server.Close()
+
+	svc, job := commentJob(t, "id task,Valor,Nota\nT1,10,ok\n", repository.JobOptions{})
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	if _, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "number", "f-nota": "text"}, 6000); err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+	if len(state.comments) != 0 || state.writes["T1"] != 2 {
+		t.Errorf("esperadas 2 gravações e nenhum comentário, obtido %v e %v", state.writes, state.comments)
+	}
+}
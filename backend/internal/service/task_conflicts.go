@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TaskSource reads a task by ID (implemented by *client.Client)
+type TaskSource interface {
+	GetTask(ctx context.Context, taskID string) (*model.Task, error)
+}
+
+// conflictChecker implements a job's optimistic concurrency check: a row is only written
+// if its task hasn't changed in ClickUp since the spreadsheet was generated
+type conflictChecker struct {
+	tasks *jobTasks
+	since time.Time
+	// cleared holds tasks this job already checked: its own writes bump date_updated,
+	// which mustn't flag later rows of the same task
+	cleared map[string]bool
+}
+
+// newConflictChecker returns nil when the job has no conflict check
+func newConflictChecker(tasks *jobTasks, check *repository.ConflictCheck) *conflictChecker {
+	if check == nil || check.Since.IsZero() {
+		return nil
+	}
+	return &conflictChecker{
+		tasks:   tasks,
+		since:   check.Since,
+		cleared: make(map[string]bool),
+	}
+}
+
+// check returns why the task's row must not be written ("" when it may be)
+func (c *conflictChecker) check(ctx context.Context, taskID string) (string, error) {
+	if c.cleared[taskID] {
+		return "", nil
+	}
+	task, err := c.tasks.get(ctx, taskID)
+	if err != nil {
+		return "", fmt.Errorf("verificar alterações da task: %w", err)
+	}
+	millis, err := strconv.ParseInt(strings.TrimSpace(task.DateUpdated), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("verificar alterações da task: date_updated inválido %q", task.DateUpdated)
+	}
+	if updatedAt := time.UnixMilli(millis); updatedAt.After(c.since) {
+		return fmt.Sprintf("alterada no ClickUp em %s, depois da geração da planilha (%s)",
+			updatedAt.UTC().Format(time.RFC3339), c.since.UTC().Format(time.RFC3339)), nil
+	}
+	c.cleared[taskID] = true
+	return "", nil
+}
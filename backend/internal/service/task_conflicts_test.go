@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TestProcessFileSkipsConflictingTasks simula uma task editada no ClickUp entre a geração
+// da planilha e a gravação: a linha dela não é gravada e aparece como conflito
+func TestProcessFileSkipsConflictingTasks(t *testing.T) {
+	generatedAt := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	updatedAt := map[string]time.Time{
+		"T1": generatedAt.Add(-time.Hour),
+		"T2": generatedAt.Add(30 * time.Minute), // editada por outra pessoa depois da exportação
+		"T3": generatedAt.Add(-time.Hour),
+	}
+	written := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		taskID := strings.Split(strings.TrimPrefix(r.URL.Path, "/task/"), "/")[0]
+		if r.Method == http.MethodGet {
+			fmt.Fprintf(w, `{"id": %q, "date_updated": "%d"}`, taskID, updatedAt[taskID].UnixMilli())
+			return
+		}
+		// Cada gravação atualiza a task, como no ClickUp
+		written[taskID]++
+		updatedAt[taskID] = time.Now()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	csv := "id task,Valor,Nota\n" +
+		"T1,a,1\n" +
+		"T2,b,2\n" +
+		"T3,c,3\n" +
+		"T3,,4\n" // segunda linha da mesma task: a gravação anterior do job não é conflito (Nota 3 é substituída pela 4)
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("conflitos.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	job := &repository.UpdateJob{
+		ID:       11,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Valor": "f-valor", "Nota": "f-nota"},
+		Options:  repository.JobOptions{ConflictCheck: &repository.ConflictCheck{Since: generatedAt}},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "text", "f-nota": "text"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+
+	if written["T2"] != 0 {
+		t.Errorf("task em conflito não deveria ser gravada (%d gravações)", written["T2"])
+	}
+	if written["T1"] != 2 || written["T3"] != 2 {
+		t.Errorf("gravações = %v, esperado T1=2 e T3=2", written)
+	}
+	if result.ConflictCount != 1 || len(result.Conflicts) != 1 || result.Conflicts[0].TaskID != "T2" {
+		t.Fatalf("esperado 1 conflito em T2, obtido %+v", result.Conflicts)
+	}
+	if !strings.Contains(result.Conflicts[0].Error, "conflito") {
+		t.Errorf("mensagem do conflito: %q", result.Conflicts[0].Error)
+	}
+	if result.SuccessCount != 3 || result.ErrorCount != 0 || result.ProcessedRows != 4 {
+		t.Errorf("esperado 3 sucessos, 0 erros e 4 linhas processadas, obtido %+v", result)
+	}
+
+	summary := buildJobSummary(job.ID, result, generatedAt, generatedAt, nil)
+	if summary.ConflictCount != 1 || len(summary.Conflicts) != 1 || len(summary.Errors) != 0 {
+		t.Errorf("resumo deveria listar o conflito à parte dos erros: %+v", summary)
+	}
+}
+
+func TestConflictCheckDisabled(t *testing.T) {
+	if newConflictChecker(nil, nil) != nil || newConflictChecker(nil, &repository.ConflictCheck{}) != nil {
+		t.Error("sem horário de geração não há verificação")
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// Duplicate resolution modes for rows targeting the same task and field
+const (
+	DuplicateLastWins  = "last-wins"
+	DuplicateFirstWins = "first-wins"
+	DuplicateError     = "error"
+
+	// DefaultDuplicateResolution matches the previous behaviour, where rows were
+	// written in order and the last one stuck
+	DefaultDuplicateResolution = DuplicateLastWins
+)
+
+// IsValidDuplicateResolution reports whether mode is a known resolution ("" means default)
+func IsValidDuplicateResolution(mode string) bool {
+	switch mode {
+	case "", DuplicateLastWins, DuplicateFirstWins, DuplicateError:
+		return true
+	}
+	return false
+}
+
+// duplicateSkip describes why a (row, field) write is not sent
+type duplicateSkip struct {
+	message string
+	isError bool // true in "error" mode: the row counts as failed
+}
+
+// duplicatePlan maps row index -> field ID -> skip reason
+type duplicatePlan map[int]map[string]duplicateSkip
+
+// lookup returns the skip reason for a row/field, if any
+func (p duplicatePlan) lookup(rowIndex int, fieldID string) (duplicateSkip, bool) {
+	fields, ok := p[rowIndex]
+	if !ok {
+		return duplicateSkip{}, false
+	}
+	skip, ok := fields[fieldID]
+	return skip, ok
+}
+
+func (p duplicatePlan) add(rowIndex int, fieldID string, skip duplicateSkip) {
+	if p[rowIndex] == nil {
+		p[rowIndex] = make(map[string]duplicateSkip)
+	}
+	p[rowIndex][fieldID] = skip
+}
+
+// planDuplicates finds rows that would write the same (task, field) more than once and
+// decides which writes to skip. Only cells that would actually be sent (after defaults)
+// count as targets.
+func planDuplicates(
+	columns []string,
+	data [][]string,
+	taskIDColumnIndex int,
+	mapping map[string]string,
+	defaults map[string]repository.FieldDefault,
+	mode string,
+) duplicatePlan {
+	planner := newDuplicatePlanner(columns, taskIDColumnIndex, mapping, defaults)
+	for _, row := range data {
+		planner.addRow(row)
+	}
+	return planner.plan(mode)
+}
+
+// duplicateTarget is a (task, field) pair written by a row
+type duplicateTarget struct{ taskID, fieldID string }
+
+// duplicatePlanner collects write targets row by row, so the plan can be built while
+// streaming a file; it keeps only row numbers, not row contents
+type duplicatePlanner struct {
+	columnIndexMap    map[string]int
+	taskIDColumnIndex int
+	mapping           map[string]string
+	defaults          map[string]repository.FieldDefault
+
+	rowIndex    int
+	occurrences map[duplicateTarget][]int
+	order       []duplicateTarget
+}
+
+func newDuplicatePlanner(
+	columns []string,
+	taskIDColumnIndex int,
+	mapping map[string]string,
+	defaults map[string]repository.FieldDefault,
+) *duplicatePlanner {
+	columnIndexMap := make(map[string]int)
+	for i, col := range columns {
+		columnIndexMap[col] = i
+	}
+	return &duplicatePlanner{
+		columnIndexMap:    columnIndexMap,
+		taskIDColumnIndex: taskIDColumnIndex,
+		mapping:           mapping,
+		defaults:          defaults,
+		occurrences:       make(map[duplicateTarget][]int),
+	}
+}
+
+// addRow records the targets of the next data row
+func (p *duplicatePlanner) addRow(row []string) {
+	rowIndex := p.rowIndex
+	p.rowIndex++
+
+	if p.taskIDColumnIndex >= len(row) {
+		return
+	}
+	taskID := strings.TrimSpace(row[p.taskIDColumnIndex])
+	if taskID == "" {
+		return
+	}
+
+	for columnName, fieldID := range p.mapping {
+		if isRowMarker(fieldID) {
+			continue
+		}
+		colIndex, exists := p.columnIndexMap[columnName]
+		var fieldDefault *repository.FieldDefault
+		if def, ok := p.defaults[columnName]; ok {
+			fieldDefault = &def
+		}
+		if _, ok := resolveCellValue(row, colIndex, exists, fieldDefault); !ok {
+			continue
+		}
+
+		key := duplicateTarget{taskID, fieldID}
+		if _, seen := p.occurrences[key]; !seen {
+			p.order = append(p.order, key)
+		}
+		p.occurrences[key] = append(p.occurrences[key], rowIndex)
+	}
+}
+
+// skipRow advances past a data row that won't be written (filtered out)
+func (p *duplicatePlanner) skipRow() {
+	p.rowIndex++
+}
+
+// rows returns the number of rows added so far
+func (p *duplicatePlanner) rows() int {
+	return p.rowIndex
+}
+
+// plan decides which writes to skip according to the resolution mode
+func (p *duplicatePlanner) plan(mode string) duplicatePlan {
+	if mode == "" {
+		mode = DefaultDuplicateResolution
+	}
+
+	plan := make(duplicatePlan)
+	for _, key := range p.order {
+		rows := p.occurrences[key]
+		if len(rows) < 2 {
+			continue
+		}
+
+		switch mode {
+		case DuplicateFirstWins:
+			for _, r := range rows[1:] {
+				plan.add(r, key.fieldID, duplicateSkip{
+					message: fmt.Sprintf("linha %d, task %s, campo %s: ignorado (duplicado, prevalece a linha %d)", r+1, key.taskID, key.fieldID, rows[0]+1),
+				})
+			}
+		case DuplicateError:
+			for _, r := range rows {
+				plan.add(r, key.fieldID, duplicateSkip{
+					message: fmt.Sprintf("linha %d, task %s, campo %s: alvo duplicado nas linhas %s", r+1, key.taskID, key.fieldID, joinRowNumbers(rows)),
+					isError: true,
+				})
+			}
+		default: // last-wins
+			last := rows[len(rows)-1]
+			for _, r := range rows[:len(rows)-1] {
+				plan.add(r, key.fieldID, duplicateSkip{
+					message: fmt.Sprintf("linha %d, task %s, campo %s: ignorado (duplicado, prevalece a linha %d)", r+1, key.taskID, key.fieldID, last+1),
+				})
+			}
+		}
+	}
+
+	return plan
+}
+
+// taskIDMarker is the mapping target of the task ID column in job mappings
+const taskIDMarker = "task_id"
+
+// isTaskIDField reports whether a mapping target is the task ID column marker
+func isTaskIDField(fieldID string) bool {
+	lower := strings.ToLower(fieldID)
+	return lower == "task_id" || lower == "id_task"
+}
+
+// listIDMarker is the mapping target of the optional column holding each task's list ID
+const listIDMarker = "list_id"
+
+// isListIDField reports whether a mapping target is the list ID column marker
+func isListIDField(fieldID string) bool {
+	return strings.ToLower(fieldID) == listIDMarker
+}
+
+// isRowMarker reports whether a mapping target identifies the row (task or list ID
+// column) instead of a custom field to update
+func isRowMarker(fieldID string) bool {
+	return isTaskIDField(fieldID) || isListIDField(fieldID)
+}
+
+// joinRowNumbers formats 0-based row indexes as 1-based spreadsheet lines
+func joinRowNumbers(rows []int) string {
+	parts := make([]string, len(rows))
+	for i, r := range rows {
+		parts[i] = fmt.Sprintf("%d", r+1)
+	}
+	return strings.Join(parts, ", ")
+}
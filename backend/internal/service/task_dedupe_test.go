@@ -0,0 +1,117 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+// duplicateFixture has task T1 targeted three times for the "status" field (lines 1, 3, 5)
+// and twice for "valor" (lines 1, 4); empty cells must not count as writes
+func duplicateFixture() ([]string, [][]string, map[string]string) {
+	columns := []string{"id task", "Status", "Valor"}
+	data := [][]string{
+		{"T1", "aberto", "10"},
+		{"T2", "aberto", "20"},
+		{"T1", "fechado", ""},
+		{"T1", "", "30"},
+		{"T1", "em revisão", ""},
+	}
+	mapping := map[string]string{
+		"id task": "task_id",
+		"Status":  "field-status",
+		"Valor":   "field-valor",
+	}
+	return columns, data, mapping
+}
+
+func skippedRows(plan duplicatePlan, fieldID string) []int {
+	var rows []int
+	for r := 0; r < 10; r++ {
+		if _, ok := plan.lookup(r, fieldID); ok {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlanDuplicatesLastWins(t *testing.T) {
+	columns, data, mapping := duplicateFixture()
+	plan := planDuplicates(columns, data, 0, mapping, nil, DuplicateLastWins)
+
+	// status de T1: linhas 0, 2 e 4 -> prevalece a 4
+	if got := skippedRows(plan, "field-status"); !equalInts(got, []int{0, 2}) {
+		t.Errorf("status ignorado nas linhas %v, esperado [0 2]", got)
+	}
+	// valor de T1: linhas 0 e 3 -> prevalece a 3
+	if got := skippedRows(plan, "field-valor"); !equalInts(got, []int{0}) {
+		t.Errorf("valor ignorado nas linhas %v, esperado [0]", got)
+	}
+
+	skip, _ := plan.lookup(0, "field-status")
+	if skip.isError || !strings.Contains(skip.message, "prevalece a linha 5") {
+		t.Errorf("mensagem inesperada: %+v", skip)
+	}
+}
+
+func TestPlanDuplicatesFirstWins(t *testing.T) {
+	columns, data, mapping := duplicateFixture()
+	plan := planDuplicates(columns, data, 0, mapping, nil, DuplicateFirstWins)
+
+	if got := skippedRows(plan, "field-status"); !equalInts(got, []int{2, 4}) {
+		t.Errorf("status ignorado nas linhas %v, esperado [2 4]", got)
+	}
+	if got := skippedRows(plan, "field-valor"); !equalInts(got, []int{3}) {
+		t.Errorf("valor ignorado nas linhas %v, esperado [3]", got)
+	}
+}
+
+func TestPlanDuplicatesError(t *testing.T) {
+	columns, data, mapping := duplicateFixture()
+	plan := planDuplicates(columns, data, 0, mapping, nil, DuplicateError)
+
+	if got := skippedRows(plan, "field-status"); !equalInts(got, []int{0, 2, 4}) {
+		t.Errorf("status com erro nas linhas %v, esperado [0 2 4]", got)
+	}
+	skip, _ := plan.lookup(2, "field-status")
+	if !skip.isError || !strings.Contains(skip.message, "linhas 1, 3, 5") {
+		t.Errorf("mensagem inesperada: %+v", skip)
+	}
+
+	// T2 não tem duplicados
+	if _, ok := plan.lookup(1, "field-status"); ok {
+		t.Error("linha sem duplicado não deveria ser afetada")
+	}
+}
+
+func TestPlanDuplicatesNoDuplicates(t *testing.T) {
+	columns := []string{"id task", "Status"}
+	data := [][]string{{"T1", "a"}, {"T2", "b"}, {"", "c"}}
+	mapping := map[string]string{"Status": "field-status"}
+
+	if plan := planDuplicates(columns, data, 0, mapping, nil, ""); len(plan) != 0 {
+		t.Errorf("esperado plano vazio, obtido %v", plan)
+	}
+}
+
+func TestIsValidDuplicateResolution(t *testing.T) {
+	for _, mode := range []string{"", DuplicateLastWins, DuplicateFirstWins, DuplicateError} {
+		if !IsValidDuplicateResolution(mode) {
+			t.Errorf("%q deveria ser válido", mode)
+		}
+	}
+	if IsValidDuplicateResolution("merge") {
+		t.Error("merge não deveria ser válido")
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+)
+
+// maxFieldDiffs caps the diffs kept from a dry run; DiffCount still counts all of them
+const maxFieldDiffs = 1000
+
+// FieldDiff is the change a job would make to one field of a task
+type FieldDiff struct {
+	TaskID  string      `json:"task_id"`
+	Column  string      `json:"column"`
+	FieldID string      `json:"field_id"`
+	From    interface{} `json:"from"` // current value in ClickUp, as it would be sent (nil when empty)
+	To      interface{} `json:"to"`   // value the job would send
+}
+
+// diffCollector reads the current field values of the tasks a dry run would update
+type diffCollector struct {
+	tasks *jobTasks
+}
+
+// newDiffCollector returns nil unless the job is a dry run asking for diffs
+func newDiffCollector(tasks *jobTasks, dryRun, previewDiff bool) *diffCollector {
+	if !dryRun || !previewDiff {
+		return nil
+	}
+	return &diffCollector{tasks: tasks}
+}
+
+// diff returns the change to fieldID of the task when to is written, or nil when the
+// field already holds that value
+func (d *diffCollector) diff(ctx context.Context, taskID, column, fieldID string, to interface{}) (*FieldDiff, error) {
+	task, err := d.tasks.get(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("ler valor atual: %w", err)
+	}
+	var from interface{}
+	for _, field := range task.CustomFields {
+		if field.ID == fieldID {
+			from = writeRepresentation(field)
+			break
+		}
+	}
+	if sameFieldValue(from, to) {
+		return nil, nil
+	}
+	return &FieldDiff{
+		TaskID:  taskID,
+		Column:  column,
+		FieldID: fieldID,
+		From:    from,
+		To:      to,
+	}, nil
+}
+
+// writeRepresentation converts a value read from ClickUp to the form a job writes it in
+// (see client.TransformFieldValue): dropdowns are read as the option's orderindex and
+// written as its ID, dates and numbers are read as strings and written as numbers,
+// labels and users are read as objects and written as IDs. Types whose read format
+// isn't known are kept as read.
+func writeRepresentation(field model.CustomField) interface{} {
+	if field.Value == nil {
+		return nil
+	}
+	switch field.Type {
+	case "drop_down":
+		index, ok := numericValue(field.Value)
+		if m, isMap := field.Value.(map[string]interface{}); isMap {
+			index, ok = numericValue(m["orderindex"])
+		}
+		if !ok || field.TypeConfig == nil {
+			return field.Value
+		}
+		for _, option := range field.TypeConfig.Options {
+			if float64(option.Orderindex) == index {
+				return option.ID
+			}
+		}
+		return field.Value
+	case "labels":
+		items, ok := field.Value.([]interface{})
+		if !ok {
+			return field.Value
+		}
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			if id := itemID(item); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	case "users":
+		items, ok := field.Value.([]interface{})
+		if !ok {
+			return field.Value
+		}
+		ids := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			id := itemID(item)
+			if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+				ids = append(ids, n)
+			} else if id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	case "date", "rating", "emoji":
+		if n, ok := numericValue(field.Value); ok {
+			return int64(n)
+		}
+	case "number", "currency", "percentage":
+		if n, ok := numericValue(field.Value); ok {
+			return n
+		}
+	case "manual_progress":
+		if m, ok := field.Value.(map[string]interface{}); ok {
+			if n, ok := numericValue(m["current"]); ok {
+				return map[string]interface{}{"current": n}
+			}
+		}
+	case "checkbox":
+		switch v := field.Value.(type) {
+		case bool:
+			return v
+		case string:
+			return v == "true"
+		}
+	}
+	return field.Value
+}
+
+// numericValue reads a number ClickUp may send as a JSON number or as a string
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// itemID returns the ID of a label or user as read from ClickUp (an ID or an object)
+func itemID(item interface{}) string {
+	switch v := item.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	case map[string]interface{}:
+		if id, ok := v["id"]; ok && id != nil {
+			return itemID(id)
+		}
+	}
+	return ""
+}
+
+// sameFieldValue compares two values in their JSON form, so int64(10) equals 10.0;
+// the order of list items (labels, users) doesn't matter
+func sameFieldValue(a, b interface{}) bool {
+	return reflect.DeepEqual(canonicalValue(a), canonicalValue(b))
+}
+
+// canonicalValue returns v decoded from its JSON encoding, with lists sorted
+func canonicalValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v
+	}
+	return sortLists(decoded)
+}
+
+func sortLists(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = sortLists(value[i])
+		}
+		sort.Slice(value, func(i, j int) bool { return fmt.Sprint(value[i]) < fmt.Sprint(value[j]) })
+		return value
+	case map[string]interface{}:
+		for key := range value {
+			value[key] = sortLists(value[key])
+		}
+	}
+	return v
+}
+
+// addDiff keeps a diff in the result, up to maxFieldDiffs
+func (r *BatchUpdateResult) addDiff(diff FieldDiff) {
+	r.DiffCount++
+	if len(r.Diffs) < maxFieldDiffs {
+		r.Diffs = append(r.Diffs, diff)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/model"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// TestDryRunPreviewDiff simula o ClickUp com os valores atuais das tasks: o dry-run com
+// preview_diff mostra o valor atual e o que seria gravado, sem gravar nada
+func TestDryRunPreviewDiff(t *testing.T) {
+	var mu sync.Mutex
+	reads := make(map[string]int)
+	writes := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method != http.MethodGet {
+			writes++
+			w.Write([]byte("{}"))
+			return
+		}
+		taskID := strings.Split(strings.TrimPrefix(r.URL.Path, "/task/"), "/")[0]
+		reads[taskID]++
+		switch taskID {
+		case "T1":
+			fmt.Fprint(w, `{"id": "T1", "custom_fields": [{"id": "f-valor", "type": "number", "value": "10"}, {"id": "f-nota", "type": "text", "value": "antiga"}]}`)
+		case "T3":
+			// Já tem os valores da planilha: não há diff
+			fmt.Fprint(w, `{"id": "T3", "custom_fields": [{"id": "f-valor", "type": "number", "value": "8.5"}, {"id": "f-nota", "type": "text", "value": "igual"}]}`)
+		default:
+			// Campos nunca preenchidos vêm sem value
+			fmt.Fprintf(w, `{"id": %q, "custom_fields": [{"id": "f-valor", "type": "number"}, {"id": "f-nota", "type": "text"}]}`, taskID)
+		}
+	}))
+	defer server.Close()
+
+	csv := "id task,Valor,Nota\n" +
+		"T1,\"1.234,5\",nova\n" +
+		"T2,7,\n" + // Nota vazia não é gravada, então não há diff dela
+		"T3,\"8,5\",igual\n"
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("diff.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	recorder := &fakeHistoryRecorder{}
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	svc.SetHistoryRecorder(recorder)
+	job := &repository.UpdateJob{
+		ID:       21,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Valor": "f-valor", "Nota": "f-nota"},
+		Options:  repository.JobOptions{DryRun: true, PreviewDiff: true},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "number", "f-nota": "text"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+
+	if writes != 0 {
+		t.Errorf("dry-run não deveria gravar (%d gravações)", writes)
+	}
+	if reads["T1"] != 1 || reads["T2"] != 1 || reads["T3"] != 1 {
+		t.Errorf("esperada uma leitura por task, obtido %v", reads)
+	}
+	if !result.DryRun || result.SuccessCount != 3 || result.ErrorCount != 0 {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+
+	got := make(map[string]FieldDiff)
+	for _, diff := range result.Diffs {
+		got[diff.TaskID+"/"+diff.FieldID] = diff
+	}
+	expected := map[string][2]string{
+		"T1/f-valor": {"10", "1234.5"},
+		"T1/f-nota":  {"antiga", "nova"},
+		"T2/f-valor": {"<nil>", "7"},
+	}
+	if result.DiffCount != len(expected) || len(got) != len(expected) {
+		t.Fatalf("esperados %d diffs, obtido %+v", len(expected), result.Diffs)
+	}
+	for key, want := range expected {
+		diff, ok := got[key]
+		if !ok {
+			t.Errorf("diff %s ausente", key)
+			continue
+		}
+		if fmt.Sprint(diff.From) != want[0] || fmt.Sprint(diff.To) != want[1] {
+			t.Errorf("diff %s = %v -> %v, esperado %v -> %v", key, diff.From, diff.To, want[0], want[1])
+		}
+	}
+
+	summary := buildJobSummary(job.ID, result, time.Time{}, time.Time{}, nil)
+	if !summary.DryRun || summary.DiffCount != 3 || len(summary.Diffs) != 3 {
+		t.Errorf("resumo deveria trazer os diffs: %+v", summary)
+	}
+	if len(recorder.records) != 0 {
+		t.Errorf("dry-run não deveria entrar no histórico de atualizações: %+v", recorder.records[0])
+	}
+}
+
+// TestWriteRepresentation converte valores lidos do ClickUp para o formato gravado
+func TestWriteRepresentation(t *testing.T) {
+	options := &model.TypeConfig{Options: []model.Option{
+		{ID: "opt-aberto", Name: "Aberto", Orderindex: 0},
+		{ID: "opt-fechado", Name: "Fechado", Orderindex: 1},
+	}}
+
+	tests := []struct {
+		name  string
+		field model.CustomField
+		to    interface{}
+	}{
+		{"dropdown por orderindex", model.CustomField{Type: "drop_down", TypeConfig: options, Value: float64(1)}, "opt-fechado"},
+		{"data em ms", model.CustomField{Type: "date", Value: "1710460800000"}, int64(1710460800000)},
+		{"número", model.CustomField{Type: "currency", Value: "1234.5"}, 1234.5},
+		{"número inteiro", model.CustomField{Type: "number", Value: "7"}, 7},
+		{"labels em outra ordem", model.CustomField{Type: "labels", Value: []interface{}{"l2", "l1"}}, []string{"l1", "l2"}},
+		{"users", model.CustomField{Type: "users", Value: []interface{}{map[string]interface{}{"id": float64(42), "username": "ana"}}}, []interface{}{int64(42)}},
+		{"checkbox", model.CustomField{Type: "checkbox", Value: "true"}, true},
+		{"avaliação", model.CustomField{Type: "rating", Value: "4"}, int64(4)},
+		{"progresso", model.CustomField{Type: "manual_progress", Value: map[string]interface{}{"current": "50", "percent_completed": float64(50)}}, map[string]interface{}{"current": 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := writeRepresentation(tt.field)
+			if !sameFieldValue(from, tt.to) {
+				t.Errorf("%v (%T) deveria ser igual a %v", from, from, tt.to)
+			}
+		})
+	}
+
+	if sameFieldValue(writeRepresentation(model.CustomField{Type: "drop_down", TypeConfig: options, Value: float64(0)}), "opt-fechado") {
+		t.Error("opções diferentes não deveriam ser iguais")
+	}
+}
+
+// TestDryRunWithoutPreviewDiff não faz leituras: o diff é opcional
+func TestDryRunWithoutPreviewDiff(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	csv := "id task,Valor\nT1,1\nT2,2\n"
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("dry.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	job := &repository.UpdateJob{
+		ID:       22,
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Valor": "f-valor"},
+		Options:  repository.JobOptions{DryRun: true},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "number"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+	if requests != 0 || result.SuccessCount != 2 || result.DiffCount != 0 {
+		t.Errorf("esperado dry-run sem requisições, obtido %d requisições e %+v", requests, result)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// ListFieldProvider reads synced field definitions, per field and per list
+type ListFieldProvider interface {
+	GetCustomFieldByID(fieldID string) (*repository.CustomField, error)
+	GetCustomFieldsByList(listID string) ([]repository.CustomField, error)
+}
+
+// SetListFieldProvider enables resolving dropdown/label fields against the list of
+// each row's task, so jobs can update tasks spread over several lists
+func (s *TaskUpdateService) SetListFieldProvider(provider ListFieldProvider) {
+	s.listFields = provider
+}
+
+// isOptionFieldType reports whether a field type stores option IDs
+func isOptionFieldType(fieldType string) bool {
+	return fieldType == "drop_down" || fieldType == "labels"
+}
+
+// taskListResolver maps the fields of a job to the fields of each task's list. The
+// same field name may have different options (and IDs) in each list, so an option
+// name must be resolved against the field of the list the task belongs to. The list
+// comes from the row's list ID column when mapped, otherwise from the job's tasks (read
+// once per task); lookups are cached for the whole job.
+type taskListResolver struct {
+	tasks           *jobTasks
+	provider        ListFieldProvider
+	listColumnIndex int
+
+	fields     map[string]*repository.CustomField           // mapped field ID -> definition
+	listFields map[string]map[string]repository.CustomField // list ID -> lower name -> field
+}
+
+// newTaskListResolver returns nil when no provider is set (fields are used as mapped)
+func (s *TaskUpdateService) newTaskListResolver(tasks *jobTasks, columns []string, mapping map[string]string) *taskListResolver {
+	if s.listFields == nil {
+		return nil
+	}
+	resolver := &taskListResolver{
+		tasks:           tasks,
+		provider:        s.listFields,
+		listColumnIndex: -1,
+		fields:          make(map[string]*repository.CustomField),
+		listFields:      make(map[string]map[string]repository.CustomField),
+	}
+	for colName, fieldID := range mapping {
+		if !isListIDField(fieldID) {
+			continue
+		}
+		for i, col := range columns {
+			if col == colName {
+				resolver.listColumnIndex = i
+			}
+		}
+	}
+	return resolver
+}
+
+// fieldFor returns the ID of the field to update on the row's task: the mapped field
+// when it belongs to the task's list (or isn't bound to lists), otherwise the field
+// with the same name in the task's list. When the task can't be read, the mapped field
+// is kept: the lookup only refines the write, it mustn't fail a row that may be valid.
+func (r *taskListResolver) fieldFor(ctx context.Context, row []string, taskID, fieldID string) (string, error) {
+	field, err := r.field(fieldID)
+	if err != nil {
+		return "", err
+	}
+	if field == nil || len(field.ListIDs) == 0 {
+		return fieldID, nil
+	}
+
+	listID, err := r.taskList(ctx, row, taskID)
+	if err != nil {
+		logger.Get(ctx).Warn().
+			Str("task_id", taskID).
+			Str("field_id", fieldID).
+			Err(err).
+			Msg("Lista da task não obtida, usando o campo mapeado")
+		return fieldID, nil
+	}
+	if listID == "" {
+		return fieldID, nil
+	}
+	for _, id := range field.ListIDs {
+		if id == listID {
+			return fieldID, nil
+		}
+	}
+
+	byName, err := r.fieldsOfList(listID)
+	if err != nil {
+		return "", err
+	}
+	listField, ok := byName[strings.ToLower(strings.TrimSpace(field.Name))]
+	if !ok {
+		return "", fmt.Errorf("campo '%s' não existe na lista %s da task", field.Name, listID)
+	}
+	if listField.Type != field.Type {
+		return "", fmt.Errorf("campo '%s' tem tipo %s na lista %s da task (mapeado como %s)", field.Name, listField.Type, listID, field.Type)
+	}
+	return listField.ID, nil
+}
+
+// field returns the synced definition of a mapped field (nil when unknown)
+func (r *taskListResolver) field(fieldID string) (*repository.CustomField, error) {
+	if field, ok := r.fields[fieldID]; ok {
+		return field, nil
+	}
+	field, err := r.provider.GetCustomFieldByID(fieldID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar campo: %w", err)
+	}
+	r.fields[fieldID] = field
+	return field, nil
+}
+
+// taskList returns the list of the row's task, from the list ID column when filled
+func (r *taskListResolver) taskList(ctx context.Context, row []string, taskID string) (string, error) {
+	if r.listColumnIndex >= 0 && r.listColumnIndex < len(row) {
+		if listID := strings.TrimSpace(row[r.listColumnIndex]); listID != "" {
+			return listID, nil
+		}
+	}
+	listID, err := r.tasks.list(ctx, taskID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar lista da task: %w", err)
+	}
+	return listID, nil
+}
+
+// fieldsOfList returns the fields of a list indexed by lower-case name
+func (r *taskListResolver) fieldsOfList(listID string) (map[string]repository.CustomField, error) {
+	if byName, ok := r.listFields[listID]; ok {
+		return byName, nil
+	}
+	fields, err := r.provider.GetCustomFieldsByList(listID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar campos da lista: %w", err)
+	}
+	byName := make(map[string]repository.CustomField, len(fields))
+	for _, f := range fields {
+		byName[strings.ToLower(strings.TrimSpace(f.Name))] = f
+	}
+	r.listFields[listID] = byName
+	return byName, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// fakeListFields serve os campos sincronizados em memória, como a tabela custom_fields
+type fakeListFields map[string]repository.CustomField
+
+func (f fakeListFields) GetCustomFieldByID(fieldID string) (*repository.CustomField, error) {
+	field, ok := f[fieldID]
+	if !ok {
+		return nil, nil
+	}
+	return &field, nil
+}
+
+func (f fakeListFields) GetCustomFieldsByList(listID string) ([]repository.CustomField, error) {
+	var fields []repository.CustomField
+	for _, field := range f {
+		for _, id := range field.ListIDs {
+			if id == listID {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// ResolveOptionValue resolve as opções pelo campo informado, como o MetadataService
+func (f fakeListFields) ResolveOptionValue(fieldID, fieldType, value string) (string, error) {
+	field, ok := f[fieldID]
+	if !ok {
+		return value, nil
+	}
+	return resolveOptionNames(buildOptionMap(field.Options), fieldType, value)
+}
+
+// statusField cria um campo "Status" de uma lista com as opções informadas (nome -> ID)
+func statusField(id, listID string, options map[string]string) repository.CustomField {
+	list := make([]interface{}, 0, len(options))
+	for name, optionID := range options {
+		list = append(list, map[string]interface{}{"id": optionID, "name": name})
+	}
+	return repository.CustomField{
+		ID:      id,
+		Name:    "Status",
+		Type:    "drop_down",
+		ListIDs: []string{listID},
+		Options: map[string]interface{}{"options": list},
+	}
+}
+
+// multiListServer simula o ClickUp com as tasks T1 (lista A) e T2 (lista B) e guarda
+// os valores enviados por caminho, além de quantas vezes cada task foi buscada
+func multiListServer(t *testing.T) (*httptest.Server, map[string]string, map[string]int) {
+	var mu sync.Mutex
+	written := make(map[string]string)
+	fetched := make(map[string]int)
+	taskLists := map[string]string{"T1": "list-a", "T2": "list-b"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "task" {
+			fetched[parts[1]]++
+			if _, ok := taskLists[parts[1]]; !ok {
+				http.Error(w, `{"err": "Task not found", "ECODE": "ITEM_013"}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":           parts[1],
+				"list":         map[string]string{"id": taskLists[parts[1]]},
+				"date_updated": "0",
+			})
+			return
+		}
+		var body struct {
+			Value interface{} `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		value, _ := json.Marshal(body.Value)
+		written[r.URL.Path] = string(value)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, written, fetched
+}
+
+// TestProcessFileResolvesOptionsPerTaskList atualiza tasks de duas listas em que o
+// campo "Status" tem opções diferentes: cada valor é resolvido pelo campo da lista da
+// task, em vez de falhar (ou gravar a opção errada) com o campo mapeado da lista A
+func TestProcessFileResolvesOptionsPerTaskList(t *testing.T) {
+	fields := fakeListFields{
+		"f-status-a": statusField("f-status-a", "list-a", map[string]string{"Aberto": "opt-a1", "Fechado": "opt-a2"}),
+		"f-status-b": statusField("f-status-b", "list-b", map[string]string{"Aberto": "opt-b1", "Em revisão": "opt-b2"}),
+	}
+
+	tests := []struct {
+		name        string
+		csv         string
+		mapping     map[string]string
+		options     repository.JobOptions
+		wantFetches int
+	}{
+		{
+			name:        "lista obtida da task",
+			csv:         "id task,Status\nT1,Fechado\nT2,Em revisão\nT2,Aberto\n",
+			mapping:     map[string]string{"id task": "task_id", "Status": "f-status-a"},
+			wantFetches: 2, // uma vez por task
+		},
+		{
+			name:        "coluna de lista",
+			csv:         "id task,lista,Status\nT1,list-a,Fechado\nT2,list-b,Em revisão\nT2,list-b,Aberto\n",
+			mapping:     map[string]string{"id task": "task_id", "lista": "list_id", "Status": "f-status-a"},
+			wantFetches: 0,
+		},
+		{
+			name:    "verificação de conflito lê a mesma task",
+			csv:     "id task,Status\nT1,Fechado\nT2,Em revisão\nT2,Aberto\n",
+			mapping: map[string]string{"id task": "task_id", "Status": "f-status-a"},
+			options: repository.JobOptions{
+				ConflictCheck: &repository.ConflictCheck{Since: time.Now()},
+			},
+			wantFetches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, written, fetched := multiListServer(t)
+
+			uploadService := NewUploadService(t.TempDir(), 1024*1024)
+			upload, err := uploadService.ProcessFile("listas.csv", strings.NewReader(tt.csv), int64(len(tt.csv)))
+			if err != nil {
+				t.Fatalf("ProcessFile: %v", err)
+			}
+
+			svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+			svc.SetOptionResolver(fields)
+			svc.SetListFieldProvider(fields)
+
+			job := &repository.UpdateJob{
+				ID:       7,
+				UserID:   "user-1",
+				FilePath: upload.TempPath,
+				Mapping:  tt.mapping,
+				Options:  tt.options,
+			}
+			// As duas linhas da T2 gravam o mesmo campo: vale a última
+			job.Options.DuplicateResolution = DuplicateLastWins
+			clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+			fieldTypes := map[string]string{"f-status-a": "drop_down", "f-status-b": "drop_down"}
+
+			result, err := svc.processFile(context.Background(), clickupClient, job, fieldTypes, 6000)
+			if err != nil {
+				t.Fatalf("processFile: %v", err)
+			}
+			if result.ErrorCount != 0 {
+				t.Fatalf("erros inesperados: %+v", result.Errors)
+			}
+
+			want := map[string]string{
+				"/task/T1/field/f-status-a": `"opt-a2"`,
+				"/task/T2/field/f-status-b": `"opt-b1"`,
+			}
+			if len(written) != len(want) {
+				t.Errorf("gravações = %v, esperado %v", written, want)
+			}
+			for path, value := range want {
+				if written[path] != value {
+					t.Errorf("%s = %s, esperado %s", path, written[path], value)
+				}
+			}
+
+			total := 0
+			for taskID, n := range fetched {
+				if n != 1 {
+					t.Errorf("task %s buscada %d vezes", taskID, n)
+				}
+				total += n
+			}
+			if total != tt.wantFetches {
+				t.Errorf("%d tasks buscadas, esperado %d", total, tt.wantFetches)
+			}
+		})
+	}
+}
+
+// TestProcessFileKeepsMappedFieldWhenTaskListUnknown: sem conseguir ler a task, a linha
+// grava o campo mapeado em vez de falhar
+func TestProcessFileKeepsMappedFieldWhenTaskListUnknown(t *testing.T) {
+	fields := fakeListFields{
+		"f-status-a": statusField("f-status-a", "list-a", map[string]string{"Aberto": "opt-a1", "Fechado": "opt-a2"}),
+	}
+	server, written, _ := multiListServer(t)
+
+	csv := "id task,Status\nT9,Fechado\n" // T9 não é encontrada pelo GET
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("listas.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	svc.SetOptionResolver(fields)
+	svc.SetListFieldProvider(fields)
+
+	job := &repository.UpdateJob{
+		ID:       8,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Status": "f-status-a"},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{BaseURL: server.URL})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-status-a": "drop_down"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+	if result.SuccessCount != 1 || result.ErrorCount != 0 {
+		t.Fatalf("a falha ao ler a task não deveria falhar a linha: %+v", result.Errors)
+	}
+	if written["/task/T9/field/f-status-a"] != `"opt-a2"` {
+		t.Errorf("gravações = %v, esperado o campo mapeado", written)
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,11 +18,29 @@ import (
 
 // TaskUpdateService handles batch processing of task updates
 type TaskUpdateService struct {
-	uploadService  *UploadService
-	metadataRepo   *repository.MetadataRepository
-	configRepo     *repository.ConfigRepository
-	queueRepo      *repository.QueueRepository
-	wsHub          *websocket.Hub
+	uploadService   *UploadService
+	metadataRepo    *repository.MetadataRepository
+	configRepo      *repository.ConfigRepository
+	queueRepo       *repository.QueueRepository
+	wsHub           *websocket.Hub
+	optionResolver  OptionResolver
+	defaultTimezone string
+	defaultLocale   string
+	clientOptions   client.ClientOptions
+	tokenResolver   TokenResolver
+	historyRecorder HistoryRecorder
+	listFields      ListFieldProvider
+	etas            jobETAs
+}
+
+// TokenResolver returns a user's decrypted ClickUp token for a label ("" = default token)
+type TokenResolver interface {
+	GetUserToken(ctx context.Context, userID, label string) (string, error)
+}
+
+// OptionResolver resolves dropdown/label option names to ClickUp option IDs
+type OptionResolver interface {
+	ResolveOptionValue(fieldID, fieldType, value string) (string, error)
 }
 
 // TaskUpdateResult represents the result of a single task update
@@ -28,6 +48,9 @@ type TaskUpdateResult struct {
 	TaskID  string `json:"task_id"`
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
+	// Retryable marks failures ClickUp may accept on a later run (rate limit, 5xx,
+	// network); the others need the value, mapping or token fixed
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 // BatchUpdateResult represents the result of a batch update operation
@@ -36,7 +59,23 @@ type BatchUpdateResult struct {
 	ProcessedRows int                `json:"processed_rows"`
 	SuccessCount  int                `json:"success_count"`
 	ErrorCount    int                `json:"error_count"`
+	// FilteredCount counts rows skipped by the job's row filter (included in ProcessedRows)
+	FilteredCount int                `json:"filtered_count,omitempty"`
+	// ConflictCount counts rows not written because their task changed in ClickUp after
+	// the spreadsheet was generated (included in ProcessedRows, listed in Conflicts)
+	ConflictCount int                `json:"conflict_count,omitempty"`
+	Conflicts     []TaskUpdateResult `json:"conflicts,omitempty"`
 	Errors        []TaskUpdateResult `json:"errors,omitempty"`
+	// Fields counts the outcome per mapped column
+	Fields map[string]*FieldUpdateStats `json:"fields,omitempty"`
+	// DryRun marks a run that wrote nothing: successes are values that would be sent
+	DryRun bool `json:"dry_run,omitempty"`
+	// Diffs are the field changes previewed by a dry run with preview_diff (first
+	// maxFieldDiffs; DiffCount counts them all)
+	DiffCount int         `json:"diff_count,omitempty"`
+	Diffs     []FieldDiff `json:"diffs,omitempty"`
+	// CommentErrors counts tasks updated without their comment_on_update comment
+	CommentErrors int `json:"comment_errors,omitempty"`
 }
 
 // NewTaskUpdateService creates a new task update service
@@ -56,6 +95,44 @@ func NewTaskUpdateService(
 	}
 }
 
+// SetOptionResolver sets the resolver used for drop_down and labels values
+func (s *TaskUpdateService) SetOptionResolver(resolver OptionResolver) {
+	s.optionResolver = resolver
+}
+
+// SetDefaultTimezone sets the timezone used for date values when the job doesn't specify one
+func (s *TaskUpdateService) SetDefaultTimezone(timezone string) {
+	s.defaultTimezone = timezone
+}
+
+// SetDefaultLocale sets the locale used for numbers and dates when the job doesn't specify one
+func (s *TaskUpdateService) SetDefaultLocale(locale string) {
+	s.defaultLocale = locale
+}
+
+// jobLocale returns the job's locale, or the default one when the job doesn't specify it
+func (s *TaskUpdateService) jobLocale(job *repository.UpdateJob) string {
+	if job.Options.Locale != "" {
+		return job.Options.Locale
+	}
+	return s.defaultLocale
+}
+
+// SetTokenResolver sets how the job's ClickUp token is looked up (job option token_label)
+func (s *TaskUpdateService) SetTokenResolver(resolver TokenResolver) {
+	s.tokenResolver = resolver
+}
+
+// SetClientOptions sets the timeout and connection pool of the ClickUp clients created per job
+func (s *TaskUpdateService) SetClientOptions(opts client.ClientOptions) {
+	s.clientOptions = opts
+}
+
+// EstimatedSecondsRemaining returns the latest time estimate of a job being processed
+func (s *TaskUpdateService) EstimatedSecondsRemaining(jobID int) (int, bool) {
+	return s.etas.get(jobID)
+}
+
 // ProcessJob processes a job from the queue
 // This is the main entry point called by QueueService
 func (s *TaskUpdateService) ProcessJob(ctx context.Context, job *repository.UpdateJob) error {
@@ -72,15 +149,45 @@ func (s *TaskUpdateService) ProcessJob(ctx context.Context, job *repository.Upda
 	if err != nil {
 		return fmt.Errorf("erro ao buscar configuração do usuário: %w", err)
 	}
-	if config == nil {
-		return fmt.Errorf("configuração do usuário não encontrada")
+	rateLimit := client.RequestsPerMinute
+	if config != nil && config.RateLimitPerMinute > 0 {
+		rateLimit = config.RateLimitPerMinute
 	}
-	if config.ClickUpTokenEncrypted == "" {
-		return fmt.Errorf("token do ClickUp não configurado")
+
+	// Resolve the token chosen for the job (default token when no label is set)
+	var token string
+	if s.tokenResolver != nil {
+		token, err = s.tokenResolver.GetUserToken(WithTokenPurpose(ctx, TokenPurposeTaskUpdate), job.UserID, job.Options.TokenLabel)
+		if err != nil {
+			return fmt.Errorf("token do ClickUp não configurado: %w", err)
+		}
+	} else {
+		if config == nil {
+			return fmt.Errorf("configuração do usuário não encontrada")
+		}
+		if config.ClickUpTokenEncrypted == "" {
+			return fmt.Errorf("token do ClickUp não configurado")
+		}
+		token = config.ClickUpTokenEncrypted
 	}
 
 	// Create ClickUp client with user's token
-	clickupClient := client.NewClient(config.ClickUpTokenEncrypted)
+	clickupClient := client.NewClientWithOptions(token, s.clientOptions)
+	if locale := s.jobLocale(job); locale != "" {
+		clickupClient.SetLocale(locale)
+	}
+	if job.Options.CustomTaskIDs {
+		clickupClient.SetCustomTaskIDs(job.Options.TeamID)
+	}
+	timezone := job.Options.Timezone
+	if timezone == "" {
+		timezone = s.defaultTimezone
+	}
+	if timezone != "" {
+		if err := clickupClient.SetTimezone(timezone); err != nil {
+			return err
+		}
+	}
 
 	// Get custom fields for type information
 	customFields, err := s.metadataRepo.GetCustomFields()
@@ -94,20 +201,7 @@ func (s *TaskUpdateService) ProcessJob(ctx context.Context, job *repository.Upda
 		fieldTypeMap[field.ID] = field.Type
 	}
 
-	// Read file data
-	columns, data, err := s.uploadService.GetFileData(job.FilePath)
-	if err != nil {
-		return fmt.Errorf("erro ao ler arquivo: %w", err)
-	}
-
-	// Find task ID column index
-	taskIDColumnIndex := s.findTaskIDColumnIndex(columns, job.Mapping)
-	if taskIDColumnIndex < 0 {
-		return fmt.Errorf("coluna 'id task' não encontrada no mapeamento")
-	}
-
-	// Process rows with rate limiting
-	result, err := s.processBatch(ctx, clickupClient, job, columns, data, taskIDColumnIndex, fieldTypeMap, config.RateLimitPerMinute)
+	result, err := s.processFile(ctx, clickupClient, job, fieldTypeMap, rateLimit)
 	if err != nil {
 		return err
 	}
@@ -128,24 +222,82 @@ func (s *TaskUpdateService) ProcessJob(ctx context.Context, job *repository.Upda
 	return nil
 }
 
+// processFile applies the job's file to ClickUp and records the job summary in the
+// operation history once the rows were processed (or processing stopped)
+func (s *TaskUpdateService) processFile(
+	ctx context.Context,
+	clickupClient *client.Client,
+	job *repository.UpdateJob,
+	fieldTypeMap map[string]string,
+	rateLimit int,
+) (*BatchUpdateResult, error) {
+	log := logger.Get(ctx)
 
-// findTaskIDColumnIndex finds the index of the task ID column
-func (s *TaskUpdateService) findTaskIDColumnIndex(columns []string, mapping map[string]string) int {
-	// Look for "id task" or similar column names in the mapping
-	taskIDKeys := []string{"id task", "id_task", "task_id", "taskid", "id"}
-	
-	for i, col := range columns {
-		colLower := strings.ToLower(strings.TrimSpace(col))
-		for _, key := range taskIDKeys {
-			if colLower == key {
-				return i
-			}
+	// Read the header; rows are streamed from the file so large uploads stay out of memory
+	columns, err := s.uploadService.GetColumns(job.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo: %w", err)
+	}
+	rows := func(fn func(row []string) error) error {
+		return s.uploadService.StreamRows(job.FilePath, fn)
+	}
+
+	// Mappings saved with normalization refer to columns by name variants; use the
+	// header's own names from here on
+	if job.Options.NormalizeColumns {
+		resolved := *job
+		resolved.Mapping, resolved.Options.Defaults = resolveJobColumns(columns, job.Mapping, job.Options.Defaults)
+		job = &resolved
+	}
+
+	// Find task ID column index
+	taskIDColumnIndex := s.findTaskIDColumnIndex(columns, job.Mapping)
+	if taskIDColumnIndex < 0 {
+		return nil, fmt.Errorf("coluna 'id task' não encontrada no mapeamento")
+	}
+
+	// Rows not matching the job's filter are skipped entirely
+	filter, err := compileRowFilter(job.Options.RowFilter, columns, job.Options.NormalizeColumns, s.jobLocale(job))
+	if err != nil {
+		return nil, err
+	}
+
+	// Detect rows targeting the same task/field before sending anything (first pass)
+	planner := newDuplicatePlanner(columns, taskIDColumnIndex, job.Mapping, job.Options.Defaults)
+	if err := rows(func(row []string) error {
+		if !filter.matches(row) {
+			planner.skipRow()
+			return nil
 		}
+		planner.addRow(row)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo: %w", err)
+	}
+	duplicates := planner.plan(job.Options.DuplicateResolution)
+	if len(duplicates) > 0 {
+		log.Info().
+			Int("job_id", job.ID).
+			Int("rows", len(duplicates)).
+			Str("resolution", job.Options.DuplicateResolution).
+			Msg("Alvos duplicados encontrados no arquivo")
 	}
 	
-	// Also check if any column is mapped to a special "task_id" field
+	// Process rows with rate limiting
+	started := time.Now()
+	result, err := s.processBatch(ctx, clickupClient, job, columns, rows, planner.rows(), taskIDColumnIndex, fieldTypeMap, rateLimit, duplicates, filter)
+	if result != nil {
+		s.recordJobSummary(ctx, job, buildJobSummary(job.ID, result, started, time.Now(), err))
+	}
+	return result, err
+}
+
+
+// findTaskIDColumnIndex finds the index of the task ID column
+func (s *TaskUpdateService) findTaskIDColumnIndex(columns []string, mapping map[string]string) int {
+	// The column marked as task ID in the mapping wins (it may hold custom task IDs)
 	for colName, fieldID := range mapping {
-		if strings.ToLower(fieldID) == "task_id" || strings.ToLower(fieldID) == "id_task" {
+		if isTaskIDField(fieldID) {
 			for i, col := range columns {
 				if col == colName {
 					return i
@@ -154,19 +306,37 @@ func (s *TaskUpdateService) findTaskIDColumnIndex(columns []string, mapping map[
 		}
 	}
 	
+	// Otherwise look for "id task" or similar column names
+	taskIDKeys := []string{"id task", "id_task", "task_id", "taskid", "id", "custom id", "custom_id", "custom task id", "id personalizado"}
+	
+	for i, col := range columns {
+		colLower := strings.ToLower(strings.TrimSpace(col))
+		for _, key := range taskIDKeys {
+			if colLower == key {
+				return i
+			}
+		}
+	}
+	
 	return -1
 }
 
+// rowSource iterates the data rows of a job's file, calling fn for each one
+type rowSource func(fn func(row []string) error) error
+
 // processBatch processes all rows in the batch with rate limiting
 func (s *TaskUpdateService) processBatch(
 	ctx context.Context,
 	clickupClient *client.Client,
 	job *repository.UpdateJob,
 	columns []string,
-	data [][]string,
+	rows rowSource,
+	totalRows int,
 	taskIDColumnIndex int,
 	fieldTypeMap map[string]string,
 	rateLimitPerMinute int,
+	duplicates duplicatePlan,
+	filter *rowFilter,
 ) (*BatchUpdateResult, error) {
 	log := logger.Get(ctx)
 	
@@ -174,8 +344,9 @@ func (s *TaskUpdateService) processBatch(
 	limiter := rate.NewLimiter(rate.Every(time.Minute/time.Duration(rateLimitPerMinute)), 50)
 	
 	result := &BatchUpdateResult{
-		TotalRows: len(data),
+		TotalRows: totalRows,
 		Errors:    make([]TaskUpdateResult, 0),
+		DryRun:    job.Options.DryRun,
 	}
 	
 	errorDetails := make([]string, 0)
@@ -186,10 +357,50 @@ func (s *TaskUpdateService) processBatch(
 		columnIndexMap[col] = i
 	}
 	
-	for rowIndex, row := range data {
+	// Mapped field columns, in a stable order so the fields written before an error are predictable
+	fieldColumns := make([]string, 0, len(job.Mapping))
+	for columnName, fieldID := range job.Mapping {
+		if !isRowMarker(fieldID) {
+			fieldColumns = append(fieldColumns, columnName)
+		}
+	}
+	sort.Strings(fieldColumns)
+	
+	// Estimate the remaining time from the throughput, capped by the rate limit
+	progressLog := logger.Sampled(log)
+	eta := newETAEstimator(rateLimitPerMinute, len(fieldColumns))
+	eta.observe(time.Now(), 0)
+	defer s.etas.remove(job.ID)
+	
+	// Tasks read for the checks below are shared between them
+	tasks := newJobTasks(clickupClient)
+	
+	// Dropdown/label fields are resolved against the list of each task
+	lists := s.newTaskListResolver(tasks, columns, job.Mapping)
+	
+	// Users fields accept member emails and usernames besides IDs
+	assignees := newAssigneeResolver(clickupClient, job.Options.TeamID)
+	
+	// Optimistic concurrency: tasks changed after the spreadsheet was generated are left alone
+	conflicts := newConflictChecker(tasks, job.Options.ConflictCheck)
+	
+	// Dry runs may read the current values to preview each change (opt-in: one read per task)
+	diffs := newDiffCollector(tasks, job.Options.DryRun, job.Options.PreviewDiff)
+	
+	rowIndex := -1
+	err := rows(func(row []string) error {
+		rowIndex++
+		
 		// Check context cancellation
 		if ctx.Err() != nil {
-			return result, ctx.Err()
+			return ctx.Err()
+		}
+		
+		// Rows not matching the filter count as processed, not as success or error
+		if !filter.matches(row) {
+			result.FilteredCount++
+			result.ProcessedRows++
+			return nil
 		}
 		
 		// Get task ID from row
@@ -203,7 +414,7 @@ func (s *TaskUpdateService) processBatch(
 			})
 			result.ErrorCount++
 			result.ProcessedRows++
-			continue
+			return nil
 		}
 		
 		taskID := strings.TrimSpace(row[taskIDColumnIndex])
@@ -217,35 +428,75 @@ func (s *TaskUpdateService) processBatch(
 			})
 			result.ErrorCount++
 			result.ProcessedRows++
-			continue
+			return nil
+		}
+		
+		if conflicts != nil {
+			conflict, err := conflicts.check(ctx, taskID)
+			if err != nil {
+				errorMsg := fmt.Sprintf("linha %d, task %s: %v", rowIndex+1, taskID, err)
+				errorDetails = append(errorDetails, errorMsg)
+				result.Errors = append(result.Errors, TaskUpdateResult{
+					TaskID:    taskID,
+					Success:   false,
+					Error:     errorMsg,
+					Retryable: client.IsRetryable(err),
+				})
+				result.ErrorCount++
+				result.ProcessedRows++
+				return nil
+			}
+			if conflict != "" {
+				conflictMsg := fmt.Sprintf("linha %d, task %s: conflito, não gravada: %s", rowIndex+1, taskID, conflict)
+				errorDetails = append(errorDetails, conflictMsg)
+				result.Conflicts = append(result.Conflicts, TaskUpdateResult{
+					TaskID:  taskID,
+					Success: false,
+					Error:   conflictMsg,
+				})
+				result.ConflictCount++
+				result.ProcessedRows++
+				return nil
+			}
 		}
 		
 		// Process each mapped field for this row
 		rowSuccess := true
+		rowRetryable := false
 		var rowError string
+		var written []fieldChange // for the comment_on_update comment
 		
-		for columnName, fieldID := range job.Mapping {
-			// Skip task_id column mapping
-			if strings.ToLower(fieldID) == "task_id" || strings.ToLower(fieldID) == "id_task" {
-				continue
-			}
+		attempted := 0
+		for _, columnName := range fieldColumns {
+			attempted++
+			fieldID := job.Mapping[columnName]
+			stats := result.fieldStats(columnName, fieldID)
 			
-			// Get column index
-			colIndex, exists := columnIndexMap[columnName]
-			if !exists {
+			// Skip writes superseded by (or conflicting with) another row
+			if skip, ok := duplicates.lookup(rowIndex, fieldID); ok {
+				if skip.isError {
+					rowSuccess = false
+					rowError = skip.message
+					stats.Errors++
+				} else {
+					errorDetails = append(errorDetails, skip.message)
+					stats.Skipped++
+				}
 				continue
 			}
 			
-			// Get value from row
-			if colIndex >= len(row) {
-				continue
+			// Get value from row, applying the column default when configured
+			colIndex, exists := columnIndexMap[columnName]
+			var fieldDefault *repository.FieldDefault
+			if def, ok := job.Options.Defaults[columnName]; ok {
+				fieldDefault = &def
 			}
-			value := row[colIndex]
-			
-			// Skip empty values
-			if strings.TrimSpace(value) == "" {
+			value, ok := resolveCellValue(row, colIndex, exists, fieldDefault)
+			if !ok {
+				stats.Skipped++
 				continue
 			}
+			cellValue := value
 			
 			// Get field type
 			fieldType := fieldTypeMap[fieldID]
@@ -253,23 +504,102 @@ func (s *TaskUpdateService) processBatch(
 				fieldType = "text" // Default to text if type unknown
 			}
 			
+			// Options belong to the field of the task's list, which may differ from the mapped one
+			targetFieldID := fieldID
+			if lists != nil && isOptionFieldType(fieldType) {
+				listFieldID, err := lists.fieldFor(ctx, row, taskID, fieldID)
+				if err != nil {
+					rowSuccess = false
+					rowError = fmt.Sprintf("linha %d, task %s, campo %s: %v", rowIndex+1, taskID, fieldID, err)
+					stats.Errors++
+					break
+				}
+				targetFieldID = listFieldID
+			}
+			
+			// Resolve option names to option IDs
+			if s.optionResolver != nil {
+				resolved, err := s.optionResolver.ResolveOptionValue(targetFieldID, fieldType, value)
+				if err != nil {
+					rowSuccess = false
+					rowError = fmt.Sprintf("linha %d, task %s, campo %s: %v", rowIndex+1, taskID, fieldID, err)
+					stats.Errors++
+					break
+				}
+				value = resolved
+			}
+			
+			if fieldType == "users" {
+				resolved, err := assignees.resolve(ctx, value)
+				if err != nil {
+					rowSuccess = false
+					rowError = fmt.Sprintf("linha %d, task %s, campo %s: %v", rowIndex+1, taskID, fieldID, err)
+					stats.Errors++
+					break
+				}
+				value = resolved
+			}
+			
+			// Dry run: the value is resolved but not sent
+			if job.Options.DryRun {
+				if diffs != nil {
+					diff, err := diffs.diff(ctx, taskID, columnName, targetFieldID, clickupClient.TransformValue(value, fieldType))
+					if err != nil {
+						rowSuccess = false
+						rowRetryable = client.IsRetryable(err)
+						rowError = fmt.Sprintf("linha %d, task %s, campo %s: %v", rowIndex+1, taskID, fieldID, err)
+						stats.Errors++
+						break
+					}
+					if diff != nil {
+						result.addDiff(*diff)
+					}
+				}
+				stats.Success++
+				continue
+			}
+			
 			// Wait for rate limiter
 			if err := limiter.Wait(ctx); err != nil {
-				return result, fmt.Errorf("rate limiter: %w", err)
+				return fmt.Errorf("rate limiter: %w", err)
 			}
 			
 			// Update the custom field
-			err := clickupClient.SetCustomFieldValueWithRetry(ctx, taskID, fieldID, value, fieldType)
+			err := clickupClient.SetCustomFieldValueWithRetry(ctx, taskID, targetFieldID, value, fieldType)
 			if err != nil {
 				rowSuccess = false
+				rowRetryable = client.IsRetryable(err)
 				rowError = fmt.Sprintf("linha %d, task %s, campo %s: %v", rowIndex+1, taskID, fieldID, err)
-				log.Warn().
+				stats.Errors++
+				event := log.Warn().
 					Str("task_id", taskID).
 					Str("field_id", fieldID).
-					Err(err).
-					Msg("Erro ao atualizar campo")
+					Bool("retryable", rowRetryable).
+					Err(err)
+				var clickupErr *client.ClickUpError
+				if errors.As(err, &clickupErr) {
+					event = event.Int("status", clickupErr.StatusCode).Str("clickup_code", clickupErr.Code)
+				}
+				event.Msg("Erro ao atualizar campo")
 				break // Stop processing this row on first error
 			}
+			stats.Success++
+			if job.Options.CommentOnUpdate {
+				written = append(written, fieldChange{Column: columnName, Value: cellValue})
+			}
+		}
+		
+		// Fields left after the error that stopped the row count as skipped
+		for _, columnName := range fieldColumns[attempted:] {
+			result.fieldStats(columnName, job.Mapping[columnName]).Skipped++
+		}
+		
+		// Note the fields written (even if a later one failed) in a comment on the task
+		if len(written) > 0 {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("rate limiter: %w", err)
+			}
+			commentUpdate(ctx, clickupClient, job, taskID, written, result)
 		}
 		
 		result.ProcessedRows++
@@ -280,20 +610,21 @@ func (s *TaskUpdateService) processBatch(
 			result.ErrorCount++
 			errorDetails = append(errorDetails, rowError)
 			result.Errors = append(result.Errors, TaskUpdateResult{
-				TaskID:  taskID,
-				Success: false,
-				Error:   rowError,
+				TaskID:    taskID,
+				Success:   false,
+				Error:     rowError,
+				Retryable: rowRetryable,
 			})
 		}
 		
 		// Update job progress periodically (every 10 rows or on last row)
 		if result.ProcessedRows%10 == 0 || result.ProcessedRows == result.TotalRows {
-			s.updateJobProgress(job.ID, job.UserID, result, errorDetails)
+			s.updateJobProgress(job.ID, job.UserID, result, errorDetails, eta)
 		}
 		
-		// Log progress periodically
+		// Log progress periodically (sampled further on big jobs)
 		if result.ProcessedRows%100 == 0 {
-			log.Info().
+			progressLog.Info().
 				Int("job_id", job.ID).
 				Int("processed", result.ProcessedRows).
 				Int("total", result.TotalRows).
@@ -301,21 +632,63 @@ func (s *TaskUpdateService) processBatch(
 				Int("errors", result.ErrorCount).
 				Msg("Progresso do processamento")
 		}
+		
+		return nil
+	})
+	if err != nil {
+		return result, err
 	}
 	
 	// Final progress update
-	s.updateJobProgress(job.ID, job.UserID, result, errorDetails)
+	s.updateJobProgress(job.ID, job.UserID, result, errorDetails, eta)
 	
 	return result, nil
 }
 
+// resolveCellValue returns the value to send for a mapped column, applying its default.
+// A column absent from the file is "missing"; a blank or truncated cell is "empty".
+// Without an applicable default, missing and empty values are skipped (ok == false).
+func resolveCellValue(row []string, colIndex int, columnExists bool, def *repository.FieldDefault) (string, bool) {
+	value := ""
+	if columnExists && colIndex < len(row) {
+		value = row[colIndex]
+	}
+	isEmpty := columnExists && strings.TrimSpace(value) == ""
+
+	if def != nil {
+		switch def.When {
+		case DefaultWhenAlways:
+			return def.Value, true
+		case DefaultWhenMissing:
+			if !columnExists {
+				return def.Value, true
+			}
+		case DefaultWhenEmpty:
+			if isEmpty {
+				return def.Value, true
+			}
+		}
+	}
+
+	if !columnExists || isEmpty {
+		return "", false
+	}
+	return value, true
+}
+
 // updateJobProgress updates job progress in database and sends WebSocket notification
-func (s *TaskUpdateService) updateJobProgress(jobID int, userID string, result *BatchUpdateResult, errorDetails []string) {
+func (s *TaskUpdateService) updateJobProgress(jobID int, userID string, result *BatchUpdateResult, errorDetails []string, eta *etaEstimator) {
 	// Update database
 	if s.queueRepo != nil {
 		s.queueRepo.UpdateJobProgress(jobID, result.ProcessedRows, result.SuccessCount, result.ErrorCount, errorDetails)
 	}
 	
+	eta.observe(time.Now(), result.ProcessedRows)
+	remaining, hasETA := eta.estimate(result.TotalRows)
+	if hasETA {
+		s.etas.set(jobID, remaining)
+	}
+	
 	// Send WebSocket notification
 	if s.wsHub != nil {
 		progress := websocket.ProgressUpdate{
@@ -327,6 +700,9 @@ func (s *TaskUpdateService) updateJobProgress(jobID int, userID string, result *
 			ErrorCount:    result.ErrorCount,
 			Message:       fmt.Sprintf("Processando... %d/%d", result.ProcessedRows, result.TotalRows),
 		}
+		if hasETA {
+			progress.EstimatedSecondsRemaining = remaining
+		}
 		
 		if result.ProcessedRows == result.TotalRows {
 			progress.Status = "completed"
@@ -1,11 +1,16 @@
 package service
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cleberrangel/clickup-excel-api/internal/client"
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -385,3 +390,334 @@ func joinStrings(strs []string, sep string) string {
 	}
 	return result
 }
+
+// TestDefaultValueResolution verifies how column defaults are applied to cells
+// and validated against each custom field type.
+func TestDefaultValueResolution(t *testing.T) {
+	row := []string{"abc123", "  ", "42"}
+
+	resolveTests := []struct {
+		name      string
+		colIndex  int
+		colExists bool
+		def       *repository.FieldDefault
+		want      string
+		wantOK    bool
+	}{
+		{"value without default", 2, true, nil, "42", true},
+		{"empty cell without default is skipped", 1, true, nil, "", false},
+		{"missing column without default is skipped", 0, false, nil, "", false},
+		{"empty default fills blank cell", 1, true, &repository.FieldDefault{Value: "Open", When: DefaultWhenEmpty}, "Open", true},
+		{"empty default fills truncated row", 5, true, &repository.FieldDefault{Value: "Open", When: DefaultWhenEmpty}, "Open", true},
+		{"empty default keeps filled cell", 2, true, &repository.FieldDefault{Value: "0", When: DefaultWhenEmpty}, "42", true},
+		{"empty default ignores missing column", 0, false, &repository.FieldDefault{Value: "Open", When: DefaultWhenEmpty}, "", false},
+		{"missing default fills absent column", 0, false, &repository.FieldDefault{Value: "Open", When: DefaultWhenMissing}, "Open", true},
+		{"missing default keeps blank cell skipped", 1, true, &repository.FieldDefault{Value: "Open", When: DefaultWhenMissing}, "", false},
+		{"always default overrides value", 2, true, &repository.FieldDefault{Value: "7", When: DefaultWhenAlways}, "7", true},
+		{"always default fills absent column", 0, false, &repository.FieldDefault{Value: "7", When: DefaultWhenAlways}, "7", true},
+	}
+
+	for _, tt := range resolveTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveCellValue(row, tt.colIndex, tt.colExists, tt.def)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveCellValue() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	validateTests := []struct {
+		fieldType string
+		value     string
+		wantErr   bool
+	}{
+		{"text", "Open", false},
+		{"drop_down", "High", false},
+		{"number", "12.5", false},
+		{"number", "abc", true},
+		{"currency", "-10", false},
+		{"checkbox", "sim", false},
+		{"checkbox", "false", false},
+		{"checkbox", "talvez", true},
+		{"date", "2024-12-31", false},
+		{"date", "31/12/2024", false},
+		{"date", "amanhã", true},
+		{"labels", "a, b", false},
+		{"labels", " , ", true},
+		{"users", "123", false},
+	}
+
+	for _, tt := range validateTests {
+		t.Run(tt.fieldType+"/"+tt.value, func(t *testing.T) {
+			err := client.ValidateFieldValue(tt.value, tt.fieldType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldValue(%q, %q) error = %v, wantErr %v", tt.value, tt.fieldType, err, tt.wantErr)
+			}
+		})
+	}
+
+	modeTests := []struct {
+		name    string
+		mapping ColumnMapping
+		wantErr bool
+	}{
+		{"no default", ColumnMapping{Column: "Status"}, false},
+		{"default with implicit mode", ColumnMapping{Column: "Status", DefaultValue: "Open"}, false},
+		{"default with explicit mode", ColumnMapping{Column: "Status", DefaultValue: "Open", ApplyDefaultWhen: DefaultWhenAlways}, false},
+		{"mode without default", ColumnMapping{Column: "Status", ApplyDefaultWhen: DefaultWhenEmpty}, true},
+		{"unknown mode", ColumnMapping{Column: "Status", DefaultValue: "Open", ApplyDefaultWhen: "sometimes"}, true},
+	}
+
+	for _, tt := range modeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			errMsg := validateDefaultMode(tt.mapping)
+			if (errMsg != "") != tt.wantErr {
+				t.Errorf("validateDefaultMode() = %q, wantErr %v", errMsg, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLocaleAwareTransformation verifies number and date parsing for pt-BR and en-US
+func TestLocaleAwareTransformation(t *testing.T) {
+	date := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).UnixMilli()
+	}
+
+	tests := []struct {
+		name      string
+		locale    string
+		fieldType string
+		value     string
+		want      interface{}
+	}{
+		{"pt-BR decimal with thousands", client.LocalePtBR, "number", "1.234,56", 1234.56},
+		{"pt-BR integer with thousands", client.LocalePtBR, "number", "1.234.567", int64(1234567)},
+		{"pt-BR plain decimal comma", client.LocalePtBR, "number", "0,5", 0.5},
+		{"pt-BR raw decimal point", client.LocalePtBR, "number", "12.5", 12.5},
+		{"pt-BR negative", client.LocalePtBR, "number", "-1.234,56", -1234.56},
+		{"pt-BR currency prefix", client.LocalePtBR, "currency", "R$ 1.234,56", 1234.56},
+		{"pt-BR negative currency", client.LocalePtBR, "currency", "-R$ 10,00", -10.0},
+		{"pt-BR accounting negative", client.LocalePtBR, "currency", "(1.000,00)", -1000.0},
+		{"pt-BR percentage", client.LocalePtBR, "percentage", "45,5%", 45.5},
+		{"pt-BR invalid", client.LocalePtBR, "number", "1,2,3", 0},
+		{"pt-BR empty", client.LocalePtBR, "number", "", 0},
+		{"en-US decimal with thousands", client.LocaleEnUS, "number", "1,234.56", 1234.56},
+		{"en-US integer with thousands", client.LocaleEnUS, "number", "1,234", int64(1234)},
+		{"en-US negative currency", client.LocaleEnUS, "currency", "-$1,000.50", -1000.5},
+		{"en-US integer", client.LocaleEnUS, "number", "42", int64(42)},
+		{"pt-BR day first", client.LocalePtBR, "date", "31/12/2024", date(2024, 12, 31)},
+		{"pt-BR ambiguous date", client.LocalePtBR, "date", "01/02/2024", date(2024, 2, 1)},
+		{"pt-BR ISO date", client.LocalePtBR, "date", "2024-12-31", date(2024, 12, 31)},
+		{"en-US month first", client.LocaleEnUS, "date", "12/31/2024", date(2024, 12, 31)},
+		{"en-US ambiguous date", client.LocaleEnUS, "date", "01/02/2024", date(2024, 1, 2)},
+		{"en-US falls back to day first", client.LocaleEnUS, "date", "31/12/2024", date(2024, 12, 31)},
+		{"invalid date", client.LocalePtBR, "date", "31/31/2024", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := client.TransformFieldValueWithOptions(tt.value, tt.fieldType, client.TransformOptions{Locale: tt.locale})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TransformFieldValueWithOptions(%q, %q, %q) = %#v, want %#v", tt.value, tt.fieldType, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMappingValidationUsesLocale verifies that sampled and default values are checked in
+// the request locale, or in the service default when the request doesn't give one
+func TestMappingValidationUsesLocale(t *testing.T) {
+	svc := NewMappingService(customFieldList{{ID: "f-valor", Name: "Valor", Type: "number"}})
+	request := func(locale string) *MappingRequest {
+		return &MappingRequest{
+			Title:  "Valores",
+			Locale: locale,
+			Mappings: []ColumnMapping{
+				{Column: "id task", IsTaskID: true},
+				{Column: "Valor", FieldID: "f-valor"},
+			},
+			SampleRows: [][]string{{"t1", "1,234.56"}},
+		}
+	}
+	columns := []string{"id task", "Valor"}
+	warned := func(req *MappingRequest) bool {
+		result, err := svc.ValidateMapping(req, columns)
+		if err != nil {
+			t.Fatalf("ValidateMapping: %v", err)
+		}
+		return len(result.Warnings) > 0
+	}
+
+	if !warned(request("")) {
+		t.Error("pt-BR (padrão) deveria rejeitar 1,234.56")
+	}
+	if warned(request(client.LocaleEnUS)) {
+		t.Error("en-US informado na requisição deveria aceitar 1,234.56")
+	}
+
+	svc.SetDefaultLocale(client.LocaleEnUS)
+	if warned(request("")) {
+		t.Error("en-US configurado como padrão deveria aceitar 1,234.56")
+	}
+
+	result, err := svc.ValidateMapping(request("fr-FR"), columns)
+	if err != nil || result.Valid {
+		t.Errorf("locale inválido deveria invalidar o mapeamento: %+v, %v", result, err)
+	}
+}
+
+// TestTimezoneAwareDates verifies that date-only values become midnight in the job timezone
+// and that values with an explicit offset are kept as-is
+func TestTimezoneAwareDates(t *testing.T) {
+	mustLoad := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("timezone %s indisponível: %v", name, err)
+		}
+		return loc
+	}
+
+	saoPaulo := mustLoad("America/Sao_Paulo")
+	newYork := mustLoad("America/New_York")
+	berlin := mustLoad("Europe/Berlin")
+	tokyo := mustLoad("Asia/Tokyo")
+
+	tests := []struct {
+		name  string
+		loc   *time.Location
+		value string
+		want  int64
+	}{
+		{"Sao Paulo date-only", saoPaulo, "2024-01-15", time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC).UnixMilli()},
+		{"Sao Paulo BR format", saoPaulo, "15/01/2024", time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC).UnixMilli()},
+		// Horário de verão 2018: meia-noite de 04/11 não existe, o relógio pula para 01:00 (-02)
+		{"Sao Paulo DST start", saoPaulo, "2018-11-04", time.Date(2018, 11, 4, 3, 0, 0, 0, time.UTC).UnixMilli()},
+		{"Sao Paulo during DST", saoPaulo, "2019-01-10", time.Date(2019, 1, 10, 2, 0, 0, 0, time.UTC).UnixMilli()},
+		{"New York before DST", newYork, "2024-03-09", time.Date(2024, 3, 9, 5, 0, 0, 0, time.UTC).UnixMilli()},
+		{"New York after DST", newYork, "2024-03-11", time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC).UnixMilli()},
+		{"Berlin summer", berlin, "2024-07-01", time.Date(2024, 6, 30, 22, 0, 0, 0, time.UTC).UnixMilli()},
+		{"Berlin winter", berlin, "2024-12-01", time.Date(2024, 11, 30, 23, 0, 0, 0, time.UTC).UnixMilli()},
+		{"Tokyo datetime without offset", tokyo, "2024-01-15 10:30:00", time.Date(2024, 1, 15, 1, 30, 0, 0, time.UTC).UnixMilli()},
+		{"explicit UTC is kept", saoPaulo, "2024-01-15T00:00:00Z", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC).UnixMilli()},
+		{"explicit offset is kept", tokyo, "2024-01-15T00:00:00-03:00", time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC).UnixMilli()},
+		{"nil location defaults to UTC", nil, "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC).UnixMilli()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := client.TransformOptions{Locale: client.LocalePtBR, Location: tt.loc}
+			got := client.TransformFieldValueWithOptions(tt.value, "date", opts)
+			if got != tt.want {
+				t.Errorf("TransformFieldValueWithOptions(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCustomTaskIDColumn verifies that the column marked as task ID in the mapping is
+// used by the job, even with custom task IDs under a column not named "id task"
+func TestCustomTaskIDColumn(t *testing.T) {
+	mappingService := NewMappingService(nil)
+	jobMapping := mappingService.ConvertToJobMapping([]ColumnMapping{
+		{Column: "Chave", IsTaskID: true},
+		{Column: "Valor", FieldID: "field-1"},
+	})
+	if jobMapping["Chave"] != taskIDMarker || jobMapping["Valor"] != "field-1" {
+		t.Fatalf("mapeamento do job = %v", jobMapping)
+	}
+
+	s := &TaskUpdateService{}
+	columns := []string{"id", "Valor", "Chave"}
+	if idx := s.findTaskIDColumnIndex(columns, jobMapping); idx != 2 {
+		t.Errorf("coluna de task = %d, esperado 2 (Chave)", idx)
+	}
+
+	// Sem coluna marcada, nomes usuais de IDs personalizados são reconhecidos
+	if idx := s.findTaskIDColumnIndex([]string{"Valor", "Custom ID"}, map[string]string{"Valor": "field-1"}); idx != 1 {
+		t.Errorf("coluna de task = %d, esperado 1 (Custom ID)", idx)
+	}
+
+	// Apelidos usados só nas sugestões não mudam a coluna que o job usa
+	if idx := s.findTaskIDColumnIndex([]string{"ID Tarefa", "Valor"}, map[string]string{"Valor": "field-1"}); idx != -1 {
+		t.Errorf("coluna de task = %d, esperado -1", idx)
+	}
+	if !isSuggestedTaskIDColumn("ID Tarefa") {
+		t.Error("ID Tarefa deveria ser sugerida como coluna de task")
+	}
+}
+
+// TestResolveJobColumns verifies that normalized mappings are rewritten to the header
+// names so the job finds the task ID column, mapped values and defaults
+func TestResolveJobColumns(t *testing.T) {
+	columns := []string{"Id Task", "Valor Total", "Situação"}
+	mapping := map[string]string{"id task": taskIDMarker, "valor total ": "field-1", "situacao": "field-2", "Ausente": "field-3"}
+	defaults := map[string]repository.FieldDefault{"SITUACAO": {Value: "ok", When: DefaultWhenEmpty}}
+
+	resolved, resolvedDefaults := resolveJobColumns(columns, mapping, defaults)
+	want := map[string]string{"Id Task": taskIDMarker, "Valor Total": "field-1", "Situação": "field-2", "Ausente": "field-3"}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("mapeamento = %v, esperado %v", resolved, want)
+	}
+	if def, ok := resolvedDefaults["Situação"]; !ok || def.Value != "ok" {
+		t.Errorf("padrões = %v", resolvedDefaults)
+	}
+
+	s := &TaskUpdateService{}
+	if idx := s.findTaskIDColumnIndex(columns, resolved); idx != 0 {
+		t.Errorf("coluna de task = %d, esperado 0", idx)
+	}
+}
+
+// TestProcessFileClassifiesClickUpErrors marca como retryable só as falhas transitórias
+// do ClickUp; valores rejeitados (400) precisam ser corrigidos na planilha
+func TestProcessFileClassifiesClickUpErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/task/T1/"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"err":"Value is not a valid option","ECODE":"FIELD_033"}`))
+		case strings.Contains(r.URL.Path, "/task/T2/"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"err":"Service unavailable","ECODE":"APP_001"}`))
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	csv := "id task,Valor\nT1,a\nT2,b\nT3,c\n"
+	uploadService := NewUploadService(t.TempDir(), 1024*1024)
+	upload, err := uploadService.ProcessFile("valores.csv", strings.NewReader(csv), int64(len(csv)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	svc := NewTaskUpdateService(uploadService, nil, nil, nil, nil)
+	job := &repository.UpdateJob{
+		ID:       9,
+		UserID:   "user-1",
+		FilePath: upload.TempPath,
+		Mapping:  map[string]string{"id task": "task_id", "Valor": "f-valor"},
+	}
+	clickupClient := client.NewClientWithOptions("pk_test", client.ClientOptions{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 1},
+	})
+
+	result, err := svc.processFile(context.Background(), clickupClient, job, map[string]string{"f-valor": "text"}, 6000)
+	if err != nil {
+		t.Fatalf("processFile: %v", err)
+	}
+	if result.SuccessCount != 1 || result.ErrorCount != 2 {
+		t.Fatalf("esperado 1 sucesso e 2 erros, obtido %+v", result)
+	}
+
+	retryable := make(map[string]bool)
+	for _, e := range result.Errors {
+		retryable[e.TaskID] = e.Retryable
+	}
+	if retryable["T1"] || !retryable["T2"] {
+		t.Errorf("classificação = %v, esperado T1 corrigível e T2 transitório", retryable)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+)
+
+// Finalidades de acesso ao token, registradas na auditoria de cada descriptografia
+const (
+	TokenPurposeReport     = "report"
+	TokenPurposeTaskUpdate = "task_update"
+	TokenPurposeUnknown    = "unspecified"
+)
+
+type tokenPurposeKey struct{}
+
+// WithTokenPurpose marca no contexto por que o token será descriptografado
+func WithTokenPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, tokenPurposeKey{}, purpose)
+}
+
+// tokenPurpose lê a finalidade marcada no contexto (TokenPurposeUnknown se ausente)
+func tokenPurpose(ctx context.Context) string {
+	if purpose, ok := ctx.Value(tokenPurposeKey{}).(string); ok && purpose != "" {
+		return purpose
+	}
+	return TokenPurposeUnknown
+}
+
+// TokenAccess descreve uma descriptografia de token do ClickUp. Nunca contém o token.
+type TokenAccess struct {
+	UserID    string
+	Label     string
+	Purpose   string
+	RequestID string
+	Success   bool
+}
+
+// TokenAccessHook é chamado, em processo, a cada descriptografia de token
+type TokenAccessHook func(ctx context.Context, access TokenAccess)
+
+// AddTokenAccessHook registra um hook de acesso a token (ex.: detecção de anomalias)
+func (s *MetadataService) AddTokenAccessHook(hook TokenAccessHook) {
+	s.tokenAccessHooks = append(s.tokenAccessHooks, hook)
+}
+
+// openUserToken descriptografa um token salvo do usuário, registrando o acesso na
+// auditoria, na métrica e nos hooks, com ou sem sucesso
+func (s *MetadataService) openUserToken(ctx context.Context, userID, label, encrypted string) (string, error) {
+	token, err := s.decryptToken(encrypted)
+
+	access := TokenAccess{
+		UserID:    userID,
+		Label:     label,
+		Purpose:   tokenPurpose(ctx),
+		RequestID: logger.GetRequestID(ctx),
+		Success:   err == nil,
+	}
+	s.recordTokenAccess(ctx, access, err)
+
+	return token, err
+}
+
+// recordTokenAccess emite o evento de auditoria e a métrica e avisa os hooks
+func (s *MetadataService) recordTokenAccess(ctx context.Context, access TokenAccess, err error) {
+	event := logger.AuditEvent{
+		Action:     logger.AuditActionTokenDecrypt,
+		UserID:     access.UserID,
+		Resource:   "clickup_token",
+		ResourceID: access.Label,
+		RequestID:  access.RequestID,
+		Success:    access.Success,
+		Details:    map[string]interface{}{"purpose": access.Purpose},
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	logger.Audit(ctx, event)
+	metrics.Get().IncrementTokenDecrypt(access.Success)
+
+	for _, hook := range s.tokenAccessHooks {
+		hook(ctx, access)
+	}
+}
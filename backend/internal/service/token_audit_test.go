@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/logger"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
+)
+
+func TestOpenUserTokenAuditsEachDecryption(t *testing.T) {
+	service := NewMetadataService(nil, nil, "chave-de-teste")
+
+	var accesses []TokenAccess
+	service.AddTokenAccessHook(func(ctx context.Context, access TokenAccess) {
+		accesses = append(accesses, access)
+	})
+
+	encrypted, err := service.encryptToken("pk_segredo")
+	if err != nil {
+		t.Fatalf("encryptToken: %v", err)
+	}
+
+	ctx := WithTokenPurpose(logger.WithRequestID(context.Background(), "req-123"), TokenPurposeReport)
+	before := metrics.Get().Snapshot().Tokens
+
+	token, err := service.openUserToken(ctx, "ana", DefaultTokenLabel, encrypted)
+	if err != nil || token != "pk_segredo" {
+		t.Fatalf("openUserToken = %q, %v", token, err)
+	}
+	if len(accesses) != 1 {
+		t.Fatalf("hook chamado %d vezes, esperava 1", len(accesses))
+	}
+	want := TokenAccess{UserID: "ana", Label: DefaultTokenLabel, Purpose: TokenPurposeReport, RequestID: "req-123", Success: true}
+	if accesses[0] != want {
+		t.Errorf("acesso = %+v, esperava %+v", accesses[0], want)
+	}
+
+	// Falha de descriptografia também é auditada, uma vez, sem finalidade marcada
+	if _, err := service.openUserToken(context.Background(), "ana", "Cliente A", "invalido"); err == nil {
+		t.Fatal("esperava erro com token inválido")
+	}
+	if len(accesses) != 2 {
+		t.Fatalf("hook chamado %d vezes, esperava 2", len(accesses))
+	}
+	if accesses[1].Success || accesses[1].Purpose != TokenPurposeUnknown || accesses[1].Label != "Cliente A" {
+		t.Errorf("acesso com falha = %+v", accesses[1])
+	}
+	for _, access := range accesses {
+		if strings.Contains(access.UserID+access.Label+access.Purpose+access.RequestID, "pk_segredo") {
+			t.Errorf("acesso não deve conter o token: %+v", access)
+		}
+	}
+
+	after := metrics.Get().Snapshot().Tokens
+	if after.Decryptions-before.Decryptions != 2 || after.Errors-before.Errors != 1 {
+		t.Errorf("métricas: antes %+v, depois %+v", before, after)
+	}
+}
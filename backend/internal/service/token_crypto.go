@@ -0,0 +1,154 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tokens are stored as "<key id>$<base64(nonce+ciphertext)>" so the encryption key can
+// be rotated: the id says which configured key decrypts each value. Values written
+// before key ids existed have no prefix and are tried against every configured key.
+
+// tokenKeySeparator separa o id da chave do texto cifrado (não faz parte do alfabeto base64)
+const tokenKeySeparator = "$"
+
+// ErrUnknownEncryptionKey indica um token cifrado com uma chave que não está configurada
+var ErrUnknownEncryptionKey = errors.New("token cifrado com chave desconhecida (configure a chave anterior em ENCRYPTION_KEYS_PREVIOUS)")
+
+// tokenCipher cifra tokens com a chave atual e decifra com a atual ou as anteriores
+type tokenCipher struct {
+	primarySecret string
+	primaryID     string
+	keys          map[string][]byte
+	order         []string // atual primeiro, depois as anteriores na ordem configurada
+}
+
+// deriveTokenKey converte o segredo configurado em uma chave AES-256 (completa ou trunca em 32 bytes)
+func deriveTokenKey(secret string) []byte {
+	key := make([]byte, 32)
+	copy(key, []byte(secret))
+	return key
+}
+
+// tokenKeyID identifica uma chave sem revelá-la
+func tokenKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// newTokenCipher cria o cifrador com a chave atual e as anteriores aceitas na decifragem
+func newTokenCipher(primary string, previous ...string) *tokenCipher {
+	c := &tokenCipher{primarySecret: primary, keys: make(map[string][]byte)}
+	for i, secret := range append([]string{primary}, previous...) {
+		if i > 0 && strings.TrimSpace(secret) == "" {
+			continue
+		}
+		key := deriveTokenKey(secret)
+		id := tokenKeyID(key)
+		if i == 0 {
+			c.primaryID = id
+		}
+		if _, ok := c.keys[id]; ok {
+			continue
+		}
+		c.keys[id] = key
+		c.order = append(c.order, id)
+	}
+	return c
+}
+
+// encrypt cifra com a chave atual
+func (c *tokenCipher) encrypt(plaintext string) (string, error) {
+	gcm, err := newGCM(c.keys[c.primaryID])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return c.primaryID + tokenKeySeparator + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt decifra com a chave indicada no valor, ou tenta todas em valores sem id
+func (c *tokenCipher) decrypt(ciphertext string) (string, error) {
+	if id, payload, ok := strings.Cut(ciphertext, tokenKeySeparator); ok {
+		key, known := c.keys[id]
+		if !known {
+			return "", fmt.Errorf("%w: %s", ErrUnknownEncryptionKey, id)
+		}
+		return openToken(key, payload)
+	}
+
+	var lastErr error
+	for _, id := range c.order {
+		plaintext, err := openToken(c.keys[id], ciphertext)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// isCurrent indica se o valor já está cifrado com a chave atual
+func (c *tokenCipher) isCurrent(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, c.primaryID+tokenKeySeparator)
+}
+
+// reencryptToken decifra um valor com from e o cifra novamente com a chave atual de to.
+// Valores já cifrados com a chave de destino são mantidos.
+func reencryptToken(from, to *tokenCipher, ciphertext string) (string, error) {
+	if ciphertext == "" || to.isCurrent(ciphertext) {
+		return ciphertext, nil
+	}
+	plaintext, err := from.decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return to.encrypt(plaintext)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// openToken decifra base64(nonce+ciphertext) com uma chave
+func openToken(key []byte, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, sealed := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
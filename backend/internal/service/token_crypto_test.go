@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	oldTestKey = "old-encryption-key-32-bytes-long"
+	newTestKey = "new-encryption-key-32-bytes-long"
+)
+
+// legacyEncrypt reproduz o formato anterior ao id de chave: base64(nonce+ciphertext)
+func legacyEncrypt(t *testing.T, secret, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher(deriveTokenKey(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestTokenCipherRoundTrip(t *testing.T) {
+	c := newTokenCipher(oldTestKey)
+
+	encrypted, err := c.encrypt("pk_123")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, c.primaryID+tokenKeySeparator) {
+		t.Errorf("valor sem id da chave: %q", encrypted)
+	}
+	if got, err := c.decrypt(encrypted); err != nil || got != "pk_123" {
+		t.Errorf("decrypt = %q, %v", got, err)
+	}
+}
+
+func TestTokenCipherDecryptsWithPreviousKeys(t *testing.T) {
+	old := newTokenCipher(oldTestKey)
+	encrypted, err := old.encrypt("pk_old")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	legacy := legacyEncrypt(t, oldTestKey, "pk_legacy")
+
+	// Durante a troca, a nova chave cifra e a antiga ainda decifra
+	rotated := newTokenCipher(newTestKey, oldTestKey)
+	if got, err := rotated.decrypt(encrypted); err != nil || got != "pk_old" {
+		t.Errorf("decrypt com chave anterior = %q, %v", got, err)
+	}
+	if got, err := rotated.decrypt(legacy); err != nil || got != "pk_legacy" {
+		t.Errorf("decrypt de valor sem id = %q, %v", got, err)
+	}
+	if fresh, _ := rotated.encrypt("pk_new"); !rotated.isCurrent(fresh) || old.isCurrent(fresh) {
+		t.Errorf("novo valor deveria usar a nova chave: %q", fresh)
+	}
+
+	// Sem a chave anterior configurada, o erro identifica a chave
+	if _, err := newTokenCipher(newTestKey).decrypt(encrypted); !errors.Is(err, ErrUnknownEncryptionKey) {
+		t.Errorf("esperado ErrUnknownEncryptionKey, obtido %v", err)
+	}
+}
+
+func TestReencryptTokenAfterRotation(t *testing.T) {
+	from := newTokenCipher(oldTestKey)
+	to := newTokenCipher(newTestKey)
+
+	encrypted, _ := from.encrypt("pk_123")
+	for _, value := range []string{encrypted, legacyEncrypt(t, oldTestKey, "pk_123")} {
+		rotated, err := reencryptToken(from, to, value)
+		if err != nil {
+			t.Fatalf("reencryptToken(%q): %v", value, err)
+		}
+		if !to.isCurrent(rotated) {
+			t.Errorf("valor não foi cifrado com a nova chave: %q", rotated)
+		}
+
+		// Depois da troca, só a nova chave é necessária
+		if got, err := to.decrypt(rotated); err != nil || got != "pk_123" {
+			t.Errorf("decrypt após troca = %q, %v", got, err)
+		}
+
+		// Executar de novo não altera valores já convertidos
+		again, err := reencryptToken(from, to, rotated)
+		if err != nil || again != rotated {
+			t.Errorf("segunda execução alterou o valor: %q, %v", again, err)
+		}
+	}
+
+	if _, err := reencryptToken(newTokenCipher("outra-chave"), to, encrypted); err == nil {
+		t.Error("chave antiga errada deveria falhar")
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
@@ -11,25 +12,40 @@ import (
 	"sync"
 	"time"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/cleberrangel/clickup-excel-api/internal/filestore"
+	"github.com/cleberrangel/clickup-excel-api/internal/metrics"
 )
 
 // File upload errors
 var (
 	ErrInvalidFile     = errors.New("arquivo inválido ou corrompido")
-	ErrFileTooLarge    = errors.New("arquivo excede limite de 10MB")
-	ErrUnsupportedType = errors.New("formato de arquivo não suportado (use CSV ou XLSX)")
+	ErrFileTooLarge    = errors.New("arquivo excede o tamanho máximo permitido")
+	ErrUnsupportedType = errors.New("formato de arquivo não suportado (use CSV, XLSX ou ODS)")
 	ErrEmptyFile       = errors.New("arquivo está vazio")
 	ErrNoColumns       = errors.New("arquivo não contém colunas")
+	ErrSheetNotFound   = errors.New("aba não encontrada na planilha")
+	ErrTooManyRows     = errors.New("arquivo excede o limite de linhas")
+	ErrFileRejected    = errors.New("arquivo rejeitado pela verificação de segurança")
+	ErrInvalidTempPath = errors.New("caminho de arquivo inválido")
+
+	ErrInvalidPreviewRows = errors.New("preview_rows deve ser um número não negativo")
 )
 
 const (
-	// MaxFileSize is the maximum allowed file size (10MB)
-	MaxFileSize = 10 * 1024 * 1024
+	// DefaultMaxFileSize is the default maximum upload size (10MB)
+	DefaultMaxFileSize = 10 * 1024 * 1024
 	// PreviewRows is the number of rows to show in preview
 	PreviewRows = 5
-	// TempFileExpiry is how long temp files are kept before cleanup
+	// MaxPreviewRows caps the preview rows a client may request
+	MaxPreviewRows = 100
+	// TempFileExpiry is the default time temp files are kept before cleanup
 	TempFileExpiry = 1 * time.Hour
+	// tempFilePrefix names every upload temp file, so leftovers can be found on startup
+	tempFilePrefix = "upload_"
+	// stagingFilePrefix names the local copy scanned before the file goes to the store
+	stagingFilePrefix = "staging_"
+	// DefaultMaxRows is the default maximum number of data rows per upload
+	DefaultMaxRows = 50000
 )
 
 // FileUpload represents an uploaded file with extracted metadata
@@ -41,36 +57,124 @@ type FileUpload struct {
 	Preview     [][]string `json:"preview"`
 	TempPath    string     `json:"temp_path"`
 	TotalRows   int        `json:"total_rows"`
+	Sheets      []string   `json:"sheets,omitempty"`
+	Sheet       string     `json:"sheet,omitempty"`
+	// ColumnStats summarizes every column, computed in the same pass as the preview
+	ColumnStats []ColumnStats `json:"column_stats,omitempty"`
 }
 
+// ScanFunc inspects a saved upload before it is parsed (e.g. ClamAV or a content
+// sniffer); a non-nil error rejects the file
+type ScanFunc func(path string) error
+
 // UploadService handles file upload and processing
 type UploadService struct {
 	tempDir     string
 	tempFiles   map[string]time.Time
 	tempFilesMu sync.RWMutex
+	maxRows     int
+	maxFileSize int64
+	scan        ScanFunc
+	tempFileTTL time.Duration
+	inUse       func(path string) (bool, error)
+	now         func() time.Time
+	// encryptionKey enables at-rest encryption of temp files (nil = plaintext)
+	encryptionKey []byte
+	// store keeps the uploaded files (the temp directory by default)
+	store filestore.FileStore
+	// chunked holds the uploads being sent in parts, by upload ID
+	chunked   map[string]*chunkedUpload
+	chunkedMu sync.Mutex
 }
 
-// NewUploadService creates a new upload service
-func NewUploadService(tempDir string) *UploadService {
+// NewUploadService creates a new upload service; maxFileSize is the upload size
+// limit in bytes (<= 0 uses DefaultMaxFileSize)
+func NewUploadService(tempDir string, maxFileSize int64) *UploadService {
 	if tempDir == "" {
 		tempDir = os.TempDir()
 	}
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
 	
 	service := &UploadService{
 		tempDir:   tempDir,
 		tempFiles: make(map[string]time.Time),
+		maxRows:     DefaultMaxRows,
+		maxFileSize: maxFileSize,
+		store:       filestore.NewLocal(tempDir),
+		tempFileTTL: TempFileExpiry,
+		now:         time.Now,
+		chunked:     make(map[string]*chunkedUpload),
 	}
 	
-	// Start cleanup goroutine
-	go service.cleanupLoop()
-	
 	return service
 }
 
-// ProcessFile processes an uploaded file and extracts columns and preview
+// SetTempFileTTL sets how long uploaded temp files are kept (<= 0 restores the default)
+func (s *UploadService) SetTempFileTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = TempFileExpiry
+	}
+	s.tempFileTTL = ttl
+}
+
+// SetInUseCheck sets a check that keeps expired temp files still referenced by queued jobs
+func (s *UploadService) SetInUseCheck(inUse func(path string) (bool, error)) {
+	s.inUse = inUse
+}
+
+// SetClock replaces the time source (used by tests)
+func (s *UploadService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetMaxRows sets the maximum number of data rows per upload (<= 0 restores the default)
+func (s *UploadService) SetMaxRows(maxRows int) {
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+	s.maxRows = maxRows
+}
+
+// SetScanFunc sets the scanner run on every upload after it is written to disk (nil disables it)
+func (s *UploadService) SetScanFunc(scan ScanFunc) {
+	s.scan = scan
+}
+
+// MaxRows returns the maximum number of data rows per upload
+func (s *UploadService) MaxRows() int {
+	return s.maxRows
+}
+
+// MaxFileSize returns the upload size limit in bytes
+func (s *UploadService) MaxFileSize() int64 {
+	return s.maxFileSize
+}
+
+// ProcessFile processes an uploaded file and extracts columns and preview,
+// using the first sheet of XLSX workbooks
 func (s *UploadService) ProcessFile(filename string, reader io.Reader, size int64) (*FileUpload, error) {
+	return s.ProcessFileWithOptions(filename, reader, size, UploadOptions{})
+}
+
+// ProcessFileWithOptions processes an uploaded file and extracts columns and preview.
+// opts.Sheet selects the XLSX tab (ignored by other formats); SkipRows/HeaderRow locate
+// the header and are kept with the temp file so GetFileData uses the same offsets.
+func (s *UploadService) ProcessFileWithOptions(filename string, reader io.Reader, size int64, opts UploadOptions) (*FileUpload, error) {
+	return s.ProcessFileContext(context.Background(), filename, reader, size, opts)
+}
+
+// ProcessFileContext is ProcessFileWithOptions bound to a request: once ctx is done
+// (deadline or client gone) the upload stops, its temp file is removed and ctx's error
+// is returned.
+func (s *UploadService) ProcessFileContext(ctx context.Context, filename string, reader io.Reader, size int64, opts UploadOptions) (*FileUpload, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	
 	// Validate file size
-	if size > MaxFileSize {
+	if size > s.maxFileSize {
 		return nil, ErrFileTooLarge
 	}
 	
@@ -86,37 +190,79 @@ func (s *UploadService) ProcessFile(filename string, reader io.Reader, size int6
 		return nil, ErrUnsupportedType
 	}
 	
-	// Save to temp file
-	tempPath, err := s.saveTempFile(filename, reader)
+	// Save a local staging copy for the scanner
+	stagingPath, err := s.saveStagingFile(filename, contextReader{ctx: ctx, r: reader})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("erro ao salvar arquivo temporário: %w", err)
+	}
+	defer os.Remove(stagingPath)
+	
+	// Scan before trusting the content
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.scan != nil {
+		if err := s.scan(stagingPath); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFileRejected, err)
+		}
+	}
+	
+	// Move it to the file store (encrypted at rest when enabled); from here on the
+	// file is read from the store, so any instance can process it
+	tempPath, err := s.storeStagingFile(stagingPath, ext)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao salvar arquivo temporário: %w", err)
 	}
 	
 	// Process based on file type
-	var columns []string
-	var preview [][]string
-	var totalRows int
+	var preview *previewBuilder
+	var sheets []string
+	var selectedSheet string
 	
 	switch ext {
 	case ".csv":
-		columns, preview, totalRows, err = s.processCSV(tempPath)
+		opts.Format, err = s.detectCSVFormat(tempPath, opts)
+		if err == nil {
+			preview, err = s.processCSV(ctx, tempPath, opts)
+		}
 	case ".xlsx":
-		columns, preview, totalRows, err = s.processXLSX(tempPath)
+		sheets, selectedSheet, err = s.selectXLSXSheet(tempPath, opts.Sheet)
+		if err == nil {
+			preview, err = s.processXLSX(tempPath, opts)
+		}
+	case ".ods":
+		preview, err = s.processODS(tempPath, opts)
 	default:
-		os.Remove(tempPath)
+		s.deleteTempFile(tempPath)
 		return nil, ErrUnsupportedType
 	}
 	
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
-		os.Remove(tempPath)
+		s.deleteTempFile(tempPath)
 		return nil, err
 	}
 	
-	if len(columns) == 0 {
-		os.Remove(tempPath)
+	if len(preview.columns) == 0 {
+		s.deleteTempFile(tempPath)
 		return nil, ErrNoColumns
 	}
 	
+	if preview.totalRows > s.maxRows {
+		s.deleteTempFile(tempPath)
+		return nil, fmt.Errorf("%w: %d linhas (máximo %d)", ErrTooManyRows, preview.totalRows, s.maxRows)
+	}
+	
+	if err := s.saveLayout(tempPath, opts); err != nil {
+		s.deleteTempFile(tempPath)
+		return nil, err
+	}
+	
 	// Track temp file for cleanup
 	s.trackTempFile(tempPath)
 	
@@ -124,46 +270,43 @@ func (s *UploadService) ProcessFile(filename string, reader io.Reader, size int6
 		Filename:    filename,
 		Size:        size,
 		ContentType: contentType,
-		Columns:     columns,
-		Preview:     preview,
+		Columns:     preview.columns,
+		Preview:     preview.preview,
 		TempPath:    tempPath,
-		TotalRows:   totalRows,
+		TotalRows:   preview.totalRows,
+		Sheets:      sheets,
+		Sheet:       selectedSheet,
+		ColumnStats: preview.columnStats(),
 	}, nil
 }
 
 
-// processCSV processes a CSV file and extracts columns and preview
-func (s *UploadService) processCSV(filePath string) ([]string, [][]string, int, error) {
-	file, err := os.Open(filePath)
+// processCSV processes a CSV file and extracts columns, preview and column statistics
+func (s *UploadService) processCSV(ctx context.Context, filePath string, opts UploadOptions) (*previewBuilder, error) {
+	file, err := s.openTempFile(filePath)
 	if err != nil {
-		return nil, nil, 0, fmt.Errorf("erro ao abrir arquivo: %w", err)
+		return nil, err
 	}
 	defer file.Close()
 	
-	reader := csv.NewReader(file)
-	reader.LazyQuotes = true
-	reader.TrimLeadingSpace = true
+	reader := newCSVReader(file, opts.Format.comma())
 	
-	// Read header (first row)
-	header, err := reader.Read()
+	// Read header
+	header, err := readCSVHeader(reader, opts)
 	if err != nil {
-		if err == io.EOF {
-			return nil, nil, 0, ErrEmptyFile
-		}
-		return nil, nil, 0, fmt.Errorf("erro ao ler cabeçalho: %w", err)
+		return nil, err
 	}
 	
 	// Clean column names
-	columns := make([]string, len(header))
-	for i, col := range header {
-		columns[i] = strings.TrimSpace(col)
-	}
+	columns := cleanColumns(header)
 	
-	// Read preview rows
-	preview := make([][]string, 0, PreviewRows)
-	totalRows := 0
+	// Read every row for the count and statistics, keeping the first ones as preview
+	preview := newPreviewBuilder(columns, opts.previewRowCount())
 	
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		row, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -173,61 +316,120 @@ func (s *UploadService) processCSV(filePath string) ([]string, [][]string, int,
 			continue
 		}
 		
-		totalRows++
-		
-		if len(preview) < PreviewRows {
-			// Normalize row to match column count
-			normalizedRow := normalizeRow(row, len(columns))
-			preview = append(preview, normalizedRow)
-		}
+		preview.add(row)
 	}
 	
-	return columns, preview, totalRows, nil
+	return preview, nil
+}
+
+// readCSVHeader reads up to the header row described by opts. Rows before the header
+// may have any width; rows after it must match the header's field count.
+func readCSVHeader(reader *csv.Reader, opts UploadOptions) ([]string, error) {
+	reader.FieldsPerRecord = -1
+	locator := newHeaderLocator(opts)
+	
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, locator.notFound()
+			}
+			return nil, fmt.Errorf("erro ao ler cabeçalho: %w", err)
+		}
+		if locator.isHeader(row) {
+			reader.FieldsPerRecord = len(row)
+			return row, nil
+		}
+	}
 }
 
-// processXLSX processes an XLSX file and extracts columns and preview
-func (s *UploadService) processXLSX(filePath string) ([]string, [][]string, int, error) {
-	f, err := excelize.OpenFile(filePath)
+// processXLSX processes an XLSX file and extracts columns, preview and column statistics
+func (s *UploadService) processXLSX(filePath string, opts UploadOptions) (*previewBuilder, error) {
+	f, err := s.openWorkbook(filePath)
 	if err != nil {
-		return nil, nil, 0, fmt.Errorf("erro ao abrir arquivo Excel: %w", err)
+		return nil, fmt.Errorf("erro ao abrir arquivo Excel: %w", err)
 	}
 	defer f.Close()
 	
-	// Get first sheet
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, nil, 0, ErrEmptyFile
+	// Get the selected sheet (see selectXLSXSheet)
+	sheetName := f.GetSheetName(f.GetActiveSheetIndex())
+	if sheetName == "" {
+		return nil, ErrEmptyFile
 	}
 	
-	sheetName := sheets[0]
-	
 	// Get all rows
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return nil, nil, 0, fmt.Errorf("erro ao ler linhas: %w", err)
+		return nil, fmt.Errorf("erro ao ler linhas: %w", err)
+	}
+	
+	columns, dataRows, err := splitHeader(rows, opts)
+	if err != nil {
+		return nil, err
 	}
 	
-	if len(rows) == 0 {
-		return nil, nil, 0, ErrEmptyFile
+	preview := newPreviewBuilder(columns, opts.previewRowCount())
+	for _, row := range dataRows {
+		preview.add(row)
 	}
 	
-	// First row is header
-	header := rows[0]
-	columns := make([]string, len(header))
-	for i, col := range header {
-		columns[i] = strings.TrimSpace(col)
+	return preview, nil
+}
+
+// selectXLSXSheet lists the workbook's sheets and marks the chosen one (or the first)
+// as active in the temp copy, so every later read of the file uses the same tab
+func (s *UploadService) selectXLSXSheet(filePath string, sheet string) ([]string, string, error) {
+	f, err := s.openWorkbook(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao abrir arquivo Excel: %w", err)
 	}
+	defer f.Close()
 	
-	// Get preview rows (skip header)
-	preview := make([][]string, 0, PreviewRows)
-	totalRows := len(rows) - 1 // Exclude header
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, "", ErrEmptyFile
+	}
+	
+	selected := sheets[0]
+	if sheet != "" {
+		selected = ""
+		for _, name := range sheets {
+			if name == sheet {
+				selected = name
+				break
+			}
+		}
+		if selected == "" {
+			return sheets, "", fmt.Errorf("%w: %q (disponíveis: %s)", ErrSheetNotFound, sheet, strings.Join(sheets, ", "))
+		}
+	}
 	
-	for i := 1; i < len(rows) && len(preview) < PreviewRows; i++ {
-		normalizedRow := normalizeRow(rows[i], len(columns))
-		preview = append(preview, normalizedRow)
+	index, err := f.GetSheetIndex(selected)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao selecionar aba: %w", err)
+	}
+	if f.GetActiveSheetIndex() != index {
+		f.SetActiveSheet(index)
+		if err := s.saveWorkbook(f, filePath); err != nil {
+			return nil, "", fmt.Errorf("erro ao selecionar aba: %w", err)
+		}
 	}
 	
-	return columns, preview, totalRows, nil
+	return sheets, selected, nil
+}
+
+// contextReader stops reading once its context is done, so copying a request body
+// doesn't outlive the request
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
 
 // normalizeRow ensures a row has the correct number of columns
@@ -243,26 +445,26 @@ func normalizeRow(row []string, columnCount int) []string {
 	return normalized
 }
 
-// saveTempFile saves the uploaded file to a temporary location
-func (s *UploadService) saveTempFile(filename string, reader io.Reader) (string, error) {
+// saveStagingFile saves the uploaded file to a local staging file
+func (s *UploadService) saveStagingFile(filename string, reader io.Reader) (string, error) {
 	ext := filepath.Ext(filename)
 	
 	// Create temp file with original extension
-	tempFile, err := os.CreateTemp(s.tempDir, "upload_*"+ext)
+	tempFile, err := os.CreateTemp(s.tempDir, stagingFilePrefix+"*"+ext)
 	if err != nil {
 		return "", err
 	}
 	defer tempFile.Close()
 	
 	// Copy content with size limit
-	limitedReader := io.LimitReader(reader, MaxFileSize+1)
+	limitedReader := io.LimitReader(reader, s.maxFileSize+1)
 	written, err := io.Copy(tempFile, limitedReader)
 	if err != nil {
 		os.Remove(tempFile.Name())
 		return "", err
 	}
 	
-	if written > MaxFileSize {
+	if written > s.maxFileSize {
 		os.Remove(tempFile.Name())
 		return "", ErrFileTooLarge
 	}
@@ -277,6 +479,8 @@ func (s *UploadService) getContentType(ext string) string {
 		return "text/csv"
 	case ".xlsx":
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ".ods":
+		return "application/vnd.oasis.opendocument.spreadsheet"
 	default:
 		return ""
 	}
@@ -286,7 +490,7 @@ func (s *UploadService) getContentType(ext string) string {
 func (s *UploadService) trackTempFile(path string) {
 	s.tempFilesMu.Lock()
 	defer s.tempFilesMu.Unlock()
-	s.tempFiles[path] = time.Now()
+	s.tempFiles[path] = s.now()
 }
 
 // RemoveTempFile removes a temp file from tracking and deletes it
@@ -295,79 +499,154 @@ func (s *UploadService) RemoveTempFile(path string) error {
 	delete(s.tempFiles, path)
 	s.tempFilesMu.Unlock()
 	
-	return os.Remove(path)
+	if _, err := s.store.Stat(tempFileKey(path)); err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			return os.ErrNotExist
+		}
+		return err
+	}
+	return s.deleteTempFile(path)
 }
 
-// cleanupLoop periodically cleans up expired temp files
-func (s *UploadService) cleanupLoop() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
+// StartTempFileCleanup adopts temp files left by a previous run and starts a goroutine
+// that periodically removes the expired ones
+func (s *UploadService) StartTempFileCleanup() {
+	s.adoptExistingTempFiles()
+	s.removeLeftoverChunkParts()
 	
-	for range ticker.C {
-		s.cleanupExpiredFiles()
+	interval := 10 * time.Minute
+	if s.tempFileTTL/2 < interval {
+		interval = s.tempFileTTL / 2
 	}
+	
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for range ticker.C {
+			s.CleanupExpiredFiles()
+		}
+	}()
 }
 
-// cleanupExpiredFiles removes temp files older than TempFileExpiry
-func (s *UploadService) cleanupExpiredFiles() {
+// adoptExistingTempFiles tracks upload temp files already in the store (e.g. abandoned
+// before a restart) using their modification time as creation time. Stores that can't
+// list their files (S3) rely on the bucket's own expiration rules instead.
+func (s *UploadService) adoptExistingTempFiles() {
+	lister, ok := s.store.(filestore.Lister)
+	if !ok {
+		return
+	}
+	keys, err := lister.List(tempFilePrefix)
+	if err != nil {
+		return
+	}
+	
+	s.tempFilesMu.Lock()
+	defer s.tempFilesMu.Unlock()
+	
+	for _, key := range keys {
+		switch strings.ToLower(filepath.Ext(key)) {
+		case ".csv", ".xlsx", ".ods":
+		default:
+			continue // layout sidecars are removed together with their file
+		}
+		info, err := s.store.Stat(key)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(s.tempDir, key)
+		if _, tracked := s.tempFiles[path]; !tracked {
+			s.tempFiles[path] = info.ModTime
+		}
+	}
+}
+
+// CleanupExpiredFiles removes temp files older than the configured TTL, skipping files
+// still used by queued jobs, and chunked uploads abandoned for as long. It returns how
+// many files and bytes were reclaimed.
+func (s *UploadService) CleanupExpiredFiles() (int, int64) {
+	now := s.now()
+	removed, reclaimed := s.cleanupChunkedUploads(now)
+	
 	s.tempFilesMu.Lock()
 	defer s.tempFilesMu.Unlock()
 	
-	now := time.Now()
 	for path, created := range s.tempFiles {
-		if now.Sub(created) > TempFileExpiry {
-			os.Remove(path)
-			delete(s.tempFiles, path)
+		if now.Sub(created) <= s.tempFileTTL {
+			continue
 		}
+		if s.inUse != nil {
+			if inUse, err := s.inUse(path); err != nil || inUse {
+				continue // keep it; checked again on the next sweep
+			}
+		}
+		
+		for _, key := range []string{tempFileKey(path), layoutKey(path)} {
+			if info, err := s.store.Stat(key); err == nil {
+				if s.store.Delete(key) == nil {
+					reclaimed += info.Size
+				}
+			}
+		}
+		delete(s.tempFiles, path)
+		removed++
+	}
+	
+	if removed > 0 {
+		metrics.Get().IncrementTempFilesReclaimed(removed, reclaimed)
 	}
+	return removed, reclaimed
 }
 
 // GetFileData reads all data from a processed file
 func (s *UploadService) GetFileData(tempPath string) ([]string, [][]string, error) {
 	ext := strings.ToLower(filepath.Ext(tempPath))
 	
+	opts, err := s.loadLayout(tempPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	
 	switch ext {
 	case ".csv":
-		return s.readAllCSV(tempPath)
+		return s.readAllCSV(tempPath, opts)
 	case ".xlsx":
-		return s.readAllXLSX(tempPath)
+		return s.readAllXLSX(tempPath, opts)
+	case ".ods":
+		return s.readAllODS(tempPath, opts)
 	default:
 		return nil, nil, ErrUnsupportedType
 	}
 }
 
 // readAllCSV reads all data from a CSV file
-func (s *UploadService) readAllCSV(filePath string) ([]string, [][]string, error) {
-	file, err := os.Open(filePath)
+func (s *UploadService) readAllCSV(filePath string, opts UploadOptions) ([]string, [][]string, error) {
+	file, err := s.openTempFile(filePath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+		return nil, nil, err
 	}
 	defer file.Close()
 	
-	reader := csv.NewReader(file)
-	reader.LazyQuotes = true
-	reader.TrimLeadingSpace = true
+	reader := newCSVReader(file, opts.Format.comma())
 	
-	// Read all records
-	records, err := reader.ReadAll()
+	header, err := readCSVHeader(reader, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("erro ao ler arquivo: %w", err)
+		return nil, nil, err
 	}
 	
-	if len(records) == 0 {
-		return nil, nil, ErrEmptyFile
-	}
+	columns := cleanColumns(header)
 	
-	// First row is header
-	columns := make([]string, len(records[0]))
-	for i, col := range records[0] {
-		columns[i] = strings.TrimSpace(col)
+	// Read all records
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao ler arquivo: %w", err)
 	}
 	
 	// Rest is data
-	data := make([][]string, 0, len(records)-1)
-	for i := 1; i < len(records); i++ {
-		normalizedRow := normalizeRow(records[i], len(columns))
+	data := make([][]string, 0, len(records))
+	for _, record := range records {
+		normalizedRow := normalizeRow(record, len(columns))
 		data = append(data, normalizedRow)
 	}
 	
@@ -375,37 +654,32 @@ func (s *UploadService) readAllCSV(filePath string) ([]string, [][]string, error
 }
 
 // readAllXLSX reads all data from an XLSX file
-func (s *UploadService) readAllXLSX(filePath string) ([]string, [][]string, error) {
-	f, err := excelize.OpenFile(filePath)
+func (s *UploadService) readAllXLSX(filePath string, opts UploadOptions) ([]string, [][]string, error) {
+	f, err := s.openWorkbook(filePath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("erro ao abrir arquivo Excel: %w", err)
 	}
 	defer f.Close()
 	
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
+	sheetName := f.GetSheetName(f.GetActiveSheetIndex())
+	if sheetName == "" {
 		return nil, nil, ErrEmptyFile
 	}
 	
-	rows, err := f.GetRows(sheets[0])
+	rows, err := f.GetRows(sheetName)
 	if err != nil {
 		return nil, nil, fmt.Errorf("erro ao ler linhas: %w", err)
 	}
 	
-	if len(rows) == 0 {
-		return nil, nil, ErrEmptyFile
-	}
-	
-	// First row is header
-	columns := make([]string, len(rows[0]))
-	for i, col := range rows[0] {
-		columns[i] = strings.TrimSpace(col)
+	columns, rows, err := splitHeader(rows, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 	
 	// Rest is data
-	data := make([][]string, 0, len(rows)-1)
-	for i := 1; i < len(rows); i++ {
-		normalizedRow := normalizeRow(rows[i], len(columns))
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		normalizedRow := normalizeRow(row, len(columns))
 		data = append(data, normalizedRow)
 	}
 	
@@ -415,7 +689,7 @@ func (s *UploadService) readAllXLSX(filePath string) ([]string, [][]string, erro
 // ValidateFileFormat validates that a file has the correct format
 func (s *UploadService) ValidateFileFormat(filename string) error {
 	ext := strings.ToLower(filepath.Ext(filename))
-	if ext != ".csv" && ext != ".xlsx" {
+	if ext != ".csv" && ext != ".xlsx" && ext != ".ods" {
 		return ErrUnsupportedType
 	}
 	return nil
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Chunked upload errors
+var (
+	ErrChunkedUploadNotFound = errors.New("upload em partes não encontrado ou expirado")
+	ErrInvalidChunk          = errors.New("parte do upload inválida")
+	ErrUploadIncomplete      = errors.New("upload em partes incompleto")
+	ErrTooManyChunkedUploads = errors.New("limite de uploads em partes abertos atingido")
+)
+
+const (
+	// chunkPartDir is the directory, inside the temp dir, where chunked uploads are assembled.
+	// Parts never go to the file store: only the finished file does, through ProcessFileWithOptions.
+	chunkPartDir = "chunk_parts"
+	// chunkPartPrefix names the local file a chunked upload is assembled in
+	chunkPartPrefix = "chunked_"
+	// maxChunkedUploadsPerUser limits the uploads a user may keep open; each one may
+	// reserve up to the maximum file size on disk
+	maxChunkedUploadsPerUser = 3
+)
+
+// ByteRange is an inclusive range of bytes of a chunked upload
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// chunkedUpload is the state of an upload sent in parts. Parts may arrive in any order
+// and may be repeated (a retried request): each one is written at its own offset.
+type chunkedUpload struct {
+	mu        sync.Mutex
+	id        string
+	userID    string
+	filename  string
+	size      int64
+	opts      UploadOptions
+	partPath  string
+	received  []ByteRange // merged and sorted
+	updatedAt time.Time
+	// writing counts parts being copied to the file, which happens outside mu
+	writing int
+	// closed is set once the upload is completed or expired; late parts are rejected
+	closed bool
+}
+
+// ChunkedUploadStatus reports how much of a chunked upload has been received
+type ChunkedUploadStatus struct {
+	UploadID  string      `json:"upload_id"`
+	Filename  string      `json:"filename"`
+	Size      int64       `json:"size"`
+	Received  int64       `json:"received"`
+	Missing   []ByteRange `json:"missing,omitempty"`
+	Complete  bool        `json:"complete"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// InitChunkedUpload starts an upload sent in parts. The file is checked as far as it can be
+// before any byte arrives (format, size and header options); opts are used on completion.
+func (s *UploadService) InitChunkedUpload(userID, filename string, size int64, opts UploadOptions) (*ChunkedUploadStatus, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateFileFormat(filename); err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, ErrEmptyFile
+	}
+	if size > s.maxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	dir := s.chunkPartDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório temporário: %w", err)
+	}
+	part, err := os.CreateTemp(dir, chunkPartPrefix+"*.part")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	part.Close()
+
+	upload := &chunkedUpload{
+		id:        newChunkedUploadID(),
+		userID:    userID,
+		filename:  filename,
+		size:      size,
+		opts:      opts,
+		partPath:  part.Name(),
+		updatedAt: s.now(),
+	}
+
+	s.chunkedMu.Lock()
+	open := 0
+	for _, other := range s.chunked {
+		if other.userID == userID {
+			open++
+		}
+	}
+	if open >= maxChunkedUploadsPerUser {
+		s.chunkedMu.Unlock()
+		os.Remove(upload.partPath)
+		return nil, fmt.Errorf("%w: conclua ou aguarde a expiração de um dos %d uploads abertos", ErrTooManyChunkedUploads, open)
+	}
+	s.chunked[upload.id] = upload
+	s.chunkedMu.Unlock()
+
+	return s.chunkedStatus(upload), nil
+}
+
+// WriteChunk stores length bytes of the upload starting at offset. Sending a part again
+// rewrites the same bytes, so retries after a dropped connection are harmless. The part is
+// copied without holding the upload's lock, so parts may be sent in parallel.
+func (s *UploadService) WriteChunk(userID, uploadID string, offset, length int64, reader io.Reader) (*ChunkedUploadStatus, error) {
+	upload, err := s.getChunkedUpload(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || length <= 0 || offset+length > upload.size {
+		return nil, fmt.Errorf("%w: bytes %d-%d fora do arquivo de %d bytes", ErrInvalidChunk, offset, offset+length-1, upload.size)
+	}
+
+	upload.mu.Lock()
+	if upload.closed {
+		upload.mu.Unlock()
+		return nil, ErrChunkedUploadNotFound
+	}
+	upload.writing++
+	upload.mu.Unlock()
+
+	writeErr := writeChunkAt(upload.partPath, offset, length, reader)
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	upload.writing--
+	if upload.closed {
+		return nil, ErrChunkedUploadNotFound
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	upload.received = addByteRange(upload.received, ByteRange{Start: offset, End: offset + length - 1})
+	upload.updatedAt = s.now()
+
+	return s.chunkedStatus(upload), nil
+}
+
+// writeChunkAt copies length bytes from reader to the file at offset
+func writeChunkAt(path string, offset, length int64, reader io.Reader) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo temporário: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("erro ao gravar parte: %w", err)
+	}
+	written, err := io.CopyN(file, reader, length)
+	if err != nil {
+		// Only whole parts count as received; a short part is sent again by the client
+		return fmt.Errorf("%w: recebidos %d de %d bytes", ErrInvalidChunk, written, length)
+	}
+	return nil
+}
+
+// GetChunkedUploadStatus returns the received and missing ranges of an upload, so an
+// interrupted client knows what to send again
+func (s *UploadService) GetChunkedUploadStatus(userID, uploadID string) (*ChunkedUploadStatus, error) {
+	upload, err := s.getChunkedUpload(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if upload.closed {
+		return nil, ErrChunkedUploadNotFound
+	}
+	return s.chunkedStatus(upload), nil
+}
+
+// CompleteChunkedUpload processes the assembled file like a single-request upload. It
+// fails with ErrUploadIncomplete while parts are missing; once processing starts the
+// parts are discarded, whatever the result.
+func (s *UploadService) CompleteChunkedUpload(ctx context.Context, userID, uploadID string) (*FileUpload, error) {
+	upload, err := s.getChunkedUpload(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if upload.closed {
+		return nil, ErrChunkedUploadNotFound
+	}
+
+	if missing := missingByteRanges(upload.received, upload.size); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: faltam %d intervalo(s) de bytes", ErrUploadIncomplete, len(missing))
+	}
+	if upload.writing > 0 {
+		// A part sent again is still being copied over the file
+		return nil, fmt.Errorf("%w: %d parte(s) ainda em gravação", ErrUploadIncomplete, upload.writing)
+	}
+
+	s.closeChunkedUpload(upload)
+	defer os.Remove(upload.partPath)
+
+	file, err := os.Open(upload.partPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo temporário: %w", err)
+	}
+	defer file.Close()
+
+	return s.ProcessFileContext(ctx, upload.filename, file, upload.size, upload.opts)
+}
+
+// cleanupChunkedUploads discards uploads without new parts for longer than the temp file
+// TTL and returns how many uploads and bytes were removed
+func (s *UploadService) cleanupChunkedUploads(now time.Time) (int, int64) {
+	s.chunkedMu.Lock()
+	uploads := make([]*chunkedUpload, 0, len(s.chunked))
+	for _, upload := range s.chunked {
+		uploads = append(uploads, upload)
+	}
+	s.chunkedMu.Unlock()
+
+	removed := 0
+	var reclaimed int64
+	for _, upload := range uploads {
+		upload.mu.Lock()
+		if !upload.closed && upload.writing == 0 && now.Sub(upload.updatedAt) > s.tempFileTTL {
+			s.closeChunkedUpload(upload)
+			if info, err := os.Stat(upload.partPath); err == nil {
+				reclaimed += info.Size()
+			}
+			os.Remove(upload.partPath)
+			removed++
+		}
+		upload.mu.Unlock()
+	}
+	return removed, reclaimed
+}
+
+// closeChunkedUpload forgets an upload; the caller holds upload.mu
+func (s *UploadService) closeChunkedUpload(upload *chunkedUpload) {
+	upload.closed = true
+	s.chunkedMu.Lock()
+	delete(s.chunked, upload.id)
+	s.chunkedMu.Unlock()
+}
+
+// chunkPartDir is where the parts of chunked uploads are assembled
+func (s *UploadService) chunkPartDir() string {
+	return filepath.Join(s.tempDir, chunkPartDir)
+}
+
+// removeLeftoverChunkParts deletes parts of chunked uploads from a previous run: their
+// state was in memory, so they can't be completed anymore. Only parts untouched for
+// longer than the temp file TTL are removed, since another instance sharing the temp
+// dir may still be receiving its own.
+func (s *UploadService) removeLeftoverChunkParts() {
+	matches, err := filepath.Glob(filepath.Join(s.chunkPartDir(), chunkPartPrefix+"*.part"))
+	if err != nil {
+		return
+	}
+	now := s.now()
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) > s.tempFileTTL {
+			os.Remove(path)
+		}
+	}
+}
+
+// getChunkedUpload finds an upload of the user; other users' uploads are reported as not found
+func (s *UploadService) getChunkedUpload(userID, uploadID string) (*chunkedUpload, error) {
+	s.chunkedMu.Lock()
+	defer s.chunkedMu.Unlock()
+
+	upload, ok := s.chunked[uploadID]
+	if !ok || upload.userID != userID {
+		return nil, ErrChunkedUploadNotFound
+	}
+	return upload, nil
+}
+
+// chunkedStatus summarizes an upload; the caller holds upload.mu (or owns the upload)
+func (s *UploadService) chunkedStatus(upload *chunkedUpload) *ChunkedUploadStatus {
+	var received int64
+	for _, r := range upload.received {
+		received += r.End - r.Start + 1
+	}
+	missing := missingByteRanges(upload.received, upload.size)
+	return &ChunkedUploadStatus{
+		UploadID:  upload.id,
+		Filename:  upload.filename,
+		Size:      upload.size,
+		Received:  received,
+		Missing:   missing,
+		Complete:  len(missing) == 0,
+		ExpiresAt: upload.updatedAt.Add(s.tempFileTTL),
+	}
+}
+
+// addByteRange adds r to a sorted list of disjoint ranges, merging overlapping and adjacent ones
+func addByteRange(ranges []ByteRange, r ByteRange) []ByteRange {
+	ranges = append(ranges, r)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := ranges[:1]
+	for _, next := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if next.Start <= last.End+1 {
+			if next.End > last.End {
+				last.End = next.End
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// missingByteRanges returns the gaps left by the received ranges in a file of size bytes
+func missingByteRanges(received []ByteRange, size int64) []ByteRange {
+	var missing []ByteRange
+	var next int64
+	for _, r := range received {
+		if r.Start > next {
+			missing = append(missing, ByteRange{Start: next, End: r.Start - 1})
+		}
+		next = r.End + 1
+	}
+	if next < size {
+		missing = append(missing, ByteRange{Start: next, End: size - 1})
+	}
+	return missing
+}
+
+// newChunkedUploadID generates a random upload identifier
+func newChunkedUploadID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
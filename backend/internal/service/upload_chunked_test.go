@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func chunkedCSV(rows int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("id task,Valor\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&buf, "task%05d,%d\n", i, i)
+	}
+	return buf.Bytes()
+}
+
+func TestChunkedUploadAssemblesOutOfOrderParts(t *testing.T) {
+	svc := NewUploadService(t.TempDir(), 0)
+	content := chunkedCSV(500)
+	size := int64(len(content))
+
+	status, err := svc.InitChunkedUpload("ana", "grande.csv", size, UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitChunkedUpload: %v", err)
+	}
+	if status.Complete || len(status.Missing) != 1 || status.Missing[0] != (ByteRange{0, size - 1}) {
+		t.Fatalf("status inicial inesperado: %+v", status)
+	}
+
+	// Quatro partes, enviadas fora de ordem e com a segunda repetida
+	part := size / 4
+	ranges := []ByteRange{{2 * part, 3*part - 1}, {0, part - 1}, {3 * part, size - 1}, {part, 2*part - 1}, {part, 2*part - 1}}
+	for i, r := range ranges {
+		status, err = svc.WriteChunk("ana", status.UploadID, r.Start, r.End-r.Start+1, bytes.NewReader(content[r.Start:r.End+1]))
+		if err != nil {
+			t.Fatalf("WriteChunk %d: %v", i, err)
+		}
+		if i == 2 {
+			if status.Complete || len(status.Missing) != 1 || status.Missing[0] != (ByteRange{part, 2*part - 1}) {
+				t.Errorf("faltando após 3 partes: %+v", status.Missing)
+			}
+		}
+	}
+	if !status.Complete || status.Received != size {
+		t.Fatalf("status final inesperado: %+v", status)
+	}
+
+	result, err := svc.CompleteChunkedUpload(context.Background(), "ana", status.UploadID)
+	if err != nil {
+		t.Fatalf("CompleteChunkedUpload: %v", err)
+	}
+	if result.TotalRows != 500 || result.Size != size || strings.Join(result.Columns, ",") != "id task,Valor" {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+
+	_, rows, err := svc.GetFileData(result.TempPath)
+	if err != nil {
+		t.Fatalf("GetFileData: %v", err)
+	}
+	if rows[499][0] != "task00499" {
+		t.Errorf("última linha = %v", rows[499])
+	}
+
+	// Concluído, o upload não aceita mais partes e suas partes foram removidas
+	if _, err := svc.WriteChunk("ana", status.UploadID, 0, 1, bytes.NewReader(content[:1])); !errors.Is(err, ErrChunkedUploadNotFound) {
+		t.Errorf("esperava ErrChunkedUploadNotFound, obteve %v", err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*")); len(leftovers) != 0 {
+		t.Errorf("partes não removidas: %v", leftovers)
+	}
+}
+
+func TestChunkedUploadRejectsIncompleteAndInvalidParts(t *testing.T) {
+	svc := NewUploadService(t.TempDir(), 1024)
+	content := chunkedCSV(10)
+	size := int64(len(content))
+
+	if _, err := svc.InitChunkedUpload("ana", "dados.txt", size, UploadOptions{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("formato: esperava ErrUnsupportedType, obteve %v", err)
+	}
+	if _, err := svc.InitChunkedUpload("ana", "dados.csv", 2048, UploadOptions{}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("tamanho: esperava ErrFileTooLarge, obteve %v", err)
+	}
+
+	status, err := svc.InitChunkedUpload("ana", "dados.csv", size, UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitChunkedUpload: %v", err)
+	}
+	id := status.UploadID
+
+	if _, err := svc.WriteChunk("ana", id, size-2, 4, bytes.NewReader([]byte("abcd"))); !errors.Is(err, ErrInvalidChunk) {
+		t.Errorf("parte além do fim: esperava ErrInvalidChunk, obteve %v", err)
+	}
+	// Corpo menor que o informado: a parte não conta como recebida
+	if _, err := svc.WriteChunk("ana", id, 0, 10, bytes.NewReader(content[:4])); !errors.Is(err, ErrInvalidChunk) {
+		t.Errorf("parte curta: esperava ErrInvalidChunk, obteve %v", err)
+	}
+	if _, err := svc.WriteChunk("bruno", id, 0, size, bytes.NewReader(content)); !errors.Is(err, ErrChunkedUploadNotFound) {
+		t.Errorf("outro usuário: esperava ErrChunkedUploadNotFound, obteve %v", err)
+	}
+
+	if _, err := svc.WriteChunk("ana", id, 0, size/2, bytes.NewReader(content[:size/2])); err != nil {
+		t.Fatalf("WriteChunk: %v", err)
+	}
+	if _, err := svc.CompleteChunkedUpload(context.Background(), "ana", id); !errors.Is(err, ErrUploadIncomplete) {
+		t.Errorf("esperava ErrUploadIncomplete, obteve %v", err)
+	}
+
+	// Depois de uma falha, o upload continua de onde parou
+	status, err = svc.GetChunkedUploadStatus("ana", id)
+	if err != nil || len(status.Missing) != 1 || status.Missing[0].Start != size/2 {
+		t.Fatalf("status após falha: %+v, %v", status, err)
+	}
+	missing := status.Missing[0]
+	if _, err := svc.WriteChunk("ana", id, missing.Start, missing.End-missing.Start+1, bytes.NewReader(content[missing.Start:])); err != nil {
+		t.Fatalf("WriteChunk: %v", err)
+	}
+	if _, err := svc.CompleteChunkedUpload(context.Background(), "ana", id); err != nil {
+		t.Errorf("CompleteChunkedUpload: %v", err)
+	}
+}
+
+func TestChunkedUploadSweeperRemovesAbandonedUploads(t *testing.T) {
+	svc := NewUploadService(t.TempDir(), 0)
+	svc.SetTempFileTTL(time.Hour)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	svc.SetClock(func() time.Time { return now })
+
+	content := chunkedCSV(5)
+	abandoned, _ := svc.InitChunkedUpload("ana", "a.csv", int64(len(content)), UploadOptions{})
+	svc.WriteChunk("ana", abandoned.UploadID, 0, 10, bytes.NewReader(content[:10]))
+
+	now = now.Add(50 * time.Minute)
+	active, _ := svc.InitChunkedUpload("ana", "b.csv", int64(len(content)), UploadOptions{})
+
+	now = now.Add(20 * time.Minute)
+	removed, reclaimed := svc.CleanupExpiredFiles()
+	if removed != 1 || reclaimed != 10 {
+		t.Errorf("CleanupExpiredFiles = %d, %d; esperava 1, 10", removed, reclaimed)
+	}
+	if _, err := svc.GetChunkedUploadStatus("ana", abandoned.UploadID); !errors.Is(err, ErrChunkedUploadNotFound) {
+		t.Errorf("upload abandonado: esperava ErrChunkedUploadNotFound, obteve %v", err)
+	}
+	if _, err := svc.GetChunkedUploadStatus("ana", active.UploadID); err != nil {
+		t.Errorf("upload ativo removido: %v", err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*")); len(leftovers) != 1 {
+		t.Errorf("esperava só a parte do upload ativo, obteve %v", leftovers)
+	}
+
+	// Na inicialização, outra instância com o mesmo diretório temporário mantém a parte
+	// recente e remove só as antigas, de uma execução anterior
+	other := NewUploadService(svc.tempDir, 0)
+	other.SetTempFileTTL(time.Hour)
+	other.removeLeftoverChunkParts()
+	if leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*")); len(leftovers) != 1 {
+		t.Fatalf("parte recente removida: %v", leftovers)
+	}
+
+	stale := time.Now().Add(-2 * time.Hour)
+	leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*"))
+	os.Chtimes(leftovers[0], stale, stale)
+	other.removeLeftoverChunkParts()
+	if leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*")); len(leftovers) != 0 {
+		t.Errorf("partes antigas não removidas: %v", leftovers)
+	}
+}
+
+func TestChunkedUploadLimitPerUser(t *testing.T) {
+	svc := NewUploadService(t.TempDir(), 0)
+	content := chunkedCSV(5)
+	size := int64(len(content))
+
+	var first *ChunkedUploadStatus
+	for i := 0; i < maxChunkedUploadsPerUser; i++ {
+		status, err := svc.InitChunkedUpload("ana", "a.csv", size, UploadOptions{})
+		if err != nil {
+			t.Fatalf("InitChunkedUpload %d: %v", i, err)
+		}
+		if first == nil {
+			first = status
+		}
+	}
+
+	if _, err := svc.InitChunkedUpload("ana", "a.csv", size, UploadOptions{}); !errors.Is(err, ErrTooManyChunkedUploads) {
+		t.Fatalf("esperava ErrTooManyChunkedUploads, obteve %v", err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(svc.chunkPartDir(), chunkPartPrefix+"*")); len(leftovers) != maxChunkedUploadsPerUser {
+		t.Errorf("parte do upload recusado não removida: %v", leftovers)
+	}
+
+	// O limite é por usuário
+	if _, err := svc.InitChunkedUpload("bia", "a.csv", size, UploadOptions{}); err != nil {
+		t.Errorf("outro usuário: %v", err)
+	}
+
+	// Concluir um upload libera uma vaga
+	if _, err := svc.WriteChunk("ana", first.UploadID, 0, size, bytes.NewReader(content)); err != nil {
+		t.Fatalf("WriteChunk: %v", err)
+	}
+	if _, err := svc.CompleteChunkedUpload(context.Background(), "ana", first.UploadID); err != nil {
+		t.Fatalf("CompleteChunkedUpload: %v", err)
+	}
+	if _, err := svc.InitChunkedUpload("ana", "a.csv", size, UploadOptions{}); err != nil {
+		t.Errorf("InitChunkedUpload após concluir: %v", err)
+	}
+}
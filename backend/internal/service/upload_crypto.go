@@ -0,0 +1,316 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Uploads can be encrypted at rest with the same AES-GCM key used for ClickUp tokens.
+// The file is written in plaintext only to the local staging copy scanned during the
+// upload request; it is sealed while being copied to the file store, and every later read
+// decrypts it, segment by segment, into a local copy removed when the file is closed.
+// Sealed files start with uploadEncryptionMagic, so plaintext files left from before the
+// option was enabled are still readable, as are files sealed whole by earlier versions
+// (uploadEncryptionMagicV1).
+
+const (
+	// uploadEncryptionMagic marks an encrypted temp file, followed by the nonce prefix and
+	// the sealed segments
+	uploadEncryptionMagic = "CUXENC2\x00"
+	// uploadEncryptionMagicV1 marks a temp file sealed whole (nonce+ciphertext)
+	uploadEncryptionMagicV1 = "CUXENC1\x00"
+	// uploadSegmentSize is the plaintext size of each sealed segment but the last
+	uploadSegmentSize = 64 * 1024
+	// uploadNoncePrefixSize is the random part of each segment's nonce; the rest is the
+	// segment number (4 bytes) and whether it is the last one (1 byte)
+	uploadNoncePrefixSize = 7
+	// decryptedFilePrefix names the local plaintext copy of an encrypted temp file being read
+	decryptedFilePrefix = "decrypted_"
+)
+
+// ErrUploadKeyMissing indicates an encrypted temp file read without an encryption key
+var ErrUploadKeyMissing = errors.New("arquivo temporário criptografado, mas a criptografia de uploads está desativada")
+
+// SetEncryptionKey enables at-rest encryption of uploaded temp files (empty disables it)
+func (s *UploadService) SetEncryptionKey(key string) {
+	if key == "" {
+		s.encryptionKey = nil
+		return
+	}
+	s.encryptionKey = deriveTokenKey(key)
+}
+
+// segmentNonce is the nonce of segment i: the file's random prefix, the segment number
+// and a flag for the last segment, so reordered or truncated segments fail to open
+func segmentNonce(prefix []byte, i uint32, last bool) []byte {
+	nonce := make([]byte, uploadNoncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[uploadNoncePrefixSize:], i)
+	if last {
+		nonce[uploadNoncePrefixSize+4] = 1
+	}
+	return nonce
+}
+
+// sealingReader encrypts a plaintext stream as it is read
+type sealingReader struct {
+	gcm    cipher.AEAD
+	src    *bufio.Reader
+	prefix []byte
+	index  uint32
+	plain  []byte
+	out    []byte // sealed bytes not read yet
+	done   bool
+}
+
+// sealReader returns src encrypted when encryption is enabled
+func (s *UploadService) sealReader(src io.Reader) (io.Reader, error) {
+	if s.encryptionKey == nil {
+		return src, nil
+	}
+	gcm, err := newGCM(s.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar arquivo: %w", err)
+	}
+	prefix := make([]byte, uploadNoncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, fmt.Errorf("erro ao criptografar arquivo: %w", err)
+	}
+	return &sealingReader{
+		gcm:    gcm,
+		src:    bufio.NewReaderSize(src, uploadSegmentSize),
+		prefix: prefix,
+		plain:  make([]byte, uploadSegmentSize),
+		out:    append([]byte(uploadEncryptionMagic), prefix...),
+	}, nil
+}
+
+func (r *sealingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		last := n < len(r.plain)
+		if !last {
+			// A full segment is the last one when nothing follows it
+			if _, err := r.src.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		r.out = r.gcm.Seal(r.out[:0], segmentNonce(r.prefix, r.index, last), r.plain[:n], nil)
+		r.index++
+		r.done = last
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// writeTempFile replaces the content of a temp file, encrypting it when enabled
+func (s *UploadService) writeTempFile(path string, content []byte) error {
+	return s.putTempFile(path, bytes.NewReader(content))
+}
+
+// putTempFile stores a temp file from a stream, encrypting it when enabled
+func (s *UploadService) putTempFile(path string, content io.Reader) error {
+	sealed, err := s.sealReader(content)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(tempFileKey(path), sealed); err != nil {
+		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+	return nil
+}
+
+// decryptedFile is the local plaintext copy of an encrypted temp file, removed on Close
+type decryptedFile struct {
+	*os.File
+}
+
+func (f *decryptedFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// openTempFile opens a temp file for reading, decrypting it if needed. Encrypted files
+// are returned as a *decryptedFile.
+func (s *UploadService) openTempFile(path string) (io.ReadCloser, error) {
+	file, err := s.store.Get(tempFileKey(path))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+
+	magic := make([]byte, len(uploadEncryptionMagic))
+	n, err := io.ReadFull(file, magic)
+	if err != nil || (string(magic) != uploadEncryptionMagic && string(magic) != uploadEncryptionMagicV1) {
+		// Plaintext: hand back what was peeked followed by the rest
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(magic[:n]), file), file}, nil
+	}
+	defer file.Close()
+
+	if s.encryptionKey == nil {
+		return nil, ErrUploadKeyMissing
+	}
+	gcm, err := newGCM(s.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if string(magic) == uploadEncryptionMagicV1 {
+		return openSealedV1(gcm, file)
+	}
+
+	plain, err := os.CreateTemp(s.tempDir, decryptedFilePrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	decrypted := &decryptedFile{File: plain}
+	if err := decryptSegments(gcm, file, plain); err != nil {
+		decrypted.Close()
+		return nil, err
+	}
+	if _, err := plain.Seek(0, io.SeekStart); err != nil {
+		decrypted.Close()
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	return decrypted, nil
+}
+
+// decryptSegments writes the plaintext of a sealed stream (after its magic) to dst
+func decryptSegments(gcm cipher.AEAD, src io.Reader, dst io.Writer) error {
+	reader := bufio.NewReaderSize(src, uploadSegmentSize+gcm.Overhead())
+	prefix := make([]byte, uploadNoncePrefixSize)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return ErrInvalidFile
+	}
+
+	segment := make([]byte, uploadSegmentSize+gcm.Overhead())
+	var plain []byte
+	for i := uint32(0); ; i++ {
+		n, err := io.ReadFull(reader, segment)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("erro ao abrir arquivo: %w", err)
+		}
+		last := n < len(segment)
+		if !last {
+			if _, err := reader.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return fmt.Errorf("erro ao abrir arquivo: %w", err)
+			}
+		}
+
+		plain, err = gcm.Open(plain[:0], segmentNonce(prefix, i, last), segment[:n], nil)
+		if err != nil {
+			return fmt.Errorf("erro ao descriptografar arquivo: %w", err)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return fmt.Errorf("erro ao descriptografar arquivo: %w", err)
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// openSealedV1 decrypts a file sealed whole by earlier versions (after its magic)
+func openSealedV1(gcm cipher.AEAD, src io.Reader) (io.ReadCloser, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrInvalidFile
+	}
+	content, err := gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao descriptografar arquivo: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// readTempFile returns the whole (decrypted) content of a temp file
+func (s *UploadService) readTempFile(path string) ([]byte, error) {
+	file, err := s.openTempFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	return content, nil
+}
+
+// openWorkbook opens an XLSX temp file, decrypting it if needed
+func (s *UploadService) openWorkbook(path string) (*excelize.File, error) {
+	file, err := s.openTempFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return excelize.OpenReader(file)
+}
+
+// saveWorkbook writes a modified workbook back to its temp file
+func (s *UploadService) saveWorkbook(f *excelize.File, path string) error {
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return err
+	}
+	return s.writeTempFile(path, buf.Bytes())
+}
+
+// openODS opens an ODS temp file as a zip archive, decrypting it if needed;
+// closeFn releases the file
+func (s *UploadService) openODS(path string) (zr *zip.Reader, closeFn func() error, err error) {
+	file, err := s.openTempFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if decrypted, ok := file.(*decryptedFile); ok {
+		info, err := decrypted.Stat()
+		if err == nil {
+			zr, err = zip.NewReader(decrypted, info.Size())
+		}
+		if err != nil {
+			decrypted.Close()
+			return nil, nil, err
+		}
+		return zr, decrypted.Close, nil
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	zr, err = zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return zr, func() error { return nil }, nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSV encodings reported in FileFormat
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	// EncodingLatin1 covers files that are not valid UTF-8, usually exported by Excel on
+	// Windows (Windows-1252/ISO-8859-1); they are read as is, so accents are garbled
+	EncodingLatin1 = "iso-8859-1"
+)
+
+const (
+	// csvDelimiter is the delimiter CSV uploads are read with unless another one is detected
+	csvDelimiter = ","
+	// formatSampleSize is how much of the file is inspected to detect its format
+	formatSampleSize = 64 * 1024
+	// formatSampleRows is how many rows after the header must keep its width for a delimiter to be chosen
+	formatSampleRows = 10
+	// utf8BOM starts CSV files saved as "UTF-8 with BOM" (Excel does it)
+	utf8BOM = "\xef\xbb\xbf"
+)
+
+// delimiterCandidates are the delimiters recognized in a header, in order of preference on ties
+var delimiterCandidates = []string{",", ";", "\t", "|"}
+
+// FileFormat describes how a CSV upload was read, so a mapping that doesn't match the
+// parsed columns can be explained. It is detected once, when the file is processed.
+type FileFormat struct {
+	// Delimiter is the delimiter the file is read with
+	Delimiter string `json:"delimiter"`
+	Encoding  string `json:"encoding"`
+	// HeaderLine is the raw text of the header row, exactly as in the file (converted to UTF-8)
+	HeaderLine string `json:"header_line"`
+}
+
+// comma is the delimiter as the CSV reader takes it
+func (f *FileFormat) comma() rune {
+	if f == nil || f.Delimiter == "" {
+		return rune(csvDelimiter[0])
+	}
+	return rune(f.Delimiter[0])
+}
+
+// delimiterNames describes each candidate in warnings
+var delimiterNames = map[string]string{
+	",":  "vírgula (,)",
+	";":  "ponto e vírgula (;)",
+	"\t": "tabulação",
+	"|":  "barra vertical (|)",
+}
+
+// warnings explains format problems that may make columns not match a mapping;
+// missingColumns tells whether some mapped column was not found
+func (f *FileFormat) warnings(missingColumns bool) []string {
+	var warnings []string
+	if missingColumns && f.Delimiter != csvDelimiter {
+		warnings = append(warnings, fmt.Sprintf(
+			"o arquivo foi lido separado por %s, e não por vírgula: confira na linha de cabeçalho se as colunas não encontradas foram separadas corretamente",
+			delimiterNames[f.Delimiter]))
+	}
+	if f.Encoding == EncodingLatin1 {
+		warnings = append(warnings, "o arquivo não está em UTF-8 (parece "+EncodingLatin1+"): nomes de colunas com acentos podem não corresponder (salve o CSV como UTF-8)")
+	}
+	return warnings
+}
+
+// GetFileFormat returns how a CSV upload was read, as detected when it was processed.
+// Spreadsheets have no such format and return nil.
+func (s *UploadService) GetFileFormat(tempPath string) (*FileFormat, error) {
+	opts, err := s.loadLayout(tempPath)
+	if err != nil {
+		return nil, err
+	}
+	return opts.Format, nil
+}
+
+// detectCSVFormat inspects the beginning of a CSV file: its encoding and the delimiter
+// that splits the header (found after the layout offsets) into the most columns while
+// the first rows keep the header's width, with a comma on ties. The sample is read by
+// the same CSV reader used to parse uploads, once per candidate.
+func (s *UploadService) detectCSVFormat(filePath string, opts UploadOptions) (*FileFormat, error) {
+	file, err := s.openTempFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sample, err := io.ReadAll(io.LimitReader(file, formatSampleSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(sample) == formatSampleSize {
+		// The last line may have been cut in the middle (even in the middle of a character)
+		if end := bytes.LastIndexByte(sample, '\n'); end > 0 {
+			sample = sample[:end+1]
+		}
+	}
+
+	format := &FileFormat{Delimiter: csvDelimiter, Encoding: EncodingUTF8}
+	switch {
+	case bytes.HasPrefix(sample, []byte(utf8BOM)):
+		format.Encoding = EncodingUTF8BOM
+	case !utf8.Valid(sample):
+		format.Encoding = EncodingLatin1
+	}
+
+	best := 0
+	for i, candidate := range delimiterCandidates {
+		width, line, consistent := sampleHeader(sample, rune(candidate[0]), opts)
+		if i == 0 {
+			format.HeaderLine = line // kept when no candidate reads the rows consistently
+		}
+		if consistent && width > best {
+			best = width
+			format.Delimiter = candidate
+			format.HeaderLine = line
+		}
+	}
+
+	format.HeaderLine = strings.TrimPrefix(format.HeaderLine, utf8BOM)
+	if format.Encoding == EncodingLatin1 {
+		format.HeaderLine = latin1ToUTF8(format.HeaderLine)
+	}
+	return format, nil
+}
+
+// sampleHeader reads the header of a file sample with the given delimiter, returning
+// its width and raw lines, and whether the first rows after it have the same width
+func sampleHeader(sample []byte, comma rune, opts UploadOptions) (int, string, bool) {
+	reader := newCSVReader(bytes.NewReader(sample), comma)
+	header, err := readCSVHeader(reader, opts)
+	if err != nil || len(header) == 0 {
+		return 0, "", false
+	}
+
+	first, _ := reader.FieldPos(0)
+	last, _ := reader.FieldPos(len(header) - 1)
+	lines := strings.Split(string(sample), "\n")
+	if last > len(lines) {
+		last = len(lines)
+	}
+	raw := make([]string, 0, last-first+1)
+	for _, line := range lines[first-1 : last] {
+		raw = append(raw, strings.TrimSuffix(line, "\r"))
+	}
+
+	// readCSVHeader makes the reader require the header's width
+	for i := 0; i < formatSampleRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			if errors.Is(err, csv.ErrFieldCount) {
+				return len(header), strings.Join(raw, "\n"), false
+			}
+			break
+		}
+	}
+	return len(header), strings.Join(raw, "\n"), true
+}
+
+// newCSVReader returns the reader for CSV uploads with the given delimiter. Leading
+// spaces are trimmed, except with tabs, where that would merge empty fields.
+func newCSVReader(r io.Reader, comma rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = comma != '\t'
+	return reader
+}
+
+// latin1ToUTF8 converts ISO-8859-1 text, where every byte is a character
+func latin1ToUTF8(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/repository"
+)
+
+// customFieldList serve campos personalizados da memória, como a tabela custom_fields
+type customFieldList []repository.CustomField
+
+func (l customFieldList) GetCustomFields() ([]repository.CustomField, error) {
+	return l, nil
+}
+
+func uploadCSV(t *testing.T, svc *UploadService, content string, opts UploadOptions) *FileUpload {
+	t.Helper()
+	upload, err := svc.ProcessFileWithOptions("dados.csv", strings.NewReader(content), int64(len(content)), opts)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	return upload
+}
+
+func TestSemicolonFileIsParsedWithItsDelimiter(t *testing.T) {
+	svc := NewUploadService(t.TempDir(), 0)
+	upload := uploadCSV(t, svc, "id task;Valor;Status\nabc;10;ok\n", UploadOptions{})
+
+	if strings.Join(upload.Columns, "|") != "id task|Valor|Status" {
+		t.Errorf("colunas = %q", upload.Columns)
+	}
+	columns, rows, err := svc.GetFileData(upload.TempPath)
+	if err != nil {
+		t.Fatalf("GetFileData: %v", err)
+	}
+	if len(columns) != 3 || len(rows) != 1 || strings.Join(rows[0], "|") != "abc|10|ok" {
+		t.Errorf("dados = %q %q", columns, rows)
+	}
+	format, err := svc.GetFileFormat(upload.TempPath)
+	if err != nil {
+		t.Fatalf("GetFileFormat: %v", err)
+	}
+	want := FileFormat{Delimiter: ";", Encoding: EncodingUTF8, HeaderLine: "id task;Valor;Status"}
+	if format == nil || *format != want {
+		t.Fatalf("formato = %+v, esperado %+v", format, want)
+	}
+
+	mappingService := NewMappingService(customFieldList{{ID: "f-valor", Name: "Valor", Type: "number"}})
+	validate := func(column string) *MappingValidationResult {
+		t.Helper()
+		result, err := mappingService.ValidateMapping(&MappingRequest{
+			FilePath:   upload.TempPath,
+			Mappings:   []ColumnMapping{{Column: "id task", IsTaskID: true}, {Column: column, FieldID: "f-valor"}},
+			SampleRows: rows,
+			Format:     format,
+		}, columns)
+		if err != nil {
+			t.Fatalf("ValidateMapping: %v", err)
+		}
+		return result
+	}
+
+	// As colunas do arquivo separado por ponto e vírgula casam com o mapeamento
+	if result := validate("Valor"); !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("mapeamento deveria ser válido e sem avisos: %+v", result)
+	}
+
+	// Uma coluna esperada que não existe explica como o arquivo foi lido
+	result := validate("Valor,Status")
+	if result.Valid {
+		t.Error("mapeamento deveria ser inválido: a coluna não existe no arquivo lido")
+	}
+	if result.File == nil || result.File.HeaderLine != "id task;Valor;Status" {
+		t.Errorf("linha de cabeçalho não retornada: %+v", result.File)
+	}
+	found := false
+	for _, warning := range result.Warnings {
+		if strings.Contains(warning, "ponto e vírgula") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("esperava aviso de delimitador, avisos: %v", result.Warnings)
+	}
+}
+
+func TestDetectFileFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		opts     UploadOptions
+		want     FileFormat
+		columns  int
+		warnings int // with missing columns
+	}{
+		{
+			name:    "vírgula",
+			content: "id task,Valor\r\nabc,10\r\n",
+			want:    FileFormat{Delimiter: ",", Encoding: EncodingUTF8, HeaderLine: "id task,Valor"},
+			columns: 2,
+		},
+		{
+			name:    "BOM",
+			content: "\xef\xbb\xbfid task,Valor\nabc,10\n",
+			want:    FileFormat{Delimiter: ",", Encoding: EncodingUTF8BOM, HeaderLine: "id task,Valor"},
+			columns: 2,
+		},
+		{
+			name:     "latin-1",
+			content:  "id task,Descri\xe7\xe3o\nabc,x\n",
+			want:     FileFormat{Delimiter: ",", Encoding: EncodingLatin1, HeaderLine: "id task,Descrição"},
+			columns:  2,
+			warnings: 1,
+		},
+		{
+			name:     "tabulação com vírgula entre aspas",
+			content:  "id task\t\"Valor, total\"\nabc\t10\n",
+			want:     FileFormat{Delimiter: "\t", Encoding: EncodingUTF8, HeaderLine: "id task\t\"Valor, total\""},
+			columns:  2,
+			warnings: 1,
+		},
+		{
+			name:    "quebra de linha entre aspas no cabeçalho",
+			content: "\"id\ntask\",Valor;total\nabc,10\n",
+			want:    FileFormat{Delimiter: ",", Encoding: EncodingUTF8, HeaderLine: "\"id\ntask\",Valor;total"},
+			columns: 2,
+		},
+		{
+			name:    "cabeçalho após linhas ignoradas",
+			content: "Relatório mensal\n\nid task,Valor\nabc,10\n",
+			opts:    UploadOptions{SkipRows: 1},
+			want:    FileFormat{Delimiter: ",", Encoding: EncodingUTF8, HeaderLine: "id task,Valor"},
+			columns: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewUploadService(t.TempDir(), 0)
+			upload := uploadCSV(t, svc, tt.content, tt.opts)
+
+			format, err := svc.GetFileFormat(upload.TempPath)
+			if err != nil {
+				t.Fatalf("GetFileFormat: %v", err)
+			}
+			if format == nil || *format != tt.want {
+				t.Fatalf("formato = %+v, esperado %+v", format, tt.want)
+			}
+			if len(upload.Columns) != tt.columns {
+				t.Errorf("colunas = %q, esperadas %d", upload.Columns, tt.columns)
+			}
+			if warnings := format.warnings(true); len(warnings) != tt.warnings {
+				t.Errorf("avisos = %v, esperado %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
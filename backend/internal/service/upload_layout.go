@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/filestore"
+)
+
+// Header layout errors
+var (
+	ErrInvalidLayout  = errors.New("header_row e skip_rows devem ser números não negativos")
+	ErrHeaderNotFound = errors.New("linha de cabeçalho não encontrada (arquivo tem menos linhas que o indicado)")
+)
+
+// UploadOptions controls how an uploaded file is parsed
+type UploadOptions struct {
+	// Sheet is the XLSX tab to parse ("" means the first one)
+	Sheet string `json:"sheet,omitempty"`
+	// SkipRows is the number of leading rows discarded before looking for the header
+	SkipRows int `json:"skip_rows,omitempty"`
+	// HeaderRow is the 1-based header row counted after SkipRows (0 means the first)
+	HeaderRow int `json:"header_row,omitempty"`
+	// PreviewRows is the number of rows returned in the preview (0 means PreviewRows,
+	// larger values are capped at MaxPreviewRows); not part of the stored layout
+	PreviewRows int `json:"-"`
+	// Format is how a CSV file is read, detected when it is processed (nil for spreadsheets)
+	Format *FileFormat `json:"format,omitempty"`
+}
+
+// Validate checks the header offsets and the preview size
+func (o UploadOptions) Validate() error {
+	if o.SkipRows < 0 || o.HeaderRow < 0 {
+		return ErrInvalidLayout
+	}
+	if o.PreviewRows < 0 {
+		return ErrInvalidPreviewRows
+	}
+	return nil
+}
+
+// previewRowCount is the effective number of preview rows
+func (o UploadOptions) previewRowCount() int {
+	switch {
+	case o.PreviewRows <= 0:
+		return PreviewRows
+	case o.PreviewRows > MaxPreviewRows:
+		return MaxPreviewRows
+	}
+	return o.PreviewRows
+}
+
+// headerOffset is the number of non-blank rows that precede the header
+func (o UploadOptions) headerOffset() int {
+	offset := o.SkipRows
+	if o.HeaderRow > 1 {
+		offset += o.HeaderRow - 1
+	}
+	return offset
+}
+
+// hasLayout reports whether the header is not simply the first non-blank row
+func (o UploadOptions) hasLayout() bool {
+	return o.headerOffset() > 0
+}
+
+// isBlankRow reports whether every cell of a row is empty. Blank rows are never
+// counted by SkipRows/HeaderRow, since the CSV reader drops empty lines anyway.
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// headerLocator walks rows until it reaches the header described by the options
+type headerLocator struct {
+	toSkip int
+	seen   bool
+}
+
+func newHeaderLocator(opts UploadOptions) *headerLocator {
+	return &headerLocator{toSkip: opts.headerOffset()}
+}
+
+// isHeader reports whether row is the header; call it for each row in order until true
+func (l *headerLocator) isHeader(row []string) bool {
+	if isBlankRow(row) {
+		return false
+	}
+	l.seen = true
+	if l.toSkip > 0 {
+		l.toSkip--
+		return false
+	}
+	return true
+}
+
+// notFound returns the error for a file that ended before the header
+func (l *headerLocator) notFound() error {
+	if !l.seen {
+		return ErrEmptyFile
+	}
+	return ErrHeaderNotFound
+}
+
+// findHeaderRow returns the index of the header row in rows
+func findHeaderRow(rows [][]string, opts UploadOptions) (int, error) {
+	locator := newHeaderLocator(opts)
+	for i, row := range rows {
+		if locator.isHeader(row) {
+			return i, nil
+		}
+	}
+	return -1, locator.notFound()
+}
+
+// splitHeader locates the header in rows and returns the cleaned column names and
+// the data rows that follow it (not normalized)
+func splitHeader(rows [][]string, opts UploadOptions) ([]string, [][]string, error) {
+	headerIndex, err := findHeaderRow(rows, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cleanColumns(rows[headerIndex]), rows[headerIndex+1:], nil
+}
+
+// layoutPath is the sidecar file that keeps the header layout of a temp file, so
+// mapping validation and job processing read it with the same offsets (and CSV
+// delimiter) as the preview
+func layoutPath(tempPath string) string {
+	return tempPath + ".layout.json"
+}
+
+// saveLayout writes the sidecar for a temp file; nothing is written for the default
+// layout of a spreadsheet
+func (s *UploadService) saveLayout(tempPath string, opts UploadOptions) error {
+	if !opts.hasLayout() && opts.Format == nil {
+		return nil
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(layoutKey(tempPath), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("erro ao salvar layout do arquivo: %w", err)
+	}
+	return nil
+}
+
+// loadLayout reads the sidecar for a temp file, returning the default layout if absent
+func (s *UploadService) loadLayout(tempPath string) (UploadOptions, error) {
+	var opts UploadOptions
+	file, err := s.store.Get(layoutKey(tempPath))
+	if err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			return opts, nil
+		}
+		return opts, fmt.Errorf("erro ao ler layout do arquivo: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return opts, fmt.Errorf("erro ao ler layout do arquivo: %w", err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("erro ao ler layout do arquivo: %w", err)
+	}
+	return opts, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import "unicode/utf8"
+
+// Column statistics limits, keeping the upload response small for wide or large files
+const (
+	// columnStatsSampleValues is how many distinct values are returned per column
+	columnStatsSampleValues = 5
+	// columnStatsSampleLength truncates long sample values (in characters)
+	columnStatsSampleLength = 100
+	// columnStatsMaxDistinct stops tracking distinct values of a column past this count
+	columnStatsMaxDistinct = 10000
+)
+
+// ColumnStats summarizes the values of a file column to help choosing mappings
+type ColumnStats struct {
+	Column        string `json:"column"`
+	DistinctCount int    `json:"distinct_count"`
+	// DistinctCapped means the column has more than columnStatsMaxDistinct distinct
+	// values and DistinctCount stopped there
+	DistinctCapped bool     `json:"distinct_capped,omitempty"`
+	EmptyCount     int      `json:"empty_count"`
+	MinLength      int      `json:"min_length"` // in characters, over non-empty values
+	MaxLength      int      `json:"max_length"`
+	SampleValues   []string `json:"sample_values"`
+}
+
+// previewBuilder collects the preview rows, the row count and the column statistics
+// in the same pass over the file
+type previewBuilder struct {
+	columns     []string
+	preview     [][]string
+	previewRows int
+	totalRows   int
+	stats       []columnStatsBuilder
+}
+
+type columnStatsBuilder struct {
+	distinct  map[string]struct{}
+	capped    bool
+	empty     int
+	minLength int
+	maxLength int
+	samples   []string
+}
+
+func newPreviewBuilder(columns []string, previewRows int) *previewBuilder {
+	stats := make([]columnStatsBuilder, len(columns))
+	for i := range stats {
+		stats[i].distinct = make(map[string]struct{})
+		stats[i].samples = make([]string, 0, columnStatsSampleValues)
+	}
+	return &previewBuilder{
+		columns:     columns,
+		preview:     make([][]string, 0, previewRows),
+		previewRows: previewRows,
+		stats:       stats,
+	}
+}
+
+// add records a data row, normalized to the header's column count
+func (b *previewBuilder) add(row []string) {
+	normalized := normalizeRow(row, len(b.columns))
+	b.totalRows++
+	if len(b.preview) < b.previewRows {
+		b.preview = append(b.preview, normalized)
+	}
+	for i, value := range normalized {
+		b.stats[i].add(value)
+	}
+}
+
+func (c *columnStatsBuilder) add(value string) {
+	if value == "" {
+		c.empty++
+		return
+	}
+
+	length := utf8.RuneCountInString(value)
+	if c.minLength == 0 || length < c.minLength {
+		c.minLength = length
+	}
+	if length > c.maxLength {
+		c.maxLength = length
+	}
+
+	if _, seen := c.distinct[value]; seen || c.capped {
+		return
+	}
+	if len(c.distinct) >= columnStatsMaxDistinct {
+		c.capped = true
+		return
+	}
+	c.distinct[value] = struct{}{}
+	if len(c.samples) < columnStatsSampleValues {
+		c.samples = append(c.samples, truncateSample(value))
+	}
+}
+
+// columnStats returns the statistics of every column, in header order
+func (b *previewBuilder) columnStats() []ColumnStats {
+	stats := make([]ColumnStats, len(b.columns))
+	for i, column := range b.columns {
+		c := b.stats[i]
+		stats[i] = ColumnStats{
+			Column:         column,
+			DistinctCount:  len(c.distinct),
+			DistinctCapped: c.capped,
+			EmptyCount:     c.empty,
+			MinLength:      c.minLength,
+			MaxLength:      c.maxLength,
+			SampleValues:   c.samples,
+		}
+	}
+	return stats
+}
+
+// truncateSample shortens a sample value to columnStatsSampleLength characters
+func truncateSample(value string) string {
+	if utf8.RuneCountInString(value) <= columnStatsSampleLength {
+		return value
+	}
+	return string([]rune(value)[:columnStatsSampleLength]) + "…"
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/filestore"
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+)
+
+// Uploaded files live in a filestore.FileStore, the temp directory by default. The
+// temp path handed to clients and saved with jobs keeps the form <temp dir>/<key>, and
+// only its base name is used as the store key, so an instance with a different temp
+// directory (or a job resumed on another pod) still finds the file in a shared store.
+
+// SetFileStore replaces the store that keeps uploaded files (e.g. S3, so every instance
+// can read them); nil restores the local temp directory
+func (s *UploadService) SetFileStore(store filestore.FileStore) {
+	if store == nil {
+		store = filestore.NewLocal(s.tempDir)
+	}
+	s.store = store
+}
+
+// ValidateTempPath checks that a path sent by a client is an upload temp path: a file
+// directly in the temp directory, without traversal. Every endpoint that accepts a
+// file path must call it before touching the file.
+func (s *UploadService) ValidateTempPath(path string) error {
+	if !middleware.ValidateFilePath(path, s.tempDir) {
+		return ErrInvalidTempPath
+	}
+	absPath, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return ErrInvalidTempPath
+	}
+	absDir, err := filepath.Abs(s.tempDir)
+	if err != nil || filepath.Dir(absPath) != absDir || !filestore.ValidKey(filepath.Base(absPath)) {
+		return ErrInvalidTempPath
+	}
+	return nil
+}
+
+// tempFileKey is the store key of a temp file
+func tempFileKey(path string) string {
+	return filepath.Base(path)
+}
+
+// layoutKey is the store key of the layout sidecar of a temp file
+func layoutKey(path string) string {
+	return filepath.Base(layoutPath(path))
+}
+
+// storeStagingFile moves a scanned staging file to the store under a new key and
+// returns its temp path
+func (s *UploadService) storeStagingFile(stagingPath, ext string) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, suffix); err != nil {
+		return "", err
+	}
+	tempPath := filepath.Join(s.tempDir, tempFilePrefix+hex.EncodeToString(suffix)+strings.ToLower(ext))
+
+	file, err := os.Open(stagingPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := s.putTempFile(tempPath, file); err != nil {
+		return "", err
+	}
+	return tempPath, nil
+}
+
+// deleteTempFile removes a temp file and its layout sidecar from the store
+func (s *UploadService) deleteTempFile(path string) error {
+	s.store.Delete(layoutKey(path))
+	return s.store.Delete(tempFileKey(path))
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/filestore"
+)
+
+// memoryStore is an in-memory filestore.FileStore shared by several services,
+// standing in for a bucket seen by every instance
+type memoryStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{files: make(map[string][]byte)}
+}
+
+func (m *memoryStore) Put(key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key] = data
+	return nil
+}
+
+func (m *memoryStore) Get(key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, filestore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	return nil
+}
+
+func (m *memoryStore) Stat(key string) (filestore.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return filestore.FileInfo{}, filestore.ErrNotFound
+	}
+	return filestore.FileInfo{Size: int64(len(data)), ModTime: time.Now()}, nil
+}
+
+func TestUploadService_SharedFileStore(t *testing.T) {
+	store := newMemoryStore()
+
+	// Two instances with their own temp directories, sharing the store
+	uploader := NewUploadService(t.TempDir(), 0)
+	uploader.SetFileStore(store)
+	processor := NewUploadService(t.TempDir(), 0)
+	processor.SetFileStore(store)
+
+	content := "Relatório de tarefas\n\nid task,Valor\nabc1,10\nabc2,20\n"
+	result, err := uploader.ProcessFileWithOptions("tarefas.csv", strings.NewReader(content), int64(len(content)), UploadOptions{SkipRows: 1})
+	if err != nil {
+		t.Fatalf("ProcessFileWithOptions: %v", err)
+	}
+
+	// Nothing is left on the uploader's disk: the file and its layout are in the store
+	entries, _ := os.ReadDir(uploader.tempDir)
+	if len(entries) != 0 {
+		t.Errorf("files left in the temp dir: %v", entries)
+	}
+	if len(store.files) != 2 {
+		t.Errorf("expected file and layout in the store, got %d entries", len(store.files))
+	}
+
+	// The other instance reads it from the same temp path, with the same layout
+	columns, data, err := processor.GetFileData(result.TempPath)
+	if err != nil {
+		t.Fatalf("GetFileData on another instance: %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id task", "Valor"}) || len(data) != 2 {
+		t.Errorf("columns %v, data %v", columns, data)
+	}
+
+	var streamed int
+	err = processor.StreamRows(result.TempPath, func(row []string) error {
+		streamed++
+		return nil
+	})
+	if err != nil || streamed != 2 {
+		t.Errorf("StreamRows: %d rows, %v", streamed, err)
+	}
+
+	if err := processor.RemoveTempFile(result.TempPath); err != nil {
+		t.Fatalf("RemoveTempFile: %v", err)
+	}
+	if len(store.files) != 0 {
+		t.Errorf("store should be empty after removal, got %d entries", len(store.files))
+	}
+	if _, _, err := uploader.GetFileData(result.TempPath); !errors.Is(err, filestore.ErrNotFound) {
+		t.Errorf("reading a removed file: %v", err)
+	}
+}
+
+func TestUploadService_SharedFileStoreEncrypted(t *testing.T) {
+	store := newMemoryStore()
+	uploader := NewUploadService(t.TempDir(), 0)
+	uploader.SetFileStore(store)
+	uploader.SetEncryptionKey("upload-encryption-key-32-bytes!!")
+	processor := NewUploadService(t.TempDir(), 0)
+	processor.SetFileStore(store)
+	processor.SetEncryptionKey("upload-encryption-key-32-bytes!!")
+
+	content := "id task,CPF\nabc1,123.456.789-00\n"
+	result, err := uploader.ProcessFile("dados.csv", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	stored := store.files[tempFileKey(result.TempPath)]
+	if !bytes.HasPrefix(stored, []byte(uploadEncryptionMagic)) || bytes.Contains(stored, []byte("123.456.789-00")) {
+		t.Error("file should be stored encrypted")
+	}
+
+	_, data, err := processor.GetFileData(result.TempPath)
+	if err != nil || len(data) != 1 || data[0][1] != "123.456.789-00" {
+		t.Errorf("GetFileData = %v, %v", data, err)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// StreamRows calls fn for each data row of a processed file (after the header, using
+// the file's layout), one row at a time, so large uploads are never fully loaded into
+// memory. Rows are normalized to the header's column count. An error returned by fn
+// stops the iteration and is returned as is.
+//
+// CSV and XLSX are read incrementally; ODS sheets are parsed in one go and then iterated.
+func (s *UploadService) StreamRows(filePath string, fn func(row []string) error) error {
+	return s.streamFile(filePath, nil, fn)
+}
+
+// GetColumns reads only the header of a processed file
+func (s *UploadService) GetColumns(filePath string) ([]string, error) {
+	var columns []string
+	err := s.streamFile(filePath, func(header []string) error {
+		columns = header
+		return errStopStream
+	}, nil)
+	if err != nil && !errors.Is(err, errStopStream) {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// errStopStream ends an iteration early without reporting an error
+var errStopStream = errors.New("stop")
+
+// streamFile reads a processed file, calling onHeader (if set) with the cleaned header
+// and then fn for every data row
+func (s *UploadService) streamFile(filePath string, onHeader func(columns []string) error, fn func(row []string) error) error {
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	opts, err := s.loadLayout(filePath)
+	if err != nil {
+		return err
+	}
+
+	switch ext {
+	case ".csv":
+		return s.streamCSV(filePath, opts, onHeader, fn)
+	case ".xlsx":
+		return s.streamXLSX(filePath, opts, onHeader, fn)
+	case ".ods":
+		columns, data, err := s.readAllODS(filePath, opts)
+		if err != nil {
+			return err
+		}
+		if onHeader != nil {
+			if err := onHeader(columns); err != nil {
+				return err
+			}
+		}
+		for _, row := range data {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ErrUnsupportedType
+	}
+}
+
+// streamCSV reads a CSV file record by record
+func (s *UploadService) streamCSV(filePath string, opts UploadOptions, onHeader func([]string) error, fn func(row []string) error) error {
+	file, err := s.openTempFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := newCSVReader(file, opts.Format.comma())
+
+	header, err := readCSVHeader(reader, opts)
+	if err != nil {
+		return err
+	}
+	columns := cleanColumns(header)
+	if onHeader != nil {
+		if err := onHeader(columns); err != nil {
+			return err
+		}
+	}
+
+	// normalizeRow copies each record, so the reader's buffer can be reused
+	reader.ReuseRecord = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler arquivo: %w", err)
+		}
+		if err := fn(normalizeRow(record, len(columns))); err != nil {
+			return err
+		}
+	}
+}
+
+// streamXLSX iterates the selected sheet with excelize's row iterator, which
+// reads the sheet XML incrementally instead of building the whole grid like GetRows
+func (s *UploadService) streamXLSX(filePath string, opts UploadOptions, onHeader func([]string) error, fn func(row []string) error) error {
+	f, err := s.openWorkbook(filePath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo Excel: %w", err)
+	}
+	defer f.Close()
+
+	sheetName := f.GetSheetName(f.GetActiveSheetIndex())
+	if sheetName == "" {
+		return ErrEmptyFile
+	}
+
+	rows, err := f.Rows(sheetName)
+	if err != nil {
+		return fmt.Errorf("erro ao ler linhas: %w", err)
+	}
+	defer rows.Close()
+
+	locator := newHeaderLocator(opts)
+	var columns []string
+	// Empty rows are held back until a non-empty one follows, since GetRows drops
+	// trailing empty rows and both readers must return the same data
+	pendingEmpty := 0
+
+	for rows.Next() {
+		row, err := rows.Columns()
+		if err != nil {
+			return fmt.Errorf("erro ao ler linhas: %w", err)
+		}
+
+		if columns == nil {
+			if !locator.isHeader(row) {
+				continue
+			}
+			columns = cleanColumns(row)
+			if onHeader != nil {
+				if err := onHeader(columns); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if len(row) == 0 {
+			pendingEmpty++
+			continue
+		}
+		for ; pendingEmpty > 0; pendingEmpty-- {
+			if err := fn(normalizeRow(nil, len(columns))); err != nil {
+				return err
+			}
+		}
+		if err := fn(normalizeRow(row, len(columns))); err != nil {
+			return err
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return fmt.Errorf("erro ao ler linhas: %w", err)
+	}
+
+	if columns == nil {
+		return locator.notFound()
+	}
+	return nil
+}
+
+// cleanColumns trims header cells
+func cleanColumns(header []string) []string {
+	columns := make([]string, len(header))
+	for i, col := range header {
+		columns[i] = strings.TrimSpace(col)
+	}
+	return columns
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func collectRows(t *testing.T, s *UploadService, path string) [][]string {
+	t.Helper()
+	var rows [][]string
+	if err := s.StreamRows(path, func(row []string) error {
+		rows = append(rows, row)
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamRows: %v", err)
+	}
+	return rows
+}
+
+func TestStreamRowsMatchesGetFileData(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	csvContent := "Título\nid task,Status,Valor\nabc1,aberto,10\nabc2,,\nabc3,fechado,\n"
+
+	xlsxPath := filepath.Join(tempDir, "stream.xlsx")
+	f := excelize.NewFile()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Título"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"id task", "Status", "Valor"})
+	f.SetSheetRow("Sheet1", "A3", &[]interface{}{"abc1", "aberto", "10"})
+	// row 4 left blank in the middle
+	f.SetSheetRow("Sheet1", "A5", &[]interface{}{"abc3", "fechado"})
+	// trailing formatted-but-empty row must not show up as data
+	f.SetRowHeight("Sheet1", 8, 30)
+	if err := f.SaveAs(xlsxPath); err != nil {
+		t.Fatalf("Failed to save workbook: %v", err)
+	}
+	f.Close()
+	xlsxContent, _ := os.ReadFile(xlsxPath)
+
+	files := map[string][]byte{
+		"stream.csv":  []byte(csvContent),
+		"stream.xlsx": xlsxContent,
+	}
+
+	for filename, content := range files {
+		t.Run(filename, func(t *testing.T) {
+			result, err := uploadService.ProcessFileWithOptions(filename, strings.NewReader(string(content)), int64(len(content)), UploadOptions{SkipRows: 1})
+			if err != nil {
+				t.Fatalf("ProcessFileWithOptions: %v", err)
+			}
+			defer uploadService.RemoveTempFile(result.TempPath)
+
+			wantColumns, wantData, err := uploadService.GetFileData(result.TempPath)
+			if err != nil {
+				t.Fatalf("GetFileData: %v", err)
+			}
+
+			columns, err := uploadService.GetColumns(result.TempPath)
+			if err != nil {
+				t.Fatalf("GetColumns: %v", err)
+			}
+			if !reflect.DeepEqual(columns, wantColumns) {
+				t.Errorf("GetColumns = %v, GetFileData = %v", columns, wantColumns)
+			}
+
+			rows := collectRows(t, uploadService, result.TempPath)
+			if !reflect.DeepEqual(rows, wantData) {
+				t.Errorf("StreamRows = %q, GetFileData = %q", rows, wantData)
+			}
+		})
+	}
+}
+
+func TestStreamRowsStopsOnCallbackError(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	content := "id task,Status\na,1\nb,2\nc,3\n"
+	result, err := uploadService.ProcessFile("stop.csv", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	defer uploadService.RemoveTempFile(result.TempPath)
+
+	stop := errors.New("cancelado")
+	seen := 0
+	err = uploadService.StreamRows(result.TempPath, func(row []string) error {
+		seen++
+		if seen == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 2 {
+		t.Errorf("err = %v after %d rows, expected callback error after 2", err, seen)
+	}
+}
+
+// liveHeap returns the heap in use after a full collection
+func liveHeap() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestStreamRowsMemoryBounded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("arquivo grande ignorado em modo -short")
+	}
+
+	const rowCount = 200000
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "large.csv")
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "id task,Status,Valor,Descrição")
+	for i := 0; i < rowCount; i++ {
+		fmt.Fprintf(w, "task%07d,status %d,%d,descrição da linha %d com algum texto\n", i, i%7, i, i)
+	}
+	w.Flush()
+	file.Close()
+
+	uploadService := NewUploadService(tempDir, 0)
+
+	// Materializing every row keeps well over 40MB alive; streaming should keep only
+	// the row being processed
+	const maxGrowth = 8 << 20
+	baseline := liveHeap()
+	var peak uint64
+	count := 0
+
+	err = uploadService.StreamRows(path, func(row []string) error {
+		count++
+		if count%20000 == 0 {
+			if heap := liveHeap(); heap > peak {
+				peak = heap
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamRows: %v", err)
+	}
+	if count != rowCount {
+		t.Fatalf("streamed %d rows, expected %d", count, rowCount)
+	}
+	if peak > baseline && peak-baseline > maxGrowth {
+		t.Errorf("heap grew %d bytes while streaming, expected at most %d", peak-baseline, maxGrowth)
+	}
+}
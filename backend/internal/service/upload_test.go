@@ -3,11 +3,14 @@ package service
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
@@ -28,7 +31,7 @@ func TestFileProcessingConsistency(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Configure properties with reasonable test parameters
 	parameters := gopter.DefaultTestParameters()
@@ -382,10 +385,10 @@ func TestUploadService_FileSizeValidation(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Test file too large
-	largeContent := strings.Repeat("a,b,c\n", MaxFileSize/6+1)
+	largeContent := strings.Repeat("a,b,c\n", DefaultMaxFileSize/6+1)
 	reader := strings.NewReader(largeContent)
 
 	_, err = uploadService.ProcessFile("large.csv", reader, int64(len(largeContent)))
@@ -394,6 +397,31 @@ func TestUploadService_FileSizeValidation(t *testing.T) {
 	}
 }
 
+func TestUploadService_CustomFileSizeLimit(t *testing.T) {
+	uploadService := NewUploadService(t.TempDir(), 1024)
+	if uploadService.MaxFileSize() != 1024 {
+		t.Fatalf("MaxFileSize = %d, expected 1024", uploadService.MaxFileSize())
+	}
+
+	small := "id task,Valor\nabc,1\n"
+	if _, err := uploadService.ProcessFile("small.csv", strings.NewReader(small), int64(len(small))); err != nil {
+		t.Errorf("file under the limit: %v", err)
+	}
+
+	large := "id task,Valor\n" + strings.Repeat("abc,1\n", 200)
+	if _, err := uploadService.ProcessFile("large.csv", strings.NewReader(large), int64(len(large))); err != ErrFileTooLarge {
+		t.Errorf("declared size over the limit: expected ErrFileTooLarge, got %v", err)
+	}
+	// A size understated by the caller is still caught while the file is saved
+	if _, err := uploadService.ProcessFile("large.csv", strings.NewReader(large), 100); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("understated size: expected ErrFileTooLarge, got %v", err)
+	}
+
+	if NewUploadService(t.TempDir(), 0).MaxFileSize() != DefaultMaxFileSize {
+		t.Error("limit <= 0 should use DefaultMaxFileSize")
+	}
+}
+
 func TestUploadService_EmptyFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "upload_test_*")
 	if err != nil {
@@ -401,7 +429,7 @@ func TestUploadService_EmptyFile(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Test empty file
 	reader := strings.NewReader("")
@@ -419,7 +447,7 @@ func TestUploadService_UnsupportedFormat(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	uploadService := NewUploadService(tempDir)
+	uploadService := NewUploadService(tempDir, 0)
 
 	// Test unsupported format
 	reader := strings.NewReader("some content")
@@ -431,7 +459,7 @@ func TestUploadService_UnsupportedFormat(t *testing.T) {
 }
 
 func TestUploadService_ValidateFileFormat(t *testing.T) {
-	uploadService := NewUploadService("")
+	uploadService := NewUploadService("", 0)
 
 	tests := []struct {
 		filename string
@@ -441,6 +469,8 @@ func TestUploadService_ValidateFileFormat(t *testing.T) {
 		{"test.CSV", false},
 		{"test.xlsx", false},
 		{"test.XLSX", false},
+		{"test.ods", false},
+		{"test.ODS", false},
 		{"test.txt", true},
 		{"test.pdf", true},
 		{"test", true},
@@ -457,3 +487,508 @@ func TestUploadService_ValidateFileFormat(t *testing.T) {
 }
 
 
+
+func createTwoSheetXLSX(t *testing.T, tempDir string) string {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Resumo", "Total"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"geral", "3"})
+
+	if _, err := f.NewSheet("Dados"); err != nil {
+		t.Fatalf("Failed to create sheet: %v", err)
+	}
+	f.SetSheetRow("Dados", "A1", &[]interface{}{"id task", "Status", "Valor"})
+	f.SetSheetRow("Dados", "A2", &[]interface{}{"abc1", "aberto", "10"})
+	f.SetSheetRow("Dados", "A3", &[]interface{}{"abc2", "fechado", "20"})
+
+	path := filepath.Join(tempDir, "two_sheets.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("Failed to save workbook: %v", err)
+	}
+	return path
+}
+
+func TestUploadService_XLSXSheetSelection(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+	path := createTwoSheetXLSX(t, tempDir)
+
+	process := func(sheet string) (*FileUpload, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open workbook: %v", err)
+		}
+		defer file.Close()
+		stat, _ := file.Stat()
+		return uploadService.ProcessFileWithOptions("two_sheets.xlsx", file, stat.Size(), UploadOptions{Sheet: sheet})
+	}
+
+	t.Run("default is first sheet", func(t *testing.T) {
+		result, err := process("")
+		if err != nil {
+			t.Fatalf("ProcessFileWithOptions: %v", err)
+		}
+		defer uploadService.RemoveTempFile(result.TempPath)
+
+		if !reflect.DeepEqual(result.Sheets, []string{"Sheet1", "Dados"}) {
+			t.Errorf("Sheets = %v", result.Sheets)
+		}
+		if result.Sheet != "Sheet1" || !reflect.DeepEqual(result.Columns, []string{"Resumo", "Total"}) {
+			t.Errorf("sheet %q columns %v", result.Sheet, result.Columns)
+		}
+	})
+
+	t.Run("selected sheet", func(t *testing.T) {
+		result, err := process("Dados")
+		if err != nil {
+			t.Fatalf("ProcessFileWithOptions: %v", err)
+		}
+		defer uploadService.RemoveTempFile(result.TempPath)
+
+		if result.Sheet != "Dados" || !reflect.DeepEqual(result.Columns, []string{"id task", "Status", "Valor"}) {
+			t.Errorf("sheet %q columns %v", result.Sheet, result.Columns)
+		}
+		if result.TotalRows != 2 {
+			t.Errorf("TotalRows = %d, expected 2", result.TotalRows)
+		}
+
+		// Later reads of the temp file (mapping, job processing) must use the same sheet
+		columns, data, err := uploadService.GetFileData(result.TempPath)
+		if err != nil {
+			t.Fatalf("GetFileData: %v", err)
+		}
+		if !reflect.DeepEqual(columns, result.Columns) || len(data) != 2 || data[1][0] != "abc2" {
+			t.Errorf("GetFileData columns %v data %v", columns, data)
+		}
+	})
+
+	t.Run("unknown sheet", func(t *testing.T) {
+		_, err := process("Inexistente")
+		if !errors.Is(err, ErrSheetNotFound) {
+			t.Errorf("Expected ErrSheetNotFound, got %v", err)
+		}
+	})
+}
+
+func TestUploadService_HeaderLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	csvContent := "Relatório de tarefas\nGerado em 01/02/2025\n\nid task,Status\nabc1,aberto\nabc2,fechado\n"
+
+	xlsxPath := filepath.Join(tempDir, "junk.xlsx")
+	f := excelize.NewFile()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Relatório de tarefas"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Gerado em 01/02/2025"})
+	// row 3 left blank
+	f.SetSheetRow("Sheet1", "A4", &[]interface{}{"id task", "Status"})
+	f.SetSheetRow("Sheet1", "A5", &[]interface{}{"abc1", "aberto"})
+	f.SetSheetRow("Sheet1", "A6", &[]interface{}{"abc2", "fechado"})
+	if err := f.SaveAs(xlsxPath); err != nil {
+		t.Fatalf("Failed to save workbook: %v", err)
+	}
+	f.Close()
+	xlsxContent, err := os.ReadFile(xlsxPath)
+	if err != nil {
+		t.Fatalf("Failed to read workbook: %v", err)
+	}
+
+	files := map[string][]byte{
+		"junk.csv":  []byte(csvContent),
+		"junk.xlsx": xlsxContent,
+	}
+
+	layouts := []struct {
+		name string
+		opts UploadOptions
+	}{
+		{"skip_rows", UploadOptions{SkipRows: 2}},
+		{"header_row", UploadOptions{HeaderRow: 3}},
+		{"both", UploadOptions{SkipRows: 1, HeaderRow: 2}},
+	}
+
+	for filename, content := range files {
+		for _, layout := range layouts {
+			t.Run(filename+"/"+layout.name, func(t *testing.T) {
+				result, err := uploadService.ProcessFileWithOptions(filename, bytes.NewReader(content), int64(len(content)), layout.opts)
+				if err != nil {
+					t.Fatalf("ProcessFileWithOptions: %v", err)
+				}
+				defer uploadService.RemoveTempFile(result.TempPath)
+
+				wantColumns := []string{"id task", "Status"}
+				if !reflect.DeepEqual(result.Columns, wantColumns) {
+					t.Errorf("Columns = %v, expected %v", result.Columns, wantColumns)
+				}
+				if result.TotalRows != 2 || len(result.Preview) != 2 || result.Preview[0][0] != "abc1" {
+					t.Errorf("TotalRows = %d, Preview = %v", result.TotalRows, result.Preview)
+				}
+
+				// Mapping validation and job processing must see the same layout
+				columns, data, err := uploadService.GetFileData(result.TempPath)
+				if err != nil {
+					t.Fatalf("GetFileData: %v", err)
+				}
+				if !reflect.DeepEqual(columns, wantColumns) || len(data) != 2 || data[1][0] != "abc2" {
+					t.Errorf("GetFileData columns %v data %v", columns, data)
+				}
+			})
+		}
+	}
+
+	t.Run("header beyond end of file", func(t *testing.T) {
+		_, err := uploadService.ProcessFileWithOptions("junk.csv", strings.NewReader(csvContent), int64(len(csvContent)), UploadOptions{SkipRows: 10})
+		if !errors.Is(err, ErrHeaderNotFound) {
+			t.Errorf("Expected ErrHeaderNotFound, got %v", err)
+		}
+	})
+
+	t.Run("negative offsets", func(t *testing.T) {
+		_, err := uploadService.ProcessFileWithOptions("junk.csv", strings.NewReader(csvContent), int64(len(csvContent)), UploadOptions{SkipRows: -1})
+		if !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("Expected ErrInvalidLayout, got %v", err)
+		}
+	})
+
+	t.Run("layout removed with temp file", func(t *testing.T) {
+		result, err := uploadService.ProcessFileWithOptions("junk.csv", strings.NewReader(csvContent), int64(len(csvContent)), UploadOptions{SkipRows: 2})
+		if err != nil {
+			t.Fatalf("ProcessFileWithOptions: %v", err)
+		}
+		uploadService.RemoveTempFile(result.TempPath)
+		if _, err := os.Stat(layoutPath(result.TempPath)); !os.IsNotExist(err) {
+			t.Errorf("layout sidecar should be removed, stat err = %v", err)
+		}
+	})
+}
+
+func TestUploadService_MaxRows(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+	uploadService.SetMaxRows(10)
+
+	rowsOf := func(n int) [][]string {
+		rows := make([][]string, n)
+		for i := range rows {
+			rows[i] = []string{fmt.Sprintf("task%d", i), "valor"}
+		}
+		return rows
+	}
+	columns := []string{"id task", "Valor"}
+
+	tests := []struct {
+		name    string
+		rows    int
+		wantErr bool
+	}{
+		{"exactly MaxRows", 10, false},
+		{"MaxRows+1", 11, true},
+	}
+
+	for _, tt := range tests {
+		t.Run("csv/"+tt.name, func(t *testing.T) {
+			content := createCSVContent(columns, rowsOf(tt.rows))
+			result, err := uploadService.ProcessFile("rows.csv", strings.NewReader(content), int64(len(content)))
+			checkMaxRowsResult(t, uploadService, result, err, tt.rows, tt.wantErr)
+		})
+
+		t.Run("xlsx/"+tt.name, func(t *testing.T) {
+			path, err := createXLSXFile(tempDir, columns, rowsOf(tt.rows))
+			if err != nil {
+				t.Fatalf("Failed to create XLSX: %v", err)
+			}
+			content, _ := os.ReadFile(path)
+			result, err := uploadService.ProcessFile("rows.xlsx", bytes.NewReader(content), int64(len(content)))
+			checkMaxRowsResult(t, uploadService, result, err, tt.rows, tt.wantErr)
+		})
+	}
+}
+
+func checkMaxRowsResult(t *testing.T, s *UploadService, result *FileUpload, err error, rows int, wantErr bool) {
+	t.Helper()
+	if !wantErr {
+		if err != nil {
+			t.Fatalf("ProcessFile: %v", err)
+		}
+		s.RemoveTempFile(result.TempPath)
+		if result.TotalRows != rows {
+			t.Errorf("TotalRows = %d, expected %d", result.TotalRows, rows)
+		}
+		return
+	}
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("Expected ErrTooManyRows, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("%d linhas", rows)) {
+		t.Errorf("error should report the actual row count: %v", err)
+	}
+}
+
+func TestUploadService_ScanFuncRejects(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	var scanned []string
+	uploadService.SetScanFunc(func(path string) error {
+		scanned = append(scanned, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(content, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+			return errors.New("assinatura EICAR encontrada")
+		}
+		return nil
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		content := "id task,Valor\nabc,X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*\n"
+		_, err := uploadService.ProcessFile("infected.csv", strings.NewReader(content), int64(len(content)))
+		if !errors.Is(err, ErrFileRejected) {
+			t.Fatalf("Expected ErrFileRejected, got %v", err)
+		}
+		if len(scanned) != 1 {
+			t.Fatalf("scanner called %d times, expected 1", len(scanned))
+		}
+		if _, err := os.Stat(scanned[0]); !os.IsNotExist(err) {
+			t.Errorf("rejected temp file should be removed, stat err = %v", err)
+		}
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		content := "id task,Valor\nabc,10\n"
+		result, err := uploadService.ProcessFile("clean.csv", strings.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatalf("ProcessFile: %v", err)
+		}
+		defer uploadService.RemoveTempFile(result.TempPath)
+		// The scanner sees a local staging copy, removed once the file is in the store
+		if _, err := os.Stat(scanned[len(scanned)-1]); !os.IsNotExist(err) {
+			t.Errorf("staging copy should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(result.TempPath); err != nil {
+			t.Errorf("stored temp file: %v", err)
+		}
+	})
+}
+
+func TestUploadService_EncryptionAtRest(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+	uploadService.SetEncryptionKey("upload-encryption-key-32-bytes!!")
+
+	content := "id task,CPF,Valor\nabc1,123.456.789-00,10\nabc2,987.654.321-00,20\n"
+	result, err := uploadService.ProcessFile("sensitive.csv", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if !reflect.DeepEqual(result.Columns, []string{"id task", "CPF", "Valor"}) || result.TotalRows != 2 {
+		t.Errorf("columns %v rows %d", result.Columns, result.TotalRows)
+	}
+
+	onDisk, err := os.ReadFile(result.TempPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(onDisk, []byte("123.456.789-00")) || bytes.Contains(onDisk, []byte("id task")) {
+		t.Fatal("temp file stored in plaintext")
+	}
+
+	// Reads decrypt transparently
+	columns, data, err := uploadService.GetFileData(result.TempPath)
+	if err != nil {
+		t.Fatalf("GetFileData: %v", err)
+	}
+	if len(columns) != 3 || len(data) != 2 || data[1][1] != "987.654.321-00" {
+		t.Errorf("GetFileData columns %v data %v", columns, data)
+	}
+	var streamed [][]string
+	if err := uploadService.StreamRows(result.TempPath, func(row []string) error {
+		streamed = append(streamed, row)
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamRows: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, data) {
+		t.Errorf("StreamRows %v, GetFileData %v", streamed, data)
+	}
+
+	// Without the key the file can't be read
+	if _, _, err := NewUploadService(tempDir, 0).GetFileData(result.TempPath); !errors.Is(err, ErrUploadKeyMissing) {
+		t.Errorf("expected ErrUploadKeyMissing, got %v", err)
+	}
+
+	// XLSX sheet selection rewrites the temp file, which must stay encrypted
+	path := createTwoSheetXLSX(t, t.TempDir())
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	stat, _ := file.Stat()
+	xlsx, err := uploadService.ProcessFileWithOptions("two_sheets.xlsx", file, stat.Size(), UploadOptions{Sheet: "Dados"})
+	file.Close()
+	if err != nil {
+		t.Fatalf("ProcessFileWithOptions: %v", err)
+	}
+	if onDisk, _ := os.ReadFile(xlsx.TempPath); !bytes.HasPrefix(onDisk, []byte(uploadEncryptionMagic)) {
+		t.Error("XLSX temp file not encrypted after sheet selection")
+	}
+	if columns, data, err := uploadService.GetFileData(xlsx.TempPath); err != nil || columns[0] != "id task" || len(data) != 2 {
+		t.Errorf("GetFileData XLSX columns %v data %v err %v", columns, data, err)
+	}
+
+	// ODS archives are read from the decrypted copy
+	odsPath, err := createODSFile(t.TempDir(), map[string][][]string{"Dados": {{"id task", "Valor"}, {"abc1", "10"}}}, []string{"Dados"})
+	if err != nil {
+		t.Fatalf("createODSFile: %v", err)
+	}
+	odsFile, _ := os.Open(odsPath)
+	odsStat, _ := odsFile.Stat()
+	ods, err := uploadService.ProcessFile("planilha.ods", odsFile, odsStat.Size())
+	odsFile.Close()
+	if err != nil {
+		t.Fatalf("ProcessFile ODS: %v", err)
+	}
+	if columns, data, err := uploadService.GetFileData(ods.TempPath); err != nil || columns[0] != "id task" || len(data) != 1 || data[0][1] != "10" {
+		t.Errorf("GetFileData ODS columns %v data %v err %v", columns, data, err)
+	}
+	uploadService.RemoveTempFile(ods.TempPath)
+
+	// Removal and the expiry sweep work the same on encrypted files
+	if err := uploadService.RemoveTempFile(result.TempPath); err != nil {
+		t.Errorf("RemoveTempFile: %v", err)
+	}
+	uploadService.SetClock(func() time.Time { return time.Now().Add(2 * TempFileExpiry) })
+	if removed, _ := uploadService.CleanupExpiredFiles(); removed != 1 {
+		t.Errorf("CleanupExpiredFiles removed %d files, expected 1", removed)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tempDir, tempFilePrefix+"*")); len(matches) != 0 {
+		t.Errorf("temp files left: %v", matches)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tempDir, decryptedFilePrefix+"*")); len(matches) != 0 {
+		t.Errorf("decrypted copies left: %v", matches)
+	}
+}
+
+func TestUploadService_EncryptedStreamFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+	uploadService.SetEncryptionKey("upload-encryption-key-32-bytes!!")
+
+	// Sizes around the segment boundaries, including an empty file
+	for _, size := range []int{0, 1, uploadSegmentSize - 1, uploadSegmentSize, 3*uploadSegmentSize + 17} {
+		content := bytes.Repeat([]byte("a1,"), size/3+1)[:size]
+		path := filepath.Join(tempDir, fmt.Sprintf("%stest%d.csv", tempFilePrefix, size))
+		if err := uploadService.writeTempFile(path, content); err != nil {
+			t.Fatalf("writeTempFile(%d): %v", size, err)
+		}
+		got, err := uploadService.readTempFile(path)
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("size %d: read %d bytes, err %v", size, len(got), err)
+		}
+	}
+
+	// A file cut at a segment boundary or with a changed byte is rejected
+	path := filepath.Join(tempDir, tempFilePrefix+"cut.csv")
+	content := bytes.Repeat([]byte("x"), 2*uploadSegmentSize+10)
+	if err := uploadService.writeTempFile(path, content); err != nil {
+		t.Fatalf("writeTempFile: %v", err)
+	}
+	sealed, _ := os.ReadFile(path)
+	header := len(uploadEncryptionMagic) + uploadNoncePrefixSize
+	segment := uploadSegmentSize + 16
+	os.WriteFile(path, sealed[:header+segment], 0600)
+	if _, err := uploadService.readTempFile(path); err == nil {
+		t.Error("truncated file should not decrypt")
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[header+segment+5] ^= 1
+	os.WriteFile(path, tampered, 0600)
+	if _, err := uploadService.readTempFile(path); err == nil {
+		t.Error("tampered file should not decrypt")
+	}
+
+	// Files sealed whole by earlier versions are still read
+	gcm, _ := newGCM(uploadService.encryptionKey)
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := gcm.Seal(append([]byte(uploadEncryptionMagicV1), nonce...), nonce, []byte("id task\nabc\n"), nil)
+	os.WriteFile(path, legacy, 0600)
+	if got, err := uploadService.readTempFile(path); err != nil || string(got) != "id task\nabc\n" {
+		t.Errorf("legacy file read %q, err %v", got, err)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(tempDir, decryptedFilePrefix+"*")); len(matches) != 0 {
+		t.Errorf("decrypted copies left: %v", matches)
+	}
+}
+
+func TestUploadService_ColumnStats(t *testing.T) {
+	tempDir := t.TempDir()
+	uploadService := NewUploadService(tempDir, 0)
+
+	columns := []string{"id task", "Status", "Obs"}
+	rows := [][]string{
+		{"t1", "aberto", ""},
+		{"t2", "fechado", "ação"},
+		{"t3", "aberto", " "},
+		{"t4", "em andamento", strings.Repeat("x", 150)},
+		{"t5", "aberto"}, // célula ausente conta como vazia
+		{"t6", "pausado", "ação"},
+		{"t7", "cancelado", "b"},
+		{"t8", "revisão", "c"},
+	}
+
+	expected := []ColumnStats{
+		{Column: "id task", DistinctCount: 8, MinLength: 2, MaxLength: 2, SampleValues: []string{"t1", "t2", "t3", "t4", "t5"}},
+		{Column: "Status", DistinctCount: 6, MinLength: 6, MaxLength: 12, SampleValues: []string{"aberto", "fechado", "em andamento", "pausado", "cancelado"}},
+		{Column: "Obs", DistinctCount: 4, EmptyCount: 3, MinLength: 1, MaxLength: 150, SampleValues: []string{"ação", strings.Repeat("x", columnStatsSampleLength) + "…", "b", "c"}},
+	}
+
+	check := func(t *testing.T, result *FileUpload, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("ProcessFile: %v", err)
+		}
+		defer uploadService.RemoveTempFile(result.TempPath)
+		if !reflect.DeepEqual(result.ColumnStats, expected) {
+			t.Errorf("ColumnStats =\n%+v\nexpected\n%+v", result.ColumnStats, expected)
+		}
+		if len(result.Preview) != PreviewRows || result.TotalRows != len(rows) {
+			t.Errorf("preview %d rows, total %d", len(result.Preview), result.TotalRows)
+		}
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		content := "id task,Status,Obs\nt1,aberto,\nt2,fechado,ação\nt3,aberto, \nt4,em andamento," + strings.Repeat("x", 150) +
+			"\nt5,aberto,\nt6,pausado,ação\nt7,cancelado,b\nt8,revisão,c\n"
+		result, err := uploadService.ProcessFile("stats.csv", strings.NewReader(content), int64(len(content)))
+		check(t, result, err)
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		path, err := createXLSXFile(tempDir, columns, rows)
+		if err != nil {
+			t.Fatalf("createXLSXFile: %v", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		result, err := uploadService.ProcessFile("stats.xlsx", bytes.NewReader(content), int64(len(content)))
+		check(t, result, err)
+	})
+
+	t.Run("distinct values are capped", func(t *testing.T) {
+		b := newPreviewBuilder([]string{"id"}, PreviewRows)
+		for i := 0; i < columnStatsMaxDistinct+5; i++ {
+			b.add([]string{fmt.Sprintf("v%d", i)})
+		}
+		stats := b.columnStats()[0]
+		if stats.DistinctCount != columnStatsMaxDistinct || !stats.DistinctCapped || len(stats.SampleValues) != columnStatsSampleValues {
+			t.Errorf("stats = %+v", stats)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+)
+
+// MaxUserImportRows is the largest number of users accepted in one import
+const MaxUserImportRows = 1000
+
+// User import row outcomes
+const (
+	UserImportCreated = "created"
+	UserImportSkipped = "skipped"
+	UserImportError   = "error"
+)
+
+var (
+	ErrEmptyUserImport    = errors.New("arquivo de importação sem usuários")
+	ErrUserImportTooLarge = fmt.Errorf("importação excede o limite de %d usuários", MaxUserImportRows)
+)
+
+// UserImportResult is the outcome of one CSV row
+type UserImportResult struct {
+	Row      int    `json:"row"` // line in the file, counting the header
+	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// UserImportSummary counts the outcomes of an import
+type UserImportSummary struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// SummarizeUserImport counts results by status
+func SummarizeUserImport(results []UserImportResult) UserImportSummary {
+	summary := UserImportSummary{Total: len(results)}
+	for _, result := range results {
+		switch result.Status {
+		case UserImportCreated:
+			summary.Created++
+		case UserImportSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// ImportUsers creates users from a CSV with username,password[,role] columns (an
+// optional header row starting with "username" is ignored). Each row is validated
+// with the same rules as user creation and handled on its own: invalid rows are
+// reported as errors and existing usernames (or repeated ones in the file) are
+// skipped, without stopping the rest of the import. The error is only set when the
+// CSV itself can't be read.
+func (s *AuthService) ImportUsers(r io.Reader) ([]UserImportResult, error) {
+	return importUsers(r, s.CreateUserWithRole)
+}
+
+// importUsers implements ImportUsers with the user creation injected (used by tests)
+func importUsers(r io.Reader, create func(username, password, role string) error) ([]UserImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	first := 0
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "username") {
+		first = 1
+	}
+	if len(records) == first {
+		return nil, ErrEmptyUserImport
+	}
+	if len(records)-first > MaxUserImportRows {
+		return nil, ErrUserImportTooLarge
+	}
+
+	seen := make(map[string]bool)
+	results := make([]UserImportResult, 0, len(records)-first)
+
+	for i := first; i < len(records); i++ {
+		record := records[i]
+		result := UserImportResult{Row: i + 1}
+
+		field := func(index int) string {
+			if index < len(record) {
+				return record[index]
+			}
+			return ""
+		}
+		username := middleware.CanonicalUsername(middleware.SanitizeUsername(field(0)))
+		password := middleware.SanitizePassword(field(1))
+		role := strings.ToLower(strings.TrimSpace(field(2)))
+		if role == "" {
+			role = middleware.RoleUser
+		}
+		result.Username = username
+		result.Role = role
+
+		switch {
+		case !middleware.ValidateUsername(username):
+			result.Status, result.Error = UserImportError, "nome de usuário inválido (3 a 100 caracteres: letras, números, _ e -)"
+		case !middleware.ValidatePassword(password):
+			result.Status, result.Error = UserImportError, "senha deve ter entre 6 e 128 caracteres"
+		case !middleware.ValidRole(role):
+			result.Status, result.Error = UserImportError, ErrInvalidRole.Error()
+		case seen[username]:
+			result.Status, result.Error = UserImportSkipped, "usuário repetido no arquivo"
+		default:
+			seen[username] = true
+			if err := create(username, password, role); err != nil {
+				if errors.Is(err, ErrUserAlreadyExists) {
+					result.Status, result.Error = UserImportSkipped, ErrUserAlreadyExists.Error()
+				} else {
+					result.Status, result.Error = UserImportError, "erro ao criar usuário"
+				}
+			} else {
+				result.Status = UserImportCreated
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
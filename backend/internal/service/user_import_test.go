@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImportUsersMixedValidity(t *testing.T) {
+	existing := map[string]bool{"joao": true}
+	created := map[string]string{}
+	create := func(username, password, role string) error {
+		if existing[username] {
+			return ErrUserAlreadyExists
+		}
+		if username == "falha-db" {
+			return errors.New("connection refused")
+		}
+		existing[username] = true
+		created[username] = role
+		return nil
+	}
+
+	csv := strings.Join([]string{
+		"username,password,role",
+		"ana,senha123,admin",
+		"bruno,senha123",     // papel padrão
+		"joao,senha123,user", // já existe
+		"x,senha123,user",    // nome curto
+		"carla,123,user",     // senha curta
+		"davi,senha123,root", // papel inválido
+		"ana,outra123,user",  // repetido no arquivo
+		"falha-db,senha123,user",
+		"eva,senha123,USER",
+	}, "\n")
+
+	results, err := importUsers(strings.NewReader(csv), create)
+	if err != nil {
+		t.Fatalf("importUsers: %v", err)
+	}
+
+	want := []struct {
+		row      int
+		username string
+		status   string
+	}{
+		{2, "ana", UserImportCreated},
+		{3, "bruno", UserImportCreated},
+		{4, "joao", UserImportSkipped},
+		{5, "x", UserImportError},
+		{6, "carla", UserImportError},
+		{7, "davi", UserImportError},
+		{8, "ana", UserImportSkipped},
+		{9, "falha-db", UserImportError},
+		{10, "eva", UserImportCreated},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("esperados %d resultados, obtidos %d: %+v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Row != w.row || got.Username != w.username || got.Status != w.status {
+			t.Errorf("linha %d: obtido %+v, esperado %+v", i, got, w)
+		}
+		if got.Status != UserImportCreated && got.Error == "" {
+			t.Errorf("linha %d (%s) sem motivo", got.Row, got.Status)
+		}
+	}
+
+	if created["ana"] != "admin" || created["bruno"] != "user" || created["eva"] != "user" {
+		t.Errorf("papéis criados: %v", created)
+	}
+	if strings.Contains(results[7].Error, "connection refused") {
+		t.Errorf("erro interno exposto no resultado: %q", results[7].Error)
+	}
+
+	summary := SummarizeUserImport(results)
+	if summary != (UserImportSummary{Total: 9, Created: 3, Skipped: 2, Failed: 4}) {
+		t.Errorf("resumo = %+v", summary)
+	}
+}
+
+func TestImportUsersWithoutHeader(t *testing.T) {
+	var names []string
+	results, err := importUsers(strings.NewReader("ana,senha123\nbruno,senha123\n"), func(username, password, role string) error {
+		names = append(names, username)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("importUsers: %v", err)
+	}
+	if len(results) != 2 || strings.Join(names, ",") != "ana,bruno" {
+		t.Errorf("resultados %+v, criados %v", results, names)
+	}
+}
+
+func TestImportUsersMixedCaseDuplicates(t *testing.T) {
+	var names []string
+	csv := "Ana,senha123\nANA,outra123\nBruno,senha123\n"
+	results, err := importUsers(strings.NewReader(csv), func(username, password, role string) error {
+		names = append(names, username)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("importUsers: %v", err)
+	}
+	if len(results) != 3 || results[1].Status != UserImportSkipped || results[1].Username != "ana" {
+		t.Errorf("a segunda linha deveria ser ignorada como repetida: %+v", results)
+	}
+	if strings.Join(names, ",") != "ana,bruno" {
+		t.Errorf("usuários criados = %v, esperado [ana bruno]", names)
+	}
+}
+
+func TestImportUsersRejectsEmptyOrOversizedFile(t *testing.T) {
+	noop := func(username, password, role string) error { return nil }
+
+	if _, err := importUsers(strings.NewReader("username,password,role\n"), noop); !errors.Is(err, ErrEmptyUserImport) {
+		t.Errorf("esperado ErrEmptyUserImport, obtido %v", err)
+	}
+
+	var b strings.Builder
+	for i := 0; i <= MaxUserImportRows; i++ {
+		b.WriteString("user,senha123\n")
+	}
+	if _, err := importUsers(strings.NewReader(b.String()), noop); !errors.Is(err, ErrUserImportTooLarge) {
+		t.Errorf("esperado ErrUserImportTooLarge, obtido %v", err)
+	}
+}
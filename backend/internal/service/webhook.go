@@ -17,14 +17,16 @@ import (
 
 // WebhookService envia resultados para webhooks
 type WebhookService struct {
-	httpClient *http.Client
+	httpClient   *http.Client
+	allowPrivate bool
 }
 
-// NewWebhookService cria um novo serviço de webhook
+// NewWebhookService cria um novo serviço de webhook, que recusa endereços internos
+// (veja SetAllowPrivateNetworks)
 func NewWebhookService() *WebhookService {
 	return &WebhookService{
 		// Timeout controlado pelo contexto do processAsync (30min)
-		httpClient: &http.Client{},
+		httpClient: newGuardedWebhookClient(),
 	}
 }
 
@@ -45,20 +47,8 @@ func (w *WebhookService) SendSuccess(ctx context.Context, webhookURL string, res
 		var body bytes.Buffer
 		writer := multipart.NewWriter(&body)
 
-		if err := writer.WriteField("success", "true"); err != nil {
-			return fmt.Errorf("write success: %w", err)
-		}
-		if err := writer.WriteField("folder_name", result.FolderName); err != nil {
-			return fmt.Errorf("write folder_name: %w", err)
-		}
-		if err := writer.WriteField("total_tasks", fmt.Sprintf("%d", result.TotalTasks)); err != nil {
-			return fmt.Errorf("write total_tasks: %w", err)
-		}
-		if err := writer.WriteField("total_lists", fmt.Sprintf("%d", result.TotalLists)); err != nil {
-			return fmt.Errorf("write total_lists: %w", err)
-		}
-		if err := writer.WriteField("file_mime", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"); err != nil {
-			return fmt.Errorf("write file_mime: %w", err)
+		if err := writeResultFields(writer, result); err != nil {
+			return err
 		}
 
 		filename := fmt.Sprintf("%s.xlsx", result.FolderName)
@@ -92,7 +82,7 @@ func (w *WebhookService) SendSuccess(ctx context.Context, webhookURL string, res
 		}
 
 		logger.Get(ctx).Info().
-			Str("url", webhookURL).
+			Str("url", logger.Redact(webhookURL)).
 			Int("status", resp.StatusCode).
 			Str("mode", "buffer").
 			Int64("size_bytes", stat.Size()).
@@ -107,24 +97,8 @@ func (w *WebhookService) SendSuccess(ctx context.Context, webhookURL string, res
 	go func() {
 		defer file.Close()
 
-		if err := writer.WriteField("success", "true"); err != nil {
-			pw.CloseWithError(fmt.Errorf("write success: %w", err))
-			return
-		}
-		if err := writer.WriteField("folder_name", result.FolderName); err != nil {
-			pw.CloseWithError(fmt.Errorf("write folder_name: %w", err))
-			return
-		}
-		if err := writer.WriteField("total_tasks", fmt.Sprintf("%d", result.TotalTasks)); err != nil {
-			pw.CloseWithError(fmt.Errorf("write total_tasks: %w", err))
-			return
-		}
-		if err := writer.WriteField("total_lists", fmt.Sprintf("%d", result.TotalLists)); err != nil {
-			pw.CloseWithError(fmt.Errorf("write total_lists: %w", err))
-			return
-		}
-		if err := writer.WriteField("file_mime", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"); err != nil {
-			pw.CloseWithError(fmt.Errorf("write file_mime: %w", err))
+		if err := writeResultFields(writer, result); err != nil {
+			pw.CloseWithError(err)
 			return
 		}
 
@@ -164,13 +138,38 @@ func (w *WebhookService) SendSuccess(ctx context.Context, webhookURL string, res
 	}
 
 	logger.Get(ctx).Info().
-		Str("url", webhookURL).
+		Str("url", logger.Redact(webhookURL)).
 		Int("status", resp.StatusCode).
 		Str("mode", "streaming").
 		Msg("Webhook enviado com sucesso")
 	return nil
 }
 
+// writeResultFields escreve os campos do resultado que acompanham o arquivo.
+// Relatórios parciais incluem partial=true, warnings e o resultado de cada lista (JSON).
+func writeResultFields(writer *multipart.Writer, result *ReportResult) error {
+	fields := [][2]string{
+		{"success", "true"},
+		{"folder_name", result.FolderName},
+		{"total_tasks", fmt.Sprintf("%d", result.TotalTasks)},
+		{"total_lists", fmt.Sprintf("%d", result.TotalLists)},
+		{"file_mime", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"partial", fmt.Sprintf("%t", result.Partial)},
+	}
+	if result.Partial {
+		warnings, _ := json.Marshal(result.Warnings)
+		lists, _ := json.Marshal(result.Lists)
+		fields = append(fields, [2]string{"warnings", string(warnings)}, [2]string{"lists", string(lists)})
+	}
+
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return fmt.Errorf("write %s: %w", field[0], err)
+		}
+	}
+	return nil
+}
+
 // SendError envia o resultado de erro para o webhook
 func (w *WebhookService) SendError(ctx context.Context, webhookURL string, err error) error {
 	payload := model.WebhookPayload{
@@ -181,8 +180,13 @@ func (w *WebhookService) SendError(ctx context.Context, webhookURL string, err e
 	return w.send(ctx, webhookURL, payload)
 }
 
-// send envia o payload para o webhook
-func (w *WebhookService) send(ctx context.Context, webhookURL string, payload model.WebhookPayload) error {
+// SendSyncCompleted envia o resumo de uma sincronização de metadados concluída
+func (w *WebhookService) SendSyncCompleted(ctx context.Context, webhookURL string, summary SyncSummary) error {
+	return w.send(ctx, webhookURL, summary)
+}
+
+// send envia o payload (JSON) para o webhook
+func (w *WebhookService) send(ctx context.Context, webhookURL string, payload interface{}) error {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
@@ -206,7 +210,7 @@ func (w *WebhookService) send(ctx context.Context, webhookURL string, payload mo
 	}
 
 	logger.Get(ctx).Info().
-		Str("url", webhookURL).
+		Str("url", logger.Redact(webhookURL)).
 		Int("status", resp.StatusCode).
 		Msg("Webhook enviado com sucesso")
 
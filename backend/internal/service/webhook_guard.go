@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookURLNotAllowed indica uma URL de webhook inválida ou que aponta para a rede interna
+var ErrWebhookURLNotAllowed = errors.New("URL de webhook não permitida")
+
+// blockedWebhookNetworks são faixas fora da internet pública além das reconhecidas por
+// net.IP (loopback, privadas, link-local): CGNAT e a rede "this host"
+var blockedWebhookNetworks = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("0.0.0.0/8"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isInternalIP indica se ip é loopback, privado, link-local, multicast ou não especificado
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAllowPrivateNetworks permite webhooks na rede interna (ex.: um serviço no mesmo
+// cluster); por padrão endereços internos são recusados, ao salvar e ao conectar
+func (w *WebhookService) SetAllowPrivateNetworks(allow bool) {
+	w.allowPrivate = allow
+	if allow {
+		w.httpClient = &http.Client{}
+		return
+	}
+	w.httpClient = newGuardedWebhookClient()
+}
+
+// ValidateURL confere uma URL de webhook antes de salvá-la ou usá-la: precisa ser
+// http(s) e, salvo SetAllowPrivateNetworks, o host não pode resolver para a rede interna
+func (w *WebhookService) ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: informe uma URL http(s)", ErrWebhookURLNotAllowed)
+	}
+	if w.allowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: %s aponta para a rede interna", ErrWebhookURLNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return fmt.Errorf("%w: %s é um endereço interno", ErrWebhookURLNotAllowed, host)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: não foi possível resolver %s", ErrWebhookURLNotAllowed, host)
+	}
+	for _, addr := range addrs {
+		if isInternalIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolve para o endereço interno %s", ErrWebhookURLNotAllowed, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// newGuardedWebhookClient cria o cliente HTTP dos webhooks, que recusa conectar a
+// endereços internos. A checagem usa o IP já resolvido de cada conexão (inclusive de
+// redirecionamentos), então um DNS que muda depois da validação não a contorna; por
+// isso também não usa proxy.
+func newGuardedWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isInternalIP(ip) {
+				return fmt.Errorf("%w: conexão a %s recusada", ErrWebhookURLNotAllowed, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
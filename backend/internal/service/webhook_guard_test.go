@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookValidateURL(t *testing.T) {
+	webhook := NewWebhookService()
+
+	for _, tc := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"http://8.8.8.8:8080/hook", true},
+		{"ftp://93.184.216.34/hook", false},
+		{"93.184.216.34/hook", false},
+		{"http://localhost:8080/hook", false},
+		{"http://api.localhost/hook", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://172.16.3.4/hook", false},
+		{"http://192.168.0.10/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+	} {
+		err := webhook.ValidateURL(context.Background(), tc.url)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: esperado permitido, obtido %v", tc.url, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrWebhookURLNotAllowed) {
+			t.Errorf("%s: esperado ErrWebhookURLNotAllowed, obtido %v", tc.url, err)
+		}
+	}
+
+	// Liberando a rede interna, só o esquema é conferido
+	webhook.SetAllowPrivateNetworks(true)
+	if err := webhook.ValidateURL(context.Background(), "http://10.0.0.5/hook"); err != nil {
+		t.Errorf("rede interna liberada: %v", err)
+	}
+	if err := webhook.ValidateURL(context.Background(), "ftp://10.0.0.5/hook"); !errors.Is(err, ErrWebhookURLNotAllowed) {
+		t.Errorf("esquema inválido aceito: %v", err)
+	}
+}
+
+func TestWebhookRefusesInternalAddressOnConnect(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// Mesmo sem passar por ValidateURL (ex.: URL salva antes ou DNS alterado depois),
+	// a conexão a um endereço interno é recusada
+	err := NewWebhookService().SendSyncCompleted(context.Background(), server.URL, SyncSummary{Event: SyncEventCompleted})
+	if !errors.Is(err, ErrWebhookURLNotAllowed) || called {
+		t.Errorf("esperado ErrWebhookURLNotAllowed sem chamar o servidor, obtido %v (chamado: %v)", err, called)
+	}
+
+	allowed := NewWebhookService()
+	allowed.SetAllowPrivateNetworks(true)
+	if err := allowed.SendSyncCompleted(context.Background(), server.URL, SyncSummary{Event: SyncEventCompleted}); err != nil || !called {
+		t.Errorf("rede interna liberada: %v (chamado: %v)", err, called)
+	}
+}
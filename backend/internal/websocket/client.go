@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
@@ -14,7 +15,7 @@ func (h *Hub) ServeWS(c *gin.Context) {
 	// Get user information from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
+		middleware.ErrorJSON(c, http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Usuário não autenticado",
 			"code":    "USER_NOT_AUTHENTICATED",
@@ -99,6 +100,10 @@ func (c *Client) writePump() {
 		c.conn.Close()
 	}()
 
+	// Catch up on the jobs that progressed while the client was away; read here so the
+	// query doesn't hold up the connection handler
+	c.sendActiveJobSnapshots()
+
 	for {
 		select {
 		case message, ok := <-c.Send:
@@ -155,12 +160,33 @@ func (c *Client) handleMessage(data []byte) {
 		}
 		c.SendMessage(pong)
 
+	case "cancel_report":
+		// Data may carry {"report_id": "..."}; without it every report of the user is canceled
+		if c.Hub.reports == nil {
+			return
+		}
+		reportID := ""
+		if data, ok := msg.Data.(map[string]interface{}); ok {
+			reportID, _ = data["report_id"].(string)
+		}
+		canceled := c.Hub.reports.CancelReports(c.UserID, reportID)
+		c.Hub.logger.Info().
+			Str("user_id", c.UserID).
+			Str("report_id", reportID).
+			Int("canceled", canceled).
+			Msg("Report cancellation requested over WebSocket")
+
 	case "subscribe":
-		// Handle subscription requests (for future use)
+		// Data may carry {"job_id": 123}; the client gets the job's stored progress at once
 		c.Hub.logger.Debug().
 			Str("user_id", c.UserID).
 			Str("message_type", msg.Type).
 			Msg("Client subscription request received")
+		if data, ok := msg.Data.(map[string]interface{}); ok {
+			if jobID, ok := data["job_id"].(float64); ok && jobID > 0 {
+				c.sendJobSnapshot(int(jobID))
+			}
+		}
 
 	default:
 		c.Hub.logger.Debug().
@@ -181,16 +207,40 @@ func (c *Client) SendMessage(message interface{}) {
 		return
 	}
 
+	if !c.trySend(data) {
+		c.Hub.logger.Warn().
+			Str("user_id", c.UserID).
+			Msg("Client send channel is full or closed, closing connection")
+		c.closeSend()
+	}
+}
+
+// trySend queues data without blocking; false if the buffer is full or Send was closed
+func (c *Client) trySend(data []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return false
+	}
 	select {
 	case c.Send <- data:
+		return true
 	default:
-		c.Hub.logger.Warn().
-			Str("user_id", c.UserID).
-			Msg("Client send channel is full, closing connection")
-		close(c.Send)
+		return false
 	}
 }
 
+// closeSend closes Send once, whichever of the hub or the client gets there first;
+// writePump then closes the connection
+func (c *Client) closeSend() {
+	c.closeOnce.Do(func() {
+		c.sendMu.Lock()
+		c.sendClosed = true
+		close(c.Send)
+		c.sendMu.Unlock()
+	})
+}
+
 // IsConnected returns true if the client connection is still active
 func (c *Client) IsConnected() bool {
 	return c.conn != nil
@@ -31,6 +31,23 @@ type Hub struct {
 
 	// Logger
 	logger *zerolog.Logger
+
+	// Cancels web reports on "cancel_report" messages (optional)
+	reports ReportCanceler
+
+	// Coalesces job progress updates (see SetProgressInterval)
+	progress *progressThrottle
+
+	// Receive job progress updates outside WebSocket connections (see SubscribeProgress)
+	subscribers progressSubscribers
+
+	// Stored job progress sent on connect and subscribe (see SetJobSnapshots)
+	snapshots JobSnapshotSource
+}
+
+// ReportCanceler cancels a user's running reports (implemented by service.ReportRegistry)
+type ReportCanceler interface {
+	CancelReports(userID, reportID string) int
 }
 
 // Client is a middleman between the websocket connection and the hub
@@ -38,8 +55,12 @@ type Client struct {
 	// The websocket connection
 	conn *websocket.Conn
 
-	// Buffered channel of outbound messages
+	// Buffered channel of outbound messages; written with trySend and closed with closeSend
 	Send chan []byte
+	// sendMu orders sends after closeSend, so a late message is dropped instead of panicking
+	sendMu     sync.Mutex
+	sendClosed bool
+	closeOnce  sync.Once
 
 	// User identification
 	UserID   string
@@ -65,6 +86,52 @@ type ProgressUpdate struct {
 	Message       string    `json:"message,omitempty"`
 	Timestamp     time.Time `json:"timestamp"`
 	Progress      float64   `json:"progress,omitempty"` // 0-100 percentage
+	// EstimatedSecondsRemaining is omitted until enough rows were processed to measure a rate
+	EstimatedSecondsRemaining int `json:"estimated_seconds_remaining,omitempty"`
+	// Snapshot marks the stored state sent on connect or subscribe, not a new event
+	Snapshot bool `json:"snapshot,omitempty"`
+}
+
+// UploadProgress reports how much of an upload request body has been received
+type UploadProgress struct {
+	Type       string    `json:"type"`
+	BytesRead  int64     `json:"bytes_read"`
+	TotalBytes int64     `json:"total_bytes,omitempty"` // 0 when the client sent no Content-Length
+	Progress   float64   `json:"progress,omitempty"`    // 0-100 percentage
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ReportProgress reports how far the task collection of a report has gone
+type ReportProgress struct {
+	Type           string    `json:"type"`
+	ReportID       string    `json:"report_id,omitempty"` // sent back in "cancel_report" to stop it
+	ListsProcessed int       `json:"lists_processed"`
+	TotalLists     int       `json:"total_lists"`
+	TasksCollected int       `json:"tasks_collected"`
+	Progress       float64   `json:"progress,omitempty"` // 0-100 percentage of lists
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Announcement levels
+const (
+	AnnouncementInfo        = "info"
+	AnnouncementWarning     = "warning"
+	AnnouncementMaintenance = "maintenance"
+)
+
+// Announcement is a server-wide notice (e.g. planned maintenance) sent to every
+// connected client, regardless of user
+type Announcement struct {
+	Type      string    `json:"type"`
+	Level     string    `json:"level"` // info, warning or maintenance
+	Title     string    `json:"title,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ValidAnnouncementLevel reports whether level is a known announcement level
+func ValidAnnouncementLevel(level string) bool {
+	return level == AnnouncementInfo || level == AnnouncementWarning || level == AnnouncementMaintenance
 }
 
 // Message represents a generic WebSocket message
@@ -100,13 +167,27 @@ var upgrader = websocket.Upgrader{
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:    make(map[string]map[*Client]bool),
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		logger:     logger.Global(),
 	}
+	h.progress = newProgressThrottle(DefaultProgressInterval, h.sendProgressNow)
+	return h
+}
+
+// SetProgressInterval sets the shortest time between two "processing" updates of a
+// job; updates in between are coalesced into the latest one. Other statuses are always
+// sent at once. <= 0 sends every update.
+func (h *Hub) SetProgressInterval(interval time.Duration) {
+	h.progress.setInterval(interval)
+}
+
+// SetReportCanceler enables the "cancel_report" client message
+func (h *Hub) SetReportCanceler(canceler ReportCanceler) {
+	h.reports = canceler
 }
 
 // Run starts the hub's main loop
@@ -161,7 +242,7 @@ func (h *Hub) unregisterClient(client *Client) {
 	if clients, ok := h.clients[client.UserID]; ok {
 		if _, ok := clients[client]; ok {
 			delete(clients, client)
-			close(client.Send)
+			client.closeSend()
 
 			// Track metrics
 			metrics.Get().DecrementWSConnection()
@@ -180,33 +261,61 @@ func (h *Hub) unregisterClient(client *Client) {
 	}
 }
 
-// broadcastMessage broadcasts a message to all connected clients
-func (h *Hub) broadcastMessage(message []byte) {
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
+// broadcastMessage broadcasts a message to all connected clients and returns how
+// many received it. Sends never block: a client whose buffer is full is closed.
+func (h *Hub) broadcastMessage(message []byte) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 
+	delivered := 0
 	for userID, clients := range h.clients {
 		for client := range clients {
-			select {
-			case client.Send <- message:
-			default:
+			if client.trySend(message) {
+				metrics.Get().IncrementWSMessageOut()
+				delivered++
+			} else {
 				h.logger.Warn().
 					Str("user_id", userID).
 					Msg("Failed to send message to client, closing connection")
-				close(client.Send)
+				client.closeSend()
 				delete(clients, client)
+				metrics.Get().DecrementWSConnection()
 				if len(clients) == 0 {
 					delete(h.clients, userID)
 				}
 			}
 		}
 	}
+	return delivered
+}
+
+// Broadcast sends an announcement to every connected client and returns how many
+// connections received it
+func (h *Hub) Broadcast(message Announcement) int {
+	message.Type = "announcement"
+	if message.Level == "" {
+		message.Level = AnnouncementInfo
+	}
+	message.Timestamp = time.Now()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to marshal announcement")
+		return 0
+	}
+
+	delivered := h.broadcastMessage(data)
+	h.logger.Info().
+		Str("level", message.Level).
+		Int("delivered", delivered).
+		Msg("Announcement broadcast")
+	return delivered
 }
 
 // SendToUser sends a message to all connections of a specific user
 func (h *Hub) SendToUser(userID string, message interface{}) {
 	h.mutex.RLock()
-	clients, exists := h.clients[userID]
+	_, exists := h.clients[userID]
 	h.mutex.RUnlock()
 
 	if !exists {
@@ -225,19 +334,24 @@ func (h *Hub) SendToUser(userID string, message interface{}) {
 		return
 	}
 
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
+	// Clients that can't keep up are removed, so the write lock is needed (as in
+	// broadcastMessage)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 
+	clients, exists := h.clients[userID]
+	if !exists {
+		return
+	}
 	for client := range clients {
-		select {
-		case client.Send <- data:
+		if client.trySend(data) {
 			// Track outgoing message
 			metrics.Get().IncrementWSMessageOut()
-		default:
+		} else {
 			h.logger.Warn().
 				Str("user_id", userID).
 				Msg("Failed to send message to user client, closing connection")
-			close(client.Send)
+			client.closeSend()
 			delete(clients, client)
 			metrics.Get().DecrementWSConnection()
 		}
@@ -249,8 +363,14 @@ func (h *Hub) SendToUser(userID string, message interface{}) {
 	}
 }
 
-// SendProgress sends a progress update to a specific user
+// SendProgress sends a progress update to a specific user. "processing" updates of a
+// job are throttled (see SetProgressInterval).
 func (h *Hub) SendProgress(userID string, progress ProgressUpdate) {
+	h.progress.submit(userID, progress)
+}
+
+// sendProgressNow sends a progress update without throttling
+func (h *Hub) sendProgressNow(userID string, progress ProgressUpdate) {
 	progress.Type = "progress"
 	progress.Timestamp = time.Now()
 	
@@ -259,6 +379,41 @@ func (h *Hub) SendProgress(userID string, progress ProgressUpdate) {
 		progress.Progress = float64(progress.ProcessedRows) / float64(progress.TotalRows) * 100
 	}
 
+	if progress.JobID != 0 {
+		h.subscribers.publish(userID, progress)
+	}
+	h.SendToUser(userID, progress)
+}
+
+// SendUploadProgress sends upload transfer progress to a specific user
+func (h *Hub) SendUploadProgress(userID string, bytesRead, totalBytes int64) {
+	progress := UploadProgress{
+		Type:      "upload_progress",
+		BytesRead: bytesRead,
+		Timestamp: time.Now(),
+	}
+	if totalBytes > 0 {
+		progress.TotalBytes = totalBytes
+		progress.Progress = float64(bytesRead) / float64(totalBytes) * 100
+	}
+
+	h.SendToUser(userID, progress)
+}
+
+// SendReportProgress sends report generation progress to a specific user
+func (h *Hub) SendReportProgress(userID, reportID string, listsProcessed, totalLists, tasksCollected int) {
+	progress := ReportProgress{
+		Type:           "report_progress",
+		ReportID:       reportID,
+		ListsProcessed: listsProcessed,
+		TotalLists:     totalLists,
+		TasksCollected: tasksCollected,
+		Timestamp:      time.Now(),
+	}
+	if totalLists > 0 {
+		progress.Progress = float64(listsProcessed) / float64(totalLists) * 100
+	}
+
 	h.SendToUser(userID, progress)
 }
 
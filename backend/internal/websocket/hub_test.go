@@ -399,4 +399,92 @@ func TestWebSocketConnectionManagement(t *testing.T) {
 	if len(connectedUsers) != 1 || connectedUsers[0] != "user2" {
 		t.Errorf("Connected users should only contain user2, got %v", connectedUsers)
 	}
-}
\ No newline at end of file
+}
+
+// Test upload progress messages sent to the uploading user
+func TestSendUploadProgress(t *testing.T) {
+	hub := NewHub()
+	client := &Client{
+		UserID: "uploader",
+		Send:   make(chan []byte, 4),
+		Hub:    hub,
+	}
+	hub.mutex.Lock()
+	hub.clients[client.UserID] = map[*Client]bool{client: true}
+	hub.mutex.Unlock()
+
+	hub.SendUploadProgress("uploader", 512, 2048)
+
+	select {
+	case msg := <-client.Send:
+		var progress UploadProgress
+		if err := json.Unmarshal(msg, &progress); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if progress.Type != "upload_progress" || progress.BytesRead != 512 || progress.TotalBytes != 2048 {
+			t.Errorf("unexpected message %+v", progress)
+		}
+		if progress.Progress != 25.0 {
+			t.Errorf("Expected Progress 25.0, got %f", progress.Progress)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("No message received")
+	}
+}
+
+func TestBroadcastAnnouncement(t *testing.T) {
+	hub := NewHub()
+
+	var clients []*Client
+	for _, userID := range []string{"user1", "user1", "user2", "user3"} {
+		client := &Client{UserID: userID, Send: make(chan []byte, 4), Hub: hub}
+		hub.mutex.Lock()
+		if hub.clients[userID] == nil {
+			hub.clients[userID] = make(map[*Client]bool)
+		}
+		hub.clients[userID][client] = true
+		hub.mutex.Unlock()
+		clients = append(clients, client)
+	}
+
+	// A client with a full buffer must not block the others
+	slow := &Client{UserID: "slow", Send: make(chan []byte, 1), Hub: hub}
+	slow.Send <- []byte("pending")
+	hub.mutex.Lock()
+	hub.clients["slow"] = map[*Client]bool{slow: true}
+	hub.mutex.Unlock()
+
+	done := make(chan int, 1)
+	go func() {
+		done <- hub.Broadcast(Announcement{Level: AnnouncementMaintenance, Message: "Manutenção às 22h"})
+	}()
+
+	var delivered int
+	select {
+	case delivered = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Broadcast blocked on a slow client")
+	}
+	if delivered != len(clients) {
+		t.Errorf("Expected %d deliveries, got %d", len(clients), delivered)
+	}
+
+	for i, client := range clients {
+		select {
+		case msg := <-client.Send:
+			var announcement Announcement
+			if err := json.Unmarshal(msg, &announcement); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if announcement.Type != "announcement" || announcement.Level != AnnouncementMaintenance || announcement.Message != "Manutenção às 22h" {
+				t.Errorf("client %d: unexpected message %+v", i, announcement)
+			}
+		default:
+			t.Errorf("client %d (%s) did not receive the announcement", i, client.UserID)
+		}
+	}
+
+	if hub.GetUserConnectionCount("slow") != 0 {
+		t.Error("the slow client should have been disconnected")
+	}
+}
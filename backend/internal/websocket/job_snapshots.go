@@ -0,0 +1,44 @@
+package websocket
+
+// JobSnapshotSource reads the stored progress of a user's jobs (implemented by service.QueueService)
+type JobSnapshotSource interface {
+	// JobProgressSnapshot returns the stored progress of a job; false if it doesn't
+	// exist or belongs to another user
+	JobProgressSnapshot(userID string, jobID int) (ProgressUpdate, bool)
+	// ActiveJobSnapshots returns the stored progress of the user's pending and running jobs
+	ActiveJobSnapshots(userID string) []ProgressUpdate
+}
+
+// SetJobSnapshots makes the hub send the stored progress of jobs when a client connects
+// (every active job of the user) and when it subscribes to a job, so a reconnecting UI
+// shows the current state instead of waiting for the next update
+func (h *Hub) SetJobSnapshots(source JobSnapshotSource) {
+	h.snapshots = source
+}
+
+// sendActiveJobSnapshots sends the stored progress of the client's active jobs
+func (c *Client) sendActiveJobSnapshots() {
+	if c.Hub.snapshots == nil {
+		return
+	}
+	for _, snapshot := range c.Hub.snapshots.ActiveJobSnapshots(c.UserID) {
+		c.sendSnapshot(snapshot)
+	}
+}
+
+// sendJobSnapshot sends the stored progress of one of the client's jobs
+func (c *Client) sendJobSnapshot(jobID int) {
+	if c.Hub.snapshots == nil {
+		return
+	}
+	if snapshot, ok := c.Hub.snapshots.JobProgressSnapshot(c.UserID, jobID); ok {
+		c.sendSnapshot(snapshot)
+	}
+}
+
+// sendSnapshot sends a stored state as a "progress" message marked as a snapshot
+func (c *Client) sendSnapshot(snapshot ProgressUpdate) {
+	snapshot.Type = "progress"
+	snapshot.Snapshot = true
+	c.SendMessage(snapshot)
+}
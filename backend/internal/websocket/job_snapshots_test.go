@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storedJobs serves snapshots from a fixed set of jobs, as saved by UpdateJobProgress
+type storedJobs map[int]struct {
+	userID   string
+	progress ProgressUpdate
+}
+
+func (s storedJobs) JobProgressSnapshot(userID string, jobID int) (ProgressUpdate, bool) {
+	job, ok := s[jobID]
+	if !ok || job.userID != userID {
+		return ProgressUpdate{}, false
+	}
+	return job.progress, true
+}
+
+func (s storedJobs) ActiveJobSnapshots(userID string) []ProgressUpdate {
+	var snapshots []ProgressUpdate
+	for _, job := range s {
+		if job.userID == userID && (job.progress.Status == "pending" || job.progress.Status == "processing") {
+			snapshots = append(snapshots, job.progress)
+		}
+	}
+	return snapshots
+}
+
+func receiveProgress(t *testing.T, client *Client) ProgressUpdate {
+	t.Helper()
+	select {
+	case data := <-client.Send:
+		var update ProgressUpdate
+		if err := json.Unmarshal(data, &update); err != nil {
+			t.Fatalf("invalid message %s: %v", data, err)
+		}
+		return update
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("no message sent")
+		return ProgressUpdate{}
+	}
+}
+
+func TestSubscribeSendsStoredProgress(t *testing.T) {
+	hub := NewHub()
+	hub.SetJobSnapshots(storedJobs{
+		7: {userID: "user-1", progress: ProgressUpdate{
+			JobID: 7, Status: "processing", ProcessedRows: 40, TotalRows: 160, SuccessCount: 38, ErrorCount: 2, Progress: 25,
+		}},
+		8: {userID: "user-2", progress: ProgressUpdate{JobID: 8, Status: "processing"}},
+	})
+	client := &Client{UserID: "user-1", Send: make(chan []byte, 10), Hub: hub}
+
+	// Subscribing mid-job gets the stored state at once, without waiting for the next update
+	client.handleMessage([]byte(`{"type": "subscribe", "data": {"job_id": 7}}`))
+	snapshot := receiveProgress(t, client)
+	if snapshot.Type != "progress" || !snapshot.Snapshot || snapshot.JobID != 7 || snapshot.Status != "processing" ||
+		snapshot.ProcessedRows != 40 || snapshot.TotalRows != 160 || snapshot.SuccessCount != 38 ||
+		snapshot.ErrorCount != 2 || snapshot.Progress != 25 {
+		t.Errorf("snapshot doesn't match the stored state: %+v", snapshot)
+	}
+
+	// Other users' jobs aren't disclosed
+	client.handleMessage([]byte(`{"type": "subscribe", "data": {"job_id": 8}}`))
+	select {
+	case data := <-client.Send:
+		t.Errorf("unexpected message for another user's job: %s", data)
+	default:
+	}
+}
+
+func TestReconnectSendsActiveJobSnapshots(t *testing.T) {
+	hub := NewHub()
+	hub.SetJobSnapshots(storedJobs{
+		1: {userID: "user-1", progress: ProgressUpdate{JobID: 1, Status: "processing", ProcessedRows: 5, TotalRows: 10}},
+		2: {userID: "user-1", progress: ProgressUpdate{JobID: 2, Status: "completed", ProcessedRows: 10, TotalRows: 10}},
+	})
+	client := &Client{UserID: "user-1", Send: make(chan []byte, 10), Hub: hub}
+
+	client.sendActiveJobSnapshots()
+	snapshot := receiveProgress(t, client)
+	if snapshot.JobID != 1 || !snapshot.Snapshot || snapshot.ProcessedRows != 5 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if len(client.Send) != 0 {
+		t.Error("only active jobs should be sent")
+	}
+
+	// Without a source nothing is sent
+	quiet := &Client{UserID: "user-1", Send: make(chan []byte, 10), Hub: NewHub()}
+	quiet.sendActiveJobSnapshots()
+	quiet.handleMessage([]byte(`{"type": "subscribe", "data": {"job_id": 1}}`))
+	if len(quiet.Send) != 0 {
+		t.Error("no snapshots expected without a source")
+	}
+}
+
+func TestClientSendAfterClose(t *testing.T) {
+	hub := NewHub()
+	client := &Client{UserID: "user-1", Send: make(chan []byte, 1), Hub: hub}
+
+	// A full buffer closes the client; the hub closing it again or sending later is harmless
+	client.SendMessage(Message{Type: "pong"})
+	client.SendMessage(Message{Type: "pong"})
+	client.closeSend()
+	if client.trySend([]byte(`{}`)) {
+		t.Error("expected sends to be dropped after close")
+	}
+
+	<-client.Send
+	if _, ok := <-client.Send; ok {
+		t.Error("expected Send to be closed")
+	}
+}
+
+// TestSendToUserConcurrentPrune sends to a user whose buffers are full from several
+// goroutines at once; run with -race to check that pruning clients is synchronized
+func TestSendToUserConcurrentPrune(t *testing.T) {
+	hub := NewHub()
+	clients := make(map[*Client]bool)
+	for i := 0; i < 4; i++ {
+		clients[&Client{UserID: "user-1", Send: make(chan []byte, 1), Hub: hub}] = true
+	}
+	hub.mutex.Lock()
+	hub.clients["user-1"] = clients
+	hub.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				hub.SendToUser("user-1", Message{Type: "pong"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	hub.mutex.RLock()
+	_, exists := hub.clients["user-1"]
+	hub.mutex.RUnlock()
+	if exists {
+		t.Error("expected the user's clients with full buffers to be removed")
+	}
+}
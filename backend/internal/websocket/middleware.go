@@ -9,19 +9,31 @@ import (
 )
 
 // AuthMiddleware creates a WebSocket authentication middleware
-// It checks for session authentication via cookie or query parameter
-func AuthMiddleware(authMiddleware *middleware.BasicAuthMiddleware) gin.HandlerFunc {
+// It checks for a connection ticket (?ticket=, when tickets is not nil), then for
+// session authentication via cookie or query parameter
+func AuthMiddleware(authMiddleware *middleware.BasicAuthMiddleware, tickets *TicketStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var sessionID string
 		var err error
 
-		// First try to get session from cookie
-		sessionID, err = c.Cookie("session_id")
-		if err != nil {
+		if ticket := c.Query("ticket"); ticket != "" && tickets != nil {
+			// Tickets are single use: consumed here even if the upgrade fails later
+			var ok bool
+			sessionID, ok = tickets.Consume(ticket)
+			if !ok {
+				middleware.AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "Ticket inválido ou expirado",
+					"code":    "TICKET_INVALID",
+				})
+				return
+			}
+		} else if sessionID, err = c.Cookie("session_id"); err != nil {
+			// Without a ticket, try the session cookie first
 			// If cookie is not available, try query parameter (for WebSocket connections)
 			sessionID = c.Query("session_id")
 			if sessionID == "" {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				middleware.AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 					"success": false,
 					"error":   "Sessão não encontrada",
 					"code":    "SESSION_NOT_FOUND",
@@ -33,7 +45,7 @@ func AuthMiddleware(authMiddleware *middleware.BasicAuthMiddleware) gin.HandlerF
 		// Validate session
 		session, valid := authMiddleware.GetSession(sessionID)
 		if !valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			middleware.AbortWithErrorJSON(c, http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Sessão inválida ou expirada",
 				"code":    "SESSION_INVALID",
@@ -0,0 +1,75 @@
+package websocket
+
+import "sync"
+
+// progressSubscriberBuffer is how many updates a subscriber may fall behind before the
+// oldest pending one is dropped
+const progressSubscriberBuffer = 16
+
+// progressSubscriber receives the progress updates of one job outside WebSocket
+// connections (e.g. a server-sent events stream)
+type progressSubscriber struct {
+	userID string
+	jobID  int
+	ch     chan ProgressUpdate
+}
+
+// progressSubscribers holds the subscribers of job progress updates
+type progressSubscribers struct {
+	mu   sync.Mutex
+	subs map[*progressSubscriber]struct{}
+}
+
+// SubscribeProgress returns the progress updates of a user's job, as sent to its
+// WebSocket connections (after throttling), and a function that ends the
+// subscription. A subscriber that falls behind loses its oldest pending updates,
+// never the latest one.
+func (h *Hub) SubscribeProgress(userID string, jobID int) (<-chan ProgressUpdate, func()) {
+	sub := &progressSubscriber{
+		userID: userID,
+		jobID:  jobID,
+		ch:     make(chan ProgressUpdate, progressSubscriberBuffer),
+	}
+
+	h.subscribers.mu.Lock()
+	if h.subscribers.subs == nil {
+		h.subscribers.subs = make(map[*progressSubscriber]struct{})
+	}
+	h.subscribers.subs[sub] = struct{}{}
+	h.subscribers.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			h.subscribers.mu.Lock()
+			delete(h.subscribers.subs, sub)
+			h.subscribers.mu.Unlock()
+		})
+	}
+}
+
+// publish delivers an update to the subscribers of its job without blocking
+func (s *progressSubscribers) publish(userID string, progress ProgressUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		if sub.userID != userID || sub.jobID != progress.JobID {
+			continue
+		}
+		select {
+		case sub.ch <- progress:
+			continue
+		default:
+		}
+		// Full: drop the oldest update to make room for the latest
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- progress:
+		default:
+		}
+	}
+}
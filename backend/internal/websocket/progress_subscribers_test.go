@@ -0,0 +1,34 @@
+package websocket
+
+import "testing"
+
+func TestProgressSubscribersKeepLatestUpdate(t *testing.T) {
+	hub := NewHub()
+	hub.SetProgressInterval(0)
+	updates, unsubscribe := hub.SubscribeProgress("ana", 7)
+
+	// A subscriber that doesn't read loses the oldest updates, not the final one
+	for processed := 1; processed <= progressSubscriberBuffer+5; processed++ {
+		hub.SendProgress("ana", ProgressUpdate{JobID: 7, Status: "processing", ProcessedRows: processed, TotalRows: 100})
+	}
+	hub.SendProgress("ana", ProgressUpdate{JobID: 7, Status: "completed", ProcessedRows: 100, TotalRows: 100})
+
+	var last ProgressUpdate
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	if last.Status != "completed" || last.Progress != 100 {
+		t.Errorf("last update = %+v, expected the completed one", last)
+	}
+
+	// Once unsubscribed nothing else is delivered
+	unsubscribe()
+	unsubscribe() // safe to call twice
+	hub.SendProgress("ana", ProgressUpdate{JobID: 7, Status: "failed"})
+	if len(updates) != 0 {
+		t.Errorf("update delivered after unsubscribe")
+	}
+	if len(hub.subscribers.subs) != 0 {
+		t.Errorf("%d subscribers left", len(hub.subscribers.subs))
+	}
+}
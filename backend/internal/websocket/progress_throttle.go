@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultProgressInterval is the shortest time between two "processing" updates of a job
+const DefaultProgressInterval = 250 * time.Millisecond
+
+// progressKey identifies the progress stream of a job for a user
+type progressKey struct {
+	userID string
+	jobID  int
+}
+
+// throttledProgress is the send state of a job's progress stream
+type throttledProgress struct {
+	lastSent time.Time
+	pending  *ProgressUpdate // latest update held back, sent when timer fires
+	timer    *time.Timer
+}
+
+// progressThrottle coalesces the "processing" updates of each job: the first one goes
+// out at once, later ones within the interval are held and only the latest is sent
+// when it ends. Any other status (completed, failed, cancelled...) is sent immediately
+// and drops the held update, so the final state is never overwritten by a stale one.
+type progressThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	jobs     map[progressKey]*throttledProgress
+	send     func(userID string, progress ProgressUpdate)
+	now      func() time.Time
+}
+
+func newProgressThrottle(interval time.Duration, send func(userID string, progress ProgressUpdate)) *progressThrottle {
+	return &progressThrottle{
+		interval: interval,
+		jobs:     make(map[progressKey]*throttledProgress),
+		send:     send,
+		now:      time.Now,
+	}
+}
+
+// setInterval changes the interval (<= 0 sends every update)
+func (t *progressThrottle) setInterval(interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interval = interval
+}
+
+// submit sends or holds an update. Sends happen under the lock so a held update
+// can't be delivered after the terminal update that superseded it.
+func (t *progressThrottle) submit(userID string, progress ProgressUpdate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := progressKey{userID: userID, jobID: progress.JobID}
+	if progress.JobID == 0 || t.interval <= 0 || progress.Status != "processing" {
+		t.drop(key)
+		t.send(userID, progress)
+		return
+	}
+
+	now := t.now()
+	state, exists := t.jobs[key]
+	if !exists {
+		t.prune(now)
+		state = &throttledProgress{}
+		t.jobs[key] = state
+	}
+	if state.timer == nil && now.Sub(state.lastSent) >= t.interval {
+		state.lastSent = now
+		t.send(userID, progress)
+		return
+	}
+
+	state.pending = &progress
+	if state.timer == nil {
+		state.timer = time.AfterFunc(state.lastSent.Add(t.interval).Sub(now), func() { t.flush(key) })
+	}
+}
+
+// flush sends the update held for a job when its interval ends
+func (t *progressThrottle) flush(key progressKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.jobs[key]
+	if !exists || state.pending == nil {
+		return
+	}
+	progress := *state.pending
+	state.pending = nil
+	state.timer = nil
+	state.lastSent = t.now()
+	t.send(key.userID, progress)
+}
+
+// drop forgets a job's stream, discarding any held update
+func (t *progressThrottle) drop(key progressKey) {
+	if state, exists := t.jobs[key]; exists {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+		delete(t.jobs, key)
+	}
+}
+
+// prune removes idle streams: without a held update and past their interval they
+// behave exactly like a new one (e.g. jobs that stopped without a terminal update)
+func (t *progressThrottle) prune(now time.Time) {
+	for key, state := range t.jobs {
+		if state.timer == nil && now.Sub(state.lastSent) >= t.interval {
+			delete(t.jobs, key)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// progressClient registers a client with room for every message of the test
+func progressClient(t *testing.T, hub *Hub, userID string) *Client {
+	client := &Client{
+		UserID:      userID,
+		Username:    userID,
+		Send:        make(chan []byte, 2000),
+		Hub:         hub,
+		ConnectedAt: time.Now(),
+		LastPing:    time.Now(),
+	}
+	hub.registerClient(client)
+	drainWelcomeMessage(client)
+	return client
+}
+
+// receivedProgress returns the progress messages delivered so far, waiting up to wait
+// for more
+func receivedProgress(t *testing.T, client *Client, wait time.Duration) []ProgressUpdate {
+	var updates []ProgressUpdate
+	for {
+		select {
+		case msg := <-client.Send:
+			var update ProgressUpdate
+			if err := json.Unmarshal(msg, &update); err != nil {
+				t.Fatalf("invalid message %s: %v", msg, err)
+			}
+			updates = append(updates, update)
+		case <-time.After(wait):
+			return updates
+		}
+	}
+}
+
+func TestProgressUpdatesAreThrottled(t *testing.T) {
+	hub := NewHub()
+	hub.SetProgressInterval(100 * time.Millisecond)
+	client := progressClient(t, hub, "ana")
+
+	// 1000 rows reported every 10 rows, as fast as possible
+	const totalRows = 10000
+	for processed := 10; processed < totalRows; processed += 10 {
+		hub.SendProgress("ana", ProgressUpdate{
+			JobID:         7,
+			Status:        "processing",
+			ProcessedRows: processed,
+			TotalRows:     totalRows,
+			SuccessCount:  processed,
+		})
+	}
+	updates := receivedProgress(t, client, 20*time.Millisecond)
+	if len(updates) != 1 || updates[0].ProcessedRows != 10 {
+		t.Fatalf("expected only the first update right away, got %d", len(updates))
+	}
+
+	// The latest counts arrive when the interval ends
+	updates = receivedProgress(t, client, 200*time.Millisecond)
+	if len(updates) != 1 || updates[0].ProcessedRows != totalRows-10 {
+		t.Fatalf("expected one coalesced update with the latest counts, got %+v", updates)
+	}
+
+	// A terminal update goes out at once, even while another update is held
+	hub.SetProgressInterval(time.Minute)
+	hub.SendProgress("ana", ProgressUpdate{JobID: 7, Status: "processing", ProcessedRows: totalRows - 5, TotalRows: totalRows})
+	hub.SendProgress("ana", ProgressUpdate{
+		JobID:         7,
+		Status:        "completed",
+		ProcessedRows: totalRows,
+		TotalRows:     totalRows,
+		SuccessCount:  totalRows - 3,
+		ErrorCount:    3,
+	})
+	updates = receivedProgress(t, client, 200*time.Millisecond)
+	if len(updates) != 1 {
+		t.Fatalf("expected only the terminal update (the held one is dropped), got %+v", updates)
+	}
+	final := updates[0]
+	if final.Status != "completed" || final.ProcessedRows != totalRows || final.SuccessCount != totalRows-3 ||
+		final.ErrorCount != 3 || final.Progress != 100 {
+		t.Errorf("inaccurate final state: %+v", final)
+	}
+}
+
+func TestProgressThrottleIsPerJob(t *testing.T) {
+	hub := NewHub()
+	hub.SetProgressInterval(time.Minute)
+	client := progressClient(t, hub, "bia")
+
+	hub.SendProgress("bia", ProgressUpdate{JobID: 1, Status: "processing", ProcessedRows: 10, TotalRows: 100})
+	hub.SendProgress("bia", ProgressUpdate{JobID: 2, Status: "processing", ProcessedRows: 20, TotalRows: 100})
+	hub.SendProgress("bia", ProgressUpdate{JobID: 1, Status: "processing", ProcessedRows: 30, TotalRows: 100})
+	// Updates without a job (e.g. queue notices) are never held
+	hub.SendProgress("bia", ProgressUpdate{Status: "processing", Message: "fila"})
+	hub.SendProgress("bia", ProgressUpdate{Status: "processing", Message: "fila"})
+
+	updates := receivedProgress(t, client, 20*time.Millisecond)
+	if len(updates) != 4 {
+		t.Fatalf("expected the first update of each job and both job-less updates, got %+v", updates)
+	}
+
+	// Disabled: every update is sent
+	hub.SetProgressInterval(0)
+	for i := 0; i < 5; i++ {
+		hub.SendProgress("bia", ProgressUpdate{JobID: 3, Status: "processing", ProcessedRows: i, TotalRows: 5})
+	}
+	if updates := receivedProgress(t, client, 20*time.Millisecond); len(updates) != 5 {
+		t.Errorf("expected 5 updates with throttling disabled, got %d", len(updates))
+	}
+}
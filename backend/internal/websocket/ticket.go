@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// DefaultTicketTTL is how long a connection ticket stays valid after being issued
+const DefaultTicketTTL = 30 * time.Second
+
+// TicketStore issues single-use, short-lived tickets bound to a session, so clients
+// that cannot send the session cookie (embeds, other subdomains) can open a
+// WebSocket with ?ticket= instead
+type TicketStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]connectionTicket
+	now     func() time.Time
+}
+
+type connectionTicket struct {
+	sessionID string
+	expiresAt time.Time
+}
+
+// NewTicketStore creates a ticket store; ttl <= 0 uses DefaultTicketTTL
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	if ttl <= 0 {
+		ttl = DefaultTicketTTL
+	}
+	return &TicketStore{
+		ttl:     ttl,
+		tickets: make(map[string]connectionTicket),
+		now:     time.Now,
+	}
+}
+
+// Issue creates a ticket for the session and returns it with its expiration
+func (s *TicketStore) Issue(sessionID string) (string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	expiresAt := s.now().Add(s.ttl)
+	s.tickets[ticket] = connectionTicket{sessionID: sessionID, expiresAt: expiresAt}
+	return ticket, expiresAt, nil
+}
+
+// Consume removes the ticket and returns the session it was issued for. It fails
+// for unknown, already used or expired tickets.
+func (s *TicketStore) Consume(ticket string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticket]
+	if !ok {
+		return "", false
+	}
+	delete(s.tickets, ticket)
+	if s.now().After(t.expiresAt) {
+		return "", false
+	}
+	return t.sessionID, true
+}
+
+// pruneLocked drops expired tickets that were never used
+func (s *TicketStore) pruneLocked() {
+	now := s.now()
+	for ticket, t := range s.tickets {
+		if now.After(t.expiresAt) {
+			delete(s.tickets, ticket)
+		}
+	}
+}
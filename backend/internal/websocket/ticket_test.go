@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cleberrangel/clickup-excel-api/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTicketStore(t *testing.T) {
+	store := NewTicketStore(time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	ticket, expiresAt, err := store.Issue("session-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expiresAt = %v", expiresAt)
+	}
+
+	// Valid ticket
+	if sessionID, ok := store.Consume(ticket); !ok || sessionID != "session-1" {
+		t.Fatalf("Consume = (%q, %v), expected session-1", sessionID, ok)
+	}
+
+	// Reused ticket
+	if _, ok := store.Consume(ticket); ok {
+		t.Error("a ticket must not be accepted twice")
+	}
+
+	// Expired ticket
+	expired, _, _ := store.Issue("session-1")
+	now = now.Add(time.Minute + time.Second)
+	if _, ok := store.Consume(expired); ok {
+		t.Error("an expired ticket must be rejected")
+	}
+
+	// Unknown ticket
+	if _, ok := store.Consume("unknown"); ok {
+		t.Error("an unknown ticket must be rejected")
+	}
+}
+
+func TestAuthMiddlewareTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auth := middleware.NewBasicAuthMiddleware(middleware.BasicAuthConfig{})
+	sessionID, err := auth.CreateSession("ana")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	store := NewTicketStore(time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	router := gin.New()
+	router.GET("/ws", AuthMiddleware(auth, store), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+	connect := func(ticket string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws?ticket="+ticket, nil))
+		return w
+	}
+
+	ticket, _, _ := store.Issue(sessionID)
+	if w := connect(ticket); w.Code != http.StatusOK || w.Body.String() != "ana" {
+		t.Fatalf("valid ticket: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w := connect(ticket); w.Code != http.StatusUnauthorized {
+		t.Errorf("reused ticket: status %d, expected 401", w.Code)
+	}
+
+	expired, _, _ := store.Issue(sessionID)
+	now = now.Add(2 * time.Minute)
+	if w := connect(expired); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired ticket: status %d, expected 401", w.Code)
+	}
+
+	// The ticket is only as good as the session it was issued for
+	loggedOut, _, _ := store.Issue(sessionID)
+	auth.DeleteSession(sessionID)
+	if w := connect(loggedOut); w.Code != http.StatusUnauthorized {
+		t.Errorf("ticket of a closed session: status %d, expected 401", w.Code)
+	}
+}
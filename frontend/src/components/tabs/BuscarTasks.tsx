@@ -18,8 +18,12 @@ interface SpaceData {
   id: string
   name: string
   folders: FolderData[]
+  lists?: ListData[] // lists directly under the space, without a folder
 }
 
+// Folderless lists are offered as a virtual folder so the picker keeps its three levels
+const FOLDERLESS_FOLDER_ID = '__folderless__'
+
 interface WorkspaceData {
   id: string
   name: string
@@ -117,7 +121,9 @@ export default function BuscarTasks() {
     if (!selectedSpace) return []
     const spaces = getFilteredSpaces()
     const space = spaces.find(s => s.id === selectedSpace)
-    return space?.folders || []
+    if (!space) return []
+    if (!space.lists || space.lists.length === 0) return space.folders
+    return [...space.folders, { id: FOLDERLESS_FOLDER_ID, name: '(Sem pasta)', lists: space.lists }]
   }, [selectedSpace, getFilteredSpaces])
 
   // Get filtered lists based on selected folder
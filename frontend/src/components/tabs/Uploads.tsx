@@ -190,13 +190,13 @@ export default function Uploads() {
 
   // File validation
   const validateFile = (file: File): string | null => {
-    const validTypes = ['text/csv', 'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet']
-    const validExtensions = ['.csv', '.xlsx']
+    const validTypes = ['text/csv', 'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet', 'application/vnd.oasis.opendocument.spreadsheet']
+    const validExtensions = ['.csv', '.xlsx', '.ods']
     const maxSize = 10 * 1024 * 1024 // 10MB
 
     const extension = file.name.toLowerCase().slice(file.name.lastIndexOf('.'))
     if (!validExtensions.includes(extension) && !validTypes.includes(file.type)) {
-      return 'Formato inválido. Apenas arquivos CSV e XLSX são aceitos.'
+      return 'Formato inválido. Apenas arquivos CSV, XLSX e ODS são aceitos.'
     }
     if (file.size > maxSize) {
       return 'Arquivo muito grande. O limite máximo é 10MB.'
@@ -512,7 +512,7 @@ export default function Uploads() {
     <div data-testid="uploads-tab">
       <h2 className="text-lg font-medium text-gray-900 mb-4">Uploads</h2>
       <p className="text-gray-600 mb-6">
-        Faça upload de arquivos CSV ou XLSX para atualizar campos personalizados.
+        Faça upload de arquivos CSV, XLSX ou ODS para atualizar campos personalizados.
       </p>
 
       {/* Error Message */}
@@ -543,7 +543,7 @@ export default function Uploads() {
           <input
             ref={fileInputRef}
             type="file"
-            accept=".csv,.xlsx"
+            accept=".csv,.xlsx,.ods"
             onChange={handleFileSelect}
             className="hidden"
             data-testid="file-input"
@@ -569,7 +569,7 @@ export default function Uploads() {
               <p className="mt-4 text-gray-600">
                 <span className="text-blue-600 font-medium">Clique para selecionar</span> ou arraste um arquivo
               </p>
-              <p className="mt-2 text-sm text-gray-500">CSV ou XLSX (máx. 10MB)</p>
+              <p className="mt-2 text-sm text-gray-500">CSV, XLSX ou ODS (máx. 10MB)</p>
             </>
           )}
         </div>